@@ -2,7 +2,6 @@ package app
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"reflect"
 
@@ -13,13 +12,17 @@ import (
 	"github.com/kastheco/kasmos/config/planparser"
 	"github.com/kastheco/kasmos/config/planstate"
 	"github.com/kastheco/kasmos/config/planstore"
-	"github.com/kastheco/kasmos/internal/clickup"
-	"github.com/kastheco/kasmos/internal/mcpclient"
+	"github.com/kastheco/kasmos/control"
+	"github.com/kastheco/kasmos/internal/mcpserver"
 	sentrypkg "github.com/kastheco/kasmos/internal/sentry"
+	"github.com/kastheco/kasmos/internal/tracker"
 	"github.com/kastheco/kasmos/log"
+	"github.com/kastheco/kasmos/notify"
+	"github.com/kastheco/kasmos/orchestration"
 	"github.com/kastheco/kasmos/session"
 	"github.com/kastheco/kasmos/session/git"
 	"github.com/kastheco/kasmos/session/tmux"
+	"github.com/kastheco/kasmos/tracing"
 	"github.com/kastheco/kasmos/ui"
 	"github.com/kastheco/kasmos/ui/overlay"
 	"os"
@@ -34,8 +37,6 @@ import (
 
 const GlobalInstanceLimit = 20
 
-const clickUpOpTimeout = 30 * time.Second
-
 // Run is the main entrypoint into the application.
 func Run(ctx context.Context, program string, autoYes bool) error {
 	// Set the terminal's default background to the theme base color so every
@@ -56,6 +57,9 @@ func Run(ctx context.Context, program string, autoYes bool) error {
 		tea.WithAltScreen(),
 		tea.WithMouseAllMotion(), // Full mouse tracking for hover + scroll + click
 	)
+	if srv := startControlServer(h, p); srv != nil {
+		defer srv.Close()
+	}
 	_, err := p.Run()
 	return err
 }
@@ -102,18 +106,29 @@ const (
 	stateChangeTopic
 	// stateSetStatus is the state when the user is force-overriding a plan's status via picker.
 	stateSetStatus
-	// stateClickUpSearch is the state when the user is typing a ClickUp search query.
-	stateClickUpSearch
-	// stateClickUpPicker is the state when the user is picking from ClickUp search results.
-	stateClickUpPicker
-	// stateClickUpFetching is when kasmos is fetching a full task from ClickUp.
-	stateClickUpFetching
+	// stateIssueSearch is the state when the user is typing an issue search query.
+	stateIssueSearch
+	// stateIssuePicker is the state when the user is picking from issue search results.
+	stateIssuePicker
+	// stateIssueFetching is when kasmos is searching trackers or fetching a full issue.
+	stateIssueFetching
 	// statePermission is when an opencode permission prompt is detected and the modal is shown.
 	statePermission
 	// stateTmuxBrowser is the state when the tmux session browser overlay is shown.
 	stateTmuxBrowser
 	// stateChatAboutPlan is the state when the user is typing a question about a plan.
 	stateChatAboutPlan
+	// stateMergeStrategy is the state when the user is picking how to merge a plan branch.
+	stateMergeStrategy
+	// statePRReviewFollowUp is the state when the user is picking what happens to
+	// PR review threads once an agent has addressed them.
+	statePRReviewFollowUp
+	// stateRollbackWave is the state when the user is picking the wave
+	// checkpoint to roll a plan back to.
+	stateRollbackWave
+	// stateAgentQuestion is the state when an agent's ask_user question is
+	// waiting for the user's answer.
+	stateAgentQuestion
 )
 
 type home struct {
@@ -165,8 +180,13 @@ type home struct {
 	// nav displays plans + instances
 	nav *ui.NavigationPanel
 	// auditPane displays recent audit events below the nav panel
-	auditPane         *ui.AuditPane
-	auditBootstrapped bool // true after first audit query on boot
+	auditPane *ui.AuditPane
+	// auditEvents caches the audit pane's events, newest first. They are
+	// reloaded every auditRefreshInterval by the metadata tick (picking up
+	// events from the daemon and other machines); audit adds this process's
+	// own events in between.
+	auditEvents      []auditlog.Event
+	lastAuditRefresh time.Time
 	// menu displays the bottom menu
 	menu *ui.Menu
 	// statusBar displays the top contextual status bar
@@ -196,12 +216,64 @@ type home struct {
 	pendingPlanName string
 	// pendingPlanDesc stores the plan description during the two-step plan creation flow
 	pendingPlanDesc string
+	// pendingPlanParent is the plan a plan being created is stacked on, if any
+	pendingPlanParent string
 	// pendingPRTitle stores the PR title during the two-step PR creation flow
 	pendingPRTitle string
 	// pendingChangeTopicPlan stores the plan filename during the change-topic flow
 	pendingChangeTopicPlan string
 	// pendingSetStatusPlan stores the plan filename during the set-status flow
 	pendingSetStatusPlan string
+	// pendingMergePlan stores the plan filename during the merge-strategy flow
+	pendingMergePlan string
+	// pendingRollbackPlan stores the plan filename during the wave rollback flow
+	pendingRollbackPlan string
+	// pendingPRReview holds the fetched review comments while the user picks a
+	// follow-up for them.
+	pendingPRReview *prReviewFetchedMsg
+	// prReviewFollowUps maps plan files to the review threads to answer once
+	// the coder addressing them signals implement-finished.
+	prReviewFollowUps map[string]prReviewFollowUp
+	// planDrift holds how far each active plan branch is ahead of/behind the
+	// default branch, refreshed every driftCheckInterval by the metadata tick.
+	planDrift      map[string]git.BranchDrift
+	lastDriftCheck time.Time
+	// stackTips remembers a parent plan's branch tip from just before it was
+	// merged, so plans stacked on it can be moved off the deleted branch.
+	stackTips map[string]string
+	// restacking marks stacked plans with a restack in flight;
+	// restackConflicts marks those whose automatic restack failed, which are
+	// left to the user until a rebase of theirs succeeds.
+	restacking       map[string]bool
+	restackConflicts map[string]bool
+	// planPRs holds the last polled pull request of each plan that has one,
+	// refreshed every prCheckInterval by pollPlanPRs; prPollInFlight is set
+	// while a check runs.
+	planPRs        map[string]git.PullRequest
+	lastPRCheck    time.Time
+	prPollInFlight bool
+	// forge is the code-hosting service of forgeRepo, resolved once per
+	// repository rather than on every poll; see repoForge.
+	forge     git.Forge
+	forgeRepo string
+	// planCollisions lists pairs of active plans whose branches change the
+	// same files, refreshed every collisionCheckInterval by
+	// pollPlanCollisions; warnedCollisions marks the conflicting pairs
+	// already toasted. collisionCache carries the last check's git results
+	// to the next one.
+	planCollisions         []planCollision
+	lastCollisionCheck     time.Time
+	collisionCheckInFlight bool
+	collisionCache         *collisionCache
+	warnedCollisions       map[string]bool
+	// archivedActivity caches the activity timelines archived from removed
+	// instances, per plan. archivedActivityPlan is the plan the info pane
+	// showed last; selecting a different plan reloads that plan's entry on
+	// the next metadata tick. archivedActivityGen counts archives made here,
+	// so a load that raced one is discarded and retried.
+	archivedActivity     map[string][]session.InstanceActivity
+	archivedActivityPlan string
+	archivedActivityGen  int
 	// pendingChatAboutPlan stores the plan filename during the chat-about-plan flow
 	pendingChatAboutPlan string
 	// pendingPRToastID stores the toast ID for the in-progress PR creation
@@ -215,12 +287,13 @@ type home struct {
 	tmuxBrowser *overlay.TmuxBrowserOverlay
 	// tmuxSessionCount is the latest count of kas_-prefixed tmux sessions.
 	tmuxSessionCount int
-	// clickUpConfig stores the detected ClickUp MCP server config (nil if not detected)
-	clickUpConfig *clickup.MCPServerConfig
-	// clickUpImporter handles search/fetch via MCP (nil until first use)
-	clickUpImporter *clickup.Importer
-	// clickUpResults stores the latest search results for the picker
-	clickUpResults []clickup.SearchResult
+	// issueSources are the trackers offered by the import overlay (nil until
+	// detected at startup).
+	issueSources []tracker.IssueSource
+	// issueSync writes status changes of imported plans back to their issues.
+	issueSync *tracker.Syncer
+	// issueResults stores the latest search results for the picker
+	issueResults []issueSearchResult
 
 	// Layout dimensions for mouse hit-testing
 	navWidth      int
@@ -267,7 +340,7 @@ type home struct {
 	cachedPlanRendered string
 
 	// waveOrchestrators tracks active wave orchestrations by plan filename.
-	waveOrchestrators map[string]*WaveOrchestrator
+	waveOrchestrators map[string]*orchestration.WaveOrchestrator
 
 	// pendingAllComplete holds plan files whose all-waves-complete prompt was
 	// deferred because an overlay was active when the orchestrator finished.
@@ -293,6 +366,13 @@ type home struct {
 	// NOT set on esc — allows re-prompt.
 	plannerPrompted map[string]bool
 
+	// agentQuestions queues agents' ask_user questions; the first is on
+	// screen while in stateAgentQuestion. Shown on the metadata tick once
+	// other overlays close.
+	agentQuestions []*agentQuestion
+	// waveNotes holds the notes wave task agents post to their peers.
+	waveNotes map[waveKey][]mcpserver.Note
+
 	// deferredPlannerDialogs holds plan files whose PlannerFinished dialog
 	// could not be shown because an overlay was active at signal-processing time.
 	// On each metadata tick, any queued plans are shown once the overlay clears.
@@ -329,6 +409,16 @@ type home struct {
 	// across multiple metadata ticks while opencode processes the first response.
 	// Cleared when the pane no longer contains a permission prompt for that instance.
 	permissionHandled map[*session.Instance]string
+	// permissionPrompts holds the prompt each instance is currently blocked on,
+	// so the control API can answer it without the modal.
+	permissionPrompts map[*session.Instance]*session.PermissionPrompt
+
+	// -- Control API --
+
+	// control serves the local control socket; nil when it failed to start.
+	control *control.Server
+	// controlStatuses is the last instance status published to control watchers.
+	controlStatuses map[string]string
 }
 
 func newHome(ctx context.Context, program string, autoYes bool) *home {
@@ -370,7 +460,7 @@ func newHome(ctx context.Context, program string, autoYes bool) *home {
 		signalsDir:            filepath.Join(activeRepoPath, ".kasmos", "signals"),
 		planStoreProject:      project,
 		instanceFinalizers:    make(map[*session.Instance]func()),
-		waveOrchestrators:     make(map[string]*WaveOrchestrator),
+		waveOrchestrators:     make(map[string]*orchestration.WaveOrchestrator),
 		plannerPrompted:       make(map[string]bool),
 		pendingReviewFeedback: make(map[string]string),
 	}
@@ -405,6 +495,12 @@ func newHome(ctx context.Context, program string, autoYes bool) *home {
 
 	h.planStore = planstore.NewHTTPStore(planStoreURL, project)
 	h.fsm = planfsm.New(h.planStore, project, h.planStateDir)
+	h.fsm.OnTransition(h.recordStatusChange)
+	h.issueSync = tracker.NewSyncer(appConfig.Issues.Sync, func(planFile string) (planstore.PlanEntry, error) {
+		return h.planStore.Get(project, planFile)
+	})
+	h.fsm.OnTransition(h.issueSync.Observe)
+	go h.issueSync.Run(ctx)
 
 	// One-time migration: import plan-state.json into the DB if it exists.
 	// Use the embedded store directly (bypasses HTTP round-trip).
@@ -422,14 +518,25 @@ func newHome(ctx context.Context, program string, autoYes bool) *home {
 		}
 	}
 
-	// Initialize audit logger. Always uses local SQLite regardless of plan
-	// store backend — audit events are purely local state.
+	// Initialize audit logger. Events always land in the local SQLite DB; with
+	// a remote plan store they are also shipped to the server so the whole
+	// team shares one history, and the local copy covers outages.
 	if al, err := auditlog.NewSQLiteLogger(dbPath); err != nil {
 		log.WarningLog.Printf("audit logger init failed: %v", err)
 		h.auditLogger = auditlog.NopLogger()
 	} else {
 		h.auditLogger = al
 	}
+	if appConfig.PlanStore != "" {
+		h.auditLogger = auditlog.NewHTTPLogger(appConfig.PlanStore, h.auditLogger)
+	}
+	// With [tracing] configured, plan lifecycles are also exported as traces.
+	h.auditLogger = tracing.Attach(appConfig, h.auditLogger)
+	var router *notify.Router
+	h.auditLogger, router = notify.Attach(appConfig, h.auditLogger)
+	if router != nil {
+		session.Notifier = router.Dispatch
+	}
 
 	h.nav = ui.NewNavigationPanel(&h.spinner)
 	h.toastManager = overlay.NewToastManager(&h.spinner)
@@ -548,10 +655,6 @@ func (m *home) updateHandleWindowSizeEvent(msg tea.WindowSizeMsg) {
 		// Pass full content height — the nav panel clamps to whatever space
 		// remains below the list content (active/plans sections + legend).
 		auditH := contentHeight
-		if !m.auditBootstrapped {
-			m.refreshAuditPane() // load historical events on first render
-			m.auditBootstrapped = true
-		}
 		m.auditPane.SetSize(auditInnerW, auditH)
 		m.nav.SetAuditView(m.auditPane.String(), auditH)
 	} else {
@@ -604,7 +707,7 @@ func (m *home) Init() tea.Cmd {
 		},
 		tickUpdateMetadataCmd,
 		m.toastTickCmd(),
-		detectClickUpCmd(m.activeRepoPath),
+		detectIssueSourcesCmd(m.appConfig, m.activeRepoPath),
 	)
 }
 
@@ -619,6 +722,9 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case prCreatedMsg:
 		m.toastManager.Resolve(m.pendingPRToastID, overlay.ToastSuccess, "PR created!")
 		m.pendingPRToastID = ""
+		m.recordPlanPR(msg.planFile, msg.pr)
+		m.updateSidebarPlans()
+		m.updateInfoPane()
 		m.audit(auditlog.EventPRCreated, fmt.Sprintf("PR created: %s", msg.prTitle),
 			auditlog.WithInstance(msg.instanceTitle),
 		)
@@ -677,36 +783,15 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case keyupMsg:
 		m.menu.ClearKeydown()
 		return m, nil
-	case clickUpDetectedMsg:
-		m.clickUpConfig = &msg.Config
-		m.nav.SetClickUpAvailable(true)
-		return m, nil
-	case clickUpSearchResultMsg:
-		if msg.Err != nil {
-			m.toastManager.Error("clickup search failed: " + msg.Err.Error())
-			m.state = stateDefault
-			return m, m.toastTickCmd()
-		}
-		if len(msg.Results) == 0 {
-			m.toastManager.Info("no clickup tasks found")
-			m.state = stateDefault
-			return m, m.toastTickCmd()
-		}
-		m.clickUpResults = msg.Results
-		items := make([]string, len(msg.Results))
-		for i, r := range msg.Results {
-			label := r.ID + " · " + r.Name
-			if r.Status != "" {
-				label += " (" + r.Status + ")"
-			}
-			if r.ListName != "" {
-				label += " — " + r.ListName
-			}
-			items[i] = label
+	case issueSourcesDetectedMsg:
+		m.issueSources = msg.sources
+		m.nav.SetImportSources(issueSourceNames(msg.sources))
+		if m.issueSync != nil {
+			m.issueSync.SetSources(msg.sources)
 		}
-		m.state = stateClickUpPicker
-		m.pickerOverlay = overlay.NewPickerOverlay("select clickup task", items)
 		return m, nil
+	case issueSearchMsg:
+		return m.showIssueResults(msg)
 	case tickUpdateMetadataMessage:
 		// Snapshot the instance list for the goroutine. The slice header is
 		// copied but the pointers are shared — CollectMetadata only reads
@@ -719,14 +804,35 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		signalsDir := m.signalsDir     // snapshot for goroutine
 		store := m.planStore           // snapshot for goroutine
 		project := m.planStoreProject  // snapshot for goroutine
+		repoPath := m.activeRepoPath   // snapshot for goroutine
+		checkDrift := time.Since(m.lastDriftCheck) >= driftCheckInterval
+		if checkDrift {
+			m.lastDriftCheck = time.Now()
+		}
+		// Audit log queries can be network round trips (remote plan store),
+		// so they run here rather than in Update.
+		logger := m.auditLogger // snapshot for goroutine
+		refreshAudit := logger != nil && m.auditPane != nil &&
+			time.Since(m.lastAuditRefresh) >= auditRefreshInterval
+		if refreshAudit {
+			m.lastAuditRefresh = time.Now()
+		}
+		archivedPlan := ""
+		if _, cached := m.archivedActivity[m.archivedActivityPlan]; logger != nil && !cached {
+			archivedPlan = m.archivedActivityPlan
+		}
+		archivedGen := m.archivedActivityGen
 
 		return m, func() tea.Msg {
 			results := make([]instanceMetadata, 0, len(snapshots))
+			// One ps snapshot per tick, shared by every instance. On error
+			// procs is nil and resource usage is skipped this tick.
+			procs, _ := session.ReadProcessTable()
 			for _, inst := range snapshots {
 				if !inst.Started() || inst.Paused() {
 					continue
 				}
-				md := inst.CollectMetadata()
+				md := inst.CollectMetadata(procs)
 				results = append(results, instanceMetadata{
 					Title:              inst.Title,
 					Content:            md.Content,
@@ -734,8 +840,7 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					Updated:            md.Updated,
 					HasPrompt:          md.HasPrompt,
 					DiffStats:          md.DiffStats,
-					CPUPercent:         md.CPUPercent,
-					MemMB:              md.MemMB,
+					Resources:          md.Resources,
 					ResourceUsageValid: md.ResourceUsageValid,
 					TmuxAlive:          md.TmuxAlive,
 					PermissionPrompt:   md.PermissionPrompt,
@@ -789,9 +894,30 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				waveSignals = planfsm.ScanWaveSignals(signalsDir)
 			}
 
+			// Branch drift — alongside the per-instance diff stats above, but
+			// per plan and throttled: it walks history for every active plan.
+			var drift map[string]git.BranchDrift
+			if checkDrift && ps != nil {
+				drift = collectPlanDrift(repoPath, ps)
+			}
+
+			var audit *auditQueryResult
+			if refreshAudit {
+				audit = &auditQueryResult{Since: time.Now()}
+				audit.Events, audit.Err = queryAuditEvents(logger, project)
+			}
+			var archived *archivedActivityResult
+			if archivedPlan != "" {
+				archived = &archivedActivityResult{
+					PlanFile: archivedPlan,
+					Gen:      archivedGen,
+					Activity: queryArchivedActivity(logger, project, archivedPlan),
+				}
+			}
+
 			tmuxCount := tmux.CountKasSessions(cmd2.MakeExecutor())
 			time.Sleep(200 * time.Millisecond)
-			return metadataResultMsg{Results: results, PlanState: ps, Signals: signals, WaveSignals: waveSignals, TmuxSessionCount: tmuxCount}
+			return metadataResultMsg{Results: results, PlanState: ps, Signals: signals, WaveSignals: waveSignals, TmuxSessionCount: tmuxCount, PlanDrift: drift, Audit: audit, ArchivedActivity: archived}
 		}
 	case metadataResultMsg:
 		// Process agent sentinel signals — feed to FSM and consume sentinel files.
@@ -799,101 +925,13 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Side-effect cmds (reviewer/coder spawns) are collected and batched below.
 		var signalCmds []tea.Cmd
 		for _, sig := range msg.Signals {
-			// Guard: if a wave orchestrator is active for this plan, ignore
-			// implement-finished signals. Wave task agents may write this sentinel
-			// after completing their individual task, but the wave orchestrator
-			// owns the implementing→reviewing transition. Without this guard,
-			// the first task to finish prematurely triggers review and pauses
-			// sibling tasks, spawning a "applying fixes" coder alongside the
-			// reviewer.
-			if sig.Event == planfsm.ImplementFinished {
-				if _, hasOrch := m.waveOrchestrators[sig.PlanFile]; hasOrch {
-					log.WarningLog.Printf("ignoring implement-finished signal for %q — wave orchestrator active", sig.PlanFile)
-					planfsm.ConsumeSignal(sig)
-					continue
-				}
-			}
-
-			if err := m.fsm.Transition(sig.PlanFile, sig.Event); err != nil {
+			cmds, err := m.applySignal(sig)
+			planfsm.ConsumeSignal(sig)
+			if err != nil {
 				log.WarningLog.Printf("signal %s for %s rejected: %v", sig.Event, sig.PlanFile, err)
-				planfsm.ConsumeSignal(sig)
 				continue
 			}
-			planfsm.ConsumeSignal(sig)
-
-			// Side effects: spawn agents in response to successful transitions.
-			switch sig.Event {
-			case planfsm.ImplementFinished:
-				// Pause the coder that wrote this signal.
-				for _, inst := range m.nav.GetInstances() {
-					if inst.PlanFile == sig.PlanFile && inst.AgentType == session.AgentTypeCoder {
-						inst.ImplementationComplete = true
-						_ = inst.Pause()
-						break
-					}
-				}
-				if cmd := m.spawnReviewer(sig.PlanFile); cmd != nil {
-					signalCmds = append(signalCmds, cmd)
-				}
-			case planfsm.ReviewApproved:
-				planName := planstate.DisplayName(sig.PlanFile)
-				m.audit(auditlog.EventPlanTransition, "reviewing → done (review approved)",
-					auditlog.WithPlan(sig.PlanFile))
-				m.toastManager.Success(fmt.Sprintf("review approved: %s", planName))
-				// Kill the reviewer instance — it's done.
-				for _, inst := range m.nav.GetInstances() {
-					if inst.PlanFile == sig.PlanFile && inst.IsReviewer {
-						_ = inst.Kill()
-						break
-					}
-				}
-			case planfsm.ReviewChangesRequested:
-				feedback := sig.Body
-				m.pendingReviewFeedback[sig.PlanFile] = feedback
-				// Pause the reviewer that wrote this signal.
-				for _, inst := range m.nav.GetInstances() {
-					if inst.PlanFile == sig.PlanFile && inst.IsReviewer {
-						_ = inst.Pause()
-						break
-					}
-				}
-				if cmd := m.spawnCoderWithFeedback(sig.PlanFile, feedback); cmd != nil {
-					signalCmds = append(signalCmds, cmd)
-				}
-			case planfsm.PlannerFinished:
-				capturedPlanFile := sig.PlanFile
-				// Ingest the plan content from the agent's worktree into the DB.
-				// Planners run on the main branch, so the plan file is in activeRepoPath.
-				m.ingestPlanContent(capturedPlanFile, m.activeRepoPath)
-				if m.plannerPrompted[capturedPlanFile] {
-					break
-				}
-				if m.isUserInOverlay() {
-					// Overlay is active — defer the dialog to the next tick
-					// instead of silently dropping it. The sentinel has already
-					// been consumed and the FSM transitioned; we must not lose
-					// the "show dialog" side effect.
-					m.deferredPlannerDialogs = append(m.deferredPlannerDialogs, capturedPlanFile)
-					break
-				}
-				// Focus the planner instance so the user sees its output behind the overlay.
-				for _, inst := range m.nav.GetInstances() {
-					if inst.PlanFile == sig.PlanFile && inst.AgentType == session.AgentTypePlanner {
-						if cmd := m.focusInstanceForOverlay(inst); cmd != nil {
-							signalCmds = append(signalCmds, cmd)
-						}
-						m.pendingPlannerInstanceTitle = inst.Title
-						break
-					}
-				}
-				m.pendingPlannerPlanFile = capturedPlanFile
-				m.confirmAction(
-					fmt.Sprintf("plan '%s' is ready. start implementation?", planstate.DisplayName(capturedPlanFile)),
-					func() tea.Msg {
-						return plannerCompleteMsg{planFile: capturedPlanFile}
-					},
-				)
-			}
+			signalCmds = append(signalCmds, cmds...)
 		}
 		if len(msg.Signals) > 0 {
 			m.loadPlanState() // refresh after signal processing
@@ -924,6 +962,11 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		// Show agents' ask_user questions that were queued behind another overlay.
+		if cmd := m.showAgentQuestion(); cmd != nil {
+			signalCmds = append(signalCmds, cmd)
+		}
+
 		// Process wave signals — trigger implementation for specific waves.
 		for _, ws := range msg.WaveSignals {
 			planfsm.ConsumeWaveSignal(ws)
@@ -946,27 +989,19 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				continue
 			}
 
-			if ws.WaveNumber > len(plan.Waves) {
-				m.toastManager.Error(fmt.Sprintf("plan has %d waves, requested wave %d", len(plan.Waves), ws.WaveNumber))
-				continue
-			}
-
 			entry, ok := m.planState.Entry(ws.PlanFile)
 			if !ok {
 				log.WarningLog.Printf("wave signal: plan %s not found in plan state", ws.PlanFile)
 				continue
 			}
 
-			orch := NewWaveOrchestrator(ws.PlanFile, plan)
-			m.waveOrchestrators[ws.PlanFile] = orch
-
 			// Fast-forward to the requested wave
-			for i := 1; i < ws.WaveNumber; i++ {
-				tasks := orch.StartNextWave()
-				for _, t := range tasks {
-					orch.MarkTaskComplete(t.Number)
-				}
+			orch := orchestration.NewWaveOrchestrator(ws.PlanFile, plan)
+			if !orch.Rewind(ws.WaveNumber) {
+				m.toastManager.Error(fmt.Sprintf("plan has %d waves, requested wave %d", len(plan.Waves), ws.WaveNumber))
+				continue
 			}
+			m.waveOrchestrators[ws.PlanFile] = orch
 
 			mdl, cmd := m.startNextWave(orch, entry)
 			m = mdl.(*home)
@@ -998,7 +1033,11 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					inst.SetStatus(session.Running)
 					inst.PromptDetected = false
 					if md.Content != "" {
-						inst.LastActivity = session.ParseActivity(md.Content, inst.Program)
+						if a := session.ParseActivity(md.Content, inst.Program); a != nil {
+							inst.RecordActivity(a)
+						} else {
+							inst.LastActivity = nil
+						}
 					}
 				} else {
 					if md.HasPrompt {
@@ -1018,6 +1057,8 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				}
 			}
 
+			m.trackPermissionPrompt(inst, md.PermissionPrompt)
+
 			// Permission prompt detection for opencode.
 			if md.PermissionPrompt != nil && m.state == stateDefault {
 				pp := md.PermissionPrompt
@@ -1078,8 +1119,10 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				inst.SetDiffStats(md.DiffStats)
 			}
 			if md.ResourceUsageValid {
-				inst.CPUPercent = md.CPUPercent
-				inst.MemMB = md.MemMB
+				inst.RecordResourceUsage(md.Resources)
+				if cmd := m.enforceResourceLimits(inst); cmd != nil {
+					asyncCmds = append(asyncCmds, cmd)
+				}
 			}
 		}
 
@@ -1097,6 +1140,36 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.planState = msg.PlanState
 		}
 
+		if msg.PlanDrift != nil {
+			m.planDrift = msg.PlanDrift
+		}
+
+		if msg.Audit != nil && msg.Audit.Err == nil {
+			m.setAuditEvents(msg.Audit.Events, msg.Audit.Since)
+		}
+		if a := msg.ArchivedActivity; a != nil && a.Gen == m.archivedActivityGen {
+			m.setArchivedActivity(a.PlanFile, a.Activity)
+		}
+
+		// Pull request status runs in its own command: every check is a
+		// forge round trip per tracked plan.
+		if cmd := m.pollPlanPRs(); cmd != nil {
+			asyncCmds = append(asyncCmds, cmd)
+		}
+
+		// File collisions between active plans test-merge branch pairs, so
+		// they run in their own command too.
+		if cmd := m.pollPlanCollisions(); cmd != nil {
+			asyncCmds = append(asyncCmds, cmd)
+		}
+
+		// Stacked plans follow their parent once fresh drift is in.
+		if msg.PlanDrift != nil {
+			if cmd := m.restackStackedPlans(); cmd != nil {
+				asyncCmds = append(asyncCmds, cmd)
+			}
+		}
+
 		// Store the latest tmux session count for the bottom bar.
 		m.tmuxSessionCount = msg.TmuxSessionCount
 		m.menu.SetTmuxSessionCount(m.tmuxSessionCount)
@@ -1174,46 +1247,35 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			// Wave completion monitoring: check task completion and trigger wave transitions.
-			// We process both WaveStateRunning (check task statuses) and WaveStateWaveComplete
+			// We process both orchestration.WaveStateRunning (check task statuses) and orchestration.WaveStateWaveComplete
 			// (re-show confirm dialog after user cancelled, resetting the latch via ResetConfirm).
 			for planFile, orch := range m.waveOrchestrators {
 				orchState := orch.State()
-				if orchState != WaveStateRunning && orchState != WaveStateWaveComplete {
+				if orchState != orchestration.WaveStateRunning && orchState != orchestration.WaveStateWaveComplete {
 					continue
 				}
 
-				if orchState == WaveStateRunning {
+				if orchState == orchestration.WaveStateRunning {
 					// Check task status updates only while the wave is actively running.
-					planName := planstate.DisplayName(planFile)
+					// A missing instance (e.g. spawn crashed) fails its task.
 					for _, task := range orch.CurrentWaveTasks() {
-						taskTitle := fmt.Sprintf("%s-W%d-T%d", planName, orch.CurrentWaveNumber(), task.Number)
-						inst, exists := instanceMap[taskTitle]
-						if !exists {
-							// No matching instance — treat as failed (e.g. spawn crashed).
-							orch.MarkTaskFailed(task.Number)
-							continue
-						}
-						if inst.Paused() {
-							// Paused task instances are treated as failures.
-							orch.MarkTaskFailed(task.Number)
-							continue
+						inst := instanceMap[orchestration.TaskTitle(planFile, orch.CurrentWaveNumber(), task.Number)]
+						var alive bool
+						if inst != nil && !inst.Paused() {
+							var collected bool
+							if alive, collected = tmuxAliveMap[inst.Title]; !collected {
+								continue
+							}
 						}
-						alive, collected := tmuxAliveMap[inst.Title]
-						if !collected {
-							continue
-						}
-						if inst.PromptDetected && !inst.AwaitingWork {
-							orch.MarkTaskComplete(task.Number)
+						if orch.ReconcileTask(task.Number, inst, alive) {
 							inst.SetStatus(session.Ready)
-						} else if !alive {
-							orch.MarkTaskFailed(task.Number)
 						}
 					}
 					orchState = orch.State() // refresh after task updates
 				}
 
 				// All waves complete — pause the last wave's tasks, prompt for review.
-				if orchState == WaveStateAllComplete {
+				if orchState == orchestration.WaveStateAllComplete {
 					capturedPlanFile := planFile
 					planName := planstate.DisplayName(planFile)
 
@@ -1246,7 +1308,7 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					continue
 				}
 
-				// orchState must be WaveStateWaveComplete here.
+				// orchState must be orchestration.WaveStateWaveComplete here.
 				// Show wave decision confirm once per wave (NeedsConfirm is one-shot;
 				// ResetConfirm on cancel allows the prompt to reappear next tick).
 				if !m.isUserInOverlay() && time.Since(m.waveConfirmDismissedAt) > 30*time.Second && orch.NeedsConfirm() {
@@ -1268,7 +1330,8 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						m.audit(auditlog.EventWaveFailed,
 							fmt.Sprintf("wave %d: %d/%d tasks failed", waveNum, failed, total),
 							auditlog.WithPlan(capturedPlanFile),
-							auditlog.WithWave(waveNum, 0))
+							auditlog.WithWave(waveNum, 0),
+							auditlog.WithFailedTasks(orch.FailedTasks()))
 						message := fmt.Sprintf(
 							"%s — wave %d: %d/%d tasks complete, %d failed.\n\n"+
 								"[r] retry failed   [n] next wave   [a] abort",
@@ -1304,6 +1367,7 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		m.updateSidebarPlans()
 		m.updateInfoPane()
+		m.publishControlEvents()
 		completionCmd := m.checkPlanCompletion()
 		asyncCmds = append(asyncCmds, signalCmds...)
 		asyncCmds = append(asyncCmds, tickUpdateMetadataCmd, completionCmd)
@@ -1349,28 +1413,59 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case planStageConfirmedMsg:
 		// User confirmed past the topic-concurrency gate — execute the stage.
 		return m.executePlanStage(msg.planFile, msg.stage)
+	case ui.CommitLogMsg:
+		m.tabbedWindow.SetDiffCommits(msg)
+		return m, nil
+	case planCollisionsMsg:
+		return m, m.handlePlanCollisions(msg)
+	case planPRsMsg:
+		return m, m.handlePlanPRs(msg)
+	case planMergedMsg:
+		return m.handlePlanMerged(msg)
+	case planMergeConflictMsg:
+		return m, m.handleMergeConflict(msg)
+	case mergeFixerMsg:
+		return m, m.spawnMergeFixer(msg.planFile, msg.conflict)
+	case planRebasedMsg:
+		return m, m.handlePlanRebased(msg)
+	case waveRollbackMsg:
+		return m, m.rollbackPlanCmd(msg.planFile, msg.wave)
+	case waveRolledBackMsg:
+		return m.handleWaveRolledBack(msg)
+	case deliveryStatsMsg:
+		if msg.err != nil {
+			return m, m.handleError(msg.err)
+		}
+		// Don't pull the user out of a dialog they opened meanwhile.
+		if m.state == stateDefault {
+			m.textOverlay = overlay.NewTextOverlay(renderDeliveryStats(msg.stats))
+			m.state = stateHelp
+		}
+		return m, nil
+	case prReviewFetchedMsg:
+		return m, m.handlePRReviewFetched(msg)
+	case prReviewRepliedMsg:
+		return m, m.handlePRReviewReplied(msg)
 	case planRefreshMsg:
 		// Reload plan state and refresh sidebar after async plan mutation.
 		m.loadPlanState()
 		m.updateSidebarPlans()
 		return m, tea.WindowSize()
-	case clickUpTaskFetchedMsg:
-		if msg.Err != nil {
-			m.toastManager.Error("clickup fetch failed: " + msg.Err.Error())
-			m.state = stateDefault
+	case issueFetchedMsg:
+		m.state = stateDefault
+		if msg.err != nil {
+			m.toastManager.Error(msg.source + " fetch failed: " + msg.err.Error())
 			return m, m.toastTickCmd()
 		}
-		m.state = stateDefault
-		return m.importClickUpTask(msg.Task)
+		return m.importIssue(msg.source, msg.issue)
 	case waveAdvanceMsg:
 		orch, ok := m.waveOrchestrators[msg.planFile]
 		if !ok {
 			return m, nil
 		}
 		// Pause completed wave's instances before starting the next.
-		planName := planstate.DisplayName(msg.planFile)
 		for _, task := range orch.CurrentWaveTasks() {
-			taskTitle := fmt.Sprintf("%s-W%d-T%d", planName, orch.CurrentWaveNumber(), task.Number)
+			taskTitle := orchestration.TaskTitle(msg.planFile, orch.CurrentWaveNumber(), task.Number)
 			for _, inst := range m.nav.GetInstances() {
 				if inst.Title == taskTitle && inst.PromptDetected {
 					if err := inst.Pause(); err != nil {
@@ -1519,6 +1614,10 @@ func (m *home) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tmuxAttachReturnMsg:
 		m.toastManager.Info("detached from tmux session")
 		return m, tea.Batch(tea.WindowSize(), m.toastTickCmd())
+	case controlRequestMsg:
+		value, cmd, err := msg.fn(m)
+		msg.reply <- controlReply{value: value, err: err}
+		return m, cmd
 	case permissionAutoApproveMsg:
 		if msg.instance != nil && msg.instance.Started() {
 			i := msg.instance
@@ -1680,14 +1779,24 @@ func (m *home) View() string {
 		result = overlay.PlaceOverlay(0, 0, m.formOverlay.Render(), mainView, true, true)
 	case m.state == stateNewPlanTopic && m.pickerOverlay != nil:
 		result = overlay.PlaceOverlay(0, 0, m.pickerOverlay.Render(), mainView, true, true)
-	case m.state == stateClickUpSearch && m.textInputOverlay != nil:
+	case m.state == stateIssueSearch && m.textInputOverlay != nil:
 		result = overlay.PlaceOverlay(0, 0, m.textInputOverlay.Render(), mainView, true, true)
-	case m.state == stateClickUpPicker && m.pickerOverlay != nil:
+	case m.state == stateIssuePicker && m.pickerOverlay != nil:
 		result = overlay.PlaceOverlay(0, 0, m.pickerOverlay.Render(), mainView, true, true)
 	case m.state == stateChangeTopic && m.pickerOverlay != nil:
 		result = overlay.PlaceOverlay(0, 0, m.pickerOverlay.Render(), mainView, true, true)
 	case m.state == stateSetStatus && m.pickerOverlay != nil:
 		result = overlay.PlaceOverlay(0, 0, m.pickerOverlay.Render(), mainView, true, true)
+	case m.state == stateMergeStrategy && m.pickerOverlay != nil:
+		result = overlay.PlaceOverlay(0, 0, m.pickerOverlay.Render(), mainView, true, true)
+	case m.state == statePRReviewFollowUp && m.pickerOverlay != nil:
+		result = overlay.PlaceOverlay(0, 0, m.pickerOverlay.Render(), mainView, true, true)
+	case m.state == stateRollbackWave && m.pickerOverlay != nil:
+		result = overlay.PlaceOverlay(0, 0, m.pickerOverlay.Render(), mainView, true, true)
+	case m.state == stateAgentQuestion && m.pickerOverlay != nil:
+		result = overlay.PlaceOverlay(0, 0, m.pickerOverlay.Render(), mainView, true, true)
+	case m.state == stateAgentQuestion && m.textInputOverlay != nil:
+		result = overlay.PlaceOverlay(0, 0, m.textInputOverlay.Render(), mainView, true, true)
 	case m.state == statePrompt:
 		if m.textInputOverlay == nil {
 			log.ErrorLog.Printf("text input overlay is nil")
@@ -1758,6 +1867,9 @@ type permissionResponseMsg struct {
 type prCreatedMsg struct {
 	instanceTitle string
 	prTitle       string
+	// planFile is the plan the instance belongs to; empty for ad-hoc work.
+	planFile string
+	pr       *git.PullRequest
 }
 
 // prErrorMsg is sent when async PR creation fails.
@@ -1847,23 +1959,6 @@ type tmuxKillResultMsg struct {
 // tmuxAttachReturnMsg is sent when the user detaches from a passively attached orphan session.
 type tmuxAttachReturnMsg struct{}
 
-// clickUpDetectedMsg is sent at startup when ClickUp MCP is detected.
-type clickUpDetectedMsg struct {
-	Config clickup.MCPServerConfig
-}
-
-// clickUpSearchResultMsg is sent when ClickUp search completes.
-type clickUpSearchResultMsg struct {
-	Results []clickup.SearchResult
-	Err     error
-}
-
-// clickUpTaskFetchedMsg is sent when a full ClickUp task is fetched.
-type clickUpTaskFetchedMsg struct {
-	Task *clickup.Task
-	Err  error
-}
-
 // addInstanceFinalizer registers a finalizer for the given instance.
 // Lazily initializes the map so tests that don't pre-initialize it still work.
 func (m *home) addInstanceFinalizer(inst *session.Instance, fn func()) {
@@ -1897,8 +1992,7 @@ type instanceMetadata struct {
 	Updated            bool
 	HasPrompt          bool
 	DiffStats          *git.DiffStats
-	CPUPercent         float64
-	MemMB              float64
+	Resources          session.ResourceUsage
 	ResourceUsageValid bool
 	TmuxAlive          bool
 	PermissionPrompt   *session.PermissionPrompt // non-nil when opencode shows a permission dialog
@@ -1911,6 +2005,31 @@ type metadataResultMsg struct {
 	Signals          []planfsm.Signal     // agent sentinel files found this tick
 	WaveSignals      []planfsm.WaveSignal // implement-wave-N signal files found this tick
 	TmuxSessionCount int                  // number of kas_-prefixed tmux sessions
+	// PlanDrift maps plan files to their branch drift; nil when drift was
+	// not checked this tick.
+	PlanDrift map[string]git.BranchDrift
+	// Audit holds the reloaded audit pane events; nil when the audit log was
+	// not queried this tick.
+	Audit *auditQueryResult
+	// ArchivedActivity holds the archived activity of the selected plan; nil
+	// when it was already cached.
+	ArchivedActivity *archivedActivityResult
+}
+
+// auditQueryResult is the audit pane's events as queried by the metadata
+// tick. Since is when the query started.
+type auditQueryResult struct {
+	Events []auditlog.Event
+	Err    error
+	Since  time.Time
+}
+
+// archivedActivityResult is a plan's archived activity as queried by the
+// metadata tick. Gen is archivedActivityGen when the query started.
+type archivedActivityResult struct {
+	PlanFile string
+	Gen      int
+	Activity []session.InstanceActivity
 }
 
 // tickUpdateMetadataCmd is the callback to update the metadata of the instances every 200ms. We iterate
@@ -1927,150 +2046,3 @@ func (m *home) toastTickCmd() tea.Cmd {
 		return overlay.ToastTickMsg{}
 	}
 }
-
-func (m *home) searchClickUp(query string) tea.Cmd {
-	return func() tea.Msg {
-		ctx, cancel := context.WithTimeout(m.ctx, clickUpOpTimeout)
-		defer cancel()
-
-		importer, err := m.getOrCreateImporter(ctx)
-		if err != nil {
-			return clickUpSearchResultMsg{Err: normalizeClickUpError(err)}
-		}
-
-		searchDone := make(chan clickUpSearchResultMsg, 1)
-		go func() {
-			results, searchErr := importer.Search(query)
-			searchDone <- clickUpSearchResultMsg{Results: results, Err: searchErr}
-		}()
-
-		select {
-		case msg := <-searchDone:
-			msg.Err = normalizeClickUpError(msg.Err)
-			return msg
-		case <-ctx.Done():
-			return clickUpSearchResultMsg{Err: normalizeClickUpError(ctx.Err())}
-		}
-	}
-}
-
-func (m *home) fetchClickUpTaskWithTimeout(taskID string) tea.Cmd {
-	return func() tea.Msg {
-		ctx, cancel := context.WithTimeout(m.ctx, clickUpOpTimeout)
-		defer cancel()
-
-		if m.clickUpImporter == nil {
-			return clickUpTaskFetchedMsg{Err: fmt.Errorf("importer not initialized")}
-		}
-
-		fetchDone := make(chan clickUpTaskFetchedMsg, 1)
-		go func() {
-			task, fetchErr := m.clickUpImporter.FetchTask(taskID)
-			fetchDone <- clickUpTaskFetchedMsg{Task: task, Err: fetchErr}
-		}()
-
-		select {
-		case msg := <-fetchDone:
-			msg.Err = normalizeClickUpError(msg.Err)
-			return msg
-		case <-ctx.Done():
-			return clickUpTaskFetchedMsg{Err: normalizeClickUpError(ctx.Err())}
-		}
-	}
-}
-
-func normalizeClickUpError(err error) error {
-	if err == nil {
-		return nil
-	}
-	if errors.Is(err, context.Canceled) {
-		return fmt.Errorf("operation canceled")
-	}
-	if errors.Is(err, context.DeadlineExceeded) {
-		return fmt.Errorf("operation timed out after %s", clickUpOpTimeout)
-	}
-	return err
-}
-
-func (m *home) getOrCreateImporter(ctx context.Context) (*clickup.Importer, error) {
-	if m.clickUpImporter != nil {
-		return m.clickUpImporter, nil
-	}
-	if m.clickUpConfig == nil {
-		return nil, fmt.Errorf("no clickup MCP server configured")
-	}
-
-	transport, err := m.createTransport(ctx, *m.clickUpConfig)
-	if err != nil {
-		return nil, err
-	}
-
-	client, err := mcpclient.NewClient(transport)
-	if err != nil {
-		_ = transport.Close()
-		return nil, err
-	}
-	if err := client.Initialize(); err != nil {
-		_ = client.Close()
-		return nil, fmt.Errorf("MCP initialize: %w", err)
-	}
-	if _, err := client.ListTools(); err != nil {
-		_ = client.Close()
-		return nil, fmt.Errorf("MCP list tools: %w", err)
-	}
-
-	m.clickUpImporter = clickup.NewImporter(client)
-	return m.clickUpImporter, nil
-}
-
-func (m *home) createTransport(ctx context.Context, cfg clickup.MCPServerConfig) (mcpclient.Transport, error) {
-	switch cfg.Type {
-	case "http":
-		token, err := m.getClickUpToken(ctx)
-		if err != nil {
-			return nil, err
-		}
-		return mcpclient.NewHTTPTransport(cfg.URL, token), nil
-	case "stdio":
-		envSlice := make([]string, 0, len(cfg.Env))
-		for k, v := range cfg.Env {
-			envSlice = append(envSlice, k+"="+v)
-		}
-		return mcpclient.NewStdioTransport(cfg.Command, cfg.Args, envSlice)
-	default:
-		return nil, fmt.Errorf("unsupported transport type: %s", cfg.Type)
-	}
-}
-
-func (m *home) getClickUpToken(ctx context.Context) (string, error) {
-	path := mcpclient.TokenPath()
-	tok, err := mcpclient.LoadToken(path)
-	if err == nil && !tok.IsExpired() {
-		return tok.AccessToken, nil
-	}
-
-	oauthCfg := mcpclient.OAuthConfig{
-		AuthURL:  "https://app.clickup.com/api",
-		TokenURL: "https://api.clickup.com/api/v2/oauth/token",
-		ClientID: "kasmos", // TODO: register ClickUp OAuth app
-	}
-	tok, err = mcpclient.OAuthFlow(ctx, oauthCfg, nil)
-	if err != nil {
-		return "", fmt.Errorf("oauth: %w", err)
-	}
-	if err := mcpclient.SaveToken(path, tok); err != nil {
-		return "", fmt.Errorf("save token: %w", err)
-	}
-	return tok.AccessToken, nil
-}
-
-func detectClickUpCmd(repoPath string) tea.Cmd {
-	return func() tea.Msg {
-		claudeDir := filepath.Join(os.Getenv("HOME"), ".claude")
-		cfg, found := clickup.DetectMCP(repoPath, claudeDir)
-		if !found {
-			return nil
-		}
-		return clickUpDetectedMsg{Config: cfg}
-	}
-}
@@ -12,6 +12,7 @@ import (
 	"github.com/kastheco/kasmos/config/planparser"
 	"github.com/kastheco/kasmos/config/planstate"
 	"github.com/kastheco/kasmos/internal/initcmd/scaffold"
+	"github.com/kastheco/kasmos/orchestration"
 	"github.com/kastheco/kasmos/session"
 	gitpkg "github.com/kastheco/kasmos/session/git"
 	"github.com/kastheco/kasmos/ui/overlay"
@@ -30,6 +31,7 @@ func (m *home) executeContextAction(action string) (tea.Model, tea.Cmd) {
 			m.audit(auditlog.EventAgentKilled, "agent killed",
 				auditlog.WithInstance(title),
 				auditlog.WithAgent(selected.AgentType),
+				auditlog.WithProgram(selected.Program),
 				auditlog.WithPlan(selected.PlanFile),
 			)
 			m.removeFromAllInstances(title)
@@ -277,44 +279,45 @@ func (m *home) executeContextAction(action string) (tea.Model, tea.Cmd) {
 		if planFile == "" || m.planState == nil {
 			return m, nil
 		}
-		entry, ok := m.planState.Entry(planFile)
-		if !ok {
-			return m, m.handleError(fmt.Errorf("plan not found: %s", planFile))
+		m.pendingMergePlan = planFile
+		m.pickerOverlay = overlay.NewPickerOverlay("merge strategy", m.mergeStrategyChoices())
+		m.state = stateMergeStrategy
+		return m, nil
+
+	case "address_pr_review":
+		planFile := m.nav.GetSelectedPlanFile()
+		if planFile == "" {
+			return m, nil
 		}
-		if entry.Branch == "" {
-			return m, m.handleError(fmt.Errorf("plan has no branch to merge"))
+		return m, m.fetchPRReview(planFile)
+
+	case "stack_plan":
+		planFile := m.nav.GetSelectedPlanFile()
+		if planFile == "" {
+			return m, nil
 		}
-		planName := planstate.DisplayName(planFile)
-		mergeAction := func() tea.Msg {
-			// Kill all instances bound to this plan.
-			for i := len(m.allInstances) - 1; i >= 0; i-- {
-				if m.allInstances[i].PlanFile == planFile {
-					_ = m.allInstances[i].Kill()
-					m.allInstances = append(m.allInstances[:i], m.allInstances[i+1:]...)
-				}
-			}
-			if err := gitpkg.MergePlanBranch(m.activeRepoPath, entry.Branch); err != nil {
-				return err
-			}
-			// Walk through FSM to done if not already there.
-			if planfsm.Status(entry.Status) != planfsm.StatusDone {
-				if planfsm.Status(entry.Status) != planfsm.StatusReviewing {
-					if err := m.fsmSetReviewing(planFile); err != nil {
-						return err
-					}
-				}
-				if err := m.fsm.Transition(planFile, planfsm.ReviewApproved); err != nil {
-					return err
-				}
-			}
-			m.audit(auditlog.EventPlanMerged, "plan merged to main: "+planName,
-				auditlog.WithPlan(planFile))
-			_ = m.saveAllInstances()
-			m.loadPlanState()
-			m.updateSidebarPlans()
-			return planRefreshMsg{}
+		return m.startStackedPlan(planFile)
+
+	case "view_collisions":
+		planFile := m.nav.GetSelectedPlanFile()
+		if planFile == "" {
+			return m, nil
+		}
+		return m.viewPlanCollisions(planFile)
+
+	case "rollback_wave":
+		planFile := m.nav.GetSelectedPlanFile()
+		if planFile == "" {
+			return m, nil
+		}
+		return m.openRollbackPicker(planFile)
+
+	case "rebase_plan":
+		planFile := m.nav.GetSelectedPlanFile()
+		if planFile == "" {
+			return m, nil
 		}
-		return m, m.confirmAction(fmt.Sprintf("merge '%s' branch into main?", planName), mergeAction)
+		return m, m.rebasePlan(planFile)
 
 	case "mark_plan_done":
 		planFile := m.nav.GetSelectedPlanFile()
@@ -419,6 +422,7 @@ func (m *home) executeContextAction(action string) (tea.Model, tea.Cmd) {
 			if err := gitpkg.ResetPlanBranch(m.activeRepoPath, entry.Branch); err != nil {
 				return err
 			}
+			_ = gitpkg.DeleteWaveCheckpoints(m.activeRepoPath, planFile)
 			if err := m.fsmForceToPlanning(planFile); err != nil {
 				return err
 			}
@@ -653,8 +657,24 @@ func (m *home) openPlanContextMenu() (tea.Model, tea.Cmd) {
 					overlay.ContextMenuItem{Label: "resume implement", Action: "resume_implement"},
 				)
 			}
+			if entry.Status == planstate.StatusImplementing || entry.Status == planstate.StatusReviewing {
+				items = append(items, overlay.ContextMenuItem{Label: "roll back to before wave", Action: "rollback_wave"})
+			}
+			if len(m.planCollisionsFor(planFile)) > 0 {
+				items = append(items, overlay.ContextMenuItem{Label: "view file collisions", Action: "view_collisions"})
+			}
+			if entry.PRNumber > 0 && entry.Status != planstate.StatusCancelled {
+				items = append(items, overlay.ContextMenuItem{Label: "address PR review", Action: "address_pr_review"})
+			}
+			if entry.Status != planstate.StatusDone && entry.Status != planstate.StatusCancelled {
+				items = append(items, overlay.ContextMenuItem{Label: "new stacked plan", Action: "stack_plan"})
+			}
 		}
 	}
+	rebaseLabel := "rebase onto main"
+	if m.planState != nil && stackParentBranch(m.planState, planFile) != "" {
+		rebaseLabel = "rebase onto " + planstate.DisplayName(m.planState.Plans[planFile].Parent)
+	}
 	// History plans get an "inspect plan" option to move them to the dead section.
 	if m.nav.IsSelectedHistoryPlan() {
 		items = append(items,
@@ -673,6 +693,7 @@ func (m *home) openPlanContextMenu() (tea.Model, tea.Cmd) {
 		overlay.ContextMenuItem{Label: autoAdvanceLabel, Action: "toggle_auto_advance"},
 		overlay.ContextMenuItem{Label: "set status", Action: "set_status"},
 		overlay.ContextMenuItem{Label: "merge to main", Action: "merge_plan"},
+		overlay.ContextMenuItem{Label: rebaseLabel, Action: "rebase_plan"},
 		overlay.ContextMenuItem{Label: "mark done", Action: "mark_plan_done"},
 		overlay.ContextMenuItem{Label: "start over", Action: "start_over_plan"},
 		overlay.ContextMenuItem{Label: "cancel plan", Action: "cancel_plan"},
@@ -826,7 +847,7 @@ func (m *home) executePlanStage(planFile, stage string) (tea.Model, tea.Cmd) {
 			return m, tea.Batch(m.toastTickCmd(), func() tea.Msg { return planRefreshMsg{} }, spawnCmd)
 		}
 
-		orch := NewWaveOrchestrator(planFile, plan)
+		orch := orchestration.NewWaveOrchestrator(planFile, plan)
 		m.waveOrchestrators[planFile] = orch
 
 		if err := m.fsmSetImplementing(planFile); err != nil {
@@ -0,0 +1,362 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/session"
+	"github.com/kastheco/kasmos/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// analyticsWindow is how far back the delivery analytics overlay looks.
+const analyticsWindow = 90 * 24 * time.Hour
+
+// analyticsWeeks is how many weeks of throughput the overlay charts.
+const analyticsWeeks = 8
+
+// analyticsPageSize is how many events are read per audit query; the
+// loggers cap a single query at 500.
+const analyticsPageSize = 500
+
+// analyticsStages are the lifecycle stages the overlay times, in order.
+var analyticsStages = []planfsm.Status{
+	planfsm.StatusReady,
+	planfsm.StatusPlanning,
+	planfsm.StatusImplementing,
+	planfsm.StatusReviewing,
+}
+
+// analyticsKinds are the audit events delivery analytics are computed from.
+var analyticsKinds = []auditlog.EventKind{
+	auditlog.EventPlanStatusChanged,
+	auditlog.EventAgentSpawned,
+	auditlog.EventWaveCompleted,
+	auditlog.EventWaveFailed,
+}
+
+// deliveryStats summarises how a project's plans moved through the
+// lifecycle over analyticsWindow.
+type deliveryStats struct {
+	project string
+	// weeks holds plans done per week, oldest first.
+	weeks []weekThroughput
+	// stageMedians is the median time a plan spent in each stage;
+	// stageSamples is how many stays each median is taken over.
+	stageMedians map[planfsm.Status]time.Duration
+	stageSamples map[planfsm.Status]int
+	// waveOutcomes counts waves that finished, waveFailures those with
+	// failed tasks. taskSpawns counts wave task agents started and
+	// taskRetries those re-spawned for a task that already ran.
+	waveOutcomes, waveFailures int
+	taskSpawns, taskRetries    int
+	// approved and changesRequested count review verdicts.
+	approved, changesRequested int
+	// harnesses breaks spawns down by agent program and model.
+	harnesses []harnessStats
+}
+
+// weekThroughput is the number of plans done in the week starting at start.
+type weekThroughput struct {
+	start time.Time
+	done  int
+}
+
+// harnessStats is one agent program/model combination's record. shipped
+// and rejected count plans it coded that were done or sent back by review.
+type harnessStats struct {
+	name                               string
+	spawns, retries, shipped, rejected int
+}
+
+// deliveryStatsMsg carries the computed analytics back to Update.
+type deliveryStatsMsg struct {
+	stats deliveryStats
+	err   error
+}
+
+// recordStatusChange is the plan FSM's transition observer. It writes the
+// structured status change delivery analytics are computed from; the
+// human-readable plan_transition line is emitted by the caller.
+func (m *home) recordStatusChange(planFile string, from, to planfsm.Status, event planfsm.Event) {
+	if m.auditLogger == nil {
+		return
+	}
+	e := auditlog.Event{
+		Kind:     auditlog.EventPlanStatusChanged,
+		Project:  m.planStoreProject,
+		PlanFile: planFile,
+		Message:  fmt.Sprintf("%s → %s", from, to),
+	}
+	auditlog.WithStatusChange(auditlog.StatusChange{Event: string(event), From: string(from), To: string(to)})(&e)
+	m.auditLogger.Emit(e)
+}
+
+// loadDeliveryStats reads the project's recent audit history off the UI
+// goroutine and computes the analytics overlay's numbers.
+func (m *home) loadDeliveryStats() tea.Cmd {
+	logger, project := m.auditLogger, m.planStoreProject
+	if logger == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		now := time.Now()
+		events, err := queryDeliveryEvents(logger, project, now.Add(-analyticsWindow))
+		if err != nil {
+			return deliveryStatsMsg{err: err}
+		}
+		return deliveryStatsMsg{stats: computeDeliveryStats(project, events, now)}
+	}
+}
+
+// queryDeliveryEvents pages through the analytics events recorded for
+// project since after and returns them oldest first.
+func queryDeliveryEvents(logger auditlog.Logger, project string, after time.Time) ([]auditlog.Event, error) {
+	filter := auditlog.QueryFilter{
+		Project: project,
+		Kinds:   analyticsKinds,
+		After:   after,
+		Limit:   analyticsPageSize,
+	}
+	var all []auditlog.Event
+	for {
+		events, err := logger.Query(filter)
+		if err != nil {
+			return nil, fmt.Errorf("query audit log: %w", err)
+		}
+		all = append(all, events...)
+		if len(events) < analyticsPageSize {
+			break
+		}
+		filter.Before = events[len(events)-1].Timestamp
+	}
+	for i, j := 0, len(all)-1; i < j; i, j = i+1, j-1 {
+		all[i], all[j] = all[j], all[i]
+	}
+	return all, nil
+}
+
+// computeDeliveryStats derives delivery analytics from audit events sorted
+// oldest first. Time in a stage is measured between consecutive status
+// changes of a plan; a stay broken by a manual override is not counted.
+func computeDeliveryStats(project string, events []auditlog.Event, now time.Time) deliveryStats {
+	stats := deliveryStats{
+		project:      project,
+		stageMedians: make(map[planfsm.Status]time.Duration),
+		stageSamples: make(map[planfsm.Status]int),
+	}
+	thisWeek := startOfWeek(now)
+	for i := analyticsWeeks - 1; i >= 0; i-- {
+		stats.weeks = append(stats.weeks, weekThroughput{start: thisWeek.AddDate(0, 0, -7*i)})
+	}
+
+	type stay struct {
+		status planfsm.Status
+		since  time.Time
+	}
+	current := make(map[string]stay)
+	stays := make(map[planfsm.Status][]time.Duration)
+	spawnedTasks := make(map[string]bool)
+	harnesses := make(map[string]*harnessStats)
+	planCoders := make(map[string]map[string]bool)
+	harness := func(name string) *harnessStats {
+		h, ok := harnesses[name]
+		if !ok {
+			h = &harnessStats{name: name}
+			harnesses[name] = h
+		}
+		return h
+	}
+
+	for _, e := range events {
+		switch e.Kind {
+		case auditlog.EventPlanStatusChanged:
+			sc, ok := auditlog.ParseStatusChange(e.Detail)
+			if !ok {
+				continue
+			}
+			from, to := planfsm.Status(sc.From), planfsm.Status(sc.To)
+			if prev, ok := current[e.PlanFile]; ok && prev.status == from {
+				stays[from] = append(stays[from], e.Timestamp.Sub(prev.since))
+			}
+			current[e.PlanFile] = stay{status: to, since: e.Timestamp}
+
+			switch planfsm.Event(sc.Event) {
+			case planfsm.ReviewApproved:
+				stats.approved++
+			case planfsm.ReviewChangesRequested:
+				stats.changesRequested++
+				for name := range planCoders[e.PlanFile] {
+					harness(name).rejected++
+				}
+			}
+			if to == planfsm.StatusDone {
+				for name := range planCoders[e.PlanFile] {
+					harness(name).shipped++
+				}
+				week := startOfWeek(e.Timestamp.In(now.Location()))
+				for i := range stats.weeks {
+					if stats.weeks[i].start.Equal(week) {
+						stats.weeks[i].done++
+					}
+				}
+			}
+		case auditlog.EventAgentSpawned:
+			name := harnessName(e.Program)
+			h := harness(name)
+			h.spawns++
+			if e.AgentType == session.AgentTypeCoder && e.PlanFile != "" {
+				if planCoders[e.PlanFile] == nil {
+					planCoders[e.PlanFile] = make(map[string]bool)
+				}
+				planCoders[e.PlanFile][name] = true
+			}
+			if e.WaveNumber > 0 && e.TaskNumber > 0 {
+				stats.taskSpawns++
+				task := fmt.Sprintf("%s\x00%d\x00%d", e.PlanFile, e.WaveNumber, e.TaskNumber)
+				if spawnedTasks[task] {
+					stats.taskRetries++
+					h.retries++
+				}
+				spawnedTasks[task] = true
+			}
+		case auditlog.EventWaveFailed:
+			stats.waveOutcomes++
+			stats.waveFailures++
+		case auditlog.EventWaveCompleted:
+			// "all waves complete" carries no wave number and is not an outcome.
+			if e.WaveNumber > 0 {
+				stats.waveOutcomes++
+			}
+		}
+	}
+
+	for _, stage := range analyticsStages {
+		if d := stays[stage]; len(d) > 0 {
+			stats.stageMedians[stage] = medianDuration(d)
+			stats.stageSamples[stage] = len(d)
+		}
+	}
+	for _, h := range harnesses {
+		stats.harnesses = append(stats.harnesses, *h)
+	}
+	sort.Slice(stats.harnesses, func(i, j int) bool {
+		a, b := stats.harnesses[i], stats.harnesses[j]
+		if a.shipped != b.shipped {
+			return a.shipped > b.shipped
+		}
+		if a.spawns != b.spawns {
+			return a.spawns > b.spawns
+		}
+		return a.name < b.name
+	})
+	return stats
+}
+
+// startOfWeek returns midnight on the Monday of t's week, in t's location.
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	y, mo, d := t.AddDate(0, 0, -offset).Date()
+	return time.Date(y, mo, d, 0, 0, 0, 0, t.Location())
+}
+
+// medianDuration returns the median of ds, which must not be empty.
+func medianDuration(ds []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// harnessName labels an agent command line by program and model:
+// "/usr/bin/claude --model opus" becomes "claude opus".
+func harnessName(program string) string {
+	name, model := auditlog.SplitProgram(program)
+	if model == "" {
+		return name
+	}
+	return name + " " + model
+}
+
+// renderDeliveryStats lays out the analytics overlay.
+func renderDeliveryStats(s deliveryStats) string {
+	lines := []string{
+		ui.GradientText("delivery analytics", ui.GradientStart, ui.GradientEnd),
+		descStyle.Render(fmt.Sprintf("%s — last %d days", s.project, int(analyticsWindow.Hours()/24))),
+		"",
+		headerStyle.Render("plans done per week:"),
+	}
+	for _, w := range s.weeks {
+		lines = append(lines, keyStyle.Render(w.start.Format("Jan 02"))+
+			descStyle.Render(fmt.Sprintf("  %3d %s", w.done, strings.Repeat("█", min(w.done, 30)))))
+	}
+
+	lines = append(lines, "", headerStyle.Render("median time in stage:"))
+	for _, stage := range analyticsStages {
+		value := "—"
+		if n := s.stageSamples[stage]; n > 0 {
+			value = fmt.Sprintf("%s (%d)", formatStageDuration(s.stageMedians[stage]), n)
+		}
+		lines = append(lines, keyStyle.Render(fmt.Sprintf("%-14s", stage))+descStyle.Render(value))
+	}
+
+	lines = append(lines,
+		"",
+		headerStyle.Render("waves and reviews:"),
+		keyStyle.Render("wave failures ")+descStyle.Render(formatRate(s.waveFailures, s.waveOutcomes)+" of finished waves"),
+		keyStyle.Render("task retries  ")+descStyle.Render(formatRate(s.taskRetries, s.taskSpawns)+" of task spawns"),
+		keyStyle.Render("rejections    ")+descStyle.Render(formatRate(s.changesRequested, s.changesRequested+s.approved)+" of reviews"),
+		"",
+		headerStyle.Render("by program/model:"),
+	)
+	if len(s.harnesses) == 0 {
+		lines = append(lines, descStyle.Render("no agents spawned"))
+	} else {
+		lines = append(lines, descStyle.Render(fmt.Sprintf("%-24s %7s %8s %8s %9s", "", "spawns", "retries", "shipped", "rejected")))
+		for _, h := range s.harnesses {
+			lines = append(lines, keyStyle.Render(fmt.Sprintf("%-24s", truncateHarness(h.name)))+
+				descStyle.Render(fmt.Sprintf(" %7d %8d %8d %9d", h.spawns, h.retries, h.shipped, h.rejected)))
+		}
+	}
+
+	lines = append(lines, "", descStyle.Render("press any key to close"))
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// truncateHarness keeps a harness name within its table column.
+func truncateHarness(name string) string {
+	if r := []rune(name); len(r) > 24 {
+		return string(r[:23]) + "…"
+	}
+	return name
+}
+
+// formatRate renders n out of total as a percentage, or a dash when there
+// is nothing to measure.
+func formatRate(n, total int) string {
+	if total == 0 {
+		return "—"
+	}
+	return fmt.Sprintf("%d%% (%d/%d)", n*100/total, n, total)
+}
+
+// formatStageDuration renders a stage duration at a readable precision.
+func formatStageDuration(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%.1fd", d.Hours()/24)
+	case d >= time.Hour:
+		return fmt.Sprintf("%.1fh", d.Hours())
+	default:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+}
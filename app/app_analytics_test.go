@@ -0,0 +1,143 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/config/planstore"
+	"github.com/kastheco/kasmos/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusChange builds a plan_status_changed event for the analytics tests.
+func statusChange(plan string, at time.Time, event planfsm.Event, from, to planfsm.Status) auditlog.Event {
+	e := auditlog.Event{Kind: auditlog.EventPlanStatusChanged, PlanFile: plan, Timestamp: at}
+	auditlog.WithStatusChange(auditlog.StatusChange{Event: string(event), From: string(from), To: string(to)})(&e)
+	return e
+}
+
+func TestComputeDeliveryStats(t *testing.T) {
+	// now is a Wednesday; base is the Tuesday of the week before.
+	now := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+	base := now.Add(-8 * 24 * time.Hour)
+	h := time.Hour
+	coder := func(plan, program string, at time.Time, wave, task int) auditlog.Event {
+		return auditlog.Event{Kind: auditlog.EventAgentSpawned, PlanFile: plan, Program: program,
+			AgentType: session.AgentTypeCoder, WaveNumber: wave, TaskNumber: task, Timestamp: at}
+	}
+
+	events := []auditlog.Event{
+		// a.md: ready 1h, planning 2h, implementing 4h, rejected once, done.
+		statusChange("a.md", base, planfsm.PlanStart, planfsm.StatusReady, planfsm.StatusPlanning),
+		statusChange("a.md", base.Add(2*h), planfsm.PlannerFinished, planfsm.StatusPlanning, planfsm.StatusReady),
+		statusChange("a.md", base.Add(3*h), planfsm.ImplementStart, planfsm.StatusReady, planfsm.StatusImplementing),
+		coder("a.md", "claude --model opus", base.Add(3*h), 1, 1),
+		coder("a.md", "claude --model opus", base.Add(3*h), 1, 2),
+		{Kind: auditlog.EventWaveFailed, PlanFile: "a.md", WaveNumber: 1, Timestamp: base.Add(4 * h)},
+		coder("a.md", "claude --model opus", base.Add(4*h), 1, 2),
+		{Kind: auditlog.EventWaveCompleted, PlanFile: "a.md", WaveNumber: 1, Timestamp: base.Add(5 * h)},
+		{Kind: auditlog.EventWaveCompleted, PlanFile: "a.md", Timestamp: base.Add(5 * h)},
+		statusChange("a.md", base.Add(7*h), planfsm.ImplementFinished, planfsm.StatusImplementing, planfsm.StatusReviewing),
+		{Kind: auditlog.EventAgentSpawned, PlanFile: "a.md", Program: "codex", AgentType: session.AgentTypeReviewer, Timestamp: base.Add(7 * h)},
+		statusChange("a.md", base.Add(8*h), planfsm.ReviewChangesRequested, planfsm.StatusReviewing, planfsm.StatusImplementing),
+		statusChange("a.md", base.Add(10*h), planfsm.ImplementFinished, planfsm.StatusImplementing, planfsm.StatusReviewing),
+		statusChange("a.md", base.Add(11*h), planfsm.ReviewApproved, planfsm.StatusReviewing, planfsm.StatusDone),
+		// b.md: coded by opencode, done this week.
+		coder("b.md", "/usr/local/bin/opencode -m sonnet", now.Add(-3*h), 0, 0),
+		statusChange("b.md", now.Add(-2*h), planfsm.ImplementFinished, planfsm.StatusImplementing, planfsm.StatusReviewing),
+		statusChange("b.md", now.Add(-h), planfsm.ReviewApproved, planfsm.StatusReviewing, planfsm.StatusDone),
+		// Events without a parseable detail are ignored.
+		{Kind: auditlog.EventPlanStatusChanged, PlanFile: "c.md", Detail: "garbage", Timestamp: now},
+	}
+
+	stats := computeDeliveryStats("kasmos", events, now)
+
+	require.Len(t, stats.weeks, analyticsWeeks)
+	assert.Equal(t, time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC), stats.weeks[analyticsWeeks-1].start)
+	assert.Equal(t, 1, stats.weeks[analyticsWeeks-1].done, "b.md this week")
+	assert.Equal(t, 1, stats.weeks[analyticsWeeks-2].done, "a.md last week")
+
+	assert.Equal(t, time.Hour, stats.stageMedians[planfsm.StatusReady])
+	assert.Equal(t, 2*time.Hour, stats.stageMedians[planfsm.StatusPlanning])
+	assert.Equal(t, 3*time.Hour, stats.stageMedians[planfsm.StatusImplementing], "median of 4h and 2h")
+	assert.Equal(t, 3, stats.stageSamples[planfsm.StatusReviewing])
+	assert.Equal(t, time.Hour, stats.stageMedians[planfsm.StatusReviewing])
+
+	assert.Equal(t, 2, stats.waveOutcomes)
+	assert.Equal(t, 1, stats.waveFailures)
+	assert.Equal(t, 3, stats.taskSpawns)
+	assert.Equal(t, 1, stats.taskRetries)
+	assert.Equal(t, 2, stats.approved)
+	assert.Equal(t, 1, stats.changesRequested)
+
+	assert.Equal(t, []harnessStats{
+		{name: "claude opus", spawns: 3, retries: 1, shipped: 1, rejected: 1},
+		{name: "opencode sonnet", spawns: 1, shipped: 1},
+		{name: "codex", spawns: 1},
+	}, stats.harnesses)
+}
+
+func TestHarnessName(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                             "unknown",
+		"claude":                       "claude",
+		"/usr/bin/claude --model opus": "claude opus",
+		"opencode -m anthropic/sonnet": "opencode anthropic/sonnet",
+		"codex --model=gpt-5 --yolo":   "codex gpt-5",
+		"amp --model":                  "amp",
+		"gemini --sandbox --model pro": "gemini pro",
+	} {
+		assert.Equal(t, want, harnessName(in), in)
+	}
+}
+
+func TestRenderDeliveryStats(t *testing.T) {
+	now := time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC)
+	empty := renderDeliveryStats(computeDeliveryStats("kasmos", nil, now))
+	assert.Contains(t, empty, "no agents spawned")
+	assert.Contains(t, empty, "—")
+
+	stats := computeDeliveryStats("kasmos", []auditlog.Event{
+		{Kind: auditlog.EventAgentSpawned, Program: "claude --model opus", Timestamp: now},
+		statusChange("a.md", now.Add(-3*time.Hour), planfsm.ImplementFinished, planfsm.StatusImplementing, planfsm.StatusReviewing),
+		statusChange("a.md", now, planfsm.ReviewChangesRequested, planfsm.StatusReviewing, planfsm.StatusImplementing),
+	}, now)
+	out := renderDeliveryStats(stats)
+	assert.Contains(t, out, "claude opus")
+	assert.Contains(t, out, "100% (1/1)")
+	assert.Contains(t, out, "3.0h (1)")
+}
+
+func TestAnalyticsKey_OpensOverlayFromFSMTransitions(t *testing.T) {
+	h := newTestHome()
+	logger, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+	defer logger.Close()
+	h.auditLogger = logger
+	h.planStoreProject = "kasmos"
+
+	store := planstore.NewTestSQLiteStore(t)
+	require.NoError(t, store.Create("kasmos", planstore.PlanEntry{Filename: "a.md", Status: "reviewing"}))
+	fsm := planfsm.New(store, "kasmos", t.TempDir())
+	fsm.OnTransition(h.recordStatusChange)
+	require.NoError(t, fsm.Transition("a.md", planfsm.ReviewApproved))
+
+	h.keySent = true
+	_, cmd := h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("A")})
+	require.NotNil(t, cmd)
+	msg, ok := cmd().(deliveryStatsMsg)
+	require.True(t, ok)
+	require.NoError(t, msg.err)
+	assert.Equal(t, 1, msg.stats.approved)
+	assert.Equal(t, 1, msg.stats.weeks[analyticsWeeks-1].done)
+
+	model, _ := h.Update(msg)
+	updated := model.(*home)
+	assert.Equal(t, stateHelp, updated.state)
+	require.NotNil(t, updated.textOverlay)
+	assert.Contains(t, updated.textOverlay.Render(), "kasmos — last 90 days")
+}
@@ -31,12 +31,23 @@ func TestAuditPaneToggle(t *testing.T) {
 
 func TestAuditPaneRefresh_EmptyWithNilLogger(t *testing.T) {
 	h := newTestHome()
-	// With nil auditLogger, refreshAuditPane should not panic
+	// With nil auditLogger, rendering and auditing should not panic
 	assert.NotPanics(t, func() {
-		h.refreshAuditPane()
+		h.renderAuditPane()
+		h.audit(auditlog.EventAgentSpawned, "spawned")
 	})
 }
 
+// reloadAuditPane does what the metadata tick does: query the audit log and
+// hand the events to the model.
+func reloadAuditPane(t *testing.T, h *home) {
+	t.Helper()
+	since := time.Now()
+	events, err := queryAuditEvents(h.auditLogger, h.planStoreProject)
+	require.NoError(t, err)
+	h.setAuditEvents(events, since)
+}
+
 // TestRefreshAuditPane_TimestampInLocalTime verifies that audit event timestamps
 // are displayed in local time, not UTC. Timestamps are stored as UTC in the DB
 // and must be converted to local time before formatting for display.
@@ -58,7 +69,7 @@ func TestRefreshAuditPane_TimestampInLocalTime(t *testing.T) {
 	h := newTestHome()
 	h.auditLogger = logger
 	h.planStoreProject = "test"
-	h.refreshAuditPane()
+	reloadAuditPane(t, h)
 
 	// The displayed time must match local time, not UTC.
 	localTime := utcTime.Local()
@@ -72,3 +83,53 @@ func TestRefreshAuditPane_TimestampInLocalTime(t *testing.T) {
 		"audit timestamp must be displayed in local time (got %q, UTC would be %q)",
 		events[0].Time, utcTimeStr)
 }
+
+func TestRefreshAuditPane_LabelsOtherMachines(t *testing.T) {
+	logger, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+	defer logger.Close()
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	logger.Emit(auditlog.Event{Kind: auditlog.EventAgentSpawned, Project: "test", Message: "mine", Timestamp: base})
+	logger.Emit(auditlog.Event{Kind: auditlog.EventAgentSpawned, Project: "test", Message: "theirs",
+		Host: "other-box", User: "sam", Timestamp: base.Add(time.Minute)})
+
+	h := newTestHome()
+	h.auditLogger = logger
+	h.planStoreProject = "test"
+	reloadAuditPane(t, h)
+
+	events := h.auditPane.Events()
+	require.Len(t, events, 2)
+	assert.Equal(t, "sam@other-box: theirs", events[0].Message)
+	assert.Equal(t, "mine", events[1].Message)
+}
+
+func TestAudit_ShowsEventWithoutQuerying(t *testing.T) {
+	sqlite, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+	defer sqlite.Close()
+	logger := &countingLogger{Logger: sqlite}
+
+	h := newTestHome()
+	h.auditLogger = logger
+	h.planStoreProject = "test"
+
+	h.audit(auditlog.EventAgentSpawned, "spawned coder")
+	require.Len(t, h.auditPane.Events(), 1)
+	assert.Equal(t, "spawned coder", h.auditPane.Events()[0].Message)
+	assert.Zero(t, logger.all, "emitting must not query the audit log")
+
+	// A reload that started before the next event keeps it on top, and one
+	// that already contains an event does not show it twice.
+	since := time.Now()
+	events, err := queryAuditEvents(logger, "test")
+	require.NoError(t, err)
+	h.audit(auditlog.EventAgentKilled, "killed coder")
+	h.setAuditEvents(events, since)
+	require.Len(t, h.auditPane.Events(), 2)
+	assert.Equal(t, "killed coder", h.auditPane.Events()[0].Message)
+
+	reloadAuditPane(t, h)
+	assert.Len(t, h.auditPane.Events(), 2)
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/kastheco/kasmos/config/auditlog"
@@ -325,7 +326,7 @@ func TestAuditHomeEmit_PlanCreated(t *testing.T) {
 	h.planStateDir = plansDir
 	h.planStore = store
 
-	err = h.createPlanEntry("my cool plan", "description", "")
+	err = h.createPlanEntry("my cool plan", "description", "", "")
 	require.NoError(t, err)
 
 	events, err := logger.Query(auditlog.QueryFilter{
@@ -584,3 +585,80 @@ func TestAuditHomeEmit_WaveStarted(t *testing.T) {
 	assert.Equal(t, 1, events[0].WaveNumber)
 	assert.Contains(t, events[0].Message, "wave 1")
 }
+
+func TestArchiveInstanceActivity_SurvivesRemoval(t *testing.T) {
+	logger, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+	defer logger.Close()
+
+	h := newTestHome()
+	h.auditLogger = logger
+	h.planStoreProject = "test"
+
+	inst, err := session.NewInstance(session.InstanceOptions{
+		Title:    "plan-W1-T1",
+		Path:     "/tmp",
+		Program:  "opencode",
+		PlanFile: "plan.md",
+	})
+	require.NoError(t, err)
+	inst.RecordActivity(&session.Activity{Action: "editing", Detail: "a.go", Path: "pkg/a.go", Timestamp: time.Now()})
+	h.allInstances = []*session.Instance{inst}
+
+	h.removeFromAllInstances(inst.Title)
+	assert.Empty(t, h.allInstances)
+
+	h.setArchivedActivity("plan.md", queryArchivedActivity(logger, "test", "plan.md"))
+	history := h.planActivityHistory("plan.md")
+	require.Len(t, history, 1)
+	assert.Equal(t, "plan-W1-T1", history[0].Instance)
+	assert.Equal(t, "pkg/a.go", history[0].Path)
+}
+
+// countingLogger counts the queries made against the logger it wraps: all
+// of them, and the archived-activity ones.
+type countingLogger struct {
+	auditlog.Logger
+	all     int
+	queries int
+}
+
+func (l *countingLogger) Query(f auditlog.QueryFilter) ([]auditlog.Event, error) {
+	l.all++
+	if len(f.Kinds) == 1 && f.Kinds[0] == auditlog.EventActivityArchived {
+		l.queries++
+	}
+	return l.Logger.Query(f)
+}
+
+func TestPlanActivityHistory_CachesArchivedActivity(t *testing.T) {
+	sqlite, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+	defer sqlite.Close()
+	logger := &countingLogger{Logger: sqlite}
+
+	h := newTestHome()
+	h.auditLogger = logger
+	h.planStoreProject = "test"
+
+	require.Empty(t, h.planActivityHistory("plan.md"))
+	assert.Zero(t, logger.all, "rendering the info pane never queries the audit log")
+	h.setArchivedActivity("plan.md", queryArchivedActivity(logger, "test", "plan.md"))
+
+	inst, err := session.NewInstance(session.InstanceOptions{
+		Title:    "plan-W1-T1",
+		Path:     "/tmp",
+		Program:  "opencode",
+		PlanFile: "plan.md",
+	})
+	require.NoError(t, err)
+	inst.RecordActivity(&session.Activity{Action: "editing", Detail: "a.go", Path: "pkg/a.go", Timestamp: time.Now()})
+	gen := h.archivedActivityGen
+	h.archiveInstanceActivity(inst)
+	assert.NotEqual(t, gen, h.archivedActivityGen, "a load started before the archive is discarded")
+
+	history := h.planActivityHistory("plan.md")
+	require.Len(t, history, 1, "archiving updates the cached history")
+	assert.Equal(t, "plan-W1-T1", history[0].Instance)
+	assert.Equal(t, 1, logger.queries, "archiving does not query again")
+}
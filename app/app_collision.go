@@ -0,0 +1,330 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kastheco/kasmos/config/planstate"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+	"github.com/kastheco/kasmos/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// collisionCheckInterval throttles cross-plan collision checks: each check
+// resolves every active branch head and re-diffs or test-merges whatever
+// changed since the last one.
+const collisionCheckInterval = 30 * time.Second
+
+// planCollision is a pair of active plans whose branches change the same files.
+type planCollision struct {
+	// planA and planB are plan files, planA sorting first.
+	planA, planB string
+	// files are changed on both branches; conflicts are those that would
+	// not merge cleanly.
+	files     []string
+	conflicts []string
+}
+
+// other returns the plan on the other side of the collision from planFile.
+func (c planCollision) other(planFile string) string {
+	if c.planA == planFile {
+		return c.planB
+	}
+	return c.planA
+}
+
+// key identifies the pair of plans.
+func (c planCollision) key() string {
+	return c.planA + "\x00" + c.planB
+}
+
+// collisionActive reports whether a plan's branch is still being worked on,
+// so other plans can collide with it.
+func collisionActive(entry planstate.PlanEntry) bool {
+	return entry.Branch != "" &&
+		(entry.Status == planstate.StatusImplementing || entry.Status == planstate.StatusReviewing)
+}
+
+// stackedTogether reports whether one plan is stacked (directly or through
+// other plans) on the other; such plans share files by design.
+func stackedTogether(ps *planstate.PlanState, a, b string) bool {
+	for _, pair := range [][2]string{{a, b}, {b, a}} {
+		seen := map[string]bool{}
+		for cur := ps.Plans[pair[0]].Parent; cur != "" && !seen[cur]; cur = ps.Plans[cur].Parent {
+			if cur == pair[1] {
+				return true
+			}
+			seen[cur] = true
+		}
+	}
+	return false
+}
+
+// collisionPlan is an active plan as seen by a collision check: its branch
+// and the branch it is compared against ("" for the default branch).
+type collisionPlan struct {
+	planFile, branch, base string
+}
+
+// collisionInput is a snapshot of the plan state for collectPlanCollisions,
+// taken on the event loop so the check can run in the background.
+type collisionInput struct {
+	// plans are the active plans, sorted by plan file.
+	plans []collisionPlan
+	// stacked holds the keys (planA\x00planB) of pairs stacked on each other.
+	stacked map[string]bool
+}
+
+// newCollisionInput snapshots the implementing and reviewing plans of ps.
+func newCollisionInput(ps *planstate.PlanState) collisionInput {
+	in := collisionInput{stacked: make(map[string]bool)}
+	for filename, entry := range ps.Plans {
+		if collisionActive(entry) {
+			in.plans = append(in.plans, collisionPlan{
+				planFile: filename,
+				branch:   entry.Branch,
+				base:     stackParentBranch(ps, filename),
+			})
+		}
+	}
+	sort.Slice(in.plans, func(i, j int) bool { return in.plans[i].planFile < in.plans[j].planFile })
+	for i, a := range in.plans {
+		for _, b := range in.plans[i+1:] {
+			if stackedTogether(ps, a.planFile, b.planFile) {
+				in.stacked[planCollision{planA: a.planFile, planB: b.planFile}.key()] = true
+			}
+		}
+	}
+	return in
+}
+
+// collisionCache memoizes the git work of a collision check by commit SHA,
+// so a check only re-diffs branches whose head (or base) moved and only
+// test-merges pairs where either head moved. Never mutated once built.
+type collisionCache struct {
+	// changed maps "head\x00base" to the files changed on head since base.
+	changed map[string][]string
+	// conflicts maps "headA\x00headB" to the files that would conflict.
+	conflicts map[string][]string
+}
+
+// planCollisionsMsg carries the result of a background collision check and
+// the cache to reuse for the next one.
+type planCollisionsMsg struct {
+	collisions []planCollision
+	cache      *collisionCache
+}
+
+// collectPlanCollisions finds pairs of active plans whose branches change
+// the same files, and which of those files would conflict if both landed.
+// Plans stacked on each other are not compared. Results for unchanged heads
+// come from prev (nil for none); the returned cache holds this check's
+// results only, so entries for old heads drop out. The collisions slice is
+// non-nil. Safe to call from a goroutine.
+func collectPlanCollisions(repoPath string, in collisionInput, prev *collisionCache) ([]planCollision, *collisionCache) {
+	collisions := make([]planCollision, 0)
+	cache := &collisionCache{changed: make(map[string][]string), conflicts: make(map[string][]string)}
+	if prev == nil {
+		prev = &collisionCache{}
+	}
+	defaultBase := gitpkg.DefaultBranch(repoPath)
+	if defaultBase == "" {
+		return collisions, cache
+	}
+
+	heads := make(map[string]string)
+	tip := func(branch string) string {
+		sha, ok := heads[branch]
+		if !ok {
+			sha, _ = gitpkg.BranchTip(repoPath, branch)
+			heads[branch] = sha
+		}
+		return sha
+	}
+
+	var active []collisionPlan
+	changed := make(map[string][]string)
+	for _, p := range in.plans {
+		base := p.base
+		if base == "" {
+			base = defaultBase
+		}
+		head, baseHead := tip(p.branch), tip(base)
+		if head == "" || baseHead == "" {
+			continue
+		}
+		key := head + "\x00" + baseHead
+		files, ok := prev.changed[key]
+		if !ok {
+			var err error
+			if files, err = gitpkg.ChangedFiles(repoPath, head, baseHead); err != nil {
+				continue
+			}
+		}
+		cache.changed[key] = files
+		if len(files) == 0 {
+			continue
+		}
+		active = append(active, p)
+		changed[p.planFile] = files
+	}
+
+	for i, a := range active {
+		for _, b := range active[i+1:] {
+			c := planCollision{planA: a.planFile, planB: b.planFile}
+			if in.stacked[c.key()] {
+				continue
+			}
+			c.files = gitpkg.IntersectFiles(changed[a.planFile], changed[b.planFile])
+			if len(c.files) == 0 {
+				continue
+			}
+			key := heads[a.branch] + "\x00" + heads[b.branch]
+			conflicts, ok := prev.conflicts[key]
+			if !ok {
+				var err error
+				if conflicts, err = gitpkg.MergeConflicts(repoPath, heads[a.branch], heads[b.branch]); err != nil {
+					collisions = append(collisions, c)
+					continue
+				}
+			}
+			cache.conflicts[key] = conflicts
+			c.conflicts = conflicts
+			collisions = append(collisions, c)
+		}
+	}
+	return collisions, cache
+}
+
+// pollPlanCollisions starts a background collision check when
+// collisionCheckInterval has passed and no check is in flight. The result
+// comes back as planCollisionsMsg.
+func (m *home) pollPlanCollisions() tea.Cmd {
+	if m.collisionCheckInFlight || m.planState == nil || time.Since(m.lastCollisionCheck) < collisionCheckInterval {
+		return nil
+	}
+	m.lastCollisionCheck = time.Now()
+	m.collisionCheckInFlight = true
+	in, prev, repoPath := newCollisionInput(m.planState), m.collisionCache, m.activeRepoPath
+	return func() tea.Msg {
+		collisions, cache := collectPlanCollisions(repoPath, in, prev)
+		return planCollisionsMsg{collisions: collisions, cache: cache}
+	}
+}
+
+// handlePlanCollisions applies a finished collision check.
+func (m *home) handlePlanCollisions(msg planCollisionsMsg) tea.Cmd {
+	m.collisionCheckInFlight = false
+	m.collisionCache = msg.cache
+	cmd := m.applyPlanCollisions(msg.collisions)
+	m.updateSidebarPlans()
+	return cmd
+}
+
+// applyPlanCollisions stores a fresh collision check and warns once about
+// each pair of plans that has started to conflict.
+func (m *home) applyPlanCollisions(collisions []planCollision) tea.Cmd {
+	m.planCollisions = collisions
+	conflicting := make(map[string]bool)
+	var warnings []string
+	for _, c := range collisions {
+		if len(c.conflicts) == 0 {
+			continue
+		}
+		conflicting[c.key()] = true
+		if m.warnedCollisions[c.key()] {
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("%s and %s will conflict in %s",
+			planstate.DisplayName(c.planA), planstate.DisplayName(c.planB), summarizeFiles(c.conflicts, 3)))
+	}
+	// Pairs that stopped conflicting are warned about again if they regress.
+	m.warnedCollisions = conflicting
+	if len(warnings) == 0 {
+		return nil
+	}
+	for _, w := range warnings {
+		m.toastManager.Warning(w)
+	}
+	return m.toastTickCmd()
+}
+
+// planCollisionsFor returns the collisions involving planFile.
+func (m *home) planCollisionsFor(planFile string) []planCollision {
+	var out []planCollision
+	for _, c := range m.planCollisions {
+		if c.planA == planFile || c.planB == planFile {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// collisionBadge summarizes planFile's collisions for the sidebar.
+func (m *home) collisionBadge(planFile string) (collides int, conflicts bool) {
+	for _, c := range m.planCollisionsFor(planFile) {
+		collides++
+		conflicts = conflicts || len(c.conflicts) > 0
+	}
+	return collides, conflicts
+}
+
+// collisionSummary describes planFile's collisions for the info pane,
+// e.g. "billing (conflicts in 1 file), search (2 shared files)".
+func (m *home) collisionSummary(planFile string) string {
+	var parts []string
+	for _, c := range m.planCollisionsFor(planFile) {
+		name := planstate.DisplayName(c.other(planFile))
+		if len(c.conflicts) > 0 {
+			parts = append(parts, fmt.Sprintf("%s (conflicts in %s)", name, pluralFiles(len(c.conflicts))))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s (%s shared)", name, pluralFiles(len(c.files))))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func pluralFiles(n int) string {
+	if n == 1 {
+		return "1 file"
+	}
+	return fmt.Sprintf("%d files", n)
+}
+
+// viewPlanCollisions lists the files planFile shares with other active plans
+// in the preview tab, marking those that will conflict.
+func (m *home) viewPlanCollisions(planFile string) (tea.Model, tea.Cmd) {
+	collisions := m.planCollisionsFor(planFile)
+	if len(collisions) == 0 {
+		m.toastManager.Info("no file collisions with other active plans")
+		return m, m.toastTickCmd()
+	}
+	m.tabbedWindow.SetActiveTab(ui.PreviewTab)
+	m.tabbedWindow.SetDocumentContent(renderPlanCollisions(planFile, collisions))
+	return m, nil
+}
+
+// renderPlanCollisions formats planFile's collisions as plain text.
+func renderPlanCollisions(planFile string, collisions []planCollision) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "file collisions for %s\n", planstate.DisplayName(planFile))
+	for _, c := range collisions {
+		conflicts := make(map[string]bool, len(c.conflicts))
+		for _, f := range c.conflicts {
+			conflicts[f] = true
+		}
+		fmt.Fprintf(&b, "\nwith %s — %s shared, %d conflicting\n",
+			planstate.DisplayName(c.other(planFile)), pluralFiles(len(c.files)), len(c.conflicts))
+		for _, f := range c.files {
+			mark := " "
+			if conflicts[f] {
+				mark = "✕"
+			}
+			fmt.Fprintf(&b, "  %s %s\n", mark, f)
+		}
+	}
+	return b.String()
+}
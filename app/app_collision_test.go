@@ -0,0 +1,134 @@
+package app
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kastheco/kasmos/config/planstate"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupCollisionRepo extends setupDriftRepo with three implementing plans:
+// auth and billing both edit shared.go differently, docs only touches its
+// own file.
+func setupCollisionRepo(t *testing.T) (string, *planstate.PlanState) {
+	t.Helper()
+	dir, ps, auth := setupDriftRepo(t)
+	git := func(args ...string) {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoErrorf(t, err, "git %v: %s", args, out)
+	}
+	commit := func(branch, file, content string) {
+		tree := gitpkg.PlanWorktreePath(dir, branch)
+		if _, err := os.Stat(tree); err != nil {
+			git("-C", dir, "worktree", "add", tree, branch)
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(tree, file), []byte(content), 0o644))
+		git("-C", tree, "add", file)
+		git("-C", tree, "commit", "-m", "edit "+file)
+	}
+
+	require.NoError(t, ps.Register("2026-03-02-billing.md", "billing", "plan/billing", time.Now()))
+	require.NoError(t, ps.Register("2026-03-03-docs.md", "docs", "plan/docs", time.Now()))
+	for _, p := range []string{auth, "2026-03-02-billing.md", "2026-03-03-docs.md"} {
+		seedPlanStatus(t, ps, p, planstate.StatusImplementing)
+	}
+	git("-C", dir, "branch", "plan/billing")
+	git("-C", dir, "branch", "plan/docs")
+	commit("plan/auth", "shared.go", "package shared // auth\n")
+	commit("plan/auth", "auth.go", "package auth\n")
+	commit("plan/billing", "shared.go", "package shared // billing\n")
+	commit("plan/docs", "README", "docs\n")
+	return dir, ps
+}
+
+func TestCollectPlanCollisions(t *testing.T) {
+	dir, ps := setupCollisionRepo(t)
+
+	collisions, _ := collectPlanCollisions(dir, newCollisionInput(ps), nil)
+	require.Len(t, collisions, 1, "docs collides with nobody")
+	c := collisions[0]
+	assert.Equal(t, "2026-03-01-auth.md", c.planA)
+	assert.Equal(t, "2026-03-02-billing.md", c.planB)
+	assert.Equal(t, []string{"shared.go"}, c.files)
+	assert.Equal(t, []string{"shared.go"}, c.conflicts)
+
+	require.NoError(t, ps.SetParent("2026-03-02-billing.md", "2026-03-01-auth.md"))
+	collisions, _ = collectPlanCollisions(dir, newCollisionInput(ps), nil)
+	assert.Empty(t, collisions, "stacked plans share files by design")
+}
+
+func TestApplyPlanCollisions_WarnsOnce(t *testing.T) {
+	dir, ps := setupCollisionRepo(t)
+	m := newTestHomeWithToast()
+	m.planState = ps
+	m.activeRepoPath = dir
+
+	collisions, _ := collectPlanCollisions(dir, newCollisionInput(ps), nil)
+	assert.NotNil(t, m.applyPlanCollisions(collisions), "a new conflict is toasted")
+	assert.Nil(t, m.applyPlanCollisions(collisions), "and only once")
+
+	collides, conflicts := m.collisionBadge("2026-03-02-billing.md")
+	assert.Equal(t, 1, collides)
+	assert.True(t, conflicts)
+	assert.Equal(t, "auth (conflicts in 1 file)", m.collisionSummary("2026-03-02-billing.md"))
+	assert.Empty(t, m.collisionSummary("2026-03-03-docs.md"))
+	assert.Contains(t, renderPlanCollisions("2026-03-01-auth.md", m.planCollisionsFor("2026-03-01-auth.md")),
+		"with billing — 1 file shared, 1 conflicting\n  ✕ shared.go")
+
+	assert.Nil(t, m.applyPlanCollisions(nil))
+	assert.NotNil(t, m.applyPlanCollisions(collisions), "a conflict that comes back is toasted again")
+}
+
+func TestCollectPlanCollisions_CachedByHeads(t *testing.T) {
+	dir, ps := setupCollisionRepo(t)
+	in := newCollisionInput(ps)
+
+	first, cache := collectPlanCollisions(dir, in, nil)
+	require.Len(t, first, 1)
+	require.Len(t, cache.conflicts, 1)
+
+	// Poison the cached merge result: an unchanged pair of heads must reuse
+	// it instead of test-merging again.
+	for key := range cache.conflicts {
+		cache.conflicts[key] = []string{"cached.go"}
+	}
+	second, cache := collectPlanCollisions(dir, in, cache)
+	require.Len(t, second, 1)
+	assert.Equal(t, []string{"cached.go"}, second[0].conflicts)
+
+	// Moving a head invalidates the pair.
+	tree := gitpkg.PlanWorktreePath(dir, "plan/billing")
+	require.NoError(t, os.WriteFile(filepath.Join(tree, "more.go"), []byte("package more\n"), 0o644))
+	for _, args := range [][]string{{"add", "more.go"}, {"commit", "-m", "more"}} {
+		out, err := exec.Command("git", append([]string{"-C", tree}, args...)...).CombinedOutput()
+		require.NoErrorf(t, err, "%s", out)
+	}
+	third, _ := collectPlanCollisions(dir, in, cache)
+	require.Len(t, third, 1)
+	assert.Equal(t, []string{"shared.go"}, third[0].conflicts)
+}
+
+func TestPollPlanCollisions_ThrottledAndSingleFlight(t *testing.T) {
+	dir, ps := setupCollisionRepo(t)
+	m := newTestHomeWithToast()
+	m.planState = ps
+	m.activeRepoPath = dir
+
+	cmd := m.pollPlanCollisions()
+	require.NotNil(t, cmd)
+	assert.Nil(t, m.pollPlanCollisions(), "one check at a time")
+
+	msg, ok := cmd().(planCollisionsMsg)
+	require.True(t, ok)
+	m.handlePlanCollisions(msg)
+	assert.False(t, m.collisionCheckInFlight)
+	assert.NotNil(t, m.collisionCache)
+	assert.Len(t, m.planCollisions, 1)
+	assert.Nil(t, m.pollPlanCollisions(), "throttled until collisionCheckInterval passes")
+}
@@ -0,0 +1,337 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/control"
+	"github.com/kastheco/kasmos/internal/mcpserver"
+	"github.com/kastheco/kasmos/log"
+	"github.com/kastheco/kasmos/session"
+	"github.com/kastheco/kasmos/ui/overlay"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// controlCallTimeout bounds how long a control API request waits for the
+// event loop. Pausing an instance commits and removes its worktree, so this
+// is generous.
+const controlCallTimeout = 30 * time.Second
+
+// controlFunc runs on the bubbletea event loop with exclusive access to the
+// model. It returns the API result, an optional Cmd for the program, and an
+// error that becomes the HTTP status.
+type controlFunc func(m *home) (any, tea.Cmd, error)
+
+// controlRequestMsg carries a control API call into Update.
+type controlRequestMsg struct {
+	fn    controlFunc
+	reply chan controlReply
+}
+
+type controlReply struct {
+	value any
+	err   error
+}
+
+// controlBackend implements control.Backend by marshalling every call onto the
+// event loop, so the API never races with the TUI over instance state.
+type controlBackend struct {
+	send func(tea.Msg)
+}
+
+// startControlServer serves the control API for the active repo. Failure is
+// not fatal: the TUI works without it (e.g. a second TUI on the same repo).
+func startControlServer(h *home, p *tea.Program) *control.Server {
+	path, err := control.SocketPath(h.activeRepoPath)
+	if err != nil {
+		log.WarningLog.Printf("control api disabled: %v", err)
+		return nil
+	}
+	backend := &controlBackend{send: p.Send}
+	mcp := mcpserver.NewServer(&mcpBackend{controlBackend: backend}, "")
+	srv, err := control.Listen(path, backend, control.WithMCP(mcpserver.HTTPHandler(mcp.Handle)))
+	if err != nil {
+		log.WarningLog.Printf("control api disabled: %v", err)
+		return nil
+	}
+	h.control = srv
+	return srv
+}
+
+func (b *controlBackend) call(fn controlFunc) (any, error) {
+	reply := make(chan controlReply, 1)
+	b.send(controlRequestMsg{fn: fn, reply: reply})
+	select {
+	case r := <-reply:
+		return r.value, r.err
+	case <-time.After(controlCallTimeout):
+		return nil, fmt.Errorf("kasmos did not respond within %s", controlCallTimeout)
+	}
+}
+
+func (b *controlBackend) ListInstances() ([]control.Instance, error) {
+	v, err := b.call(func(m *home) (any, tea.Cmd, error) {
+		instances := m.controlInstances()
+		out := make([]control.Instance, 0, len(instances))
+		for _, inst := range instances {
+			out = append(out, m.controlInstance(inst))
+		}
+		return out, nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]control.Instance), nil
+}
+
+func (b *controlBackend) ListPlans() ([]control.Plan, error) {
+	v, err := b.call(func(m *home) (any, tea.Cmd, error) {
+		out := []control.Plan{}
+		if m.planState == nil {
+			return out, nil, nil
+		}
+		for filename, entry := range m.planState.Plans {
+			out = append(out, control.Plan{
+				Filename:    filename,
+				Status:      string(entry.Status),
+				Description: entry.Description,
+				Branch:      entry.Branch,
+				Topic:       entry.Topic,
+			})
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].Filename < out[j].Filename })
+		return out, nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]control.Plan), nil
+}
+
+func (b *controlBackend) SendPrompt(title, prompt string) error {
+	v, err := b.call(func(m *home) (any, tea.Cmd, error) {
+		inst, err := m.controlLookup(title)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !inst.Started() || inst.Paused() || inst.Exited {
+			return nil, nil, fmt.Errorf("%w: instance %q is not running", control.ErrInvalid, title)
+		}
+		return inst, nil, nil
+	})
+	if err != nil {
+		return err
+	}
+	// SendPrompt sleeps between tmux calls — keep it off the event loop.
+	inst := v.(*session.Instance)
+	if err := inst.SendPrompt(prompt); err != nil {
+		return err
+	}
+	_, err = b.call(func(m *home) (any, tea.Cmd, error) {
+		inst.SetStatus(session.Running)
+		auditMsg := prompt
+		if len(auditMsg) > 200 {
+			auditMsg = auditMsg[:200]
+		}
+		m.audit(auditlog.EventPromptSent, auditMsg, auditlog.WithInstance(inst.Title))
+		return nil, nil, nil
+	})
+	return err
+}
+
+func (b *controlBackend) AnswerPermission(title, choice string) error {
+	var answer overlay.PermissionChoice
+	switch choice {
+	case control.PermissionAllowOnce:
+		answer = overlay.PermissionAllowOnce
+	case control.PermissionAllowAlways:
+		answer = overlay.PermissionAllowAlways
+	case control.PermissionReject:
+		answer = overlay.PermissionReject
+	default:
+		return fmt.Errorf("%w: unknown permission choice %q", control.ErrInvalid, choice)
+	}
+	_, err := b.call(func(m *home) (any, tea.Cmd, error) {
+		inst, err := m.controlLookup(title)
+		if err != nil {
+			return nil, nil, err
+		}
+		pp := m.permissionPrompts[inst]
+		if _, handled := m.permissionHandled[inst]; pp == nil || handled {
+			return nil, nil, fmt.Errorf("%w: instance %q has no pending permission prompt", control.ErrInvalid, title)
+		}
+		// Answered remotely while the modal is open — dismiss it.
+		if m.pendingPermissionInstance == inst {
+			m.permissionOverlay = nil
+			m.pendingPermissionInstance = nil
+			m.state = stateDefault
+		}
+		return nil, m.answerPermission(inst, answer, pp.Pattern, pp.Description), nil
+	})
+	return err
+}
+
+func (b *controlBackend) InstanceAction(title, action string) error {
+	_, err := b.call(func(m *home) (any, tea.Cmd, error) {
+		inst, err := m.controlLookup(title)
+		if err != nil {
+			return nil, nil, err
+		}
+		switch action {
+		case control.ActionKill:
+			m.killInstance(inst)
+		case control.ActionPause:
+			if inst.Paused() {
+				return nil, nil, fmt.Errorf("%w: instance %q is already paused", control.ErrInvalid, title)
+			}
+			if err := inst.Pause(); err != nil {
+				return nil, nil, err
+			}
+			m.audit(auditlog.EventAgentPaused, "agent paused",
+				auditlog.WithInstance(inst.Title),
+				auditlog.WithAgent(inst.AgentType),
+				auditlog.WithPlan(inst.PlanFile),
+			)
+		case control.ActionResume:
+			if !inst.Paused() {
+				return nil, nil, fmt.Errorf("%w: instance %q is not paused", control.ErrInvalid, title)
+			}
+			if err := inst.Resume(); err != nil {
+				return nil, nil, err
+			}
+			m.audit(auditlog.EventAgentResumed, "agent resumed",
+				auditlog.WithInstance(inst.Title),
+				auditlog.WithAgent(inst.AgentType),
+				auditlog.WithPlan(inst.PlanFile),
+			)
+		default:
+			return nil, nil, fmt.Errorf("%w: unknown action %q", control.ErrInvalid, action)
+		}
+		m.saveAllInstances()
+		m.updateNavPanelStatus()
+		return nil, tea.Batch(tea.WindowSize(), m.instanceChanged()), nil
+	})
+	return err
+}
+
+func (b *controlBackend) TriggerPlanStage(planFile, stage string) error {
+	_, err := b.call(func(m *home) (any, tea.Cmd, error) {
+		if m.planState == nil {
+			return nil, nil, fmt.Errorf("%w: no plan state loaded", control.ErrInvalid)
+		}
+		entry, ok := m.planState.Plans[planFile]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: plan %q", control.ErrNotFound, planFile)
+		}
+		// triggerPlanStage reports problems as toasts; check the ones a
+		// remote caller needs to hear about up front.
+		switch stage {
+		case "plan", "solo", "implement", "review", "finished":
+		default:
+			return nil, nil, fmt.Errorf("%w: unknown stage %q", control.ErrInvalid, stage)
+		}
+		if isLocked(entry.Status, stage) {
+			return nil, nil, fmt.Errorf("%w: stage %q is locked while plan is %s", control.ErrInvalid, stage, entry.Status)
+		}
+		_, cmd := m.triggerPlanStage(planFile, stage)
+		return nil, cmd, nil
+	})
+	return err
+}
+
+// controlInstances returns every instance the API exposes: the master list
+// plus instances still starting, which are only in the nav panel.
+func (m *home) controlInstances() []*session.Instance {
+	seen := make(map[*session.Instance]bool, len(m.allInstances))
+	instances := make([]*session.Instance, 0, len(m.allInstances))
+	for _, inst := range m.allInstances {
+		seen[inst] = true
+		instances = append(instances, inst)
+	}
+	for _, inst := range m.nav.GetInstances() {
+		if !seen[inst] {
+			instances = append(instances, inst)
+		}
+	}
+	return instances
+}
+
+func (m *home) controlLookup(title string) (*session.Instance, error) {
+	for _, inst := range m.controlInstances() {
+		if inst.Title == title {
+			return inst, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: instance %q", control.ErrNotFound, title)
+}
+
+func (m *home) controlInstance(inst *session.Instance) control.Instance {
+	_, handled := m.permissionHandled[inst]
+	return control.Instance{
+		Title:             inst.Title,
+		Status:            controlStatus(inst),
+		Program:           inst.Program,
+		Branch:            inst.Branch,
+		PlanFile:          inst.PlanFile,
+		AgentType:         inst.AgentType,
+		Wave:              inst.WaveNumber,
+		Task:              inst.TaskNumber,
+		AwaitingInput:     inst.PromptDetected,
+		PermissionPending: m.permissionPrompts[inst] != nil && !handled,
+		Exited:            inst.Exited,
+	}
+}
+
+// controlStatus names an instance's status for API consumers.
+func controlStatus(inst *session.Instance) string {
+	if inst.Exited {
+		return "exited"
+	}
+	return statusString(inst.Status)
+}
+
+// trackPermissionPrompt remembers the prompt currently shown by inst so it can
+// be answered through the control API even when the modal is not open.
+func (m *home) trackPermissionPrompt(inst *session.Instance, pp *session.PermissionPrompt) {
+	if pp == nil {
+		delete(m.permissionPrompts, inst)
+		return
+	}
+	if m.permissionPrompts == nil {
+		m.permissionPrompts = make(map[*session.Instance]*session.PermissionPrompt)
+	}
+	m.permissionPrompts[inst] = pp
+}
+
+// publishControlEvents diffs instance statuses against the last published
+// snapshot and streams the changes to control API watchers.
+func (m *home) publishControlEvents() {
+	if m.control == nil {
+		return
+	}
+	if m.controlStatuses == nil {
+		m.controlStatuses = make(map[string]string)
+	}
+	current := make(map[string]bool)
+	for _, inst := range m.controlInstances() {
+		current[inst.Title] = true
+		status := controlStatus(inst)
+		prev, known := m.controlStatuses[inst.Title]
+		switch {
+		case !known:
+			m.control.Publish(control.Event{Type: control.EventInstanceAdded, Instance: inst.Title, Status: status})
+		case prev != status:
+			m.control.Publish(control.Event{Type: control.EventInstanceStatus, Instance: inst.Title, Status: status, Previous: prev})
+		}
+		m.controlStatuses[inst.Title] = status
+	}
+	for title, prev := range m.controlStatuses {
+		if !current[title] {
+			m.control.Publish(control.Event{Type: control.EventInstanceRemoved, Instance: title, Previous: prev})
+			delete(m.controlStatuses, title)
+		}
+	}
+}
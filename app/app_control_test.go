@@ -0,0 +1,134 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/control"
+	"github.com/kastheco/kasmos/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestControlBackend wires a backend straight into m.Update, standing in
+// for tea.Program.Send.
+func newTestControlBackend(m *home) *controlBackend {
+	return &controlBackend{send: func(msg tea.Msg) { m.Update(msg) }}
+}
+
+func TestControl_ListInstancesReportsStatusAndPermission(t *testing.T) {
+	m := newTestHomeWithCache(t)
+	inst := &session.Instance{Title: "auth-implement", Program: "opencode", PlanFile: "auth.md", AgentType: session.AgentTypeCoder}
+	inst.MarkStartedForTest()
+	m.nav.AddInstance(inst)()
+	m.allInstances = append(m.allInstances, inst)
+
+	_, _ = m.Update(metadataResultMsg{Results: []instanceMetadata{
+		{Title: "auth-implement", PermissionPrompt: &session.PermissionPrompt{Pattern: "/opt/*", Description: "Access /opt"}},
+	}})
+
+	instances, err := newTestControlBackend(m).ListInstances()
+	require.NoError(t, err)
+	require.Len(t, instances, 1)
+	assert.Equal(t, "auth-implement", instances[0].Title)
+	assert.Equal(t, "running", instances[0].Status)
+	assert.Equal(t, "auth.md", instances[0].PlanFile)
+	assert.True(t, instances[0].PermissionPending)
+}
+
+func TestControl_AnswerPermissionDismissesModalAndCaches(t *testing.T) {
+	m := newTestHomeWithCache(t)
+	inst := &session.Instance{Title: "test-agent", Program: "opencode"}
+	inst.MarkStartedForTest()
+	m.nav.AddInstance(inst)()
+
+	_, _ = m.Update(metadataResultMsg{Results: []instanceMetadata{
+		{Title: "test-agent", PermissionPrompt: &session.PermissionPrompt{Pattern: "/opt/*", Description: "Access /opt"}},
+	}})
+	require.Equal(t, statePermission, m.state)
+
+	b := newTestControlBackend(m)
+	require.NoError(t, b.AnswerPermission("test-agent", control.PermissionAllowAlways))
+
+	assert.Equal(t, stateDefault, m.state)
+	assert.Nil(t, m.permissionOverlay)
+	assert.Nil(t, m.pendingPermissionInstance)
+	assert.Contains(t, m.permissionHandled, inst)
+	assert.True(t, m.permissionStore.IsAllowedAlways(m.activeProject(), "/opt/*"))
+
+	assert.ErrorIs(t, b.AnswerPermission("test-agent", control.PermissionAllowOnce), control.ErrInvalid,
+		"a prompt can only be answered once")
+	assert.ErrorIs(t, b.AnswerPermission("missing", control.PermissionReject), control.ErrNotFound)
+}
+
+func TestControl_TriggerPlanStageValidatesBeforeDispatch(t *testing.T) {
+	m := newTestHome()
+	m.setupPlanState(t, "auth.md", planstate.StatusImplementing, "")
+	b := newTestControlBackend(m)
+
+	assert.ErrorIs(t, b.TriggerPlanStage("missing.md", "implement"), control.ErrNotFound)
+	assert.ErrorIs(t, b.TriggerPlanStage("auth.md", "finished"), control.ErrInvalid, "finished is locked until review")
+	assert.ErrorIs(t, b.TriggerPlanStage("auth.md", "deploy"), control.ErrInvalid)
+}
+
+func TestControl_PublishesStatusTransitions(t *testing.T) {
+	m := newTestHomeWithCache(t)
+	inst := &session.Instance{Title: "docs", Program: "opencode"}
+	inst.MarkStartedForTest()
+	inst.SetStatus(session.Running)
+	m.nav.AddInstance(inst)()
+
+	dir, err := os.MkdirTemp("", "kasctl")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	srv, err := control.Listen(filepath.Join(dir, "c.sock"), newTestControlBackend(m))
+	require.NoError(t, err)
+	t.Cleanup(func() { srv.Close() })
+	m.control = srv
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events := make(chan control.Event, 64)
+	go control.NewClient(srv.Path()).Watch(ctx, func(e control.Event) error {
+		events <- e
+		return nil
+	})
+
+	// Re-publish the "added" event until the watcher is subscribed.
+	require.Eventually(t, func() bool {
+		m.controlStatuses = nil
+		m.publishControlEvents()
+		select {
+		case e := <-events:
+			return e.Type == control.EventInstanceAdded && e.Instance == "docs"
+		default:
+			return false
+		}
+	}, 4*time.Second, 20*time.Millisecond)
+
+	inst.SetStatus(session.Ready)
+	m.publishControlEvents()
+	m.nav.RemoveByTitle("docs")
+	m.publishControlEvents()
+
+	var got []control.Event
+	for len(got) < 2 {
+		select {
+		case e := <-events:
+			if e.Type != control.EventInstanceAdded {
+				got = append(got, e)
+			}
+		case <-ctx.Done():
+			t.Fatalf("missing events, got %+v", got)
+		}
+	}
+	assert.Equal(t, control.EventInstanceStatus, got[0].Type)
+	assert.Equal(t, "running", got[0].Previous)
+	assert.Equal(t, "ready", got[0].Status)
+	assert.Equal(t, control.EventInstanceRemoved, got[1].Type)
+}
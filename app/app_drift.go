@@ -0,0 +1,146 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/config/planstate"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// driftCheckInterval throttles plan branch drift checks in the metadata tick.
+const driftCheckInterval = 10 * time.Second
+
+// planRebasedMsg reports the outcome of rebasing a plan branch onto its base.
+type planRebasedMsg struct {
+	planFile string
+	base     string
+	newBase  string
+	err      error
+	// restack is set for automatic restacks of stacked plans; unstack also
+	// clears the plan's parent once its commits are on the default branch.
+	restack bool
+	unstack bool
+}
+
+// collectPlanDrift computes how far each active plan branch has drifted from
+// the repository's default branch, or from its parent's branch for stacked
+// plans. Plans whose branch does not exist yet are skipped. Safe to call from
+// a goroutine.
+func collectPlanDrift(repoPath string, ps *planstate.PlanState) map[string]gitpkg.BranchDrift {
+	drift := make(map[string]gitpkg.BranchDrift)
+	defaultBase := gitpkg.DefaultBranch(repoPath)
+	if defaultBase == "" {
+		return drift
+	}
+	for filename, entry := range ps.Plans {
+		if entry.Branch == "" || entry.Status == planstate.StatusDone || entry.Status == planstate.StatusCancelled {
+			continue
+		}
+		base := stackParentBranch(ps, filename)
+		if base == "" {
+			base = defaultBase
+		}
+		if d, err := gitpkg.PlanBranchDrift(repoPath, entry.Branch, base); err == nil {
+			drift[filename] = d
+		}
+	}
+	return drift
+}
+
+// rebasePlan rebases the plan branch onto the default branch in its worktree,
+// or onto its parent's branch for a stacked plan. Refused while any of the
+// plan's agents is running, since rewriting history under a live agent loses
+// its work.
+func (m *home) rebasePlan(planFile string) tea.Cmd {
+	if m.planState == nil {
+		return nil
+	}
+	entry, ok := m.planState.Entry(planFile)
+	if !ok {
+		return m.handleError(fmt.Errorf("plan not found: %s", planFile))
+	}
+	if entry.Branch == "" {
+		return m.handleError(fmt.Errorf("plan has no branch to rebase"))
+	}
+	if inst := m.livePlanAgent(planFile); inst != nil {
+		return m.handleError(fmt.Errorf("stop or pause %s before rebasing", inst.Title))
+	}
+	repoPath := m.activeRepoPath
+	branch := entry.Branch
+	parentBranch := stackParentBranch(m.planState, planFile)
+	return func() tea.Msg {
+		base := parentBranch
+		if base == "" {
+			base = gitpkg.DefaultBranch(repoPath)
+		}
+		newBase, err := gitpkg.RebasePlanBranch(repoPath, branch, base)
+		return planRebasedMsg{planFile: planFile, base: base, newBase: newBase, err: err}
+	}
+}
+
+// handlePlanRebased applies a finished rebase: the plan's instances diff
+// against the new base from now on, and conflicts are reported file by file.
+func (m *home) handlePlanRebased(msg planRebasedMsg) tea.Cmd {
+	planName := planstate.DisplayName(msg.planFile)
+	delete(m.restacking, msg.planFile)
+	if msg.err != nil && msg.restack {
+		// Don't retry on every tick; the user rebases by hand from here.
+		if m.restackConflicts == nil {
+			m.restackConflicts = make(map[string]bool)
+		}
+		m.restackConflicts[msg.planFile] = true
+	}
+	if conflict, ok := gitpkg.IsMergeConflict(msg.err); ok {
+		m.toastManager.Error(fmt.Sprintf("rebase aborted — conflicts in %s", summarizeFiles(conflict.Files, 3)))
+		return tea.Batch(m.toastTickCmd(), m.confirmAction(
+			fmt.Sprintf("rebase of '%s' onto %s conflicts in %d file(s): %s\n\nspawn a fixer agent in the plan worktree?",
+				planName, msg.base, len(conflict.Files), summarizeFiles(conflict.Files, 5)),
+			func() tea.Msg { return mergeFixerMsg{planFile: msg.planFile, conflict: conflict} },
+		))
+	}
+	if msg.err != nil {
+		return m.handleError(msg.err)
+	}
+	delete(m.restackConflicts, msg.planFile)
+	if msg.unstack && m.planState != nil {
+		if err := m.planState.SetParent(msg.planFile, ""); err != nil {
+			return m.handleError(err)
+		}
+	}
+	if msg.newBase == "" {
+		// The branch didn't exist yet, so there was nothing to move.
+		m.updateSidebarPlans()
+		return nil
+	}
+	for _, inst := range m.allInstances {
+		if inst.PlanFile == msg.planFile {
+			inst.SetBaseCommitSHA(msg.newBase)
+		}
+	}
+	m.saveAllInstances()
+	if d, ok := m.planDrift[msg.planFile]; ok {
+		d.Behind = 0
+		m.planDrift[msg.planFile] = d
+	}
+	m.lastDriftCheck = time.Time{} // refresh ahead count on the next tick
+	verb := "rebased"
+	if msg.restack {
+		verb = "restacked"
+	}
+	m.audit(auditlog.EventPlanRebased, fmt.Sprintf("plan %s onto %s: %s", verb, msg.base, planName),
+		auditlog.WithPlan(msg.planFile), auditlog.WithDetail(msg.newBase))
+	m.toastManager.Success(fmt.Sprintf("%s %s onto %s", verb, planName, msg.base))
+	m.updateSidebarPlans()
+	m.updateInfoPane()
+	return tea.Batch(m.toastTickCmd(), m.instanceChanged())
+}
+
+// planDriftFor returns the drift of planFile's branch, if known.
+func (m *home) planDriftFor(planFile string) (gitpkg.BranchDrift, bool) {
+	d, ok := m.planDrift[planFile]
+	return d, ok
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/session"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupDriftRepo creates a repo whose plan branch is one commit behind the
+// default branch, and a plan state that registers it.
+func setupDriftRepo(t *testing.T) (string, *planstate.PlanState, string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init"},
+		{"config", "user.email", "test@example.com"},
+		{"config", "user.name", "test"},
+		{"commit", "--allow-empty", "-m", "initial"},
+		{"branch", "plan/auth"},
+		{"commit", "--allow-empty", "-m", "main moves on"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		if err != nil {
+			t.Skipf("git setup failed (%v): %s", err, out)
+		}
+	}
+	plansDir := filepath.Join(dir, "docs", "plans")
+	require.NoError(t, os.MkdirAll(plansDir, 0o755))
+	ps, err := newTestPlanState(t, plansDir)
+	require.NoError(t, err)
+	planFile := "2026-03-01-auth.md"
+	require.NoError(t, ps.Register(planFile, "auth", "plan/auth", time.Now()))
+	return dir, ps, planFile
+}
+
+func TestCollectPlanDrift(t *testing.T) {
+	dir, ps, planFile := setupDriftRepo(t)
+	require.NoError(t, ps.Register("2026-03-02-unborn.md", "no branch yet", "plan/unborn", time.Now()))
+
+	drift := collectPlanDrift(dir, ps)
+	require.Contains(t, drift, planFile)
+	assert.Equal(t, 1, drift[planFile].Behind)
+	assert.Equal(t, 0, drift[planFile].Ahead)
+	assert.NotContains(t, drift, "2026-03-02-unborn.md", "missing branches are skipped")
+}
+
+func TestRebasePlan_RefusedWhileAgentRuns(t *testing.T) {
+	dir, ps, planFile := setupDriftRepo(t)
+	m := newTestHomeWithToast()
+	m.planState = ps
+	m.activeRepoPath = dir
+
+	inst := &session.Instance{Title: "auth-implement", PlanFile: planFile, AgentType: session.AgentTypeCoder}
+	inst.MarkStartedForTest()
+	m.nav.AddInstance(inst)()
+
+	cmd := m.rebasePlan(planFile)
+	require.NotNil(t, cmd)
+	_, isRebase := cmd().(planRebasedMsg)
+	assert.False(t, isRebase, "must not rebase under a running agent")
+}
+
+func TestRebasePlan_ClearsBehindCount(t *testing.T) {
+	dir, ps, planFile := setupDriftRepo(t)
+	m := newTestHomeWithToast()
+	m.planState = ps
+	m.activeRepoPath = dir
+	m.planDrift = collectPlanDrift(dir, ps)
+	require.Equal(t, 1, m.planDrift[planFile].Behind)
+
+	msg, ok := m.rebasePlan(planFile)().(planRebasedMsg)
+	require.True(t, ok)
+	require.NoError(t, msg.err)
+	_, _ = m.Update(msg)
+
+	assert.Equal(t, 0, m.planDrift[planFile].Behind)
+	d, err := gitpkg.PlanBranchDrift(dir, "plan/auth", msg.base)
+	require.NoError(t, err)
+	assert.Equal(t, 0, d.Behind)
+}
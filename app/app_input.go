@@ -8,6 +8,7 @@ import (
 	"github.com/kastheco/kasmos/keys"
 	"github.com/kastheco/kasmos/log"
 	"github.com/kastheco/kasmos/session"
+	gitpkg "github.com/kastheco/kasmos/session/git"
 	"github.com/kastheco/kasmos/session/tmux"
 	"github.com/kastheco/kasmos/ui"
 	"github.com/kastheco/kasmos/ui/overlay"
@@ -26,7 +27,7 @@ func (m *home) handleMenuHighlighting(msg tea.KeyMsg) (cmd tea.Cmd, returnEarly
 		m.keySent = false
 		return nil, false
 	}
-	if m.state == statePrompt || m.state == stateHelp || m.state == stateConfirm || m.state == stateNewPlan || m.state == stateNewPlanDeriving || m.state == stateNewPlanTopic || m.state == stateSpawnAgent || m.state == stateSearch || m.state == stateContextMenu || m.state == statePRTitle || m.state == statePRBody || m.state == stateRenameInstance || m.state == stateRenamePlan || m.state == stateSendPrompt || m.state == stateFocusAgent || m.state == stateChangeTopic || m.state == stateSetStatus || m.state == stateClickUpSearch || m.state == stateClickUpPicker || m.state == stateClickUpFetching || m.state == statePermission || m.state == stateTmuxBrowser || m.state == stateChatAboutPlan {
+	if m.state == statePrompt || m.state == stateHelp || m.state == stateConfirm || m.state == stateNewPlan || m.state == stateNewPlanDeriving || m.state == stateNewPlanTopic || m.state == stateSpawnAgent || m.state == stateSearch || m.state == stateContextMenu || m.state == statePRTitle || m.state == statePRBody || m.state == stateRenameInstance || m.state == stateRenamePlan || m.state == stateSendPrompt || m.state == stateFocusAgent || m.state == stateChangeTopic || m.state == stateSetStatus || m.state == stateMergeStrategy || m.state == statePRReviewFollowUp || m.state == stateRollbackWave || m.state == stateAgentQuestion || m.state == stateIssueSearch || m.state == stateIssuePicker || m.state == stateIssueFetching || m.state == statePermission || m.state == stateTmuxBrowser || m.state == stateChatAboutPlan {
 		return nil, false
 	}
 	// If it's in the global keymap, we should try to highlight it.
@@ -369,17 +370,24 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 					m.pendingPRToastID = m.toastManager.Loading("Creating PR...")
 					prToastID := m.pendingPRToastID
 					capturedTitle := selected.Title
+					capturedPlanFile := selected.PlanFile
 					capturedPRTitle := prTitle
+					// Stacked plans open their PR against the parent's branch.
+					var prBase string
+					if m.planState != nil && capturedPlanFile != "" {
+						prBase = stackParentBranch(m.planState, capturedPlanFile)
+					}
 					return m, tea.Batch(tea.WindowSize(), func() tea.Msg {
 						commitMsg := fmt.Sprintf("[kas] update from '%s' on %s", capturedTitle, time.Now().Format(time.RFC822))
 						worktree, err := selected.GetGitWorktree()
 						if err != nil {
 							return prErrorMsg{id: prToastID, err: err}
 						}
-						if err := worktree.CreatePR(capturedPRTitle, prBody, commitMsg); err != nil {
+						pr, err := worktree.CreatePR(capturedPRTitle, prBody, commitMsg, prBase)
+						if err != nil {
 							return prErrorMsg{id: prToastID, err: err}
 						}
-						return prCreatedMsg{instanceTitle: capturedTitle, prTitle: capturedPRTitle}
+						return prCreatedMsg{instanceTitle: capturedTitle, prTitle: capturedPRTitle, planFile: capturedPlanFile, pr: pr}
 					}, m.toastTickCmd())
 				}
 			}
@@ -689,49 +697,15 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 				choice := m.permissionOverlay.Choice()
 				// Read the pattern/description from the overlay (captured at detection
 				// time) rather than re-parsing CachedContent, which may have changed.
-				cacheKey := config.CacheKey(m.permissionOverlay.Pattern(), m.permissionOverlay.Description())
+				pattern, description := m.permissionOverlay.Pattern(), m.permissionOverlay.Description()
 				inst := m.pendingPermissionInstance
 
-				// Cache "allow always" decisions
-				if choice == overlay.PermissionAllowAlways && cacheKey != "" && m.permissionStore != nil {
-					m.permissionStore.Remember(m.activeProject(), cacheKey)
-				}
-
 				m.permissionOverlay = nil
+				m.pendingPermissionInstance = nil
 				m.state = stateDefault
 
-				// Guard against re-trigger: the pane still shows the permission
-				// prompt for a few ticks while the key sequence propagates.
-				// Without this, the next metadata tick re-opens the modal.
 				if inst != nil {
-					guardKey := cacheKey
-					if guardKey == "" {
-						guardKey = "__handled__"
-					}
-					m.permissionHandled[inst] = guardKey
-				}
-
-				if inst != nil {
-					// overlay.PermissionChoice and tmux.PermissionChoice share the same
-					// iota ordering, so a direct cast is safe.
-					capturedInst := inst
-					capturedChoice := tmux.PermissionChoice(choice)
-					// Emit audit event for permission answered.
-					choiceStr := "allow once"
-					switch choice {
-					case overlay.PermissionAllowAlways:
-						choiceStr = "allow always"
-					case overlay.PermissionReject:
-						choiceStr = "reject"
-					}
-					m.audit(auditlog.EventPermissionAnswered, choiceStr,
-						auditlog.WithInstance(inst.Title),
-					)
-					m.pendingPermissionInstance = nil
-					return m, func() tea.Msg {
-						capturedInst.SendPermissionResponse(capturedChoice)
-						return nil
-					}
+					return m, m.answerPermission(inst, choice, pattern, description)
 				}
 			}
 			// Esc dismiss — also guard so the same prompt doesn't re-open.
@@ -825,12 +799,13 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 					topic = picked
 				}
 			}
-			if err := m.createPlanEntry(m.pendingPlanName, m.pendingPlanDesc, topic); err != nil {
+			if err := m.createPlanEntry(m.pendingPlanName, m.pendingPlanDesc, topic, m.pendingPlanParent); err != nil {
 				m.state = stateDefault
 				m.menu.SetState(ui.StateDefault)
 				m.pickerOverlay = nil
 				m.pendingPlanName = ""
 				m.pendingPlanDesc = ""
+				m.pendingPlanParent = ""
 				return m, m.handleError(err)
 			}
 			m.loadPlanState()
@@ -840,6 +815,7 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 			m.pickerOverlay = nil
 			m.pendingPlanName = ""
 			m.pendingPlanDesc = ""
+			m.pendingPlanParent = ""
 			return m, tea.WindowSize()
 		}
 		return m, nil
@@ -944,8 +920,91 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		return m, nil
 	}
 
-	// Handle ClickUp search input state
-	if m.state == stateClickUpSearch {
+	// Handle merge-strategy picker for merging a plan branch
+	if m.state == stateMergeStrategy {
+		if m.pickerOverlay == nil {
+			m.state = stateDefault
+			m.pendingMergePlan = ""
+			return m, nil
+		}
+		shouldClose := m.pickerOverlay.HandleKeyPress(msg)
+		if shouldClose {
+			planFile := m.pendingMergePlan
+			picked := ""
+			if m.pickerOverlay.IsSubmitted() {
+				picked = m.pickerOverlay.Value()
+			}
+			m.state = stateDefault
+			m.pickerOverlay = nil
+			m.pendingMergePlan = ""
+			if picked == "" || planFile == "" {
+				return m, tea.WindowSize()
+			}
+			strategy, err := gitpkg.ParseMergeStrategy(picked)
+			if err != nil {
+				return m, m.handleError(err)
+			}
+			return m, m.confirmMergePlan(planFile, strategy)
+		}
+		return m, nil
+	}
+
+	// Handle the wave picker for rolling a plan back to a checkpoint
+	if m.state == stateAgentQuestion {
+		return m.handleAgentQuestionKey(msg)
+	}
+
+	if m.state == stateRollbackWave {
+		if m.pickerOverlay == nil {
+			m.state = stateDefault
+			m.pendingRollbackPlan = ""
+			return m, nil
+		}
+		shouldClose := m.pickerOverlay.HandleKeyPress(msg)
+		if shouldClose {
+			planFile := m.pendingRollbackPlan
+			picked := ""
+			if m.pickerOverlay.IsSubmitted() {
+				picked = m.pickerOverlay.Value()
+			}
+			m.state = stateDefault
+			m.pickerOverlay = nil
+			m.pendingRollbackPlan = ""
+			wave, ok := parseRollbackChoice(picked)
+			if !ok || planFile == "" {
+				return m, tea.WindowSize()
+			}
+			return m, m.confirmRollback(planFile, wave)
+		}
+		return m, nil
+	}
+
+	if m.state == statePRReviewFollowUp {
+		if m.pickerOverlay == nil {
+			m.state = stateDefault
+			m.pendingPRReview = nil
+			return m, nil
+		}
+		shouldClose := m.pickerOverlay.HandleKeyPress(msg)
+		if shouldClose {
+			review := m.pendingPRReview
+			picked := ""
+			if m.pickerOverlay.IsSubmitted() {
+				picked = m.pickerOverlay.Value()
+			}
+			m.state = stateDefault
+			m.pickerOverlay = nil
+			m.pendingPRReview = nil
+			if picked == "" || review == nil {
+				return m, tea.WindowSize()
+			}
+			return m, m.addressPRReview(review.planFile, review.comments, picked)
+		}
+		return m, nil
+	}
+
+	// Handle issue search input state
+	if m.state == stateIssueSearch {
 		if m.textInputOverlay == nil {
 			m.state = stateDefault
 			return m, nil
@@ -956,10 +1015,10 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 			if m.textInputOverlay.IsSubmitted() {
 				query := strings.TrimSpace(m.textInputOverlay.GetValue())
 				if query != "" {
-					m.state = stateClickUpFetching
+					m.state = stateIssueFetching
 					m.textInputOverlay = nil
-					m.toastManager.Info("searching clickup...")
-					return m, tea.Batch(m.searchClickUp(query), m.toastTickCmd())
+					m.toastManager.Info("searching " + strings.Join(issueSourceNames(m.issueSources), ", ") + "...")
+					return m, tea.Batch(m.searchIssues(query), m.toastTickCmd())
 				}
 			}
 			m.state = stateDefault
@@ -968,8 +1027,8 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		return m, nil
 	}
 
-	// Handle ClickUp task picker state
-	if m.state == stateClickUpPicker {
+	// Handle issue picker state
+	if m.state == stateIssuePicker {
 		if m.pickerOverlay == nil {
 			m.state = stateDefault
 			return m, nil
@@ -978,15 +1037,12 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		if closed {
 			if m.pickerOverlay.IsSubmitted() {
 				selected := m.pickerOverlay.Value()
-				if selected != "" {
-					for _, r := range m.clickUpResults {
-						label := r.ID + " · " + r.Name
-						if strings.HasPrefix(selected, label) {
-							m.state = stateClickUpFetching
-							m.pickerOverlay = nil
-							m.toastManager.Info("fetching task details...")
-							return m, tea.Batch(m.fetchClickUpTaskWithTimeout(r.ID), m.toastTickCmd())
-						}
+				for _, r := range m.issueResults {
+					if r.label() == selected {
+						m.state = stateIssueFetching
+						m.pickerOverlay = nil
+						m.toastManager.Info("fetching issue details...")
+						return m, tea.Batch(m.fetchIssue(r.source, r.result.ID), m.toastTickCmd())
 					}
 				}
 			}
@@ -996,7 +1052,7 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		return m, nil
 	}
 
-	if m.state == stateClickUpFetching {
+	if m.state == stateIssueFetching {
 		return m, nil
 	}
 
@@ -1127,12 +1183,38 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		return m, nil
 	}
 
+	// Shift+Up/Down: previous/next file in the diff tab
+	if (msg.Type == tea.KeyShiftUp || msg.Type == tea.KeyShiftDown) && m.tabbedWindow.IsInDiffTab() {
+		if msg.Type == tea.KeyShiftUp {
+			m.tabbedWindow.ScrollUp()
+		} else {
+			m.tabbedWindow.ScrollDown()
+		}
+		return m, nil
+	}
+
 	name, ok := keys.GlobalKeyStringsMap[msg.String()]
 	if !ok {
 		return m, nil
 	}
 
 	switch name {
+	case keys.KeyDiffSideBySide, keys.KeyDiffPrevCommit, keys.KeyDiffNextCommit, keys.KeyDiffTaskFilter:
+		if !m.tabbedWindow.IsInDiffTab() {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		switch name {
+		case keys.KeyDiffSideBySide:
+			m.tabbedWindow.ToggleDiffSideBySide()
+		case keys.KeyDiffPrevCommit:
+			cmd = m.tabbedWindow.DiffPrevCommit()
+		case keys.KeyDiffNextCommit:
+			cmd = m.tabbedWindow.DiffNextCommit()
+		case keys.KeyDiffTaskFilter:
+			cmd = m.tabbedWindow.CycleDiffTaskFilter()
+		}
+		return m, cmd
 	case keys.KeyHelp:
 		return m.showHelpScreen(helpTypeGeneral{}, nil)
 	case keys.KeyPrompt:
@@ -1197,11 +1279,8 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		m.nextFocusSlot()
 		return m, nil
 	case keys.KeySpace:
-		if m.focusSlot == slotNav && m.nav.GetSelectedID() == ui.SidebarImportClickUp {
-			m.state = stateClickUpSearch
-			m.textInputOverlay = overlay.NewTextInputOverlay("enter clickup id or url", "")
-			m.textInputOverlay.SetSize(50, 1)
-			return m, nil
+		if m.focusSlot == slotNav && m.nav.GetSelectedID() == ui.SidebarImportIssue {
+			return m.openIssueSearch()
 		}
 		if m.focusSlot == slotNav && m.nav.ToggleSelectedExpand() {
 			return m, nil
@@ -1250,6 +1329,7 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		m.audit(auditlog.EventAgentKilled, "killed instance",
 			auditlog.WithInstance(selected.Title),
 			auditlog.WithAgent(selected.AgentType),
+			auditlog.WithProgram(selected.Program),
 			auditlog.WithPlan(selected.PlanFile),
 		)
 		inst := selected
@@ -1342,11 +1422,8 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		return m, tea.WindowSize()
 	case keys.KeyEnter:
 		// Sidebar always has focus: handle plan/instance interactions first.
-		if m.nav.GetSelectedID() == ui.SidebarImportClickUp {
-			m.state = stateClickUpSearch
-			m.textInputOverlay = overlay.NewTextInputOverlay("enter clickup id or url", "")
-			m.textInputOverlay.SetSize(50, 1)
-			return m, nil
+		if m.nav.GetSelectedID() == ui.SidebarImportIssue {
+			return m.openIssueSearch()
 		}
 		// Plan header or plan file: open plan context menu
 		if m.nav.IsSelectedPlanHeader() {
@@ -1403,16 +1480,15 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 			m.auditPane.ToggleVisible()
 		}
 		return m, tea.WindowSize()
+	case keys.KeyAnalytics:
+		return m, m.loadDeliveryStats()
 	case keys.KeyArrowLeft:
 		// Sidebar always has focus — no-op.
 		return m, nil
 	case keys.KeyArrowRight:
 		// Toggle expand/collapse on the selected sidebar item (same as space's expand behavior).
-		if m.nav.GetSelectedID() == ui.SidebarImportClickUp {
-			m.state = stateClickUpSearch
-			m.textInputOverlay = overlay.NewTextInputOverlay("enter clickup id or url", "")
-			m.textInputOverlay.SetSize(50, 1)
-			return m, nil
+		if m.nav.GetSelectedID() == ui.SidebarImportIssue {
+			return m.openIssueSearch()
 		}
 		// Right on an instance while in the info tab: jump to the agent tab.
 		if m.nav.GetSelectedInstance() != nil && m.tabbedWindow.IsInInfoTab() {
@@ -1422,11 +1498,7 @@ func (m *home) handleKeyPress(msg tea.KeyMsg) (mod tea.Model, cmd tea.Cmd) {
 		m.nav.ToggleSelectedExpand()
 		return m, nil
 	case keys.KeyNewPlan:
-		m.state = stateNewPlan
-		m.textInputOverlay = overlay.NewTextInputOverlay("new plan", "")
-		m.textInputOverlay.SetMultiline(true)
-		m.textInputOverlay.SetPlaceholder("describe what you want to work on...")
-		m.textInputOverlay.SetSize(70, 8)
+		m.openNewPlanForm("")
 		return m, nil
 	case keys.KeySpawnAgent:
 		if m.tmuxSessionCount >= GlobalInstanceLimit {
@@ -1517,6 +1589,44 @@ func keyToBytes(msg tea.KeyMsg) []byte {
 	}
 }
 
+// answerPermission records the user's answer to inst's permission prompt and
+// returns the Cmd that sends it to the pane. "Allow always" is cached under the
+// prompt's pattern so future prompts auto-approve.
+func (m *home) answerPermission(inst *session.Instance, choice overlay.PermissionChoice, pattern, description string) tea.Cmd {
+	cacheKey := config.CacheKey(pattern, description)
+	if choice == overlay.PermissionAllowAlways && cacheKey != "" && m.permissionStore != nil {
+		m.permissionStore.Remember(m.activeProject(), cacheKey)
+	}
+
+	// Guard against re-trigger: the pane still shows the permission
+	// prompt for a few ticks while the key sequence propagates.
+	// Without this, the next metadata tick re-opens the modal.
+	guardKey := cacheKey
+	if guardKey == "" {
+		guardKey = "__handled__"
+	}
+	m.permissionHandled[inst] = guardKey
+
+	choiceStr := "allow once"
+	switch choice {
+	case overlay.PermissionAllowAlways:
+		choiceStr = "allow always"
+	case overlay.PermissionReject:
+		choiceStr = "reject"
+	}
+	m.audit(auditlog.EventPermissionAnswered, choiceStr,
+		auditlog.WithInstance(inst.Title),
+	)
+
+	// overlay.PermissionChoice and tmux.PermissionChoice share the same
+	// iota ordering, so a direct cast is safe.
+	tmuxChoice := tmux.PermissionChoice(choice)
+	return func() tea.Msg {
+		inst.SendPermissionResponse(tmuxChoice)
+		return nil
+	}
+}
+
 func (m *home) handleError(err error) tea.Cmd {
 	log.ErrorLog.Printf("%v", err)
 	m.toastManager.Error(err.Error())
@@ -0,0 +1,264 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	cmd2 "github.com/kastheco/kasmos/cmd"
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/internal/clickup"
+	"github.com/kastheco/kasmos/internal/tracker"
+	"github.com/kastheco/kasmos/log"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+	"github.com/kastheco/kasmos/ui/overlay"
+)
+
+// issueOpTimeout bounds a tracker search or fetch. MCP calls take no
+// context, so the overlay stops waiting rather than cancelling them.
+const issueOpTimeout = 30 * time.Second
+
+// issueSourcesDetectedMsg is sent at startup with the trackers the import
+// overlay can search.
+type issueSourcesDetectedMsg struct {
+	sources []tracker.IssueSource
+}
+
+// issueSearchResult is a picker entry: a search result and its source.
+type issueSearchResult struct {
+	source tracker.IssueSource
+	result tracker.SearchResult
+}
+
+// label renders the picker line for r.
+func (r issueSearchResult) label() string {
+	label := r.source.Name() + " · " + r.result.ID + " · " + r.result.Title
+	if r.result.Status != "" {
+		label += " (" + r.result.Status + ")"
+	}
+	if r.result.Project != "" {
+		label += " — " + r.result.Project
+	}
+	return label
+}
+
+// issueSearchMsg is sent when every source has answered a search.
+type issueSearchMsg struct {
+	results []issueSearchResult
+	// errs holds one "source: error" entry per failed source.
+	errs []string
+}
+
+// issueFetchedMsg is sent when a full issue is fetched.
+type issueFetchedMsg struct {
+	source string
+	issue  *tracker.Issue
+	err    error
+}
+
+func issueSourceNames(sources []tracker.IssueSource) []string {
+	names := make([]string, len(sources))
+	for i, s := range sources {
+		names[i] = s.Name()
+	}
+	return names
+}
+
+// openIssueSearch shows the query input of the import overlay.
+func (m *home) openIssueSearch() (tea.Model, tea.Cmd) {
+	m.state = stateIssueSearch
+	m.textInputOverlay = overlay.NewTextInputOverlay("search issues or enter an id or url", "")
+	m.textInputOverlay.SetSize(50, 1)
+	return m, nil
+}
+
+// searchIssues queries every source concurrently. Results keep source order
+// so the picker lists one tracker after another.
+func (m *home) searchIssues(query string) tea.Cmd {
+	sources := m.issueSources
+	parent := m.ctx
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(parent, issueOpTimeout)
+		defer cancel()
+
+		results := make([][]tracker.SearchResult, len(sources))
+		errs := make([]error, len(sources))
+		var wg sync.WaitGroup
+		for i, src := range sources {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				done := make(chan struct{})
+				var found []tracker.SearchResult
+				var err error
+				go func() {
+					found, err = src.Search(ctx, query)
+					close(done)
+				}()
+				select {
+				case <-done:
+					results[i], errs[i] = found, normalizeIssueError(err)
+				case <-ctx.Done():
+					errs[i] = normalizeIssueError(ctx.Err())
+				}
+			}()
+		}
+		wg.Wait()
+
+		var msg issueSearchMsg
+		for i, src := range sources {
+			if errs[i] != nil {
+				msg.errs = append(msg.errs, src.Name()+": "+errs[i].Error())
+				continue
+			}
+			for _, r := range results[i] {
+				msg.results = append(msg.results, issueSearchResult{source: src, result: r})
+			}
+		}
+		return msg
+	}
+}
+
+// showIssueResults opens the picker for a finished search. Failures of some
+// sources are reported without hiding the results of the others.
+func (m *home) showIssueResults(msg issueSearchMsg) (tea.Model, tea.Cmd) {
+	if len(msg.results) == 0 {
+		m.state = stateDefault
+		if len(msg.errs) > 0 {
+			m.toastManager.Error("issue search failed: " + strings.Join(msg.errs, "; "))
+		} else {
+			m.toastManager.Info("no issues found")
+		}
+		return m, m.toastTickCmd()
+	}
+	if len(msg.errs) > 0 {
+		m.toastManager.Warning("some trackers failed: " + strings.Join(msg.errs, "; "))
+	}
+	m.issueResults = msg.results
+	items := make([]string, len(msg.results))
+	for i, r := range msg.results {
+		items[i] = r.label()
+	}
+	m.state = stateIssuePicker
+	m.pickerOverlay = overlay.NewPickerOverlay("select issue", items)
+	return m, m.toastTickCmd()
+}
+
+func (m *home) fetchIssue(src tracker.IssueSource, id string) tea.Cmd {
+	parent := m.ctx
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(parent, issueOpTimeout)
+		defer cancel()
+
+		fetchDone := make(chan issueFetchedMsg, 1)
+		go func() {
+			issue, err := src.Fetch(ctx, id)
+			fetchDone <- issueFetchedMsg{source: src.Name(), issue: issue, err: err}
+		}()
+
+		select {
+		case msg := <-fetchDone:
+			msg.err = normalizeIssueError(msg.err)
+			return msg
+		case <-ctx.Done():
+			return issueFetchedMsg{source: src.Name(), err: normalizeIssueError(ctx.Err())}
+		}
+	}
+}
+
+func normalizeIssueError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, context.Canceled) {
+		return fmt.Errorf("operation canceled")
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("operation timed out after %s", issueOpTimeout)
+	}
+	return err
+}
+
+// importIssue registers a plan scaffolded from issue, remembering the issue
+// so status changes sync back to source, and spawns a planner to turn it
+// into a real plan.
+func (m *home) importIssue(source string, issue *tracker.Issue) (tea.Model, tea.Cmd) {
+	if issue == nil {
+		m.toastManager.Error("issue fetch failed: empty issue payload")
+		return m, m.toastTickCmd()
+	}
+
+	date := time.Now().Format("2006-01-02")
+	filename := tracker.ScaffoldFilename(issue.Title, date)
+
+	if m.planState == nil {
+		m.loadPlanState()
+	}
+	if m.planState == nil {
+		m.toastManager.Error("failed to register imported plan: plan state unavailable")
+		return m, m.toastTickCmd()
+	}
+
+	filename = dedupePlanFilenameInState(m.planState, filename)
+
+	scaffold := tracker.ScaffoldPlan(*issue)
+
+	branch := gitpkg.PlanBranchFromFile(filename)
+	if err := m.planState.Register(filename, issue.Title, branch, time.Now()); err != nil {
+		m.toastManager.Error("failed to register imported plan: " + err.Error())
+		return m, m.toastTickCmd()
+	}
+	if err := m.planState.SetContent(filename, scaffold); err != nil {
+		m.toastManager.Error("failed to save imported plan content: " + err.Error())
+		return m, m.toastTickCmd()
+	}
+	if err := m.planState.SetIssue(filename, source, issue.ID); err != nil {
+		log.WarningLog.Printf("issue import: record %s %s on %q: %v", source, issue.ID, filename, err)
+	}
+
+	if err := m.fsm.Transition(filename, planfsm.PlanStart); err != nil {
+		log.WarningLog.Printf("issue import transition failed for %q: %v", filename, err)
+	}
+
+	m.loadPlanState()
+	m.updateSidebarPlans()
+
+	prompt := fmt.Sprintf(`Analyze this imported %[1]s issue. The issue details and subtasks are included as reference in the plan file.
+
+Determine if the issue is well-specified enough for implementation or needs further analysis. Write a proper implementation plan with ## Wave sections, task breakdowns, architecture notes, and tech stack. Use the %[1]s subtasks as a starting point but reorganize into waves based on dependencies.
+
+The plan file is at: docs/plans/%[2]s`, issue.Source, filename)
+
+	m.toastManager.Success("imported! spawning planner...")
+	model, cmd := m.spawnPlanAgent(filename, "plan", prompt)
+	if cmd == nil {
+		return model, m.toastTickCmd()
+	}
+	return model, tea.Batch(cmd, m.toastTickCmd())
+}
+
+// detectIssueSourcesCmd finds the trackers configured in cfg or discovered
+// for the repository, plus a ClickUp MCP server when one is installed.
+func detectIssueSourcesCmd(cfg *config.Config, repoPath string) tea.Cmd {
+	return func() tea.Msg {
+		if cfg == nil {
+			return nil
+		}
+		claudeDir := filepath.Join(os.Getenv("HOME"), ".claude")
+		sources := tracker.Detect(cfg, repoPath, claudeDir, cmd2.MakeExecutor())
+		if src, found := clickup.DetectSource(cfg, repoPath, claudeDir, true); found {
+			sources = append(sources, src)
+		}
+		if len(sources) == 0 {
+			return nil
+		}
+		return issueSourcesDetectedMsg{sources: sources}
+	}
+}
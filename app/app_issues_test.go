@@ -0,0 +1,148 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/config/planstore"
+	"github.com/kastheco/kasmos/internal/tracker"
+	"github.com/kastheco/kasmos/session"
+	"github.com/kastheco/kasmos/ui"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIssueSource answers searches with canned results, or fails with err.
+type fakeIssueSource struct {
+	name    string
+	results []tracker.SearchResult
+	err     error
+	fetched []string
+}
+
+func (f *fakeIssueSource) Name() string { return f.name }
+
+func (f *fakeIssueSource) Search(context.Context, string) ([]tracker.SearchResult, error) {
+	return f.results, f.err
+}
+
+func (f *fakeIssueSource) Fetch(_ context.Context, id string) (*tracker.Issue, error) {
+	f.fetched = append(f.fetched, id)
+	return &tracker.Issue{Source: "Jira", ID: id, Title: "Rate limit"}, nil
+}
+
+func TestIssueImport_SearchesAllSourcesAndFetchesPick(t *testing.T) {
+	h := newTestHomeWithToast()
+	gh := &fakeIssueSource{name: "github", results: []tracker.SearchResult{{ID: "#12", Title: "Flaky CI", Status: "open"}}}
+	jira := &fakeIssueSource{name: "jira", results: []tracker.SearchResult{{ID: "PROJ-9", Title: "Rate limit", Project: "PROJ"}}}
+	down := &fakeIssueSource{name: "linear", err: errors.New("unauthorized")}
+
+	model, _ := h.Update(issueSourcesDetectedMsg{sources: []tracker.IssueSource{gh, jira, down}})
+	h = model.(*home)
+	require.Equal(t, ui.SidebarImportIssue, h.nav.GetSelectedID())
+
+	h.keySent = true
+	_, _ = h.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	require.Equal(t, stateIssueSearch, h.state)
+	for _, r := range "rate" {
+		h.handleKeyPress(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	_, cmd := h.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	require.Equal(t, stateIssueFetching, h.state)
+	require.NotNil(t, cmd)
+
+	msg := h.searchIssues("rate")().(issueSearchMsg)
+	assert.Equal(t, []string{"linear: unauthorized"}, msg.errs)
+	require.Len(t, msg.results, 2)
+	assert.Equal(t, "github · #12 · Flaky CI (open)", msg.results[0].label())
+	assert.Equal(t, "jira · PROJ-9 · Rate limit — PROJ", msg.results[1].label())
+
+	model, _ = h.Update(msg)
+	h = model.(*home)
+	require.Equal(t, stateIssuePicker, h.state)
+	h.handleKeyPress(tea.KeyMsg{Type: tea.KeyDown})
+	_, cmd = h.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	require.NotNil(t, cmd)
+	assert.Equal(t, stateIssueFetching, h.state)
+
+	fetched := h.fetchIssue(jira, "PROJ-9")().(issueFetchedMsg)
+	require.NoError(t, fetched.err)
+	assert.Equal(t, "jira", fetched.source)
+	assert.Equal(t, "PROJ-9", fetched.issue.ID)
+	assert.Contains(t, jira.fetched, "PROJ-9")
+}
+
+func TestIssueImport_AllSourcesFailing(t *testing.T) {
+	h := newTestHomeWithToast()
+	h.issueSources = []tracker.IssueSource{&fakeIssueSource{name: "jira", err: errors.New("401")}}
+	h.state = stateIssueFetching
+
+	model, _ := h.Update(h.searchIssues("x")())
+	h = model.(*home)
+	assert.Equal(t, stateDefault, h.state)
+	assert.Nil(t, h.issueResults)
+}
+
+// fakeIssueUpdater is a fakeIssueSource that records status changes.
+type fakeIssueUpdater struct {
+	fakeIssueSource
+	mu       sync.Mutex
+	statuses []string
+}
+
+func (f *fakeIssueUpdater) SetStatus(_ context.Context, id, status string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statuses = append(f.statuses, id+" "+status)
+	return nil
+}
+
+func (f *fakeIssueUpdater) Comment(context.Context, string, string) error { return nil }
+
+func (f *fakeIssueUpdater) recorded() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.statuses...)
+}
+
+func TestIssueImport_SyncsPlanStatusToIssue(t *testing.T) {
+	store := newTestStore(t)
+	h := newTestHomeWithToast()
+	h.planStateDir = filepath.Join(t.TempDir(), "docs", "plans")
+	h.planStore = store
+	h.planStoreProject = "test"
+	h.instanceFinalizers = make(map[*session.Instance]func())
+	h.fsm = planfsm.New(store, "test", h.planStateDir)
+	h.issueSync = tracker.NewSyncer(config.IssueSyncConfig{}, func(planFile string) (planstore.PlanEntry, error) {
+		return store.Get("test", planFile)
+	})
+	h.fsm.OnTransition(h.issueSync.Observe)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.issueSync.Run(ctx)
+
+	jira := &fakeIssueUpdater{fakeIssueSource: fakeIssueSource{name: "jira"}}
+	model, _ := h.Update(issueSourcesDetectedMsg{sources: []tracker.IssueSource{jira}})
+	h = model.(*home)
+	model, _ = h.Update(issueFetchedMsg{source: "jira", issue: &tracker.Issue{Source: "Jira", ID: "PROJ-9", Title: "Rate limit"}})
+	h = model.(*home)
+
+	plans, err := store.List("test")
+	require.NoError(t, err)
+	require.Len(t, plans, 1)
+	assert.Equal(t, "jira", plans[0].IssueSource)
+	assert.Equal(t, "PROJ-9", plans[0].IssueID)
+	assert.Equal(t, planstore.Status("planning"), plans[0].Status)
+
+	require.NoError(t, h.fsm.Transition(plans[0].Filename, planfsm.PlannerFinished))
+	require.NoError(t, h.fsm.Transition(plans[0].Filename, planfsm.ImplementStart))
+	require.Eventually(t, func() bool { return len(jira.recorded()) > 0 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"PROJ-9 in progress"}, jira.recorded())
+}
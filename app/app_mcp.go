@@ -0,0 +1,361 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/internal/mcpserver"
+	"github.com/kastheco/kasmos/orchestration"
+	"github.com/kastheco/kasmos/session"
+	"github.com/kastheco/kasmos/ui/overlay"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxWaveNotes caps the post_note_to_peers notes kept per wave.
+const maxWaveNotes = 50
+
+// waveKey identifies one wave of one plan.
+type waveKey struct {
+	planFile string
+	wave     int
+}
+
+// agentQuestion is an ask_user call waiting for the user.
+type agentQuestion struct {
+	agent    mcpserver.Agent
+	question string
+	choices  []string
+	// answer receives the reply once; dismissed questions get ok=false.
+	answer chan agentAnswer
+}
+
+type agentAnswer struct {
+	text string
+	ok   bool
+}
+
+// mcpBackend implements mcpserver.Backend for the agents of this TUI. Like
+// controlBackend it runs everything on the event loop, so tool calls drive
+// the same FSM transitions and wave orchestrators as sentinels and idle
+// detection do.
+type mcpBackend struct {
+	*controlBackend
+}
+
+func (b *mcpBackend) ReportTaskDone(_ context.Context, agent mcpserver.Agent, summary string) (string, error) {
+	v, err := b.call(func(m *home) (any, tea.Cmd, error) {
+		if agent.IsWaveTask() {
+			orch, err := m.agentWave(agent)
+			if err != nil {
+				return nil, nil, err
+			}
+			if orch.IsTaskComplete(agent.Task) {
+				return fmt.Sprintf("task %d is already complete", agent.Task), nil, nil
+			}
+			if !orch.IsTaskRunning(agent.Task) {
+				return nil, nil, fmt.Errorf("task %d is not running", agent.Task)
+			}
+			orch.MarkTaskComplete(agent.Task)
+			if inst := m.waveTaskInstance(agent); inst != nil {
+				inst.SetStatus(session.Ready)
+			}
+			m.auditAgentReport(agent, "task done", summary)
+			m.toastManager.Success(fmt.Sprintf("%s: task %d done", planstate.DisplayName(agent.PlanFile), agent.Task))
+			return fmt.Sprintf("task %d marked complete", agent.Task), tea.Batch(m.instanceChanged(), m.toastTickCmd()), nil
+		}
+		event, ok := mcpserver.DoneEvent(agent)
+		if !ok {
+			m.auditAgentReport(agent, "done", summary)
+			return "noted", nil, nil
+		}
+		return m.applyAgentSignal(agent, event, summary)
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+func (b *mcpBackend) ReportTaskFailed(_ context.Context, agent mcpserver.Agent, reason string) (string, error) {
+	v, err := b.call(func(m *home) (any, tea.Cmd, error) {
+		if agent.IsWaveTask() {
+			orch, err := m.agentWave(agent)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !orch.IsTaskRunning(agent.Task) {
+				return nil, nil, fmt.Errorf("task %d is not running", agent.Task)
+			}
+			orch.MarkTaskFailed(agent.Task)
+			m.auditAgentReport(agent, "task failed", reason, auditlog.WithLevel("error"))
+			m.toastManager.Error(fmt.Sprintf("%s: task %d failed: %s", planstate.DisplayName(agent.PlanFile), agent.Task, reason))
+			return fmt.Sprintf("task %d marked failed", agent.Task), tea.Batch(m.instanceChanged(), m.toastTickCmd()), nil
+		}
+		if event, ok := mcpserver.FailedEvent(agent); ok {
+			return m.applyAgentSignal(agent, event, reason)
+		}
+		m.auditAgentReport(agent, "failed", reason, auditlog.WithLevel("error"))
+		label := agent.String()
+		if agent.PlanFile != "" {
+			label = planstate.DisplayName(agent.PlanFile) + " " + label
+		}
+		m.toastManager.Error(fmt.Sprintf("%s failed: %s", label, reason))
+		return "reported to the user", m.toastTickCmd(), nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+func (b *mcpBackend) RequestReview(_ context.Context, agent mcpserver.Agent, summary string) (string, error) {
+	if agent.IsWaveTask() {
+		return "", fmt.Errorf("wave tasks do not request review; call report_task_done")
+	}
+	v, err := b.call(func(m *home) (any, tea.Cmd, error) {
+		return m.applyAgentSignal(agent, planfsm.ImplementFinished, summary)
+	})
+	if err != nil {
+		return "", err
+	}
+	return v.(string), nil
+}
+
+func (b *mcpBackend) ReadPlan(_ context.Context, agent mcpserver.Agent, planFile string) (mcpserver.PlanView, error) {
+	if planFile == "" {
+		planFile = agent.PlanFile
+	}
+	v, err := b.call(func(m *home) (any, tea.Cmd, error) {
+		if m.planState == nil {
+			return nil, nil, fmt.Errorf("no plan state loaded")
+		}
+		entry, ok := m.planState.Entry(planFile)
+		if !ok {
+			return nil, nil, fmt.Errorf("plan %q not found", planFile)
+		}
+		content, err := m.planState.GetContent(planFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("read plan %s: %w", planFile, err)
+		}
+		var taskStatus func(wave, task int) string
+		if orch, ok := m.waveOrchestrators[planFile]; ok {
+			taskStatus = func(_, task int) string { return orch.TaskStatus(task).String() }
+		}
+		return mcpserver.NewPlanView(planFile, string(entry.Status), entry.Description, entry.Branch, content, taskStatus), nil, nil
+	})
+	if err != nil {
+		return mcpserver.PlanView{}, err
+	}
+	return v.(mcpserver.PlanView), nil
+}
+
+func (b *mcpBackend) ListWavePeers(_ context.Context, agent mcpserver.Agent) (mcpserver.WavePeers, error) {
+	v, err := b.call(func(m *home) (any, tea.Cmd, error) {
+		orch, err := m.agentWave(agent)
+		if err != nil {
+			return nil, nil, err
+		}
+		peers := mcpserver.WavePeers{Wave: agent.Wave}
+		for _, t := range orch.CurrentWaveTasks() {
+			if t.Number == agent.Task {
+				continue
+			}
+			peer := mcpserver.Peer{TaskView: mcpserver.TaskView{
+				Number: t.Number,
+				Title:  t.Title,
+				Status: orch.TaskStatus(t.Number).String(),
+			}}
+			if inst := m.waveTaskInstance(mcpserver.Agent{PlanFile: agent.PlanFile, Wave: agent.Wave, Task: t.Number}); inst != nil {
+				peer.Instance = inst.Title
+			}
+			peers.Peers = append(peers.Peers, peer)
+		}
+		peers.Notes = append([]mcpserver.Note{}, m.waveNotes[waveKey{agent.PlanFile, agent.Wave}]...)
+		return peers, nil, nil
+	})
+	if err != nil {
+		return mcpserver.WavePeers{}, err
+	}
+	return v.(mcpserver.WavePeers), nil
+}
+
+func (b *mcpBackend) PostNote(_ context.Context, agent mcpserver.Agent, note string) error {
+	_, err := b.call(func(m *home) (any, tea.Cmd, error) {
+		if _, err := m.agentWave(agent); err != nil {
+			return nil, nil, err
+		}
+		key := waveKey{agent.PlanFile, agent.Wave}
+		if m.waveNotes == nil {
+			m.waveNotes = make(map[waveKey][]mcpserver.Note)
+		}
+		notes := append(m.waveNotes[key], mcpserver.Note{
+			From: agent.String(),
+			Task: agent.Task,
+			Text: note,
+			Time: time.Now(),
+		})
+		if len(notes) > maxWaveNotes {
+			notes = notes[len(notes)-maxWaveNotes:]
+		}
+		m.waveNotes[key] = notes
+		return nil, nil, nil
+	})
+	return err
+}
+
+func (b *mcpBackend) AskUser(ctx context.Context, agent mcpserver.Agent, question string, choices []string) (string, error) {
+	q := &agentQuestion{
+		agent:    agent,
+		question: question,
+		choices:  choices,
+		answer:   make(chan agentAnswer, 1),
+	}
+	if _, err := b.call(func(m *home) (any, tea.Cmd, error) {
+		m.agentQuestions = append(m.agentQuestions, q)
+		return nil, m.showAgentQuestion(), nil
+	}); err != nil {
+		return "", err
+	}
+	select {
+	case a := <-q.answer:
+		if !a.ok {
+			return "", errors.New("the user dismissed the question; continue with your best judgement")
+		}
+		return a.text, nil
+	case <-ctx.Done():
+		// The agent gave up — take the question off the screen.
+		_, _ = b.call(func(m *home) (any, tea.Cmd, error) {
+			return nil, m.dropAgentQuestion(q), nil
+		})
+		return "", ctx.Err()
+	}
+}
+
+// applyAgentSignal applies event for agent's plan exactly like the matching
+// sentinel file, and acknowledges it for the agent.
+func (m *home) applyAgentSignal(agent mcpserver.Agent, event planfsm.Event, body string) (any, tea.Cmd, error) {
+	if agent.PlanFile == "" {
+		return nil, nil, fmt.Errorf("you are not working on a plan")
+	}
+	cmds, err := m.applySignal(planfsm.Signal{Event: event, PlanFile: agent.PlanFile, Body: body})
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s for %s rejected: %w", event, agent.PlanFile, err)
+	}
+	m.loadPlanState()
+	cmds = append(cmds, m.instanceChanged(), m.toastTickCmd())
+	return fmt.Sprintf("reported %s for %s", event, agent.PlanFile), tea.Batch(cmds...), nil
+}
+
+// agentWave returns the orchestrator running agent's wave.
+func (m *home) agentWave(agent mcpserver.Agent) (*orchestration.WaveOrchestrator, error) {
+	orch, ok := m.waveOrchestrators[agent.PlanFile]
+	if !ok || orch.CurrentWaveNumber() != agent.Wave {
+		return nil, fmt.Errorf("wave %d of %s is not running", agent.Wave, agent.PlanFile)
+	}
+	return orch, nil
+}
+
+// waveTaskInstance returns the instance running agent's wave task, if any.
+func (m *home) waveTaskInstance(agent mcpserver.Agent) *session.Instance {
+	title := orchestration.TaskTitle(agent.PlanFile, agent.Wave, agent.Task)
+	for _, inst := range m.nav.GetInstances() {
+		if inst.Title == title {
+			return inst
+		}
+	}
+	return nil
+}
+
+func (m *home) auditAgentReport(agent mcpserver.Agent, what, detail string, opts ...auditlog.EventOption) {
+	opts = append([]auditlog.EventOption{
+		auditlog.WithAgent(agent.Role),
+		auditlog.WithPlan(agent.PlanFile),
+		auditlog.WithWave(agent.Wave, agent.Task),
+		auditlog.WithDetail(detail),
+	}, opts...)
+	m.audit(auditlog.EventAgentFinished, fmt.Sprintf("%s reported %s", agent, what), opts...)
+}
+
+// showAgentQuestion opens the first queued ask_user question unless another
+// overlay is up; the metadata tick retries once it closes.
+func (m *home) showAgentQuestion() tea.Cmd {
+	if len(m.agentQuestions) == 0 || m.isUserInOverlay() {
+		return nil
+	}
+	q := m.agentQuestions[0]
+	title := q.agent.String()
+	if q.agent.PlanFile != "" {
+		title = planstate.DisplayName(q.agent.PlanFile) + " " + title
+	}
+	title += " asks: " + q.question
+	if len(q.choices) > 0 {
+		m.pickerOverlay = overlay.NewPickerOverlay(title, q.choices)
+		m.pickerOverlay.SetAllowCustom(true)
+	} else {
+		m.textInputOverlay = overlay.NewTextInputOverlay(title, "")
+		m.textInputOverlay.SetSize(60, 5)
+		m.textInputOverlay.SetMultiline(true)
+		m.textInputOverlay.SetPlaceholder("your answer")
+	}
+	m.state = stateAgentQuestion
+	return tea.WindowSize()
+}
+
+// answerAgentQuestion replies to the question on screen and closes it.
+func (m *home) answerAgentQuestion(a agentAnswer) tea.Cmd {
+	if len(m.agentQuestions) > 0 {
+		m.agentQuestions[0].answer <- a
+		m.agentQuestions = m.agentQuestions[1:]
+	}
+	m.closeAgentQuestion()
+	return tea.WindowSize()
+}
+
+// dropAgentQuestion forgets q, closing it if it is on screen.
+func (m *home) dropAgentQuestion(q *agentQuestion) tea.Cmd {
+	for i, queued := range m.agentQuestions {
+		if queued != q {
+			continue
+		}
+		m.agentQuestions = append(m.agentQuestions[:i], m.agentQuestions[i+1:]...)
+		if i == 0 && m.state == stateAgentQuestion {
+			m.closeAgentQuestion()
+			return tea.WindowSize()
+		}
+		break
+	}
+	return nil
+}
+
+func (m *home) closeAgentQuestion() {
+	m.pickerOverlay = nil
+	m.textInputOverlay = nil
+	m.state = stateDefault
+}
+
+// handleAgentQuestionKey routes keys to the open ask_user overlay.
+func (m *home) handleAgentQuestionKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case m.pickerOverlay != nil:
+		if !m.pickerOverlay.HandleKeyPress(msg) {
+			return m, nil
+		}
+		if m.pickerOverlay.IsSubmitted() && m.pickerOverlay.Value() != "" {
+			return m, m.answerAgentQuestion(agentAnswer{text: m.pickerOverlay.Value(), ok: true})
+		}
+	case m.textInputOverlay != nil:
+		if !m.textInputOverlay.HandleKeyPress(msg) {
+			return m, nil
+		}
+		if m.textInputOverlay.IsSubmitted() && m.textInputOverlay.GetValue() != "" {
+			return m, m.answerAgentQuestion(agentAnswer{text: m.textInputOverlay.GetValue(), ok: true})
+		}
+	}
+	return m, m.answerAgentQuestion(agentAnswer{})
+}
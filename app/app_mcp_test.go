@@ -0,0 +1,138 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/kastheco/kasmos/config/planparser"
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/internal/mcpserver"
+	"github.com/kastheco/kasmos/orchestration"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// waveMCPHome returns a home running wave 1 (tasks 1 and 2) of auth.md.
+func waveMCPHome(t *testing.T) (*home, *orchestration.WaveOrchestrator) {
+	t.Helper()
+	m := newTestHomeWithToast()
+	orch := orchestration.NewWaveOrchestrator("auth.md", &planparser.Plan{Waves: []planparser.Wave{
+		{Number: 1, Tasks: []planparser.Task{{Number: 1, Title: "Models"}, {Number: 2, Title: "Views"}}},
+		{Number: 2, Tasks: []planparser.Task{{Number: 3, Title: "Wiring"}}},
+	}})
+	orch.StartNextWave()
+	m.waveOrchestrators = map[string]*orchestration.WaveOrchestrator{"auth.md": orch}
+	return m, orch
+}
+
+func TestMCP_WaveTaskReportsDriveOrchestrator(t *testing.T) {
+	m, orch := waveMCPHome(t)
+	b := &mcpBackend{controlBackend: newTestControlBackend(m)}
+	ctx := context.Background()
+	task1 := mcpserver.Agent{Role: mcpserver.RoleCoder, PlanFile: "auth.md", Wave: 1, Task: 1}
+	task2 := mcpserver.Agent{Role: mcpserver.RoleCoder, PlanFile: "auth.md", Wave: 1, Task: 2}
+
+	ack, err := b.ReportTaskDone(ctx, task1, "models added")
+	require.NoError(t, err)
+	assert.Equal(t, "task 1 marked complete", ack)
+	assert.True(t, orch.IsTaskComplete(1))
+
+	ack, err = b.ReportTaskDone(ctx, task1, "")
+	require.NoError(t, err, "reporting twice is harmless")
+	assert.Contains(t, ack, "already complete")
+
+	_, err = b.ReportTaskFailed(ctx, task2, "cannot build")
+	require.NoError(t, err)
+	assert.True(t, orch.IsTaskFailed(2))
+	assert.Equal(t, orchestration.WaveStateWaveComplete, orch.State())
+
+	_, err = b.ReportTaskDone(ctx, mcpserver.Agent{PlanFile: "auth.md", Wave: 2, Task: 3}, "")
+	assert.Error(t, err, "wave 2 is not running")
+	_, err = b.RequestReview(ctx, task1, "")
+	assert.Error(t, err, "wave tasks do not request review")
+}
+
+func TestMCP_WavePeersAndNotes(t *testing.T) {
+	m, _ := waveMCPHome(t)
+	b := &mcpBackend{controlBackend: newTestControlBackend(m)}
+	ctx := context.Background()
+	task1 := mcpserver.Agent{Role: mcpserver.RoleCoder, PlanFile: "auth.md", Wave: 1, Task: 1}
+
+	require.NoError(t, b.PostNote(ctx, task1, "User now has an Email field"))
+
+	peers, err := b.ListWavePeers(ctx, mcpserver.Agent{Role: mcpserver.RoleCoder, PlanFile: "auth.md", Wave: 1, Task: 2})
+	require.NoError(t, err)
+	require.Len(t, peers.Peers, 1)
+	assert.Equal(t, 1, peers.Peers[0].Number)
+	assert.Equal(t, "running", peers.Peers[0].Status)
+	require.Len(t, peers.Notes, 1)
+	assert.Equal(t, "wave 1 task 1", peers.Notes[0].From)
+	assert.Equal(t, "User now has an Email field", peers.Notes[0].Text)
+
+	assert.Error(t, b.PostNote(ctx, mcpserver.Agent{PlanFile: "other.md", Wave: 1, Task: 1}, "hi"))
+}
+
+func TestMCP_TransitionsRejectedLikeSentinels(t *testing.T) {
+	m := newTestHomeWithToast()
+	m.setupPlanState(t, "auth.md", planstate.StatusReady, "")
+	b := &mcpBackend{controlBackend: newTestControlBackend(m)}
+
+	_, err := b.ReportTaskDone(context.Background(), mcpserver.Agent{Role: mcpserver.RoleReviewer, PlanFile: "auth.md"}, "")
+	assert.Error(t, err, "a ready plan cannot be approved")
+	entry, _ := m.planState.Entry("auth.md")
+	assert.Equal(t, planstate.StatusReady, entry.Status)
+}
+
+func TestMCP_AskUserWaitsForAnswer(t *testing.T) {
+	m := newTestHomeWithToast()
+	msgs := make(chan tea.Msg)
+	b := &mcpBackend{controlBackend: &controlBackend{send: func(msg tea.Msg) { msgs <- msg }}}
+
+	type result struct {
+		answer string
+		err    error
+	}
+	done := make(chan result, 1)
+	agent := mcpserver.Agent{Role: mcpserver.RolePlanner, PlanFile: "auth.md"}
+	go func() {
+		answer, err := b.AskUser(context.Background(), agent, "which database?", []string{"sqlite", "postgres"})
+		done <- result{answer, err}
+	}()
+
+	m.Update(<-msgs)
+	require.Equal(t, stateAgentQuestion, m.state)
+	require.NotNil(t, m.pickerOverlay)
+
+	m.keySent = true
+	m.handleKeyPress(tea.KeyMsg{Type: tea.KeyEnter})
+	r := <-done
+	require.NoError(t, r.err)
+	assert.Equal(t, "sqlite", r.answer)
+	assert.Equal(t, stateDefault, m.state)
+	assert.Empty(t, m.agentQuestions)
+}
+
+func TestMCP_AskUserCancelledByAgent(t *testing.T) {
+	m := newTestHomeWithToast()
+	m.state = stateHelp // another overlay is up, so the question queues
+	msgs := make(chan tea.Msg)
+	b := &mcpBackend{controlBackend: &controlBackend{send: func(msg tea.Msg) { msgs <- msg }}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := b.AskUser(ctx, mcpserver.Agent{Role: mcpserver.RoleCoder}, "continue?", nil)
+		done <- err
+	}()
+
+	m.Update(<-msgs)
+	assert.Equal(t, stateHelp, m.state, "the question waits for the open overlay")
+	require.Len(t, m.agentQuestions, 1)
+
+	cancel()
+	m.Update(<-msgs)
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Empty(t, m.agentQuestions)
+	assert.Equal(t, stateHelp, m.state)
+}
@@ -0,0 +1,251 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/log"
+	"github.com/kastheco/kasmos/session"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// planMergeConflictMsg reports a plan merge that was aborted on conflicts.
+type planMergeConflictMsg struct {
+	planFile string
+	conflict *gitpkg.MergeConflictError
+}
+
+// mergeFixerMsg asks Update to spawn an agent that resolves a merge conflict.
+type mergeFixerMsg struct {
+	planFile string
+	conflict *gitpkg.MergeConflictError
+}
+
+// defaultMergeStrategy returns the merge_strategy from config.toml, falling
+// back to a --no-ff merge when unset or invalid.
+func (m *home) defaultMergeStrategy() gitpkg.MergeStrategy {
+	if m.appConfig == nil {
+		return gitpkg.MergeStrategyMerge
+	}
+	strategy, err := gitpkg.ParseMergeStrategy(m.appConfig.MergeStrategy)
+	if err != nil {
+		log.WarningLog.Printf("config: %v — using merge", err)
+		return gitpkg.MergeStrategyMerge
+	}
+	return strategy
+}
+
+// mergeStrategyChoices lists the strategies for the merge picker with the
+// configured default first, so enter accepts it.
+func (m *home) mergeStrategyChoices() []string {
+	def := m.defaultMergeStrategy()
+	choices := []string{string(def)}
+	for _, s := range gitpkg.MergeStrategies {
+		if s != def {
+			choices = append(choices, string(s))
+		}
+	}
+	return choices
+}
+
+// confirmMergePlan asks for confirmation, then merges the plan branch with
+// strategy in the background.
+func (m *home) confirmMergePlan(planFile string, strategy gitpkg.MergeStrategy) tea.Cmd {
+	if m.planState == nil {
+		return nil
+	}
+	entry, ok := m.planState.Entry(planFile)
+	if !ok {
+		return m.handleError(fmt.Errorf("plan not found: %s", planFile))
+	}
+	if entry.Branch == "" {
+		return m.handleError(fmt.Errorf("plan has no branch to merge"))
+	}
+	if parentBranch := stackParentBranch(m.planState, planFile); parentBranch != "" {
+		return m.handleError(fmt.Errorf("plan is stacked on %s — land %s first",
+			planstate.DisplayName(entry.Parent), parentBranch))
+	}
+	planName := planstate.DisplayName(planFile)
+	base := gitpkg.DefaultBranch(m.activeRepoPath)
+	return m.confirmAction(fmt.Sprintf("%s '%s' branch into %s?", strategy, planName, base),
+		m.mergePlanCmd(planFile, entry, base, strategy))
+}
+
+// planMergedMsg reports a plan branch that landed on base.
+type planMergedMsg struct {
+	planFile string
+	entry    planstate.PlanEntry
+	base     string
+	strategy gitpkg.MergeStrategy
+}
+
+// mergePlanCmd lands the plan's branch on base in the background. Success
+// comes back as planMergedMsg, conflicts as planMergeConflictMsg; the plan's
+// agents keep running until the merge has actually happened.
+func (m *home) mergePlanCmd(planFile string, entry planstate.PlanEntry, base string, strategy gitpkg.MergeStrategy) tea.Cmd {
+	m.rememberStackTip(planFile, entry.Branch)
+	repoPath := m.activeRepoPath
+	return func() tea.Msg {
+		err := gitpkg.MergePlanBranchWith(repoPath, entry.Branch, gitpkg.MergeOptions{
+			Strategy: strategy,
+			Base:     base,
+			PlanFile: planFile,
+		})
+		if conflict, ok := gitpkg.IsMergeConflict(err); ok {
+			return planMergeConflictMsg{planFile: planFile, conflict: conflict}
+		}
+		if err != nil {
+			return err
+		}
+		// The branch is gone, so its wave checkpoints are useless.
+		_ = gitpkg.DeleteWaveCheckpoints(repoPath, planFile)
+		return planMergedMsg{planFile: planFile, entry: entry, base: base, strategy: strategy}
+	}
+}
+
+// handlePlanMerged tears down the merged plan's agents and walks the plan to
+// done.
+func (m *home) handlePlanMerged(msg planMergedMsg) (tea.Model, tea.Cmd) {
+	planFile := msg.planFile
+	var stale []*session.Instance
+	for _, inst := range m.allInstances {
+		if inst.PlanFile == planFile {
+			stale = append(stale, inst)
+		}
+	}
+	for _, inst := range stale {
+		m.nav.RemoveByTitle(inst.Title)
+		m.removeFromAllInstances(inst.Title)
+	}
+	delete(m.waveOrchestrators, planFile)
+	_ = m.saveAllInstances()
+
+	var cmds []tea.Cmd
+	// Walk through FSM to done if not already there.
+	if status := planfsm.Status(msg.entry.Status); status != planfsm.StatusDone {
+		var err error
+		if status != planfsm.StatusReviewing {
+			err = m.fsmSetReviewing(planFile)
+		}
+		if err == nil {
+			err = m.fsm.Transition(planFile, planfsm.ReviewApproved)
+		}
+		if err != nil {
+			cmds = append(cmds, m.handleError(err))
+		}
+	}
+	m.audit(auditlog.EventPlanMerged,
+		fmt.Sprintf("plan merged to %s: %s", msg.base, planstate.DisplayName(planFile)),
+		auditlog.WithPlan(planFile), auditlog.WithDetail(string(msg.strategy)))
+	m.loadPlanState()
+	m.updateSidebarPlans()
+	m.updateNavPanelStatus()
+
+	cmds = append(cmds, tea.WindowSize(), m.instanceChanged(), func() tea.Msg {
+		for _, inst := range stale {
+			_ = inst.Kill()
+		}
+		return nil
+	})
+	return m, tea.Batch(cmds...)
+}
+
+// handleMergeConflict reports the conflicting files and offers to hand the
+// conflict to a fixer agent in the plan worktree.
+func (m *home) handleMergeConflict(msg planMergeConflictMsg) tea.Cmd {
+	c := msg.conflict
+	planName := planstate.DisplayName(msg.planFile)
+	m.audit(auditlog.EventMergeConflict,
+		fmt.Sprintf("%s of %s into %s aborted: %d conflicting file(s)", c.Strategy, planName, c.Base, len(c.Files)),
+		auditlog.WithPlan(msg.planFile),
+		auditlog.WithLevel("warn"),
+		auditlog.WithDetail(strings.Join(c.Files, "\n")),
+	)
+	m.toastManager.Error(fmt.Sprintf("merge aborted — conflicts in %s", summarizeFiles(c.Files, 3)))
+	m.loadPlanState()
+	m.updateSidebarPlans()
+	return tea.Batch(m.toastTickCmd(), m.confirmAction(
+		fmt.Sprintf("merge of '%s' conflicts in %d file(s): %s\n\nspawn a fixer agent in the plan worktree?",
+			planName, len(c.Files), summarizeFiles(c.Files, 5)),
+		func() tea.Msg { return mergeFixerMsg{planFile: msg.planFile, conflict: c} },
+	))
+}
+
+// summarizeFiles joins up to max file names, noting how many were left out.
+func summarizeFiles(files []string, max int) string {
+	if len(files) <= max {
+		return strings.Join(files, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(files[:max], ", "), len(files)-max)
+}
+
+// spawnMergeFixer starts a coder in the plan's shared worktree with
+// instructions to bring the branch up to date with base and resolve the
+// conflicts, so the merge can be retried.
+func (m *home) spawnMergeFixer(planFile string, conflict *gitpkg.MergeConflictError) tea.Cmd {
+	planName := planstate.DisplayName(planFile)
+	branch := m.planBranch(planFile)
+	if branch == "" {
+		return m.handleError(fmt.Errorf("could not resolve branch for plan %q", planFile))
+	}
+	m.killExistingPlanAgent(planFile, session.AgentTypeCoder)
+
+	inst, err := session.NewInstance(session.InstanceOptions{
+		Title:     planName + "-fix-merge",
+		Path:      m.activeRepoPath,
+		Program:   m.programForAgent(session.AgentTypeCoder),
+		PlanFile:  planFile,
+		AgentType: session.AgentTypeCoder,
+	})
+	if err != nil {
+		return m.handleError(err)
+	}
+	inst.QueuedPrompt = buildMergeFixPrompt(conflict)
+	inst.SetStatus(session.Loading)
+
+	m.addInstanceFinalizer(inst, m.nav.AddInstance(inst))
+	m.nav.SelectInstance(inst)
+	m.audit(auditlog.EventAgentSpawned, fmt.Sprintf("spawned merge fixer for %s", planName),
+		auditlog.WithPlan(planFile),
+		auditlog.WithInstance(inst.Title),
+		auditlog.WithAgent(session.AgentTypeCoder),
+		auditlog.WithProgram(inst.Program),
+		auditlog.WithDetail(strings.Join(conflict.Files, "\n")),
+	)
+
+	shared := m.sharedPlanWorktree(planFile, branch)
+	return func() tea.Msg {
+		if err := shared.Setup(); err != nil {
+			return instanceStartedMsg{instance: inst, err: err}
+		}
+		err := inst.StartInSharedWorktree(shared, branch)
+		return instanceStartedMsg{instance: inst, err: err}
+	}
+}
+
+// buildMergeFixPrompt returns the prompt for a merge-fixer agent. Rebase
+// merges need the conflicts resolved commit by commit; the others only need
+// the base merged into the plan branch.
+func buildMergeFixPrompt(c *gitpkg.MergeConflictError) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "The plan branch %s no longer merges cleanly into %s. Conflicting files:\n", c.Branch, c.Base)
+	for _, f := range c.Files {
+		fmt.Fprintf(&sb, "- %s\n", f)
+	}
+	sb.WriteString("\n")
+	if c.Strategy == gitpkg.MergeStrategyRebase {
+		fmt.Fprintf(&sb, "Run `git rebase %s` in this worktree, resolve each conflict keeping the intent of both sides, "+
+			"then `git add` the files and `git rebase --continue` until the rebase completes.", c.Base)
+	} else {
+		fmt.Fprintf(&sb, "Run `git merge %s` in this worktree, resolve the conflicts keeping the intent of both sides, "+
+			"then `git add` the files and commit the merge.", c.Base)
+	}
+	sb.WriteString(" Run the project's build and tests afterwards and fix anything the resolution broke. " +
+		"Do not push and do not merge into the base branch yourself.")
+	return sb.String()
+}
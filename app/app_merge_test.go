@@ -0,0 +1,113 @@
+package app
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/orchestration"
+	"github.com/kastheco/kasmos/session"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeStrategyChoices_DefaultFirst(t *testing.T) {
+	m := newTestHome()
+	assert.Equal(t, []string{"merge", "squash", "rebase"}, m.mergeStrategyChoices())
+
+	m.appConfig.MergeStrategy = "rebase"
+	assert.Equal(t, []string{"rebase", "merge", "squash"}, m.mergeStrategyChoices())
+
+	m.appConfig.MergeStrategy = "bogus"
+	assert.Equal(t, gitpkg.MergeStrategyMerge, m.defaultMergeStrategy())
+}
+
+func TestBuildMergeFixPrompt(t *testing.T) {
+	c := &gitpkg.MergeConflictError{Branch: "plan/auth", Base: "main", Strategy: gitpkg.MergeStrategyMerge, Files: []string{"a.go", "b.go"}}
+	prompt := buildMergeFixPrompt(c)
+	assert.Contains(t, prompt, "- a.go\n- b.go")
+	assert.Contains(t, prompt, "git merge main")
+
+	c.Strategy = gitpkg.MergeStrategyRebase
+	assert.Contains(t, buildMergeFixPrompt(c), "git rebase --continue")
+}
+
+func TestMergeConflict_OffersFixerAgent(t *testing.T) {
+	dir := t.TempDir()
+	plansDir := filepath.Join(dir, "docs", "plans")
+	require.NoError(t, os.MkdirAll(plansDir, 0o755))
+	ps, err := newTestPlanState(t, plansDir)
+	require.NoError(t, err)
+	planFile := "2026-03-01-auth.md"
+	require.NoError(t, ps.Register(planFile, "auth", "plan/auth", time.Now()))
+
+	m := newTestHomeWithToast()
+	m.planState = ps
+	m.activeRepoPath = dir
+	m.instanceFinalizers = make(map[*session.Instance]func())
+
+	conflict := &gitpkg.MergeConflictError{Branch: "plan/auth", Base: "main", Strategy: gitpkg.MergeStrategySquash, Files: []string{"auth.go"}}
+	_, _ = m.Update(planMergeConflictMsg{planFile: planFile, conflict: conflict})
+	require.Equal(t, stateConfirm, m.state)
+	require.NotNil(t, m.pendingConfirmAction)
+
+	msg := m.pendingConfirmAction()
+	fix, ok := msg.(mergeFixerMsg)
+	require.True(t, ok)
+	assert.Equal(t, planFile, fix.planFile)
+
+	cmd := m.spawnMergeFixer(fix.planFile, fix.conflict)
+	assert.NotNil(t, cmd)
+	inst := m.nav.GetSelectedInstance()
+	require.NotNil(t, inst)
+	assert.Equal(t, "auth-fix-merge", inst.Title)
+	assert.Equal(t, session.AgentTypeCoder, inst.AgentType)
+	assert.Contains(t, inst.QueuedPrompt, "auth.go")
+}
+
+func TestMergePlan_KeepsAgentsUntilMergeLands(t *testing.T) {
+	dir, ps, planFile := setupDriftRepo(t)
+	plansDir := filepath.Join(dir, "docs", "plans")
+	seedPlanStatus(t, ps, planFile, planstate.StatusReviewing)
+
+	m := waveFlowHome(t, ps, plansDir, make(map[string]*orchestration.WaveOrchestrator))
+	m.fsm = newPlanFSMForTest(t, plansDir)
+	m.planStore = storeForDir(t, plansDir)
+	m.planStoreProject = "test"
+	m.activeRepoPath = dir
+	inst, err := session.NewInstance(session.InstanceOptions{Title: "auth-review", Path: dir, Program: "claude", PlanFile: planFile})
+	require.NoError(t, err)
+	m.nav.AddInstance(inst)
+	m.allInstances = append(m.allInstances, inst)
+
+	base := gitpkg.DefaultBranch(dir)
+	entry, ok := ps.Entry(planFile)
+	require.True(t, ok)
+
+	// A dirty base fails the pre-flight check: nothing is torn down.
+	tracked := filepath.Join(dir, "tracked.txt")
+	require.NoError(t, os.WriteFile(tracked, []byte("a\n"), 0o644))
+	for _, args := range [][]string{{"add", "tracked.txt"}, {"commit", "-m", "tracked"}} {
+		out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+		require.NoErrorf(t, err, "%s", out)
+	}
+	require.NoError(t, os.WriteFile(tracked, []byte("b\n"), 0o644))
+	msg := m.mergePlanCmd(planFile, entry, base, gitpkg.MergeStrategyMerge)()
+	_, isErr := msg.(error)
+	require.True(t, isErr, "got %T", msg)
+	assert.Len(t, m.allInstances, 1)
+	assert.Len(t, m.nav.GetInstances(), 1)
+
+	require.NoError(t, os.WriteFile(tracked, []byte("a\n"), 0o644))
+	merged, ok := m.mergePlanCmd(planFile, entry, base, gitpkg.MergeStrategyMerge)().(planMergedMsg)
+	require.True(t, ok)
+	m.handlePlanMerged(merged)
+
+	assert.Empty(t, m.allInstances)
+	assert.Empty(t, m.nav.GetInstances())
+	assert.Equal(t, planstate.StatusDone, m.planState.Plans[planFile].Status)
+}
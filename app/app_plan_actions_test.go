@@ -12,6 +12,7 @@ import (
 	"github.com/kastheco/kasmos/config"
 	"github.com/kastheco/kasmos/config/planstate"
 	"github.com/kastheco/kasmos/config/planstore"
+	"github.com/kastheco/kasmos/orchestration"
 	"github.com/kastheco/kasmos/session"
 	"github.com/kastheco/kasmos/ui"
 	"github.com/kastheco/kasmos/ui/overlay"
@@ -47,13 +48,6 @@ func TestBuildWaveAnnotationPrompt_SingleWaveFallback(t *testing.T) {
 	assert.Contains(t, prompt, "## Wave 1", "prompt must specify ## Wave 1 as the minimum structure")
 }
 
-func TestBuildImplementPrompt(t *testing.T) {
-	prompt := buildImplementPrompt("2026-02-21-auth-refactor.md")
-	if !strings.Contains(prompt, "Implement docs/plans/2026-02-21-auth-refactor.md") {
-		t.Fatalf("prompt missing plan path")
-	}
-}
-
 func TestBuildSoloPrompt_WithDescription(t *testing.T) {
 	prompt := buildSoloPrompt("auth-refactor", "Refactor JWT auth", "2026-02-21-auth-refactor.md")
 	assert.Contains(t, prompt, "Implement auth-refactor")
@@ -382,7 +376,7 @@ func setupTopicConflictHome(t *testing.T) (*home, string) {
 	seedPlanStatus(t, ps, targetPlan, planstate.StatusReady)
 	seedPlanStatus(t, ps, conflictPlan, planstate.StatusImplementing)
 
-	h := waveFlowHome(t, ps, plansDir, make(map[string]*WaveOrchestrator))
+	h := waveFlowHome(t, ps, plansDir, make(map[string]*orchestration.WaveOrchestrator))
 	h.fsm = newFSMForTest(t, plansDir).PlanStateMachine
 	h.activeRepoPath = dir
 	h.program = "opencode"
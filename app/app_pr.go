@@ -0,0 +1,178 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/log"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+	"github.com/kastheco/kasmos/ui"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// prCheckInterval throttles pull request polling. Each check shells out to
+// the forge CLI (or API) once per tracked plan.
+const prCheckInterval = time.Minute
+
+// repoForge returns the forge of the active repository, detecting it on
+// first use and again only when the active repository changes.
+func (m *home) repoForge() gitpkg.Forge {
+	if m.forge == nil || m.forgeRepo != m.activeRepoPath {
+		m.forge = gitpkg.DetectForge(m.activeRepoPath)
+		m.forgeRepo = m.activeRepoPath
+	}
+	return m.forge
+}
+
+// planPRsMsg carries the pull requests fetched by pollPlanPRs.
+type planPRsMsg struct {
+	prs map[string]gitpkg.PullRequest
+}
+
+// trackedPRBranches maps every active plan with a pull request to its branch.
+func trackedPRBranches(ps *planstate.PlanState) map[string]string {
+	branches := make(map[string]string)
+	for filename, entry := range ps.Plans {
+		if entry.PRNumber == 0 || entry.Branch == "" ||
+			entry.Status == planstate.StatusDone || entry.Status == planstate.StatusCancelled {
+			continue
+		}
+		branches[filename] = entry.Branch
+	}
+	return branches
+}
+
+// collectPlanPRs fetches the pull request of each plan in branches. Plans
+// whose status cannot be fetched are left out, so a flaky forge keeps the
+// last known status on screen. Safe to call from a goroutine.
+func collectPlanPRs(forge gitpkg.Forge, repoPath string, branches map[string]string) map[string]gitpkg.PullRequest {
+	prs := make(map[string]gitpkg.PullRequest)
+	for filename, branch := range branches {
+		pr, err := forge.PRStatus(repoPath, branch)
+		if err != nil {
+			if !errors.Is(err, gitpkg.ErrNoPR) && !errors.Is(err, gitpkg.ErrUnsupported) {
+				log.WarningLog.Printf("pr status for %s: %v", filename, err)
+			}
+			continue
+		}
+		prs[filename] = *pr
+	}
+	return prs
+}
+
+// pollPlanPRs starts a background pull request check when prCheckInterval
+// has passed and no check is in flight. Returns nil when there is nothing to
+// poll. The result comes back as planPRsMsg.
+func (m *home) pollPlanPRs() tea.Cmd {
+	if m.prPollInFlight || m.planState == nil || time.Since(m.lastPRCheck) < prCheckInterval {
+		return nil
+	}
+	branches := trackedPRBranches(m.planState)
+	if len(branches) == 0 {
+		return nil
+	}
+	m.lastPRCheck = time.Now()
+	m.prPollInFlight = true
+	forge, repoPath := m.repoForge(), m.activeRepoPath
+	return func() tea.Msg {
+		return planPRsMsg{prs: collectPlanPRs(forge, repoPath, branches)}
+	}
+}
+
+// handlePlanPRs applies a finished pull request check.
+func (m *home) handlePlanPRs(msg planPRsMsg) tea.Cmd {
+	m.prPollInFlight = false
+	if !m.applyPlanPRs(msg.prs) {
+		return nil
+	}
+	m.loadPlanState()
+	m.updateSidebarPlans()
+	return m.toastTickCmd()
+}
+
+// applyPlanPRs records freshly polled pull requests and moves plans whose
+// pull request merged to done. Returns true when any plan changed status.
+func (m *home) applyPlanPRs(prs map[string]gitpkg.PullRequest) bool {
+	if m.planPRs == nil {
+		m.planPRs = make(map[string]gitpkg.PullRequest, len(prs))
+	}
+	changed := false
+	for planFile, pr := range prs {
+		m.planPRs[planFile] = pr
+		if pr.State != gitpkg.PRStateMerged || m.planState == nil || m.fsm == nil {
+			continue
+		}
+		entry, ok := m.planState.Entry(planFile)
+		if !ok || entry.Status == planstate.StatusDone || entry.Status == planstate.StatusCancelled {
+			continue
+		}
+		// Walk through any missing lifecycle stages, as "mark done" does.
+		if entry.Status != planstate.StatusReviewing {
+			if err := m.fsmSetReviewing(planFile); err != nil {
+				log.WarningLog.Printf("pr merged for %s: %v", planFile, err)
+				continue
+			}
+		}
+		if err := m.fsm.Transition(planFile, planfsm.ReviewApproved); err != nil {
+			log.WarningLog.Printf("pr merged for %s: %v", planFile, err)
+			continue
+		}
+		planName := planstate.DisplayName(planFile)
+		m.audit(auditlog.EventPRMerged, fmt.Sprintf("PR #%d merged: %s", pr.Number, planName),
+			auditlog.WithPlan(planFile), auditlog.WithDetail(pr.URL))
+		m.audit(auditlog.EventPlanTransition, string(entry.Status)+" → done (PR merged)",
+			auditlog.WithPlan(planFile))
+		m.toastManager.Success(fmt.Sprintf("PR #%d merged — %s is done", pr.Number, planName))
+		changed = true
+	}
+	return changed
+}
+
+// recordPlanPR stores a newly created pull request on its plan so it is
+// polled from the next tick on.
+func (m *home) recordPlanPR(planFile string, pr *gitpkg.PullRequest) {
+	if planFile == "" || pr == nil || m.planState == nil {
+		return
+	}
+	if err := m.planState.SetPR(planFile, pr.Number, pr.URL); err != nil {
+		log.WarningLog.Printf("record PR for %s: %v", planFile, err)
+		return
+	}
+	if m.planPRs == nil {
+		m.planPRs = make(map[string]gitpkg.PullRequest)
+	}
+	m.planPRs[planFile] = *pr
+	if m.issueSync != nil {
+		m.issueSync.PullRequestOpened(planFile, pr.URL)
+	}
+}
+
+// planPRFor returns the last polled pull request of planFile, falling back to
+// the number and URL stored on the plan entry before the first poll.
+func (m *home) planPRFor(planFile string, entry planstate.PlanEntry) (gitpkg.PullRequest, bool) {
+	if pr, ok := m.planPRs[planFile]; ok {
+		return pr, true
+	}
+	if entry.PRNumber > 0 {
+		return gitpkg.PullRequest{Number: entry.PRNumber, URL: entry.PRURL}, true
+	}
+	return gitpkg.PullRequest{}, false
+}
+
+// setInfoPanePR fills the pull request rows of the info pane for planFile.
+func (m *home) setInfoPanePR(data *ui.InfoData, planFile string, entry planstate.PlanEntry) {
+	pr, ok := m.planPRFor(planFile, entry)
+	if !ok {
+		return
+	}
+	data.PlanPRNumber = pr.Number
+	data.PlanPRURL = pr.URL
+	data.PlanPRState = string(pr.State)
+	data.PlanPRChecks = string(pr.Checks)
+	data.PlanPRReview = string(pr.Review)
+}
@@ -0,0 +1,230 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/log"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+	"github.com/kastheco/kasmos/ui/overlay"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Picker choices for what happens to the review threads once the agent has
+// addressed them.
+const (
+	prReviewLeaveThreads   = "leave threads open"
+	prReviewReplyThreads   = "reply on threads when done"
+	prReviewResolveThreads = "reply and resolve threads when done"
+)
+
+// prReviewFetchedMsg carries the unresolved review comments of a plan's PR.
+type prReviewFetchedMsg struct {
+	planFile string
+	comments []gitpkg.ReviewComment
+	err      error
+}
+
+// prReviewRepliedMsg reports the outcome of pushing the fixes and answering
+// review threads after the agent addressed them. leftOpen is set when the
+// threads were not to be answered.
+type prReviewRepliedMsg struct {
+	planFile string
+	replied  int
+	resolved bool
+	leftOpen bool
+	err      error
+}
+
+// prReviewFollowUp remembers what to do once the agent addressing review
+// threads signals it is done: the branch is always pushed, and the threads
+// are answered when reply is set.
+type prReviewFollowUp struct {
+	comments []gitpkg.ReviewComment
+	reply    bool
+	resolve  bool
+}
+
+// fetchPRReview loads the unresolved review comments of the plan's PR.
+func (m *home) fetchPRReview(planFile string) tea.Cmd {
+	if m.planState == nil {
+		return nil
+	}
+	entry, ok := m.planState.Entry(planFile)
+	if !ok {
+		return m.handleError(fmt.Errorf("plan not found: %s", planFile))
+	}
+	if entry.Branch == "" || entry.PRNumber == 0 {
+		return m.handleError(fmt.Errorf("plan has no pull request"))
+	}
+	repoPath := m.activeRepoPath
+	branch := entry.Branch
+	forge := m.repoForge()
+	return func() tea.Msg {
+		if err := forge.Check(); err != nil {
+			return prReviewFetchedMsg{planFile: planFile, err: err}
+		}
+		comments, err := forge.ReviewComments(repoPath, branch)
+		return prReviewFetchedMsg{planFile: planFile, comments: comments, err: err}
+	}
+}
+
+// handlePRReviewFetched asks what to do with the threads once they are
+// addressed, or reports that there is nothing to address.
+func (m *home) handlePRReviewFetched(msg prReviewFetchedMsg) tea.Cmd {
+	if msg.err != nil {
+		if errors.Is(msg.err, gitpkg.ErrUnsupported) {
+			return m.handleError(fmt.Errorf("this forge does not expose review comments"))
+		}
+		return m.handleError(msg.err)
+	}
+	if len(msg.comments) == 0 {
+		m.toastManager.Info(fmt.Sprintf("no unresolved review comments on %s", planstate.DisplayName(msg.planFile)))
+		return m.toastTickCmd()
+	}
+	m.pendingPRReview = &msg
+	m.pickerOverlay = overlay.NewPickerOverlay(
+		fmt.Sprintf("address %d review comment(s)", len(msg.comments)),
+		[]string{prReviewReplyThreads, prReviewResolveThreads, prReviewLeaveThreads},
+	)
+	m.state = statePRReviewFollowUp
+	return nil
+}
+
+// addressPRReview hands the review comments to a coder in the plan worktree.
+// followUp is one of the prReview* picker choices.
+func (m *home) addressPRReview(planFile string, comments []gitpkg.ReviewComment, followUp string) tea.Cmd {
+	if err := m.fsmReopenForFixes(planFile); err != nil {
+		return m.handleError(err)
+	}
+	if m.prReviewFollowUps == nil {
+		m.prReviewFollowUps = make(map[string]prReviewFollowUp)
+	}
+	m.prReviewFollowUps[planFile] = prReviewFollowUp{
+		comments: comments,
+		reply:    followUp == prReviewReplyThreads || followUp == prReviewResolveThreads,
+		resolve:  followUp == prReviewResolveThreads,
+	}
+	m.loadPlanState()
+	m.updateSidebarPlans()
+	return m.spawnCoderWithFeedback(planFile, buildPRReviewFeedback(comments))
+}
+
+// fsmReopenForFixes moves a plan back to implementing so the coder's
+// implement-finished signal is accepted again.
+func (m *home) fsmReopenForFixes(planFile string) error {
+	entry, ok := m.planState.Entry(planFile)
+	if !ok {
+		return fmt.Errorf("plan not found: %s", planFile)
+	}
+	var event planfsm.Event
+	switch entry.Status {
+	case planstate.StatusImplementing:
+		return nil
+	case planstate.StatusReviewing:
+		event = planfsm.ReviewChangesRequested
+	case planstate.StatusDone:
+		event = planfsm.Reimplement
+	default:
+		return m.fsmSetImplementing(planFile)
+	}
+	if err := m.fsm.Transition(planFile, event); err != nil {
+		return err
+	}
+	m.audit(auditlog.EventPlanTransition, string(entry.Status)+" → implementing (PR review)",
+		auditlog.WithPlan(planFile))
+	return nil
+}
+
+// buildPRReviewFeedback renders review comments into the feedback section of
+// a coder prompt.
+func buildPRReviewFeedback(comments []gitpkg.ReviewComment) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Unresolved review comments on pull request #%d:\n", comments[0].PR)
+	for i, c := range comments {
+		loc := "general"
+		switch {
+		case c.Path != "" && c.Line > 0:
+			loc = fmt.Sprintf("%s:%d", c.Path, c.Line)
+		case c.Path != "":
+			loc = c.Path
+		}
+		fmt.Fprintf(&sb, "\n%d. %s (%s)\n", i+1, loc, c.Author)
+		for _, line := range strings.Split(c.Body, "\n") {
+			sb.WriteString("   " + line + "\n")
+		}
+	}
+	sb.WriteString("\nAddress every comment, or explain in the commit message why a comment does not apply. " +
+		"Commit your changes; do not push — kasmos pushes the branch when you are done.")
+	return sb.String()
+}
+
+// finishPRReview wraps up a PR review of planFile once the coder that
+// addressed it is done: the branch is pushed, then, if requested, each thread
+// gets a reply naming the commit and is resolved. No-op when no follow-up is
+// pending.
+func (m *home) finishPRReview(planFile string) tea.Cmd {
+	followUp, ok := m.prReviewFollowUps[planFile]
+	if !ok {
+		return nil
+	}
+	delete(m.prReviewFollowUps, planFile)
+	branch := m.planBranch(planFile)
+	if branch == "" {
+		return nil
+	}
+	repoPath := m.activeRepoPath
+	forge := m.repoForge()
+	return func() tea.Msg {
+		shared := gitpkg.NewSharedPlanWorktree(repoPath, branch)
+		if err := shared.PushChanges("address review comments", false); err != nil {
+			return prReviewRepliedMsg{planFile: planFile, err: err}
+		}
+		if !followUp.reply {
+			return prReviewRepliedMsg{planFile: planFile, leftOpen: true}
+		}
+		sha, err := shared.HeadSHA()
+		if err != nil {
+			return prReviewRepliedMsg{planFile: planFile, err: err}
+		}
+		if len(sha) > 7 {
+			sha = sha[:7]
+		}
+		body := fmt.Sprintf("Addressed in %s.", sha)
+		replied, resolved := 0, followUp.resolve
+		for _, c := range followUp.comments {
+			if err := forge.ReplyToReview(repoPath, c, body, followUp.resolve); err != nil {
+				if !errors.Is(err, gitpkg.ErrUnsupported) {
+					return prReviewRepliedMsg{planFile: planFile, replied: replied, err: err}
+				}
+				resolved = false // replied, but the forge cannot resolve threads
+			}
+			replied++
+		}
+		return prReviewRepliedMsg{planFile: planFile, replied: replied, resolved: resolved}
+	}
+}
+
+// handlePRReviewReplied reports how the review threads were answered.
+func (m *home) handlePRReviewReplied(msg prReviewRepliedMsg) tea.Cmd {
+	planName := planstate.DisplayName(msg.planFile)
+	if msg.err != nil {
+		log.ErrorLog.Printf("answer review threads for %s: %v", msg.planFile, msg.err)
+		return m.handleError(fmt.Errorf("replied to %d review thread(s) of %s, then: %w", msg.replied, planName, msg.err))
+	}
+	if msg.leftOpen {
+		m.toastManager.Success(fmt.Sprintf("pushed review fixes for %s", planName))
+		return m.toastTickCmd()
+	}
+	verb := "replied to"
+	if msg.resolved {
+		verb = "replied to and resolved"
+	}
+	m.toastManager.Success(fmt.Sprintf("%s %d review thread(s) on %s", verb, msg.replied, planName))
+	return m.toastTickCmd()
+}
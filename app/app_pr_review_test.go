@@ -0,0 +1,96 @@
+package app
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kastheco/kasmos/cmd/cmd_test"
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/planstate"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPRReviewFeedback(t *testing.T) {
+	got := buildPRReviewFeedback([]gitpkg.ReviewComment{
+		{PR: 7, Path: "auth/token.go", Line: 42, Author: "alice", Body: "check the error\nhere too"},
+		{PR: 7, Path: "README.md", Author: "bob", Body: "typo"},
+		{PR: 7, Author: "carol", Body: "needs a test"},
+	})
+	assert.Contains(t, got, "pull request #7")
+	assert.Contains(t, got, "1. auth/token.go:42 (alice)\n   check the error\n   here too\n")
+	assert.Contains(t, got, "2. README.md (bob)\n   typo\n")
+	assert.Contains(t, got, "3. general (carol)\n   needs a test\n")
+}
+
+func TestFsmReopenForFixes(t *testing.T) {
+	for _, status := range []planstate.Status{planstate.StatusReviewing, planstate.StatusDone} {
+		t.Run(string(status), func(t *testing.T) {
+			plansDir := t.TempDir()
+			ps, err := newTestPlanState(t, plansDir)
+			require.NoError(t, err)
+			planFile := "2026-03-01-auth.md"
+			require.NoError(t, ps.Register(planFile, "auth", "plan/auth", time.Now()))
+			seedPlanStatus(t, ps, planFile, status)
+
+			m := newTestHomeWithToast()
+			m.planState = ps
+			m.fsm = newPlanFSMForTest(t, plansDir)
+
+			require.NoError(t, m.fsmReopenForFixes(planFile))
+			reloaded, err := newTestPlanState(t, plansDir)
+			require.NoError(t, err)
+			assert.Equal(t, planstate.StatusImplementing, reloaded.Plans[planFile].Status)
+		})
+	}
+}
+
+func TestHandlePRReviewFetched_OpensFollowUpPicker(t *testing.T) {
+	m := newTestHomeWithToast()
+	comments := []gitpkg.ReviewComment{{PR: 7, ThreadID: "T1", Author: "alice", Body: "nit"}}
+
+	m.handlePRReviewFetched(prReviewFetchedMsg{planFile: "2026-03-01-auth.md", comments: comments})
+	assert.Equal(t, statePRReviewFollowUp, m.state)
+	require.NotNil(t, m.pendingPRReview)
+	assert.Equal(t, comments, m.pendingPRReview.comments)
+
+	// Nothing is answered unless a follow-up was recorded.
+	assert.Nil(t, m.finishPRReview("2026-03-01-auth.md"))
+}
+
+func TestFinishPRReview_LeaveThreadsOpenStillPushes(t *testing.T) {
+	dir, ps, planFile := setupDriftRepo(t)
+	remote := filepath.Join(t.TempDir(), "origin.git")
+	git := func(args ...string) string {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoErrorf(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "--bare", remote)
+	git("-C", dir, "remote", "add", "origin", remote)
+	worktree := gitpkg.PlanWorktreePath(dir, "plan/auth")
+	git("-C", dir, "worktree", "add", worktree, "plan/auth")
+	git("-C", worktree, "commit", "--allow-empty", "-m", "address review")
+
+	m := newTestHomeWithToast()
+	m.planState = ps
+	m.activeRepoPath = dir
+	m.forge = gitpkg.NewForge(config.ForgeConfig{Type: config.ForgeGitHub}, "", cmd_test.NewMockExecutor())
+	m.forgeRepo = dir
+	m.prReviewFollowUps = map[string]prReviewFollowUp{
+		planFile: {comments: []gitpkg.ReviewComment{{PR: 7, ThreadID: "T1"}}},
+	}
+
+	cmd := m.finishPRReview(planFile)
+	require.NotNil(t, cmd)
+	msg, ok := cmd().(prReviewRepliedMsg)
+	require.True(t, ok)
+	require.NoError(t, msg.err)
+	assert.True(t, msg.leftOpen)
+	assert.Zero(t, msg.replied)
+	assert.Equal(t, git("-C", worktree, "rev-parse", "HEAD"), git("-C", remote, "rev-parse", "plan/auth"))
+}
@@ -0,0 +1,119 @@
+package app
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/kastheco/kasmos/cmd/cmd_test"
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/planstate"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ghStub returns a GitHub forge whose gh CLI answers `pr view` with out and
+// records the branches it was asked about.
+func ghStub(out string, branches *[]string) gitpkg.Forge {
+	mock := cmd_test.NewMockExecutor()
+	mock.OutputFunc = func(c *exec.Cmd) ([]byte, error) {
+		*branches = append(*branches, c.Args[3])
+		return []byte(out), nil
+	}
+	return gitpkg.NewForge(config.ForgeConfig{Type: config.ForgeGitHub}, "", mock)
+}
+
+func TestCollectPlanPRs(t *testing.T) {
+	ps, err := newTestPlanState(t, t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, ps.Register("2026-03-01-auth.md", "auth", "plan/auth", time.Now()))
+	require.NoError(t, ps.Register("2026-03-02-no-pr.md", "no pr", "plan/no-pr", time.Now()))
+	require.NoError(t, ps.SetPR("2026-03-01-auth.md", 7, "https://github.com/o/r/pull/7"))
+	branches := trackedPRBranches(ps)
+	require.Equal(t, map[string]string{"2026-03-01-auth.md": "plan/auth"}, branches)
+
+	var polled []string
+	forge := ghStub(`{"number":7,"url":"https://github.com/o/r/pull/7","state":"OPEN","reviewDecision":"APPROVED"}`, &polled)
+
+	prs := collectPlanPRs(forge, t.TempDir(), branches)
+	assert.Equal(t, []string{"plan/auth"}, polled, "only plans with a PR are polled")
+	require.Contains(t, prs, "2026-03-01-auth.md")
+	assert.Equal(t, gitpkg.PRStateOpen, prs["2026-03-01-auth.md"].State)
+	assert.Equal(t, gitpkg.ReviewApproved, prs["2026-03-01-auth.md"].Review)
+}
+
+func TestApplyPlanPRs_MergedPRMarksPlanDone(t *testing.T) {
+	plansDir := t.TempDir()
+	ps, err := newTestPlanState(t, plansDir)
+	require.NoError(t, err)
+	planFile := "2026-03-01-auth.md"
+	require.NoError(t, ps.Register(planFile, "auth", "plan/auth", time.Now()))
+	require.NoError(t, ps.SetPR(planFile, 7, "https://github.com/o/r/pull/7"))
+	seedPlanStatus(t, ps, planFile, planstate.StatusImplementing)
+
+	m := newTestHomeWithToast()
+	m.planState = ps
+	m.fsm = newPlanFSMForTest(t, plansDir)
+
+	var branches []string
+	forge := ghStub(`{"number":7,"url":"https://github.com/o/r/pull/7","state":"MERGED"}`, &branches)
+	changed := m.applyPlanPRs(collectPlanPRs(forge, t.TempDir(), trackedPRBranches(ps)))
+	assert.True(t, changed)
+
+	reloaded, err := newTestPlanState(t, plansDir)
+	require.NoError(t, err)
+	assert.Equal(t, planstate.StatusDone, reloaded.Plans[planFile].Status)
+	assert.Equal(t, gitpkg.PRStateMerged, m.planPRs[planFile].State)
+
+	// Done plans are no longer polled.
+	assert.Empty(t, trackedPRBranches(reloaded))
+}
+
+func TestPollPlanPRs_ThrottledAndSingleFlight(t *testing.T) {
+	ps, err := newTestPlanState(t, t.TempDir())
+	require.NoError(t, err)
+	planFile := "2026-03-01-auth.md"
+	require.NoError(t, ps.Register(planFile, "auth", "plan/auth", time.Now()))
+	require.NoError(t, ps.SetPR(planFile, 7, "https://github.com/o/r/pull/7"))
+
+	var polled []string
+	m := newTestHomeWithToast()
+	m.planState = ps
+	m.forge = ghStub(`{"number":7,"url":"https://github.com/o/r/pull/7","state":"OPEN"}`, &polled)
+	m.forgeRepo = m.activeRepoPath
+
+	cmd := m.pollPlanPRs()
+	require.NotNil(t, cmd)
+	assert.Nil(t, m.pollPlanPRs(), "one check at a time")
+
+	msg, ok := cmd().(planPRsMsg)
+	require.True(t, ok)
+	m.handlePlanPRs(msg)
+	assert.Equal(t, []string{"plan/auth"}, polled)
+	assert.Equal(t, gitpkg.PRStateOpen, m.planPRs[planFile].State)
+	assert.Nil(t, m.pollPlanPRs(), "throttled until prCheckInterval passes")
+
+	m.lastPRCheck = time.Now().Add(-prCheckInterval)
+	assert.NotNil(t, m.pollPlanPRs())
+}
+
+func TestPRCreated_RecordsPlanPR(t *testing.T) {
+	ps, err := newTestPlanState(t, t.TempDir())
+	require.NoError(t, err)
+	planFile := "2026-03-01-auth.md"
+	require.NoError(t, ps.Register(planFile, "auth", "plan/auth", time.Now()))
+
+	m := newTestHomeWithToast()
+	m.planState = ps
+	pr := &gitpkg.PullRequest{Number: 9, URL: "https://gitlab.example.com/o/r/-/merge_requests/9", State: gitpkg.PRStateOpen}
+	_, _ = m.Update(prCreatedMsg{instanceTitle: "auth-implement", prTitle: "Add auth", planFile: planFile, pr: pr})
+
+	entry, ok := ps.Entry(planFile)
+	require.True(t, ok)
+	assert.Equal(t, 9, entry.PRNumber)
+	assert.Equal(t, pr.URL, entry.PRURL)
+	got, ok := m.planPRFor(planFile, entry)
+	require.True(t, ok)
+	assert.Equal(t, gitpkg.PRStateOpen, got.State)
+}
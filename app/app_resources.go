@@ -0,0 +1,83 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/log"
+	"github.com/kastheco/kasmos/session"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// enforceResourceLimits checks inst's latest usage against the [limits]
+// configured for its role. A soft breach warns; a hard breach stops the
+// instance's process tree and then pauses (keeping the branch) or kills it. Each crossing is reported once and recorded in the audit log.
+func (m *home) enforceResourceLimits(inst *session.Instance) tea.Cmd {
+	if m.appConfig == nil || len(m.appConfig.Limits) == 0 {
+		return nil
+	}
+	limits := m.appConfig.LimitsFor(inst.AgentType)
+	if limits.IsZero() {
+		return nil
+	}
+	breach, ok := inst.CheckResourceLimits(limits)
+	if !ok {
+		return nil
+	}
+
+	opts := []auditlog.EventOption{
+		auditlog.WithInstance(inst.Title),
+		auditlog.WithAgent(inst.AgentType),
+		auditlog.WithPlan(inst.PlanFile),
+	}
+	if breach.Level == session.LimitSoft {
+		m.audit(auditlog.EventResourceLimit, breach.Reason,
+			append(opts, auditlog.WithLevel("warn"), auditlog.WithDetail("warn"))...)
+		m.toastManager.Warning(fmt.Sprintf("%s: %s", inst.Title, breach.Reason))
+		return m.toastTickCmd()
+	}
+
+	action := limits.HardAction
+	m.audit(auditlog.EventResourceLimit, breach.Reason,
+		append(opts, auditlog.WithLevel("error"), auditlog.WithDetail(action))...)
+	// Stop the whole process tree first: Pause only detaches and drops the
+	// worktree, which would leave the agent running unsupervised.
+	if err := inst.StopProcessTree(); err != nil {
+		log.WarningLog.Printf("could not stop %s over resource limit: %v", inst.Title, err)
+	}
+	switch action {
+	case config.LimitActionKill:
+		m.killInstance(inst)
+		m.toastManager.Error(fmt.Sprintf("%s killed: %s", inst.Title, breach.Reason))
+	default:
+		if err := inst.Pause(); err != nil {
+			return m.handleError(fmt.Errorf("could not pause %s over resource limit: %w", inst.Title, err))
+		}
+		m.audit(auditlog.EventAgentPaused, "agent paused", opts...)
+		m.toastManager.Error(fmt.Sprintf("%s paused: %s", inst.Title, breach.Reason))
+	}
+	m.saveAllInstances()
+	m.updateNavPanelStatus()
+	return tea.Batch(m.toastTickCmd(), m.instanceChanged())
+}
+
+// killInstance kills inst and drops it from the model, dismissing its
+// permission modal if one is open.
+func (m *home) killInstance(inst *session.Instance) {
+	m.audit(auditlog.EventAgentKilled, "agent killed",
+		auditlog.WithInstance(inst.Title),
+		auditlog.WithAgent(inst.AgentType),
+		auditlog.WithProgram(inst.Program),
+		auditlog.WithPlan(inst.PlanFile),
+	)
+	if m.pendingPermissionInstance == inst {
+		m.permissionOverlay = nil
+		m.pendingPermissionInstance = nil
+		m.state = stateDefault
+	}
+	_ = inst.Kill()
+	m.nav.RemoveByTitle(inst.Title)
+	m.removeFromAllInstances(inst.Title)
+}
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceLimits_SoftWarnsOnceAndHardKills(t *testing.T) {
+	logger, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+	defer logger.Close()
+
+	m := newTestHomeWithToast()
+	m.auditLogger = logger
+	m.planStoreProject = "proj"
+	m.appConfig.Limits = map[string]config.ResourceLimits{
+		config.DefaultLimitsRole: {SoftMemoryMB: 500},
+		session.AgentTypeCoder:   {HardMemoryMB: 1000, HardAction: config.LimitActionKill},
+	}
+	inst := &session.Instance{Title: "auth-W1-T1", Program: "opencode", AgentType: session.AgentTypeCoder}
+	inst.MarkStartedForTest()
+	m.nav.AddInstance(inst)()
+	m.allInstances = append(m.allInstances, inst)
+
+	tick := func(memMB float64) {
+		_, _ = m.Update(metadataResultMsg{Results: []instanceMetadata{{
+			Title:              inst.Title,
+			Resources:          session.ResourceUsage{MemMB: memMB, CPUPercent: 10},
+			ResourceUsageValid: true,
+		}}})
+	}
+	query := func() []auditlog.Event {
+		events, err := logger.Query(auditlog.QueryFilter{
+			Project: "proj",
+			Kinds:   []auditlog.EventKind{auditlog.EventResourceLimit},
+			Limit:   10,
+		})
+		require.NoError(t, err)
+		return events
+	}
+
+	tick(600)
+	tick(650)
+	events := query()
+	require.Len(t, events, 1, "soft breach is reported once")
+	assert.Equal(t, "warn", events[0].Level)
+	assert.True(t, m.toastManager.HasActiveToasts())
+	assert.Len(t, inst.ResourceHistory(), 2)
+
+	tick(1200)
+	events = query()
+	require.Len(t, events, 2)
+	assert.Equal(t, "error", events[0].Level)
+	assert.Equal(t, config.LimitActionKill, events[0].Detail)
+	assert.Empty(t, m.allInstances, "killed instance is removed")
+}
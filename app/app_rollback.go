@@ -0,0 +1,178 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/config/planparser"
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/orchestration"
+	"github.com/kastheco/kasmos/session"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+	"github.com/kastheco/kasmos/ui/overlay"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// rollbackChoicePrefix starts each entry of the rollback picker.
+const rollbackChoicePrefix = "before wave "
+
+// waveRollbackMsg asks Update to roll planFile back to the checkpoint taken
+// before wave, once the user has confirmed.
+type waveRollbackMsg struct {
+	planFile string
+	wave     int
+}
+
+// waveRolledBackMsg reports a finished rollback of a plan branch.
+type waveRolledBackMsg struct {
+	planFile string
+	wave     int
+	sha      string
+	err      error
+}
+
+// openRollbackPicker lists the plan's wave checkpoints to roll back to.
+func (m *home) openRollbackPicker(planFile string) (tea.Model, tea.Cmd) {
+	checkpoints, err := gitpkg.WaveCheckpoints(m.activeRepoPath, planFile)
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	if len(checkpoints) == 0 {
+		m.toastManager.Info("no wave checkpoints recorded for this plan")
+		return m, m.toastTickCmd()
+	}
+	// Latest first: rolling back the last wave is the common case.
+	choices := make([]string, 0, len(checkpoints))
+	for i := len(checkpoints) - 1; i >= 0; i-- {
+		choices = append(choices, rollbackChoicePrefix+strconv.Itoa(checkpoints[i].Wave))
+	}
+	m.pendingRollbackPlan = planFile
+	m.pickerOverlay = overlay.NewPickerOverlay("roll back to", choices)
+	m.state = stateRollbackWave
+	return m, nil
+}
+
+// parseRollbackChoice returns the wave number of a rollback picker entry.
+func parseRollbackChoice(choice string) (int, bool) {
+	wave, err := strconv.Atoi(strings.TrimPrefix(choice, rollbackChoicePrefix))
+	return wave, err == nil
+}
+
+// confirmRollback asks before discarding the plan's work since wave started.
+func (m *home) confirmRollback(planFile string, wave int) tea.Cmd {
+	planName := planstate.DisplayName(planFile)
+	return m.confirmAction(
+		fmt.Sprintf("roll '%s' back to before wave %d? its agents are killed and all later work is discarded.", planName, wave),
+		func() tea.Msg { return waveRollbackMsg{planFile: planFile, wave: wave} })
+}
+
+// rollbackPlanCmd removes the plan's agents, then resets its branch to the
+// checkpoint taken before wave in the background.
+func (m *home) rollbackPlanCmd(planFile string, wave int) tea.Cmd {
+	if m.planState == nil {
+		return nil
+	}
+	entry, ok := m.planState.Entry(planFile)
+	if !ok {
+		return m.handleError(fmt.Errorf("plan not found: %s", planFile))
+	}
+	if entry.Branch == "" {
+		return m.handleError(fmt.Errorf("plan has no branch to roll back"))
+	}
+
+	var stale []*session.Instance
+	for _, inst := range m.allInstances {
+		if inst.PlanFile == planFile {
+			stale = append(stale, inst)
+		}
+	}
+	for _, inst := range stale {
+		m.nav.RemoveByTitle(inst.Title)
+		m.removeFromAllInstances(inst.Title)
+	}
+	delete(m.waveOrchestrators, planFile)
+	_ = m.saveAllInstances()
+
+	repoPath := m.activeRepoPath
+	branch := entry.Branch
+	return func() tea.Msg {
+		for _, inst := range stale {
+			_ = inst.Kill()
+		}
+		sha, err := gitpkg.RollbackToCheckpoint(repoPath, branch, planFile, wave)
+		return waveRolledBackMsg{planFile: planFile, wave: wave, sha: sha, err: err}
+	}
+}
+
+// handleWaveRolledBack reopens the plan at the rolled-back wave: a fresh
+// orchestrator counts the earlier waves as done and starts that wave again.
+func (m *home) handleWaveRolledBack(msg waveRolledBackMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		return m, m.handleError(msg.err)
+	}
+	planName := planstate.DisplayName(msg.planFile)
+	m.audit(auditlog.EventWaveRolledBack,
+		fmt.Sprintf("rolled back to before wave %d: %s", msg.wave, planName),
+		auditlog.WithPlan(msg.planFile),
+		auditlog.WithWave(msg.wave, 0),
+		auditlog.WithDetail(msg.sha))
+	m.lastDriftCheck = time.Time{}
+
+	content, err := os.ReadFile(filepath.Join(m.activeRepoPath, "docs", "plans", msg.planFile))
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	plan, err := planparser.Parse(string(content))
+	if err != nil {
+		return m, m.handleError(err)
+	}
+	orch := orchestration.NewWaveOrchestrator(msg.planFile, plan)
+	if !orch.Rewind(msg.wave) {
+		return m, m.handleError(fmt.Errorf("plan %s has no wave %d", planName, msg.wave))
+	}
+
+	if err := m.fsmReopenImplementing(msg.planFile); err != nil {
+		return m, m.handleError(err)
+	}
+	m.loadPlanState()
+	m.updateSidebarPlans()
+	entry, ok := m.planState.Entry(msg.planFile)
+	if !ok {
+		return m, m.handleError(fmt.Errorf("plan not found: %s", msg.planFile))
+	}
+	m.waveOrchestrators[msg.planFile] = orch
+	m.toastManager.Success(fmt.Sprintf("rolled %s back to before wave %d", planName, msg.wave))
+	model, cmd := m.startNextWave(orch, entry)
+	return model, tea.Batch(cmd, m.toastTickCmd())
+}
+
+// fsmReopenImplementing moves a plan back to implementing after a rollback,
+// including from review or done.
+func (m *home) fsmReopenImplementing(planFile string) error {
+	entry, ok := m.planState.Entry(planFile)
+	if !ok {
+		return fmt.Errorf("plan not found: %s", planFile)
+	}
+	var event planfsm.Event
+	switch entry.Status {
+	case planstate.StatusReviewing:
+		event = planfsm.ReviewChangesRequested
+	case planstate.StatusDone:
+		event = planfsm.Reimplement
+	default:
+		return m.fsmSetImplementing(planFile)
+	}
+	if err := m.fsm.Transition(planFile, event); err != nil {
+		return err
+	}
+	m.audit(auditlog.EventPlanTransition, string(entry.Status)+" → implementing (rollback)",
+		auditlog.WithPlan(planFile))
+	return nil
+}
@@ -0,0 +1,81 @@
+package app
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/orchestration"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRollbackChoice(t *testing.T) {
+	wave, ok := parseRollbackChoice("before wave 3")
+	assert.True(t, ok)
+	assert.Equal(t, 3, wave)
+	_, ok = parseRollbackChoice("")
+	assert.False(t, ok)
+}
+
+func TestRollbackPlan_ReopensWave(t *testing.T) {
+	dir, ps, planFile := setupDriftRepo(t)
+	plansDir := filepath.Join(dir, "docs", "plans")
+	content := "# Auth\n\n## Wave 1\n\n### Task 1: Models\n\nx\n\n## Wave 2\n\n### Task 2: Handlers\n\ny\n\n## Wave 3\n\n### Task 3: Docs\n\nz\n"
+	require.NoError(t, os.WriteFile(filepath.Join(plansDir, planFile), []byte(content), 0o644))
+	seedPlanStatus(t, ps, planFile, planstate.StatusReviewing)
+
+	worktree := gitpkg.PlanWorktreePath(dir, "plan/auth")
+	git := func(args ...string) string {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoErrorf(t, err, "git %v: %s", args, out)
+		return strings.TrimSpace(string(out))
+	}
+	git("-C", dir, "worktree", "add", worktree, "plan/auth")
+	var wave2 string
+	for wave := 1; wave <= 3; wave++ {
+		sha, err := gitpkg.CreateWaveCheckpoint(dir, "plan/auth", planFile, wave)
+		require.NoError(t, err)
+		if wave == 2 {
+			wave2 = sha
+		}
+		git("-C", worktree, "commit", "--allow-empty", "-m", "wave work")
+	}
+
+	m := waveFlowHome(t, ps, plansDir, make(map[string]*orchestration.WaveOrchestrator))
+	m.fsm = newPlanFSMForTest(t, plansDir)
+	m.planStore = storeForDir(t, plansDir)
+	m.planStoreProject = "test"
+	m.activeRepoPath = dir
+
+	msg, ok := m.rollbackPlanCmd(planFile, 2)().(waveRolledBackMsg)
+	require.True(t, ok)
+	require.NoError(t, msg.err)
+	assert.Equal(t, wave2, msg.sha)
+	m.handleWaveRolledBack(msg)
+
+	assert.Equal(t, wave2, git("-C", dir, "rev-parse", "plan/auth"))
+	assert.Equal(t, planstate.StatusImplementing, m.planState.Plans[planFile].Status)
+
+	orch := m.waveOrchestrators[planFile]
+	require.NotNil(t, orch)
+	assert.Equal(t, 2, orch.CurrentWaveNumber())
+	assert.True(t, orch.IsTaskComplete(1))
+	assert.True(t, orch.IsTaskRunning(2))
+	assert.False(t, orch.IsTaskComplete(3))
+
+	var titles []string
+	for _, inst := range m.nav.GetInstances() {
+		titles = append(titles, inst.Title)
+	}
+	assert.Equal(t, []string{"auth-W2-T2"}, titles)
+
+	checkpoints, err := gitpkg.WaveCheckpoints(dir, planFile)
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 2, "the wave 3 checkpoint is dropped")
+	assert.Equal(t, wave2, checkpoints[1].SHA)
+}
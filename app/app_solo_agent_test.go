@@ -10,6 +10,7 @@ import (
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/kastheco/kasmos/config"
 	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/orchestration"
 	"github.com/kastheco/kasmos/session"
 	"github.com/kastheco/kasmos/ui"
 	"github.com/kastheco/kasmos/ui/overlay"
@@ -55,7 +56,7 @@ func TestSoloAgent_NoAutomaticPushPromptOnExit(t *testing.T) {
 		planState:         ps,
 		planStateDir:      plansDir,
 		fsm:               fsm,
-		waveOrchestrators: make(map[string]*WaveOrchestrator),
+		waveOrchestrators: make(map[string]*orchestration.WaveOrchestrator),
 	}
 
 	msg := metadataResultMsg{
@@ -0,0 +1,179 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/session"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+	"github.com/kastheco/kasmos/ui/overlay"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// stackParentBranch returns the branch planFile is stacked on: its parent
+// plan's branch while the parent is still open. Plans that are not stacked,
+// or whose parent has landed or was cancelled, build on the default branch
+// and get "".
+func stackParentBranch(ps *planstate.PlanState, planFile string) string {
+	entry, ok := ps.Entry(planFile)
+	if !ok || entry.Parent == "" {
+		return ""
+	}
+	parent, ok := ps.Entry(entry.Parent)
+	if !ok || parent.Status == planstate.StatusDone || parent.Status == planstate.StatusCancelled {
+		return ""
+	}
+	return parent.Branch
+}
+
+// stackedOnLabel names the plan entry is stacked on for the info pane,
+// noting when the parent has landed and the restack is still pending.
+func (m *home) stackedOnLabel(entry planstate.PlanEntry) string {
+	if entry.Parent == "" {
+		return ""
+	}
+	label := planstate.DisplayName(entry.Parent)
+	if parent, ok := m.planState.Entry(entry.Parent); ok && parent.Status == planstate.StatusDone {
+		label += " (landed, restack pending)"
+	}
+	return label
+}
+
+// sharedPlanWorktree returns the plan's shared worktree. Stacked plans branch
+// off their parent's branch, which is created from HEAD first if the parent
+// has not started yet.
+func (m *home) sharedPlanWorktree(planFile, branch string) *gitpkg.GitWorktree {
+	shared := gitpkg.NewSharedPlanWorktree(m.activeRepoPath, branch)
+	if m.planState == nil {
+		return shared
+	}
+	if base := stackParentBranch(m.planState, planFile); base != "" {
+		if err := gitpkg.EnsurePlanBranch(m.activeRepoPath, base); err != nil {
+			return shared
+		}
+		shared.SetBaseBranch(base)
+	}
+	return shared
+}
+
+// livePlanAgent returns a running agent of the plan, or nil. Rewriting a plan
+// branch's history under a live agent loses its work.
+func (m *home) livePlanAgent(planFile string) *session.Instance {
+	for _, inst := range m.nav.GetInstances() {
+		if inst.PlanFile == planFile && inst.Started() && !inst.Paused() && !inst.Exited {
+			return inst
+		}
+	}
+	return nil
+}
+
+// rememberStackTip records the plan's branch tip before the branch is merged
+// and deleted, so plans stacked on it can be moved onto the default branch
+// without replaying the parent's commits.
+func (m *home) rememberStackTip(planFile, branch string) {
+	if m.planState == nil || len(m.planState.Children(planFile)) == 0 {
+		return
+	}
+	tip, err := gitpkg.BranchTip(m.activeRepoPath, branch)
+	if err != nil {
+		return
+	}
+	if m.stackTips == nil {
+		m.stackTips = make(map[string]string)
+	}
+	m.stackTips[planFile] = tip
+}
+
+// restackStackedPlans keeps stacked plans on top of their parent: plans whose
+// parent branch moved are rebased onto it, and plans whose parent landed are
+// moved onto the default branch and unstacked. Plans with a live agent, a
+// restack in flight or an unresolved restack conflict are left alone.
+func (m *home) restackStackedPlans() tea.Cmd {
+	if m.planState == nil {
+		return nil
+	}
+	var cmds []tea.Cmd
+	for filename, entry := range m.planState.Plans {
+		if entry.Parent == "" || entry.Branch == "" || entry.Status == planstate.StatusDone || entry.Status == planstate.StatusCancelled {
+			continue
+		}
+		if m.restacking[filename] || m.restackConflicts[filename] || m.livePlanAgent(filename) != nil {
+			continue
+		}
+		parent, ok := m.planState.Entry(entry.Parent)
+		if !ok || parent.Branch == "" {
+			continue
+		}
+		switch {
+		case parent.Status == planstate.StatusDone:
+			cmds = append(cmds, m.restackPlanCmd(filename, entry.Branch, parent.Branch, m.stackTips[entry.Parent]))
+		case parent.Status != planstate.StatusCancelled && m.planDrift[filename].Base == parent.Branch && m.planDrift[filename].Behind > 0:
+			cmds = append(cmds, m.restackPlanCmd(filename, entry.Branch, parent.Branch, ""))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// restackPlanCmd moves branch onto its parent in the background. With
+// landedTip set, or when the parent branch is already gone, the parent has
+// landed: the plan's own commits move onto the default branch and the plan is
+// unstacked once that succeeds.
+func (m *home) restackPlanCmd(planFile, branch, parentBranch, landedTip string) tea.Cmd {
+	if m.restacking == nil {
+		m.restacking = make(map[string]bool)
+	}
+	m.restacking[planFile] = true
+	parentLanded := m.planState.Plans[m.planState.Plans[planFile].Parent].Status == planstate.StatusDone
+	repoPath := m.activeRepoPath
+	return func() tea.Msg {
+		msg := planRebasedMsg{planFile: planFile, base: parentBranch, restack: true, unstack: parentLanded}
+		if _, err := gitpkg.BranchTip(repoPath, branch); err != nil {
+			// Not started yet: it will branch off the right base when it does.
+			return msg
+		}
+		onto, upstream := parentBranch, parentBranch
+		if parentLanded {
+			onto = gitpkg.DefaultBranch(repoPath)
+			msg.base = onto
+			if landedTip != "" {
+				upstream = landedTip
+			} else if _, err := gitpkg.BranchTip(repoPath, parentBranch); err != nil {
+				upstream = onto
+			}
+		}
+		msg.newBase, msg.err = gitpkg.RestackPlanBranch(repoPath, branch, onto, upstream)
+		return msg
+	}
+}
+
+// startStackedPlan opens the new plan form for a plan stacked on parent.
+func (m *home) startStackedPlan(parent string) (tea.Model, tea.Cmd) {
+	if m.planState == nil {
+		return m, nil
+	}
+	entry, ok := m.planState.Entry(parent)
+	if !ok {
+		return m, m.handleError(fmt.Errorf("plan not found: %s", parent))
+	}
+	if entry.Status == planstate.StatusDone || entry.Status == planstate.StatusCancelled {
+		return m, m.handleError(fmt.Errorf("cannot stack on a %s plan", entry.Status))
+	}
+	m.openNewPlanForm(parent)
+	return m, nil
+}
+
+// openNewPlanForm opens the new plan description form. A non-empty parent
+// stacks the new plan on that plan's branch.
+func (m *home) openNewPlanForm(parent string) {
+	title := "new plan"
+	if parent != "" {
+		title = "new plan stacked on " + planstate.DisplayName(parent)
+	}
+	m.pendingPlanParent = parent
+	m.state = stateNewPlan
+	m.textInputOverlay = overlay.NewTextInputOverlay(title, "")
+	m.textInputOverlay.SetMultiline(true)
+	m.textInputOverlay.SetPlaceholder("describe what you want to work on...")
+	m.textInputOverlay.SetSize(70, 8)
+}
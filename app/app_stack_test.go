@@ -0,0 +1,84 @@
+package app
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kastheco/kasmos/config/planstate"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupStackRepo extends setupDriftRepo with plan/ui stacked on plan/auth,
+// after which plan/auth gains a commit the stacked plan lacks.
+func setupStackRepo(t *testing.T) (string, *planstate.PlanState, string, string) {
+	t.Helper()
+	dir, ps, parent := setupDriftRepo(t)
+	child := "2026-03-02-ui.md"
+	require.NoError(t, ps.Register(child, "ui", "plan/ui", time.Now()))
+	require.NoError(t, ps.SetParent(child, parent))
+	require.NoError(t, gitpkg.EnsurePlanBranchFrom(dir, "plan/ui", "plan/auth"))
+	authTree := gitpkg.PlanWorktreePath(dir, "plan/auth")
+	for _, args := range [][]string{
+		{"-C", dir, "worktree", "add", authTree, "plan/auth"},
+		{"-C", authTree, "commit", "--allow-empty", "-m", "auth moves on"},
+	} {
+		out, err := exec.Command("git", args...).CombinedOutput()
+		require.NoErrorf(t, err, "git %v: %s", args, out)
+	}
+	return dir, ps, parent, child
+}
+
+func TestStackParentBranch(t *testing.T) {
+	_, ps, parent, child := setupStackRepo(t)
+	assert.Equal(t, "plan/auth", stackParentBranch(ps, child))
+	assert.Empty(t, stackParentBranch(ps, parent))
+
+	require.NoError(t, ps.ForceSetStatus(parent, planstate.StatusDone))
+	assert.Empty(t, stackParentBranch(ps, child), "landed parents no longer hold the stack")
+}
+
+func TestRestackStackedPlans_FollowsParent(t *testing.T) {
+	dir, ps, _, child := setupStackRepo(t)
+	m := newTestHomeWithToast()
+	m.planState = ps
+	m.activeRepoPath = dir
+	m.planDrift = collectPlanDrift(dir, ps)
+	require.Equal(t, "plan/auth", m.planDrift[child].Base, "stacked plans drift against their parent")
+	require.Equal(t, 1, m.planDrift[child].Behind)
+
+	require.NotNil(t, m.restackStackedPlans())
+	assert.Equal(t, map[string]bool{child: true}, m.restacking, "only the stacked plan moves")
+
+	msg, ok := m.restackPlanCmd(child, "plan/ui", "plan/auth", "")().(planRebasedMsg)
+	require.True(t, ok)
+	require.NoError(t, msg.err)
+	assert.False(t, msg.unstack)
+	m.handlePlanRebased(msg)
+
+	d, err := gitpkg.PlanBranchDrift(dir, "plan/ui", "plan/auth")
+	require.NoError(t, err)
+	assert.Equal(t, 0, d.Behind)
+	assert.Empty(t, m.restacking)
+}
+
+func TestRestackStackedPlans_ParentLanded(t *testing.T) {
+	dir, ps, parent, child := setupStackRepo(t)
+	m := newTestHomeWithToast()
+	m.planState = ps
+	m.activeRepoPath = dir
+	require.NoError(t, ps.ForceSetStatus(parent, planstate.StatusDone))
+
+	msg, ok := m.restackPlanCmd(child, "plan/ui", "plan/auth", "")().(planRebasedMsg)
+	require.True(t, ok)
+	require.NoError(t, msg.err)
+	assert.True(t, msg.unstack)
+	assert.Equal(t, gitpkg.DefaultBranch(dir), msg.base)
+	m.handlePlanRebased(msg)
+
+	assert.Empty(t, ps.Plans[child].Parent, "the plan is unstacked once it sits on the default branch")
+	assert.DirExists(t, filepath.Join(dir, ".worktrees", "plan-ui"))
+}
@@ -5,20 +5,20 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/glamour"
 	cmd2 "github.com/kastheco/kasmos/cmd"
-	"github.com/kastheco/kasmos/config"
 	"github.com/kastheco/kasmos/config/auditlog"
 	"github.com/kastheco/kasmos/config/planfsm"
 	"github.com/kastheco/kasmos/config/planparser"
 	"github.com/kastheco/kasmos/config/planstate"
-	"github.com/kastheco/kasmos/internal/clickup"
-	"github.com/kastheco/kasmos/internal/initcmd/scaffold"
 	"github.com/kastheco/kasmos/keys"
 	"github.com/kastheco/kasmos/log"
+	"github.com/kastheco/kasmos/orchestration"
 	"github.com/kastheco/kasmos/session"
 	gitpkg "github.com/kastheco/kasmos/session/git"
 	"github.com/kastheco/kasmos/session/tmux"
@@ -88,12 +88,12 @@ func (m *home) computeStatusBarData() ui.StatusBarData {
 					tasks := orch.CurrentWaveTasks()
 					data.TaskGlyphs = make([]ui.TaskGlyph, len(tasks))
 					for i, task := range tasks {
-						switch orch.taskStates[task.Number] {
-						case taskComplete:
+						switch orch.TaskStatus(task.Number) {
+						case orchestration.TaskComplete:
 							data.TaskGlyphs[i] = ui.TaskGlyphComplete
-						case taskFailed:
+						case orchestration.TaskFailed:
 							data.TaskGlyphs[i] = ui.TaskGlyphFailed
-						case taskRunning:
+						case orchestration.TaskRunning:
 							data.TaskGlyphs[i] = ui.TaskGlyphRunning
 						default:
 							data.TaskGlyphs[i] = ui.TaskGlyphPending
@@ -279,9 +279,11 @@ func (m *home) saveAllInstances() error {
 }
 
 // removeFromAllInstances removes an instance from the master list by title.
+// Plan-bound instances have their activity timeline archived first.
 func (m *home) removeFromAllInstances(title string) {
 	for i, inst := range m.allInstances {
 		if inst.Title == title {
+			m.archiveInstanceActivity(inst)
 			m.allInstances = append(m.allInstances[:i], m.allInstances[i+1:]...)
 			return
 		}
@@ -338,7 +340,18 @@ func (m *home) instanceChanged() tea.Cmd {
 		}
 	}
 
-	m.tabbedWindow.UpdateDiff(selected)
+	// Reload the archived activity of a newly selected plan, picking up
+	// timelines archived elsewhere (e.g. by the daemon) since it was cached.
+	selectedPlan := ""
+	if m.nav.IsSelectedPlanHeader() {
+		selectedPlan = m.nav.GetSelectedPlanFile()
+	}
+	if selectedPlan != m.archivedActivityPlan {
+		delete(m.archivedActivity, selectedPlan)
+		m.archivedActivityPlan = selectedPlan
+	}
+
+	diffCmd := m.tabbedWindow.UpdateDiff(selected)
 	m.tabbedWindow.SetInstance(selected)
 	m.updateInfoPane()
 	// Update menu with current instance
@@ -359,6 +372,9 @@ func (m *home) instanceChanged() tea.Cmd {
 	if spawnCmd != nil {
 		cmds = append(cmds, spawnCmd)
 	}
+	if diffCmd != nil {
+		cmds = append(cmds, diffCmd)
+	}
 
 	if len(cmds) == 0 {
 		return nil
@@ -436,10 +452,16 @@ func (m *home) updateInfoPaneForPlanHeader() {
 		PlanStatus:           string(entry.Status),
 		PlanTopic:            entry.Topic,
 		PlanBranch:           entry.Branch,
+		PlanStackedOn:        m.stackedOnLabel(entry),
+		PlanCollisions:       m.collisionSummary(planFile),
 	}
 	if !entry.CreatedAt.IsZero() {
 		data.PlanCreated = entry.CreatedAt.Format("2006-01-02")
 	}
+	if d, ok := m.planDriftFor(planFile); ok {
+		data.PlanBase, data.PlanAhead, data.PlanBehind = d.Base, d.Ahead, d.Behind
+	}
+	m.setInfoPanePR(&data, planFile, entry)
 	// Count instances belonging to this plan.
 	for _, inst := range m.nav.GetInstances() {
 		if inst.PlanFile != planFile {
@@ -455,6 +477,9 @@ func (m *home) updateInfoPaneForPlanHeader() {
 			data.PlanReadyCount++
 		}
 	}
+	instances := m.nav.GetInstances()
+	data.Activity = activityEntries(m.planActivityHistory(planFile))
+	data.OverlapFiles = planOverlapFiles(instances, planFile, "")
 	// Include wave progress if an orchestrator exists for this plan.
 	if orch, ok := m.waveOrchestrators[planFile]; ok {
 		data.TotalWaves = orch.TotalWaves()
@@ -499,12 +524,32 @@ func (m *home) updateInfoPane() {
 		AgentType:   selected.AgentType,
 		TaskNumber:  selected.TaskNumber,
 		WaveNumber:  selected.WaveNumber,
+		CPUPercent:  selected.CPUPercent,
+		MemMB:       selected.MemMB,
+		CPUSeconds:  selected.CPUSeconds,
+	}
+
+	if history := selected.ResourceHistory(); len(history) > 0 {
+		data.CPUHistory = make([]float64, len(history))
+		data.MemHistory = make([]float64, len(history))
+		for i, s := range history {
+			data.CPUHistory[i] = s.CPUPercent
+			data.MemHistory[i] = s.MemMB
+		}
 	}
 
 	if !selected.CreatedAt.IsZero() {
 		data.Created = selected.CreatedAt.Format("2006-01-02 15:04")
 	}
 
+	timeline := selected.ActivityTimeline()
+	own := make([]session.InstanceActivity, len(timeline))
+	for i, a := range timeline {
+		own[i] = session.InstanceActivity{Activity: a}
+	}
+	data.Activity = activityEntries(own)
+	data.OverlapFiles = planOverlapFiles(m.nav.GetInstances(), selected.PlanFile, selected.Title)
+
 	if selected.PlanFile != "" {
 		if m.planState != nil {
 			entry, ok := m.planState.Entry(selected.PlanFile)
@@ -515,9 +560,14 @@ func (m *home) updateInfoPane() {
 				data.PlanStatus = string(entry.Status)
 				data.PlanTopic = entry.Topic
 				data.PlanBranch = entry.Branch
+				data.PlanStackedOn = m.stackedOnLabel(entry)
 				if !entry.CreatedAt.IsZero() {
 					data.PlanCreated = entry.CreatedAt.Format("2006-01-02")
 				}
+				if d, ok := m.planDriftFor(selected.PlanFile); ok {
+					data.PlanBase, data.PlanAhead, data.PlanBehind = d.Base, d.Ahead, d.Behind
+				}
+				m.setInfoPanePR(&data, selected.PlanFile, entry)
 			}
 		}
 
@@ -543,6 +593,132 @@ func (m *home) updateInfoPane() {
 	m.tabbedWindow.SetInfoData(data)
 }
 
+// archiveInstanceActivity records a plan-bound instance's activity timeline as
+// an audit event so the plan's history outlives the instance.
+func (m *home) archiveInstanceActivity(inst *session.Instance) {
+	if inst.PlanFile == "" {
+		return
+	}
+	timeline := inst.ActivityTimeline()
+	if len(timeline) == 0 {
+		return
+	}
+	detail, err := session.MarshalActivity(timeline)
+	if err != nil {
+		log.WarningLog.Printf("could not archive activity for %q: %v", inst.Title, err)
+		return
+	}
+	m.audit(auditlog.EventActivityArchived,
+		fmt.Sprintf("archived %d activity entries for %s", len(timeline), inst.Title),
+		auditlog.WithPlan(inst.PlanFile),
+		auditlog.WithInstance(inst.Title),
+		auditlog.WithAgent(inst.AgentType),
+		auditlog.WithWave(inst.WaveNumber, inst.TaskNumber),
+		auditlog.WithDetail(detail),
+	)
+	m.archivedActivityGen++
+	if archived, ok := m.archivedActivity[inst.PlanFile]; ok {
+		for _, a := range timeline {
+			archived = append(archived, session.InstanceActivity{Activity: a, Instance: inst.Title})
+		}
+		m.archivedActivity[inst.PlanFile] = archived
+	}
+}
+
+// planActivityHistory returns the full activity history of planFile, oldest
+// first: timelines of live instances merged with those archived from removed
+// ones. Archived timelines are loaded by the metadata tick once the plan is
+// selected and then kept up to date by archiveInstanceActivity; until then
+// only live timelines are shown.
+func (m *home) planActivityHistory(planFile string) []session.InstanceActivity {
+	history := session.PlanActivity(m.nav.GetInstances(), planFile)
+	history = append(history, m.archivedActivity[planFile]...)
+	sort.SliceStable(history, func(a, b int) bool {
+		return history[a].Timestamp.Before(history[b].Timestamp)
+	})
+	return history
+}
+
+// setArchivedActivity caches the archived activity loaded for planFile and
+// refreshes the info pane if the plan is still selected.
+func (m *home) setArchivedActivity(planFile string, archived []session.InstanceActivity) {
+	if m.archivedActivity == nil {
+		m.archivedActivity = make(map[string][]session.InstanceActivity)
+	}
+	m.archivedActivity[planFile] = archived
+	if planFile == m.archivedActivityPlan {
+		m.updateInfoPane()
+	}
+}
+
+// queryArchivedActivity queries the audit log for the activity timelines
+// archived from planFile's removed instances. Safe to call from a goroutine.
+func queryArchivedActivity(logger auditlog.Logger, project, planFile string) []session.InstanceActivity {
+	events, err := logger.Query(auditlog.QueryFilter{
+		Project:  project,
+		PlanFile: planFile,
+		Kinds:    []auditlog.EventKind{auditlog.EventActivityArchived},
+	})
+	if err != nil {
+		log.WarningLog.Printf("could not query archived activity for %s: %v", planFile, err)
+		return nil
+	}
+	var archived []session.InstanceActivity
+	for _, e := range events {
+		entries, err := session.UnmarshalActivity(e.Detail)
+		if err != nil {
+			continue
+		}
+		for _, a := range entries {
+			archived = append(archived, session.InstanceActivity{Activity: a, Instance: e.InstanceTitle})
+		}
+	}
+	return archived
+}
+
+// activityEntries converts a chronological activity history into info pane
+// rows, newest first.
+func activityEntries(history []session.InstanceActivity) []ui.ActivityEntry {
+	if len(history) == 0 {
+		return nil
+	}
+	rows := make([]ui.ActivityEntry, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		a := history[i]
+		rows = append(rows, ui.ActivityEntry{
+			Time:     a.Timestamp.Format("15:04:05"),
+			Action:   a.Action,
+			Detail:   a.Detail,
+			Instance: a.Instance,
+		})
+	}
+	return rows
+}
+
+// planOverlapFiles returns the sorted list of files edited by more than one
+// instance of planFile. When onlyTitle is set, only overlaps involving that
+// instance are returned.
+func planOverlapFiles(instances []*session.Instance, planFile, onlyTitle string) []string {
+	if planFile == "" {
+		return nil
+	}
+	var planInstances []*session.Instance
+	for _, inst := range instances {
+		if inst.PlanFile == planFile {
+			planInstances = append(planInstances, inst)
+		}
+	}
+	var files []string
+	for f, titles := range session.FileOverlaps(planInstances) {
+		if onlyTitle != "" && !slices.Contains(titles, onlyTitle) {
+			continue
+		}
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
+}
+
 // loadPlanState reads plan state from the store for the active repo.
 // Called on user-triggered events (plan creation, repo switch, etc.). The periodic
 // metadata tick loads plan state in its goroutine instead.
@@ -579,12 +755,20 @@ func (m *home) updateSidebarPlans() {
 			if p.Status == planstate.StatusDone || p.Status == planstate.StatusCancelled {
 				continue // finished/cancelled plans handled separately
 			}
+			pr, _ := m.planPRFor(p.Filename, m.planState.Plans[p.Filename])
+			collides, conflicts := m.collisionBadge(p.Filename)
 			planDisplays = append(planDisplays, ui.PlanDisplay{
 				Filename:    p.Filename,
 				Status:      string(p.Status),
 				Description: p.Description,
 				Branch:      p.Branch,
 				Topic:       p.Topic,
+				Behind:      m.planDrift[p.Filename].Behind,
+				PRNumber:    pr.Number,
+				PRChecks:    string(pr.Checks),
+				Parent:      m.planState.Plans[p.Filename].Parent,
+				Collides:    collides,
+				Conflicts:   conflicts,
 			})
 		}
 		if len(planDisplays) > 0 {
@@ -596,11 +780,19 @@ func (m *home) updateSidebarPlans() {
 	ungroupedInfos := m.planState.UngroupedPlans()
 	ungrouped := make([]ui.PlanDisplay, 0, len(ungroupedInfos))
 	for _, p := range ungroupedInfos {
+		pr, _ := m.planPRFor(p.Filename, m.planState.Plans[p.Filename])
+		collides, conflicts := m.collisionBadge(p.Filename)
 		ungrouped = append(ungrouped, ui.PlanDisplay{
 			Filename:    p.Filename,
 			Status:      string(p.Status),
 			Description: p.Description,
 			Branch:      p.Branch,
+			Behind:      m.planDrift[p.Filename].Behind,
+			PRNumber:    pr.Number,
+			PRChecks:    string(pr.Checks),
+			Parent:      m.planState.Plans[p.Filename].Parent,
+			Collides:    collides,
+			Conflicts:   conflicts,
 		})
 	}
 
@@ -694,6 +886,104 @@ func (m *home) transitionToReview(coderInst *session.Instance) tea.Cmd {
 	return m.spawnReviewer(planFile)
 }
 
+// applySignal feeds an agent's signal to the FSM and runs the side effects of
+// the transition (spawning the reviewer or coder, prompting the user, …). It
+// serves both sentinel files and the agents' MCP tool calls; the caller
+// consumes sentinels. The returned cmds belong to the transition.
+func (m *home) applySignal(sig planfsm.Signal) ([]tea.Cmd, error) {
+	var cmds []tea.Cmd
+	// The wave orchestrator owns implementing → reviewing; without this
+	// guard the first task to finish would start the review and pause its
+	// siblings.
+	_, hasOrch := m.waveOrchestrators[sig.PlanFile]
+	if err := orchestration.CheckSignal(sig, hasOrch); err != nil {
+		return nil, err
+	}
+
+	if err := m.fsm.Transition(sig.PlanFile, sig.Event); err != nil {
+		return nil, err
+	}
+
+	// Side effects: spawn agents in response to successful transitions.
+	switch sig.Event {
+	case planfsm.ImplementFinished:
+		// Pause the coder that wrote this signal.
+		for _, inst := range m.nav.GetInstances() {
+			if inst.PlanFile == sig.PlanFile && inst.AgentType == session.AgentTypeCoder {
+				inst.ImplementationComplete = true
+				_ = inst.Pause()
+				break
+			}
+		}
+		if cmd := m.spawnReviewer(sig.PlanFile); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+		// Answer the PR review threads this round addressed, if asked to.
+		if cmd := m.finishPRReview(sig.PlanFile); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case planfsm.ReviewApproved:
+		planName := planstate.DisplayName(sig.PlanFile)
+		m.audit(auditlog.EventPlanTransition, orchestration.TransitionMessage(sig.Event),
+			auditlog.WithPlan(sig.PlanFile))
+		m.toastManager.Success(fmt.Sprintf("review approved: %s", planName))
+		// Kill the reviewer instance — it's done.
+		for _, inst := range m.nav.GetInstances() {
+			if inst.PlanFile == sig.PlanFile && inst.IsReviewer {
+				_ = inst.Kill()
+				break
+			}
+		}
+	case planfsm.ReviewChangesRequested:
+		feedback := sig.Body
+		m.pendingReviewFeedback[sig.PlanFile] = feedback
+		// Pause the reviewer that wrote this signal.
+		for _, inst := range m.nav.GetInstances() {
+			if inst.PlanFile == sig.PlanFile && inst.IsReviewer {
+				_ = inst.Pause()
+				break
+			}
+		}
+		if cmd := m.spawnCoderWithFeedback(sig.PlanFile, feedback); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	case planfsm.PlannerFinished:
+		capturedPlanFile := sig.PlanFile
+		// Ingest the plan content from the agent's worktree into the DB.
+		// Planners run on the main branch, so the plan file is in activeRepoPath.
+		m.ingestPlanContent(capturedPlanFile, m.activeRepoPath)
+		if m.plannerPrompted[capturedPlanFile] {
+			break
+		}
+		if m.isUserInOverlay() {
+			// Overlay is active — defer the dialog to the next tick
+			// instead of silently dropping it. The sentinel has already
+			// been consumed and the FSM transitioned; we must not lose
+			// the "show dialog" side effect.
+			m.deferredPlannerDialogs = append(m.deferredPlannerDialogs, capturedPlanFile)
+			break
+		}
+		// Focus the planner instance so the user sees its output behind the overlay.
+		for _, inst := range m.nav.GetInstances() {
+			if inst.PlanFile == sig.PlanFile && inst.AgentType == session.AgentTypePlanner {
+				if cmd := m.focusInstanceForOverlay(inst); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+				m.pendingPlannerInstanceTitle = inst.Title
+				break
+			}
+		}
+		m.pendingPlannerPlanFile = capturedPlanFile
+		m.confirmAction(
+			fmt.Sprintf("plan '%s' is ready. start implementation?", planstate.DisplayName(capturedPlanFile)),
+			func() tea.Msg {
+				return plannerCompleteMsg{planFile: capturedPlanFile}
+			},
+		)
+	}
+	return cmds, nil
+}
+
 // spawnReviewer creates and starts a reviewer session for the given plan,
 // using the plan's shared worktree so it reviews the actual implementation branch.
 // Does NOT perform any FSM transition — the caller is responsible for that.
@@ -705,8 +995,7 @@ func (m *home) spawnReviewer(planFile string) tea.Cmd {
 		}
 	}
 	planName := planstate.DisplayName(planFile)
-	planPath := "docs/plans/" + planFile
-	prompt := scaffold.LoadReviewPrompt(planPath, planName)
+	prompt := orchestration.BuildReviewPrompt(planFile)
 
 	// Kill any previous reviewer for this plan so the new session gets a fresh
 	// tmux session instead of reattaching to a stale/errored one.
@@ -720,7 +1009,7 @@ func (m *home) spawnReviewer(planFile string) tea.Cmd {
 	}
 
 	reviewerInst, err := session.NewInstance(session.InstanceOptions{
-		Title:     planName + "-review",
+		Title:     orchestration.ReviewerTitle(planFile),
 		Path:      m.activeRepoPath,
 		Program:   m.programForAgent(session.AgentTypeReviewer),
 		PlanFile:  planFile,
@@ -741,11 +1030,12 @@ func (m *home) spawnReviewer(planFile string) tea.Cmd {
 		auditlog.WithPlan(planFile),
 		auditlog.WithInstance(reviewerInst.Title),
 		auditlog.WithAgent(session.AgentTypeReviewer),
+		auditlog.WithProgram(reviewerInst.Program),
 	)
 
 	m.toastManager.Success(fmt.Sprintf("implementation complete → review started for %s", planName))
 
-	shared := gitpkg.NewSharedPlanWorktree(m.activeRepoPath, branch)
+	shared := m.sharedPlanWorktree(planFile, branch)
 	return func() tea.Msg {
 		if err := shared.Setup(); err != nil {
 			return instanceStartedMsg{instance: reviewerInst, err: err}
@@ -799,30 +1089,17 @@ func (m *home) programForAgent(agentType string) string {
 	if m.appConfig == nil {
 		return m.program
 	}
-	// Map agent types to config phases or profile names.
-	var profile config.AgentProfile
-	switch agentType {
-	case session.AgentTypeCoder:
-		profile = m.appConfig.ResolveProfile("implementing", m.program)
-	case session.AgentTypePlanner:
-		profile = m.appConfig.ResolveProfile("planning", m.program)
-	case session.AgentTypeReviewer:
-		profile = m.appConfig.ResolveProfile("quality_review", m.program)
-	case session.AgentTypeFixer:
-		profile = m.appConfig.ResolveProfile("fixer", m.program)
-	default:
-		// Ad-hoc — use the "chat" profile if available.
-		if p, ok := m.appConfig.Profiles["chat"]; ok && p.Enabled && p.Program != "" {
-			profile = p
-		} else {
-			return m.program
-		}
-		// Ad-hoc sessions have no --agent flag, so pass --model explicitly.
-		prog := profile.BuildCommand()
-		return withOpenCodeModelFlag(prog, profile.Model)
-	}
 	// Typed agents: opencode handles model via --agent <type> + its own config.
-	return profile.BuildCommand()
+	if phase, ok := orchestration.AgentPhase(agentType); ok {
+		return m.appConfig.ResolveProfile(phase, m.program).BuildCommand()
+	}
+	// Ad-hoc — use the "chat" profile if available.
+	profile, ok := m.appConfig.Profiles["chat"]
+	if !ok || !profile.Enabled || profile.Program == "" {
+		return m.program
+	}
+	// Ad-hoc sessions have no --agent flag, so pass --model explicitly.
+	return withOpenCodeModelFlag(profile.BuildCommand(), profile.Model)
 }
 
 func normalizeOpenCodeModelID(model string) string {
@@ -849,15 +1126,7 @@ func (m *home) killExistingPlanAgent(planFile, agentType string) {
 	// First pass: identify matching instances by title.
 	var titles []string
 	for _, inst := range m.nav.GetInstances() {
-		if inst.PlanFile != planFile {
-			continue
-		}
-		match := inst.AgentType == agentType
-		// Legacy reviewer instances may only have IsReviewer without AgentType.
-		if !match && agentType == session.AgentTypeReviewer && inst.IsReviewer {
-			match = true
-		}
-		if match {
+		if orchestration.IsPlanAgent(inst, planFile, agentType) {
 			titles = append(titles, inst.Title)
 		}
 	}
@@ -881,10 +1150,7 @@ func (m *home) killExistingPlanAgent(planFile, agentType string) {
 // Does NOT perform any FSM transition — the caller is responsible for that.
 func (m *home) spawnCoderWithFeedback(planFile, feedback string) tea.Cmd {
 	planName := planstate.DisplayName(planFile)
-	prompt := buildImplementPrompt(planFile)
-	if feedback != "" {
-		prompt += fmt.Sprintf("\n\nReviewer feedback from previous round:\n%s", feedback)
-	}
+	prompt := orchestration.BuildFeedbackPrompt(planFile, feedback)
 
 	// Kill any previous coder for this plan so the new session gets a fresh
 	// tmux session instead of reattaching to a stale/errored one.
@@ -898,7 +1164,7 @@ func (m *home) spawnCoderWithFeedback(planFile, feedback string) tea.Cmd {
 	}
 
 	coderInst, err := session.NewInstance(session.InstanceOptions{
-		Title:     planName + "-implement",
+		Title:     orchestration.CoderTitle(planFile),
 		Path:      m.activeRepoPath,
 		Program:   m.programForAgent(session.AgentTypeCoder),
 		PlanFile:  planFile,
@@ -926,12 +1192,13 @@ func (m *home) spawnCoderWithFeedback(planFile, feedback string) tea.Cmd {
 		auditlog.WithPlan(planFile),
 		auditlog.WithInstance(coderInst.Title),
 		auditlog.WithAgent(session.AgentTypeCoder),
+		auditlog.WithProgram(coderInst.Program),
 		auditlog.WithDetail(detail),
 	)
 
 	m.toastManager.Info(fmt.Sprintf("review changes requested → re-implementing %s", planName))
 
-	shared := gitpkg.NewSharedPlanWorktree(m.activeRepoPath, branch)
+	shared := m.sharedPlanWorktree(planFile, branch)
 	return func() tea.Msg {
 		if err := shared.Setup(); err != nil {
 			return instanceStartedMsg{instance: coderInst, err: err}
@@ -952,15 +1219,7 @@ func (m *home) materializePlanFile(planFile, repoPath string) error {
 	if err != nil {
 		return fmt.Errorf("get plan content %s: %w", planFile, err)
 	}
-	plansDir := filepath.Join(repoPath, "docs", "plans")
-	if err := os.MkdirAll(plansDir, 0o755); err != nil {
-		return fmt.Errorf("create plans dir: %w", err)
-	}
-	planPath := filepath.Join(plansDir, planFile)
-	if err := os.WriteFile(planPath, []byte(content), 0o644); err != nil {
-		return fmt.Errorf("write plan file: %w", err)
-	}
-	return nil
+	return orchestration.WritePlanFile(repoPath, planFile, content)
 }
 
 // ingestPlanContent reads the plan markdown file from the given repo path and
@@ -971,13 +1230,12 @@ func (m *home) ingestPlanContent(planFile, repoPath string) {
 	if m.planStore == nil || m.planState == nil {
 		return
 	}
-	planPath := filepath.Join(repoPath, "docs", "plans", planFile)
-	data, err := os.ReadFile(planPath)
+	content, err := orchestration.ReadPlanFile(repoPath, planFile)
 	if err != nil {
-		log.WarningLog.Printf("ingestPlanContent: cannot read %s: %v", planPath, err)
+		log.WarningLog.Printf("ingestPlanContent: %v", err)
 		return
 	}
-	if err := m.planState.SetContent(planFile, string(data)); err != nil {
+	if err := m.planState.SetContent(planFile, content); err != nil {
 		log.WarningLog.Printf("ingestPlanContent: cannot store content for %s: %v", planFile, err)
 	}
 }
@@ -1028,8 +1286,9 @@ func (m *home) viewSelectedPlan() (tea.Model, tea.Cmd) {
 	}
 }
 
-// createPlanEntry creates a new plan entry in the store.
-func (m *home) createPlanEntry(name, description, topic string) error {
+// createPlanEntry creates a new plan entry in the store, stacked on parent
+// when it is non-empty.
+func (m *home) createPlanEntry(name, description, topic, parent string) error {
 	if m.planState == nil {
 		if m.planStore == nil {
 			return fmt.Errorf("plan store not configured")
@@ -1050,6 +1309,11 @@ func (m *home) createPlanEntry(name, description, topic string) error {
 		}
 		return err
 	}
+	if parent != "" {
+		if err := m.planState.SetParent(filename, parent); err != nil {
+			return err
+		}
+	}
 	m.audit(auditlog.EventPlanCreated, "created plan", auditlog.WithPlan(filename))
 	m.updateSidebarPlans()
 	return nil
@@ -1120,58 +1384,6 @@ func (m *home) finalizePlanCreation(name, description string) error {
 	return nil
 }
 
-func (m *home) importClickUpTask(task *clickup.Task) (tea.Model, tea.Cmd) {
-	if task == nil {
-		m.toastManager.Error("clickup fetch failed: empty task payload")
-		return m, m.toastTickCmd()
-	}
-
-	date := time.Now().Format("2006-01-02")
-	filename := clickup.ScaffoldFilename(task.Name, date)
-
-	if m.planState == nil {
-		m.loadPlanState()
-	}
-	if m.planState == nil {
-		m.toastManager.Error("failed to register imported plan: plan state unavailable")
-		return m, m.toastTickCmd()
-	}
-
-	filename = dedupePlanFilenameInState(m.planState, filename)
-
-	scaffold := clickup.ScaffoldPlan(*task)
-
-	branch := gitpkg.PlanBranchFromFile(filename)
-	if err := m.planState.Register(filename, task.Name, branch, time.Now()); err != nil {
-		m.toastManager.Error("failed to register imported plan: " + err.Error())
-		return m, m.toastTickCmd()
-	}
-	if err := m.planState.SetContent(filename, scaffold); err != nil {
-		m.toastManager.Error("failed to save imported plan content: " + err.Error())
-		return m, m.toastTickCmd()
-	}
-
-	if err := m.fsm.Transition(filename, planfsm.PlanStart); err != nil {
-		log.WarningLog.Printf("clickup import transition failed for %q: %v", filename, err)
-	}
-
-	m.loadPlanState()
-	m.updateSidebarPlans()
-
-	prompt := fmt.Sprintf(`Analyze this imported ClickUp task. The task details and subtasks are included as reference in the plan file.
-
-Determine if the task is well-specified enough for implementation or needs further analysis. Write a proper implementation plan with ## Wave sections, task breakdowns, architecture notes, and tech stack. Use the ClickUp subtasks as a starting point but reorganize into waves based on dependencies.
-
-The plan file is at: docs/plans/%s`, filename)
-
-	m.toastManager.Success("imported! spawning planner...")
-	model, cmd := m.spawnPlanAgent(filename, "plan", prompt)
-	if cmd == nil {
-		return model, m.toastTickCmd()
-	}
-	return model, tea.Batch(cmd, m.toastTickCmd())
-}
-
 func dedupePlanFilename(plansDir, filename string) string {
 	planPath := filepath.Join(plansDir, filename)
 	if _, err := os.Stat(planPath); os.IsNotExist(err) {
@@ -1296,17 +1508,6 @@ func buildWaveAnnotationPrompt(planFile string) string {
 	)
 }
 
-// buildImplementPrompt returns the prompt for a coder agent session.
-// The plan file is materialized to docs/plans/<planFile> in the agent's worktree
-// by the TUI before the agent starts. Agents write sentinel signals to
-// .kasmos/signals/ in their worktree; the TUI ingests them on completion.
-func buildImplementPrompt(planFile string) string {
-	return fmt.Sprintf(
-		"Implement docs/plans/%s using the `kasmos-coder` skill. Execute all tasks sequentially.",
-		planFile,
-	)
-}
-
 // buildSoloPrompt returns a minimal prompt for a solo agent session.
 // If planFile is non-empty, it references the plan file. Otherwise just name + description.
 func buildSoloPrompt(planName, description, planFile string) string {
@@ -1384,6 +1585,7 @@ func (m *home) spawnAdHocAgent(name, branch, workPath string) (tea.Model, tea.Cm
 	m.audit(auditlog.EventAgentSpawned, fmt.Sprintf("spawned fixer agent: %s", name),
 		auditlog.WithInstance(name),
 		auditlog.WithAgent(session.AgentTypeFixer),
+		auditlog.WithProgram(inst.Program),
 	)
 
 	m.addInstanceFinalizer(inst, m.nav.AddInstance(inst))
@@ -1462,7 +1664,7 @@ func (m *home) spawnPlanAgent(planFile, action, prompt string) (tea.Model, tea.C
 		}
 
 		// Coder and reviewer share the plan's feature branch worktree
-		shared := gitpkg.NewSharedPlanWorktree(m.activeRepoPath, entry.Branch)
+		shared := m.sharedPlanWorktree(planFile, entry.Branch)
 		if err := shared.Setup(); err != nil {
 			return m, m.handleError(err)
 		}
@@ -1479,6 +1681,7 @@ func (m *home) spawnPlanAgent(planFile, action, prompt string) (tea.Model, tea.C
 		auditlog.WithPlan(planFile),
 		auditlog.WithInstance(title),
 		auditlog.WithAgent(agentType),
+		auditlog.WithProgram(inst.Program),
 	)
 
 	m.addInstanceFinalizer(inst, m.nav.AddInstance(inst))
@@ -1509,7 +1712,7 @@ func (m *home) getTopicNames() []string {
 //  2. Fast-forward the orchestrator to the wave the instances are on.
 //  3. Mark tasks as complete for instances that are already paused (finished their work).
 //
-// Tasks that are still running remain in taskRunning state so the metadata tick can
+// Tasks that are still running stay running so the metadata tick can
 // detect their completion normally (or the user can mark them complete manually).
 func (m *home) rebuildOrphanedOrchestrators() {
 	if m.planState == nil || m.planStateDir == "" {
@@ -1557,38 +1760,22 @@ func (m *home) rebuildOrphanedOrchestrators() {
 			continue
 		}
 
-		orch := NewWaveOrchestrator(planFile, plan)
-
-		// Determine which wave the instances are on (use the max wave number seen).
+		// Determine which wave the instances are on (use the max wave number
+		// seen); tasks there whose agents are paused already finished.
 		targetWave := 0
 		for _, t := range tasks {
-			if t.waveNumber > targetWave {
-				targetWave = t.waveNumber
-			}
+			targetWave = max(targetWave, t.waveNumber)
 		}
-
-		// Fast-forward the orchestrator wave-by-wave up to the target wave.
-		// Waves before the target are considered fully complete.
-		for orch.currentWave < len(plan.Waves) {
-			orch.StartNextWave()
-			if orch.CurrentWaveNumber() == targetWave {
-				break
-			}
-			// Mark all tasks in this earlier wave as complete to advance.
-			for _, t := range plan.Waves[orch.currentWave].Tasks {
-				orch.MarkTaskComplete(t.Number)
-			}
-		}
-
-		// Now apply the actual task states for the current wave.
+		var done []int
 		for _, t := range tasks {
-			if t.waveNumber != targetWave {
-				continue
+			if t.waveNumber == targetWave && t.paused {
+				done = append(done, t.taskNumber)
 			}
-			if t.paused {
-				orch.MarkTaskComplete(t.taskNumber)
-			}
-			// Running tasks stay in taskRunning — metadata tick handles them.
+		}
+		orch := orchestration.RestoreWaveOrchestrator(planFile, plan, targetWave, done)
+		if orch == nil {
+			log.WarningLog.Printf("rebuildOrphanedOrchestrators: %s has no wave %d", planFile, targetWave)
+			continue
 		}
 
 		m.waveOrchestrators[planFile] = orch
@@ -1599,22 +1786,21 @@ func (m *home) rebuildOrphanedOrchestrators() {
 
 // spawnWaveTasks creates and starts instances for the given task list within an orchestrator.
 // Used by both startNextWave (initial spawn) and retryFailedWaveTasks (re-spawn failed tasks).
-func (m *home) spawnWaveTasks(orch *WaveOrchestrator, tasks []planparser.Task, entry planstate.PlanEntry) (tea.Model, tea.Cmd) {
+func (m *home) spawnWaveTasks(orch *orchestration.WaveOrchestrator, tasks []planparser.Task, entry planstate.PlanEntry) (tea.Model, tea.Cmd) {
 	planFile := orch.PlanFile()
-	planName := planstate.DisplayName(planFile)
 
 	// Set up shared worktree for all tasks in this batch.
-	shared := gitpkg.NewSharedPlanWorktree(m.activeRepoPath, entry.Branch)
+	shared := m.sharedPlanWorktree(planFile, entry.Branch)
 	if err := shared.Setup(); err != nil {
 		return m, m.handleError(err)
 	}
 
 	var cmds []tea.Cmd
 	for _, task := range tasks {
-		prompt := buildTaskPrompt(orch.plan, task, orch.CurrentWaveNumber(), orch.TotalWaves(), len(tasks))
+		prompt := orchestration.BuildTaskPrompt(orch.Plan(), task, orch.CurrentWaveNumber(), orch.TotalWaves(), len(tasks))
 
 		inst, err := session.NewInstance(session.InstanceOptions{
-			Title:      fmt.Sprintf("%s-W%d-T%d", planName, orch.CurrentWaveNumber(), task.Number),
+			Title:      orchestration.TaskTitle(planFile, orch.CurrentWaveNumber(), task.Number),
 			Path:       m.activeRepoPath,
 			Program:    m.programForAgent(session.AgentTypeCoder),
 			PlanFile:   planFile,
@@ -1639,6 +1825,7 @@ func (m *home) spawnWaveTasks(orch *WaveOrchestrator, tasks []planparser.Task, e
 			auditlog.WithPlan(planFile),
 			auditlog.WithInstance(inst.Title),
 			auditlog.WithAgent(session.AgentTypeCoder),
+			auditlog.WithProgram(inst.Program),
 			auditlog.WithWave(orch.CurrentWaveNumber(), task.Number),
 		)
 
@@ -1658,7 +1845,7 @@ func (m *home) spawnWaveTasks(orch *WaveOrchestrator, tasks []planparser.Task, e
 }
 
 // startNextWave advances the orchestrator to the next wave and spawns its task instances.
-func (m *home) startNextWave(orch *WaveOrchestrator, entry planstate.PlanEntry) (tea.Model, tea.Cmd) {
+func (m *home) startNextWave(orch *orchestration.WaveOrchestrator, entry planstate.PlanEntry) (tea.Model, tea.Cmd) {
 	tasks := orch.StartNextWave()
 	if tasks == nil {
 		return m, nil
@@ -1670,13 +1857,16 @@ func (m *home) startNextWave(orch *WaveOrchestrator, entry planstate.PlanEntry)
 		fmt.Sprintf("wave %d started: %d task(s)", waveNum, len(tasks)),
 		auditlog.WithPlan(orch.PlanFile()),
 		auditlog.WithWave(waveNum, 0))
-	return m.spawnWaveTasks(orch, tasks, entry)
+	model, cmd := m.spawnWaveTasks(orch, tasks, entry)
+	// Setup has created the branch; the agents have not started yet.
+	orchestration.RecordWaveCheckpoint(m.activeRepoPath, entry.Branch, orch.PlanFile(), waveNum)
+	return model, cmd
 }
 
 // retryFailedWaveTasks retries all failed tasks in the current wave by re-spawning them.
 // Old failed instances are removed first to prevent ghost duplicates that accumulate
 // across retries and all get marked ImplementationComplete when waves finish.
-func (m *home) retryFailedWaveTasks(orch *WaveOrchestrator, entry planstate.PlanEntry) (tea.Model, tea.Cmd) {
+func (m *home) retryFailedWaveTasks(orch *orchestration.WaveOrchestrator, entry planstate.PlanEntry) (tea.Model, tea.Cmd) {
 	tasks := orch.RetryFailedTasks()
 	if len(tasks) == 0 {
 		return m, nil
@@ -1776,7 +1966,7 @@ func (m *home) spawnChatAboutPlan(planFile, question string) (tea.Model, tea.Cmd
 	var startCmd tea.Cmd
 	branch := m.planBranch(planFile)
 	if branch != "" {
-		shared := gitpkg.NewSharedPlanWorktree(m.activeRepoPath, branch)
+		shared := m.sharedPlanWorktree(planFile, branch)
 		startCmd = func() tea.Msg {
 			if err := shared.Setup(); err != nil {
 				return instanceStartedMsg{instance: inst, err: err}
@@ -1800,6 +1990,7 @@ func (m *home) spawnChatAboutPlan(planFile, question string) (tea.Model, tea.Cmd
 		auditlog.WithPlan(planFile),
 		auditlog.WithInstance(title),
 		auditlog.WithAgent(session.AgentTypeFixer),
+		auditlog.WithProgram(inst.Program),
 	)
 
 	m.addInstanceFinalizer(inst, m.nav.AddInstance(inst))
@@ -1850,29 +2041,98 @@ func (m *home) audit(kind auditlog.EventKind, msg string, opts ...auditlog.Event
 	for _, opt := range opts {
 		opt(&e)
 	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
 	m.auditLogger.Emit(e)
-	m.refreshAuditPane()
+	// Show the event right away; the next reload of the pane confirms it.
+	m.auditEvents = append([]auditlog.Event{e}, m.auditEvents...)
+	if len(m.auditEvents) > auditPaneLimit {
+		m.auditEvents = m.auditEvents[:auditPaneLimit]
+	}
+	m.renderAuditPane()
 }
 
-// refreshAuditPane queries the audit logger and updates the audit pane display.
-// Shows a global activity feed — not filtered by sidebar selection.
-func (m *home) refreshAuditPane() {
-	if m.auditPane == nil || m.auditLogger == nil {
-		return
+// remoteOrigin returns "user@host" for events recorded by another user or
+// machine sharing the plan store server, and "" for this machine's own.
+func remoteOrigin(e auditlog.Event) string {
+	host, user := auditlog.Origin()
+	if e.Host == "" || (e.Host == host && e.User == user) {
+		return ""
 	}
+	if e.User == "" {
+		return e.Host
+	}
+	return e.User + "@" + e.Host
+}
 
-	filter := auditlog.QueryFilter{
-		Project: m.planStoreProject,
-		Limit:   200,
+// auditRefreshInterval throttles audit pane reloads in the metadata tick.
+// Events emitted by this process show up immediately; those recorded by the
+// daemon or other machines sharing the plan store take up to this long.
+const auditRefreshInterval = 2 * time.Second
+
+// auditPaneLimit is how many of the most recent events the audit pane holds.
+const auditPaneLimit = 200
+
+// queryAuditEvents loads the most recent audit events of project, newest
+// first. Safe to call from a goroutine.
+func queryAuditEvents(logger auditlog.Logger, project string) ([]auditlog.Event, error) {
+	return logger.Query(auditlog.QueryFilter{
+		Project: project,
+		Limit:   auditPaneLimit,
+	})
+}
+
+// setAuditEvents replaces the cached audit events with a reload that
+// started at since. Events emitted here after since may be missing from it,
+// so they are kept on top.
+func (m *home) setAuditEvents(events []auditlog.Event, since time.Time) {
+	loaded := make(map[auditEventKey]bool, len(events))
+	for _, e := range events {
+		loaded[keyOfAuditEvent(e)] = true
+	}
+	var pending []auditlog.Event
+	for _, e := range m.auditEvents {
+		if e.Timestamp.Before(since) {
+			break
+		}
+		if !loaded[keyOfAuditEvent(e)] {
+			pending = append(pending, e)
+		}
+	}
+	m.auditEvents = append(pending, events...)
+	if len(m.auditEvents) > auditPaneLimit {
+		m.auditEvents = m.auditEvents[:auditPaneLimit]
 	}
+	m.renderAuditPane()
+}
 
-	events, err := m.auditLogger.Query(filter)
-	if err != nil {
+// auditEventKey identifies an event across the local cache and a reload,
+// which assigns IDs and origin the cached copy lacks.
+type auditEventKey struct {
+	kind     auditlog.EventKind
+	at       int64
+	instance string
+	message  string
+}
+
+func keyOfAuditEvent(e auditlog.Event) auditEventKey {
+	return auditEventKey{e.Kind, e.Timestamp.UnixNano(), e.InstanceTitle, e.Message}
+}
+
+// renderAuditPane updates the audit pane display from the cached events.
+// Shows a global activity feed — not filtered by sidebar selection.
+func (m *home) renderAuditPane() {
+	if m.auditPane == nil {
 		return
 	}
+	events := m.auditEvents
 
 	displays := make([]ui.AuditEventDisplay, 0, len(events))
 	for _, e := range events {
+		if e.Kind == auditlog.EventPlanStatusChanged {
+			continue
+		}
 		icon, color := ui.EventKindIcon(string(e.Kind))
 		timeStr := e.Timestamp.Local().Format("15:04")
 		msg := e.Message
@@ -1884,6 +2144,9 @@ func (m *home) refreshAuditPane() {
 				msg = "[" + label + "] " + msg
 			}
 		}
+		if origin := remoteOrigin(e); origin != "" {
+			msg = origin + ": " + msg
+		}
 		displays = append(displays, ui.AuditEventDisplay{
 			Time:    timeStr,
 			Kind:    string(e.Kind),
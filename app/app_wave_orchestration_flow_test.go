@@ -14,6 +14,7 @@ import (
 	"github.com/kastheco/kasmos/config/planfsm"
 	"github.com/kastheco/kasmos/config/planparser"
 	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/orchestration"
 	"github.com/kastheco/kasmos/session"
 	"github.com/kastheco/kasmos/ui"
 	"github.com/kastheco/kasmos/ui/overlay"
@@ -22,7 +23,7 @@ import (
 )
 
 // waveFlowHome builds a minimal home struct suitable for wave-orchestration flow tests.
-func waveFlowHome(t *testing.T, ps *planstate.PlanState, plansDir string, orchMap map[string]*WaveOrchestrator) *home {
+func waveFlowHome(t *testing.T, ps *planstate.PlanState, plansDir string, orchMap map[string]*orchestration.WaveOrchestrator) *home {
 	t.Helper()
 	sp := spinner.New(spinner.WithSpinner(spinner.Dot))
 	list := ui.NewNavigationPanel(&sp)
@@ -51,7 +52,7 @@ func TestWaveMonitor_CancelWaveAdvanceRePrompts(t *testing.T) {
 			{Number: 2, Tasks: []planparser.Task{{Number: 2, Title: "Second", Body: "do second"}}},
 		},
 	}
-	orch := NewWaveOrchestrator("test.md", plan)
+	orch := orchestration.NewWaveOrchestrator("test.md", plan)
 	orch.StartNextWave()
 	orch.MarkTaskComplete(1) // wave 1 done
 	orch.NeedsConfirm()      // consume the one-shot latch so it won't fire again
@@ -66,7 +67,7 @@ func TestWaveMonitor_CancelWaveAdvanceRePrompts(t *testing.T) {
 		menu:                       ui.NewMenu(),
 		tabbedWindow:               ui.NewTabbedWindow(ui.NewPreviewPane(), ui.NewDiffPane(), ui.NewInfoPane()),
 		toastManager:               overlay.NewToastManager(&sp),
-		waveOrchestrators:          map[string]*WaveOrchestrator{"test.md": orch},
+		waveOrchestrators:          map[string]*orchestration.WaveOrchestrator{"test.md": orch},
 		pendingWaveConfirmPlanFile: "test.md",
 		confirmationOverlay:        overlay.NewConfirmationOverlay("Wave 1 complete. Start Wave 2?"),
 	}
@@ -91,7 +92,7 @@ func TestWaveMonitor_PausedTaskCountsAsFailed(t *testing.T) {
 			{Number: 2, Tasks: []planparser.Task{{Number: 2, Title: "Task 2", Body: "follow up"}}},
 		},
 	}
-	orch := NewWaveOrchestrator(planFile, plan)
+	orch := orchestration.NewWaveOrchestrator(planFile, plan)
 	orch.StartNextWave()
 
 	dir := t.TempDir()
@@ -114,7 +115,7 @@ func TestWaveMonitor_PausedTaskCountsAsFailed(t *testing.T) {
 	require.NoError(t, err)
 	inst.SetStatus(session.Paused)
 
-	h := waveFlowHome(t, ps, plansDir, map[string]*WaveOrchestrator{planFile: orch})
+	h := waveFlowHome(t, ps, plansDir, map[string]*orchestration.WaveOrchestrator{planFile: orch})
 	_ = h.nav.AddInstance(inst)
 
 	msg := metadataResultMsg{
@@ -148,7 +149,7 @@ func TestWaveMonitor_MissingTaskCountsAsFailed(t *testing.T) {
 			{Number: 2, Tasks: []planparser.Task{{Number: 2, Title: "Task 2", Body: "follow up"}}},
 		},
 	}
-	orch := NewWaveOrchestrator(planFile, plan)
+	orch := orchestration.NewWaveOrchestrator(planFile, plan)
 	orch.StartNextWave()
 
 	dir := t.TempDir()
@@ -160,7 +161,7 @@ func TestWaveMonitor_MissingTaskCountsAsFailed(t *testing.T) {
 	seedPlanStatus(t, ps, planFile, planstate.StatusImplementing)
 
 	// No instance added to the list — the task is "missing"
-	h := waveFlowHome(t, ps, plansDir, map[string]*WaveOrchestrator{planFile: orch})
+	h := waveFlowHome(t, ps, plansDir, map[string]*orchestration.WaveOrchestrator{planFile: orch})
 
 	msg := metadataResultMsg{
 		Results:   []instanceMetadata{},
@@ -188,10 +189,10 @@ func TestWaveMonitor_AbortKeyDeletesOrchestrator(t *testing.T) {
 			{Number: 2, Tasks: []planparser.Task{{Number: 2, Title: "Task 2", Body: "follow up"}}},
 		},
 	}
-	orch := NewWaveOrchestrator(planFile, plan)
+	orch := orchestration.NewWaveOrchestrator(planFile, plan)
 	orch.StartNextWave()
 	orch.MarkTaskFailed(1)
-	require.Equal(t, WaveStateWaveComplete, orch.State())
+	require.Equal(t, orchestration.WaveStateWaveComplete, orch.State())
 
 	sp := spinner.New(spinner.WithSpinner(spinner.Dot))
 	h := &home{
@@ -202,7 +203,7 @@ func TestWaveMonitor_AbortKeyDeletesOrchestrator(t *testing.T) {
 		menu:                       ui.NewMenu(),
 		tabbedWindow:               ui.NewTabbedWindow(ui.NewPreviewPane(), ui.NewDiffPane(), ui.NewInfoPane()),
 		toastManager:               overlay.NewToastManager(&sp),
-		waveOrchestrators:          map[string]*WaveOrchestrator{planFile: orch},
+		waveOrchestrators:          map[string]*orchestration.WaveOrchestrator{planFile: orch},
 		pendingWaveConfirmPlanFile: planFile,
 		confirmationOverlay:        overlay.NewConfirmationOverlay("Wave 1 failed. r=retry n=next wave a=abort"),
 		pendingWaveAbortAction: func() tea.Msg {
@@ -258,7 +259,7 @@ func TestTriggerPlanStage_ImplementNoWaves_RespawnsPlanner(t *testing.T) {
 		menu:              ui.NewMenu(),
 		tabbedWindow:      ui.NewTabbedWindow(ui.NewPreviewPane(), ui.NewDiffPane(), ui.NewInfoPane()),
 		toastManager:      overlay.NewToastManager(&sp),
-		waveOrchestrators: make(map[string]*WaveOrchestrator),
+		waveOrchestrators: make(map[string]*orchestration.WaveOrchestrator),
 	}
 
 	_, _ = h.triggerPlanStage(planFile, "implement")
@@ -293,13 +294,13 @@ func TestTriggerPlanStage_ImplementNoWaves_RespawnsPlanner(t *testing.T) {
 func TestWaveMonitor_AllComplete_ShowsReviewPrompt(t *testing.T) {
 	const planFile = "2026-02-24-all-complete.md"
 
-	// Single wave plan — completing its tasks triggers WaveStateAllComplete directly.
+	// Single wave plan — completing its tasks triggers orchestration.WaveStateAllComplete directly.
 	plan := &planparser.Plan{
 		Waves: []planparser.Wave{
 			{Number: 1, Tasks: []planparser.Task{{Number: 1, Title: "Only task", Body: "do it"}}},
 		},
 	}
-	orch := NewWaveOrchestrator(planFile, plan)
+	orch := orchestration.NewWaveOrchestrator(planFile, plan)
 	orch.StartNextWave()
 
 	dir := t.TempDir()
@@ -322,7 +323,7 @@ func TestWaveMonitor_AllComplete_ShowsReviewPrompt(t *testing.T) {
 	require.NoError(t, err)
 	inst.PromptDetected = true
 
-	h := waveFlowHome(t, ps, plansDir, map[string]*WaveOrchestrator{planFile: orch})
+	h := waveFlowHome(t, ps, plansDir, map[string]*orchestration.WaveOrchestrator{planFile: orch})
 	h.fsm = newPlanFSMForTest(t, plansDir)
 	_ = h.nav.AddInstance(inst)
 
@@ -360,7 +361,7 @@ func TestWaveAllCompleteMsg_TransitionsToReviewing(t *testing.T) {
 	require.NoError(t, ps.Register(planFile, "review transition test", "plan/review-trans", time.Now()))
 	seedPlanStatus(t, ps, planFile, planstate.StatusImplementing)
 
-	h := waveFlowHome(t, ps, plansDir, make(map[string]*WaveOrchestrator))
+	h := waveFlowHome(t, ps, plansDir, make(map[string]*orchestration.WaveOrchestrator))
 	h.fsm = newPlanFSMForTest(t, plansDir)
 
 	model, _ := h.Update(waveAllCompleteMsg{planFile: planFile})
@@ -389,13 +390,13 @@ func TestWaveMonitor_AllComplete_MultiWave(t *testing.T) {
 			{Number: 2, Tasks: []planparser.Task{{Number: 2, Title: "W2 task", Body: "second"}}},
 		},
 	}
-	orch := NewWaveOrchestrator(planFile, plan)
+	orch := orchestration.NewWaveOrchestrator(planFile, plan)
 	orch.StartNextWave()     // start wave 1
-	orch.MarkTaskComplete(1) // wave 1 done → WaveStateWaveComplete
-	require.Equal(t, WaveStateWaveComplete, orch.State())
+	orch.MarkTaskComplete(1) // wave 1 done → orchestration.WaveStateWaveComplete
+	require.Equal(t, orchestration.WaveStateWaveComplete, orch.State())
 
 	orch.StartNextWave() // advance to wave 2
-	require.Equal(t, WaveStateRunning, orch.State())
+	require.Equal(t, orchestration.WaveStateRunning, orch.State())
 
 	dir := t.TempDir()
 	plansDir := filepath.Join(dir, "docs", "plans")
@@ -417,7 +418,7 @@ func TestWaveMonitor_AllComplete_MultiWave(t *testing.T) {
 	require.NoError(t, err)
 	inst.PromptDetected = true
 
-	h := waveFlowHome(t, ps, plansDir, map[string]*WaveOrchestrator{planFile: orch})
+	h := waveFlowHome(t, ps, plansDir, map[string]*orchestration.WaveOrchestrator{planFile: orch})
 	h.fsm = newPlanFSMForTest(t, plansDir)
 	_ = h.nav.AddInstance(inst)
 
@@ -450,13 +451,13 @@ func TestRetryFailedWaveTasks_RemovesOldInstances(t *testing.T) {
 			}},
 		},
 	}
-	orch := NewWaveOrchestrator(planFile, plan)
+	orch := orchestration.NewWaveOrchestrator(planFile, plan)
 	orch.StartNextWave()
 
 	// Task 1 completed, task 6 failed.
 	orch.MarkTaskComplete(1)
 	orch.MarkTaskFailed(6)
-	require.Equal(t, WaveStateAllComplete, orch.State(), "single-wave plan should be AllComplete")
+	require.Equal(t, orchestration.WaveStateAllComplete, orch.State(), "single-wave plan should be AllComplete")
 
 	dir := t.TempDir()
 	plansDir := filepath.Join(dir, "docs", "plans")
@@ -496,7 +497,7 @@ func TestRetryFailedWaveTasks_RemovesOldInstances(t *testing.T) {
 	storage, err := session.NewStorage(state)
 	require.NoError(t, err)
 
-	h := waveFlowHome(t, ps, plansDir, map[string]*WaveOrchestrator{planFile: orch})
+	h := waveFlowHome(t, ps, plansDir, map[string]*orchestration.WaveOrchestrator{planFile: orch})
 	h.storage = storage
 	h.allInstances = []*session.Instance{inst1, failedInst6}
 	h.activeRepoPath = dir
@@ -612,7 +613,7 @@ func TestPlannerExit_CancelKillsInstanceAndMarksPrompted(t *testing.T) {
 		tabbedWindow:                ui.NewTabbedWindow(ui.NewPreviewPane(), ui.NewDiffPane(), ui.NewInfoPane()),
 		toastManager:                overlay.NewToastManager(&sp),
 		storage:                     storage,
-		waveOrchestrators:           make(map[string]*WaveOrchestrator),
+		waveOrchestrators:           make(map[string]*orchestration.WaveOrchestrator),
 		plannerPrompted:             make(map[string]bool),
 		pendingPlannerInstanceTitle: "planner-cancel-inst",
 		pendingPlannerPlanFile:      planFile,
@@ -652,7 +653,7 @@ func TestWaveMonitor_FocusesTaskInstance_WhenWaveCompleteShown(t *testing.T) {
 			{Number: 2, Tasks: []planparser.Task{{Number: 2, Title: "Task 2", Body: "follow up"}}},
 		},
 	}
-	orch := NewWaveOrchestrator(planFile, plan)
+	orch := orchestration.NewWaveOrchestrator(planFile, plan)
 	orch.StartNextWave()
 
 	dir := t.TempDir()
@@ -679,7 +680,7 @@ func TestWaveMonitor_FocusesTaskInstance_WhenWaveCompleteShown(t *testing.T) {
 	taskInst.MarkStartedForTest()
 	taskInst.PromptDetected = true
 
-	h := waveFlowHome(t, ps, plansDir, map[string]*WaveOrchestrator{planFile: orch})
+	h := waveFlowHome(t, ps, plansDir, map[string]*orchestration.WaveOrchestrator{planFile: orch})
 	_ = h.nav.AddInstance(otherInst)
 	_ = h.nav.AddInstance(taskInst)
 	h.updateSidebarPlans() // register plans so rebuildRows emits plan-grouped instances
@@ -713,7 +714,7 @@ func TestWaveMonitor_FocusesTaskInstance_WhenFailedWaveShown(t *testing.T) {
 			{Number: 2, Tasks: []planparser.Task{{Number: 2, Title: "Task 2", Body: "follow up"}}},
 		},
 	}
-	orch := NewWaveOrchestrator(planFile, plan)
+	orch := orchestration.NewWaveOrchestrator(planFile, plan)
 	orch.StartNextWave()
 
 	dir := t.TempDir()
@@ -740,7 +741,7 @@ func TestWaveMonitor_FocusesTaskInstance_WhenFailedWaveShown(t *testing.T) {
 	taskInst.MarkStartedForTest()
 	taskInst.SetStatus(session.Paused) // paused = treated as failed
 
-	h := waveFlowHome(t, ps, plansDir, map[string]*WaveOrchestrator{planFile: orch})
+	h := waveFlowHome(t, ps, plansDir, map[string]*orchestration.WaveOrchestrator{planFile: orch})
 	_ = h.nav.AddInstance(otherInst)
 	_ = h.nav.AddInstance(taskInst)
 	h.updateSidebarPlans() // register plans so rebuildRows emits plan-grouped instances
@@ -789,7 +790,7 @@ func TestPlannerExit_FocusesPlannerInstance_BeforeConfirm(t *testing.T) {
 	otherInst.MarkStartedForTest()
 
 	h := waveFlowHome(t, ps, plansDir, nil)
-	h.waveOrchestrators = make(map[string]*WaveOrchestrator)
+	h.waveOrchestrators = make(map[string]*orchestration.WaveOrchestrator)
 	h.plannerPrompted = make(map[string]bool)
 	h.pendingReviewFeedback = make(map[string]string)
 	h.fsm = newPlanFSMForTest(t, plansDir)
@@ -831,7 +832,7 @@ func TestWaveMonitor_AllComplete_DeferredWhenOverlayActive(t *testing.T) {
 			{Number: 1, Tasks: []planparser.Task{{Number: 1, Title: "Only task", Body: "do it"}}},
 		},
 	}
-	orch := NewWaveOrchestrator(planFile, plan)
+	orch := orchestration.NewWaveOrchestrator(planFile, plan)
 	orch.StartNextWave()
 
 	dir := t.TempDir()
@@ -853,7 +854,7 @@ func TestWaveMonitor_AllComplete_DeferredWhenOverlayActive(t *testing.T) {
 	require.NoError(t, err)
 	inst.PromptDetected = true
 
-	h := waveFlowHome(t, ps, plansDir, map[string]*WaveOrchestrator{planFile: orch})
+	h := waveFlowHome(t, ps, plansDir, map[string]*orchestration.WaveOrchestrator{planFile: orch})
 	h.fsm = newPlanFSMForTest(t, plansDir)
 	_ = h.nav.AddInstance(inst)
 
@@ -903,13 +904,13 @@ func TestAutoAdvanceWaves_SkipsConfirmOnSuccess(t *testing.T) {
 			{Number: 2, Tasks: []planparser.Task{{Number: 2, Title: "T2"}}},
 		},
 	}
-	orch := NewWaveOrchestrator("test.md", plan)
+	orch := orchestration.NewWaveOrchestrator("test.md", plan)
 	orch.StartNextWave()
 	orch.MarkTaskComplete(1) // wave 1 complete, no failures
 
 	m := &home{
 		appConfig:         &config.Config{AutoAdvanceWaves: true},
-		waveOrchestrators: map[string]*WaveOrchestrator{"test.md": orch},
+		waveOrchestrators: map[string]*orchestration.WaveOrchestrator{"test.md": orch},
 		planState:         &planstate.PlanState{Plans: map[string]planstate.PlanEntry{"test.md": {Status: "implementing"}}},
 		state:             stateDefault,
 	}
@@ -938,7 +939,7 @@ func TestAutoAdvanceWaves_ShowsConfirmOnFailure(t *testing.T) {
 			{Number: 2, Tasks: []planparser.Task{{Number: 3, Title: "T3"}}},
 		},
 	}
-	orch := NewWaveOrchestrator(planFile, plan)
+	orch := orchestration.NewWaveOrchestrator(planFile, plan)
 	orch.StartNextWave()
 	orch.MarkTaskComplete(1)
 	orch.MarkTaskFailed(2) // wave 1 complete with 1 failure
@@ -974,7 +975,7 @@ func TestAutoAdvanceWaves_ShowsConfirmOnFailure(t *testing.T) {
 	require.NoError(t, err)
 	inst2.SetStatus(session.Paused) // failed
 
-	h := waveFlowHome(t, ps, plansDir, map[string]*WaveOrchestrator{planFile: orch})
+	h := waveFlowHome(t, ps, plansDir, map[string]*orchestration.WaveOrchestrator{planFile: orch})
 	// Enable auto-advance
 	h.appConfig = &config.Config{AutoAdvanceWaves: true}
 	_ = h.nav.AddInstance(inst1)
@@ -1011,7 +1012,7 @@ func TestAutoAdvanceWaves_EmitsAdvanceMsgOnSuccess(t *testing.T) {
 			{Number: 2, Tasks: []planparser.Task{{Number: 2, Title: "T2"}}},
 		},
 	}
-	orch := NewWaveOrchestrator(planFile, plan)
+	orch := orchestration.NewWaveOrchestrator(planFile, plan)
 	orch.StartNextWave()
 
 	dir := t.TempDir()
@@ -1033,7 +1034,7 @@ func TestAutoAdvanceWaves_EmitsAdvanceMsgOnSuccess(t *testing.T) {
 	require.NoError(t, err)
 	inst.PromptDetected = true
 
-	h := waveFlowHome(t, ps, plansDir, map[string]*WaveOrchestrator{planFile: orch})
+	h := waveFlowHome(t, ps, plansDir, map[string]*orchestration.WaveOrchestrator{planFile: orch})
 	// Enable auto-advance
 	h.appConfig = &config.Config{AutoAdvanceWaves: true}
 	_ = h.nav.AddInstance(inst)
@@ -1081,7 +1082,7 @@ func TestCoderExit_FocusesCoderInstance_BeforePushConfirm(t *testing.T) {
 	otherInst.MarkStartedForTest()
 
 	h := waveFlowHome(t, ps, plansDir, nil)
-	h.waveOrchestrators = make(map[string]*WaveOrchestrator)
+	h.waveOrchestrators = make(map[string]*orchestration.WaveOrchestrator)
 	h.plannerPrompted = make(map[string]bool)
 	h.pendingReviewFeedback = make(map[string]string)
 	_ = h.nav.AddInstance(otherInst)
@@ -66,10 +66,15 @@ func (h helpTypeGeneral) toContent() string {
 		keyStyle.Render("tab/shift+tab")+descStyle.Render(" - cycle tabs (info → agent → diff)"),
 		keyStyle.Render("!/@ /#")+descStyle.Render("        - jump to agent/diff/info tab"),
 		keyStyle.Render("g")+descStyle.Render("             - info tab"),
+		keyStyle.Render("shift+↑↓")+descStyle.Render("      - diff tab: previous / next file"),
+		keyStyle.Render("|")+descStyle.Render("             - diff tab: toggle side-by-side"),
+		keyStyle.Render("[/]")+descStyle.Render("           - diff tab: step through branch commits"),
+		keyStyle.Render("f")+descStyle.Render("             - diff tab: filter by task"),
 		keyStyle.Render("↑↓")+descStyle.Render("            - navigate within focused pane"),
 		keyStyle.Render("←→")+descStyle.Render("            - move between panes"),
 		keyStyle.Render("ctrl+s")+descStyle.Render("        - toggle sidebar visibility"),
 		keyStyle.Render("L")+descStyle.Render("             - toggle audit log pane"),
+		keyStyle.Render("A")+descStyle.Render("             - delivery analytics"),
 		keyStyle.Render("/")+descStyle.Render("             - search plans and instances"),
 		keyStyle.Render("q")+descStyle.Render("             - quit"),
 	)
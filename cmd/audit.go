@@ -0,0 +1,332 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/config/planstore"
+	"github.com/spf13/cobra"
+)
+
+// auditPageSize is how many events are fetched per query; the loggers cap a
+// single query at 500.
+const auditPageSize = 500
+
+// auditFollowInterval is how often --follow polls for new events.
+const auditFollowInterval = time.Second
+
+// auditFormats are the output formats accepted by --format.
+var auditFormats = []string{"table", "json", "jsonl", "csv"}
+
+// NewAuditCmd returns the `kas audit` cobra command.
+func NewAuditCmd() *cobra.Command {
+	var (
+		project   string
+		plan      string
+		instance  string
+		kinds     []string
+		levels    []string
+		since     string
+		until     string
+		limit     int
+		follow    bool
+		format    string
+		olderThan string
+	)
+
+	auditCmd := &cobra.Command{
+		Use:   "audit",
+		Short: "query, follow and export the audit log",
+		Long: `Print audit events, oldest first. Filters combine; --since and --until take
+an age (30m, 12h, 7d, 2w), a date (2026-03-01) or an RFC3339 timestamp.
+When plan_store points at a remote server the shared log is queried.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(auditFormats, format) {
+				return fmt.Errorf("unknown format %q (want %s)", format, strings.Join(auditFormats, ", "))
+			}
+			if follow && format == "json" {
+				return fmt.Errorf("--follow cannot stream a json array; use --format=jsonl")
+			}
+			now := time.Now()
+			filter := auditlog.QueryFilter{
+				Project:       project,
+				PlanFile:      plan,
+				InstanceTitle: instance,
+				Levels:        levels,
+				Limit:         limit,
+			}
+			for _, k := range kinds {
+				filter.Kinds = append(filter.Kinds, auditlog.EventKind(k))
+			}
+			var err error
+			if filter.After, err = parseAuditTime(since, now); err != nil {
+				return fmt.Errorf("--since: %w", err)
+			}
+			if filter.Before, err = parseAuditTime(until, now); err != nil {
+				return fmt.Errorf("--until: %w", err)
+			}
+
+			logger, err := openAuditLogger()
+			if err != nil {
+				return err
+			}
+			defer logger.Close()
+
+			if !follow {
+				return executeAuditQuery(os.Stdout, logger, filter, format)
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return followAudit(ctx, os.Stdout, logger, filter, format, auditFollowInterval)
+		},
+	}
+
+	auditCmd.Flags().StringVar(&project, "project", "", "only events for this project")
+	auditCmd.Flags().StringVar(&plan, "plan", "", "only events for this plan file")
+	auditCmd.Flags().StringVar(&instance, "instance", "", "only events for this instance title")
+	auditCmd.Flags().StringSliceVar(&kinds, "kind", nil, "only these event kinds (repeatable or comma separated)")
+	auditCmd.Flags().StringSliceVar(&levels, "level", nil, "only these levels: info, warn, error")
+	auditCmd.Flags().StringVar(&since, "since", "", "only events after this time or age")
+	auditCmd.Flags().StringVar(&until, "until", "", "only events before this time or age")
+	auditCmd.Flags().IntVarP(&limit, "limit", "n", 50, "most recent events to print (0 for all)")
+	auditCmd.Flags().BoolVarP(&follow, "follow", "f", false, "keep printing new events as they arrive")
+	auditCmd.Flags().StringVar(&format, "format", "table", "output format: "+strings.Join(auditFormats, ", "))
+
+	// kas audit prune
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "delete audit events older than a given age",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			age, err := ParseAge(olderThan)
+			if err != nil {
+				return fmt.Errorf("--older-than: %w", err)
+			}
+			logger, err := openAuditLogger()
+			if err != nil {
+				return err
+			}
+			defer logger.Close()
+			deleted, err := executeAuditPrune(logger, time.Now().Add(-age))
+			if err != nil {
+				return err
+			}
+			fmt.Printf("pruned %d audit events older than %s\n", deleted, olderThan)
+			return nil
+		},
+	}
+	pruneCmd.Flags().StringVar(&olderThan, "older-than", "30d", "age of the events to delete (e.g. 12h, 30d, 8w)")
+	auditCmd.AddCommand(pruneCmd)
+
+	return auditCmd
+}
+
+// openAuditLogger opens the audit log the TUI writes: the local SQLite DB,
+// fronted by the remote plan store server when one is configured.
+func openAuditLogger() (auditlog.Logger, error) {
+	local, err := auditlog.NewSQLiteLogger(planstore.ResolvedDBPath())
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	if cfg := config.LoadConfig(); cfg.PlanStore != "" {
+		return auditlog.NewHTTPLogger(cfg.PlanStore, local), nil
+	}
+	return local, nil
+}
+
+// executeAuditQuery prints the events matching filter, oldest first.
+func executeAuditQuery(w io.Writer, logger auditlog.Logger, filter auditlog.QueryFilter, format string) error {
+	events, err := fetchAuditEvents(logger, filter)
+	if err != nil {
+		return err
+	}
+	return writeAuditEvents(w, events, format, true)
+}
+
+// fetchAuditEvents runs filter page by page until filter.Limit events (all
+// of them when 0) have been read, and returns them oldest first.
+func fetchAuditEvents(logger auditlog.Logger, filter auditlog.QueryFilter) ([]auditlog.Event, error) {
+	want := filter.Limit
+	var all []auditlog.Event
+	page := filter
+	for {
+		page.Limit = auditPageSize
+		if want > 0 {
+			page.Limit = min(auditPageSize, want-len(all))
+		}
+		events, err := logger.Query(page)
+		if err != nil {
+			return nil, fmt.Errorf("query audit log: %w", err)
+		}
+		all = append(all, events...)
+		if len(events) < page.Limit || (want > 0 && len(all) >= want) {
+			break
+		}
+		page.Before = events[len(events)-1].Timestamp
+	}
+	slices.Reverse(all)
+	return all, nil
+}
+
+// followAudit prints the latest events like executeAuditQuery, then polls
+// for newer ones every interval until ctx is done.
+func followAudit(ctx context.Context, w io.Writer, logger auditlog.Logger, filter auditlog.QueryFilter, format string, interval time.Duration) error {
+	events, err := fetchAuditEvents(logger, filter)
+	if err != nil {
+		return err
+	}
+	if err := writeAuditEvents(w, events, format, true); err != nil {
+		return err
+	}
+	next := filter
+	next.Limit = 0
+	next.Before = time.Time{}
+	if len(events) > 0 {
+		next.After = events[len(events)-1].Timestamp
+	} else if next.After.IsZero() {
+		next.After = time.Now()
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		events, err := fetchAuditEvents(logger, next)
+		if err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			continue
+		}
+		if err := writeAuditEvents(w, events, format, false); err != nil {
+			return err
+		}
+		next.After = events[len(events)-1].Timestamp
+	}
+}
+
+// executeAuditPrune deletes events older than before.
+func executeAuditPrune(logger auditlog.Logger, before time.Time) (int64, error) {
+	p, ok := logger.(auditlog.Pruner)
+	if !ok {
+		return 0, fmt.Errorf("this audit log cannot be pruned")
+	}
+	return p.Prune(before)
+}
+
+// csvAuditHeader names the columns written by --format=csv.
+var csvAuditHeader = []string{
+	"id", "timestamp", "kind", "level", "project", "plan_file", "instance_title",
+	"agent_type", "program", "wave_number", "task_number", "host", "user", "message", "detail",
+}
+
+// writeAuditEvents renders events in format. header writes the csv header
+// row or the start of a json array; follow mode passes false for the batches
+// after the first.
+func writeAuditEvents(w io.Writer, events []auditlog.Event, format string, header bool) error {
+	switch format {
+	case "json":
+		if events == nil {
+			events = []auditlog.Event{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(events)
+	case "jsonl":
+		enc := json.NewEncoder(w)
+		for _, e := range events {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "csv":
+		cw := csv.NewWriter(w)
+		if header {
+			if err := cw.Write(csvAuditHeader); err != nil {
+				return err
+			}
+		}
+		for _, e := range events {
+			if err := cw.Write([]string{
+				strconv.FormatInt(e.ID, 10),
+				e.Timestamp.UTC().Format(time.RFC3339Nano),
+				string(e.Kind),
+				auditLevel(e),
+				e.Project,
+				e.PlanFile,
+				e.InstanceTitle,
+				e.AgentType,
+				e.Program,
+				strconv.Itoa(e.WaveNumber),
+				strconv.Itoa(e.TaskNumber),
+				e.Host,
+				e.User,
+				e.Message,
+				e.Detail,
+			}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		for _, e := range events {
+			plan := "-"
+			if e.PlanFile != "" {
+				plan = planstate.DisplayName(e.PlanFile)
+			}
+			line := fmt.Sprintf("%s  %-5s  %-22s  %-20s  %s",
+				e.Timestamp.Local().Format("2006-01-02 15:04:05"), auditLevel(e), e.Kind, plan, e.Message)
+			if _, err := fmt.Fprintln(w, strings.TrimRight(line, " ")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// auditLevel returns the event's level, defaulting to info.
+func auditLevel(e auditlog.Event) string {
+	if e.Level == "" {
+		return "info"
+	}
+	return e.Level
+}
+
+// parseAuditTime parses a --since/--until value: an age counted back from
+// now, a date, or an RFC3339 timestamp. Empty means no bound.
+func parseAuditTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	age, err := ParseAge(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not an age, date or RFC3339 time", s)
+	}
+	return now.Add(-age), nil
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setupAuditLog returns an in-memory audit log holding five events a minute
+// apart, starting at base: four info agent events and one merge warning.
+func setupAuditLog(t *testing.T, base time.Time) *auditlog.SQLiteLogger {
+	t.Helper()
+	logger, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { logger.Close() })
+	for i := 0; i < 4; i++ {
+		logger.Emit(auditlog.Event{
+			Kind:      auditlog.EventAgentSpawned,
+			Project:   "kasmos",
+			PlanFile:  "2026-03-01-auth.md",
+			Message:   "spawned " + string(rune('a'+i)),
+			Timestamp: base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+	logger.Emit(auditlog.Event{
+		Kind:      auditlog.EventPlanMerged,
+		Project:   "kasmos",
+		Message:   "merge, with a comma",
+		Level:     "warn",
+		Timestamp: base.Add(4 * time.Minute),
+	})
+	return logger
+}
+
+// syncBuffer is a bytes.Buffer safe to read while followAudit writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestAuditCmd_Exists(t *testing.T) {
+	cmd, _, err := NewRootCmd().Find([]string{"audit", "prune"})
+	require.NoError(t, err)
+	assert.Equal(t, "prune", cmd.Name())
+}
+
+func TestExecuteAuditQuery_Table(t *testing.T) {
+	logger := setupAuditLog(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	var out bytes.Buffer
+	require.NoError(t, executeAuditQuery(&out, logger, auditlog.QueryFilter{Limit: 2}, "table"))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2, "limit keeps the newest events")
+	assert.Contains(t, lines[0], "spawned d", "printed oldest first")
+	assert.Contains(t, lines[0], "auth")
+	assert.Contains(t, lines[1], "warn")
+	assert.Contains(t, lines[1], "merge, with a comma")
+}
+
+func TestExecuteAuditQuery_FiltersAndFormats(t *testing.T) {
+	logger := setupAuditLog(t, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+
+	var out bytes.Buffer
+	require.NoError(t, executeAuditQuery(&out, logger, auditlog.QueryFilter{Levels: []string{"warn"}}, "json"))
+	var events []auditlog.Event
+	require.NoError(t, json.Unmarshal(out.Bytes(), &events))
+	require.Len(t, events, 1)
+	assert.Equal(t, auditlog.EventPlanMerged, events[0].Kind)
+
+	out.Reset()
+	filter := auditlog.QueryFilter{Kinds: []auditlog.EventKind{auditlog.EventAgentSpawned}}
+	require.NoError(t, executeAuditQuery(&out, logger, filter, "jsonl"))
+	assert.Len(t, strings.Split(strings.TrimSpace(out.String()), "\n"), 4)
+
+	out.Reset()
+	require.NoError(t, executeAuditQuery(&out, logger, auditlog.QueryFilter{}, "csv"))
+	rows, err := csv.NewReader(&out).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 6)
+	assert.Equal(t, csvAuditHeader, rows[0])
+	assert.Equal(t, "merge, with a comma", rows[5][13])
+}
+
+func TestFetchAuditEvents_Pages(t *testing.T) {
+	logger, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+	defer logger.Close()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < auditPageSize+20; i++ {
+		logger.Emit(auditlog.Event{Kind: auditlog.EventAgentSpawned, Timestamp: base.Add(time.Duration(i) * time.Second)})
+	}
+
+	events, err := fetchAuditEvents(logger, auditlog.QueryFilter{})
+	require.NoError(t, err)
+	require.Len(t, events, auditPageSize+20)
+	assert.Equal(t, base, events[0].Timestamp.UTC())
+
+	events, err = fetchAuditEvents(logger, auditlog.QueryFilter{Limit: auditPageSize + 5})
+	require.NoError(t, err)
+	assert.Len(t, events, auditPageSize+5)
+}
+
+func TestFollowAudit_PrintsNewEvents(t *testing.T) {
+	logger := setupAuditLog(t, time.Now().Add(-time.Hour))
+
+	var out syncBuffer
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- followAudit(ctx, &out, logger, auditlog.QueryFilter{Limit: 1}, "jsonl", 10*time.Millisecond)
+	}()
+
+	require.Eventually(t, func() bool { return strings.Count(out.String(), "\n") == 1 }, time.Second, 5*time.Millisecond)
+	logger.Emit(auditlog.Event{Kind: auditlog.EventPlanTransition, Message: "live"})
+	require.Eventually(t, func() bool { return strings.Contains(out.String(), "live") }, time.Second, 5*time.Millisecond)
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, 2, strings.Count(out.String(), "\n"), "each event printed once")
+}
+
+func TestExecuteAuditPrune(t *testing.T) {
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	logger := setupAuditLog(t, base)
+
+	deleted, err := executeAuditPrune(logger, base.Add(2*time.Minute))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, deleted)
+	events, err := logger.Query(auditlog.QueryFilter{})
+	require.NoError(t, err)
+	assert.Len(t, events, 3)
+
+	_, err = executeAuditPrune(auditlog.NopLogger(), base)
+	assert.Error(t, err)
+}
+
+func TestParseAuditTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	for in, want := range map[string]time.Time{
+		"":                     {},
+		"90m":                  now.Add(-90 * time.Minute),
+		"7d":                   now.Add(-7 * 24 * time.Hour),
+		"2w":                   now.Add(-14 * 24 * time.Hour),
+		"2026-03-01T08:00:00Z": time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+		"2026-03-01":           time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local),
+	} {
+		got, err := parseAuditTime(in, now)
+		require.NoError(t, err, in)
+		assert.True(t, want.Equal(got), "%s: got %v want %v", in, got, want)
+	}
+	for _, in := range []string{"soon", "-3d", "d"} {
+		_, err := parseAuditTime(in, now)
+		assert.Error(t, err, in)
+	}
+}
@@ -42,5 +42,7 @@ func NewRootCmd() *cobra.Command {
 	}
 	root.AddCommand(NewPlanCmd())
 	root.AddCommand(NewServeCmd())
+	root.AddCommand(NewAuditCmd())
+	root.AddCommand(NewMCPCmd(""))
 	return root
 }
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/kastheco/kasmos/control"
+	"github.com/spf13/cobra"
+)
+
+// executeCtlInstances returns a formatted table of the instances reported by
+// the running TUI.
+func executeCtlInstances(client *control.Client) (string, error) {
+	instances, err := client.ListInstances()
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, inst := range instances {
+		status := inst.Status
+		if inst.PermissionPending {
+			status += " (permission)"
+		}
+		line := fmt.Sprintf("%-22s %-28s %-10s %s", status, inst.Title, inst.Program, inst.PlanFile)
+		sb.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	return sb.String(), nil
+}
+
+// executeCtlPlans returns a formatted table of the running TUI's plans.
+func executeCtlPlans(client *control.Client) (string, error) {
+	plans, err := client.ListPlans()
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	for _, p := range plans {
+		line := fmt.Sprintf("%-14s %-50s %s", p.Status, p.Filename, p.Branch)
+		sb.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	return sb.String(), nil
+}
+
+// executeCtlWatch prints one line per instance event until ctx is cancelled.
+func executeCtlWatch(ctx context.Context, client *control.Client, w io.Writer) error {
+	return client.Watch(ctx, func(e control.Event) error {
+		ts := e.Time.Local().Format("15:04:05")
+		var err error
+		switch e.Type {
+		case control.EventInstanceStatus:
+			_, err = fmt.Fprintf(w, "%s %s %s → %s\n", ts, e.Instance, e.Previous, e.Status)
+		case control.EventInstanceAdded:
+			_, err = fmt.Fprintf(w, "%s %s added (%s)\n", ts, e.Instance, e.Status)
+		case control.EventInstanceRemoved:
+			_, err = fmt.Fprintf(w, "%s %s removed\n", ts, e.Instance)
+		default:
+			_, err = fmt.Fprintf(w, "%s %s %s\n", ts, e.Instance, e.Type)
+		}
+		return err
+	})
+}
+
+// NewCtlCmd returns the `kas ctl` cobra command tree, which drives a running
+// kasmos TUI through its control socket.
+func NewCtlCmd() *cobra.Command {
+	var socketFlag string
+
+	ctlCmd := &cobra.Command{
+		Use:   "ctl",
+		Short: "control a running kasmos session (list, prompt, pause, stage plans)",
+	}
+	ctlCmd.PersistentFlags().StringVar(&socketFlag, "socket", "", "control socket path (default: the socket of the kasmos TUI running in this repo)")
+
+	client := func() (*control.Client, error) {
+		path := socketFlag
+		if path == "" {
+			cwd, err := filepath.Abs(".")
+			if err != nil {
+				return nil, err
+			}
+			if path, err = control.SocketPath(cwd); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := os.Stat(path); err != nil {
+			return nil, fmt.Errorf("no kasmos session is running here (socket %s not found)", path)
+		}
+		return control.NewClient(path), nil
+	}
+
+	ctlCmd.AddCommand(&cobra.Command{
+		Use:   "instances",
+		Short: "list instances",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := client()
+			if err != nil {
+				return err
+			}
+			out, err := executeCtlInstances(c)
+			if err != nil {
+				return err
+			}
+			fmt.Print(out)
+			return nil
+		},
+	})
+
+	ctlCmd.AddCommand(&cobra.Command{
+		Use:   "plans",
+		Short: "list plans",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := client()
+			if err != nil {
+				return err
+			}
+			out, err := executeCtlPlans(c)
+			if err != nil {
+				return err
+			}
+			fmt.Print(out)
+			return nil
+		},
+	})
+
+	ctlCmd.AddCommand(&cobra.Command{
+		Use:   "watch",
+		Short: "stream instance status changes",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := client()
+			if err != nil {
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return executeCtlWatch(ctx, c, os.Stdout)
+		},
+	})
+
+	ctlCmd.AddCommand(&cobra.Command{
+		Use:   "prompt <instance> <text>",
+		Short: "send a prompt to an instance",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := client()
+			if err != nil {
+				return err
+			}
+			return c.SendPrompt(args[0], strings.Join(args[1:], " "))
+		},
+	})
+
+	ctlCmd.AddCommand(&cobra.Command{
+		Use:       "permission <instance> <allow_once|allow_always|reject>",
+		Short:     "answer an instance's pending permission prompt",
+		Args:      cobra.ExactArgs(2),
+		ValidArgs: []string{control.PermissionAllowOnce, control.PermissionAllowAlways, control.PermissionReject},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := client()
+			if err != nil {
+				return err
+			}
+			return c.AnswerPermission(args[0], args[1])
+		},
+	})
+
+	for _, action := range []string{control.ActionPause, control.ActionResume, control.ActionKill} {
+		ctlCmd.AddCommand(&cobra.Command{
+			Use:   action + " <instance>",
+			Short: action + " an instance",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				c, err := client()
+				if err != nil {
+					return err
+				}
+				if err := c.InstanceAction(args[0], action); err != nil {
+					return err
+				}
+				fmt.Printf("%s: %s\n", args[0], action)
+				return nil
+			},
+		})
+	}
+
+	ctlCmd.AddCommand(&cobra.Command{
+		Use:   "stage <plan-file> <plan|solo|implement|review|finished>",
+		Short: "trigger a plan lifecycle stage",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := client()
+			if err != nil {
+				return err
+			}
+			if err := c.TriggerPlanStage(args[0], args[1]); err != nil {
+				return err
+			}
+			fmt.Printf("%s: %s triggered\n", args[0], args[1])
+			return nil
+		},
+	})
+
+	return ctlCmd
+}
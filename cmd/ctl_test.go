@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kastheco/kasmos/control"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ctlBackend is a canned control.Backend for exercising the ctl output.
+type ctlBackend struct{}
+
+func (ctlBackend) ListInstances() ([]control.Instance, error) {
+	return []control.Instance{
+		{Title: "auth-implement", Status: "running", Program: "claude", PlanFile: "auth.md"},
+		{Title: "docs", Status: "ready", Program: "opencode", PermissionPending: true},
+	}, nil
+}
+
+func (ctlBackend) ListPlans() ([]control.Plan, error) {
+	return []control.Plan{{Filename: "auth.md", Status: "implementing", Branch: "plan/auth"}}, nil
+}
+
+func (ctlBackend) SendPrompt(string, string) error       { return nil }
+func (ctlBackend) AnswerPermission(string, string) error { return nil }
+func (ctlBackend) InstanceAction(string, string) error   { return nil }
+func (ctlBackend) TriggerPlanStage(string, string) error { return nil }
+
+func startCtlServer(t *testing.T) (*control.Server, *control.Client) {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "kasctl")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	srv, err := control.Listen(filepath.Join(dir, "c.sock"), ctlBackend{})
+	require.NoError(t, err)
+	t.Cleanup(func() { srv.Close() })
+	return srv, control.NewClient(srv.Path())
+}
+
+func TestCtlInstancesAndPlans(t *testing.T) {
+	_, client := startCtlServer(t)
+
+	out, err := executeCtlInstances(client)
+	require.NoError(t, err)
+	assert.Contains(t, out, "running")
+	assert.Contains(t, out, "auth-implement")
+	assert.Contains(t, out, "ready (permission)")
+
+	out, err = executeCtlPlans(client)
+	require.NoError(t, err)
+	assert.Equal(t, "implementing   auth.md                                            plan/auth\n", out)
+}
+
+// lineWriter hands each write to the test over a channel, dropping writes
+// once the test stops reading.
+type lineWriter chan string
+
+func (w lineWriter) Write(p []byte) (int, error) {
+	select {
+	case w <- string(p):
+	default:
+	}
+	return len(p), nil
+}
+
+func TestCtlWatchPrintsTransitions(t *testing.T) {
+	srv, client := startCtlServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	lines := make(lineWriter, 16)
+	done := make(chan error, 1)
+	go func() { done <- executeCtlWatch(ctx, client, lines) }()
+
+	// Publish until the watcher has subscribed and printed the event.
+	var line string
+	require.Eventually(t, func() bool {
+		srv.Publish(control.Event{Type: control.EventInstanceStatus, Instance: "docs", Previous: "running", Status: "ready"})
+		select {
+		case line = <-lines:
+			return true
+		default:
+			return false
+		}
+	}, 4*time.Second, 20*time.Millisecond)
+	assert.Contains(t, line, "docs running → ready")
+
+	cancel()
+	assert.NoError(t, <-done)
+}
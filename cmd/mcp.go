@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/control"
+	"github.com/kastheco/kasmos/internal/mcpserver"
+	"github.com/spf13/cobra"
+)
+
+// mainRepoRoot returns the main working tree of the repository containing
+// dir, so agents in .worktrees/ find the TUI and plans of the repo that
+// spawned them. dir is returned as is outside a git repository.
+func mainRepoRoot(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--path-format=absolute", "--git-common-dir").Output()
+	if err != nil {
+		return dir
+	}
+	return filepath.Dir(strings.TrimSpace(string(out)))
+}
+
+// mcpHandler forwards messages to the TUI listening on socketPath and
+// answers them with local while no TUI is running. The check is made per
+// message, so an agent started before the TUI reaches it once it is up.
+func mcpHandler(socketPath string, local mcpserver.HandleFunc) mcpserver.HandleFunc {
+	forward := mcpserver.Forward(control.SocketHTTPClient(socketPath), control.MCPURL)
+	return func(ctx context.Context, agent mcpserver.Agent, msg []byte) []byte {
+		if conn, err := net.Dial("unix", socketPath); err == nil {
+			conn.Close()
+			return forward(ctx, agent, msg)
+		}
+		return local(ctx, agent, msg)
+	}
+}
+
+// NewMCPCmd returns the `kas mcp` command: the MCP server, speaking over
+// stdio, that kasmos registers in each harness so agents can report
+// progress and reach the user through tool calls. version is reported to
+// clients when no TUI answers.
+func NewMCPCmd(version string) *cobra.Command {
+	var socketFlag string
+
+	mcpCmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "serve kasmos tools to agents over MCP (stdio)",
+		Long: "Serve kasmos tools (report_task_done, read_plan, ask_user, ...) to the agent that launched\n" +
+			"this process. Calls go to the kasmos TUI running in the repo, or are written as sentinel files\n" +
+			"for the daemon when none is running.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cwd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			repo := mainRepoRoot(cwd)
+			path := socketFlag
+			if path == "" {
+				if path, err = control.SocketPath(repo); err != nil {
+					return err
+				}
+			}
+			plansDir := filepath.Join(repo, "docs", "plans")
+			backend := mcpserver.NewSignalBackend(cwd, func() (*planstate.PlanState, error) {
+				return LoadPlanState(plansDir)
+			})
+			local := mcpserver.NewServer(backend, version).Handle
+
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			agent := mcpserver.AgentFromEnv(os.Getenv)
+			return mcpserver.ServeStdio(ctx, cmd.InOrStdin(), cmd.OutOrStdout(), agent, mcpHandler(path, local))
+		},
+	}
+	mcpCmd.Flags().StringVar(&socketFlag, "socket", "", "control socket path (default: the socket of the kasmos TUI running in this repo)")
+	return mcpCmd
+}
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kastheco/kasmos/control"
+	"github.com/kastheco/kasmos/internal/mcpserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPHandler_ForwardsToTUIWhenRunning(t *testing.T) {
+	dir, err := os.MkdirTemp("", "kasmcp")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socketPath := filepath.Join(dir, "c.sock")
+
+	local := func(context.Context, mcpserver.Agent, []byte) []byte { return []byte("local") }
+	handle := mcpHandler(socketPath, local)
+	agent := mcpserver.Agent{Role: mcpserver.RoleCoder, PlanFile: "auth.md"}
+
+	assert.Equal(t, "local", string(handle(context.Background(), agent, []byte("{}"))), "no TUI running")
+
+	var gotAgent mcpserver.Agent
+	tui := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAgent = mcpserver.AgentFromHeader(r.Header)
+		_, _ = w.Write([]byte("tui"))
+	})
+	srv, err := control.Listen(socketPath, ctlBackend{}, control.WithMCP(tui))
+	require.NoError(t, err)
+	defer srv.Close()
+
+	assert.Equal(t, "tui", string(handle(context.Background(), agent, []byte("{}"))))
+	assert.Equal(t, agent, gotAgent)
+}
+
+func TestMainRepoRoot_OutsideGitReturnsDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, dir, mainRepoRoot(dir))
+}
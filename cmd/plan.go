@@ -8,6 +8,7 @@ import (
 
 	"github.com/kastheco/kasmos/config"
 	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/config/planlog"
 	"github.com/kastheco/kasmos/config/planstate"
 	"github.com/kastheco/kasmos/config/planstore"
 	"github.com/spf13/cobra"
@@ -15,8 +16,9 @@ import (
 
 // executePlanRegister registers a plan file that exists on disk but isn't
 // tracked in plan state yet. It extracts a description from the first markdown
-// heading and uses the conventional branch name format.
-func executePlanRegister(plansDir, planFile, branch string, store planstore.Store) error {
+// heading and uses the conventional branch name format. A non-empty parent
+// stacks the plan on that plan's branch.
+func executePlanRegister(plansDir, planFile, branch, parent string, store planstore.Store) error {
 	fullPath := filepath.Join(plansDir, planFile)
 	if _, err := os.Stat(fullPath); err != nil {
 		return fmt.Errorf("plan file not found on disk: %s", fullPath)
@@ -46,9 +48,20 @@ func executePlanRegister(plansDir, planFile, branch string, store planstore.Stor
 		slug = strings.TrimSuffix(slug, ".md")
 		branch = "plan/" + slug
 	}
+	if parent != "" {
+		if _, ok := ps.Entry(parent); !ok {
+			return fmt.Errorf("parent plan not found: %s", parent)
+		}
+	}
 	info, _ := os.Stat(fullPath)
 	createdAt := info.ModTime()
-	return ps.Register(planFile, desc, branch, createdAt)
+	if err := ps.Register(planFile, desc, branch, createdAt); err != nil {
+		return err
+	}
+	if parent != "" {
+		return ps.SetParent(planFile, parent)
+	}
+	return nil
 }
 
 // executePlanList returns a formatted string listing all plans, optionally
@@ -174,11 +187,68 @@ func executePlanImplement(plansDir, planFile string, wave int, store planstore.S
 	return os.WriteFile(filepath.Join(signalsDir, signalName), nil, 0o644)
 }
 
+// executePlanLog returns the plan branch's history grouped by wave and task,
+// using the Kasmos-* trailers agents add to their commits. History is taken
+// relative to the branch checked out in the repo root, which is where plan
+// branches merge.
+func executePlanLog(plansDir, planFile string, store planstore.Store) (string, error) {
+	ps, err := loadPlanState(plansDir, store)
+	if err != nil {
+		return "", err
+	}
+	entry, ok := ps.Entry(planFile)
+	if !ok {
+		return "", fmt.Errorf("plan not found: %s", planFile)
+	}
+	if entry.Branch == "" {
+		return "", fmt.Errorf("plan has no branch: %s", planFile)
+	}
+	repoRoot := filepath.Dir(filepath.Dir(plansDir))
+	commits, err := planlog.Read(repoRoot, "HEAD", entry.Branch)
+	if err != nil {
+		return "", err
+	}
+	return formatPlanLog(entry.Branch, commits), nil
+}
+
+// formatPlanLog renders commits grouped by wave, then task.
+func formatPlanLog(branch string, commits []planlog.Commit) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s (%d commits)\n", branch, len(commits))
+	wave := -1
+	for _, g := range planlog.GroupByTask(commits) {
+		if g.Wave != wave {
+			wave = g.Wave
+			if wave == 0 {
+				sb.WriteString("\nno wave\n")
+			} else {
+				fmt.Fprintf(&sb, "\nwave %d\n", wave)
+			}
+		}
+		label := "  other"
+		if g.Task > 0 {
+			label = fmt.Sprintf("  task %d", g.Task)
+		}
+		if g.Agent != "" {
+			label += " (" + g.Agent + ")"
+		}
+		sb.WriteString(label + "\n")
+		for _, c := range g.Commits {
+			sha := c.SHA
+			if len(sha) > 7 {
+				sha = sha[:7]
+			}
+			fmt.Fprintf(&sb, "    %s %s\n", sha, c.Subject)
+		}
+	}
+	return sb.String()
+}
+
 // NewPlanCmd builds the `kq plan` cobra command tree.
 func NewPlanCmd() *cobra.Command {
 	planCmd := &cobra.Command{
 		Use:   "plan",
-		Short: "manage plan lifecycle (list, set-status, transition, implement)",
+		Short: "manage plan lifecycle (list, set-status, transition, implement, log)",
 	}
 
 	// kq plan list
@@ -199,7 +269,7 @@ func NewPlanCmd() *cobra.Command {
 	planCmd.AddCommand(listCmd)
 
 	// kq plan register
-	var branchFlag string
+	var branchFlag, parentFlag string
 	registerCmd := &cobra.Command{
 		Use:   "register <plan-file>",
 		Short: "register an untracked plan file (sets status to ready)",
@@ -209,7 +279,7 @@ func NewPlanCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			if err := executePlanRegister(plansDir, args[0], branchFlag, resolveStore(plansDir)); err != nil {
+			if err := executePlanRegister(plansDir, args[0], branchFlag, parentFlag, resolveStore(plansDir)); err != nil {
 				return err
 			}
 			fmt.Printf("registered: %s → ready\n", args[0])
@@ -217,6 +287,7 @@ func NewPlanCmd() *cobra.Command {
 		},
 	}
 	registerCmd.Flags().StringVar(&branchFlag, "branch", "", "override branch name (default: plan/<slug>)")
+	registerCmd.Flags().StringVar(&parentFlag, "parent", "", "stack the plan on another plan's branch (parent plan file)")
 	planCmd.AddCommand(registerCmd)
 
 	// kq plan set-status
@@ -281,6 +352,26 @@ func NewPlanCmd() *cobra.Command {
 	implementCmd.Flags().IntVar(&waveNum, "wave", 1, "wave number to trigger (default: 1)")
 	planCmd.AddCommand(implementCmd)
 
+	// kq plan log
+	logCmd := &cobra.Command{
+		Use:   "log <plan-file>",
+		Short: "show the plan branch history grouped by wave and task",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			plansDir, err := resolvePlansDir()
+			if err != nil {
+				return err
+			}
+			out, err := executePlanLog(plansDir, args[0], resolveStore(plansDir))
+			if err != nil {
+				return err
+			}
+			fmt.Print(out)
+			return nil
+		},
+	}
+	planCmd.AddCommand(logCmd)
+
 	return planCmd
 }
 
@@ -329,6 +420,25 @@ func loadPlanState(plansDir string, store planstore.Store) (*planstate.PlanState
 	return planstate.Load(store, projectFromPlansDir(plansDir), plansDir)
 }
 
+// LoadPlanState loads the plans in plansDir from the configured remote store,
+// or the local SQLite store when none is reachable.
+func LoadPlanState(plansDir string) (*planstate.PlanState, error) {
+	return loadPlanState(plansDir, resolveStore(plansDir))
+}
+
+// LoadPlanStateStrict loads the plans in plansDir like LoadPlanState, but
+// fails when the configured remote store is unreachable instead of falling
+// back to the local store. plansDir need not exist.
+func LoadPlanStateStrict(plansDir string) (*planstate.PlanState, error) {
+	store, _ := resolveStoreConfig(plansDir)
+	if store != nil {
+		if err := store.Ping(); err != nil {
+			return nil, fmt.Errorf("plan store unreachable: %w", err)
+		}
+	}
+	return loadPlanState(plansDir, store)
+}
+
 // newFSM creates a PlanStateMachine backed by the given store.
 // When store is nil, falls back to the local SQLite store.
 func newFSM(plansDir string, store planstore.Store) *planfsm.PlanStateMachine {
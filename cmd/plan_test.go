@@ -7,6 +7,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/planlog"
 	"github.com/kastheco/kasmos/config/planstate"
 	"github.com/kastheco/kasmos/config/planstore"
 	"github.com/stretchr/testify/assert"
@@ -188,7 +190,7 @@ func TestPlanImplement(t *testing.T) {
 // store-backed HTTP server, returning plan entries from the remote store.
 func TestPlanList_WithStore(t *testing.T) {
 	backend := planstore.NewTestSQLiteStore(t)
-	srv := httptest.NewServer(planstore.NewHandler(backend))
+	srv := httptest.NewServer(planstore.NewHandler(backend, nil))
 	defer srv.Close()
 
 	err := backend.Create("test-project", planstore.PlanEntry{
@@ -200,3 +202,64 @@ func TestPlanList_WithStore(t *testing.T) {
 	assert.Contains(t, output, "test.md")
 	assert.Contains(t, output, "ready")
 }
+
+func TestFormatPlanLog(t *testing.T) {
+	out := formatPlanLog("plan/auth", []planlog.Commit{
+		{SHA: "1111111aaaa", Subject: "scaffold plan"},
+		{SHA: "2222222bbbb", Subject: "add token store", Wave: 1, Task: 2, Agent: "coder"},
+		{SHA: "3333333cccc", Subject: "add login route", Wave: 1, Task: 1, Agent: "coder"},
+		{SHA: "4444444dddd", Subject: "wire middleware", Wave: 2, Task: 3, Agent: "coder"},
+	})
+	assert.Equal(t, `plan/auth (4 commits)
+
+wave 1
+  task 1 (coder)
+    3333333 add login route
+  task 2 (coder)
+    2222222 add token store
+
+wave 2
+  task 3 (coder)
+    4444444 wire middleware
+
+no wave
+  other
+    1111111 scaffold plan
+`, out)
+}
+
+func TestPlanRegister_Parent(t *testing.T) {
+	store, dir := setupTestPlanState(t)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2026-02-21-ui.md"), []byte("# UI\n"), 0o644))
+
+	err := executePlanRegister(dir, "2026-02-21-ui.md", "", "missing.md", store)
+	assert.Error(t, err, "unknown parent is rejected")
+
+	require.NoError(t, executePlanRegister(dir, "2026-02-21-ui.md", "", "2026-02-20-test-plan.md", store))
+	ps, err := planstate.Load(store, projectFromPlansDir(dir), dir)
+	require.NoError(t, err)
+	entry, ok := ps.Entry("2026-02-21-ui.md")
+	require.True(t, ok)
+	assert.Equal(t, "2026-02-20-test-plan.md", entry.Parent)
+	assert.Equal(t, "plan/ui", entry.Branch)
+}
+
+func TestLoadPlanStateStrict_FailsWhenStoreDown(t *testing.T) {
+	srv := httptest.NewServer(nil)
+	url := srv.URL
+	srv.Close()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	configDir := filepath.Join(home, ".config", "kasmos")
+	require.NoError(t, os.MkdirAll(configDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, config.ConfigFileName), []byte("{}"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(configDir, config.TOMLConfigFileName),
+		[]byte("plan_store = \""+url+"\"\n"), 0o644))
+
+	// No docs/plans on disk: the store is still consulted, and its outage is
+	// reported instead of silently loading the empty local store.
+	_, err := LoadPlanStateStrict(filepath.Join(t.TempDir(), "docs", "plans"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "plan store unreachable")
+}
@@ -9,23 +9,27 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/config/planfsm"
 	"github.com/kastheco/kasmos/config/planstore"
 	"github.com/spf13/cobra"
 )
 
 // NewServeCmd returns the `kas serve` cobra command.
-// It starts an HTTP server backed by a SQLite plan store.
+// It starts an HTTP server backed by a SQLite plan store, which also collects
+// the audit events of every kasmos instance pointed at it.
 func NewServeCmd() *cobra.Command {
 	var (
-		port int
-		db   string
-		bind string
+		port           int
+		db             string
+		bind           string
+		auditMaxEvents int
 	)
 
 	cmd := &cobra.Command{
 		Use:   "serve",
 		Short: "start the plan store HTTP server",
-		Long:  "Start an HTTP server that exposes plan state over a REST API backed by SQLite.",
+		Long:  "Start an HTTP server that exposes plan state and a shared audit log over a REST API backed by SQLite, with Prometheus metrics at /metrics.",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			store, err := planstore.NewSQLiteStore(db)
 			if err != nil {
@@ -33,7 +37,22 @@ func NewServeCmd() *cobra.Command {
 			}
 			defer store.Close()
 
-			handler := planstore.NewHandler(store)
+			audit, err := auditlog.NewSQLiteLogger(db)
+			if err != nil {
+				return fmt.Errorf("open audit log: %w", err)
+			}
+			defer audit.Close()
+			if err := audit.SetMaxEvents(auditMaxEvents); err != nil {
+				return err
+			}
+
+			metrics := planstore.NewMetrics(store, func(from, to planstore.Status) string {
+				if event, ok := planfsm.EventFor(planfsm.Status(from), planfsm.Status(to)); ok {
+					return string(event)
+				}
+				return "manual"
+			})
+			handler := planstore.NewHandler(store, audit, planstore.WithMetrics(metrics))
 			addr := fmt.Sprintf("%s:%d", bind, port)
 
 			srv := &http.Server{
@@ -72,6 +91,8 @@ func NewServeCmd() *cobra.Command {
 	cmd.Flags().IntVar(&port, "port", 7433, "port to listen on")
 	cmd.Flags().StringVar(&db, "db", defaultDB, "path to the SQLite database file")
 	cmd.Flags().StringVar(&bind, "bind", "0.0.0.0", "address to bind to")
+	cmd.Flags().IntVar(&auditMaxEvents, "audit-max-events", auditlog.DefaultMaxEvents,
+		"oldest audit events are deleted beyond this many (0 keeps everything)")
 
 	return cmd
 }
@@ -1,6 +1,10 @@
 package auditlog
 
-import "time"
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
 
 // EventKind identifies the type of audit event.
 type EventKind string
@@ -17,6 +21,9 @@ const (
 	EventAgentKilled   EventKind = "agent_killed"
 	EventAgentPaused   EventKind = "agent_paused"
 	EventAgentResumed  EventKind = "agent_resumed"
+	// EventAgentReady fires when an agent goes from working to waiting for
+	// input. It drives notifications and is not written to the audit log.
+	EventAgentReady EventKind = "agent_ready"
 )
 
 // Plan events.
@@ -25,13 +32,31 @@ const (
 	EventPlanCreated    EventKind = "plan_created"
 	EventPlanMerged     EventKind = "plan_merged"
 	EventPlanCancelled  EventKind = "plan_cancelled"
+	EventPlanRebased    EventKind = "plan_rebased"
+	// EventPlanStatusChanged is the machine-readable record of a lifecycle
+	// transition, emitted for every status the plan FSM writes; Detail is a
+	// JSON StatusChange. It feeds delivery analytics and is not shown in the
+	// audit pane, which already carries a plan_transition line.
+	EventPlanStatusChanged EventKind = "plan_status_changed"
 )
 
+// StatusChange is the Detail of an EventPlanStatusChanged event.
+type StatusChange struct {
+	Event string `json:"event"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
 // Wave events.
 const (
 	EventWaveStarted   EventKind = "wave_started"
 	EventWaveCompleted EventKind = "wave_completed"
-	EventWaveFailed    EventKind = "wave_failed"
+	// EventWaveFailed records a wave whose tasks finished with failures;
+	// Detail lists the failed task numbers (see WithFailedTasks).
+	EventWaveFailed EventKind = "wave_failed"
+	// EventWaveRolledBack records a plan branch reset to the checkpoint taken
+	// before a wave; Detail holds the checkpoint commit.
+	EventWaveRolledBack EventKind = "wave_rolled_back"
 )
 
 // Operational events.
@@ -43,6 +68,18 @@ const (
 	EventPermissionAnswered EventKind = "permission_answered"
 	EventFSMError           EventKind = "fsm_error"
 	EventError              EventKind = "error"
+	// EventActivityArchived carries an instance's activity timeline (JSON in
+	// Detail) when the instance is removed, so plan history survives it.
+	EventActivityArchived EventKind = "activity_archived"
+	// EventResourceLimit records an instance crossing a soft or hard
+	// resource limit; Detail names the action taken.
+	EventResourceLimit EventKind = "resource_limit"
+	// EventMergeConflict records a plan merge aborted on conflicts; Detail
+	// lists the conflicting files.
+	EventMergeConflict EventKind = "merge_conflict"
+	// EventPRMerged records a plan's pull request merging on the forge;
+	// Detail holds the pull request URL.
+	EventPRMerged EventKind = "pr_merged"
 )
 
 // Session lifecycle events.
@@ -51,18 +88,44 @@ const (
 	EventSessionStopped EventKind = "session_stopped"
 )
 
-// Event is a single audit log entry.
+// Event is a single audit log entry. The JSON form is the wire format of the
+// plan store server's audit endpoints.
 type Event struct {
-	ID            int64
-	Kind          EventKind
-	Timestamp     time.Time
-	Project       string
-	PlanFile      string
-	InstanceTitle string
-	AgentType     string
-	WaveNumber    int
-	TaskNumber    int
-	Message       string
-	Detail        string // JSON-encoded extra data
-	Level         string // info, warn, error
+	ID            int64     `json:"id,omitempty"`
+	Kind          EventKind `json:"kind"`
+	Timestamp     time.Time `json:"timestamp"`
+	Project       string    `json:"project,omitempty"`
+	PlanFile      string    `json:"plan_file,omitempty"`
+	InstanceTitle string    `json:"instance_title,omitempty"`
+	AgentType     string    `json:"agent_type,omitempty"`
+	Program       string    `json:"program,omitempty"` // agent command line
+	WaveNumber    int       `json:"wave_number,omitempty"`
+	TaskNumber    int       `json:"task_number,omitempty"`
+	Message       string    `json:"message"`
+	Detail        string    `json:"detail,omitempty"` // JSON-encoded extra data
+	Level         string    `json:"level,omitempty"`  // info, warn, error
+	// Host and User identify the machine and account that emitted the event,
+	// so a shared audit log tells teammates apart. Filled in by Emit.
+	Host string `json:"host,omitempty"`
+	User string `json:"user,omitempty"`
+}
+
+// SplitProgram splits an agent command line into the program's base name and
+// the model passed with --model or -m, if any: "/usr/bin/claude --model opus"
+// gives "claude" and "opus". An empty command line gives "unknown".
+func SplitProgram(program string) (name, model string) {
+	fields := strings.Fields(program)
+	if len(fields) == 0 {
+		return "unknown", ""
+	}
+	name = filepath.Base(fields[0])
+	for i := 1; i < len(fields); i++ {
+		switch f := fields[i]; {
+		case (f == "--model" || f == "-m") && i+1 < len(fields):
+			return name, fields[i+1]
+		case strings.HasPrefix(f, "--model="):
+			return name, strings.TrimPrefix(f, "--model=")
+		}
+	}
+	return name, ""
 }
@@ -0,0 +1,410 @@
+package auditlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Values encodes the filter as query parameters for the plan store server's
+// GET /v1/audit endpoint. ParseQueryFilter is its inverse.
+func (f QueryFilter) Values() url.Values {
+	v := url.Values{}
+	if f.Project != "" {
+		v.Set("project", f.Project)
+	}
+	if f.PlanFile != "" {
+		v.Set("plan", f.PlanFile)
+	}
+	if f.InstanceTitle != "" {
+		v.Set("instance", f.InstanceTitle)
+	}
+	for _, k := range f.Kinds {
+		v.Add("kind", string(k))
+	}
+	for _, l := range f.Levels {
+		v.Add("level", l)
+	}
+	if f.Limit > 0 {
+		v.Set("limit", strconv.Itoa(f.Limit))
+	}
+	if !f.Before.IsZero() {
+		v.Set("before", auditFormatTime(f.Before))
+	}
+	if !f.After.IsZero() {
+		v.Set("after", auditFormatTime(f.After))
+	}
+	return v
+}
+
+// ParseQueryFilter decodes query parameters written by QueryFilter.Values.
+func ParseQueryFilter(v url.Values) (QueryFilter, error) {
+	f := QueryFilter{
+		Project:       v.Get("project"),
+		PlanFile:      v.Get("plan"),
+		InstanceTitle: v.Get("instance"),
+	}
+	for _, k := range v["kind"] {
+		f.Kinds = append(f.Kinds, EventKind(k))
+	}
+	f.Levels = append(f.Levels, v["level"]...)
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return QueryFilter{}, fmt.Errorf("invalid limit %q", s)
+		}
+		f.Limit = n
+	}
+	for _, p := range []struct {
+		key string
+		dst *time.Time
+	}{{"before", &f.Before}, {"after", &f.After}} {
+		s := v.Get(p.key)
+		if s == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return QueryFilter{}, fmt.Errorf("invalid %s %q", p.key, s)
+		}
+		*p.dst = t
+	}
+	return f, nil
+}
+
+const (
+	// httpFlushInterval is how often buffered events are sent to the server.
+	httpFlushInterval = time.Second
+	// httpMaxBackoff caps the retry delay while the server is unreachable.
+	httpMaxBackoff = time.Minute
+	// httpMaxBuffered bounds the events held while the server is unreachable;
+	// the oldest are dropped first.
+	httpMaxBuffered = 5000
+	// httpMaxBatch is the most events sent in one request.
+	httpMaxBatch = 200
+)
+
+// HTTPLogger is a Logger that ships events to a plan store server, so a team
+// sharing one `kas serve` sees each other's audit history. Emit only appends
+// to an in-memory buffer that a background goroutine flushes in batches,
+// so it never blocks on the network.
+//
+// Events are also written to local, which answers queries while the server
+// is unreachable.
+type HTTPLogger struct {
+	baseURL string
+	client  *http.Client
+	local   Logger
+
+	mu      sync.Mutex
+	pending []queuedEvent
+	nextSeq uint64
+	// healthy is false after a failed request until the next one succeeds;
+	// queries go straight to local meanwhile instead of waiting on a dead
+	// server.
+	healthy bool
+
+	wake     chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// queuedEvent is an event waiting to be sent; seq orders the queue so a
+// flush can drop exactly what it sent.
+type queuedEvent struct {
+	seq uint64
+	Event
+}
+
+// NewHTTPLogger returns a logger that sends events to the plan store server at
+// baseURL. local (which may be nil) keeps a copy of every event and serves
+// queries while the server is unreachable.
+func NewHTTPLogger(baseURL string, local Logger) *HTTPLogger {
+	if local == nil {
+		local = NopLogger()
+	}
+	l := &HTTPLogger{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 2 * time.Second},
+		local:   local,
+		healthy: true,
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go l.run()
+	return l
+}
+
+// Emit stamps the event and queues it for the server. It never blocks on I/O
+// beyond the local copy.
+func (l *HTTPLogger) Emit(e Event) {
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	stampOrigin(&e)
+	l.local.Emit(e)
+
+	l.mu.Lock()
+	l.nextSeq++
+	l.pending = append(l.pending, queuedEvent{seq: l.nextSeq, Event: e})
+	if over := len(l.pending) - httpMaxBuffered; over > 0 {
+		l.pending = append(l.pending[:0:0], l.pending[over:]...)
+	}
+	l.mu.Unlock()
+
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Query asks the server, adding events still waiting to be sent. While the
+// server is unreachable the local copy answers instead.
+func (l *HTTPLogger) Query(f QueryFilter) ([]Event, error) {
+	l.mu.Lock()
+	healthy := l.healthy
+	var queued []Event
+	for _, q := range l.pending {
+		if f.Matches(q.Event) {
+			queued = append(queued, q.Event)
+		}
+	}
+	l.mu.Unlock()
+
+	if !healthy {
+		return l.local.Query(f)
+	}
+	remote, err := l.queryRemote(f)
+	if err != nil {
+		l.setHealthy(false)
+		return l.local.Query(f)
+	}
+	return mergeEvents(remote, queued, f.Limit), nil
+}
+
+// Prune deletes events older than before on the server and in the local
+// copy. It returns how many the server removed.
+func (l *HTTPLogger) Prune(before time.Time) (int64, error) {
+	if p, ok := l.local.(Pruner); ok {
+		if _, err := p.Prune(before); err != nil {
+			return 0, err
+		}
+	}
+	req, err := http.NewRequest(http.MethodDelete,
+		l.baseURL+"/v1/audit?"+QueryFilter{Before: before}.Values().Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("audit server unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, responseError(resp)
+	}
+	var result struct {
+		Deleted int64 `json:"deleted"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("decode prune response: %w", err)
+	}
+	return result.Deleted, nil
+}
+
+// Close flushes what it can within a couple of seconds, then stops the
+// background sender and closes the local logger.
+func (l *HTTPLogger) Close() error {
+	l.stopOnce.Do(func() { close(l.stop) })
+	<-l.done
+	return l.local.Close()
+}
+
+// run flushes pending events every httpFlushInterval or when woken, backing
+// off while the server is unreachable.
+func (l *HTTPLogger) run() {
+	defer close(l.done)
+	backoff := httpFlushInterval
+	timer := time.NewTimer(httpFlushInterval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-l.stop:
+			deadline := time.Now().Add(2 * time.Second)
+			for time.Now().Before(deadline) {
+				if sent, err := l.flush(); err != nil || sent == 0 {
+					return
+				}
+			}
+			return
+		case <-l.wake:
+			if backoff > httpFlushInterval {
+				continue // wait out the backoff; the timer flushes
+			}
+		case <-timer.C:
+		}
+
+		if _, err := l.flush(); err != nil {
+			backoff = min(backoff*2, httpMaxBackoff)
+		} else {
+			backoff = httpFlushInterval
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(backoff)
+	}
+}
+
+// flush sends up to httpMaxBatch pending events and drops them once the
+// server has accepted them. It returns how many were sent.
+func (l *HTTPLogger) flush() (int, error) {
+	l.mu.Lock()
+	healthy := l.healthy
+	n := min(len(l.pending), httpMaxBatch)
+	batch := make([]Event, n)
+	var last uint64
+	for i, q := range l.pending[:n] {
+		batch[i] = q.Event
+		last = q.seq
+	}
+	l.mu.Unlock()
+	if n == 0 {
+		// Nothing to send, but a failed query may have marked the server
+		// down; find out whether it is back.
+		if !healthy {
+			if err := l.ping(); err != nil {
+				return 0, err
+			}
+			l.setHealthy(true)
+		}
+		return 0, nil
+	}
+
+	if err := l.send(batch); err != nil {
+		l.setHealthy(false)
+		return 0, err
+	}
+
+	l.mu.Lock()
+	// The buffer may have been trimmed meanwhile; drop whatever of the
+	// batch is still queued.
+	sent := 0
+	for sent < len(l.pending) && l.pending[sent].seq <= last {
+		sent++
+	}
+	l.pending = append(l.pending[:0:0], l.pending[sent:]...)
+	l.healthy = true
+	l.mu.Unlock()
+	return n, nil
+}
+
+func (l *HTTPLogger) setHealthy(ok bool) {
+	l.mu.Lock()
+	l.healthy = ok
+	l.mu.Unlock()
+}
+
+// ping checks that the server is reachable.
+func (l *HTTPLogger) ping() error {
+	resp, err := l.client.Get(l.baseURL + "/v1/ping")
+	if err != nil {
+		return fmt.Errorf("audit server unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// send POSTs a batch of events to the server.
+func (l *HTTPLogger) send(events []Event) error {
+	body, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("encode audit events: %w", err)
+	}
+	resp, err := l.client.Post(l.baseURL+"/v1/audit", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("audit server unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return responseError(resp)
+	}
+	return nil
+}
+
+// queryRemote runs the query against the server.
+func (l *HTTPLogger) queryRemote(f QueryFilter) ([]Event, error) {
+	resp, err := l.client.Get(l.baseURL + "/v1/audit?" + f.Values().Encode())
+	if err != nil {
+		return nil, fmt.Errorf("audit server unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, responseError(resp)
+	}
+	var events []Event
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		return nil, fmt.Errorf("decode audit events: %w", err)
+	}
+	return events, nil
+}
+
+// responseError turns a non-2xx response into an error, using the server's
+// {"error": ...} body when there is one.
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var errResp struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &errResp) == nil && errResp.Error != "" {
+		return fmt.Errorf("audit server: %s (status %d)", errResp.Error, resp.StatusCode)
+	}
+	return fmt.Errorf("audit server: unexpected status %d", resp.StatusCode)
+}
+
+// mergeEvents combines events from the server with queued ones not yet sent,
+// newest first, dropping duplicates of events sent while the query ran.
+func mergeEvents(remote, queued []Event, limit int) []Event {
+	if len(queued) == 0 {
+		return remote
+	}
+	type key struct {
+		ts         int64
+		kind, host string
+		msg        string
+	}
+	seen := make(map[key]bool, len(remote))
+	for _, e := range remote {
+		seen[key{e.Timestamp.UnixNano(), string(e.Kind), e.Host, e.Message}] = true
+	}
+	merged := append([]Event(nil), remote...)
+	for _, e := range queued {
+		if !seen[key{e.Timestamp.UnixNano(), string(e.Kind), e.Host, e.Message}] {
+			merged = append(merged, e)
+		}
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Timestamp.After(merged[j].Timestamp) })
+	if limit <= 0 || limit > maxQueryLimit {
+		limit = maxQueryLimit
+	}
+	if len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged
+}
@@ -0,0 +1,165 @@
+package auditlog_test
+
+import (
+	"database/sql"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/config/planstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAuditServer starts a plan store server sharing an in-memory audit log.
+func newAuditServer(t *testing.T) (*httptest.Server, *auditlog.SQLiteLogger) {
+	t.Helper()
+	audit, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { audit.Close() })
+	srv := httptest.NewServer(planstore.NewHandler(planstore.NewTestSQLiteStore(t), audit))
+	t.Cleanup(srv.Close)
+	return srv, audit
+}
+
+func TestHTTPLogger_ShipsEventsToServer(t *testing.T) {
+	srv, audit := newAuditServer(t)
+	local, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+
+	logger := auditlog.NewHTTPLogger(srv.URL, local)
+	logger.Emit(auditlog.Event{Kind: auditlog.EventAgentSpawned, Project: "kasmos", Message: "spawned coder"})
+	logger.Emit(auditlog.Event{Kind: auditlog.EventPlanMerged, Project: "other", Message: "merged"})
+
+	// Queued events show up in queries before they are flushed.
+	events, err := logger.Query(auditlog.QueryFilter{Project: "kasmos"})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "spawned coder", events[0].Message)
+
+	require.NoError(t, logger.Close(), "close flushes the buffer")
+
+	events, err = audit.Query(auditlog.QueryFilter{Project: "kasmos"})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	host, user := auditlog.Origin()
+	assert.Equal(t, auditlog.EventAgentSpawned, events[0].Kind)
+	assert.Equal(t, host, events[0].Host)
+	assert.Equal(t, user, events[0].User)
+
+	// Another machine sees the event through the server.
+	reader := auditlog.NewHTTPLogger(srv.URL, nil)
+	defer reader.Close()
+	events, err = reader.Query(auditlog.QueryFilter{Kinds: []auditlog.EventKind{auditlog.EventPlanMerged}})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "merged", events[0].Message)
+}
+
+func TestHTTPLogger_FallsBackToLocalWhenUnreachable(t *testing.T) {
+	srv := httptest.NewServer(nil)
+	url := srv.URL
+	srv.Close()
+
+	local, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+	logger := auditlog.NewHTTPLogger(url, local)
+	defer logger.Close()
+
+	done := make(chan struct{})
+	go func() {
+		logger.Emit(auditlog.Event{Kind: auditlog.EventAgentSpawned, Project: "kasmos", Message: "offline"})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Emit blocked on an unreachable server")
+	}
+
+	events, err := logger.Query(auditlog.QueryFilter{Project: "kasmos"})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "offline", events[0].Message)
+}
+
+func TestServer_AuditEndpointsDisabled(t *testing.T) {
+	srv := httptest.NewServer(planstore.NewHandler(planstore.NewTestSQLiteStore(t), nil))
+	defer srv.Close()
+
+	logger := auditlog.NewHTTPLogger(srv.URL, nil)
+	defer logger.Close()
+	events, err := logger.Query(auditlog.QueryFilter{})
+	require.NoError(t, err, "falls back to the local logger")
+	assert.Empty(t, events)
+}
+
+func TestQueryFilter_ValuesRoundTrip(t *testing.T) {
+	f := auditlog.QueryFilter{
+		Project:       "kasmos",
+		PlanFile:      "plan.md",
+		InstanceTitle: "plan-coder",
+		Kinds:         []auditlog.EventKind{auditlog.EventAgentSpawned, auditlog.EventPlanMerged},
+		Limit:         50,
+		After:         time.Date(2026, 3, 1, 12, 0, 0, 500, time.UTC),
+	}
+	got, err := auditlog.ParseQueryFilter(f.Values())
+	require.NoError(t, err)
+	assert.Equal(t, f, got)
+
+	_, err = auditlog.ParseQueryFilter(map[string][]string{"limit": {"lots"}})
+	assert.Error(t, err)
+	_, err = auditlog.ParseQueryFilter(map[string][]string{"before": {"yesterday"}})
+	assert.Error(t, err)
+}
+
+func TestSQLiteLogger_MigratesOriginColumns(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "audit.db")
+	db, err := sql.Open("sqlite", dbPath)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE audit_events (
+		id INTEGER PRIMARY KEY, kind TEXT NOT NULL, timestamp TEXT NOT NULL,
+		project TEXT NOT NULL DEFAULT '', plan_file TEXT NOT NULL DEFAULT '',
+		instance_title TEXT NOT NULL DEFAULT '', agent_type TEXT NOT NULL DEFAULT '',
+		wave_number INTEGER NOT NULL DEFAULT 0, task_number INTEGER NOT NULL DEFAULT 0,
+		message TEXT NOT NULL DEFAULT '', detail TEXT NOT NULL DEFAULT '',
+		level TEXT NOT NULL DEFAULT 'info')`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	logger, err := auditlog.NewSQLiteLogger(dbPath)
+	require.NoError(t, err)
+	defer logger.Close()
+	logger.Emit(auditlog.Event{Kind: auditlog.EventAgentSpawned, Host: "laptop", User: "kas"})
+
+	events, err := logger.Query(auditlog.QueryFilter{})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, "laptop", events[0].Host)
+	assert.Equal(t, "kas", events[0].User)
+}
+
+func TestHTTPLogger_PrunesServerAndLocal(t *testing.T) {
+	srv, audit := newAuditServer(t)
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, audit.Insert(auditlog.Event{Kind: auditlog.EventAgentSpawned, Timestamp: base}))
+	require.NoError(t, audit.Insert(auditlog.Event{Kind: auditlog.EventAgentSpawned, Timestamp: base.Add(time.Hour)}))
+	local, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+	require.NoError(t, local.Insert(auditlog.Event{Kind: auditlog.EventAgentSpawned, Timestamp: base}))
+
+	logger := auditlog.NewHTTPLogger(srv.URL, local)
+	defer logger.Close()
+	deleted, err := logger.Prune(base.Add(time.Minute))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, deleted)
+
+	events, err := audit.Query(auditlog.QueryFilter{})
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+	events, err = local.Query(auditlog.QueryFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, events)
+}
@@ -1,6 +1,14 @@
 package auditlog
 
-import "time"
+import (
+	"encoding/json"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
 
 // QueryFilter specifies criteria for querying audit events.
 type QueryFilter struct {
@@ -8,9 +16,60 @@ type QueryFilter struct {
 	PlanFile      string
 	InstanceTitle string
 	Kinds         []EventKind
-	Limit         int
-	Before        time.Time
-	After         time.Time
+	// Levels restricts results to events at these levels (info, warn, error).
+	Levels []string
+	Limit  int
+	Before time.Time
+	After  time.Time
+}
+
+// Matches reports whether e satisfies every criterion of the filter except
+// Limit.
+func (f QueryFilter) Matches(e Event) bool {
+	if f.Project != "" && e.Project != f.Project {
+		return false
+	}
+	if f.PlanFile != "" && e.PlanFile != f.PlanFile {
+		return false
+	}
+	if f.InstanceTitle != "" && e.InstanceTitle != f.InstanceTitle {
+		return false
+	}
+	if len(f.Kinds) > 0 {
+		found := false
+		for _, k := range f.Kinds {
+			if e.Kind == k {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(f.Levels) > 0 {
+		level := e.Level
+		if level == "" {
+			level = "info"
+		}
+		found := false
+		for _, l := range f.Levels {
+			if level == l {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !f.After.IsZero() && !e.Timestamp.After(f.After) {
+		return false
+	}
+	if !f.Before.IsZero() && !e.Timestamp.Before(f.Before) {
+		return false
+	}
+	return true
 }
 
 // Logger is the interface for emitting and querying audit events.
@@ -20,6 +79,12 @@ type Logger interface {
 	Close() error
 }
 
+// Pruner is implemented by loggers that can delete old events.
+type Pruner interface {
+	// Prune deletes events older than before and returns how many it removed.
+	Prune(before time.Time) (int64, error)
+}
+
 // EventOption is a functional option for configuring optional Event fields.
 type EventOption func(*Event)
 
@@ -38,6 +103,11 @@ func WithAgent(agentType string) EventOption {
 	return func(e *Event) { e.AgentType = agentType }
 }
 
+// WithProgram sets the Program field on the event (the agent's command line).
+func WithProgram(program string) EventOption {
+	return func(e *Event) { e.Program = program }
+}
+
 // WithWave sets the WaveNumber and TaskNumber fields on the event.
 func WithWave(wave, task int) EventOption {
 	return func(e *Event) {
@@ -51,6 +121,47 @@ func WithDetail(detail string) EventOption {
 	return func(e *Event) { e.Detail = detail }
 }
 
+// WithStatusChange sets the Detail field to the JSON form of sc.
+func WithStatusChange(sc StatusChange) EventOption {
+	return func(e *Event) {
+		b, _ := json.Marshal(sc)
+		e.Detail = string(b)
+	}
+}
+
+// ParseStatusChange decodes the Detail of an EventPlanStatusChanged event.
+func ParseStatusChange(detail string) (StatusChange, bool) {
+	var sc StatusChange
+	if err := json.Unmarshal([]byte(detail), &sc); err != nil || sc.To == "" {
+		return StatusChange{}, false
+	}
+	return sc, true
+}
+
+// WithFailedTasks sets the Detail field to the comma-separated task numbers
+// of a failed wave.
+func WithFailedTasks(tasks []int) EventOption {
+	return func(e *Event) {
+		parts := make([]string, len(tasks))
+		for i, t := range tasks {
+			parts[i] = strconv.Itoa(t)
+		}
+		e.Detail = strings.Join(parts, ",")
+	}
+}
+
+// ParseFailedTasks decodes the Detail of an EventWaveFailed event. Numbers
+// that do not parse are skipped.
+func ParseFailedTasks(detail string) []int {
+	var tasks []int
+	for _, part := range strings.Split(detail, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(part)); err == nil {
+			tasks = append(tasks, n)
+		}
+	}
+	return tasks
+}
+
 // WithLevel sets the Level field on the event (info, warn, error).
 func WithLevel(level string) EventOption {
 	return func(e *Event) { e.Level = level }
@@ -73,3 +184,32 @@ func (n *nopLogger) Query(_ QueryFilter) ([]Event, error) {
 func (n *nopLogger) Close() error {
 	return nil
 }
+
+var (
+	originOnce sync.Once
+	originHost string
+	originUser string
+)
+
+// Origin returns the host name and user name stamped on events emitted by
+// this process.
+func Origin() (host, userName string) {
+	originOnce.Do(func() {
+		originHost, _ = os.Hostname()
+		if u, err := user.Current(); err == nil {
+			originUser = u.Username
+		} else {
+			originUser = os.Getenv("USER")
+		}
+	})
+	return originHost, originUser
+}
+
+// stampOrigin fills in the event's Host and User from Origin unless the event
+// already carries them (e.g. events ingested from another machine).
+func stampOrigin(e *Event) {
+	if e.Host != "" || e.User != "" {
+		return
+	}
+	e.Host, e.User = Origin()
+}
@@ -18,3 +18,24 @@ func TestNopLogger_DoesNotPanic(t *testing.T) {
 		l.Emit(auditlog.Event{Kind: auditlog.EventAgentSpawned})
 	})
 }
+
+func TestStatusChange_RoundTrip(t *testing.T) {
+	var e auditlog.Event
+	sc := auditlog.StatusChange{Event: "plan_start", From: "ready", To: "planning"}
+	auditlog.WithStatusChange(sc)(&e)
+
+	got, ok := auditlog.ParseStatusChange(e.Detail)
+	assert.True(t, ok)
+	assert.Equal(t, sc, got)
+
+	_, ok = auditlog.ParseStatusChange("ready → planning")
+	assert.False(t, ok)
+}
+
+func TestFailedTasks_RoundTrip(t *testing.T) {
+	var e auditlog.Event
+	auditlog.WithFailedTasks([]int{2, 5})(&e)
+	assert.Equal(t, "2,5", e.Detail)
+	assert.Equal(t, []int{2, 5}, auditlog.ParseFailedTasks(e.Detail))
+	assert.Empty(t, auditlog.ParseFailedTasks(""))
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	_ "modernc.org/sqlite" // register sqlite driver
@@ -18,22 +19,41 @@ CREATE TABLE IF NOT EXISTS audit_events (
 	plan_file      TEXT    NOT NULL DEFAULT '',
 	instance_title TEXT    NOT NULL DEFAULT '',
 	agent_type     TEXT    NOT NULL DEFAULT '',
+	program        TEXT    NOT NULL DEFAULT '',
 	wave_number    INTEGER NOT NULL DEFAULT 0,
 	task_number    INTEGER NOT NULL DEFAULT 0,
 	message        TEXT    NOT NULL DEFAULT '',
 	detail         TEXT    NOT NULL DEFAULT '',
-	level          TEXT    NOT NULL DEFAULT 'info'
+	level          TEXT    NOT NULL DEFAULT 'info',
+	host           TEXT    NOT NULL DEFAULT '',
+	user_name      TEXT    NOT NULL DEFAULT ''
 );
 
 CREATE INDEX IF NOT EXISTS idx_audit_project_ts ON audit_events(project, timestamp DESC);
 CREATE INDEX IF NOT EXISTS idx_audit_plan ON audit_events(plan_file, timestamp DESC);
 `
 
+// auditColumnMigrations add columns to existing databases that predate them.
+var auditColumnMigrations = []struct{ column, ddl string }{
+	{"host", `ALTER TABLE audit_events ADD COLUMN host TEXT NOT NULL DEFAULT ''`},
+	{"user_name", `ALTER TABLE audit_events ADD COLUMN user_name TEXT NOT NULL DEFAULT ''`},
+	{"program", `ALTER TABLE audit_events ADD COLUMN program TEXT NOT NULL DEFAULT ''`},
+}
+
 const maxQueryLimit = 500
 
+// DefaultMaxEvents is the size cap applied to the audit log unless
+// SetMaxEvents overrides it: beyond it the oldest events are deleted.
+const DefaultMaxEvents = 100_000
+
+// capCheckInterval is how many inserts pass between size cap checks.
+const capCheckInterval = 500
+
 // SQLiteLogger is a Logger backed by a SQLite database.
 type SQLiteLogger struct {
-	db *sql.DB
+	db        *sql.DB
+	maxEvents atomic.Int64
+	inserts   atomic.Int64
 }
 
 // NewSQLiteLogger opens (or creates) a SQLite database at dbPath, runs the
@@ -49,42 +69,128 @@ func NewSQLiteLogger(dbPath string) (*SQLiteLogger, error) {
 		db.Close()
 		return nil, fmt.Errorf("run audit log schema: %w", err)
 	}
+	if err := migrateAuditColumns(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	l := &SQLiteLogger{db: db}
+	l.maxEvents.Store(DefaultMaxEvents)
+	return l, nil
+}
 
-	return &SQLiteLogger{db: db}, nil
+// SetMaxEvents changes the size cap (0 disables it) and applies it at once.
+func (l *SQLiteLogger) SetMaxEvents(n int) error {
+	l.maxEvents.Store(int64(n))
+	return l.enforceCap()
+}
+
+// migrateAuditColumns adds the columns in auditColumnMigrations to the
+// audit_events table if they don't already exist.
+func migrateAuditColumns(db *sql.DB) error {
+	rows, err := db.Query("SELECT name FROM pragma_table_info('audit_events')")
+	if err != nil {
+		return fmt.Errorf("query audit table info: %w", err)
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan audit table info: %w", err)
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate audit table info: %w", err)
+	}
+	for _, m := range auditColumnMigrations {
+		if existing[m.column] {
+			continue
+		}
+		if _, err := db.Exec(m.ddl); err != nil {
+			return fmt.Errorf("add audit column %s: %w", m.column, err)
+		}
+	}
+	return nil
 }
 
 // Emit inserts an audit event into the database. If the event's Timestamp is
-// zero, it is set to time.Now(). Emit is synchronous and safe to call from the
-// bubbletea Update goroutine.
+// zero, it is set to time.Now(); Host and User default to this machine's.
+// Emit is synchronous and safe to call from the bubbletea Update goroutine.
 func (l *SQLiteLogger) Emit(e Event) {
+	_ = l.Insert(e)
+}
+
+// Insert is Emit with error reporting, for callers that ingest events from
+// elsewhere.
+func (l *SQLiteLogger) Insert(e Event) error {
 	if e.Timestamp.IsZero() {
 		e.Timestamp = time.Now()
 	}
+	stampOrigin(&e)
 
 	const q = `
 		INSERT INTO audit_events
 			(kind, timestamp, project, plan_file, instance_title, agent_type,
-			 wave_number, task_number, message, detail, level)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			 program, wave_number, task_number, message, detail, level, host,
+			 user_name)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	level := e.Level
 	if level == "" {
 		level = "info"
 	}
 
-	_, _ = l.db.Exec(q,
+	_, err := l.db.Exec(q,
 		string(e.Kind),
 		auditFormatTime(e.Timestamp),
 		e.Project,
 		e.PlanFile,
 		e.InstanceTitle,
 		e.AgentType,
+		e.Program,
 		e.WaveNumber,
 		e.TaskNumber,
 		e.Message,
 		e.Detail,
 		level,
+		e.Host,
+		e.User,
 	)
+	if err != nil {
+		return fmt.Errorf("insert audit event: %w", err)
+	}
+	if l.inserts.Add(1)%capCheckInterval == 0 {
+		return l.enforceCap()
+	}
+	return nil
+}
+
+// enforceCap deletes the oldest events beyond the size cap.
+func (l *SQLiteLogger) enforceCap() error {
+	limit := l.maxEvents.Load()
+	if limit <= 0 {
+		return nil
+	}
+	_, err := l.db.Exec(`
+		DELETE FROM audit_events WHERE id <= (
+			SELECT id FROM audit_events ORDER BY id DESC LIMIT 1 OFFSET ?
+		)`, limit)
+	if err != nil {
+		return fmt.Errorf("trim audit log: %w", err)
+	}
+	return nil
+}
+
+// Prune deletes events older than before and returns how many it removed.
+func (l *SQLiteLogger) Prune(before time.Time) (int64, error) {
+	res, err := l.db.Exec("DELETE FROM audit_events WHERE timestamp < ?", auditFormatTime(before))
+	if err != nil {
+		return 0, fmt.Errorf("prune audit log: %w", err)
+	}
+	return res.RowsAffected()
 }
 
 // Query returns events matching the filter, ordered newest-first.
@@ -118,6 +224,14 @@ func (l *SQLiteLogger) Query(f QueryFilter) ([]Event, error) {
 		}
 		conditions = append(conditions, "kind IN ("+strings.Join(placeholders, ", ")+")")
 	}
+	if len(f.Levels) > 0 {
+		placeholders := make([]string, len(f.Levels))
+		for i, lv := range f.Levels {
+			placeholders[i] = "?"
+			args = append(args, lv)
+		}
+		conditions = append(conditions, "level IN ("+strings.Join(placeholders, ", ")+")")
+	}
 	if !f.After.IsZero() {
 		conditions = append(conditions, "timestamp > ?")
 		args = append(args, auditFormatTime(f.After))
@@ -129,7 +243,8 @@ func (l *SQLiteLogger) Query(f QueryFilter) ([]Event, error) {
 
 	q := `
 		SELECT id, kind, timestamp, project, plan_file, instance_title,
-		       agent_type, wave_number, task_number, message, detail, level
+		       agent_type, program, wave_number, task_number, message, detail,
+		       level, host, user_name
 		FROM audit_events
 	`
 	if len(conditions) > 0 {
@@ -155,11 +270,14 @@ func (l *SQLiteLogger) Query(f QueryFilter) ([]Event, error) {
 			&e.PlanFile,
 			&e.InstanceTitle,
 			&e.AgentType,
+			&e.Program,
 			&e.WaveNumber,
 			&e.TaskNumber,
 			&e.Message,
 			&e.Detail,
 			&e.Level,
+			&e.Host,
+			&e.User,
 		); err != nil {
 			return nil, fmt.Errorf("scan audit event: %w", err)
 		}
@@ -98,3 +98,42 @@ func TestSQLiteLogger_SharedDB(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, events, 1)
 }
+
+func TestSQLiteLogger_LevelFilterAndPrune(t *testing.T) {
+	logger, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+	defer logger.Close()
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	logger.Emit(auditlog.Event{Kind: auditlog.EventAgentSpawned, Timestamp: base})
+	logger.Emit(auditlog.Event{Kind: auditlog.EventAgentKilled, Level: "error", Timestamp: base.Add(time.Hour)})
+
+	events, err := logger.Query(auditlog.QueryFilter{Levels: []string{"error"}})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, auditlog.EventAgentKilled, events[0].Kind)
+
+	deleted, err := logger.Prune(base.Add(time.Minute))
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, deleted)
+	events, err = logger.Query(auditlog.QueryFilter{})
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func TestSQLiteLogger_SizeCap(t *testing.T) {
+	logger, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+	defer logger.Close()
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		logger.Emit(auditlog.Event{Kind: auditlog.EventAgentSpawned, Timestamp: base.Add(time.Duration(i) * time.Second)})
+	}
+	require.NoError(t, logger.SetMaxEvents(3))
+
+	events, err := logger.Query(auditlog.QueryFilter{})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, base.Add(7*time.Second), events[2].Timestamp.UTC(), "the oldest events go first")
+}
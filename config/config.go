@@ -64,8 +64,11 @@ type Config struct {
 	DefaultProgram string `json:"default_program"`
 	// AutoYes is a flag to automatically accept all prompts.
 	AutoYes bool `json:"auto_yes"`
-	// DaemonPollInterval is the interval (ms) at which the daemon polls sessions for autoyes mode.
+	// DaemonPollInterval is the interval (ms) at which the daemon polls sessions and plan signals.
 	DaemonPollInterval int `json:"daemon_poll_interval"`
+	// Supervise launches the headless supervisor daemon when the TUI exits, even
+	// without auto-yes, so plans keep progressing while the TUI is closed.
+	Supervise bool `json:"supervise,omitempty"`
 	// BranchPrefix is the prefix used for git branches created by the application.
 	BranchPrefix string `json:"branch_prefix"`
 	// NotificationsEnabled controls whether macOS/Linux desktop notifications
@@ -86,6 +89,20 @@ type Config struct {
 	// PlanStore is the URL of the remote plan store server (e.g. "http://athena:7433").
 	// When empty, the legacy plan-state.json file is used.
 	PlanStore string `json:"plan_store,omitempty"`
+	// Notifications routes audit events to notification channels (desktop,
+	// webhook, ntfy, email, shell command).
+	Notifications NotificationsConfig `json:"notifications,omitempty"`
+	// Limits maps agent roles (plus "default") to process-tree resource limits.
+	Limits map[string]ResourceLimits `json:"limits,omitempty"`
+	// MergeStrategy is the default way plan branches land on main:
+	// "merge" (--no-ff, default), "squash" or "rebase".
+	MergeStrategy string `json:"merge_strategy,omitempty"`
+	// Forge overrides the pull request host detected from the origin remote.
+	Forge ForgeConfig `json:"forge,omitempty"`
+	// Tracing exports plan lifecycles as OpenTelemetry traces.
+	Tracing TracingConfig `json:"tracing,omitempty"`
+	// Issues configures the trackers plans can be imported from.
+	Issues IssuesConfig `json:"issues,omitempty"`
 }
 
 // DefaultConfig returns the default configuration
@@ -247,9 +264,30 @@ func LoadConfig() *Config {
 		if tomlResult.TelemetryEnabled != nil {
 			config.TelemetryEnabled = tomlResult.TelemetryEnabled
 		}
+		if tomlResult.Supervise {
+			config.Supervise = true
+		}
 		if tomlResult.PlanStore != "" {
 			config.PlanStore = tomlResult.PlanStore
 		}
+		if !tomlResult.Notifications.IsZero() {
+			config.Notifications = tomlResult.Notifications
+		}
+		if len(tomlResult.Limits) > 0 {
+			config.Limits = tomlResult.Limits
+		}
+		if tomlResult.MergeStrategy != "" {
+			config.MergeStrategy = tomlResult.MergeStrategy
+		}
+		if !tomlResult.Forge.IsZero() {
+			config.Forge = tomlResult.Forge
+		}
+		if !tomlResult.Tracing.IsZero() {
+			config.Tracing = tomlResult.Tracing
+		}
+		if !tomlResult.Issues.IsZero() {
+			config.Issues = tomlResult.Issues
+		}
 	}
 
 	return &config
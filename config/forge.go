@@ -0,0 +1,28 @@
+package config
+
+// Forge types accepted by [forge] type.
+const (
+	ForgeGitHub = "github"
+	ForgeGitLab = "gitlab"
+	ForgeGitea  = "gitea"
+	ForgePush   = "push"
+)
+
+// ForgeConfig selects the code-hosting service pull requests are opened on.
+// An empty Type auto-detects it from the origin remote URL.
+type ForgeConfig struct {
+	// Type is "github" (gh), "gitlab" (glab), "gitea" (REST API) or "push"
+	// (push branches only, no pull requests).
+	Type string `json:"type,omitempty" toml:"type,omitempty"`
+	// URL is the forge's web root, e.g. "https://git.example.com". Only Gitea
+	// needs it, and only when it differs from the remote's host.
+	URL string `json:"url,omitempty" toml:"url,omitempty"`
+	// TokenEnv names the environment variable holding the Gitea API token
+	// (default GITEA_TOKEN).
+	TokenEnv string `json:"token_env,omitempty" toml:"token_env,omitempty"`
+}
+
+// IsZero reports whether nothing is configured.
+func (f ForgeConfig) IsZero() bool {
+	return f == ForgeConfig{}
+}
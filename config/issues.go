@@ -0,0 +1,136 @@
+package config
+
+// IssuesConfig holds the [issues] table: the trackers plans can be imported
+// from. GitHub and GitLab issues of the origin repository are offered without
+// configuration when their CLI or token is available, and a ClickUp MCP server
+// is detected from .mcp.json or the Claude settings.
+type IssuesConfig struct {
+	GitHub GitHubIssuesConfig `json:"github,omitempty" toml:"github,omitempty"`
+	GitLab GitLabIssuesConfig `json:"gitlab,omitempty" toml:"gitlab,omitempty"`
+	Linear LinearIssuesConfig `json:"linear,omitempty" toml:"linear,omitempty"`
+	Jira   JiraIssuesConfig   `json:"jira,omitempty" toml:"jira,omitempty"`
+	// MCP lists MCP servers exposing issue search and fetch tools ([[issues.mcp]]).
+	MCP []MCPIssuesConfig `json:"mcp,omitempty" toml:"mcp,omitempty"`
+	// Sync controls how plan status changes are written back to the issues
+	// plans were imported from ([issues.sync]).
+	Sync IssueSyncConfig `json:"sync,omitempty" toml:"sync,omitempty"`
+}
+
+// GitHubIssuesConfig imports GitHub issues through the gh CLI.
+type GitHubIssuesConfig struct {
+	// Repo is "owner/name". Empty uses the origin remote when it is on GitHub.
+	Repo string `json:"repo,omitempty" toml:"repo,omitempty"`
+	// Disabled hides GitHub issues from the import overlay.
+	Disabled bool `json:"disabled,omitempty" toml:"disabled,omitempty"`
+}
+
+// GitLabIssuesConfig imports GitLab issues through the REST API.
+type GitLabIssuesConfig struct {
+	// URL is the instance's web root (default: the origin remote's host, or
+	// https://gitlab.com).
+	URL string `json:"url,omitempty" toml:"url,omitempty"`
+	// Project is the "group/name" path. Empty uses the origin remote when it
+	// is on GitLab.
+	Project string `json:"project,omitempty" toml:"project,omitempty"`
+	// TokenEnv names the environment variable holding a personal access
+	// token with read_api scope, or api scope to sync plan status back
+	// (default GITLAB_TOKEN).
+	TokenEnv string `json:"token_env,omitempty" toml:"token_env,omitempty"`
+}
+
+// LinearIssuesConfig imports Linear issues through the GraphQL API. It is
+// enabled whenever the API key variable is set.
+type LinearIssuesConfig struct {
+	// Team restricts search to one team key, e.g. "ENG".
+	Team string `json:"team,omitempty" toml:"team,omitempty"`
+	// TokenEnv names the environment variable holding a personal API key
+	// (default LINEAR_API_KEY).
+	TokenEnv string `json:"token_env,omitempty" toml:"token_env,omitempty"`
+}
+
+// JiraIssuesConfig imports Jira issues through the REST API. It is enabled
+// when URL is set.
+type JiraIssuesConfig struct {
+	// URL is the site root, e.g. "https://acme.atlassian.net".
+	URL string `json:"url,omitempty" toml:"url,omitempty"`
+	// Email authenticates Jira Cloud API tokens. Leave it empty to send the
+	// token as a bearer personal access token (Jira Server/Data Center).
+	Email string `json:"email,omitempty" toml:"email,omitempty"`
+	// Project restricts search to one project key, e.g. "PROJ".
+	Project string `json:"project,omitempty" toml:"project,omitempty"`
+	// TokenEnv names the environment variable holding the API token
+	// (default JIRA_API_TOKEN).
+	TokenEnv string `json:"token_env,omitempty" toml:"token_env,omitempty"`
+}
+
+// MCPIssuesConfig names an MCP server from .mcp.json or the Claude settings
+// whose tools search and fetch issues.
+type MCPIssuesConfig struct {
+	// Server is the key under mcpServers.
+	Server string `json:"server" toml:"server"`
+	// SearchTool and FetchTool pick the tools to call. Empty picks the first
+	// tool whose name contains "search", and "fetch" or "get".
+	SearchTool string `json:"search_tool,omitempty" toml:"search_tool,omitempty"`
+	FetchTool  string `json:"fetch_tool,omitempty" toml:"fetch_tool,omitempty"`
+	// UpdateTool and CommentTool pick the tools status sync calls. Empty
+	// picks the first tool whose name contains "update", and "comment".
+	UpdateTool  string `json:"update_tool,omitempty" toml:"update_tool,omitempty"`
+	CommentTool string `json:"comment_tool,omitempty" toml:"comment_tool,omitempty"`
+}
+
+// IssueSyncConfig maps plan statuses to tracker statuses for imported plans.
+// When a plan changes status, its issue is moved to the mapped status and,
+// on review and completion, gets a comment with the pull request.
+type IssueSyncConfig struct {
+	// Disabled stops kasmos from writing to trackers.
+	Disabled bool `json:"disabled,omitempty" toml:"disabled,omitempty"`
+	// NoComments keeps status sync but skips the comments.
+	NoComments bool `json:"no_comments,omitempty" toml:"no_comments,omitempty"`
+	// Statuses maps a plan status ("implementing", "reviewing", "done", ...)
+	// to the tracker status every tracker uses. An empty value leaves the
+	// issue's status alone.
+	Statuses map[string]string `json:"statuses,omitempty" toml:"statuses,omitempty"`
+	// Trackers overrides Statuses per tracker, keyed by tracker name
+	// ("github", "jira", "clickup" or an [[issues.mcp]] server).
+	Trackers map[string]map[string]string `json:"trackers,omitempty" toml:"trackers,omitempty"`
+}
+
+// defaultIssueStatuses are the tracker statuses used when nothing is
+// configured. GitHub and GitLab issues are only ever open or closed.
+var defaultIssueStatuses = map[string]string{
+	"implementing": "in progress",
+	"reviewing":    "in review",
+	"done":         "done",
+	"cancelled":    "cancelled",
+}
+
+var defaultOpenClosedStatuses = map[string]string{
+	"done":      "closed",
+	"cancelled": "closed",
+}
+
+// TrackerStatus returns the status an issue in tracker should move to when
+// its plan enters status, or "" to leave the issue alone.
+func (c IssueSyncConfig) TrackerStatus(tracker, status string) string {
+	if s, ok := c.Trackers[tracker][status]; ok {
+		return s
+	}
+	if s, ok := c.Statuses[status]; ok {
+		return s
+	}
+	if tracker == "github" || tracker == "gitlab" {
+		return defaultOpenClosedStatuses[status]
+	}
+	return defaultIssueStatuses[status]
+}
+
+// IsZero reports whether nothing is configured.
+func (c IssueSyncConfig) IsZero() bool {
+	return !c.Disabled && !c.NoComments && len(c.Statuses) == 0 && len(c.Trackers) == 0
+}
+
+// IsZero reports whether nothing is configured.
+func (c IssuesConfig) IsZero() bool {
+	return c.GitHub == GitHubIssuesConfig{} && c.GitLab == GitLabIssuesConfig{} &&
+		c.Linear == LinearIssuesConfig{} && c.Jira == JiraIssuesConfig{} && len(c.MCP) == 0 && c.Sync.IsZero()
+}
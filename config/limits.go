@@ -0,0 +1,77 @@
+package config
+
+import "fmt"
+
+// Hard limit actions.
+const (
+	LimitActionPause = "pause"
+	LimitActionKill  = "kill"
+)
+
+// DefaultLimitsRole is the [limits.*] table that applies to every agent role
+// unless the role's own table overrides a field.
+const DefaultLimitsRole = "default"
+
+// ResourceLimits caps the resources an agent's process tree may use. Zero
+// disables a threshold. Crossing a soft limit warns; crossing a hard limit
+// pauses (or kills) the instance.
+type ResourceLimits struct {
+	SoftMemoryMB   float64 `json:"soft_memory_mb,omitempty" toml:"soft_memory_mb,omitempty"`
+	HardMemoryMB   float64 `json:"hard_memory_mb,omitempty" toml:"hard_memory_mb,omitempty"`
+	SoftCPUSeconds float64 `json:"soft_cpu_seconds,omitempty" toml:"soft_cpu_seconds,omitempty"`
+	HardCPUSeconds float64 `json:"hard_cpu_seconds,omitempty" toml:"hard_cpu_seconds,omitempty"`
+	// HardAction is "pause" (default, keeps the branch) or "kill".
+	HardAction string `json:"hard_action,omitempty" toml:"hard_action,omitempty"`
+}
+
+// IsZero reports whether no threshold is set.
+func (l ResourceLimits) IsZero() bool {
+	return l.SoftMemoryMB == 0 && l.HardMemoryMB == 0 && l.SoftCPUSeconds == 0 && l.HardCPUSeconds == 0
+}
+
+// validateLimits rejects limit tables with an unknown hard_action.
+func validateLimits(limits map[string]ResourceLimits) error {
+	for role, l := range limits {
+		switch l.HardAction {
+		case "", LimitActionPause, LimitActionKill:
+		default:
+			return fmt.Errorf("limits.%s: unknown hard_action %q (want %q or %q)",
+				role, l.HardAction, LimitActionPause, LimitActionKill)
+		}
+	}
+	return nil
+}
+
+// merge returns l with every unset field taken from base.
+func (l ResourceLimits) merge(base ResourceLimits) ResourceLimits {
+	if l.SoftMemoryMB == 0 {
+		l.SoftMemoryMB = base.SoftMemoryMB
+	}
+	if l.HardMemoryMB == 0 {
+		l.HardMemoryMB = base.HardMemoryMB
+	}
+	if l.SoftCPUSeconds == 0 {
+		l.SoftCPUSeconds = base.SoftCPUSeconds
+	}
+	if l.HardCPUSeconds == 0 {
+		l.HardCPUSeconds = base.HardCPUSeconds
+	}
+	if l.HardAction == "" {
+		l.HardAction = base.HardAction
+	}
+	return l
+}
+
+// LimitsFor returns the limits for an agent role (session.AgentType*, empty
+// for ad-hoc sessions): the role's table layered over [limits.default].
+func (c *Config) LimitsFor(role string) ResourceLimits {
+	base := c.Limits[DefaultLimitsRole]
+	limits := base
+	if role != "" && role != DefaultLimitsRole {
+		limits = c.Limits[role].merge(base)
+	}
+	if limits.HardAction == "" {
+		limits.HardAction = LimitActionPause
+	}
+	return limits
+}
@@ -0,0 +1,68 @@
+package config
+
+// Notification channel types understood by the notify package.
+const (
+	NotifyDesktop = "desktop"
+	NotifyWebhook = "webhook"
+	NotifyNtfy    = "ntfy"
+	NotifyEmail   = "email"
+	NotifyCommand = "command"
+)
+
+// NotificationChannel configures one named notification destination. Only the
+// fields relevant to Type are read.
+type NotificationChannel struct {
+	// Type is one of desktop, webhook, ntfy, email or command.
+	Type string `json:"type" toml:"type"`
+
+	// URL is the webhook endpoint or the ntfy topic URL (e.g. https://ntfy.sh/my-topic).
+	URL string `json:"url,omitempty" toml:"url,omitempty"`
+	// Method overrides the webhook HTTP method (default POST).
+	Method string `json:"method,omitempty" toml:"method,omitempty"`
+	// Headers are extra HTTP headers sent with webhook and ntfy requests.
+	Headers map[string]string `json:"headers,omitempty" toml:"headers,omitempty"`
+	// Body is a Go text/template rendering the webhook request body. The
+	// template sees .Title, .Body and .Event; the json function quotes a value.
+	// Defaults to a JSON object describing the event.
+	Body string `json:"body,omitempty" toml:"body,omitempty"`
+
+	// Token is sent as a bearer token to ntfy.
+	Token string `json:"token,omitempty" toml:"token,omitempty"`
+	// Priority is the ntfy message priority (min, low, default, high, urgent).
+	Priority string `json:"priority,omitempty" toml:"priority,omitempty"`
+	// Tags are ntfy tags (emoji shortcodes or labels).
+	Tags []string `json:"tags,omitempty" toml:"tags,omitempty"`
+
+	// SMTPHost and SMTPPort address the mail server (port defaults to 587).
+	SMTPHost string `json:"smtp_host,omitempty" toml:"smtp_host,omitempty"`
+	SMTPPort int    `json:"smtp_port,omitempty" toml:"smtp_port,omitempty"`
+	// Username and Password enable PLAIN auth when set.
+	Username string   `json:"username,omitempty" toml:"username,omitempty"`
+	Password string   `json:"password,omitempty" toml:"password,omitempty"`
+	From     string   `json:"from,omitempty" toml:"from,omitempty"`
+	To       []string `json:"to,omitempty" toml:"to,omitempty"`
+
+	// Command is run with `sh -c`; the event is passed as JSON on stdin and
+	// as KASMOS_* environment variables.
+	Command string `json:"command,omitempty" toml:"command,omitempty"`
+}
+
+// NotificationRoute sends every event whose kind is listed in Events to each
+// channel in Channels. The event name "*" matches every kind.
+type NotificationRoute struct {
+	Events   []string `json:"events" toml:"events"`
+	Channels []string `json:"channels" toml:"channels"`
+}
+
+// NotificationsConfig holds the [notifications] table: named channels plus the
+// routes that fan audit events out to them. When no routes are configured,
+// agent_ready goes to the built-in desktop channel.
+type NotificationsConfig struct {
+	Channels map[string]NotificationChannel `json:"channels,omitempty" toml:"channels,omitempty"`
+	Routes   []NotificationRoute            `json:"routes,omitempty" toml:"routes,omitempty"`
+}
+
+// IsZero reports whether no channels or routes are configured.
+func (n NotificationsConfig) IsZero() bool {
+	return len(n.Channels) == 0 && len(n.Routes) == 0
+}
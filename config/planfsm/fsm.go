@@ -93,12 +93,36 @@ func ApplyTransition(current Status, event Event) (Status, error) {
 	return next, nil
 }
 
+// EventFor returns the event that moves a plan from one status to the other.
+// Every pair of statuses is joined by at most one event; ok is false when no
+// event joins them (e.g. a manual status override).
+func EventFor(from, to Status) (event Event, ok bool) {
+	for e, next := range transitionTable[from] {
+		if next == to {
+			return e, true
+		}
+	}
+	return "", false
+}
+
 // PlanStateMachine is the sole writer of plan state. All plan status mutations
 // must flow through Transition(). The store handles concurrency via SQLite.
 type PlanStateMachine struct {
 	dir     string          // docs/plans/ directory (for file operations)
 	store   planstore.Store // always non-nil
 	project string          // project name used with the store
+
+	observers []TransitionObserver
+}
+
+// TransitionObserver is told about every transition Transition applies.
+type TransitionObserver func(planFile string, from, to Status, event Event)
+
+// OnTransition registers fn to run after each successful transition, after
+// any observers registered before it. Observers run on the caller's
+// goroutine, so they must not block.
+func (m *PlanStateMachine) OnTransition(fn TransitionObserver) {
+	m.observers = append(m.observers, fn)
 }
 
 // New creates a PlanStateMachine backed by the given store.
@@ -124,7 +148,13 @@ func (m *PlanStateMachine) Transition(planFile string, event Event) error {
 		return err
 	}
 	// ForceSetStatus writes through to the store.
-	return ps.ForceSetStatus(planFile, planstate.Status(newStatus))
+	if err := ps.ForceSetStatus(planFile, planstate.Status(newStatus)); err != nil {
+		return err
+	}
+	for _, fn := range m.observers {
+		fn(planFile, currentStatus, newStatus, event)
+	}
+	return nil
 }
 
 // mapLegacyStatus converts old planstate statuses to FSM statuses.
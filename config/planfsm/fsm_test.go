@@ -140,3 +140,44 @@ func TestFSM_TransitionWithStore(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "planning", string(entry.Status))
 }
+
+func TestEventFor(t *testing.T) {
+	// EventFor relies on no two events joining the same pair of statuses.
+	for from, events := range transitionTable {
+		seen := make(map[Status]Event)
+		for event, to := range events {
+			prev, dup := seen[to]
+			assert.False(t, dup, "%s → %s via both %s and %s", from, to, prev, event)
+			seen[to] = event
+
+			got, ok := EventFor(from, to)
+			assert.True(t, ok)
+			assert.Equal(t, event, got)
+		}
+	}
+
+	_, ok := EventFor(StatusImplementing, StatusDone)
+	assert.False(t, ok, "a manual override has no event")
+}
+
+func TestPlanStateMachine_OnTransition(t *testing.T) {
+	fsm, store := newTestFSM(t)
+	require.NoError(t, store.Create("test-proj", planstore.PlanEntry{Filename: "test.md", Status: "ready"}))
+
+	type seen struct {
+		plan     string
+		from, to Status
+		event    Event
+	}
+	var got []seen
+	fsm.OnTransition(func(planFile string, from, to Status, event Event) {
+		got = append(got, seen{planFile, from, to, event})
+	})
+	var second int
+	fsm.OnTransition(func(string, Status, Status, Event) { second++ })
+
+	require.NoError(t, fsm.Transition("test.md", PlanStart))
+	assert.Error(t, fsm.Transition("test.md", ReviewApproved), "rejected transitions are not observed")
+	assert.Equal(t, []seen{{"test.md", StatusReady, StatusPlanning, PlanStart}}, got)
+	assert.Equal(t, 1, second, "every registered observer runs")
+}
@@ -1,6 +1,7 @@
 package planfsm
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -62,6 +63,23 @@ func ConsumeSignal(sig Signal) {
 	_ = os.Remove(sig.filePath)
 }
 
+// WriteSignal writes the sentinel for event on planFile into signalsDir, with
+// body as its contents (e.g. review feedback). It is the inverse of
+// ScanSignals, for tools that signal on an agent's behalf.
+func WriteSignal(signalsDir string, event Event, planFile, body string) error {
+	for _, sp := range sentinelPrefixes {
+		if sp.event != event {
+			continue
+		}
+		if err := os.MkdirAll(signalsDir, 0o755); err != nil {
+			return fmt.Errorf("create signals dir: %w", err)
+		}
+		name := sp.prefix + filepath.Base(planFile)
+		return os.WriteFile(filepath.Join(signalsDir, name), []byte(body), 0o644)
+	}
+	return fmt.Errorf("event %s has no sentinel", event)
+}
+
 func parseSignal(dir, filename string) (Signal, bool) {
 	for _, sp := range sentinelPrefixes {
 		if strings.HasPrefix(filename, sp.prefix) {
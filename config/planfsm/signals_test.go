@@ -39,6 +39,19 @@ func TestScanSignals_ParsesValidSentinels(t *testing.T) {
 	assert.Equal(t, "fix the tests", signals[0].Body)
 }
 
+func TestWriteSignal_RoundTrips(t *testing.T) {
+	signalsDir := filepath.Join(t.TempDir(), ".kasmos", "signals")
+	require.NoError(t, WriteSignal(signalsDir, ReviewChangesRequested, "docs/plans/2026-02-22-bar.md", "fix the tests"))
+
+	signals := ScanSignals(signalsDir)
+	require.Len(t, signals, 1)
+	assert.Equal(t, ReviewChangesRequested, signals[0].Event)
+	assert.Equal(t, "2026-02-22-bar.md", signals[0].PlanFile)
+	assert.Equal(t, "fix the tests", signals[0].Body)
+
+	assert.Error(t, WriteSignal(signalsDir, Cancel, "2026-02-22-bar.md", ""), "user-only events have no sentinel")
+}
+
 func TestScanSignals_IgnoresInvalidFiles(t *testing.T) {
 	signalsDir := filepath.Join(t.TempDir(), ".kasmos", "signals")
 	require.NoError(t, os.MkdirAll(signalsDir, 0o755))
@@ -114,7 +127,7 @@ func TestScanSignals_KasmosSignalsDir(t *testing.T) {
 // The sentinel file system is decoupled from storage — it just triggers FSM events.
 func TestSignals_WithStoreFSM(t *testing.T) {
 	backend := planstore.NewTestSQLiteStore(t)
-	srv := httptest.NewServer(planstore.NewHandler(backend))
+	srv := httptest.NewServer(planstore.NewHandler(backend, nil))
 	defer srv.Close()
 
 	store := planstore.NewHTTPStore(srv.URL, "test-project")
@@ -0,0 +1,116 @@
+// Package planlog reads plan branch history attributed to waves and tasks
+// through the Kasmos-* commit trailers agents add to their commits.
+package planlog
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Commit trailers added to agent commits by the prepare-commit-msg hook.
+const (
+	PlanTrailer  = "Kasmos-Plan"
+	WaveTrailer  = "Kasmos-Wave"
+	TaskTrailer  = "Kasmos-Task"
+	AgentTrailer = "Kasmos-Agent"
+)
+
+// Commit is a commit together with its Kasmos-* trailers.
+type Commit struct {
+	SHA     string
+	Subject string
+	Plan    string
+	Wave    int // 0 = not a wave task
+	Task    int // 0 = not a wave task
+	Agent   string
+}
+
+// Group is the commits of one task, or the unattributed commits of a wave.
+type Group struct {
+	Wave    int
+	Task    int
+	Agent   string
+	Commits []Commit
+}
+
+// logFormat separates records with RS and fields with US so subjects and
+// trailers can hold any text.
+const logFormat = "--format=%x1e%H%x1f%s%x1f%(trailers:only,unfold)"
+
+// Read returns the commits on branch that are not on base, oldest first.
+func Read(repoPath, base, branch string) ([]Commit, error) {
+	out, err := exec.Command("git", "-C", repoPath, "--no-pager", "log", "--reverse", "--no-color",
+		logFormat, base+".."+branch).Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("git log %s..%s: %s", base, branch, strings.TrimSpace(string(ee.Stderr)))
+		}
+		return nil, fmt.Errorf("git log %s..%s: %w", base, branch, err)
+	}
+	return Parse(string(out)), nil
+}
+
+// Parse parses `git log` output produced with logFormat.
+func Parse(out string) []Commit {
+	var commits []Commit
+	for _, record := range strings.Split(out, "\x1e")[1:] {
+		fields := strings.SplitN(record, "\x1f", 3)
+		if len(fields) < 2 {
+			continue
+		}
+		c := Commit{SHA: fields[0], Subject: fields[1]}
+		if len(fields) == 3 {
+			for _, line := range strings.Split(fields[2], "\n") {
+				key, value, ok := strings.Cut(line, ":")
+				if !ok {
+					continue
+				}
+				value = strings.TrimSpace(value)
+				switch key {
+				case PlanTrailer:
+					c.Plan = value
+				case WaveTrailer:
+					c.Wave, _ = strconv.Atoi(value)
+				case TaskTrailer:
+					c.Task, _ = strconv.Atoi(value)
+				case AgentTrailer:
+					c.Agent = value
+				}
+			}
+		}
+		commits = append(commits, c)
+	}
+	return commits
+}
+
+// GroupByTask groups commits by wave and task: waves in order with
+// unattributed commits (wave 0) last, tasks in order within a wave, and
+// commits in their original order within a task.
+func GroupByTask(commits []Commit) []Group {
+	var groups []Group
+	index := make(map[[2]int]int)
+	for _, c := range commits {
+		key := [2]int{c.Wave, c.Task}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, Group{Wave: c.Wave, Task: c.Task, Agent: c.Agent})
+		}
+		groups[i].Commits = append(groups[i].Commits, c)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		wi, wj := groups[i].Wave, groups[j].Wave
+		if (wi == 0) != (wj == 0) {
+			return wj == 0
+		}
+		if wi != wj {
+			return wi < wj
+		}
+		return groups[i].Task < groups[j].Task
+	})
+	return groups
+}
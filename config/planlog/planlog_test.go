@@ -0,0 +1,36 @@
+package planlog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	out := "\x1eaaa\x1fadd token store\x1fKasmos-Plan: 2026-03-01-auth.md\nKasmos-Wave: 2\nKasmos-Task: 3\nKasmos-Agent: coder\nSigned-off-by: a\n" +
+		"\n\x1ebbb\x1fmanual notes\x1f\n"
+	commits := Parse(out)
+	require.Len(t, commits, 2)
+	assert.Equal(t, Commit{SHA: "aaa", Subject: "add token store", Plan: "2026-03-01-auth.md",
+		Wave: 2, Task: 3, Agent: "coder"}, commits[0])
+	assert.Equal(t, Commit{SHA: "bbb", Subject: "manual notes"}, commits[1])
+	assert.Empty(t, Parse(""))
+}
+
+func TestGroupByTask(t *testing.T) {
+	groups := GroupByTask([]Commit{
+		{SHA: "1", Subject: "fixup"},
+		{SHA: "2", Wave: 2, Task: 4},
+		{SHA: "3", Wave: 1, Task: 2},
+		{SHA: "4", Wave: 1, Task: 1, Agent: "coder"},
+		{SHA: "5", Wave: 1, Task: 2},
+	})
+	require.Len(t, groups, 4)
+	assert.Equal(t, [2]int{1, 1}, [2]int{groups[0].Wave, groups[0].Task})
+	assert.Equal(t, "coder", groups[0].Agent)
+	assert.Equal(t, [2]int{1, 2}, [2]int{groups[1].Wave, groups[1].Task})
+	assert.Len(t, groups[1].Commits, 2)
+	assert.Equal(t, [2]int{2, 4}, [2]int{groups[2].Wave, groups[2].Task})
+	assert.Equal(t, 0, groups[3].Wave, "unattributed commits come last")
+}
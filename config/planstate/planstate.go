@@ -31,6 +31,16 @@ type PlanEntry struct {
 	Topic       string    `json:"topic,omitempty"`
 	CreatedAt   time.Time `json:"created_at,omitempty"`
 	Implemented string    `json:"implemented,omitempty"`
+	// PRNumber and PRURL identify the plan's pull request once one is opened.
+	PRNumber int    `json:"pr_number,omitempty"`
+	PRURL    string `json:"pr_url,omitempty"`
+	// Parent is the plan whose branch this plan is stacked on, empty when
+	// the plan branches off the default branch.
+	Parent string `json:"parent,omitempty"`
+	// IssueSource and IssueID identify the tracker issue the plan was
+	// imported from, so status changes can be synced back to it.
+	IssueSource string `json:"issue_source,omitempty"`
+	IssueID     string `json:"issue_id,omitempty"`
 }
 
 type TopicEntry struct {
@@ -89,6 +99,11 @@ func Load(store planstore.Store, project, dir string) (*PlanState, error) {
 			Topic:       e.Topic,
 			CreatedAt:   e.CreatedAt,
 			Implemented: e.Implemented,
+			PRNumber:    e.PRNumber,
+			PRURL:       e.PRURL,
+			Parent:      e.Parent,
+			IssueSource: e.IssueSource,
+			IssueID:     e.IssueID,
 		}
 	}
 
@@ -458,6 +473,75 @@ func (ps *PlanState) SetBranch(filename, branch string) error {
 	return nil
 }
 
+// SetPR records the plan's pull request and persists it to the store.
+func (ps *PlanState) SetPR(filename string, number int, url string) error {
+	entry, ok := ps.Plans[filename]
+	if !ok {
+		return fmt.Errorf("plan not found: %s", filename)
+	}
+	entry.PRNumber = number
+	entry.PRURL = url
+	ps.Plans[filename] = entry
+	if err := ps.store.Update(ps.project, filename, ps.toPlanstoreEntry(filename, entry)); err != nil {
+		return fmt.Errorf("plan store: %w", err)
+	}
+	return nil
+}
+
+// SetIssue records the tracker issue a plan was imported from and persists
+// it to the store.
+func (ps *PlanState) SetIssue(filename, source, id string) error {
+	entry, ok := ps.Plans[filename]
+	if !ok {
+		return fmt.Errorf("plan not found: %s", filename)
+	}
+	entry.IssueSource = source
+	entry.IssueID = id
+	ps.Plans[filename] = entry
+	if err := ps.store.Update(ps.project, filename, ps.toPlanstoreEntry(filename, entry)); err != nil {
+		return fmt.Errorf("plan store: %w", err)
+	}
+	return nil
+}
+
+// SetParent stacks a plan on parent's branch and persists it to the store.
+// Pass an empty parent to unstack the plan. A plan cannot be stacked on
+// itself or on one of its own descendants.
+func (ps *PlanState) SetParent(filename, parent string) error {
+	entry, ok := ps.Plans[filename]
+	if !ok {
+		return fmt.Errorf("plan not found: %s", filename)
+	}
+	if parent != "" {
+		if _, ok := ps.Plans[parent]; !ok {
+			return fmt.Errorf("parent plan not found: %s", parent)
+		}
+		for p := parent; p != ""; p = ps.Plans[p].Parent {
+			if p == filename {
+				return fmt.Errorf("cannot stack %s on %s: it would form a cycle", filename, parent)
+			}
+		}
+	}
+	entry.Parent = parent
+	ps.Plans[filename] = entry
+	if err := ps.store.Update(ps.project, filename, ps.toPlanstoreEntry(filename, entry)); err != nil {
+		return fmt.Errorf("plan store: %w", err)
+	}
+	return nil
+}
+
+// Children returns the plans stacked directly on filename, sorted by filename.
+func (ps *PlanState) Children(filename string) []string {
+	var result []string
+	for name, entry := range ps.Plans {
+		if entry.Parent == filename {
+			result = append(result, name)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
 // Save is a no-op — all mutations write through to the store immediately.
 // Retained for API compatibility.
 func (ps *PlanState) Save() error {
@@ -524,6 +608,13 @@ func (ps *PlanState) Rename(oldFilename, newName string) (string, error) {
 	if err := ps.store.Rename(ps.project, oldFilename, newFilename); err != nil {
 		return "", fmt.Errorf("plan store: %w", err)
 	}
+
+	// Keep plans stacked on the renamed plan pointing at it.
+	for _, child := range ps.Children(oldFilename) {
+		if err := ps.SetParent(child, newFilename); err != nil {
+			return "", err
+		}
+	}
 	return newFilename, nil
 }
 
@@ -570,5 +661,10 @@ func (ps *PlanState) toPlanstoreEntry(filename string, e PlanEntry) planstore.Pl
 		Topic:       e.Topic,
 		CreatedAt:   e.CreatedAt,
 		Implemented: e.Implemented,
+		PRNumber:    e.PRNumber,
+		PRURL:       e.PRURL,
+		Parent:      e.Parent,
+		IssueSource: e.IssueSource,
+		IssueID:     e.IssueID,
 	}
 }
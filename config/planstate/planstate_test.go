@@ -452,6 +452,46 @@ func TestSetTopic_ClearTopic(t *testing.T) {
 	assert.Equal(t, "", ps2.Plans["2026-02-28-feat.md"].Topic)
 }
 
+func TestSetPR_WithStore(t *testing.T) {
+	store := planstore.NewTestSQLiteStore(t)
+	require.NoError(t, store.Create("proj", planstore.PlanEntry{
+		Filename: "2026-02-28-feat.md", Status: "reviewing", Branch: "plan/feat",
+	}))
+
+	ps, err := Load(store, "proj", t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, ps.SetPR("2026-02-28-feat.md", 42, "https://github.com/o/r/pull/42"))
+
+	// Later writes keep the pull request.
+	require.NoError(t, ps.SetBranch("2026-02-28-feat.md", "plan/feat-2"))
+
+	ps2, err := Load(store, "proj", t.TempDir())
+	require.NoError(t, err)
+	entry := ps2.Plans["2026-02-28-feat.md"]
+	assert.Equal(t, 42, entry.PRNumber)
+	assert.Equal(t, "https://github.com/o/r/pull/42", entry.PRURL)
+
+	assert.Error(t, ps.SetPR("nonexistent.md", 1, ""))
+}
+
+func TestSetIssue_WithStore(t *testing.T) {
+	store := planstore.NewTestSQLiteStore(t)
+	require.NoError(t, store.Create("proj", planstore.PlanEntry{Filename: "2026-02-28-feat.md", Status: "ready"}))
+
+	ps, err := Load(store, "proj", t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, ps.SetIssue("2026-02-28-feat.md", "jira", "PROJ-7"))
+	require.NoError(t, ps.SetBranch("2026-02-28-feat.md", "plan/feat"))
+
+	ps2, err := Load(store, "proj", t.TempDir())
+	require.NoError(t, err)
+	entry := ps2.Plans["2026-02-28-feat.md"]
+	assert.Equal(t, "jira", entry.IssueSource)
+	assert.Equal(t, "PROJ-7", entry.IssueID)
+
+	assert.Error(t, ps.SetIssue("nonexistent.md", "jira", "PROJ-1"))
+}
+
 func TestSetTopic_NotFound(t *testing.T) {
 	ps := newTestPS(t)
 
@@ -519,3 +559,34 @@ func TestPlanState_LoadRequiresStore(t *testing.T) {
 	require.NoError(t, err)
 	assert.Len(t, ps.Plans, 1)
 }
+
+func TestSetParent(t *testing.T) {
+	store := planstore.NewTestSQLiteStore(t)
+	dir := t.TempDir()
+	ps, err := Load(store, "proj", dir)
+	require.NoError(t, err)
+	now := time.Now()
+	require.NoError(t, ps.Register("2026-03-01-api.md", "api", "plan/api", now))
+	require.NoError(t, ps.Register("2026-03-02-ui.md", "ui", "plan/ui", now))
+	require.NoError(t, ps.Register("2026-03-03-docs.md", "docs", "plan/docs", now))
+
+	require.NoError(t, ps.SetParent("2026-03-02-ui.md", "2026-03-01-api.md"))
+	require.NoError(t, ps.SetParent("2026-03-03-docs.md", "2026-03-01-api.md"))
+	assert.Equal(t, []string{"2026-03-02-ui.md", "2026-03-03-docs.md"}, ps.Children("2026-03-01-api.md"))
+
+	assert.Error(t, ps.SetParent("2026-03-01-api.md", "2026-03-02-ui.md"), "cycles are refused")
+	assert.Error(t, ps.SetParent("2026-03-01-api.md", "2026-03-01-api.md"))
+	assert.Error(t, ps.SetParent("2026-03-02-ui.md", "missing.md"))
+
+	// Renaming the parent keeps its children attached.
+	renamed, err := ps.Rename("2026-03-01-api.md", "backend")
+	require.NoError(t, err)
+
+	ps2, err := Load(store, "proj", dir)
+	require.NoError(t, err)
+	assert.Equal(t, renamed, ps2.Plans["2026-03-02-ui.md"].Parent)
+	assert.Equal(t, []string{"2026-03-02-ui.md", "2026-03-03-docs.md"}, ps2.Children(renamed))
+
+	require.NoError(t, ps2.SetParent("2026-03-02-ui.md", ""))
+	assert.Equal(t, []string{"2026-03-03-docs.md"}, ps2.Children(renamed))
+}
@@ -26,7 +26,7 @@ func StartEmbedded(dbPath string, port int) (*EmbeddedServer, error) {
 		return nil, fmt.Errorf("embedded server: open db: %w", err)
 	}
 
-	handler := NewHandler(store)
+	handler := NewHandler(store, nil)
 	srv := &http.Server{Handler: handler}
 
 	// Listen on the specified port (0 = OS-assigned).
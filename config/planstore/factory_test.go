@@ -10,7 +10,7 @@ import (
 
 func TestNewStoreFromConfig_HTTP(t *testing.T) {
 	backend := newTestStore(t)
-	srv := httptest.NewServer(NewHandler(backend))
+	srv := httptest.NewServer(NewHandler(backend, nil))
 	defer srv.Close()
 
 	store, err := NewStoreFromConfig(srv.URL, "test-project")
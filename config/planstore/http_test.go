@@ -14,7 +14,7 @@ import (
 func newTestHTTPStore(t *testing.T) *planstore.HTTPStore {
 	t.Helper()
 	backend := newTestStore(t)
-	srv := httptest.NewServer(planstore.NewHandler(backend))
+	srv := httptest.NewServer(planstore.NewHandler(backend, nil))
 	t.Cleanup(srv.Close)
 	return planstore.NewHTTPStore(srv.URL, "kasmos")
 }
@@ -40,7 +40,7 @@ func TestHTTPStore_ContentRoundTrip(t *testing.T) {
 
 func TestHTTPStore_RoundTrip(t *testing.T) {
 	backend := newTestStore(t)
-	srv := httptest.NewServer(planstore.NewHandler(backend))
+	srv := httptest.NewServer(planstore.NewHandler(backend, nil))
 	defer srv.Close()
 
 	client := planstore.NewHTTPStore(srv.URL, "kasmos")
@@ -75,7 +75,7 @@ func TestHTTPStore_ServerUnreachable(t *testing.T) {
 
 func TestHTTPStore_Ping(t *testing.T) {
 	backend := newTestStore(t)
-	srv := httptest.NewServer(planstore.NewHandler(backend))
+	srv := httptest.NewServer(planstore.NewHandler(backend, nil))
 	defer srv.Close()
 
 	client := planstore.NewHTTPStore(srv.URL, "kasmos")
@@ -0,0 +1,359 @@
+package planstore
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kastheco/kasmos/config/auditlog"
+)
+
+var (
+	// waveDurationBuckets are the upper bounds (seconds) of the wave duration
+	// histogram: one minute to eight hours.
+	waveDurationBuckets = []float64{60, 300, 600, 1800, 3600, 7200, 14400, 28800}
+	// requestLatencyBuckets are the upper bounds (seconds) of the REST API
+	// latency histogram.
+	requestLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}
+)
+
+// activeStatuses are the statuses reported by kasmos_plan_status_age_seconds;
+// done and cancelled plans are not expected to move.
+var activeStatuses = []Status{StatusReady, StatusPlanning, StatusImplementing, StatusReviewing}
+
+// ProjectLister is implemented by stores that can enumerate their projects.
+// Metrics needs it to report plans across every project.
+type ProjectLister interface {
+	ListProjects() ([]string, error)
+}
+
+// Metrics collects the plan store server's Prometheus metrics. Plan counts
+// and ages are read from the store on each scrape; transitions, waves, agents
+// and request latency are counted as the server sees them, from process
+// start.
+type Metrics struct {
+	store Store
+	// eventName names the lifecycle event behind a status change. The plan
+	// FSM lives above this package, so the server wires it in.
+	eventName func(from, to Status) string
+	now       func() time.Time
+
+	mu            sync.Mutex
+	transitions   map[[4]string]uint64    // project, event, from, to
+	waveStarts    map[[3]string]time.Time // project, plan, wave
+	waveDurations map[[2]string]*histogram
+	waveFailures  map[string]uint64
+	agents        map[[3]string]uint64 // kind, role, program
+	requests      map[[3]string]*histogram
+}
+
+// NewMetrics returns a collector reading plans from store. eventName (which
+// may be nil) labels transitions with the lifecycle event that caused them.
+func NewMetrics(store Store, eventName func(from, to Status) string) *Metrics {
+	if eventName == nil {
+		eventName = func(from, to Status) string { return "unknown" }
+	}
+	return &Metrics{
+		store:         store,
+		eventName:     eventName,
+		now:           time.Now,
+		transitions:   make(map[[4]string]uint64),
+		waveStarts:    make(map[[3]string]time.Time),
+		waveDurations: make(map[[2]string]*histogram),
+		waveFailures:  make(map[string]uint64),
+		agents:        make(map[[3]string]uint64),
+		requests:      make(map[[3]string]*histogram),
+	}
+}
+
+// ObserveTransition counts a plan moving from one status to another. A plan
+// leaving implementing drops its open wave starts: waves cancelled or
+// abandoned with it never report an end.
+func (m *Metrics) ObserveTransition(project, plan string, from, to Status) {
+	if from == to {
+		return
+	}
+	key := [4]string{project, m.eventName(from, to), string(from), string(to)}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.transitions[key]++
+	if from == StatusImplementing {
+		for wave := range m.waveStarts {
+			if wave[0] == project && wave[1] == plan {
+				delete(m.waveStarts, wave)
+			}
+		}
+	}
+}
+
+// ObserveAudit derives wave and agent metrics from an ingested audit event.
+func (m *Metrics) ObserveAudit(e auditlog.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	wave := [3]string{e.Project, e.PlanFile, strconv.Itoa(e.WaveNumber)}
+	switch e.Kind {
+	case auditlog.EventWaveStarted:
+		if e.WaveNumber > 0 {
+			m.waveStarts[wave] = e.Timestamp
+		}
+	case auditlog.EventWaveCompleted, auditlog.EventWaveFailed:
+		outcome := "completed"
+		if e.Kind == auditlog.EventWaveFailed {
+			outcome = "failed"
+			m.waveFailures[e.Project]++
+		}
+		start, ok := m.waveStarts[wave]
+		if !ok || e.WaveNumber == 0 {
+			return
+		}
+		delete(m.waveStarts, wave)
+		key := [2]string{e.Project, outcome}
+		h := m.waveDurations[key]
+		if h == nil {
+			h = newHistogram(waveDurationBuckets)
+			m.waveDurations[key] = h
+		}
+		h.observe(e.Timestamp.Sub(start).Seconds())
+	case auditlog.EventAgentSpawned, auditlog.EventAgentKilled:
+		kind := "spawned"
+		if e.Kind == auditlog.EventAgentKilled {
+			kind = "killed"
+		}
+		m.agents[[3]string{kind, labelOrUnknown(e.AgentType), programName(e.Program)}]++
+	}
+}
+
+// Instrument wraps next, recording the latency of every request by method,
+// route pattern and status code.
+func (m *Metrics) Instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := m.now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		// ServeMux fills in the matched pattern on the request it routes.
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		key := [3]string{r.Method, route, strconv.Itoa(rec.status)}
+		m.mu.Lock()
+		h := m.requests[key]
+		if h == nil {
+			h = newHistogram(requestLatencyBuckets)
+			m.requests[key] = h
+		}
+		h.observe(m.now().Sub(start).Seconds())
+		m.mu.Unlock()
+	})
+}
+
+// ServeHTTP writes every metric in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := m.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// Write writes every metric in the Prometheus text exposition format.
+func (m *Metrics) Write(w io.Writer) error {
+	var b strings.Builder
+	if err := m.writePlans(&b); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	writeHeader(&b, "kasmos_plan_transitions_total", "counter", "Plan status transitions by lifecycle event.")
+	for _, k := range sortedKeys(m.transitions) {
+		writeSample(&b, "kasmos_plan_transitions_total", labels("project", k[0], "event", k[1], "from", k[2], "to", k[3]), float64(m.transitions[k]))
+	}
+	writeHeader(&b, "kasmos_wave_duration_seconds", "histogram", "Time from a wave starting to it completing or failing.")
+	for _, k := range sortedKeys(m.waveDurations) {
+		m.waveDurations[k].write(&b, "kasmos_wave_duration_seconds", labels("project", k[0], "outcome", k[1]))
+	}
+	writeHeader(&b, "kasmos_wave_failures_total", "counter", "Waves that finished with failed tasks.")
+	for _, k := range sortedKeys(m.waveFailures) {
+		writeSample(&b, "kasmos_wave_failures_total", labels("project", k), float64(m.waveFailures[k]))
+	}
+	for _, c := range []struct{ kind, name string }{
+		{"spawned", "kasmos_agent_spawns_total"},
+		{"killed", "kasmos_agent_kills_total"},
+	} {
+		writeHeader(&b, c.name, "counter", "Agents "+c.kind+" by role and program.")
+		for _, k := range sortedKeys(m.agents) {
+			if k[0] == c.kind {
+				writeSample(&b, c.name, labels("role", k[1], "program", k[2]), float64(m.agents[k]))
+			}
+		}
+	}
+	writeHeader(&b, "kasmos_http_request_duration_seconds", "histogram", "REST API request latency.")
+	for _, k := range sortedKeys(m.requests) {
+		m.requests[k].write(&b, "kasmos_http_request_duration_seconds", labels("method", k[0], "route", k[1], "code", k[2]))
+	}
+	m.mu.Unlock()
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writePlans writes the plan count and status age gauges from the store.
+func (m *Metrics) writePlans(b *strings.Builder) error {
+	lister, ok := m.store.(ProjectLister)
+	if !ok {
+		return nil
+	}
+	projects, err := lister.ListProjects()
+	if err != nil {
+		return fmt.Errorf("list projects: %w", err)
+	}
+	now := m.now()
+	var counts, ages strings.Builder
+	for _, project := range projects {
+		plans, err := m.store.List(project)
+		if err != nil {
+			return fmt.Errorf("list plans for %s: %w", project, err)
+		}
+		byStatus := make(map[Status]int)
+		for _, p := range plans {
+			byStatus[p.Status]++
+			since := p.StatusChangedAt
+			if since.IsZero() {
+				since = p.CreatedAt
+			}
+			if since.IsZero() || !isActiveStatus(p.Status) {
+				continue
+			}
+			writeSample(&ages, "kasmos_plan_status_age_seconds",
+				labels("project", project, "plan", p.Filename, "status", string(p.Status)), now.Sub(since).Seconds())
+		}
+		for _, status := range sortedKeys(byStatus) {
+			writeSample(&counts, "kasmos_plans", labels("project", project, "status", string(status)), float64(byStatus[status]))
+		}
+	}
+	writeHeader(b, "kasmos_plans", "gauge", "Plans by project and status.")
+	b.WriteString(counts.String())
+	writeHeader(b, "kasmos_plan_status_age_seconds", "gauge", "Seconds since each unfinished plan entered its current status.")
+	b.WriteString(ages.String())
+	return nil
+}
+
+func isActiveStatus(s Status) bool {
+	for _, a := range activeStatuses {
+		if s == a {
+			return true
+		}
+	}
+	return false
+}
+
+// programName reduces an agent command line ("/usr/bin/claude --model x") to
+// the program's base name, keeping label cardinality low.
+func programName(program string) string {
+	fields := strings.Fields(program)
+	if len(fields) == 0 {
+		return "unknown"
+	}
+	return filepath.Base(fields[0])
+}
+
+func labelOrUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// histogram is a Prometheus histogram with fixed bucket upper bounds.
+type histogram struct {
+	bounds []float64
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, bound := range h.bounds {
+		if v <= bound {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// write writes the histogram's cumulative buckets, sum and count.
+func (h *histogram) write(b *strings.Builder, name, lbls string) {
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		writeSample(b, name+"_bucket", joinLabels(lbls, labels("le", formatFloat(bound))), float64(cumulative))
+	}
+	writeSample(b, name+"_bucket", joinLabels(lbls, labels("le", "+Inf")), float64(h.count))
+	writeSample(b, name+"_sum", lbls, h.sum)
+	writeSample(b, name+"_count", lbls, float64(h.count))
+}
+
+func writeHeader(b *strings.Builder, name, typ, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func writeSample(b *strings.Builder, name, lbls string, v float64) {
+	if lbls != "" {
+		lbls = "{" + lbls + "}"
+	}
+	fmt.Fprintf(b, "%s%s %s\n", name, lbls, formatFloat(v))
+}
+
+// labels renders name/value pairs as `a="1",b="2"`, escaping the values.
+func labels(pairs ...string) string {
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, pairs[i]+`="`+labelEscaper.Replace(pairs[i+1])+`"`)
+	}
+	return strings.Join(parts, ",")
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func joinLabels(a, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + "," + b
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys returns a map's keys in a stable order, so scrapes diff cleanly.
+func sortedKeys[K comparable, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+	return keys
+}
@@ -0,0 +1,102 @@
+package planstore
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAudit is an AuditStore that accepts and discards events.
+type fakeAudit struct{}
+
+func (fakeAudit) Insert(auditlog.Event) error                          { return nil }
+func (fakeAudit) Query(auditlog.QueryFilter) ([]auditlog.Event, error) { return nil, nil }
+func (fakeAudit) Prune(time.Time) (int64, error)                       { return 0, nil }
+
+func TestMetrics_Endpoint(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+	require.NoError(t, store.Create("kasmos", PlanEntry{Filename: "auth.md", Status: StatusReady}))
+	require.NoError(t, store.Create("kasmos", PlanEntry{Filename: "old.md", Status: StatusDone}))
+	require.NoError(t, store.Create("web", PlanEntry{
+		Filename: "stuck.md", Status: StatusImplementing, StatusChangedAt: now.Add(-25 * time.Hour),
+	}))
+
+	metrics := NewMetrics(store, func(from, to Status) string { return "implement_start" })
+	srv := httptest.NewServer(NewHandler(store, fakeAudit{}, WithMetrics(metrics)))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodPut, srv.URL+"/v1/projects/kasmos/plans/auth.md",
+		strings.NewReader(`{"filename":"auth.md","status":"implementing"}`))
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	events, err := json.Marshal([]auditlog.Event{
+		{Kind: auditlog.EventWaveStarted, Project: "kasmos", PlanFile: "auth.md", WaveNumber: 1, Timestamp: now.Add(-10 * time.Minute)},
+		{Kind: auditlog.EventWaveFailed, Project: "kasmos", PlanFile: "auth.md", WaveNumber: 1, Timestamp: now},
+		{Kind: auditlog.EventAgentSpawned, AgentType: "coder", Program: "/usr/local/bin/claude --model opus", Timestamp: now},
+		{Kind: auditlog.EventAgentKilled, AgentType: "coder", Timestamp: now},
+	})
+	require.NoError(t, err)
+	resp, err = http.Post(srv.URL+"/v1/audit", "application/json", bytes.NewReader(events))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	resp, err = http.Get(srv.URL + "/metrics")
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	out := string(body)
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, out, "# TYPE kasmos_plans gauge\n")
+	assert.Contains(t, out, `kasmos_plans{project="kasmos",status="done"} 1`)
+	assert.Contains(t, out, `kasmos_plans{project="kasmos",status="implementing"} 1`)
+	assert.Contains(t, out, `kasmos_plans{project="web",status="implementing"} 1`)
+	assert.Contains(t, out, `kasmos_plan_transitions_total{project="kasmos",event="implement_start",from="ready",to="implementing"} 1`)
+	assert.Contains(t, out, `kasmos_wave_failures_total{project="kasmos"} 1`)
+	assert.Contains(t, out, `kasmos_wave_duration_seconds_bucket{project="kasmos",outcome="failed",le="600"} 1`)
+	assert.Contains(t, out, `kasmos_wave_duration_seconds_bucket{project="kasmos",outcome="failed",le="300"} 0`)
+	assert.Contains(t, out, `kasmos_agent_spawns_total{role="coder",program="claude"} 1`)
+	assert.Contains(t, out, `kasmos_agent_kills_total{role="coder",program="unknown"} 1`)
+	assert.Contains(t, out, `kasmos_http_request_duration_seconds_count{method="PUT",route="PUT /v1/projects/{project}/plans/{filename}",code="200"} 1`)
+	assert.NotContains(t, out, `plan="old.md"`, "finished plans have no status age")
+
+	var age float64
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, `kasmos_plan_status_age_seconds{project="web",plan="stuck.md",status="implementing"} `) {
+			_, err := fmt.Sscan(strings.Fields(line)[1], &age)
+			require.NoError(t, err)
+		}
+	}
+	assert.Greater(t, age, float64(24*60*60), "stuck plans are visible to alerts")
+}
+
+func TestMetrics_LeavingImplementingDropsWaveStarts(t *testing.T) {
+	m := NewMetrics(newTestStore(t), nil)
+	now := time.Now()
+	m.ObserveAudit(auditlog.Event{Kind: auditlog.EventWaveStarted, Project: "kasmos", PlanFile: "auth.md", WaveNumber: 1, Timestamp: now})
+	m.ObserveAudit(auditlog.Event{Kind: auditlog.EventWaveStarted, Project: "kasmos", PlanFile: "other.md", WaveNumber: 1, Timestamp: now})
+
+	m.ObserveTransition("kasmos", "auth.md", StatusImplementing, StatusCancelled)
+	assert.Len(t, m.waveStarts, 1, "only the cancelled plan's waves are dropped")
+	_, ok := m.waveStarts[[3]string{"kasmos", "other.md", "1"}]
+	assert.True(t, ok)
+}
+
+func TestLabelsEscapeValues(t *testing.T) {
+	assert.Equal(t, `plan="a\"b\\c\nd"`, labels("plan", "a\"b\\c\nd"))
+}
@@ -5,11 +5,44 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
+
+	"github.com/kastheco/kasmos/config/auditlog"
 )
 
-// NewHandler returns an http.Handler that exposes the Store over HTTP.
+// maxAuditBatch caps the events accepted by one audit ingest request.
+const maxAuditBatch = 1000
+
+// AuditStore is the audit log a server shares under /v1/audit.
+// *auditlog.SQLiteLogger implements it.
+type AuditStore interface {
+	Insert(e auditlog.Event) error
+	Query(filter auditlog.QueryFilter) ([]auditlog.Event, error)
+	Prune(before time.Time) (int64, error)
+}
+
+// HandlerOption configures NewHandler.
+type HandlerOption func(*handlerOptions)
+
+type handlerOptions struct {
+	metrics *Metrics
+}
+
+// WithMetrics serves m at GET /metrics and feeds it the transitions, audit
+// events and requests the handler sees.
+func WithMetrics(m *Metrics) HandlerOption {
+	return func(o *handlerOptions) { o.metrics = m }
+}
+
+// NewHandler returns an http.Handler that exposes the Store over HTTP, and
+// audit (when non-nil) as a shared audit log.
 // It uses Go 1.22+ ServeMux pattern matching for method+path routing.
-func NewHandler(store Store) http.Handler {
+func NewHandler(store Store, audit AuditStore, opts ...HandlerOption) http.Handler {
+	var o handlerOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	metrics := o.metrics
 	mux := http.NewServeMux()
 
 	// Health check
@@ -90,6 +123,10 @@ func NewHandler(store Store) http.Handler {
 			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
 			return
 		}
+		var before PlanEntry
+		if metrics != nil {
+			before, _ = store.Get(project, filename)
+		}
 		if err := store.Update(project, filename, entry); err != nil {
 			if isNotFound(err) {
 				writeError(w, http.StatusNotFound, "plan not found: "+filename)
@@ -98,6 +135,9 @@ func NewHandler(store Store) http.Handler {
 			writeError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
+		if metrics != nil && before.Status != "" {
+			metrics.ObserveTransition(project, filename, before.Status, entry.Status)
+		}
 		writeJSON(w, http.StatusOK, entry)
 	})
 
@@ -191,7 +231,91 @@ func NewHandler(store Store) http.Handler {
 		writeJSON(w, http.StatusCreated, entry)
 	})
 
-	return mux
+	// Ingest a batch of audit events
+	mux.HandleFunc("POST /v1/audit", func(w http.ResponseWriter, r *http.Request) {
+		if audit == nil {
+			writeError(w, http.StatusNotFound, "audit log not enabled on this server")
+			return
+		}
+		var events []auditlog.Event
+		if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+		if len(events) > maxAuditBatch {
+			writeError(w, http.StatusRequestEntityTooLarge, "too many events in one batch")
+			return
+		}
+		for _, e := range events {
+			if e.Kind == "" {
+				writeError(w, http.StatusBadRequest, "event kind is required")
+				return
+			}
+		}
+		for _, e := range events {
+			e.ID = 0 // the server assigns IDs
+			if err := audit.Insert(e); err != nil {
+				writeError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			if metrics != nil {
+				metrics.ObserveAudit(e)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// Query audit events (?project=&plan=&instance=&kind=&limit=&before=&after=)
+	mux.HandleFunc("GET /v1/audit", func(w http.ResponseWriter, r *http.Request) {
+		if audit == nil {
+			writeError(w, http.StatusNotFound, "audit log not enabled on this server")
+			return
+		}
+		filter, err := auditlog.ParseQueryFilter(r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		events, err := audit.Query(filter)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if events == nil {
+			events = []auditlog.Event{}
+		}
+		writeJSON(w, http.StatusOK, events)
+	})
+
+	// Delete audit events older than ?before=
+	mux.HandleFunc("DELETE /v1/audit", func(w http.ResponseWriter, r *http.Request) {
+		if audit == nil {
+			writeError(w, http.StatusNotFound, "audit log not enabled on this server")
+			return
+		}
+		filter, err := auditlog.ParseQueryFilter(r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if filter.Before.IsZero() {
+			writeError(w, http.StatusBadRequest, "before is required")
+			return
+		}
+		deleted, err := audit.Prune(filter.Before)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]int64{"deleted": deleted})
+	})
+
+	if metrics == nil {
+		return mux
+	}
+	// Prometheus scrape endpoint
+	mux.Handle("GET /metrics", metrics)
+	return metrics.Instrument(mux)
 }
 
 // writeJSON encodes v as JSON and writes it to w with the given status code.
@@ -15,7 +15,7 @@ import (
 
 func TestServer_CreateAndGetPlan(t *testing.T) {
 	store := newTestStore(t)
-	srv := httptest.NewServer(planstore.NewHandler(store))
+	srv := httptest.NewServer(planstore.NewHandler(store, nil))
 	defer srv.Close()
 
 	body := `{"filename":"test.md","status":"ready","description":"test"}`
@@ -34,7 +34,7 @@ func TestServer_CreateAndGetPlan(t *testing.T) {
 
 func TestServer_ListByStatus(t *testing.T) {
 	store := newTestStore(t)
-	srv := httptest.NewServer(planstore.NewHandler(store))
+	srv := httptest.NewServer(planstore.NewHandler(store, nil))
 	defer srv.Close()
 
 	// Create plans with different statuses
@@ -54,7 +54,7 @@ func TestServer_ListByStatus(t *testing.T) {
 
 func TestServer_Ping(t *testing.T) {
 	store := newTestStore(t)
-	srv := httptest.NewServer(planstore.NewHandler(store))
+	srv := httptest.NewServer(planstore.NewHandler(store, nil))
 	defer srv.Close()
 
 	resp, err := http.Get(srv.URL + "/v1/ping")
@@ -64,7 +64,7 @@ func TestServer_Ping(t *testing.T) {
 
 func TestServer_ContentEndpoints(t *testing.T) {
 	store := newTestStore(t)
-	srv := httptest.NewServer(planstore.NewHandler(store))
+	srv := httptest.NewServer(planstore.NewHandler(store, nil))
 	defer srv.Close()
 
 	// Create a plan first
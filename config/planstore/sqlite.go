@@ -32,8 +32,16 @@ CREATE TABLE IF NOT EXISTS topics (
 );
 `
 
-// contentMigration adds the content column to existing databases that predate it.
-const contentMigration = `ALTER TABLE plans ADD COLUMN content TEXT NOT NULL DEFAULT ''`
+// columnMigrations add columns to existing databases that predate them.
+var columnMigrations = []struct{ column, ddl string }{
+	{"content", `ALTER TABLE plans ADD COLUMN content TEXT NOT NULL DEFAULT ''`},
+	{"pr_number", `ALTER TABLE plans ADD COLUMN pr_number INTEGER NOT NULL DEFAULT 0`},
+	{"pr_url", `ALTER TABLE plans ADD COLUMN pr_url TEXT NOT NULL DEFAULT ''`},
+	{"parent", `ALTER TABLE plans ADD COLUMN parent TEXT NOT NULL DEFAULT ''`},
+	{"issue_source", `ALTER TABLE plans ADD COLUMN issue_source TEXT NOT NULL DEFAULT ''`},
+	{"issue_id", `ALTER TABLE plans ADD COLUMN issue_id TEXT NOT NULL DEFAULT ''`},
+	{"status_changed_at", `ALTER TABLE plans ADD COLUMN status_changed_at TEXT NOT NULL DEFAULT ''`},
+}
 
 // SQLiteStore is a Store implementation backed by a SQLite database.
 type SQLiteStore struct {
@@ -67,26 +75,25 @@ func NewSQLiteStore(dbPath string) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("run schema migrations: %w", err)
 	}
 
-	// Add content column if it doesn't exist (upgrade existing databases).
-	if err := migrateAddContentColumn(db); err != nil {
+	// Add columns introduced after the initial schema (upgrade existing databases).
+	if err := migrateAddColumns(db); err != nil {
 		db.Close()
-		return nil, fmt.Errorf("migrate content column: %w", err)
+		return nil, fmt.Errorf("migrate plan columns: %w", err)
 	}
 
 	return &SQLiteStore{db: db}, nil
 }
 
-// migrateAddContentColumn adds the content column to the plans table if it
-// doesn't already exist. This upgrades databases created before the column
-// was introduced.
-func migrateAddContentColumn(db *sql.DB) error {
-	// Check if the column already exists by querying the table info.
+// migrateAddColumns adds the columns in columnMigrations to the plans table
+// if they don't already exist. This upgrades databases created before the
+// columns were introduced.
+func migrateAddColumns(db *sql.DB) error {
+	// Collect the existing columns by querying the table info.
 	rows, err := db.Query("PRAGMA table_info(plans)")
 	if err != nil {
 		return fmt.Errorf("query table info: %w", err)
 	}
-	defer rows.Close()
-
+	existing := make(map[string]bool)
 	for rows.Next() {
 		var cid int
 		var name, colType string
@@ -94,19 +101,24 @@ func migrateAddContentColumn(db *sql.DB) error {
 		var dfltValue sql.NullString
 		var pk int
 		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			rows.Close()
 			return fmt.Errorf("scan table info: %w", err)
 		}
-		if name == "content" {
-			return nil // column already exists
-		}
+		existing[name] = true
 	}
 	if err := rows.Err(); err != nil {
+		rows.Close()
 		return fmt.Errorf("iterate table info: %w", err)
 	}
+	rows.Close()
 
-	// Column doesn't exist — add it.
-	if _, err := db.Exec(contentMigration); err != nil {
-		return fmt.Errorf("add content column: %w", err)
+	for _, m := range columnMigrations {
+		if existing[m.column] {
+			continue
+		}
+		if _, err := db.Exec(m.ddl); err != nil {
+			return fmt.Errorf("add %s column: %w", m.column, err)
+		}
 	}
 	return nil
 }
@@ -125,9 +137,13 @@ func (s *SQLiteStore) Ping() error {
 // Returns an error if a plan with the same filename already exists in the project.
 func (s *SQLiteStore) Create(project string, entry PlanEntry) error {
 	const q = `
-		INSERT INTO plans (project, filename, status, description, branch, topic, created_at, implemented, content)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO plans (project, filename, status, description, branch, topic, created_at, implemented, content, pr_number, pr_url, parent, issue_source, issue_id, status_changed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
+	changedAt := entry.StatusChangedAt
+	if changedAt.IsZero() {
+		changedAt = time.Now()
+	}
 	_, err := s.db.Exec(q,
 		project,
 		entry.Filename,
@@ -138,6 +154,12 @@ func (s *SQLiteStore) Create(project string, entry PlanEntry) error {
 		formatTime(entry.CreatedAt),
 		entry.Implemented,
 		entry.Content,
+		entry.PRNumber,
+		entry.PRURL,
+		entry.Parent,
+		entry.IssueSource,
+		entry.IssueID,
+		formatTime(changedAt),
 	)
 	if err != nil {
 		if isUniqueConstraintError(err) {
@@ -152,7 +174,7 @@ func (s *SQLiteStore) Create(project string, entry PlanEntry) error {
 // Returns an error if the plan is not found.
 func (s *SQLiteStore) Get(project, filename string) (PlanEntry, error) {
 	const q = `
-		SELECT filename, status, description, branch, topic, created_at, implemented, content
+		SELECT filename, status, description, branch, topic, created_at, implemented, content, pr_number, pr_url, parent, issue_source, issue_id, status_changed_at
 		FROM plans
 		WHERE project = ? AND filename = ?
 	`
@@ -160,15 +182,19 @@ func (s *SQLiteStore) Get(project, filename string) (PlanEntry, error) {
 	return scanPlanEntry(row)
 }
 
-// Update replaces all fields of an existing plan entry.
+// Update replaces all fields of an existing plan entry. StatusChangedAt is
+// managed by the store: it moves to now whenever the status changes.
 // Returns an error if the plan is not found.
 func (s *SQLiteStore) Update(project, filename string, entry PlanEntry) error {
 	const q = `
 		UPDATE plans
-		SET status = ?, description = ?, branch = ?, topic = ?, created_at = ?, implemented = ?, content = ?
+		SET status_changed_at = CASE WHEN status = ? THEN status_changed_at ELSE ? END,
+			status = ?, description = ?, branch = ?, topic = ?, created_at = ?, implemented = ?, content = ?, pr_number = ?, pr_url = ?, parent = ?, issue_source = ?, issue_id = ?
 		WHERE project = ? AND filename = ?
 	`
 	result, err := s.db.Exec(q,
+		string(entry.Status),
+		formatTime(time.Now()),
 		string(entry.Status),
 		entry.Description,
 		entry.Branch,
@@ -176,6 +202,11 @@ func (s *SQLiteStore) Update(project, filename string, entry PlanEntry) error {
 		formatTime(entry.CreatedAt),
 		entry.Implemented,
 		entry.Content,
+		entry.PRNumber,
+		entry.PRURL,
+		entry.Parent,
+		entry.IssueSource,
+		entry.IssueID,
 		project,
 		filename,
 	)
@@ -220,7 +251,7 @@ func (s *SQLiteStore) Rename(project, oldFilename, newFilename string) error {
 // List returns all plan entries for the given project, sorted by filename.
 func (s *SQLiteStore) List(project string) ([]PlanEntry, error) {
 	const q = `
-		SELECT filename, status, description, branch, topic, created_at, implemented, content
+		SELECT filename, status, description, branch, topic, created_at, implemented, content, pr_number, pr_url, parent, issue_source, issue_id, status_changed_at
 		FROM plans
 		WHERE project = ?
 		ORDER BY filename ASC
@@ -233,6 +264,24 @@ func (s *SQLiteStore) List(project string) ([]PlanEntry, error) {
 	return scanPlanEntries(rows)
 }
 
+// ListProjects returns the projects that have plans, sorted.
+func (s *SQLiteStore) ListProjects() ([]string, error) {
+	rows, err := s.db.Query("SELECT DISTINCT project FROM plans ORDER BY project")
+	if err != nil {
+		return nil, fmt.Errorf("list projects: %w", err)
+	}
+	defer rows.Close()
+	var projects []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, fmt.Errorf("scan project: %w", err)
+		}
+		projects = append(projects, p)
+	}
+	return projects, rows.Err()
+}
+
 // ListByStatus returns all plan entries for the given project matching any of
 // the provided statuses, sorted by filename.
 func (s *SQLiteStore) ListByStatus(project string, statuses ...Status) ([]PlanEntry, error) {
@@ -249,7 +298,7 @@ func (s *SQLiteStore) ListByStatus(project string, statuses ...Status) ([]PlanEn
 	}
 
 	q := fmt.Sprintf(`
-		SELECT filename, status, description, branch, topic, created_at, implemented, content
+		SELECT filename, status, description, branch, topic, created_at, implemented, content, pr_number, pr_url, parent, issue_source, issue_id, status_changed_at
 		FROM plans
 		WHERE project = ? AND status IN (%s)
 		ORDER BY filename ASC
@@ -267,7 +316,7 @@ func (s *SQLiteStore) ListByStatus(project string, statuses ...Status) ([]PlanEn
 // sorted by filename.
 func (s *SQLiteStore) ListByTopic(project, topic string) ([]PlanEntry, error) {
 	const q = `
-		SELECT filename, status, description, branch, topic, created_at, implemented, content
+		SELECT filename, status, description, branch, topic, created_at, implemented, content, pr_number, pr_url, parent, issue_source, issue_id, status_changed_at
 		FROM plans
 		WHERE project = ? AND topic = ?
 		ORDER BY filename ASC
@@ -363,8 +412,9 @@ func (s *SQLiteStore) SetContent(project, filename, content string) error {
 
 // scanPlanEntry scans a single row into a PlanEntry.
 func scanPlanEntry(row *sql.Row) (PlanEntry, error) {
-	var filename, status, description, branch, topic, createdAt, implemented, content string
-	if err := row.Scan(&filename, &status, &description, &branch, &topic, &createdAt, &implemented, &content); err != nil {
+	var filename, status, description, branch, topic, createdAt, implemented, content, prURL, parent, issueSource, issueID, statusChangedAt string
+	var prNumber int
+	if err := row.Scan(&filename, &status, &description, &branch, &topic, &createdAt, &implemented, &content, &prNumber, &prURL, &parent, &issueSource, &issueID, &statusChangedAt); err != nil {
 		if err == sql.ErrNoRows {
 			return PlanEntry{}, fmt.Errorf("plan not found")
 		}
@@ -379,6 +429,13 @@ func scanPlanEntry(row *sql.Row) (PlanEntry, error) {
 		CreatedAt:   parseTime(createdAt),
 		Implemented: implemented,
 		Content:     content,
+		PRNumber:    prNumber,
+		PRURL:       prURL,
+		Parent:      parent,
+		IssueSource: issueSource,
+		IssueID:     issueID,
+
+		StatusChangedAt: parseTime(statusChangedAt),
 	}, nil
 }
 
@@ -386,8 +443,9 @@ func scanPlanEntry(row *sql.Row) (PlanEntry, error) {
 func scanPlanEntries(rows *sql.Rows) ([]PlanEntry, error) {
 	var entries []PlanEntry
 	for rows.Next() {
-		var filename, status, description, branch, topic, createdAt, implemented, content string
-		if err := rows.Scan(&filename, &status, &description, &branch, &topic, &createdAt, &implemented, &content); err != nil {
+		var filename, status, description, branch, topic, createdAt, implemented, content, prURL, parent, issueSource, issueID, statusChangedAt string
+		var prNumber int
+		if err := rows.Scan(&filename, &status, &description, &branch, &topic, &createdAt, &implemented, &content, &prNumber, &prURL, &parent, &issueSource, &issueID, &statusChangedAt); err != nil {
 			return nil, fmt.Errorf("scan plan: %w", err)
 		}
 		entries = append(entries, PlanEntry{
@@ -399,6 +457,13 @@ func scanPlanEntries(rows *sql.Rows) ([]PlanEntry, error) {
 			CreatedAt:   parseTime(createdAt),
 			Implemented: implemented,
 			Content:     content,
+			PRNumber:    prNumber,
+			PRURL:       prURL,
+			Parent:      parent,
+			IssueSource: issueSource,
+			IssueID:     issueID,
+
+			StatusChangedAt: parseTime(statusChangedAt),
 		})
 	}
 	if err := rows.Err(); err != nil {
@@ -1,6 +1,8 @@
 package planstore_test
 
 import (
+	"database/sql"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -189,3 +191,88 @@ func TestSQLiteStore_SetContent(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "# Updated", content)
 }
+
+func TestSQLiteStore_PullRequest(t *testing.T) {
+	store := newTestStore(t)
+	entry := planstore.PlanEntry{Filename: "2026-02-28-test.md", Status: planstore.StatusReviewing}
+	require.NoError(t, store.Create("proj", entry))
+
+	entry.PRNumber = 12
+	entry.PRURL = "https://gitlab.example.com/o/r/-/merge_requests/12"
+	require.NoError(t, store.Update("proj", "2026-02-28-test.md", entry))
+
+	plans, err := store.List("proj")
+	require.NoError(t, err)
+	require.Len(t, plans, 1)
+	assert.Equal(t, 12, plans[0].PRNumber)
+	assert.Equal(t, entry.PRURL, plans[0].PRURL)
+}
+
+func TestNewSQLiteStore_MigratesOldSchema(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "plans.db")
+	db, err := sql.Open("sqlite", dbPath)
+	require.NoError(t, err)
+	_, err = db.Exec(`CREATE TABLE plans (
+		id INTEGER PRIMARY KEY, project TEXT NOT NULL, filename TEXT NOT NULL,
+		status TEXT NOT NULL DEFAULT 'ready', description TEXT NOT NULL DEFAULT '',
+		branch TEXT NOT NULL DEFAULT '', topic TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL DEFAULT '', implemented TEXT NOT NULL DEFAULT '',
+		UNIQUE(project, filename))`)
+	require.NoError(t, err)
+	_, err = db.Exec(`INSERT INTO plans (project, filename) VALUES ('proj', 'old.md')`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	store, err := planstore.NewSQLiteStore(dbPath)
+	require.NoError(t, err)
+	defer store.Close()
+
+	got, err := store.Get("proj", "old.md")
+	require.NoError(t, err)
+	assert.Equal(t, "", got.Content)
+	assert.Zero(t, got.PRNumber)
+}
+
+func TestSQLiteStore_Parent(t *testing.T) {
+	store := newTestStore(t)
+	require.NoError(t, store.Create("proj", planstore.PlanEntry{Filename: "2026-02-28-api.md", Status: planstore.StatusReady}))
+	require.NoError(t, store.Create("proj", planstore.PlanEntry{
+		Filename: "2026-02-28-ui.md", Status: planstore.StatusReady, Parent: "2026-02-28-api.md",
+	}))
+
+	got, err := store.Get("proj", "2026-02-28-ui.md")
+	require.NoError(t, err)
+	assert.Equal(t, "2026-02-28-api.md", got.Parent)
+
+	got.Parent = ""
+	require.NoError(t, store.Update("proj", "2026-02-28-ui.md", got))
+	plans, err := store.List("proj")
+	require.NoError(t, err)
+	require.Len(t, plans, 2)
+	assert.Empty(t, plans[1].Parent)
+}
+
+func TestSQLiteStore_StatusChangedAt(t *testing.T) {
+	store := newTestStore(t)
+	created := time.Now().Add(-48 * time.Hour).UTC()
+	entry := planstore.PlanEntry{Filename: "plan.md", Status: planstore.StatusReady, StatusChangedAt: created}
+	require.NoError(t, store.Create("kasmos", entry))
+
+	entry.Description = "edited"
+	entry.StatusChangedAt = time.Time{}
+	require.NoError(t, store.Update("kasmos", "plan.md", entry))
+	got, err := store.Get("kasmos", "plan.md")
+	require.NoError(t, err)
+	assert.True(t, got.StatusChangedAt.Equal(created), "unchanged status keeps its timestamp")
+
+	entry.Status = planstore.StatusImplementing
+	require.NoError(t, store.Update("kasmos", "plan.md", entry))
+	got, err = store.Get("kasmos", "plan.md")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now(), got.StatusChangedAt, time.Minute)
+
+	require.NoError(t, store.Create("other", planstore.PlanEntry{Filename: "x.md", Status: planstore.StatusReady}))
+	projects, err := store.(*planstore.SQLiteStore).ListProjects()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"kasmos", "other"}, projects)
+}
@@ -29,6 +29,20 @@ type PlanEntry struct {
 	CreatedAt   time.Time `json:"created_at,omitempty"`
 	Implemented string    `json:"implemented,omitempty"`
 	Content     string    `json:"content,omitempty"`
+	// PRNumber and PRURL identify the plan's pull request once one is opened.
+	PRNumber int    `json:"pr_number,omitempty"`
+	PRURL    string `json:"pr_url,omitempty"`
+	// Parent is the filename of the plan whose branch this plan is stacked
+	// on; empty for plans based on the default branch.
+	Parent string `json:"parent,omitempty"`
+	// IssueSource and IssueID identify the tracker issue the plan was
+	// imported from; IssueSource is the tracker's name (e.g. "github").
+	IssueSource string `json:"issue_source,omitempty"`
+	IssueID     string `json:"issue_id,omitempty"`
+	// StatusChangedAt is when the plan entered its current status. The store
+	// maintains it: Create defaults it to now and Update moves it whenever the
+	// status changes.
+	StatusChangedAt time.Time `json:"status_changed_at,omitempty"`
 }
 
 // TopicEntry holds the persisted metadata for a topic grouping.
@@ -43,13 +43,32 @@ type TOMLTelemetryConfig struct {
 	Enabled *bool `toml:"enabled,omitempty"`
 }
 
+// TOMLDaemonConfig holds headless supervisor settings from the [daemon] TOML table.
+type TOMLDaemonConfig struct {
+	// Supervise launches the daemon on TUI exit even when auto-yes is off.
+	Supervise bool `toml:"supervise"`
+}
+
 // TOMLConfig is the top-level TOML file structure.
 type TOMLConfig struct {
 	Phases    map[string]string    `toml:"phases"`
 	Agents    map[string]TOMLAgent `toml:"agents"`
 	UI        TOMLUIConfig         `toml:"ui"`
 	Telemetry TOMLTelemetryConfig  `toml:"telemetry"`
+	Daemon    TOMLDaemonConfig     `toml:"daemon"`
 	PlanStore string               `toml:"plan_store,omitempty"`
+	// MergeStrategy is the default plan merge strategy: merge, squash or rebase.
+	MergeStrategy string `toml:"merge_strategy,omitempty"`
+	// Notifications configures notification channels and per-event routing.
+	Notifications NotificationsConfig `toml:"notifications,omitempty"`
+	// Limits holds per-role resource limits ([limits.default], [limits.coder], ...).
+	Limits map[string]ResourceLimits `toml:"limits,omitempty"`
+	// Forge selects the pull request host ([forge] table).
+	Forge ForgeConfig `toml:"forge,omitempty"`
+	// Tracing sends plan lifecycle traces over OTLP/HTTP ([tracing] table).
+	Tracing TracingConfig `toml:"tracing,omitempty"`
+	// Issues configures the trackers plans are imported from ([issues] table).
+	Issues IssuesConfig `toml:"issues,omitempty"`
 }
 
 // TOMLConfigResult holds the parsed config in terms of internal types.
@@ -59,7 +78,14 @@ type TOMLConfigResult struct {
 	AnimateBanner    bool
 	AutoAdvanceWaves bool
 	TelemetryEnabled *bool
+	Supervise        bool
 	PlanStore        string
+	Notifications    NotificationsConfig
+	Limits           map[string]ResourceLimits
+	MergeStrategy    string
+	Forge            ForgeConfig
+	Tracing          TracingConfig
+	Issues           IssuesConfig
 }
 
 // LoadTOMLConfigFrom reads and parses a TOML config file,
@@ -69,6 +95,9 @@ func LoadTOMLConfigFrom(path string) (*TOMLConfigResult, error) {
 	if _, err := toml.DecodeFile(path, &tc); err != nil {
 		return nil, fmt.Errorf("decode TOML config: %w", err)
 	}
+	if err := validateLimits(tc.Limits); err != nil {
+		return nil, err
+	}
 
 	result := &TOMLConfigResult{
 		Profiles:         make(map[string]AgentProfile),
@@ -76,7 +105,14 @@ func LoadTOMLConfigFrom(path string) (*TOMLConfigResult, error) {
 		AnimateBanner:    tc.UI.AnimateBanner,
 		AutoAdvanceWaves: tc.UI.AutoAdvanceWaves,
 		TelemetryEnabled: tc.Telemetry.Enabled,
+		Supervise:        tc.Daemon.Supervise,
 		PlanStore:        tc.PlanStore,
+		Notifications:    tc.Notifications,
+		Limits:           tc.Limits,
+		MergeStrategy:    tc.MergeStrategy,
+		Forge:            tc.Forge,
+		Tracing:          tc.Tracing,
+		Issues:           tc.Issues,
 	}
 
 	for name, agent := range tc.Agents {
@@ -144,6 +144,22 @@ auto_advance_waves = true
 	})
 }
 
+func TestDaemonSupervise(t *testing.T) {
+	t.Run("parses supervise from daemon section", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		tomlPath := filepath.Join(tmpDir, "config.toml")
+		content := `
+[daemon]
+supervise = true
+`
+		err := os.WriteFile(tomlPath, []byte(content), 0o644)
+		require.NoError(t, err)
+		tc, err := LoadTOMLConfigFrom(tomlPath)
+		require.NoError(t, err)
+		assert.True(t, tc.Supervise)
+	})
+}
+
 func TestResolveProfileWithDisabledAgent(t *testing.T) {
 	t.Run("disabled agent falls back to default", func(t *testing.T) {
 		cfg := &Config{
@@ -194,3 +210,192 @@ func TestResolveProfileWithDisabledAgent(t *testing.T) {
 		assert.Equal(t, "opencode", profile.Program)
 	})
 }
+
+func TestNotificationsConfig(t *testing.T) {
+	t.Run("parses channels and routes", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		tomlPath := filepath.Join(tmpDir, "config.toml")
+		content := `
+[notifications.channels.ops]
+type = "webhook"
+url = "https://hooks.example.com/kasmos"
+body = '{"text": {{json .Body}}}'
+headers = { Authorization = "Bearer x" }
+
+[notifications.channels.phone]
+type = "ntfy"
+url = "https://ntfy.sh/kasmos"
+tags = ["warning"]
+
+[[notifications.routes]]
+events = ["wave_failed", "permission_detected"]
+channels = ["ops", "phone"]
+
+[[notifications.routes]]
+events = ["agent_finished"]
+channels = ["desktop"]
+`
+		require.NoError(t, os.WriteFile(tomlPath, []byte(content), 0o644))
+		tc, err := LoadTOMLConfigFrom(tomlPath)
+		require.NoError(t, err)
+
+		n := tc.Notifications
+		require.Len(t, n.Channels, 2)
+		assert.Equal(t, NotifyWebhook, n.Channels["ops"].Type)
+		assert.Equal(t, `{"text": {{json .Body}}}`, n.Channels["ops"].Body)
+		assert.Equal(t, "Bearer x", n.Channels["ops"].Headers["Authorization"])
+		assert.Equal(t, []string{"warning"}, n.Channels["phone"].Tags)
+		require.Len(t, n.Routes, 2)
+		assert.Equal(t, []string{"wave_failed", "permission_detected"}, n.Routes[0].Events)
+		assert.Equal(t, []string{"ops", "phone"}, n.Routes[0].Channels)
+	})
+
+	t.Run("absent table is zero", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		tomlPath := filepath.Join(tmpDir, "config.toml")
+		require.NoError(t, os.WriteFile(tomlPath, []byte("[ui]\nanimate_banner = true\n"), 0o644))
+		tc, err := LoadTOMLConfigFrom(tomlPath)
+		require.NoError(t, err)
+		assert.True(t, tc.Notifications.IsZero())
+	})
+
+	t.Run("save round-trips", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "config.toml")
+		require.NoError(t, SaveTOMLConfigTo(&TOMLConfig{
+			Notifications: NotificationsConfig{
+				Channels: map[string]NotificationChannel{"hook": {Type: NotifyCommand, Command: "notify.sh"}},
+				Routes:   []NotificationRoute{{Events: []string{"*"}, Channels: []string{"hook"}}},
+			},
+		}, path))
+		tc, err := LoadTOMLConfigFrom(path)
+		require.NoError(t, err)
+		assert.Equal(t, "notify.sh", tc.Notifications.Channels["hook"].Command)
+		assert.Equal(t, []string{"*"}, tc.Notifications.Routes[0].Events)
+	})
+}
+
+func TestLimitsConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	tomlPath := filepath.Join(tmpDir, "config.toml")
+	content := `
+[limits.default]
+soft_memory_mb = 2048
+hard_memory_mb = 4096
+
+[limits.coder]
+hard_memory_mb = 8192
+hard_cpu_seconds = 3600
+hard_action = "kill"
+`
+	require.NoError(t, os.WriteFile(tomlPath, []byte(content), 0o644))
+	tc, err := LoadTOMLConfigFrom(tomlPath)
+	require.NoError(t, err)
+	require.Len(t, tc.Limits, 2)
+
+	cfg := &Config{Limits: tc.Limits}
+	coder := cfg.LimitsFor("coder")
+	assert.Equal(t, 2048.0, coder.SoftMemoryMB, "inherits default")
+	assert.Equal(t, 8192.0, coder.HardMemoryMB)
+	assert.Equal(t, 3600.0, coder.HardCPUSeconds)
+	assert.Equal(t, LimitActionKill, coder.HardAction)
+
+	planner := cfg.LimitsFor("planner")
+	assert.Equal(t, 4096.0, planner.HardMemoryMB)
+	assert.Equal(t, LimitActionPause, planner.HardAction)
+
+	assert.True(t, (&Config{}).LimitsFor("coder").IsZero())
+}
+
+func TestLimitsConfig_RejectsUnknownHardAction(t *testing.T) {
+	tomlPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(tomlPath, []byte("[limits.coder]\nhard_action = \"stop\"\n"), 0o644))
+	_, err := LoadTOMLConfigFrom(tomlPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "limits.coder")
+}
+
+func TestMergeStrategyConfig(t *testing.T) {
+	tomlPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(tomlPath, []byte("merge_strategy = \"squash\"\n"), 0o644))
+	tc, err := LoadTOMLConfigFrom(tomlPath)
+	require.NoError(t, err)
+	assert.Equal(t, "squash", tc.MergeStrategy)
+}
+
+func TestForgeConfig(t *testing.T) {
+	tomlPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(tomlPath, []byte(`
+[forge]
+type = "gitea"
+url = "https://git.example.com"
+token_env = "MY_GITEA_TOKEN"
+`), 0o644))
+	tc, err := LoadTOMLConfigFrom(tomlPath)
+	require.NoError(t, err)
+	assert.Equal(t, ForgeConfig{Type: ForgeGitea, URL: "https://git.example.com", TokenEnv: "MY_GITEA_TOKEN"}, tc.Forge)
+}
+
+func TestTracingConfig(t *testing.T) {
+	tomlPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(tomlPath, []byte(`
+[tracing]
+endpoint = "http://localhost:4318"
+headers = { "x-api-key" = "secret" }
+`), 0o644))
+	tc, err := LoadTOMLConfigFrom(tomlPath)
+	require.NoError(t, err)
+	assert.Equal(t, TracingConfig{Endpoint: "http://localhost:4318", Headers: map[string]string{"x-api-key": "secret"}}, tc.Tracing)
+	assert.True(t, TracingConfig{}.IsZero())
+}
+
+func TestIssuesConfig(t *testing.T) {
+	tomlPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(tomlPath, []byte(`
+[issues.github]
+repo = "acme/app"
+
+[issues.jira]
+url = "https://acme.atlassian.net"
+email = "me@acme.com"
+project = "PROJ"
+
+[[issues.mcp]]
+server = "linear"
+fetch_tool = "get_issue"
+`), 0o644))
+	tc, err := LoadTOMLConfigFrom(tomlPath)
+	require.NoError(t, err)
+	assert.Equal(t, IssuesConfig{
+		GitHub: GitHubIssuesConfig{Repo: "acme/app"},
+		Jira:   JiraIssuesConfig{URL: "https://acme.atlassian.net", Email: "me@acme.com", Project: "PROJ"},
+		MCP:    []MCPIssuesConfig{{Server: "linear", FetchTool: "get_issue"}},
+	}, tc.Issues)
+	assert.False(t, tc.Issues.IsZero())
+	assert.True(t, IssuesConfig{}.IsZero())
+}
+
+func TestIssueSyncConfig(t *testing.T) {
+	tomlPath := filepath.Join(t.TempDir(), "config.toml")
+	require.NoError(t, os.WriteFile(tomlPath, []byte(`
+[issues.sync.statuses]
+reviewing = "Code Review"
+cancelled = ""
+
+[issues.sync.trackers.jira]
+done = "Closed"
+`), 0o644))
+	tc, err := LoadTOMLConfigFrom(tomlPath)
+	require.NoError(t, err)
+	sync := tc.Issues.Sync
+	assert.False(t, tc.Issues.IsZero())
+
+	assert.Equal(t, "Code Review", sync.TrackerStatus("linear", "reviewing"))
+	assert.Equal(t, "Closed", sync.TrackerStatus("jira", "done"))
+	assert.Equal(t, "done", sync.TrackerStatus("linear", "done"))
+	assert.Equal(t, "in progress", sync.TrackerStatus("clickup", "implementing"))
+	assert.Empty(t, sync.TrackerStatus("linear", "cancelled"), "an empty mapping leaves the issue alone")
+	assert.Empty(t, sync.TrackerStatus("linear", "planning"))
+
+	assert.Equal(t, "closed", IssueSyncConfig{}.TrackerStatus("github", "done"))
+	assert.Empty(t, IssueSyncConfig{}.TrackerStatus("gitlab", "implementing"))
+}
@@ -0,0 +1,16 @@
+package config
+
+// TracingConfig sends plan lifecycle traces to an OpenTelemetry collector.
+type TracingConfig struct {
+	// Endpoint is the collector's OTLP/HTTP URL, e.g. "http://localhost:4318";
+	// traces are posted to its /v1/traces path. Empty disables tracing unless
+	// OTEL_EXPORTER_OTLP_ENDPOINT is set.
+	Endpoint string `json:"endpoint,omitempty" toml:"endpoint,omitempty"`
+	// Headers are sent with every export, e.g. an API key for a hosted backend.
+	Headers map[string]string `json:"headers,omitempty" toml:"headers,omitempty"`
+}
+
+// IsZero reports whether nothing is configured.
+func (t TracingConfig) IsZero() bool {
+	return t.Endpoint == "" && len(t.Headers) == 0
+}
@@ -0,0 +1,185 @@
+package control
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// clientBaseURL is a placeholder host: every request is dialled over the socket.
+const clientBaseURL = "http://kasmos"
+
+// MCPPath is where the server mounts the handler given to WithMCP.
+const MCPPath = "/v1/mcp"
+
+// MCPURL is the MCP endpoint's URL for clients from SocketHTTPClient.
+const MCPURL = clientBaseURL + MCPPath
+
+// Client talks to a Server over its unix socket.
+type Client struct {
+	http *http.Client
+	// stream has no timeout so Watch can hold a connection open indefinitely.
+	stream *http.Client
+}
+
+// NewClient returns a Client for the control socket at socketPath.
+func NewClient(socketPath string) *Client {
+	stream := SocketHTTPClient(socketPath)
+	return &Client{
+		http:   &http.Client{Transport: stream.Transport, Timeout: 30 * time.Second},
+		stream: stream,
+	}
+}
+
+// SocketHTTPClient returns an HTTP client that dials the control socket at
+// socketPath whatever the request's host. It has no timeout.
+func SocketHTTPClient(socketPath string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}}
+}
+
+// ListInstances returns every instance the TUI is managing.
+func (c *Client) ListInstances() ([]Instance, error) {
+	var instances []Instance
+	if err := c.do(http.MethodGet, "/v1/instances", nil, &instances); err != nil {
+		return nil, err
+	}
+	return instances, nil
+}
+
+// ListPlans returns the plans of the TUI's project.
+func (c *Client) ListPlans() ([]Plan, error) {
+	var plans []Plan
+	if err := c.do(http.MethodGet, "/v1/plans", nil, &plans); err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+// SendPrompt types prompt into the named instance and submits it.
+func (c *Client) SendPrompt(title, prompt string) error {
+	return c.do(http.MethodPost, "/v1/instances/"+url.PathEscape(title)+"/prompt",
+		map[string]string{"prompt": prompt}, nil)
+}
+
+// AnswerPermission answers the pending permission prompt of the named instance.
+func (c *Client) AnswerPermission(title, choice string) error {
+	return c.do(http.MethodPost, "/v1/instances/"+url.PathEscape(title)+"/permission",
+		map[string]string{"choice": choice}, nil)
+}
+
+// InstanceAction pauses, resumes or kills the named instance.
+func (c *Client) InstanceAction(title, action string) error {
+	return c.do(http.MethodPost, "/v1/instances/"+url.PathEscape(title)+"/"+url.PathEscape(action), nil, nil)
+}
+
+// TriggerPlanStage starts stage for planFile, exactly as the TUI's plan menu would.
+func (c *Client) TriggerPlanStage(planFile, stage string) error {
+	return c.do(http.MethodPost, "/v1/plans/"+url.PathEscape(planFile)+"/stages/"+url.PathEscape(stage), nil, nil)
+}
+
+// Watch streams instance events to fn until ctx is cancelled, the server goes
+// away, or fn returns an error.
+func (c *Client) Watch(ctx context.Context, fn func(Event) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, clientBaseURL+"/v1/events", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.stream.Do(req)
+	if err != nil {
+		return fmt.Errorf("control: watch: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return decodeError(resp)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("control: decode event: %w", err)
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
+
+func (c *Client) do(method, path string, body, out any) error {
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reqBody = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, clientBaseURL+path, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("control: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return decodeError(resp)
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// decodeError turns an error response back into the Backend error it came from
+// so callers can use errors.Is(err, ErrNotFound) on either side of the socket.
+func decodeError(resp *http.Response) error {
+	var body struct {
+		Error string `json:"error"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&body)
+	msg := body.Error
+	if msg == "" {
+		msg = resp.Status
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return &remoteError{msg: msg, kind: ErrNotFound}
+	case http.StatusConflict, http.StatusBadRequest:
+		return &remoteError{msg: msg, kind: ErrInvalid}
+	default:
+		return fmt.Errorf("control: %s", msg)
+	}
+}
+
+// remoteError carries the server's message verbatim (it already names the
+// sentinel) while still matching the sentinel under errors.Is.
+type remoteError struct {
+	msg  string
+	kind error
+}
+
+func (e *remoteError) Error() string { return e.msg }
+
+func (e *remoteError) Unwrap() error { return e.kind }
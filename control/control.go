@@ -0,0 +1,98 @@
+// Package control exposes a running kasmos TUI to editors and scripts over a
+// local HTTP API served on a unix socket. The TUI implements Backend; the
+// `kas ctl` CLI (and any editor plugin) talks to it through Client.
+package control
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/kastheco/kasmos/config"
+)
+
+// Errors a Backend returns to select the HTTP status of a failed call.
+var (
+	// ErrNotFound reports an unknown instance or plan.
+	ErrNotFound = errors.New("not found")
+	// ErrInvalid reports a request the current state cannot satisfy.
+	ErrInvalid = errors.New("invalid request")
+)
+
+// Instance is the wire form of a session instance.
+type Instance struct {
+	Title     string `json:"title"`
+	Status    string `json:"status"`
+	Program   string `json:"program"`
+	Branch    string `json:"branch,omitempty"`
+	PlanFile  string `json:"plan_file,omitempty"`
+	AgentType string `json:"agent_type,omitempty"`
+	Wave      int    `json:"wave,omitempty"`
+	Task      int    `json:"task,omitempty"`
+	// AwaitingInput is true when the agent is idle at its input prompt.
+	AwaitingInput bool `json:"awaiting_input"`
+	// PermissionPending is true when the agent is blocked on a permission prompt.
+	PermissionPending bool `json:"permission_pending"`
+	Exited            bool `json:"exited,omitempty"`
+}
+
+// Plan is the wire form of a plan state entry.
+type Plan struct {
+	Filename    string `json:"filename"`
+	Status      string `json:"status"`
+	Description string `json:"description,omitempty"`
+	Branch      string `json:"branch,omitempty"`
+	Topic       string `json:"topic,omitempty"`
+}
+
+// Event types streamed from /v1/events.
+const (
+	EventInstanceAdded   = "instance_added"
+	EventInstanceStatus  = "instance_status"
+	EventInstanceRemoved = "instance_removed"
+)
+
+// Event is a single instance change, streamed as newline-delimited JSON.
+type Event struct {
+	Type     string    `json:"type"`
+	Instance string    `json:"instance"`
+	Status   string    `json:"status,omitempty"`
+	Previous string    `json:"previous,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Permission prompt answers accepted by AnswerPermission.
+const (
+	PermissionAllowOnce   = "allow_once"
+	PermissionAllowAlways = "allow_always"
+	PermissionReject      = "reject"
+)
+
+// Instance lifecycle actions accepted by InstanceAction.
+const (
+	ActionPause  = "pause"
+	ActionResume = "resume"
+	ActionKill   = "kill"
+)
+
+// Backend is implemented by the process that owns the instances (the TUI).
+// Implementations must be safe to call from HTTP handler goroutines.
+type Backend interface {
+	ListInstances() ([]Instance, error)
+	ListPlans() ([]Plan, error)
+	SendPrompt(title, prompt string) error
+	AnswerPermission(title, choice string) error
+	InstanceAction(title, action string) error
+	TriggerPlanStage(planFile, stage string) error
+}
+
+// SocketPath returns the control socket for the kasmos TUI running in repoPath.
+// One socket per project lets several TUIs (one per repo) run side by side.
+func SocketPath(repoPath string) (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, fmt.Sprintf("control-%s.sock", filepath.Base(repoPath))), nil
+}
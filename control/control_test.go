@@ -0,0 +1,203 @@
+package control
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeBackend struct {
+	mu        sync.Mutex
+	instances []Instance
+	plans     []Plan
+	calls     []string
+}
+
+func (f *fakeBackend) record(format string, args ...any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, fmt.Sprintf(format, args...))
+}
+
+func (f *fakeBackend) find(title string) error {
+	for _, inst := range f.instances {
+		if inst.Title == title {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: instance %q", ErrNotFound, title)
+}
+
+func (f *fakeBackend) ListInstances() ([]Instance, error) { return f.instances, nil }
+func (f *fakeBackend) ListPlans() ([]Plan, error)         { return f.plans, nil }
+
+func (f *fakeBackend) SendPrompt(title, prompt string) error {
+	if err := f.find(title); err != nil {
+		return err
+	}
+	f.record("prompt %s %s", title, prompt)
+	return nil
+}
+
+func (f *fakeBackend) AnswerPermission(title, choice string) error {
+	if err := f.find(title); err != nil {
+		return err
+	}
+	f.record("permission %s %s", title, choice)
+	return nil
+}
+
+func (f *fakeBackend) InstanceAction(title, action string) error {
+	if err := f.find(title); err != nil {
+		return err
+	}
+	f.record("%s %s", action, title)
+	return nil
+}
+
+func (f *fakeBackend) TriggerPlanStage(planFile, stage string) error {
+	if stage == "finished" {
+		return fmt.Errorf("%w: stage %q is locked", ErrInvalid, stage)
+	}
+	f.record("stage %s %s", planFile, stage)
+	return nil
+}
+
+// shortSocketPath keeps the socket path under the unix sun_path limit, which
+// t.TempDir() can exceed on macOS.
+func shortSocketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "kasctl")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "c.sock")
+}
+
+func startServer(t *testing.T, backend Backend) (*Server, *Client) {
+	t.Helper()
+	srv, err := Listen(shortSocketPath(t), backend)
+	require.NoError(t, err)
+	t.Cleanup(func() { srv.Close() })
+	return srv, NewClient(srv.Path())
+}
+
+func TestClientServer_ListAndCommands(t *testing.T) {
+	backend := &fakeBackend{
+		instances: []Instance{{Title: "auth-coder", Status: "running", Program: "claude"}},
+		plans:     []Plan{{Filename: "auth.md", Status: "implementing"}},
+	}
+	_, client := startServer(t, backend)
+
+	instances, err := client.ListInstances()
+	require.NoError(t, err)
+	assert.Equal(t, backend.instances, instances)
+
+	plans, err := client.ListPlans()
+	require.NoError(t, err)
+	assert.Equal(t, backend.plans, plans)
+
+	require.NoError(t, client.SendPrompt("auth-coder", "run the tests"))
+	require.NoError(t, client.AnswerPermission("auth-coder", PermissionAllowAlways))
+	require.NoError(t, client.InstanceAction("auth-coder", ActionPause))
+	require.NoError(t, client.TriggerPlanStage("auth.md", "review"))
+
+	assert.Equal(t, []string{
+		"prompt auth-coder run the tests",
+		"permission auth-coder allow_always",
+		"pause auth-coder",
+		"stage auth.md review",
+	}, backend.calls)
+}
+
+func TestClientServer_Errors(t *testing.T) {
+	_, client := startServer(t, &fakeBackend{})
+
+	err := client.SendPrompt("missing", "hi")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, `not found: instance "missing"`, err.Error())
+
+	assert.ErrorIs(t, client.TriggerPlanStage("auth.md", "finished"), ErrInvalid)
+	assert.ErrorIs(t, client.AnswerPermission("missing", "maybe"), ErrInvalid, "unknown choice is rejected before the backend")
+	assert.ErrorIs(t, client.InstanceAction("missing", "explode"), ErrNotFound)
+}
+
+func TestClientServer_WatchStreamsEvents(t *testing.T) {
+	srv, client := startServer(t, &fakeBackend{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	got := make(chan Event, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- client.Watch(ctx, func(e Event) error {
+			got <- e
+			cancel()
+			return nil
+		})
+	}()
+
+	// Publish until the subscriber is registered and receives one event.
+	tick := time.NewTicker(10 * time.Millisecond)
+	defer tick.Stop()
+	var e Event
+loop:
+	for {
+		select {
+		case e = <-got:
+			break loop
+		case <-tick.C:
+			srv.Publish(Event{Type: EventInstanceStatus, Instance: "auth-coder", Status: "ready", Previous: "running"})
+		case <-ctx.Done():
+			t.Fatal("no event received")
+		}
+	}
+
+	assert.Equal(t, EventInstanceStatus, e.Type)
+	assert.Equal(t, "auth-coder", e.Instance)
+	assert.Equal(t, "ready", e.Status)
+	assert.False(t, e.Time.IsZero())
+	assert.NoError(t, <-done)
+}
+
+func TestListen_ReplacesStaleSocketButNotLiveOne(t *testing.T) {
+	path := shortSocketPath(t)
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+	srv, err := Listen(path, &fakeBackend{})
+	require.NoError(t, err, "a dead socket file is replaced")
+	defer srv.Close()
+
+	_, err = Listen(path, &fakeBackend{})
+	assert.Error(t, err, "a live socket is not stolen")
+
+	require.NoError(t, srv.Close())
+	assert.NoFileExists(t, path)
+}
+
+func TestListen_WithMCP(t *testing.T) {
+	mcp := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(append([]byte("echo:"), body...))
+	})
+	srv, err := Listen(shortSocketPath(t), &fakeBackend{}, WithMCP(mcp))
+	require.NoError(t, err)
+	defer srv.Close()
+
+	resp, err := SocketHTTPClient(srv.Path()).Post(MCPURL, "application/json", strings.NewReader("{}"))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "echo:{}", string(body))
+}
@@ -0,0 +1,291 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is how many events a slow /v1/events reader may lag behind
+// before further events are dropped for it.
+const subscriberBuffer = 64
+
+// Server serves the control API on a unix socket.
+type Server struct {
+	backend Backend
+	path    string
+	srv     *http.Server
+	mcp     http.Handler
+
+	mu     sync.Mutex
+	subs   map[chan Event]struct{}
+	closed bool
+}
+
+// Option configures a Server.
+type Option func(*Server)
+
+// WithMCP serves h at /v1/mcp, the endpoint `kas mcp` forwards agents' MCP
+// messages to.
+func WithMCP(h http.Handler) Option {
+	return func(s *Server) { s.mcp = h }
+}
+
+// Listen starts serving backend on the unix socket at socketPath. A stale
+// socket left by a crashed process is replaced; a live one is an error so two
+// TUIs never fight over the same project.
+func Listen(socketPath string, backend Backend, opts ...Option) (*Server, error) {
+	if _, err := os.Stat(socketPath); err == nil {
+		if conn, dialErr := net.Dial("unix", socketPath); dialErr == nil {
+			conn.Close()
+			return nil, fmt.Errorf("control socket %s is already in use", socketPath)
+		}
+		if err := os.Remove(socketPath); err != nil {
+			return nil, fmt.Errorf("remove stale control socket: %w", err)
+		}
+	}
+
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("control: listen: %w", err)
+	}
+	// The socket can drive agents — keep it private to the user.
+	if err := os.Chmod(socketPath, 0o600); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("control: chmod socket: %w", err)
+	}
+
+	s := &Server{
+		backend: backend,
+		path:    socketPath,
+		subs:    make(map[chan Event]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.srv = &http.Server{Handler: s.Handler()}
+	go func() {
+		// ErrServerClosed is expected on Close — nothing else is actionable.
+		_ = s.srv.Serve(ln)
+	}()
+	return s, nil
+}
+
+// Path returns the socket path the server listens on.
+func (s *Server) Path() string { return s.path }
+
+// Close stops the server, ends all event streams and removes the socket.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	for ch := range s.subs {
+		close(ch)
+		delete(s.subs, ch)
+	}
+	s.mu.Unlock()
+
+	err := s.srv.Close()
+	_ = os.Remove(s.path)
+	return err
+}
+
+// Publish fans an event out to every /v1/events subscriber. It never blocks:
+// subscribers that fall subscriberBuffer events behind miss events.
+func (s *Server) Publish(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ch := range s.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+func (s *Server) subscribe() (chan Event, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, false
+	}
+	ch := make(chan Event, subscriberBuffer)
+	s.subs[ch] = struct{}{}
+	return ch, true
+}
+
+func (s *Server) unsubscribe(ch chan Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[ch]; ok {
+		delete(s.subs, ch)
+		close(ch)
+	}
+}
+
+// Handler returns the control API routes. Exposed for tests.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	if s.mcp != nil {
+		mux.Handle(MCPPath, s.mcp)
+	}
+
+	mux.HandleFunc("GET /v1/instances", func(w http.ResponseWriter, r *http.Request) {
+		instances, err := s.backend.ListInstances()
+		if err != nil {
+			writeBackendError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, instances)
+	})
+
+	mux.HandleFunc("GET /v1/plans", func(w http.ResponseWriter, r *http.Request) {
+		plans, err := s.backend.ListPlans()
+		if err != nil {
+			writeBackendError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, plans)
+	})
+
+	// Stream instance changes as newline-delimited JSON until the client
+	// disconnects or the server closes.
+	mux.HandleFunc("GET /v1/events", func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeError(w, http.StatusInternalServerError, "streaming unsupported")
+			return
+		}
+		ch, ok := s.subscribe()
+		if !ok {
+			writeError(w, http.StatusServiceUnavailable, "server closing")
+			return
+		}
+		defer s.unsubscribe(ch)
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+		enc := json.NewEncoder(w)
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case e, open := <-ch:
+				if !open {
+					return
+				}
+				if err := enc.Encode(e); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	})
+
+	mux.HandleFunc("POST /v1/instances/{title}/prompt", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Prompt string `json:"prompt"`
+		}
+		if !decodeBody(w, r, &body) {
+			return
+		}
+		if body.Prompt == "" {
+			writeError(w, http.StatusBadRequest, "prompt is required")
+			return
+		}
+		if err := s.backend.SendPrompt(r.PathValue("title"), body.Prompt); err != nil {
+			writeBackendError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("POST /v1/instances/{title}/permission", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Choice string `json:"choice"`
+		}
+		if !decodeBody(w, r, &body) {
+			return
+		}
+		switch body.Choice {
+		case PermissionAllowOnce, PermissionAllowAlways, PermissionReject:
+		default:
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown permission choice %q", body.Choice))
+			return
+		}
+		if err := s.backend.AnswerPermission(r.PathValue("title"), body.Choice); err != nil {
+			writeBackendError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("POST /v1/instances/{title}/{action}", func(w http.ResponseWriter, r *http.Request) {
+		action := r.PathValue("action")
+		switch action {
+		case ActionPause, ActionResume, ActionKill:
+		default:
+			writeError(w, http.StatusNotFound, fmt.Sprintf("unknown action %q", action))
+			return
+		}
+		if err := s.backend.InstanceAction(r.PathValue("title"), action); err != nil {
+			writeBackendError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	mux.HandleFunc("POST /v1/plans/{filename}/stages/{stage}", func(w http.ResponseWriter, r *http.Request) {
+		if err := s.backend.TriggerPlanStage(r.PathValue("filename"), r.PathValue("stage")); err != nil {
+			writeBackendError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	return mux
+}
+
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// writeBackendError maps Backend errors onto HTTP statuses.
+func writeBackendError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrInvalid):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response with the given status code and message.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
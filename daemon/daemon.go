@@ -3,8 +3,12 @@ package daemon
 import (
 	"fmt"
 	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/config/planstore"
 	"github.com/kastheco/kasmos/log"
+	"github.com/kastheco/kasmos/notify"
 	"github.com/kastheco/kasmos/session"
+	"github.com/kastheco/kasmos/tracing"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -14,29 +18,47 @@ import (
 	"time"
 )
 
-// RunDaemon runs the daemon process which iterates over all sessions and runs AutoYes mode on them.
-// It's expected that the main process kills the daemon when the main process starts.
-func RunDaemon(cfg *config.Config) error {
+// RunDaemon runs the headless supervisor until SIGINT/SIGTERM. While the TUI is
+// closed it keeps plans progressing (see Supervisor); when the TUI starts it
+// stops the daemon via StopDaemon, and the daemon saves state and releases its
+// tmux handles before exiting so the TUI resumes exactly where it left off.
+func RunDaemon(cfg *config.Config, autoYes bool, repos []string) error {
 	log.InfoLog.Printf("starting daemon")
-	state := config.LoadState()
-	storage, err := session.NewStorage(state)
+	configDir, err := config.GetConfigDir()
 	if err != nil {
-		return fmt.Errorf("failed to initialize storage: %w", err)
+		return fmt.Errorf("failed to get config directory: %w", err)
 	}
 
-	instances, err := storage.LoadInstances()
+	store, err := openPlanStore(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to load instances: %w", err)
+		return err
+	}
+	defer store.Close()
+
+	// Audit events go to the same local SQLite DB the TUI reads, and to the
+	// remote plan store server when one is configured.
+	var auditLogger auditlog.Logger
+	if al, err := auditlog.NewSQLiteLogger(planstore.ResolvedDBPath()); err != nil {
+		log.WarningLog.Printf("audit logger init failed: %v", err)
+		auditLogger = auditlog.NopLogger()
+	} else {
+		auditLogger = al
+	}
+	if cfg.PlanStore != "" {
+		auditLogger = auditlog.NewHTTPLogger(cfg.PlanStore, auditLogger)
 	}
-	for _, instance := range instances {
-		// Assume AutoYes is true if the daemon is running.
-		instance.AutoYes = true
+	auditLogger = tracing.Attach(cfg, auditLogger)
+	auditLogger, router := notify.Attach(cfg, auditLogger)
+	if router != nil {
+		session.Notifier = router.Dispatch
 	}
+	defer auditLogger.Close()
 
-	pollInterval := time.Duration(cfg.DaemonPollInterval) * time.Millisecond
+	sup := NewSupervisor(cfg, store, auditLogger, autoYes, repos)
+	sup.WatchState(filepath.Join(configDir, config.StateFileName))
+	auditLogger.Emit(auditlog.Event{Kind: auditlog.EventSessionStarted, Message: "daemon supervisor started"})
 
-	// If we get an error for a session, it's likely that we'll keep getting the error. Log every 60 seconds.
-	everyN := log.NewEvery(60 * time.Second)
+	pollInterval := time.Duration(cfg.DaemonPollInterval) * time.Millisecond
 
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
@@ -45,19 +67,7 @@ func RunDaemon(cfg *config.Config) error {
 		defer wg.Done()
 		ticker := time.NewTimer(pollInterval)
 		for {
-			for _, instance := range instances {
-				// We only store started instances, but check anyway.
-				if instance.Started() && !instance.Paused() {
-					if _, hasPrompt := instance.HasUpdated(); hasPrompt {
-						instance.TapEnter()
-						if err := instance.UpdateDiffStats(); err != nil {
-							if everyN.ShouldLog() {
-								log.WarningLog.Printf("could not update diff stats for %s: %v", instance.Title, err)
-							}
-						}
-					}
-				}
-			}
+			sup.Tick()
 
 			// Handle stop before ticker.
 			select {
@@ -66,12 +76,16 @@ func RunDaemon(cfg *config.Config) error {
 			default:
 			}
 
-			<-ticker.C
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+			}
 			ticker.Reset(pollInterval)
 		}
 	}()
 
-	// Notify on SIGINT (Ctrl+C) and SIGTERM. Save instances before
+	// Notify on SIGINT (Ctrl+C) and SIGTERM. Save instances before exiting.
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 	sig := <-sigChan
@@ -81,21 +95,48 @@ func RunDaemon(cfg *config.Config) error {
 	close(stopCh)
 	wg.Wait()
 
-	if err := storage.SaveInstances(instances); err != nil {
-		log.ErrorLog.Printf("failed to save instances when terminating daemon: %v", err)
-	}
+	sup.Release()
+	auditLogger.Emit(auditlog.Event{Kind: auditlog.EventSessionStopped, Message: "daemon supervisor handed off"})
 	return nil
 }
 
-// LaunchDaemon launches the daemon process.
-func LaunchDaemon() error {
+// openPlanStore returns the configured remote plan store when reachable, and
+// otherwise opens the local SQLite store the TUI's embedded server uses. The
+// store has no default project: the supervisor serves several repos and names
+// each one's project per call (see projectOf).
+func openPlanStore(cfg *config.Config) (planstore.Store, error) {
+	if cfg.PlanStore != "" {
+		remote := planstore.NewHTTPStore(cfg.PlanStore, "")
+		err := remote.Ping()
+		if err == nil {
+			return remote, nil
+		}
+		log.WarningLog.Printf("remote plan store unreachable: %v — falling back to local store", err)
+	}
+	store, err := planstore.NewSQLiteStore(planstore.ResolvedDBPath())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plan store: %w", err)
+	}
+	return store, nil
+}
+
+// LaunchDaemon launches the daemon process, supervising repoPath in addition to
+// the repos of persisted instances.
+func LaunchDaemon(autoYes bool, repoPath string) error {
 	// Find the kasmos binary.
 	execPath, err := os.Executable()
 	if err != nil {
 		return fmt.Errorf("failed to get executable path: %w", err)
 	}
 
-	cmd := exec.Command(execPath, "--daemon")
+	args := []string{"--daemon"}
+	if autoYes {
+		args = append(args, "--autoyes")
+	}
+	if repoPath != "" {
+		args = append(args, "--repo", repoPath)
+	}
+	cmd := exec.Command(execPath, args...)
 
 	// Detach the process from the parent
 	cmd.Stdin = nil
@@ -126,8 +167,13 @@ func LaunchDaemon() error {
 	return nil
 }
 
-// StopDaemon attempts to stop a running daemon process if it exists. Returns no error if the daemon is not found
-// (assumes the daemon does not exist).
+// stopTimeout bounds how long StopDaemon waits for the daemon to save state and
+// exit before killing it.
+const stopTimeout = 10 * time.Second
+
+// StopDaemon asks a running daemon to hand off and waits for it to save state
+// and exit, killing it if it does not exit within stopTimeout. Returns no error
+// if the daemon is not found (assumes the daemon does not exist).
 func StopDaemon() error {
 	pidDir, err := config.GetConfigDir()
 	if err != nil {
@@ -153,8 +199,19 @@ func StopDaemon() error {
 		return fmt.Errorf("failed to find daemon process: %w", err)
 	}
 
-	if err := proc.Kill(); err != nil {
-		return fmt.Errorf("failed to stop daemon process: %w", err)
+	if err := terminate(proc); err != nil {
+		log.WarningLog.Printf("daemon process (PID: %d) not running: %v", pid, err)
+	} else {
+		deadline := time.Now().Add(stopTimeout)
+		for processAlive(proc) && time.Now().Before(deadline) {
+			time.Sleep(100 * time.Millisecond)
+		}
+		if processAlive(proc) {
+			log.WarningLog.Printf("daemon process (PID: %d) did not exit in %s, killing", pid, stopTimeout)
+			if err := proc.Kill(); err != nil {
+				return fmt.Errorf("failed to stop daemon process: %w", err)
+			}
+		}
 	}
 
 	// Clean up PID file
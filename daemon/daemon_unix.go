@@ -3,6 +3,7 @@
 package daemon
 
 import (
+	"os"
 	"syscall"
 )
 
@@ -12,3 +13,13 @@ func getSysProcAttr() *syscall.SysProcAttr {
 		Setsid: true, // Create a new session
 	}
 }
+
+// terminate asks the daemon to shut down gracefully.
+func terminate(proc *os.Process) error {
+	return proc.Signal(syscall.SIGTERM)
+}
+
+// processAlive reports whether proc is still running.
+func processAlive(proc *os.Process) bool {
+	return proc.Signal(syscall.Signal(0)) == nil
+}
@@ -4,6 +4,7 @@ package daemon
 
 import (
 	"golang.org/x/sys/windows"
+	"os"
 	"syscall"
 )
 
@@ -13,3 +14,15 @@ func getSysProcAttr() *syscall.SysProcAttr {
 		CreationFlags: windows.CREATE_NEW_PROCESS_GROUP | windows.DETACHED_PROCESS,
 	}
 }
+
+// terminate stops the daemon. Windows has no SIGTERM for detached processes,
+// so the daemon is killed outright and relies on its last periodic save.
+func terminate(proc *os.Process) error {
+	return proc.Kill()
+}
+
+// processAlive reports whether proc is still running. After Kill the process
+// is gone on Windows, so there is nothing to wait for.
+func processAlive(_ *os.Process) bool {
+	return false
+}
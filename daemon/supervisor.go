@@ -0,0 +1,853 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/kastheco/kasmos/cmd"
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/config/planparser"
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/config/planstore"
+	"github.com/kastheco/kasmos/internal/clickup"
+	"github.com/kastheco/kasmos/internal/tracker"
+	"github.com/kastheco/kasmos/log"
+	"github.com/kastheco/kasmos/orchestration"
+	"github.com/kastheco/kasmos/session"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+)
+
+// startFunc launches a freshly created agent instance on the plan's branch.
+// Swapped out in tests so no tmux sessions or worktrees are created.
+type startFunc func(inst *session.Instance, repoPath, branch, planContent string) error
+
+// Supervisor drives plan lifecycles while the TUI is closed. Each Tick it
+// reloads instance state if another process changed it, answers agent
+// prompts, consumes planfsm sentinels, advances waves per policy, spawns
+// reviewers and coders, and records audit events — the same work the TUI's
+// metadata tick does, through the same orchestration package, minus anything
+// that needs a human at the keyboard.
+type Supervisor struct {
+	cfg     *config.Config
+	autoYes bool
+
+	store  planstore.Store
+	logger auditlog.Logger
+
+	// storage persists instances; nil disables persistence (tests).
+	storage   *session.Storage
+	statePath string
+	stateMod  time.Time
+
+	instances []*session.Instance
+	// repos are repositories supervised even when no instance references them.
+	repos []string
+	// waves holds wave orchestrators keyed by waveKey(repo, planFile).
+	waves map[string]*orchestration.WaveOrchestrator
+	// waiting records plans already reported as waiting on a human decision,
+	// so the audit log gets one entry per decision rather than one per tick.
+	waiting map[string]bool
+	// issueSyncs write plan status changes back to imported issues, one
+	// per repo; stopSync stops them on Release.
+	issueSyncs map[string]*tracker.Syncer
+	syncCtx    context.Context
+	stopSync   context.CancelFunc
+	start      startFunc
+	dirty      bool
+}
+
+// NewSupervisor creates a supervisor backed by the given plan store and audit
+// logger. repos lists repositories to watch in addition to those of the
+// persisted instances (typically the repo the TUI was last opened in).
+func NewSupervisor(cfg *config.Config, store planstore.Store, logger auditlog.Logger, autoYes bool, repos []string) *Supervisor {
+	if logger == nil {
+		logger = auditlog.NopLogger()
+	}
+	syncCtx, stopSync := context.WithCancel(context.Background())
+	return &Supervisor{
+		cfg:        cfg,
+		autoYes:    autoYes,
+		store:      store,
+		logger:     logger,
+		repos:      repos,
+		waves:      make(map[string]*orchestration.WaveOrchestrator),
+		waiting:    make(map[string]bool),
+		issueSyncs: make(map[string]*tracker.Syncer),
+		syncCtx:    syncCtx,
+		stopSync:   stopSync,
+		start:      startInSharedWorktree,
+	}
+}
+
+// WatchState wires the supervisor to the persisted instance state at
+// statePath. The file is reloaded whenever another process writes it.
+func (s *Supervisor) WatchState(statePath string) {
+	s.statePath = statePath
+}
+
+// Instances returns the instances currently under supervision.
+func (s *Supervisor) Instances() []*session.Instance {
+	return s.instances
+}
+
+// Tick runs one supervision pass.
+func (s *Supervisor) Tick() {
+	s.reloadIfChanged()
+	s.pollInstances()
+	for _, repo := range s.repoPaths() {
+		s.processSignals(repo)
+		s.processWaveSignals(repo)
+	}
+	s.advanceWaves()
+	if s.dirty {
+		s.Save()
+	}
+}
+
+// Save persists the supervised instances and remembers the resulting state
+// file mtime so the supervisor's own writes don't trigger a reload.
+func (s *Supervisor) Save() {
+	s.dirty = false
+	if s.storage == nil {
+		return
+	}
+	if err := s.storage.SaveInstances(s.instances); err != nil {
+		log.ErrorLog.Printf("supervisor: failed to save instances: %v", err)
+		return
+	}
+	s.stateMod = s.stateModTime()
+}
+
+// Release saves state and drops this process's tmux handles so the TUI can
+// take over the still-running agents.
+func (s *Supervisor) Release() {
+	s.stopSync()
+	s.Save()
+	for _, inst := range s.instances {
+		inst.Release()
+	}
+}
+
+func (s *Supervisor) stateModTime() time.Time {
+	if s.statePath == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(s.statePath)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// reloadIfChanged replaces the supervised instances when the state file was
+// written by another process since the last load or save.
+func (s *Supervisor) reloadIfChanged() {
+	if s.statePath == "" {
+		return
+	}
+	mod := s.stateModTime()
+	if s.storage != nil && mod.Equal(s.stateMod) {
+		return
+	}
+	storage, err := session.NewStorage(config.LoadState())
+	if err != nil {
+		log.ErrorLog.Printf("supervisor: failed to initialize storage: %v", err)
+		return
+	}
+	instances, err := storage.LoadInstances()
+	if err != nil {
+		log.ErrorLog.Printf("supervisor: failed to load instances: %v", err)
+		return
+	}
+	if s.storage != nil {
+		log.InfoLog.Printf("supervisor: instance state changed on disk, reloading %d instance(s)", len(instances))
+	}
+	for _, inst := range s.instances {
+		inst.Release()
+	}
+	s.storage = storage
+	s.instances = instances
+	s.stateMod = mod
+}
+
+// repoPaths returns the configured repos plus every repo referenced by a
+// supervised instance, deduplicated and sorted for deterministic ticks.
+func (s *Supervisor) repoPaths() []string {
+	seen := make(map[string]bool)
+	var repos []string
+	add := func(p string) {
+		if p == "" || seen[p] {
+			return
+		}
+		seen[p] = true
+		repos = append(repos, p)
+	}
+	for _, r := range s.repos {
+		add(r)
+	}
+	for _, inst := range s.instances {
+		add(instanceRepo(inst))
+	}
+	sort.Strings(repos)
+	return repos
+}
+
+func instanceRepo(inst *session.Instance) string {
+	if p := inst.GetRepoPath(); p != "" {
+		return p
+	}
+	return inst.Path
+}
+
+// pollInstances mirrors the TUI's metadata apply step: track running/prompt
+// state, answer prompts in auto-yes mode, and deliver queued prompts.
+func (s *Supervisor) pollInstances() {
+	var killed []*session.Instance
+	procs, _ := session.ReadProcessTable() // nil on error: usage is skipped
+	for _, inst := range s.instances {
+		if !inst.Started() || inst.Paused() || inst.Exited {
+			continue
+		}
+		inst.UpdateResourceUsage(procs)
+		if s.enforceResourceLimits(inst) {
+			killed = append(killed, inst)
+			continue
+		}
+		if inst.Paused() {
+			continue
+		}
+		updated, hasPrompt := inst.HasUpdated()
+		switch {
+		case updated:
+			inst.SetStatus(session.Running)
+		case hasPrompt:
+			inst.PromptDetected = true
+			if s.autoYes {
+				inst.TapEnter()
+			}
+		default:
+			inst.SetStatus(session.Ready)
+		}
+		if inst.QueuedPrompt != "" && (inst.Status == session.Ready || inst.PromptDetected) {
+			prompt := inst.QueuedPrompt
+			inst.QueuedPrompt = ""
+			inst.AwaitingWork = true
+			s.dirty = true
+			if err := inst.SendPrompt(prompt); err != nil {
+				log.WarningLog.Printf("supervisor: could not send queued prompt to %q: %v", inst.Title, err)
+			}
+		}
+	}
+	for _, inst := range killed {
+		s.removeInstance(inst)
+	}
+}
+
+// enforceResourceLimits applies the [limits] configured for inst's role to
+// its latest usage, auditing each crossing once. A hard breach stops the
+// instance's process tree, then pauses or kills it; it returns true when inst was killed and must be removed.
+func (s *Supervisor) enforceResourceLimits(inst *session.Instance) bool {
+	if len(s.cfg.Limits) == 0 {
+		return false
+	}
+	limits := s.cfg.LimitsFor(inst.AgentType)
+	if limits.IsZero() {
+		return false
+	}
+	breach, ok := inst.CheckResourceLimits(limits)
+	if !ok {
+		return false
+	}
+	project := projectOf(instanceRepo(inst))
+	opts := []auditlog.EventOption{
+		auditlog.WithInstance(inst.Title),
+		auditlog.WithAgent(inst.AgentType),
+		auditlog.WithProgram(inst.Program),
+		auditlog.WithPlan(inst.PlanFile),
+	}
+	if breach.Level == session.LimitSoft {
+		s.audit(project, auditlog.EventResourceLimit, breach.Reason,
+			append(opts, auditlog.WithLevel("warn"), auditlog.WithDetail("warn"))...)
+		return false
+	}
+
+	s.audit(project, auditlog.EventResourceLimit, breach.Reason,
+		append(opts, auditlog.WithLevel("error"), auditlog.WithDetail(limits.HardAction))...)
+	s.dirty = true
+	if err := inst.StopProcessTree(); err != nil {
+		log.WarningLog.Printf("supervisor: could not stop %q over resource limit: %v", inst.Title, err)
+	}
+	if limits.HardAction == config.LimitActionKill {
+		s.audit(project, auditlog.EventAgentKilled, "agent killed", opts...)
+		if err := inst.Kill(); err != nil {
+			log.WarningLog.Printf("supervisor: could not kill %q over resource limit: %v", inst.Title, err)
+		}
+		return true
+	}
+	if err := inst.Pause(); err != nil {
+		log.WarningLog.Printf("supervisor: could not pause %q over resource limit: %v", inst.Title, err)
+		return false
+	}
+	s.audit(project, auditlog.EventAgentPaused, "agent paused", opts...)
+	return false
+}
+
+// processSignals consumes agent sentinels for one repo (including its agents'
+// worktrees), applies them to the plan FSM and performs the same agent side
+// effects as the TUI.
+func (s *Supervisor) processSignals(repo string) {
+	signals := planfsm.ScanSignals(filepath.Join(repo, ".kasmos", "signals"))
+	seen := make(map[string]bool)
+	for _, sig := range signals {
+		seen[sig.Key()] = true
+	}
+	for _, inst := range s.instances {
+		wt := inst.GetWorktreePath()
+		if wt == "" || instanceRepo(inst) != repo {
+			continue
+		}
+		for _, sig := range planfsm.ScanSignals(filepath.Join(wt, ".kasmos", "signals")) {
+			if !seen[sig.Key()] {
+				seen[sig.Key()] = true
+				signals = append(signals, sig)
+			}
+		}
+	}
+	if len(signals) == 0 {
+		return
+	}
+
+	project := projectOf(repo)
+	fsm := s.planFSM(repo, project)
+	for _, sig := range signals {
+		_, waveActive := s.waves[waveKey(repo, sig.PlanFile)]
+		if err := orchestration.CheckSignal(sig, waveActive); err != nil {
+			log.WarningLog.Printf("supervisor: ignoring %s for %q: %v", sig.Event, sig.PlanFile, err)
+			planfsm.ConsumeSignal(sig)
+			continue
+		}
+		if err := fsm.Transition(sig.PlanFile, sig.Event); err != nil {
+			log.WarningLog.Printf("supervisor: signal %s for %s rejected: %v", sig.Event, sig.PlanFile, err)
+			planfsm.ConsumeSignal(sig)
+			continue
+		}
+		planfsm.ConsumeSignal(sig)
+		s.audit(project, auditlog.EventPlanTransition, orchestration.TransitionMessage(sig.Event), auditlog.WithPlan(sig.PlanFile))
+
+		switch sig.Event {
+		case planfsm.ImplementFinished:
+			for _, inst := range s.instances {
+				if instanceRepo(inst) == repo && orchestration.IsPlanAgent(inst, sig.PlanFile, session.AgentTypeCoder) {
+					inst.ImplementationComplete = true
+					_ = inst.Pause()
+					s.dirty = true
+					break
+				}
+			}
+			s.spawnReviewer(repo, sig.PlanFile)
+		case planfsm.ReviewApproved:
+			for _, inst := range s.instances {
+				if instanceRepo(inst) == repo && orchestration.IsPlanAgent(inst, sig.PlanFile, session.AgentTypeReviewer) {
+					_ = inst.Kill()
+					s.removeInstance(inst)
+					break
+				}
+			}
+		case planfsm.ReviewChangesRequested:
+			for _, inst := range s.instances {
+				if instanceRepo(inst) == repo && orchestration.IsPlanAgent(inst, sig.PlanFile, session.AgentTypeReviewer) {
+					_ = inst.Pause()
+					s.dirty = true
+					break
+				}
+			}
+			s.spawnCoderWithFeedback(repo, sig.PlanFile, sig.Body)
+		case planfsm.PlannerFinished:
+			// Starting implementation is a human decision; ingest the plan so
+			// the TUI can offer it on next launch.
+			s.ingestPlanContent(repo, sig.PlanFile)
+		}
+	}
+}
+
+// processWaveSignals starts wave orchestration requested via implement-wave
+// sentinels.
+func (s *Supervisor) processWaveSignals(repo string) {
+	project := projectOf(repo)
+	for _, ws := range planfsm.ScanWaveSignals(filepath.Join(repo, ".kasmos", "signals")) {
+		planfsm.ConsumeWaveSignal(ws)
+		key := waveKey(repo, ws.PlanFile)
+		if _, exists := s.waves[key]; exists {
+			log.WarningLog.Printf("supervisor: wave already running for %q", ws.PlanFile)
+			continue
+		}
+		plan, err := s.parsePlan(project, ws.PlanFile)
+		if err != nil {
+			log.WarningLog.Printf("supervisor: wave signal: %v", err)
+			continue
+		}
+		orch := orchestration.NewWaveOrchestrator(ws.PlanFile, plan)
+		if !orch.Rewind(ws.WaveNumber) {
+			log.WarningLog.Printf("supervisor: plan %s has %d waves, requested wave %d", ws.PlanFile, len(plan.Waves), ws.WaveNumber)
+			continue
+		}
+		s.waves[key] = orch
+		s.startNextWave(repo, orch, plan)
+	}
+}
+
+// advanceWaves restores orchestrators for plans that were mid-wave when the
+// TUI exited, then checks task completion and advances per policy: clean
+// waves auto-advance when AutoAdvanceWaves is set; failed waves and manual
+// mode wait for the TUI.
+func (s *Supervisor) advanceWaves() {
+	s.rebuildOrchestrators()
+
+	byTitle := make(map[string]*session.Instance, len(s.instances))
+	for _, inst := range s.instances {
+		byTitle[inst.Title] = inst
+	}
+
+	keys := make([]string, 0, len(s.waves))
+	for key := range s.waves {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		orch := s.waves[key]
+		repo, planFile := splitWaveKey(key)
+		project := projectOf(repo)
+		planName := planstate.DisplayName(planFile)
+
+		if orch.State() == orchestration.WaveStateRunning {
+			for _, task := range orch.CurrentWaveTasks() {
+				inst := byTitle[orchestration.TaskTitle(planFile, orch.CurrentWaveNumber(), task.Number)]
+				// An agent still launching counts as alive until a later tick.
+				alive := inst != nil && (!inst.Started() || inst.TmuxAlive())
+				orch.ReconcileTask(task.Number, inst, alive)
+			}
+		}
+
+		switch orch.State() {
+		case orchestration.WaveStateAllComplete:
+			if !s.cfg.AutoAdvanceWaves {
+				s.waitOnce(key, project, auditlog.EventWaveCompleted,
+					"all waves complete: "+planName+" (awaiting review confirmation)", planFile)
+				continue
+			}
+			s.finishWaves(repo, planFile)
+			delete(s.waves, key)
+			delete(s.waiting, key)
+		case orchestration.WaveStateWaveComplete:
+			waveNum := orch.CurrentWaveNumber()
+			completed, failed := orch.CompletedTaskCount(), orch.FailedTaskCount()
+			total := completed + failed
+			if failed > 0 {
+				s.waitOnce(key, project, auditlog.EventWaveFailed,
+					fmt.Sprintf("wave %d: %d/%d tasks failed", waveNum, failed, total), planFile,
+					auditlog.WithWave(waveNum, 0), auditlog.WithFailedTasks(orch.FailedTasks()))
+				continue
+			}
+			if !s.cfg.AutoAdvanceWaves {
+				s.waitOnce(key, project, auditlog.EventWaveCompleted,
+					fmt.Sprintf("wave %d complete: %d/%d tasks", waveNum, completed, total), planFile,
+					auditlog.WithWave(waveNum, 0))
+				continue
+			}
+			s.audit(project, auditlog.EventWaveCompleted,
+				fmt.Sprintf("wave %d complete: %d/%d tasks (auto-advancing)", waveNum, completed, total),
+				auditlog.WithPlan(planFile), auditlog.WithWave(waveNum, 0))
+			delete(s.waiting, key)
+			plan, err := s.parsePlan(project, planFile)
+			if err != nil {
+				log.WarningLog.Printf("supervisor: %v", err)
+				continue
+			}
+			s.startNextWave(repo, orch, plan)
+		}
+	}
+}
+
+// waitOnce records that a plan is blocked on a human decision, once per state.
+func (s *Supervisor) waitOnce(key, project string, kind auditlog.EventKind, msg, planFile string, opts ...auditlog.EventOption) {
+	if s.waiting[key] {
+		return
+	}
+	s.waiting[key] = true
+	s.audit(project, kind, msg, append([]auditlog.EventOption{auditlog.WithPlan(planFile)}, opts...)...)
+}
+
+// rebuildOrchestrators reconstructs orchestrators for implementing plans that
+// have wave task instances but no orchestrator, mirroring the TUI's restart
+// recovery so completion detection keeps working across process hand-offs.
+func (s *Supervisor) rebuildOrchestrators() {
+	type taskInst struct {
+		taskNumber, waveNumber int
+		paused                 bool
+	}
+	byKey := make(map[string][]taskInst)
+	for _, inst := range s.instances {
+		if inst.TaskNumber == 0 || inst.PlanFile == "" || inst.ImplementationComplete {
+			continue
+		}
+		key := waveKey(instanceRepo(inst), inst.PlanFile)
+		byKey[key] = append(byKey[key], taskInst{inst.TaskNumber, inst.WaveNumber, inst.Paused()})
+	}
+
+	for key, tasks := range byKey {
+		if _, exists := s.waves[key]; exists {
+			continue
+		}
+		repo, planFile := splitWaveKey(key)
+		project := projectOf(repo)
+		entry, err := s.store.Get(project, planFile)
+		if err != nil || entry.Status != planstore.StatusImplementing {
+			continue
+		}
+		plan, err := s.parsePlan(project, planFile)
+		if err != nil {
+			log.WarningLog.Printf("supervisor: %v", err)
+			continue
+		}
+		targetWave := 0
+		for _, t := range tasks {
+			targetWave = max(targetWave, t.waveNumber)
+		}
+		var done []int
+		for _, t := range tasks {
+			if t.waveNumber == targetWave && t.paused {
+				done = append(done, t.taskNumber)
+			}
+		}
+		orch := orchestration.RestoreWaveOrchestrator(planFile, plan, targetWave, done)
+		if orch == nil {
+			log.WarningLog.Printf("supervisor: plan %s has no wave %d", planFile, targetWave)
+			continue
+		}
+		s.waves[key] = orch
+		log.InfoLog.Printf("supervisor: restored orchestrator for %s (wave %d, %d tasks)", planFile, targetWave, len(tasks))
+	}
+}
+
+// startNextWave advances orch and spawns a coder per task in the new wave.
+func (s *Supervisor) startNextWave(repo string, orch *orchestration.WaveOrchestrator, plan *planparser.Plan) {
+	tasks := orch.StartNextWave()
+	if tasks == nil {
+		return
+	}
+	planFile := orch.PlanFile()
+	project := projectOf(repo)
+	waveNum := orch.CurrentWaveNumber()
+	s.audit(project, auditlog.EventWaveStarted,
+		fmt.Sprintf("wave %d started: %d task(s)", waveNum, len(tasks)),
+		auditlog.WithPlan(planFile), auditlog.WithWave(waveNum, 0))
+
+	for _, task := range tasks {
+		inst, err := session.NewInstance(session.InstanceOptions{
+			Title:      orchestration.TaskTitle(planFile, waveNum, task.Number),
+			Path:       repo,
+			Program:    s.programForAgent(session.AgentTypeCoder),
+			PlanFile:   planFile,
+			AgentType:  session.AgentTypeCoder,
+			TaskNumber: task.Number,
+			WaveNumber: waveNum,
+			PeerCount:  len(tasks),
+		})
+		if err != nil {
+			log.WarningLog.Printf("supervisor: could not create task instance: %v", err)
+			continue
+		}
+		inst.QueuedPrompt = orchestration.BuildTaskPrompt(plan, task, waveNum, orch.TotalWaves(), len(tasks))
+		s.launch(repo, inst, fmt.Sprintf("spawned coder for wave %d task %d", waveNum, task.Number),
+			auditlog.WithWave(waveNum, task.Number))
+	}
+	// Launch has created the branch; the queued prompts go out on a later
+	// tick, so nothing has been committed for this wave yet.
+	if branch, err := s.planBranch(project, planFile); err == nil {
+		orchestration.RecordWaveCheckpoint(repo, branch, planFile, waveNum)
+	}
+}
+
+// finishWaves pauses the finished task agents, pushes the shared branch,
+// moves the plan to reviewing and spawns the reviewer.
+func (s *Supervisor) finishWaves(repo, planFile string) {
+	project := projectOf(repo)
+	planName := planstate.DisplayName(planFile)
+	pushed := false
+	for _, inst := range s.instances {
+		if instanceRepo(inst) != repo || inst.PlanFile != planFile || inst.TaskNumber == 0 {
+			continue
+		}
+		if !pushed {
+			if wt, err := inst.GetGitWorktree(); err == nil {
+				_ = wt.PushChanges(fmt.Sprintf("[kas] push completed implementation for '%s'", planName), false)
+				pushed = true
+			}
+		}
+		inst.ImplementationComplete = true
+		_ = inst.Pause()
+	}
+	s.dirty = true
+	s.audit(project, auditlog.EventWaveCompleted, "all waves complete: "+planName, auditlog.WithPlan(planFile))
+
+	fsm := s.planFSM(repo, project)
+	if err := fsm.Transition(planFile, planfsm.ImplementFinished); err != nil {
+		log.WarningLog.Printf("supervisor: could not transition %q to reviewing: %v", planFile, err)
+		return
+	}
+	s.audit(project, auditlog.EventPlanTransition, orchestration.TransitionMessage(planfsm.ImplementFinished), auditlog.WithPlan(planFile))
+	s.spawnReviewer(repo, planFile)
+}
+
+// spawnReviewer starts a reviewer on the plan's branch, replacing any previous
+// one. Solo agent plans are left alone — the user ends those manually.
+func (s *Supervisor) spawnReviewer(repo, planFile string) {
+	for _, inst := range s.instances {
+		if instanceRepo(inst) == repo && inst.PlanFile == planFile && inst.SoloAgent {
+			return
+		}
+	}
+	planName := planstate.DisplayName(planFile)
+	s.killPlanAgents(repo, planFile, session.AgentTypeReviewer)
+
+	inst, err := session.NewInstance(session.InstanceOptions{
+		Title:     orchestration.ReviewerTitle(planFile),
+		Path:      repo,
+		Program:   s.programForAgent(session.AgentTypeReviewer),
+		PlanFile:  planFile,
+		AgentType: session.AgentTypeReviewer,
+	})
+	if err != nil {
+		log.WarningLog.Printf("supervisor: could not create reviewer for %q: %v", planFile, err)
+		return
+	}
+	inst.IsReviewer = true
+	inst.QueuedPrompt = orchestration.BuildReviewPrompt(planFile)
+	s.launch(repo, inst, fmt.Sprintf("spawned reviewer for %s", planName))
+}
+
+// spawnCoderWithFeedback restarts implementation with the reviewer's feedback.
+func (s *Supervisor) spawnCoderWithFeedback(repo, planFile, feedback string) {
+	planName := planstate.DisplayName(planFile)
+	s.killPlanAgents(repo, planFile, session.AgentTypeCoder)
+
+	inst, err := session.NewInstance(session.InstanceOptions{
+		Title:     orchestration.CoderTitle(planFile),
+		Path:      repo,
+		Program:   s.programForAgent(session.AgentTypeCoder),
+		PlanFile:  planFile,
+		AgentType: session.AgentTypeCoder,
+	})
+	if err != nil {
+		log.WarningLog.Printf("supervisor: could not create coder for %q: %v", planFile, err)
+		return
+	}
+	inst.QueuedPrompt = orchestration.BuildFeedbackPrompt(planFile, feedback)
+	detail := feedback
+	if len(detail) > 200 {
+		detail = detail[:200] + "..."
+	}
+	s.launch(repo, inst, fmt.Sprintf("spawned coder with reviewer feedback for %s", planName),
+		auditlog.WithDetail(detail))
+}
+
+// launch starts inst on its plan's branch and, on success, adds it to the
+// supervised set and records an agent_spawned event.
+func (s *Supervisor) launch(repo string, inst *session.Instance, msg string, opts ...auditlog.EventOption) {
+	project := projectOf(repo)
+	branch, err := s.planBranch(project, inst.PlanFile)
+	if err != nil {
+		log.WarningLog.Printf("supervisor: %v", err)
+		return
+	}
+	content, err := s.store.GetContent(project, inst.PlanFile)
+	if err != nil {
+		log.WarningLog.Printf("supervisor: could not read plan %s: %v", inst.PlanFile, err)
+	}
+	if err := s.start(inst, repo, branch, content); err != nil {
+		log.ErrorLog.Printf("supervisor: could not start %q: %v", inst.Title, err)
+		s.audit(project, auditlog.EventError, fmt.Sprintf("could not start %s: %v", inst.Title, err),
+			auditlog.WithPlan(inst.PlanFile), auditlog.WithInstance(inst.Title), auditlog.WithLevel("error"))
+		return
+	}
+	s.instances = append(s.instances, inst)
+	s.dirty = true
+	opts = append([]auditlog.EventOption{
+		auditlog.WithPlan(inst.PlanFile),
+		auditlog.WithInstance(inst.Title),
+		auditlog.WithAgent(inst.AgentType),
+		auditlog.WithProgram(inst.Program),
+	}, opts...)
+	s.audit(project, auditlog.EventAgentSpawned, msg, opts...)
+}
+
+// startInSharedWorktree is the production startFunc: set up the plan's shared
+// worktree, materialize the plan file into it and start the agent there.
+func startInSharedWorktree(inst *session.Instance, repoPath, branch, planContent string) error {
+	shared := gitpkg.NewSharedPlanWorktree(repoPath, branch)
+	if err := shared.Setup(); err != nil {
+		return err
+	}
+	if planContent != "" {
+		if err := orchestration.WritePlanFile(shared.GetWorktreePath(), inst.PlanFile, planContent); err != nil {
+			return err
+		}
+	}
+	return inst.StartInSharedWorktree(shared, branch)
+}
+
+// killPlanAgents kills and forgets every agent of agentType bound to planFile.
+func (s *Supervisor) killPlanAgents(repo, planFile, agentType string) {
+	var stale []*session.Instance
+	for _, inst := range s.instances {
+		if instanceRepo(inst) == repo && orchestration.IsPlanAgent(inst, planFile, agentType) {
+			stale = append(stale, inst)
+		}
+	}
+	for _, inst := range stale {
+		s.removeInstance(inst)
+		if err := inst.Kill(); err != nil {
+			log.WarningLog.Printf("supervisor: could not kill old %s for %q: %v", agentType, planFile, err)
+		}
+	}
+}
+
+// removeInstance drops inst from the supervised set, archiving its activity
+// timeline to the audit log first so plan history outlives it.
+func (s *Supervisor) removeInstance(inst *session.Instance) {
+	for i, cur := range s.instances {
+		if cur != inst {
+			continue
+		}
+		if entries := inst.ActivityTimeline(); inst.PlanFile != "" && len(entries) > 0 {
+			if detail, err := session.MarshalActivity(entries); err == nil {
+				s.audit(projectOf(instanceRepo(inst)), auditlog.EventActivityArchived,
+					fmt.Sprintf("archived %d activity entries", len(entries)),
+					auditlog.WithPlan(inst.PlanFile), auditlog.WithInstance(inst.Title),
+					auditlog.WithAgent(inst.AgentType), auditlog.WithDetail(detail))
+			}
+		}
+		s.instances = append(s.instances[:i], s.instances[i+1:]...)
+		s.dirty = true
+		return
+	}
+}
+
+// ingestPlanContent stores the plan file a planner wrote into the plan store.
+func (s *Supervisor) ingestPlanContent(repo, planFile string) {
+	content, err := orchestration.ReadPlanFile(repo, planFile)
+	if err != nil {
+		log.WarningLog.Printf("supervisor: cannot read plan %s: %v", planFile, err)
+		return
+	}
+	if err := s.store.SetContent(projectOf(repo), planFile, content); err != nil {
+		log.WarningLog.Printf("supervisor: cannot store content for %s: %v", planFile, err)
+	}
+}
+
+func (s *Supervisor) parsePlan(project, planFile string) (*planparser.Plan, error) {
+	content, err := s.store.GetContent(project, planFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read plan %s: %w", planFile, err)
+	}
+	plan, err := planparser.Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse plan %s: %w", planFile, err)
+	}
+	return plan, nil
+}
+
+// planBranch returns the plan's branch, assigning the default one if unset.
+func (s *Supervisor) planBranch(project, planFile string) (string, error) {
+	entry, err := s.store.Get(project, planFile)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve branch for plan %q: %w", planFile, err)
+	}
+	if entry.Branch == "" {
+		entry.Branch = gitpkg.PlanBranchFromFile(planFile)
+		if err := s.store.Update(project, planFile, entry); err != nil {
+			log.WarningLog.Printf("supervisor: could not persist branch for %q: %v", planFile, err)
+		}
+	}
+	return entry.Branch, nil
+}
+
+// programForAgent resolves the program for an agent role from the phase
+// profiles, falling back to the default program.
+func (s *Supervisor) programForAgent(agentType string) string {
+	phase, _ := orchestration.AgentPhase(agentType)
+	return s.cfg.ResolveProfile(phase, s.cfg.DefaultProgram).BuildCommand()
+}
+
+func (s *Supervisor) audit(project string, kind auditlog.EventKind, msg string, opts ...auditlog.EventOption) {
+	e := auditlog.Event{
+		Kind:    kind,
+		Project: project,
+		Message: msg,
+	}
+	for _, o := range opts {
+		o(&e)
+	}
+	s.logger.Emit(e)
+}
+
+// planFSM returns the plan state machine for repo, recording every status
+// change it applies in the audit log and syncing it to imported issues.
+func (s *Supervisor) planFSM(repo, project string) *planfsm.PlanStateMachine {
+	fsm := planfsm.New(s.store, project, filepath.Join(repo, "docs", "plans"))
+	fsm.OnTransition(func(planFile string, from, to planfsm.Status, event planfsm.Event) {
+		s.audit(project, auditlog.EventPlanStatusChanged, fmt.Sprintf("%s → %s", from, to),
+			auditlog.WithPlan(planFile),
+			auditlog.WithStatusChange(auditlog.StatusChange{Event: string(event), From: string(from), To: string(to)}))
+	})
+	fsm.OnTransition(s.issueSync(repo, project).Observe)
+	return fsm
+}
+
+// issueSync returns the issue syncer of repo, detecting its trackers and
+// starting it on first use. ClickUp is only reached with a saved token: the
+// daemon cannot run the browser sign-in.
+func (s *Supervisor) issueSync(repo, project string) *tracker.Syncer {
+	if sync, ok := s.issueSyncs[repo]; ok {
+		return sync
+	}
+	sync := tracker.NewSyncer(s.cfg.Issues.Sync, func(planFile string) (planstore.PlanEntry, error) {
+		return s.store.Get(project, planFile)
+	})
+	claudeDir := filepath.Join(os.Getenv("HOME"), ".claude")
+	sources := tracker.Detect(s.cfg, repo, claudeDir, cmd.MakeExecutor())
+	if src, found := clickup.DetectSource(s.cfg, repo, claudeDir, false); found {
+		sources = append(sources, src)
+	}
+	sync.SetSources(sources)
+	go sync.Run(s.syncCtx)
+	s.issueSyncs[repo] = sync
+	return sync
+}
+
+// projectOf returns the plan store project of repo: its directory name, as
+// the TUI derives it. Every store and audit call names it explicitly since the
+// supervisor serves several repos through one store.
+func projectOf(repo string) string {
+	return filepath.Base(repo)
+}
+
+func waveKey(repo, planFile string) string {
+	return repo + "\x00" + planFile
+}
+
+func splitWaveKey(key string) (repo, planFile string) {
+	for i := len(key) - 1; i >= 0; i-- {
+		if key[i] == 0 {
+			return key[:i], key[i+1:]
+		}
+	}
+	return "", key
+}
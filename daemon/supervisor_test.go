@@ -0,0 +1,239 @@
+package daemon
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/config/planstore"
+	"github.com/kastheco/kasmos/log"
+	"github.com/kastheco/kasmos/session"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	log.Initialize(false)
+	defer log.Close()
+	os.Exit(m.Run())
+}
+
+const twoWavePlan = `# Two Waves
+
+**Goal:** exercise the supervisor
+
+## Wave 1
+### Task 1: First
+
+Do the first thing.
+
+### Task 2: Second
+
+Do the second thing.
+
+## Wave 2
+### Task 3: Third
+
+Do the third thing.
+`
+
+// newTestSupervisor builds a supervisor over an in-memory store and audit log
+// whose agent launcher only records what it was asked to start.
+func newTestSupervisor(t *testing.T, cfg *config.Config) (*Supervisor, planstore.Store, *auditlog.SQLiteLogger, *[]*session.Instance, string) {
+	t.Helper()
+	repo := t.TempDir()
+	store := planstore.NewTestSQLiteStore(t)
+	logger, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { logger.Close() })
+
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	s := NewSupervisor(cfg, store, logger, false, []string{repo})
+	var started []*session.Instance
+	s.start = func(inst *session.Instance, _, _, _ string) error {
+		started = append(started, inst)
+		return nil
+	}
+	return s, store, logger, &started, repo
+}
+
+func createPlan(t *testing.T, store planstore.Store, repo, planFile string, status planstore.Status, content string) {
+	t.Helper()
+	project := filepath.Base(repo)
+	require.NoError(t, store.Create(project, planstore.PlanEntry{Filename: planFile, Status: status, Branch: "plan/test"}))
+	if content != "" {
+		require.NoError(t, store.SetContent(project, planFile, content))
+	}
+}
+
+func writeSignal(t *testing.T, repo, name, body string) string {
+	t.Helper()
+	dir := filepath.Join(repo, ".kasmos", "signals")
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o644))
+	return path
+}
+
+func planStatus(t *testing.T, store planstore.Store, repo, planFile string) planstore.Status {
+	t.Helper()
+	entry, err := store.Get(filepath.Base(repo), planFile)
+	require.NoError(t, err)
+	return entry.Status
+}
+
+func eventKinds(t *testing.T, logger auditlog.Logger) []auditlog.EventKind {
+	t.Helper()
+	events, err := logger.Query(auditlog.QueryFilter{Limit: 100})
+	require.NoError(t, err)
+	kinds := make([]auditlog.EventKind, 0, len(events))
+	for _, e := range events {
+		kinds = append(kinds, e.Kind)
+	}
+	return kinds
+}
+
+func TestSupervisor_ImplementFinishedSpawnsReviewer(t *testing.T) {
+	s, store, logger, started, repo := newTestSupervisor(t, nil)
+	createPlan(t, store, repo, "feature.md", planstore.StatusImplementing, "")
+	sigPath := writeSignal(t, repo, "implement-finished-feature.md", "")
+
+	s.Tick()
+
+	assert.Equal(t, planstore.StatusReviewing, planStatus(t, store, repo, "feature.md"))
+	assert.NoFileExists(t, sigPath, "sentinel must be consumed")
+	require.Len(t, *started, 1)
+	reviewer := (*started)[0]
+	assert.True(t, reviewer.IsReviewer)
+	assert.Equal(t, session.AgentTypeReviewer, reviewer.AgentType)
+	assert.Equal(t, "feature-review", reviewer.Title)
+	assert.NotEmpty(t, reviewer.QueuedPrompt)
+	assert.Contains(t, s.Instances(), reviewer)
+
+	kinds := eventKinds(t, logger)
+	assert.Contains(t, kinds, auditlog.EventPlanTransition)
+	assert.Contains(t, kinds, auditlog.EventAgentSpawned)
+}
+
+func TestSupervisor_ChangesRequestedRespawnsCoderWithFeedback(t *testing.T) {
+	s, store, _, started, repo := newTestSupervisor(t, nil)
+	createPlan(t, store, repo, "feature.md", planstore.StatusReviewing, "")
+	writeSignal(t, repo, "review-changes-feature.md", "fix the nil check")
+
+	s.Tick()
+
+	assert.Equal(t, planstore.StatusImplementing, planStatus(t, store, repo, "feature.md"))
+	require.Len(t, *started, 1)
+	coder := (*started)[0]
+	assert.Equal(t, session.AgentTypeCoder, coder.AgentType)
+	assert.Contains(t, coder.QueuedPrompt, "fix the nil check")
+}
+
+func TestSupervisor_RejectedSignalIsConsumedWithoutSideEffects(t *testing.T) {
+	s, store, _, started, repo := newTestSupervisor(t, nil)
+	createPlan(t, store, repo, "feature.md", planstore.StatusReady, "")
+	sigPath := writeSignal(t, repo, "review-approved-feature.md", "")
+
+	s.Tick()
+
+	assert.Equal(t, planstore.StatusReady, planStatus(t, store, repo, "feature.md"))
+	assert.NoFileExists(t, sigPath)
+	assert.Empty(t, *started)
+}
+
+func TestSupervisor_WaveSignalStartsWaveAndAutoAdvances(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.AutoAdvanceWaves = true
+	s, store, logger, started, repo := newTestSupervisor(t, cfg)
+	createPlan(t, store, repo, "two-waves.md", planstore.StatusImplementing, twoWavePlan)
+	writeSignal(t, repo, "implement-wave-1-two-waves.md", "")
+
+	s.Tick()
+	require.Len(t, *started, 2, "wave 1 spawns one coder per task")
+	assert.Equal(t, "two-waves-W1-T1", (*started)[0].Title)
+	assert.Equal(t, "two-waves-W1-T2", (*started)[1].Title)
+	assert.Equal(t, 2, (*started)[0].PeerCount)
+
+	// A task agent's implement-finished sentinel must not bypass the orchestrator.
+	writeSignal(t, repo, "implement-finished-two-waves.md", "")
+	s.Tick()
+	assert.Equal(t, planstore.StatusImplementing, planStatus(t, store, repo, "two-waves.md"))
+
+	// Both tasks go idle at a prompt after finishing their work.
+	for _, inst := range *started {
+		inst.PromptDetected = true
+	}
+	s.Tick()
+	require.Len(t, *started, 3, "clean wave auto-advances to wave 2")
+	assert.Equal(t, "two-waves-W2-T3", (*started)[2].Title)
+	assert.Contains(t, eventKinds(t, logger), auditlog.EventWaveCompleted)
+}
+
+func TestSupervisor_StartWaveRecordsCheckpoint(t *testing.T) {
+	s, store, _, _, repo := newTestSupervisor(t, nil)
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"-c", "user.name=t", "-c", "user.email=t@t", "commit", "-q", "--allow-empty", "-m", "init"},
+		{"branch", "plan/test"},
+	} {
+		out, err := exec.Command("git", append([]string{"-C", repo}, args...)...).CombinedOutput()
+		require.NoErrorf(t, err, "git %v: %s", args, out)
+	}
+	createPlan(t, store, repo, "two-waves.md", planstore.StatusImplementing, twoWavePlan)
+	writeSignal(t, repo, "implement-wave-1-two-waves.md", "")
+
+	s.Tick()
+	checkpoints, err := gitpkg.WaveCheckpoints(repo, "two-waves.md")
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 1)
+	assert.Equal(t, 1, checkpoints[0].Wave)
+}
+
+func TestSupervisor_ManualWavesWaitForTUI(t *testing.T) {
+	s, store, logger, started, repo := newTestSupervisor(t, nil)
+	createPlan(t, store, repo, "two-waves.md", planstore.StatusImplementing, twoWavePlan)
+	writeSignal(t, repo, "implement-wave-1-two-waves.md", "")
+
+	s.Tick()
+	require.Len(t, *started, 2)
+	for _, inst := range *started {
+		inst.PromptDetected = true
+	}
+	s.Tick()
+	s.Tick()
+
+	assert.Len(t, *started, 2, "without auto-advance the next wave waits for the user")
+	completed := 0
+	for _, k := range eventKinds(t, logger) {
+		if k == auditlog.EventWaveCompleted {
+			completed++
+		}
+	}
+	assert.Equal(t, 1, completed, "waiting is recorded once, not every tick")
+}
+
+func TestSupervisor_ReviewApprovedRemovesReviewerAndArchivesActivity(t *testing.T) {
+	s, store, logger, _, repo := newTestSupervisor(t, nil)
+	createPlan(t, store, repo, "feature.md", planstore.StatusReviewing, "")
+
+	reviewer, err := session.NewInstance(session.InstanceOptions{
+		Title: "feature-review", Path: repo, PlanFile: "feature.md", AgentType: session.AgentTypeReviewer,
+	})
+	require.NoError(t, err)
+	reviewer.IsReviewer = true
+	reviewer.RecordActivity(&session.Activity{Action: "reading", Detail: "main.go"})
+	s.instances = []*session.Instance{reviewer}
+	writeSignal(t, repo, "review-approved-feature.md", "")
+
+	s.Tick()
+
+	assert.Equal(t, planstore.StatusDone, planStatus(t, store, repo, "feature.md"))
+	assert.Empty(t, s.Instances())
+	assert.Contains(t, eventKinds(t, logger), auditlog.EventActivityArchived)
+}
@@ -1,83 +1,85 @@
 package clickup
 
 import (
-	"encoding/json"
-	"os"
-	"path/filepath"
+	"context"
+	"fmt"
 	"strings"
-)
-
-// mcpConfigFile represents the structure of .mcp.json or Claude settings.json.
-type mcpConfigFile struct {
-	MCPServers map[string]json.RawMessage `json:"mcpServers"`
-}
 
-// serverEntry is a union of http and stdio server config fields.
-type serverEntry struct {
-	Type    string            `json:"type"`
-	URL     string            `json:"url"`
-	Command string            `json:"command"`
-	Args    []string          `json:"args"`
-	Env     map[string]string `json:"env"`
-}
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/internal/mcpclient"
+	"github.com/kastheco/kasmos/internal/tracker"
+)
 
 // DetectMCP scans config files for a ClickUp MCP server.
 // repoDir is the project root (checks .mcp.json).
 // claudeDir is the Claude config dir (checks settings.json, settings.local.json).
 // Pass empty claudeDir to skip Claude config scanning.
 func DetectMCP(repoDir, claudeDir string) (MCPServerConfig, bool) {
-	if cfg, ok := scanFile(filepath.Join(repoDir, ".mcp.json")); ok {
-		return cfg, true
-	}
+	_, cfg, found := mcpclient.FindServer(repoDir, claudeDir, func(name string) bool {
+		return strings.Contains(strings.ToLower(name), "clickup")
+	})
+	return cfg, found
+}
 
-	if claudeDir != "" {
-		if cfg, ok := scanFile(filepath.Join(claudeDir, "settings.json")); ok {
-			return cfg, true
+// DetectSource returns a Source for the ClickUp MCP server found by
+// DetectMCP, unless cfg lists it in [[issues.mcp]], where it is used as a
+// generic MCP source. interactive allows the browser OAuth flow; without it
+// the hosted server needs a saved token.
+func DetectSource(cfg *config.Config, repoDir, claudeDir string, interactive bool) (*Source, bool) {
+	for _, m := range cfg.Issues.MCP {
+		if strings.Contains(strings.ToLower(m.Server), "clickup") {
+			return nil, false
 		}
+	}
+	server, found := DetectMCP(repoDir, claudeDir)
+	if !found {
+		return nil, false
+	}
+	return NewSource(Dialer(server, interactive)), true
+}
 
-		if cfg, ok := scanFile(filepath.Join(claudeDir, "settings.local.json")); ok {
-			return cfg, true
+// Dialer connects to the ClickUp MCP server, authenticating the hosted
+// (http) server with OAuth first.
+func Dialer(server MCPServerConfig, interactive bool) tracker.MCPDialer {
+	return func(ctx context.Context) (tracker.MCPCaller, error) {
+		token := ""
+		if server.Type == "http" {
+			var err error
+			if token, err = oauthToken(ctx, interactive); err != nil {
+				return nil, err
+			}
+		}
+		client, err := mcpclient.Connect(server, token)
+		if err != nil {
+			return nil, err
 		}
+		return tracker.ClientCaller(client), nil
 	}
-
-	return MCPServerConfig{}, false
 }
 
-func scanFile(path string) (MCPServerConfig, bool) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return MCPServerConfig{}, false
+// oauthToken returns the saved ClickUp token, running the browser flow when
+// it is missing or expired and interactive is set.
+func oauthToken(ctx context.Context, interactive bool) (string, error) {
+	path := mcpclient.TokenPath()
+	tok, err := mcpclient.LoadToken(path)
+	if err == nil && !tok.IsExpired() {
+		return tok.AccessToken, nil
 	}
-
-	var file mcpConfigFile
-	if err := json.Unmarshal(data, &file); err != nil {
-		return MCPServerConfig{}, false
+	if !interactive {
+		return "", fmt.Errorf("no valid ClickUp token; import a ClickUp task in the TUI to sign in")
 	}
 
-	for name, raw := range file.MCPServers {
-		if !strings.Contains(strings.ToLower(name), "clickup") {
-			continue
-		}
-
-		var entry serverEntry
-		if err := json.Unmarshal(raw, &entry); err != nil {
-			continue
-		}
-
-		cfg := MCPServerConfig{Env: entry.Env}
-		if entry.Type == "http" || entry.URL != "" {
-			cfg.Type = "http"
-			cfg.URL = entry.URL
-		} else if entry.Command != "" {
-			cfg.Type = "stdio"
-			cfg.Command = entry.Command
-			cfg.Args = entry.Args
-		} else {
-			continue
-		}
-
-		return cfg, true
+	oauthCfg := mcpclient.OAuthConfig{
+		AuthURL:  "https://app.clickup.com/api",
+		TokenURL: "https://api.clickup.com/api/v2/oauth/token",
+		ClientID: "kasmos", // TODO: register ClickUp OAuth app
 	}
-
-	return MCPServerConfig{}, false
+	tok, err = mcpclient.OAuthFlow(ctx, oauthCfg, nil)
+	if err != nil {
+		return "", fmt.Errorf("oauth: %w", err)
+	}
+	if err := mcpclient.SaveToken(path, tok); err != nil {
+		return "", fmt.Errorf("save token: %w", err)
+	}
+	return tok.AccessToken, nil
 }
@@ -1,6 +1,7 @@
 package clickup
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -9,7 +10,7 @@ import (
 
 // MCPCaller is the subset of mcpclient.Client that Importer needs.
 type MCPCaller interface {
-	CallTool(name string, args map[string]interface{}) (*mcpclient.ToolResult, error)
+	CallTool(ctx context.Context, name string, args map[string]interface{}) (*mcpclient.ToolResult, error)
 	FindTool(substring string) (mcpclient.Tool, bool)
 }
 
@@ -24,13 +25,13 @@ func NewImporter(client MCPCaller) *Importer {
 }
 
 // Search finds ClickUp tasks matching the query.
-func (im *Importer) Search(query string) ([]SearchResult, error) {
+func (im *Importer) Search(ctx context.Context, query string) ([]SearchResult, error) {
 	tool, found := im.client.FindTool("search")
 	if !found {
 		return nil, fmt.Errorf("no search tool found in MCP server")
 	}
 
-	result, err := im.client.CallTool(tool.Name, map[string]interface{}{
+	result, err := im.client.CallTool(ctx, tool.Name, map[string]interface{}{
 		"query": query,
 	})
 	if err != nil {
@@ -58,13 +59,13 @@ func (im *Importer) Search(query string) ([]SearchResult, error) {
 }
 
 // FetchTask gets full details for a ClickUp task by ID.
-func (im *Importer) FetchTask(taskID string) (*Task, error) {
+func (im *Importer) FetchTask(ctx context.Context, taskID string) (*Task, error) {
 	tool, found := im.client.FindTool("get_task")
 	if !found {
 		return nil, fmt.Errorf("no get_task tool found in MCP server")
 	}
 
-	result, err := im.client.CallTool(tool.Name, map[string]interface{}{
+	result, err := im.client.CallTool(ctx, tool.Name, map[string]interface{}{
 		"task_id": taskID,
 	})
 	if err != nil {
@@ -84,6 +85,41 @@ func (im *Importer) FetchTask(taskID string) (*Task, error) {
 	return &task, nil
 }
 
+// UpdateStatus moves a ClickUp task to the named status.
+func (im *Importer) UpdateStatus(ctx context.Context, taskID, status string) error {
+	tool, found := im.client.FindTool("update_task")
+	if !found {
+		return fmt.Errorf("no update_task tool found in MCP server")
+	}
+	return im.write(ctx, tool.Name, map[string]interface{}{
+		"task_id": taskID,
+		"status":  status,
+	})
+}
+
+// Comment posts a comment on a ClickUp task.
+func (im *Importer) Comment(ctx context.Context, taskID, text string) error {
+	tool, found := im.client.FindTool("task_comment")
+	if !found {
+		return fmt.Errorf("no task comment tool found in MCP server")
+	}
+	return im.write(ctx, tool.Name, map[string]interface{}{
+		"task_id":      taskID,
+		"comment_text": text,
+	})
+}
+
+func (im *Importer) write(ctx context.Context, tool string, args map[string]interface{}) error {
+	result, err := im.client.CallTool(ctx, tool, args)
+	if err != nil {
+		return fmt.Errorf("%s: %w", tool, err)
+	}
+	if result.IsError {
+		return fmt.Errorf("%s: %s", tool, extractText(result))
+	}
+	return nil
+}
+
 func extractText(result *mcpclient.ToolResult) string {
 	for _, c := range result.Content {
 		if c.Type == "text" && c.Text != "" {
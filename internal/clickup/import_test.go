@@ -1,6 +1,7 @@
 package clickup_test
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 
@@ -13,11 +14,20 @@ import (
 type stubMCPClient struct {
 	callResults map[string]*mcpclient.ToolResult
 	tools       []mcpclient.Tool
+	args        map[string]map[string]interface{}
+	err         error
 }
 
 func (s *stubMCPClient) ListTools() ([]mcpclient.Tool, error) { return s.tools, nil }
 
-func (s *stubMCPClient) CallTool(name string, args map[string]interface{}) (*mcpclient.ToolResult, error) {
+func (s *stubMCPClient) CallTool(_ context.Context, name string, args map[string]interface{}) (*mcpclient.ToolResult, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.args == nil {
+		s.args = make(map[string]map[string]interface{})
+	}
+	s.args[name] = args
 	if r, ok := s.callResults[name]; ok {
 		return r, nil
 	}
@@ -59,7 +69,7 @@ func TestSearch(t *testing.T) {
 	}
 
 	importer := clickup.NewImporter(stub)
-	results, err := importer.Search("auth")
+	results, err := importer.Search(context.Background(), "auth")
 	require.NoError(t, err)
 	assert.Len(t, results, 1)
 	assert.Equal(t, "abc", results[0].ID)
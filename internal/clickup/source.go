@@ -0,0 +1,128 @@
+package clickup
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/kastheco/kasmos/internal/mcpclient"
+	"github.com/kastheco/kasmos/internal/tracker"
+)
+
+// Source imports ClickUp tasks as a tracker.IssueSource and syncs plan
+// status back to them. It connects to the MCP server on first use.
+type Source struct {
+	dial tracker.MCPDialer
+
+	mu       sync.Mutex
+	importer *Importer
+}
+
+// NewSource creates a Source that connects through dial.
+func NewSource(dial tracker.MCPDialer) *Source {
+	return &Source{dial: dial}
+}
+
+func (s *Source) Name() string { return "clickup" }
+
+func (s *Source) Search(ctx context.Context, query string) ([]tracker.SearchResult, error) {
+	im, err := s.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	found, err := im.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]tracker.SearchResult, len(found))
+	for i, r := range found {
+		results[i] = tracker.SearchResult{ID: r.ID, Title: r.Name, Status: r.Status, Project: r.ListName, URL: r.URL}
+	}
+	return results, nil
+}
+
+func (s *Source) Fetch(ctx context.Context, id string) (*tracker.Issue, error) {
+	im, err := s.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	task, err := im.FetchTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	issue := task.Issue()
+	return &issue, nil
+}
+
+func (s *Source) SetStatus(ctx context.Context, id, status string) error {
+	im, err := s.connect(ctx)
+	if err != nil {
+		return err
+	}
+	return im.UpdateStatus(ctx, id, status)
+}
+
+func (s *Source) Comment(ctx context.Context, id, body string) error {
+	im, err := s.connect(ctx)
+	if err != nil {
+		return err
+	}
+	return im.Comment(ctx, id, body)
+}
+
+func (s *Source) connect(ctx context.Context) (*Importer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.importer == nil {
+		client, err := s.dial(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.importer = NewImporter(sourceCaller{MCPCaller: client, src: s})
+	}
+	return s.importer, nil
+}
+
+// sourceCaller forgets its Source's importer when a call fails so the next
+// call redials: the server may have exited or dropped the session.
+type sourceCaller struct {
+	tracker.MCPCaller
+	src *Source
+}
+
+func (c sourceCaller) CallTool(ctx context.Context, name string, args map[string]interface{}) (*mcpclient.ToolResult, error) {
+	result, err := c.MCPCaller.CallTool(ctx, name, args)
+	if err != nil {
+		c.src.mu.Lock()
+		if c.src.importer != nil && c.src.importer.client == MCPCaller(c) {
+			c.src.importer = nil
+		}
+		c.src.mu.Unlock()
+		if closer, ok := c.MCPCaller.(io.Closer); ok {
+			_ = closer.Close()
+		}
+	}
+	return result, err
+}
+
+// Issue maps the task to a tracker issue.
+func (t Task) Issue() tracker.Issue {
+	issue := tracker.Issue{
+		Source:      "ClickUp",
+		ID:          t.ID,
+		Title:       t.Name,
+		Description: t.Description,
+		Status:      t.Status,
+		Priority:    t.Priority,
+		URL:         t.URL,
+		Project:     t.ListName,
+		ProjectKind: "List",
+	}
+	for _, st := range t.Subtasks {
+		issue.Subtasks = append(issue.Subtasks, tracker.Subtask{Name: st.Name, Status: st.Status})
+	}
+	for _, cf := range t.CustomFields {
+		issue.Fields = append(issue.Fields, tracker.Field{Name: cf.Name, Value: cf.Value})
+	}
+	return issue
+}
@@ -0,0 +1,83 @@
+package clickup_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/kastheco/kasmos/internal/clickup"
+	"github.com/kastheco/kasmos/internal/mcpclient"
+	"github.com/kastheco/kasmos/internal/tracker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSource_SearchAndFetch(t *testing.T) {
+	searchJSON, _ := json.Marshal([]map[string]interface{}{
+		{"id": "abc", "name": "Auth flow", "status": "open", "list_name": "Backend"},
+	})
+	taskJSON, _ := json.Marshal(clickup.Task{
+		ID: "abc", Name: "Auth flow", Status: "open", ListName: "Backend",
+		Subtasks: []clickup.Subtask{{Name: "Login", Status: "done"}},
+	})
+	stub := &stubMCPClient{
+		tools: []mcpclient.Tool{{Name: "clickup_search"}, {Name: "clickup_get_task"}},
+		callResults: map[string]*mcpclient.ToolResult{
+			"clickup_search":   {Content: []mcpclient.ToolContent{{Type: "text", Text: string(searchJSON)}}},
+			"clickup_get_task": {Content: []mcpclient.ToolContent{{Type: "text", Text: string(taskJSON)}}},
+		},
+	}
+	dials := 0
+	src := clickup.NewSource(func(context.Context) (tracker.MCPCaller, error) {
+		dials++
+		return stub, nil
+	})
+	var _ tracker.IssueSource = src
+
+	results, err := src.Search(context.Background(), "auth")
+	require.NoError(t, err)
+	assert.Equal(t, []tracker.SearchResult{{ID: "abc", Title: "Auth flow", Status: "open", Project: "Backend"}}, results)
+
+	issue, err := src.Fetch(context.Background(), "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "ClickUp", issue.Source)
+	assert.Equal(t, "List", issue.ProjectKind)
+	assert.Equal(t, []tracker.Subtask{{Name: "Login", Status: "done"}}, issue.Subtasks)
+	assert.Equal(t, 1, dials, "connects once")
+}
+
+func TestSource_Update(t *testing.T) {
+	stub := &stubMCPClient{
+		tools: []mcpclient.Tool{{Name: "clickup_update_task"}, {Name: "clickup_create_task_comment"}},
+		callResults: map[string]*mcpclient.ToolResult{
+			"clickup_update_task": {IsError: true, Content: []mcpclient.ToolContent{{Type: "text", Text: "Status not found"}}},
+		},
+	}
+	src := clickup.NewSource(func(context.Context) (tracker.MCPCaller, error) { return stub, nil })
+	var _ tracker.IssueUpdater = src
+	ctx := context.Background()
+
+	require.NoError(t, src.Comment(ctx, "abc", "PR opened"))
+	assert.Equal(t, map[string]interface{}{"task_id": "abc", "comment_text": "PR opened"}, stub.args["clickup_create_task_comment"])
+
+	err := src.SetStatus(ctx, "abc", "shipped")
+	assert.ErrorContains(t, err, "Status not found")
+	assert.Equal(t, map[string]interface{}{"task_id": "abc", "status": "shipped"}, stub.args["clickup_update_task"])
+}
+
+func TestSource_RedialsAfterFailedCall(t *testing.T) {
+	stub := &stubMCPClient{tools: []mcpclient.Tool{{Name: "clickup_create_task_comment"}}, err: errors.New("broken pipe")}
+	dials := 0
+	src := clickup.NewSource(func(context.Context) (tracker.MCPCaller, error) {
+		dials++
+		return stub, nil
+	})
+	ctx := context.Background()
+
+	assert.ErrorContains(t, src.Comment(ctx, "abc", "PR opened"), "broken pipe")
+	stub.err = nil
+	require.NoError(t, src.Comment(ctx, "abc", "PR opened"))
+	require.NoError(t, src.Comment(ctx, "abc", "merged"))
+	assert.Equal(t, 2, dials, "redials once after the failure, then reuses the connection")
+}
@@ -1,13 +1,9 @@
 package clickup
 
+import "github.com/kastheco/kasmos/internal/mcpclient"
+
 // MCPServerConfig holds the detected ClickUp MCP server configuration.
-type MCPServerConfig struct {
-	Type    string            // "http" or "stdio"
-	URL     string            // for http type
-	Command string            // for stdio type
-	Args    []string          // for stdio type
-	Env     map[string]string // for stdio type
-}
+type MCPServerConfig = mcpclient.ServerConfig
 
 // SearchResult is a ClickUp task from search results.
 type SearchResult struct {
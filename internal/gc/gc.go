@@ -0,0 +1,399 @@
+// Package gc finds and removes leftovers of finished work: plan worktrees no
+// instance uses, merged branches of done or cancelled plans, and kas_ tmux
+// sessions no saved instance owns.
+package gc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kastheco/kasmos/cmd"
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/session"
+	"github.com/kastheco/kasmos/session/git"
+	"github.com/kastheco/kasmos/session/tmux"
+)
+
+// Kind is the type of a garbage item.
+type Kind string
+
+const (
+	KindWorktree Kind = "worktree"
+	KindBranch   Kind = "branch"
+	KindSession  Kind = "session"
+)
+
+// Item is one orphaned worktree, branch or tmux session.
+type Item struct {
+	Kind Kind
+	// Name is the worktree path, branch name or tmux session name.
+	Name string
+	// Reason says why the item is considered garbage.
+	Reason string
+	// Bytes is the disk usage of a worktree; 0 for branches and sessions.
+	Bytes int64
+	// Modified is when the item was last touched: worktree mtime, branch
+	// tip commit time or session creation time.
+	Modified time.Time
+	// Dirty marks a worktree with uncommitted changes; removing it loses them.
+	Dirty bool
+}
+
+// Inputs is everything Find cross-references against git.
+type Inputs struct {
+	// Instances are the saved instances (raw, so nothing is restarted).
+	Instances []session.InstanceData
+	// Plans are the plan store entries of the repo, keyed by filename.
+	Plans map[string]planstate.PlanEntry
+	// Sessions are the kas_ tmux sessions, with Managed set for sessions
+	// that belong to a saved instance.
+	Sessions []tmux.SessionInfo
+}
+
+// Find returns the garbage in repoPath: kasmos worktrees no instance or
+// active plan uses, merged branches of done or cancelled plans, and tmux
+// sessions without an instance. Worktrees come first so their branches can
+// be deleted afterwards.
+func Find(repoPath string, in Inputs) ([]Item, error) {
+	usedPaths := make(map[string]bool)
+	usedBranches := make(map[string]bool)
+	for _, inst := range in.Instances {
+		if inst.Worktree.WorktreePath != "" {
+			usedPaths[filepath.Clean(inst.Worktree.WorktreePath)] = true
+		}
+		if inst.Worktree.BranchName != "" {
+			usedBranches[inst.Worktree.BranchName] = true
+		}
+	}
+	finished := make(map[string]string) // branch → plan status
+	for _, p := range in.Plans {
+		if p.Branch == "" {
+			continue
+		}
+		if p.Status == planstate.StatusDone || p.Status == planstate.StatusCancelled {
+			finished[p.Branch] = string(p.Status)
+			continue
+		}
+		// Active plans keep their shared worktree and branch.
+		usedPaths[filepath.Clean(git.PlanWorktreePath(repoPath, p.Branch))] = true
+		usedBranches[p.Branch] = true
+	}
+
+	worktrees, err := listWorktrees(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	managedDir := filepath.Join(repoPath, ".worktrees") + string(filepath.Separator)
+	checkedOut := make(map[string]bool)
+	var items []Item
+	for _, wt := range worktrees {
+		path := filepath.Clean(wt.path)
+		if !strings.HasPrefix(path+string(filepath.Separator), managedDir) || usedPaths[path] {
+			if wt.branch != "" {
+				checkedOut[wt.branch] = true
+			}
+			continue
+		}
+		item := Item{Kind: KindWorktree, Name: path}
+		switch status, ok := finished[wt.branch]; {
+		case wt.prunable:
+			item.Reason = "missing on disk"
+		case ok:
+			item.Reason = "plan is " + status
+		default:
+			item.Reason = "no instance or active plan"
+		}
+		if info, err := os.Stat(path); err == nil {
+			item.Modified = info.ModTime()
+			item.Bytes = diskUsage(path)
+			item.Dirty = isDirty(path)
+		}
+		items = append(items, item)
+	}
+
+	merged, err := mergedBranches(repoPath, git.DefaultBranch(repoPath))
+	if err != nil {
+		return nil, err
+	}
+	var branches []string
+	for branch := range finished {
+		if _, ok := merged[branch]; ok && !usedBranches[branch] && !checkedOut[branch] {
+			branches = append(branches, branch)
+		}
+	}
+	sort.Strings(branches)
+	for _, branch := range branches {
+		items = append(items, Item{
+			Kind:     KindBranch,
+			Name:     branch,
+			Reason:   fmt.Sprintf("plan is %s and branch is merged", finished[branch]),
+			Modified: merged[branch],
+		})
+	}
+
+	for _, s := range in.Sessions {
+		if s.Managed || s.Attached {
+			continue
+		}
+		items = append(items, Item{Kind: KindSession, Name: s.Name, Reason: "no saved instance", Modified: s.Created})
+	}
+	return items, nil
+}
+
+// Options configures Run.
+type Options struct {
+	// RepoPath is the repository to collect in.
+	RepoPath string
+	// DryRun only reports what would be removed.
+	DryRun bool
+	// OlderThan limits collection to items untouched for at least this long.
+	OlderThan time.Duration
+	// Force removes worktrees with uncommitted changes too.
+	Force bool
+	Out   io.Writer
+}
+
+// Run finds the garbage in opts.RepoPath, reports it and, unless DryRun is
+// set, removes it.
+func Run(opts Options) error {
+	in, err := loadInputs(opts.RepoPath)
+	if err != nil {
+		return err
+	}
+	items, err := Find(opts.RepoPath, in)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	items = OlderThan(items, opts.OlderThan, now)
+	Print(opts.Out, items, now)
+	if opts.DryRun || len(items) == 0 {
+		return nil
+	}
+
+	e := cmd.MakeExecutor()
+	var failed int
+	for _, item := range items {
+		if err := Remove(opts.RepoPath, item, opts.Force, e); err != nil {
+			fmt.Fprintf(opts.Out, "skipped %s %s: %v\n", item.Kind, item.Name, err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(opts.Out, "removed %s %s\n", item.Kind, item.Name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d item(s) could not be removed", failed)
+	}
+	return nil
+}
+
+// loadInputs gathers the saved instances of repoPath, its plans and the kas_
+// tmux sessions. repoPath must be the repository's top-level directory.
+func loadInputs(repoPath string) (Inputs, error) {
+	var in Inputs
+	var all []session.InstanceData
+	if raw := config.LoadState().GetInstances(); len(raw) > 0 {
+		if err := json.Unmarshal(raw, &all); err != nil {
+			return in, fmt.Errorf("load instances: %w", err)
+		}
+	}
+	// Sessions are matched against every saved instance, not just this
+	// repo's: another repo's live session is not garbage.
+	known := make([]string, 0, len(all))
+	for _, inst := range all {
+		known = append(known, tmux.ToKasTmuxNamePublic(inst.Title))
+		if inst.Worktree.RepoPath == "" || filepath.Clean(inst.Worktree.RepoPath) == repoPath {
+			in.Instances = append(in.Instances, inst)
+		}
+	}
+
+	// Plans live in the store whether or not docs/plans exists on disk. An
+	// unreachable store is an error: treating it as "no plans" would report
+	// every active plan's worktree as an orphan.
+	ps, err := cmd.LoadPlanStateStrict(filepath.Join(repoPath, "docs", "plans"))
+	if err != nil {
+		return in, fmt.Errorf("load plans: %w", err)
+	}
+	in.Plans = ps.Plans
+
+	sessions, err := tmux.DiscoverAll(cmd.MakeExecutor(), known)
+	if err != nil {
+		return in, err
+	}
+	in.Sessions = sessions
+	return in, nil
+}
+
+// OlderThan returns the items last modified before now-age. Items with an
+// unknown modification time are kept.
+func OlderThan(items []Item, age time.Duration, now time.Time) []Item {
+	if age <= 0 {
+		return items
+	}
+	cutoff := now.Add(-age)
+	var kept []Item
+	for _, item := range items {
+		if item.Modified.IsZero() || item.Modified.Before(cutoff) {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// Remove deletes one item. Dirty worktrees are refused unless force is set.
+func Remove(repoPath string, item Item, force bool, e cmd.Executor) error {
+	switch item.Kind {
+	case KindWorktree:
+		if item.Dirty && !force {
+			return fmt.Errorf("worktree has uncommitted changes (use --force)")
+		}
+		if out, err := exec.Command("git", "-C", repoPath, "worktree", "remove", "-f", item.Name).CombinedOutput(); err != nil {
+			if _, statErr := os.Stat(item.Name); statErr == nil {
+				return fmt.Errorf("git worktree remove: %s (%w)", strings.TrimSpace(string(out)), err)
+			}
+		}
+		return exec.Command("git", "-C", repoPath, "worktree", "prune").Run()
+	case KindBranch:
+		if out, err := exec.Command("git", "-C", repoPath, "branch", "-d", item.Name).CombinedOutput(); err != nil {
+			return fmt.Errorf("git branch -d: %s (%w)", strings.TrimSpace(string(out)), err)
+		}
+		return nil
+	case KindSession:
+		return e.Run(exec.Command("tmux", "kill-session", "-t", item.Name))
+	}
+	return fmt.Errorf("unknown item kind %q", item.Kind)
+}
+
+// Print writes a table of items and their total disk usage to w.
+func Print(w io.Writer, items []Item, now time.Time) {
+	if len(items) == 0 {
+		fmt.Fprintln(w, "nothing to collect")
+		return
+	}
+	var total int64
+	for _, item := range items {
+		size, age := "-", "-"
+		if item.Bytes > 0 {
+			size = FormatBytes(item.Bytes)
+		}
+		if !item.Modified.IsZero() {
+			age = formatAge(now.Sub(item.Modified))
+		}
+		reason := item.Reason
+		if item.Dirty {
+			reason += ", uncommitted changes"
+		}
+		fmt.Fprintf(w, "%-8s %8s %5s  %s (%s)\n", item.Kind, size, age, item.Name, reason)
+		total += item.Bytes
+	}
+	fmt.Fprintf(w, "%d item(s), %s\n", len(items), FormatBytes(total))
+}
+
+// FormatBytes renders a byte count with a binary unit.
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func formatAge(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+}
+
+type worktree struct {
+	path     string
+	branch   string
+	prunable bool
+}
+
+// listWorktrees parses `git worktree list --porcelain`.
+func listWorktrees(repoPath string) ([]worktree, error) {
+	out, err := exec.Command("git", "-C", repoPath, "worktree", "list", "--porcelain").Output()
+	if err != nil {
+		return nil, fmt.Errorf("list worktrees: %w", err)
+	}
+	var worktrees []worktree
+	for _, block := range strings.Split(strings.TrimSpace(string(out)), "\n\n") {
+		var wt worktree
+		for _, line := range strings.Split(block, "\n") {
+			switch {
+			case strings.HasPrefix(line, "worktree "):
+				wt.path = strings.TrimPrefix(line, "worktree ")
+			case strings.HasPrefix(line, "branch "):
+				wt.branch = strings.TrimPrefix(strings.TrimPrefix(line, "branch "), "refs/heads/")
+			case strings.HasPrefix(line, "prunable"):
+				wt.prunable = true
+			}
+		}
+		if wt.path != "" {
+			worktrees = append(worktrees, wt)
+		}
+	}
+	return worktrees, nil
+}
+
+// mergedBranches returns the local branches merged into base with the time
+// of their tip commit.
+func mergedBranches(repoPath, base string) (map[string]time.Time, error) {
+	out, err := exec.Command("git", "-C", repoPath, "for-each-ref", "--merged", base,
+		"--format=%(refname:short) %(committerdate:unix)", "refs/heads/").Output()
+	if err != nil {
+		return nil, fmt.Errorf("list merged branches: %w", err)
+	}
+	merged := make(map[string]time.Time)
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		name, epoch, ok := strings.Cut(line, " ")
+		if !ok || name == base {
+			continue
+		}
+		sec, _ := strconv.ParseInt(epoch, 10, 64)
+		merged[name] = time.Unix(sec, 0)
+	}
+	return merged, nil
+}
+
+// diskUsage sums the sizes of the regular files under dir.
+func diskUsage(dir string) int64 {
+	var total int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+func isDirty(dir string) bool {
+	out, err := exec.Command("git", "-C", dir, "status", "--porcelain").Output()
+	return err == nil && len(strings.TrimSpace(string(out))) > 0
+}
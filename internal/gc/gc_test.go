@@ -0,0 +1,126 @@
+package gc
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/session"
+	"github.com/kastheco/kasmos/session/git"
+	"github.com/kastheco/kasmos/session/tmux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func run(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	require.NoErrorf(t, err, "git %v: %s", args, out)
+}
+
+// setupRepo creates a repo on main with a merged done plan branch, an active
+// plan worktree, an orphaned worktree and a worktree used by an instance.
+func setupRepo(t *testing.T) string {
+	t.Helper()
+	repo, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	run(t, repo, "init", "-q", "-b", "main")
+	run(t, repo, "config", "user.email", "test@example.com")
+	run(t, repo, "config", "user.name", "test")
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "README.md"), []byte("hi\n"), 0o644))
+	run(t, repo, "add", ".")
+	run(t, repo, "commit", "-q", "-m", "init")
+
+	run(t, repo, "branch", "plan/done")
+	run(t, repo, "branch", "plan/unmerged")
+	run(t, repo, "worktree", "add", "-q", git.PlanWorktreePath(repo, "plan/unmerged"), "plan/unmerged")
+	require.NoError(t, os.WriteFile(filepath.Join(git.PlanWorktreePath(repo, "plan/unmerged"), "wip.go"), []byte("package wip\n"), 0o644))
+	run(t, git.PlanWorktreePath(repo, "plan/unmerged"), "add", ".")
+	run(t, git.PlanWorktreePath(repo, "plan/unmerged"), "commit", "-q", "-m", "wip")
+
+	run(t, repo, "worktree", "add", "-q", "-b", "plan/active", git.PlanWorktreePath(repo, "plan/active"))
+	run(t, repo, "worktree", "add", "-q", "-b", "kas/orphan", filepath.Join(repo, ".worktrees", "orphan"))
+	run(t, repo, "worktree", "add", "-q", "-b", "kas/used", filepath.Join(repo, ".worktrees", "used"))
+	return repo
+}
+
+func TestFind(t *testing.T) {
+	repo := setupRepo(t)
+	in := Inputs{
+		Instances: []session.InstanceData{{
+			Title:    "used",
+			Worktree: session.GitWorktreeData{WorktreePath: filepath.Join(repo, ".worktrees", "used"), BranchName: "kas/used"},
+		}},
+		Plans: map[string]planstate.PlanEntry{
+			"2026-03-01-done.md":     {Status: planstate.StatusDone, Branch: "plan/done"},
+			"2026-03-02-active.md":   {Status: planstate.StatusImplementing, Branch: "plan/active"},
+			"2026-03-03-unmerged.md": {Status: planstate.StatusCancelled, Branch: "plan/unmerged"},
+		},
+		Sessions: []tmux.SessionInfo{
+			{Name: "kas_used", Managed: true},
+			{Name: "kas_stale", Created: time.Now().Add(-48 * time.Hour)},
+			{Name: "kas_watched", Attached: true},
+		},
+	}
+
+	items, err := Find(repo, in)
+	require.NoError(t, err)
+
+	var names []string
+	for _, item := range items {
+		names = append(names, string(item.Kind)+" "+strings.TrimPrefix(item.Name, repo+"/"))
+	}
+	assert.Equal(t, []string{
+		"worktree .worktrees/orphan",
+		"worktree .worktrees/plan-unmerged",
+		"branch plan/done",
+		"session kas_stale",
+	}, names)
+	assert.Equal(t, "no instance or active plan", items[0].Reason)
+	assert.Equal(t, "plan is cancelled", items[1].Reason)
+	assert.Greater(t, items[1].Bytes, int64(0))
+
+	// --older-than keeps only the two-day-old session and items of unknown age.
+	old := OlderThan(items, 24*time.Hour, time.Now())
+	require.Len(t, old, 1)
+	assert.Equal(t, "kas_stale", old[0].Name)
+}
+
+func TestRemove(t *testing.T) {
+	repo := setupRepo(t)
+	orphan := filepath.Join(repo, ".worktrees", "orphan")
+	require.NoError(t, os.WriteFile(filepath.Join(orphan, "scratch.txt"), []byte("wip\n"), 0o644))
+
+	dirty := Item{Kind: KindWorktree, Name: orphan, Dirty: true}
+	require.Error(t, Remove(repo, dirty, false, nil), "dirty worktrees need --force")
+	assert.DirExists(t, orphan)
+
+	require.NoError(t, Remove(repo, dirty, true, nil))
+	assert.NoDirExists(t, orphan)
+
+	require.NoError(t, Remove(repo, Item{Kind: KindBranch, Name: "plan/done"}, false, nil))
+	out, err := exec.Command("git", "-C", repo, "branch", "--list", "plan/done").Output()
+	require.NoError(t, err)
+	assert.Empty(t, strings.TrimSpace(string(out)))
+}
+
+func TestPrint(t *testing.T) {
+	now := time.Now()
+	var buf bytes.Buffer
+	Print(&buf, []Item{
+		{Kind: KindWorktree, Name: "/r/.worktrees/a", Reason: "plan is done", Bytes: 3 << 20, Modified: now.Add(-50 * time.Hour), Dirty: true},
+		{Kind: KindSession, Name: "kas_a", Reason: "no saved instance"},
+	}, now)
+	assert.Equal(t, "worktree   3.0MiB    2d  /r/.worktrees/a (plan is done, uncommitted changes)\n"+
+		"session         -     -  kas_a (no saved instance)\n"+
+		"2 item(s), 3.0MiB\n", buf.String())
+
+	buf.Reset()
+	Print(&buf, nil, now)
+	assert.Equal(t, "nothing to collect\n", buf.String())
+}
@@ -0,0 +1,121 @@
+package scaffold
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// mcpServerName is the name `kas mcp` is registered under in each harness.
+const mcpServerName = "kasmos"
+
+// mcpCommand launches kasmos's MCP server; agents inherit KASMOS_* from
+// their session, which is how the server knows who is calling.
+var mcpCommand = []string{"kas", "mcp"}
+
+// updateJSONFile applies update to the JSON object in path (an empty object
+// when the file does not exist) and writes it back when update reports a
+// change. Other keys are preserved, so user edits survive re-scaffolding.
+func updateJSONFile(dir, path string, update func(obj map[string]any) bool) (WriteResult, error) {
+	rel, relErr := filepath.Rel(dir, path)
+	if relErr != nil {
+		rel = path
+	}
+	obj := map[string]any{}
+	if data, err := os.ReadFile(path); err == nil {
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return WriteResult{}, fmt.Errorf("parse %s: %w", rel, err)
+		}
+	}
+	if !update(obj) {
+		return WriteResult{Path: rel}, nil
+	}
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return WriteResult{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return WriteResult{}, err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return WriteResult{}, err
+	}
+	return WriteResult{Path: rel, Created: true}, nil
+}
+
+// childObject returns obj[key] as an object, adding an empty one if absent.
+func childObject(obj map[string]any, key string) map[string]any {
+	child, ok := obj[key].(map[string]any)
+	if !ok {
+		child = map[string]any{}
+		obj[key] = child
+	}
+	return child
+}
+
+// writeClaudeMCPConfig registers `kas mcp` in the project's .mcp.json and
+// approves it in .claude/settings.json, so Claude starts it without asking.
+// An existing kasmos entry is only replaced with force.
+func writeClaudeMCPConfig(dir string, force bool) ([]WriteResult, error) {
+	mcpResult, err := updateJSONFile(dir, filepath.Join(dir, ".mcp.json"), func(obj map[string]any) bool {
+		servers := childObject(obj, "mcpServers")
+		if _, ok := servers[mcpServerName]; ok && !force {
+			return false
+		}
+		servers[mcpServerName] = map[string]any{
+			"type":    "stdio",
+			"command": mcpCommand[0],
+			"args":    mcpCommand[1:],
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("write .mcp.json: %w", err)
+	}
+
+	settingsResult, err := updateJSONFile(dir, filepath.Join(dir, ".claude", "settings.json"), func(obj map[string]any) bool {
+		enabled, _ := obj["enabledMcpjsonServers"].([]any)
+		for _, name := range enabled {
+			if name == mcpServerName {
+				return false
+			}
+		}
+		obj["enabledMcpjsonServers"] = append(enabled, mcpServerName)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("write .claude/settings.json: %w", err)
+	}
+	return []WriteResult{mcpResult, settingsResult}, nil
+}
+
+// codexMCPConfig is the .codex/config.toml table registering `kas mcp`.
+var codexMCPConfig = fmt.Sprintf("[mcp_servers.%s]\ncommand = %q\nargs = [%q]\n", mcpServerName, mcpCommand[0], mcpCommand[1])
+
+// writeCodexMCPConfig registers `kas mcp` in .codex/config.toml, appending
+// to an existing config that does not have it yet.
+func writeCodexMCPConfig(dir string) (WriteResult, error) {
+	path := filepath.Join(dir, ".codex", "config.toml")
+	rel, relErr := filepath.Rel(dir, path)
+	if relErr != nil {
+		rel = path
+	}
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return WriteResult{}, err
+	}
+	if strings.Contains(string(existing), "[mcp_servers."+mcpServerName+"]") {
+		return WriteResult{Path: rel}, nil
+	}
+	content := string(existing)
+	if content != "" {
+		content = strings.TrimRight(content, "\n") + "\n\n"
+	}
+	content += codexMCPConfig
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return WriteResult{}, fmt.Errorf("write .codex/config.toml: %w", err)
+	}
+	return WriteResult{Path: rel, Created: true}, nil
+}
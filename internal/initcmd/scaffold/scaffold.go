@@ -91,7 +91,13 @@ func WriteClaudeProject(dir string, agents []harness.AgentConfig, selectedTools
 	if err != nil {
 		return nil, err
 	}
-	return append(results, staticResults...), nil
+	results = append(results, staticResults...)
+
+	mcpResults, err := writeClaudeMCPConfig(dir, force)
+	if err != nil {
+		return nil, err
+	}
+	return append(results, mcpResults...), nil
 }
 
 // renderOpenCodeConfig reads the embedded opencode.jsonc template and substitutes
@@ -349,7 +355,12 @@ func WriteCodexProject(dir string, agents []harness.AgentConfig, selectedTools [
 	if relErr != nil {
 		rel = dest
 	}
-	return []WriteResult{{Path: rel, Created: written}}, nil
+
+	mcpResult, err := writeCodexMCPConfig(dir)
+	if err != nil {
+		return nil, err
+	}
+	return []WriteResult{{Path: rel, Created: written}, mcpResult}, nil
 }
 
 // WriteProjectSkills writes embedded skill trees to <dir>/.agents/skills/.
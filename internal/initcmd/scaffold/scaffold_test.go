@@ -677,3 +677,74 @@ func TestScaffold_IncludesFixerAgent(t *testing.T) {
 }
 
 func ptrFloat(f float64) *float64 { return &f }
+
+func TestWriteClaudeProject_RegistersMCPServer(t *testing.T) {
+	dir := t.TempDir()
+	// An existing .mcp.json keeps its other servers.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".mcp.json"),
+		[]byte(`{"mcpServers":{"linear":{"type":"http","url":"https://mcp.linear.app/mcp"}}}`), 0o644))
+	agents := []harness.AgentConfig{{Role: "coder", Harness: "claude", Enabled: true}}
+
+	_, err := WriteClaudeProject(dir, agents, allTools, false)
+	require.NoError(t, err)
+
+	var mcp struct {
+		MCPServers map[string]struct {
+			Command string   `json:"command"`
+			Args    []string `json:"args"`
+			URL     string   `json:"url"`
+		} `json:"mcpServers"`
+	}
+	data, err := os.ReadFile(filepath.Join(dir, ".mcp.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &mcp))
+	assert.Equal(t, "kas", mcp.MCPServers["kasmos"].Command)
+	assert.Equal(t, []string{"mcp"}, mcp.MCPServers["kasmos"].Args)
+	assert.Equal(t, "https://mcp.linear.app/mcp", mcp.MCPServers["linear"].URL)
+
+	var settings struct {
+		Enabled []string `json:"enabledMcpjsonServers"`
+	}
+	data, err = os.ReadFile(filepath.Join(dir, ".claude", "settings.json"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &settings))
+	assert.Equal(t, []string{"kasmos"}, settings.Enabled)
+
+	// Re-scaffolding leaves both files alone.
+	results, err := WriteClaudeProject(dir, agents, allTools, false)
+	require.NoError(t, err)
+	for _, r := range results {
+		if r.Path == ".mcp.json" || r.Path == filepath.Join(".claude", "settings.json") {
+			assert.False(t, r.Created, r.Path)
+		}
+	}
+}
+
+func TestWriteOpenCodeProject_RegistersMCPServer(t *testing.T) {
+	dir := t.TempDir()
+	agents := []harness.AgentConfig{{Role: "coder", Harness: "opencode", Model: "anthropic/claude-sonnet-4-6", Enabled: true}}
+	_, err := WriteOpenCodeProject(dir, agents, nil, false)
+	require.NoError(t, err)
+
+	content, err := os.ReadFile(filepath.Join(dir, ".opencode", "opencode.jsonc"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `"command": ["kas", "mcp"]`)
+}
+
+func TestWriteCodexMCPConfig_AppendsOnce(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".codex"), 0o755))
+	path := filepath.Join(dir, ".codex", "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte("model = \"gpt-5.3-codex\"\n"), 0o644))
+
+	result, err := writeCodexMCPConfig(dir)
+	require.NoError(t, err)
+	assert.True(t, result.Created)
+	result, err = writeCodexMCPConfig(dir)
+	require.NoError(t, err)
+	assert.False(t, result.Created)
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "model = \"gpt-5.3-codex\"\n\n[mcp_servers.kasmos]\ncommand = \"kas\"\nargs = [\"mcp\"]\n", string(content))
+}
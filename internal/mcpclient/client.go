@@ -1,18 +1,44 @@
 package mcpclient
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
+	"time"
 )
 
-// Client is a minimal MCP client that supports initialize, tools/list, and tools/call.
+// ProtocolVersion is the MCP revision kasmos requests at initialize.
+const ProtocolVersion = "2025-06-18"
+
+// supportedVersions are the revisions kasmos accepts from a server.
+var supportedVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// DefaultTimeout bounds requests whose context has no deadline.
+const DefaultTimeout = 60 * time.Second
+
+// Client is an MCP client: it performs the initialize handshake, lists and
+// calls tools, reads resources and prompts, and handles the notifications
+// and requests a server sends on its own.
 type Client struct {
 	transport Transport
-	nextID    int
-	mu        sync.Mutex
-	tools     []Tool // cached after ListTools
+	// Timeout bounds requests whose context has no deadline; zero disables it.
+	Timeout time.Duration
+
+	nextID int
+	mu     sync.Mutex
+	tools  []Tool // cached after ListTools
+
+	serverInfo      ServerInfo
+	capabilities    ServerCapabilities
+	protocolVersion string
+
+	// progress maps the progress token of a running request to its callback.
+	progress      map[int]ProgressFunc
+	notifications map[string][]func(params json.RawMessage)
 }
 
 // NewClient creates a Client with the given transport.
@@ -20,63 +46,229 @@ func NewClient(t Transport) (*Client, error) {
 	if t == nil {
 		return nil, fmt.Errorf("transport required")
 	}
-	return &Client{transport: t, nextID: 1}, nil
+	c := &Client{
+		transport:     t,
+		Timeout:       DefaultTimeout,
+		nextID:        1,
+		progress:      make(map[int]ProgressFunc),
+		notifications: make(map[string][]func(json.RawMessage)),
+	}
+	t.SetHandler(c.handle)
+	return c, nil
 }
 
-// Initialize sends the MCP initialize handshake.
+// Initialize sends the MCP initialize handshake and, once the server agrees
+// on a protocol version, the initialized notification.
 func (c *Client) Initialize() error {
-	resp, err := c.call("initialize", map[string]any{
-		"protocolVersion": "2024-11-05",
-		"capabilities":    map[string]any{},
+	return c.InitializeContext(context.Background())
+}
+
+// InitializeContext is Initialize bounded by ctx.
+func (c *Client) InitializeContext(ctx context.Context) error {
+	result, err := c.request(ctx, "initialize", map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{"roots": map[string]any{}},
 		"clientInfo":      map[string]string{"name": "kasmos", "version": "0.1.0"},
 	})
 	if err != nil {
 		return fmt.Errorf("initialize: %w", err)
 	}
-	if resp.Error != nil {
-		return resp.Error
+	var init struct {
+		ProtocolVersion string             `json:"protocolVersion"`
+		Capabilities    ServerCapabilities `json:"capabilities"`
+		ServerInfo      ServerInfo         `json:"serverInfo"`
+	}
+	if err := json.Unmarshal(result, &init); err != nil {
+		return fmt.Errorf("parse initialize result: %w", err)
+	}
+	if init.ProtocolVersion == "" {
+		// Servers predating version negotiation omit it.
+		init.ProtocolVersion = supportedVersions[len(supportedVersions)-1]
+	}
+	if !slices.Contains(supportedVersions, init.ProtocolVersion) {
+		return fmt.Errorf("initialize: unsupported protocol version %q", init.ProtocolVersion)
+	}
+	c.mu.Lock()
+	c.serverInfo = init.ServerInfo
+	c.capabilities = init.Capabilities
+	c.protocolVersion = init.ProtocolVersion
+	c.mu.Unlock()
+
+	if err := c.transport.Notify(ctx, JSONRPCNotification{JSONRPC: "2.0", Method: "notifications/initialized"}); err != nil {
+		return fmt.Errorf("initialized notification: %w", err)
 	}
 	return nil
 }
 
-// ListTools returns available tools from the server.
+// ServerInfo returns the server's name and version from initialize.
+func (c *Client) ServerInfo() ServerInfo {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.serverInfo
+}
+
+// Capabilities returns the features the server declared at initialize.
+func (c *Client) Capabilities() ServerCapabilities {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.capabilities
+}
+
+// ProtocolVersion returns the protocol revision agreed at initialize.
+func (c *Client) ProtocolVersion() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.protocolVersion
+}
+
+// ListTools returns available tools from the server, following pagination
+// cursors, and caches them for FindTool.
 func (c *Client) ListTools() ([]Tool, error) {
-	resp, err := c.call("tools/list", nil)
+	return c.ListToolsContext(context.Background())
+}
+
+// ListToolsContext is ListTools bounded by ctx.
+func (c *Client) ListToolsContext(ctx context.Context) ([]Tool, error) {
+	var tools []Tool
+	err := c.paginate(ctx, "tools/list", func(result json.RawMessage) error {
+		var page struct {
+			Tools []Tool `json:"tools"`
+		}
+		if err := json.Unmarshal(result, &page); err != nil {
+			return fmt.Errorf("parse tools: %w", err)
+		}
+		tools = append(tools, page.Tools...)
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("tools/list: %w", err)
-	}
-	if resp.Error != nil {
-		return nil, resp.Error
-	}
-	var result struct {
-		Tools []Tool `json:"tools"`
-	}
-	if err := json.Unmarshal(resp.Result, &result); err != nil {
-		return nil, fmt.Errorf("parse tools: %w", err)
+		return nil, err
 	}
 	c.mu.Lock()
-	c.tools = result.Tools
+	c.tools = tools
 	c.mu.Unlock()
-	return result.Tools, nil
+	return tools, nil
 }
 
 // CallTool invokes a tool by name with the given arguments.
 func (c *Client) CallTool(name string, args map[string]any) (*ToolResult, error) {
-	resp, err := c.call("tools/call", map[string]any{
-		"name":      name,
-		"arguments": args,
-	})
+	return c.CallToolContext(context.Background(), name, args, nil)
+}
+
+// CallToolContext invokes a tool, bounded by ctx. onProgress, when set,
+// receives the progress notifications the server sends for the call.
+func (c *Client) CallToolContext(ctx context.Context, name string, args map[string]any, onProgress ProgressFunc) (*ToolResult, error) {
+	params := map[string]any{"name": name, "arguments": args}
+	if onProgress != nil {
+		token := c.watchProgress(onProgress)
+		defer c.unwatchProgress(token)
+		params["_meta"] = map[string]any{"progressToken": token}
+	}
+	result, err := c.request(ctx, "tools/call", params)
 	if err != nil {
 		return nil, fmt.Errorf("tools/call %s: %w", name, err)
 	}
-	if resp.Error != nil {
-		return nil, resp.Error
-	}
-	var result ToolResult
-	if err := json.Unmarshal(resp.Result, &result); err != nil {
+	var out ToolResult
+	if err := json.Unmarshal(result, &out); err != nil {
 		return nil, fmt.Errorf("parse tool result: %w", err)
 	}
-	return &result, nil
+	return &out, nil
+}
+
+// ListResources returns the resources the server offers.
+func (c *Client) ListResources(ctx context.Context) ([]Resource, error) {
+	var resources []Resource
+	err := c.paginate(ctx, "resources/list", func(result json.RawMessage) error {
+		var page struct {
+			Resources []Resource `json:"resources"`
+		}
+		if err := json.Unmarshal(result, &page); err != nil {
+			return fmt.Errorf("parse resources: %w", err)
+		}
+		resources = append(resources, page.Resources...)
+		return nil
+	})
+	return resources, err
+}
+
+// ListResourceTemplates returns the resource templates the server offers.
+func (c *Client) ListResourceTemplates(ctx context.Context) ([]ResourceTemplate, error) {
+	var templates []ResourceTemplate
+	err := c.paginate(ctx, "resources/templates/list", func(result json.RawMessage) error {
+		var page struct {
+			ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
+		}
+		if err := json.Unmarshal(result, &page); err != nil {
+			return fmt.Errorf("parse resource templates: %w", err)
+		}
+		templates = append(templates, page.ResourceTemplates...)
+		return nil
+	})
+	return templates, err
+}
+
+// ReadResource returns the contents of the resource at uri.
+func (c *Client) ReadResource(ctx context.Context, uri string) ([]ResourceContents, error) {
+	result, err := c.request(ctx, "resources/read", map[string]any{"uri": uri})
+	if err != nil {
+		return nil, fmt.Errorf("resources/read %s: %w", uri, err)
+	}
+	var out struct {
+		Contents []ResourceContents `json:"contents"`
+	}
+	if err := json.Unmarshal(result, &out); err != nil {
+		return nil, fmt.Errorf("parse resource: %w", err)
+	}
+	return out.Contents, nil
+}
+
+// ListPrompts returns the prompt templates the server offers.
+func (c *Client) ListPrompts(ctx context.Context) ([]Prompt, error) {
+	var prompts []Prompt
+	err := c.paginate(ctx, "prompts/list", func(result json.RawMessage) error {
+		var page struct {
+			Prompts []Prompt `json:"prompts"`
+		}
+		if err := json.Unmarshal(result, &page); err != nil {
+			return fmt.Errorf("parse prompts: %w", err)
+		}
+		prompts = append(prompts, page.Prompts...)
+		return nil
+	})
+	return prompts, err
+}
+
+// GetPrompt renders the prompt name with args.
+func (c *Client) GetPrompt(ctx context.Context, name string, args map[string]string) (*PromptResult, error) {
+	params := map[string]any{"name": name}
+	if len(args) > 0 {
+		params["arguments"] = args
+	}
+	result, err := c.request(ctx, "prompts/get", params)
+	if err != nil {
+		return nil, fmt.Errorf("prompts/get %s: %w", name, err)
+	}
+	var out PromptResult
+	if err := json.Unmarshal(result, &out); err != nil {
+		return nil, fmt.Errorf("parse prompt: %w", err)
+	}
+	return &out, nil
+}
+
+// Ping checks that the server is responsive.
+func (c *Client) Ping(ctx context.Context) error {
+	if _, err := c.request(ctx, "ping", nil); err != nil {
+		return fmt.Errorf("ping: %w", err)
+	}
+	return nil
+}
+
+// OnNotification registers fn for server notifications of method, such as
+// notifications/resources/updated or notifications/message. fn runs on the
+// transport's read loop, so it must not block or send requests.
+func (c *Client) OnNotification(method string, fn func(params json.RawMessage)) {
+	c.mu.Lock()
+	c.notifications[method] = append(c.notifications[method], fn)
+	c.mu.Unlock()
 }
 
 // FindTool returns the first tool whose name contains the given substring.
@@ -96,15 +288,145 @@ func (c *Client) Close() error {
 	return c.transport.Close()
 }
 
-func (c *Client) call(method string, params any) (JSONRPCResponse, error) {
+// request sends method and returns its result. A server error is returned
+// as the *JSONRPCError. When ctx ends first, the server is told to cancel
+// the request. When the server has dropped our session, the client
+// initializes again and retries once.
+func (c *Client) request(ctx context.Context, method string, params any) (json.RawMessage, error) {
+	if _, ok := ctx.Deadline(); !ok && c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	result, err := c.send(ctx, method, params)
+	if errors.Is(err, errSessionExpired) && method != "initialize" {
+		if err := c.InitializeContext(ctx); err != nil {
+			return nil, err
+		}
+		result, err = c.send(ctx, method, params)
+	}
+	return result, err
+}
+
+// send sends one request for method and waits for its response.
+func (c *Client) send(ctx context.Context, method string, params any) (json.RawMessage, error) {
 	c.mu.Lock()
 	id := c.nextID
 	c.nextID++
 	c.mu.Unlock()
-	return c.transport.Send(JSONRPCRequest{
+
+	resp, err := c.transport.Send(ctx, JSONRPCRequest{
 		JSONRPC: "2.0",
 		ID:      id,
 		Method:  method,
 		Params:  params,
 	})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil && method != "initialize" {
+			c.cancel(id, ctxErr)
+			return nil, fmt.Errorf("request cancelled: %w", ctxErr)
+		}
+		return nil, err
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return resp.Result, nil
+}
+
+// cancel tells the server to stop working on request id. The spec forbids
+// cancelling initialize.
+func (c *Client) cancel(id int, cause error) {
+	reason := "cancelled"
+	if errors.Is(cause, context.DeadlineExceeded) {
+		reason = "timed out"
+	}
+	ctx, done := context.WithTimeout(context.Background(), 5*time.Second)
+	defer done()
+	_ = c.transport.Notify(ctx, JSONRPCNotification{
+		JSONRPC: "2.0",
+		Method:  "notifications/cancelled",
+		Params:  map[string]any{"requestId": id, "reason": reason},
+	})
+}
+
+// paginate requests method page by page, passing each result to page until
+// the server returns no nextCursor.
+func (c *Client) paginate(ctx context.Context, method string, page func(result json.RawMessage) error) error {
+	cursor := ""
+	for {
+		var params any
+		if cursor != "" {
+			params = map[string]any{"cursor": cursor}
+		}
+		result, err := c.request(ctx, method, params)
+		if err != nil {
+			return fmt.Errorf("%s: %w", method, err)
+		}
+		if err := page(result); err != nil {
+			return err
+		}
+		var next struct {
+			NextCursor string `json:"nextCursor"`
+		}
+		_ = json.Unmarshal(result, &next)
+		if next.NextCursor == "" || next.NextCursor == cursor {
+			return nil
+		}
+		cursor = next.NextCursor
+	}
+}
+
+func (c *Client) watchProgress(fn ProgressFunc) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	token := c.nextID
+	c.nextID++
+	c.progress[token] = fn
+	return token
+}
+
+func (c *Client) unwatchProgress(token int) {
+	c.mu.Lock()
+	delete(c.progress, token)
+	c.mu.Unlock()
+}
+
+// handle is the transport Handler: it answers server requests and routes
+// notifications to progress callbacks and OnNotification handlers.
+func (c *Client) handle(method string, params json.RawMessage) (any, *JSONRPCError) {
+	switch method {
+	case "ping":
+		return struct{}{}, nil
+	case "roots/list":
+		return map[string]any{"roots": []any{}}, nil
+	case "notifications/progress":
+		var p struct {
+			ProgressToken json.RawMessage `json:"progressToken"`
+			Progress
+		}
+		var token int
+		if json.Unmarshal(params, &p) == nil && json.Unmarshal(p.ProgressToken, &token) == nil {
+			c.mu.Lock()
+			fn := c.progress[token]
+			c.mu.Unlock()
+			if fn != nil {
+				fn(p.Progress)
+			}
+		}
+	case "notifications/tools/list_changed":
+		// Refresh the FindTool cache off the read loop.
+		go func() { _, _ = c.ListTools() }()
+	}
+
+	c.mu.Lock()
+	fns := c.notifications[method]
+	c.mu.Unlock()
+	if strings.HasPrefix(method, "notifications/") {
+		for _, fn := range fns {
+			fn(params)
+		}
+		return nil, nil
+	}
+	return nil, &JSONRPCError{Code: codeMethodNotFound, Message: "method not found: " + method}
 }
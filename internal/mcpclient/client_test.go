@@ -1,6 +1,7 @@
 package mcpclient_test
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"testing"
@@ -17,11 +18,12 @@ func TestNewClient_RequiresTransport(t *testing.T) {
 
 // mockTransport records calls and returns preconfigured responses.
 type mockTransport struct {
-	responses map[string]mcpclient.JSONRPCResponse
-	closed    bool
+	responses     map[string]mcpclient.JSONRPCResponse
+	notifications []string
+	closed        bool
 }
 
-func (m *mockTransport) Send(req mcpclient.JSONRPCRequest) (mcpclient.JSONRPCResponse, error) {
+func (m *mockTransport) Send(_ context.Context, req mcpclient.JSONRPCRequest) (mcpclient.JSONRPCResponse, error) {
 	if resp, ok := m.responses[req.Method]; ok {
 		resp.ID = req.ID
 		return resp, nil
@@ -29,6 +31,13 @@ func (m *mockTransport) Send(req mcpclient.JSONRPCRequest) (mcpclient.JSONRPCRes
 	return mcpclient.JSONRPCResponse{}, fmt.Errorf("unexpected method: %s", req.Method)
 }
 
+func (m *mockTransport) Notify(_ context.Context, n mcpclient.JSONRPCNotification) error {
+	m.notifications = append(m.notifications, n.Method)
+	return nil
+}
+
+func (m *mockTransport) SetHandler(mcpclient.Handler) {}
+
 func (m *mockTransport) Close() error { m.closed = true; return nil }
 
 func TestClient_Initialize(t *testing.T) {
@@ -38,6 +47,18 @@ func TestClient_Initialize(t *testing.T) {
 	c, err := mcpclient.NewClient(mt)
 	require.NoError(t, err)
 	assert.NoError(t, c.Initialize())
+	assert.Equal(t, "2024-11-05", c.ProtocolVersion())
+	assert.Equal(t, []string{"notifications/initialized"}, mt.notifications)
+}
+
+func TestClient_Initialize_UnsupportedVersion(t *testing.T) {
+	mt := &mockTransport{responses: map[string]mcpclient.JSONRPCResponse{
+		"initialize": {Result: json.RawMessage(`{"protocolVersion":"1999-01-01"}`)},
+	}}
+	c, err := mcpclient.NewClient(mt)
+	require.NoError(t, err)
+	assert.ErrorContains(t, c.Initialize(), "unsupported protocol version")
+	assert.Empty(t, mt.notifications)
 }
 
 func TestClient_Initialize_ServerError(t *testing.T) {
@@ -147,11 +168,17 @@ type idTrackingTransport struct {
 	ids   *[]int
 }
 
-func (t *idTrackingTransport) Send(req mcpclient.JSONRPCRequest) (mcpclient.JSONRPCResponse, error) {
+func (t *idTrackingTransport) Send(ctx context.Context, req mcpclient.JSONRPCRequest) (mcpclient.JSONRPCResponse, error) {
 	*t.ids = append(*t.ids, req.ID)
-	return t.inner.Send(req)
+	return t.inner.Send(ctx, req)
 }
 
+func (t *idTrackingTransport) Notify(ctx context.Context, n mcpclient.JSONRPCNotification) error {
+	return t.inner.Notify(ctx, n)
+}
+
+func (t *idTrackingTransport) SetHandler(h mcpclient.Handler) { t.inner.SetHandler(h) }
+
 func (t *idTrackingTransport) Close() error { return t.inner.Close() }
 
 func TestJSONRPCError_Error(t *testing.T) {
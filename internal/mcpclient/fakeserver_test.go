@@ -0,0 +1,414 @@
+package mcpclient_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kastheco/kasmos/internal/mcpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMsg is any JSON-RPC message the fake server reads.
+type fakeMsg struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+// fakeServer is an in-process MCP server. serve handles one message and
+// writes notifications, requests and the response through send, the way a
+// transport would deliver them.
+type fakeServer struct {
+	mu            sync.Mutex
+	notifications []string
+
+	cancelled chan float64         // request IDs of notifications/cancelled
+	replies   chan json.RawMessage // results of the client's replies to server requests
+}
+
+func newFakeServer() *fakeServer {
+	return &fakeServer{
+		cancelled: make(chan float64, 1),
+		replies:   make(chan json.RawMessage, 1),
+	}
+}
+
+func (f *fakeServer) received() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.notifications...)
+}
+
+func (f *fakeServer) serve(ctx context.Context, m fakeMsg, send func(any)) {
+	if len(m.ID) == 0 {
+		f.mu.Lock()
+		f.notifications = append(f.notifications, m.Method)
+		f.mu.Unlock()
+		if m.Method == "notifications/cancelled" {
+			var p struct {
+				RequestID float64 `json:"requestId"`
+			}
+			_ = json.Unmarshal(m.Params, &p)
+			f.cancelled <- p.RequestID
+		}
+		return
+	}
+	respond := func(result any) {
+		send(map[string]any{"jsonrpc": "2.0", "id": m.ID, "result": result})
+	}
+	var params struct {
+		Name      string            `json:"name"`
+		Cursor    string            `json:"cursor"`
+		URI       string            `json:"uri"`
+		Arguments map[string]string `json:"arguments"`
+		Meta      struct {
+			ProgressToken json.RawMessage `json:"progressToken"`
+		} `json:"_meta"`
+	}
+	_ = json.Unmarshal(m.Params, &params)
+
+	switch m.Method {
+	case "initialize":
+		respond(map[string]any{
+			"protocolVersion": "2025-06-18",
+			"capabilities":    map[string]any{"tools": map[string]any{"listChanged": true}, "resources": map[string]any{}, "prompts": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "fake", "version": "1.0"},
+		})
+	case "ping":
+		respond(map[string]any{})
+	case "tools/list":
+		if params.Cursor == "" {
+			respond(map[string]any{"tools": []map[string]any{{"name": "search"}}, "nextCursor": "page-2"})
+		} else {
+			respond(map[string]any{"tools": []map[string]any{{"name": "fetch"}}})
+		}
+	case "resources/list":
+		respond(map[string]any{"resources": []map[string]any{{"uri": "file:///plan.md", "name": "plan"}}})
+	case "resources/templates/list":
+		respond(map[string]any{"resourceTemplates": []map[string]any{{"uriTemplate": "file:///{path}", "name": "file"}}})
+	case "resources/read":
+		respond(map[string]any{"contents": []map[string]any{{"uri": params.URI, "mimeType": "text/markdown", "text": "# Plan"}}})
+	case "prompts/list":
+		respond(map[string]any{"prompts": []map[string]any{{"name": "review", "arguments": []map[string]any{{"name": "branch", "required": true}}}}})
+	case "prompts/get":
+		respond(map[string]any{"messages": []map[string]any{{"role": "user", "content": map[string]any{"type": "text", "text": "Review " + params.Arguments["branch"]}}}})
+	case "tools/call":
+		switch params.Name {
+		case "slow":
+			for i := 1; i <= 2; i++ {
+				send(map[string]any{"jsonrpc": "2.0", "method": "notifications/progress", "params": map[string]any{
+					"progressToken": params.Meta.ProgressToken, "progress": i, "total": 2, "message": fmt.Sprintf("step %d", i),
+				}})
+			}
+			respond(map[string]any{"content": []map[string]any{{"type": "text", "text": "done"}}})
+		case "ask":
+			// Ping the client mid-call and report whether it answered.
+			send(map[string]any{"jsonrpc": "2.0", "id": "srv-1", "method": "ping"})
+			text := "no answer"
+			select {
+			case result := <-f.replies:
+				text = "answered " + string(result)
+			case <-time.After(5 * time.Second):
+			}
+			respond(map[string]any{"content": []map[string]any{{"type": "text", "text": text}}})
+		case "hang":
+			<-ctx.Done()
+		}
+	default:
+		send(map[string]any{"jsonrpc": "2.0", "id": m.ID, "error": map[string]any{"code": -32601, "message": "method not found"}})
+	}
+}
+
+// connectStdio connects a client to f over in-memory pipes.
+func connectStdio(t *testing.T, f *fakeServer) *mcpclient.Client {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		serverW.Close()
+		serverR.Close()
+	})
+
+	var writeMu sync.Mutex
+	send := func(v any) {
+		data, _ := json.Marshal(v)
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_, _ = serverW.Write(append(data, '\n'))
+	}
+	go func() {
+		scanner := bufio.NewScanner(serverR)
+		for scanner.Scan() {
+			var m fakeMsg
+			if json.Unmarshal(scanner.Bytes(), &m) != nil {
+				continue
+			}
+			if m.Method == "" {
+				f.replies <- m.Result
+				continue
+			}
+			go f.serve(ctx, m, send)
+		}
+	}()
+
+	c, err := mcpclient.NewClient(mcpclient.NewStdioTransportFromPipes(clientR, clientW))
+	require.NoError(t, err)
+	require.NoError(t, c.Initialize())
+	return c
+}
+
+// httpSession records what the fake HTTP server saw of the session.
+type httpSession struct {
+	mu       sync.Mutex
+	headers  []string // Mcp-Session-Id of each POST after initialize
+	versions []string // MCP-Protocol-Version of each POST after initialize
+	deleted  string
+	// inits counts initialize requests; each starts session-<inits>.
+	inits int
+	// expire makes the next POST in a session answer 404, as a server that
+	// forgot the session does.
+	expire bool
+	// notify releases the notification on the GET stream.
+	notify chan struct{}
+}
+
+// connectHTTP connects a client to f over the Streamable HTTP transport.
+// Requests other than initialize are answered with SSE streams, and the GET
+// stream delivers one notifications/message once notify is closed.
+func connectHTTP(t *testing.T, f *fakeServer) (*mcpclient.Client, *httpSession) {
+	sess := &httpSession{notify: make(chan struct{})}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			select {
+			case <-sess.notify:
+			case <-r.Context().Done():
+				return
+			}
+			fmt.Fprint(w, ": keepalive\n\nevent: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/message\",\n")
+			fmt.Fprint(w, "data: \"params\":{\"level\":\"info\",\"data\":\"hello\"}}\n\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		case http.MethodDelete:
+			sess.mu.Lock()
+			sess.deleted = r.Header.Get("Mcp-Session-Id")
+			sess.mu.Unlock()
+			return
+		}
+
+		var m fakeMsg
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&m))
+		if m.Method == "initialize" {
+			sess.mu.Lock()
+			sess.inits++
+			w.Header().Set("Mcp-Session-Id", fmt.Sprintf("session-%d", sess.inits))
+			sess.mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			f.serve(r.Context(), m, func(v any) { _ = json.NewEncoder(w).Encode(v) })
+			return
+		}
+		sess.mu.Lock()
+		if sess.expire {
+			sess.expire = false
+			sess.mu.Unlock()
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		sess.headers = append(sess.headers, r.Header.Get("Mcp-Session-Id"))
+		sess.versions = append(sess.versions, r.Header.Get("MCP-Protocol-Version"))
+		sess.mu.Unlock()
+		switch {
+		case m.Method == "":
+			f.replies <- m.Result
+			w.WriteHeader(http.StatusAccepted)
+		case len(m.ID) == 0:
+			f.serve(r.Context(), m, nil)
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.WriteHeader(http.StatusOK)
+			f.serve(r.Context(), m, func(v any) {
+				data, _ := json.Marshal(v)
+				fmt.Fprintf(w, "event: message\nid: 1\ndata: %s\n\n", data)
+				w.(http.Flusher).Flush()
+			})
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := mcpclient.NewClient(mcpclient.NewHTTPTransport(srv.URL, ""))
+	require.NoError(t, err)
+	require.NoError(t, c.Initialize())
+	return c, sess
+}
+
+// forEachTransport runs fn against a fake server over stdio and over HTTP.
+func forEachTransport(t *testing.T, fn func(t *testing.T, c *mcpclient.Client, f *fakeServer)) {
+	t.Run("stdio", func(t *testing.T) {
+		f := newFakeServer()
+		c := connectStdio(t, f)
+		defer c.Close()
+		fn(t, c, f)
+	})
+	t.Run("http", func(t *testing.T) {
+		f := newFakeServer()
+		c, _ := connectHTTP(t, f)
+		defer c.Close()
+		fn(t, c, f)
+	})
+}
+
+func TestFakeServer_Handshake(t *testing.T) {
+	forEachTransport(t, func(t *testing.T, c *mcpclient.Client, f *fakeServer) {
+		assert.Equal(t, mcpclient.ServerInfo{Name: "fake", Version: "1.0"}, c.ServerInfo())
+		assert.Equal(t, mcpclient.ProtocolVersion, c.ProtocolVersion())
+		caps := c.Capabilities()
+		require.NotNil(t, caps.Tools)
+		assert.True(t, caps.Tools.ListChanged)
+		assert.NotNil(t, caps.Resources)
+		assert.Nil(t, caps.Logging)
+		assert.Eventually(t, func() bool {
+			return len(f.received()) == 1 && f.received()[0] == "notifications/initialized"
+		}, 5*time.Second, time.Millisecond)
+		assert.NoError(t, c.Ping(context.Background()))
+	})
+}
+
+func TestFakeServer_ToolsPaginate(t *testing.T) {
+	forEachTransport(t, func(t *testing.T, c *mcpclient.Client, _ *fakeServer) {
+		tools, err := c.ListTools()
+		require.NoError(t, err)
+		assert.Equal(t, []mcpclient.Tool{{Name: "search"}, {Name: "fetch"}}, tools)
+		_, found := c.FindTool("fetch")
+		assert.True(t, found, "later pages are cached too")
+	})
+}
+
+func TestFakeServer_ResourcesAndPrompts(t *testing.T) {
+	forEachTransport(t, func(t *testing.T, c *mcpclient.Client, _ *fakeServer) {
+		ctx := context.Background()
+		resources, err := c.ListResources(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []mcpclient.Resource{{URI: "file:///plan.md", Name: "plan"}}, resources)
+
+		templates, err := c.ListResourceTemplates(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []mcpclient.ResourceTemplate{{URITemplate: "file:///{path}", Name: "file"}}, templates)
+
+		contents, err := c.ReadResource(ctx, "file:///plan.md")
+		require.NoError(t, err)
+		assert.Equal(t, []mcpclient.ResourceContents{{URI: "file:///plan.md", MimeType: "text/markdown", Text: "# Plan"}}, contents)
+
+		prompts, err := c.ListPrompts(ctx)
+		require.NoError(t, err)
+		require.Len(t, prompts, 1)
+		assert.Equal(t, []mcpclient.PromptArgument{{Name: "branch", Required: true}}, prompts[0].Arguments)
+
+		prompt, err := c.GetPrompt(ctx, "review", map[string]string{"branch": "plan/dark-mode"})
+		require.NoError(t, err)
+		require.Len(t, prompt.Messages, 1)
+		assert.Equal(t, "Review plan/dark-mode", prompt.Messages[0].Content.Text)
+	})
+}
+
+func TestFakeServer_Progress(t *testing.T) {
+	forEachTransport(t, func(t *testing.T, c *mcpclient.Client, _ *fakeServer) {
+		var updates []mcpclient.Progress
+		result, err := c.CallToolContext(context.Background(), "slow", nil, func(p mcpclient.Progress) {
+			updates = append(updates, p)
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "done", result.Content[0].Text)
+		assert.Equal(t, []mcpclient.Progress{
+			{Progress: 1, Total: 2, Message: "step 1"},
+			{Progress: 2, Total: 2, Message: "step 2"},
+		}, updates)
+	})
+}
+
+func TestFakeServer_AnswersServerRequests(t *testing.T) {
+	forEachTransport(t, func(t *testing.T, c *mcpclient.Client, _ *fakeServer) {
+		result, err := c.CallTool("ask", nil)
+		require.NoError(t, err)
+		assert.Equal(t, "answered {}", result.Content[0].Text)
+	})
+}
+
+func TestFakeServer_CancelsOnTimeout(t *testing.T) {
+	forEachTransport(t, func(t *testing.T, c *mcpclient.Client, f *fakeServer) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := c.CallToolContext(ctx, "hang", nil, nil)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		select {
+		case id := <-f.cancelled:
+			assert.Equal(t, float64(2), id, "initialize was request 1")
+		case <-time.After(5 * time.Second):
+			t.Fatal("server was not told to cancel")
+		}
+
+		// The client stays usable after a cancelled request.
+		assert.NoError(t, c.Ping(context.Background()))
+	})
+}
+
+func TestHTTPTransport_Session(t *testing.T) {
+	f := newFakeServer()
+	c, sess := connectHTTP(t, f)
+
+	messages := make(chan string, 1)
+	c.OnNotification("notifications/message", func(params json.RawMessage) {
+		var p struct {
+			Data string `json:"data"`
+		}
+		_ = json.Unmarshal(params, &p)
+		messages <- p.Data
+	})
+	close(sess.notify)
+	_, err := c.ListTools()
+	require.NoError(t, err)
+	select {
+	case msg := <-messages:
+		assert.Equal(t, "hello", msg, "the GET stream delivers server notifications")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification from the GET stream")
+	}
+
+	require.NoError(t, c.Close())
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	assert.Equal(t, []string{"session-1", "session-1", "session-1"}, sess.headers, "initialized and both tools/list pages")
+	assert.Equal(t, []string{"2025-06-18", "2025-06-18", "2025-06-18"}, sess.versions)
+	assert.Equal(t, "session-1", sess.deleted)
+}
+
+func TestHTTPTransport_SessionExpired(t *testing.T) {
+	f := newFakeServer()
+	c, sess := connectHTTP(t, f)
+	defer c.Close()
+
+	sess.mu.Lock()
+	sess.expire = true
+	sess.mu.Unlock()
+	require.NoError(t, c.Ping(context.Background()), "the client starts a new session and retries")
+
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	assert.Equal(t, 2, sess.inits)
+	assert.Equal(t, []string{"session-1", "session-2", "session-2"}, sess.headers, "initialized, initialized again, then the retried ping")
+}
@@ -0,0 +1,118 @@
+package mcpclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ServerConfig is an MCP server entry from .mcp.json or the Claude settings.
+type ServerConfig struct {
+	Type    string            // "http" or "stdio"
+	URL     string            // for http type
+	Command string            // for stdio type
+	Args    []string          // for stdio type
+	Env     map[string]string // for stdio type
+}
+
+// mcpConfigFile represents the structure of .mcp.json or Claude settings.json.
+type mcpConfigFile struct {
+	MCPServers map[string]json.RawMessage `json:"mcpServers"`
+}
+
+// serverEntry is a union of http and stdio server config fields.
+type serverEntry struct {
+	Type    string            `json:"type"`
+	URL     string            `json:"url"`
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env"`
+}
+
+// FindServer returns the first configured server whose name satisfies match.
+// repoDir is the project root (checks .mcp.json); claudeDir is the Claude
+// config dir (checks settings.json, settings.local.json) and may be empty.
+func FindServer(repoDir, claudeDir string, match func(name string) bool) (string, ServerConfig, bool) {
+	paths := []string{filepath.Join(repoDir, ".mcp.json")}
+	if claudeDir != "" {
+		paths = append(paths, filepath.Join(claudeDir, "settings.json"), filepath.Join(claudeDir, "settings.local.json"))
+	}
+	for _, path := range paths {
+		if name, cfg, ok := scanFile(path, match); ok {
+			return name, cfg, true
+		}
+	}
+	return "", ServerConfig{}, false
+}
+
+func scanFile(path string, match func(string) bool) (string, ServerConfig, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", ServerConfig{}, false
+	}
+	var file mcpConfigFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return "", ServerConfig{}, false
+	}
+	for name, raw := range file.MCPServers {
+		if !match(name) {
+			continue
+		}
+		var entry serverEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			continue
+		}
+		cfg := ServerConfig{Env: entry.Env}
+		if entry.Type == "http" || entry.URL != "" {
+			cfg.Type = "http"
+			cfg.URL = entry.URL
+		} else if entry.Command != "" {
+			cfg.Type = "stdio"
+			cfg.Command = entry.Command
+			cfg.Args = entry.Args
+		} else {
+			continue
+		}
+		return name, cfg, true
+	}
+	return "", ServerConfig{}, false
+}
+
+// Connect starts the server described by cfg, performs the initialize
+// handshake and caches its tools. token is sent as a bearer token to http
+// servers; pass "" to skip authorization.
+func Connect(cfg ServerConfig, token string) (*Client, error) {
+	var transport Transport
+	switch cfg.Type {
+	case "http":
+		transport = NewHTTPTransport(cfg.URL, token)
+	case "stdio":
+		envSlice := make([]string, 0, len(cfg.Env))
+		for k, v := range cfg.Env {
+			envSlice = append(envSlice, k+"="+v)
+		}
+		t, err := NewStdioTransport(cfg.Command, cfg.Args, envSlice)
+		if err != nil {
+			return nil, err
+		}
+		transport = t
+	default:
+		return nil, fmt.Errorf("unsupported transport type: %s", cfg.Type)
+	}
+
+	client, err := NewClient(transport)
+	if err != nil {
+		_ = transport.Close()
+		return nil, err
+	}
+	if err := client.Initialize(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("MCP initialize: %w", err)
+	}
+	if _, err := client.ListTools(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("MCP list tools: %w", err)
+	}
+	return client, nil
+}
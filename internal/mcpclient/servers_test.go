@@ -0,0 +1,71 @@
+package mcpclient_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kastheco/kasmos/internal/mcpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindServer(t *testing.T) {
+	repoDir, claudeDir := t.TempDir(), t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, ".mcp.json"),
+		[]byte(`{"mcpServers":{"docs":{"command":"docs-mcp"},"broken":{}}}`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(claudeDir, "settings.local.json"),
+		[]byte(`{"mcpServers":{"linear":{"type":"http","url":"https://mcp.linear.app/mcp"}}}`), 0o644))
+
+	name, cfg, found := mcpclient.FindServer(repoDir, claudeDir, func(n string) bool { return n == "linear" })
+	require.True(t, found)
+	assert.Equal(t, "linear", name)
+	assert.Equal(t, mcpclient.ServerConfig{Type: "http", URL: "https://mcp.linear.app/mcp"}, cfg)
+
+	_, cfg, found = mcpclient.FindServer(repoDir, "", func(n string) bool { return strings.HasPrefix(n, "d") })
+	require.True(t, found)
+	assert.Equal(t, "stdio", cfg.Type)
+	assert.Equal(t, "docs-mcp", cfg.Command)
+
+	_, _, found = mcpclient.FindServer(repoDir, claudeDir, func(n string) bool { return n == "broken" })
+	assert.False(t, found, "entries without a url or command are skipped")
+}
+
+func TestConnect_HTTP(t *testing.T) {
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer tok", r.Header.Get("Authorization"))
+		if r.Method == http.MethodGet {
+			// No server-initiated stream.
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		var req mcpclient.JSONRPCRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		methods = append(methods, req.Method)
+		if strings.HasPrefix(req.Method, "notifications/") {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		result := `{}`
+		if req.Method == "tools/list" {
+			result = `{"tools":[{"name":"search_issues"}]}`
+		}
+		json.NewEncoder(w).Encode(mcpclient.JSONRPCResponse{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(result)})
+	}))
+	defer srv.Close()
+
+	client, err := mcpclient.Connect(mcpclient.ServerConfig{Type: "http", URL: srv.URL}, "tok")
+	require.NoError(t, err)
+	defer client.Close()
+	assert.Equal(t, []string{"initialize", "notifications/initialized", "tools/list"}, methods)
+	_, found := client.FindTool("search")
+	assert.True(t, found, "tools are cached")
+
+	_, err = mcpclient.Connect(mcpclient.ServerConfig{Type: "sse"}, "")
+	assert.ErrorContains(t, err, "unsupported transport type")
+}
@@ -1,63 +1,298 @@
 package mcpclient
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"strings"
+	"sync"
+	"time"
 )
 
-// HTTPTransport speaks JSON-RPC over HTTP POST (Streamable HTTP MCP transport).
+// Streamable HTTP headers.
+const (
+	headerSessionID       = "Mcp-Session-Id"
+	headerProtocolVersion = "MCP-Protocol-Version"
+)
+
+// closeTimeout bounds the request that ends the session on Close.
+const closeTimeout = 5 * time.Second
+
+// errSessionExpired is returned once the server forgets our session; the
+// client must initialize again.
+var errSessionExpired = errors.New("mcp session expired")
+
+// HTTPTransport speaks the Streamable HTTP MCP transport: each message is
+// POSTed, and the server answers with a JSON body or an SSE stream that may
+// carry notifications and requests before the response. The session ID the
+// server assigns at initialize is sent with every later message, and a GET
+// stream picks up what the server sends outside of any request.
 type HTTPTransport struct {
 	url   string
 	token string
 	http  *http.Client
+
+	mu              sync.Mutex
+	sessionID       string
+	protocolVersion string
+	handler         Handler
+
+	// listenCtx ends the GET stream on Close.
+	listenCtx    context.Context
+	stopListener context.CancelFunc
 }
 
 // NewHTTPTransport creates an HTTP transport with a bearer token.
 // Pass an empty token to skip authorization headers.
 func NewHTTPTransport(url, token string) *HTTPTransport {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &HTTPTransport{
-		url:   url,
-		token: token,
-		http:  &http.Client{},
+		url:          url,
+		token:        token,
+		http:         &http.Client{},
+		listenCtx:    ctx,
+		stopListener: cancel,
+	}
+}
+
+// SetHandler installs the handler for server notifications and requests.
+func (t *HTTPTransport) SetHandler(h Handler) {
+	t.mu.Lock()
+	t.handler = h
+	t.mu.Unlock()
+}
+
+// SessionID returns the session the server assigned, if any.
+func (t *HTTPTransport) SessionID() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sessionID
+}
+
+// Send posts a JSON-RPC request and reads the response, from a JSON body or
+// from the SSE stream the server opens for it.
+func (t *HTTPTransport) Send(ctx context.Context, req JSONRPCRequest) (JSONRPCResponse, error) {
+	httpResp, err := t.post(ctx, req)
+	if err != nil {
+		return JSONRPCResponse{}, err
 	}
+	defer httpResp.Body.Close()
+
+	var resp JSONRPCResponse
+	found := false
+	if isEventStream(httpResp) {
+		err = t.readEvents(httpResp.Body, func(m message) bool {
+			if r, ok := m.response(); ok && m.isResponse() && r.ID == req.ID {
+				resp, found = r, true
+				return false
+			}
+			t.dispatch(m)
+			return true
+		})
+		if err != nil && !found {
+			if ctx.Err() != nil {
+				return JSONRPCResponse{}, ctx.Err()
+			}
+			return JSONRPCResponse{}, fmt.Errorf("read event stream: %w", err)
+		}
+		if !found {
+			return JSONRPCResponse{}, fmt.Errorf("event stream ended without a response to request %d", req.ID)
+		}
+	} else {
+		if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+			return JSONRPCResponse{}, fmt.Errorf("decode response: %w", err)
+		}
+	}
+
+	if req.Method == "initialize" && resp.Error == nil {
+		var result struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		if json.Unmarshal(resp.Result, &result) == nil {
+			t.mu.Lock()
+			t.protocolVersion = result.ProtocolVersion
+			t.mu.Unlock()
+		}
+	}
+	return resp, nil
 }
 
-// Send posts a JSON-RPC request and reads the response.
-func (t *HTTPTransport) Send(req JSONRPCRequest) (JSONRPCResponse, error) {
-	body, err := json.Marshal(req)
+// Notify posts a notification. The initialized notification also opens the
+// GET stream for server-initiated messages.
+func (t *HTTPTransport) Notify(ctx context.Context, n JSONRPCNotification) error {
+	httpResp, err := t.post(ctx, n)
 	if err != nil {
-		return JSONRPCResponse{}, fmt.Errorf("marshal: %w", err)
+		return err
 	}
+	httpResp.Body.Close()
+	if n.Method == "notifications/initialized" {
+		go t.listen()
+	}
+	return nil
+}
 
-	httpReq, err := http.NewRequest("POST", t.url, bytes.NewReader(body))
+// post sends msg and checks the status. The caller closes the body.
+func (t *HTTPTransport) post(ctx context.Context, msg any) (*http.Response, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("marshal: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
 	if err != nil {
-		return JSONRPCResponse{}, fmt.Errorf("new request: %w", err)
+		return nil, fmt.Errorf("new request: %w", err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json, text/event-stream")
+	t.setHeaders(httpReq)
+
+	httpResp, err := t.http.Do(httpReq)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("http post: %w", err)
+	}
+	if id := httpResp.Header.Get(headerSessionID); id != "" {
+		t.mu.Lock()
+		t.sessionID = id
+		t.mu.Unlock()
+	}
+	switch {
+	case httpResp.StatusCode == http.StatusOK || httpResp.StatusCode == http.StatusAccepted:
+		return httpResp, nil
+	case httpResp.StatusCode == http.StatusNotFound && httpReq.Header.Get(headerSessionID) != "":
+		httpResp.Body.Close()
+		t.mu.Lock()
+		t.sessionID = ""
+		t.mu.Unlock()
+		return nil, errSessionExpired
+	default:
+		respBody, _ := io.ReadAll(httpResp.Body)
+		httpResp.Body.Close()
+		return nil, fmt.Errorf("http %d: %s", httpResp.StatusCode, string(respBody))
+	}
+}
+
+// setHeaders adds authorization and the session headers to req.
+func (t *HTTPTransport) setHeaders(req *http.Request) {
 	if t.token != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+t.token)
+		req.Header.Set("Authorization", "Bearer "+t.token)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.sessionID != "" {
+		req.Header.Set(headerSessionID, t.sessionID)
 	}
+	if t.protocolVersion != "" {
+		req.Header.Set(headerProtocolVersion, t.protocolVersion)
+	}
+}
 
-	httpResp, err := t.http.Do(httpReq)
+// listen reads the GET stream until it ends or the transport closes.
+// Servers that do not offer one answer 405, which ends it quietly.
+func (t *HTTPTransport) listen() {
+	req, err := http.NewRequestWithContext(t.listenCtx, http.MethodGet, t.url, nil)
 	if err != nil {
-		return JSONRPCResponse{}, fmt.Errorf("http post: %w", err)
+		return
 	}
-	defer httpResp.Body.Close()
+	req.Header.Set("Accept", "text/event-stream")
+	t.setHeaders(req)
+	resp, err := t.http.Do(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !isEventStream(resp) {
+		return
+	}
+	_ = t.readEvents(resp.Body, func(m message) bool {
+		t.dispatch(m)
+		return true
+	})
+}
 
-	if httpResp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(httpResp.Body)
-		return JSONRPCResponse{}, fmt.Errorf("http %d: %s", httpResp.StatusCode, string(respBody))
+// dispatch hands a server notification or request to the handler, posting
+// the answer to requests back.
+func (t *HTTPTransport) dispatch(m message) {
+	t.mu.Lock()
+	handler := t.handler
+	t.mu.Unlock()
+	switch {
+	case m.Method == "":
+		// A stray response; nobody is waiting for it.
+	case len(m.ID) > 0:
+		r := answer(handler, m)
+		go func() {
+			if resp, err := t.post(t.listenCtx, r); err == nil {
+				resp.Body.Close()
+			}
+		}()
+	case handler != nil:
+		handler(m.Method, m.Params)
 	}
+}
 
-	var resp JSONRPCResponse
-	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
-		return JSONRPCResponse{}, fmt.Errorf("decode response: %w", err)
+// readEvents parses the SSE stream r and calls fn with each JSON-RPC
+// message until fn returns false or the stream ends.
+func (t *HTTPTransport) readEvents(r io.Reader, fn func(message) bool) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			if value, ok := strings.CutPrefix(line, "data:"); ok {
+				data = append(data, strings.TrimPrefix(value, " "))
+			}
+			// event:, id:, retry: and comments carry nothing we need.
+			continue
+		}
+		if len(data) == 0 {
+			continue
+		}
+		payload := strings.Join(data, "\n")
+		data = data[:0]
+		var m message
+		if err := json.Unmarshal([]byte(payload), &m); err != nil {
+			continue
+		}
+		if !fn(m) {
+			return nil
+		}
 	}
-	return resp, nil
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.ErrUnexpectedEOF
 }
 
-// Close is a no-op for HTTP transport.
-func (t *HTTPTransport) Close() error { return nil }
+// Close ends the session: it stops the GET stream and asks the server to
+// drop the session, when it assigned one.
+func (t *HTTPTransport) Close() error {
+	t.stopListener()
+	if t.SessionID() == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, t.url, nil)
+	if err != nil {
+		return nil
+	}
+	t.setHeaders(req)
+	if resp, err := t.http.Do(req); err == nil {
+		resp.Body.Close()
+	}
+	return nil
+}
+
+func isEventStream(resp *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return mediaType == "text/event-stream"
+}
@@ -1,6 +1,7 @@
 package mcpclient_test
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -31,7 +32,7 @@ func TestHTTPTransport_SendReceive(t *testing.T) {
 
 	tr := mcpclient.NewHTTPTransport(srv.URL, "test-token")
 	req := mcpclient.JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "tools/list"}
-	resp, err := tr.Send(req)
+	resp, err := tr.Send(context.Background(), req)
 	require.NoError(t, err)
 	assert.Equal(t, 1, resp.ID)
 }
@@ -46,7 +47,7 @@ func TestHTTPTransport_BearerAuth(t *testing.T) {
 	defer srv.Close()
 
 	tr := mcpclient.NewHTTPTransport(srv.URL, "my-secret-token")
-	_, _ = tr.Send(mcpclient.JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "test"})
+	_, _ = tr.Send(context.Background(), mcpclient.JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "test"})
 	assert.Equal(t, "Bearer my-secret-token", gotAuth)
 }
 
@@ -60,7 +61,7 @@ func TestHTTPTransport_NoAuth(t *testing.T) {
 	defer srv.Close()
 
 	tr := mcpclient.NewHTTPTransport(srv.URL, "")
-	_, _ = tr.Send(mcpclient.JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "test"})
+	_, _ = tr.Send(context.Background(), mcpclient.JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "test"})
 	assert.Empty(t, gotAuth)
 }
 
@@ -71,7 +72,7 @@ func TestHTTPTransport_HTTPError(t *testing.T) {
 	defer srv.Close()
 
 	tr := mcpclient.NewHTTPTransport(srv.URL, "tok")
-	_, err := tr.Send(mcpclient.JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "test"})
+	_, err := tr.Send(context.Background(), mcpclient.JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "test"})
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "500")
 }
@@ -2,6 +2,7 @@ package mcpclient
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,13 +10,26 @@ import (
 	"sync"
 )
 
-// StdioTransport speaks JSON-RPC over stdin/stdout of a subprocess.
+// StdioTransport speaks newline-delimited JSON-RPC over stdin/stdout of a
+// subprocess. A read loop routes responses to the waiting Send by ID and
+// hands notifications and server requests to the handler.
 type StdioTransport struct {
 	cmd    *exec.Cmd // nil when created from pipes
 	reader *bufio.Reader
 	writer io.Writer
 	closer io.Closer // stdin pipe or reader closer
-	mu     sync.Mutex
+
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	pending map[int]chan JSONRPCResponse
+	handler Handler
+
+	// The read loop starts with the first Send, so nothing the server
+	// writes is read before anyone waits for it.
+	readOnce sync.Once
+	done     chan struct{} // closed when the read loop stops
+	readErr  error
 }
 
 // NewStdioTransport spawns a subprocess and connects to its stdin/stdout.
@@ -33,47 +47,133 @@ func NewStdioTransport(command string, args []string, env []string) (*StdioTrans
 	if err := cmd.Start(); err != nil {
 		return nil, fmt.Errorf("start %s: %w", command, err)
 	}
-	return &StdioTransport{
-		cmd:    cmd,
-		reader: bufio.NewReader(stdout),
-		writer: stdin,
-		closer: stdin,
-	}, nil
+	t := newStdioTransport(bufio.NewReader(stdout), stdin, stdin)
+	t.cmd = cmd
+	return t, nil
 }
 
 // NewStdioTransportFromPipes creates a transport from pre-existing reader/writer (for testing).
 func NewStdioTransportFromPipes(r io.ReadCloser, w io.Writer) *StdioTransport {
+	return newStdioTransport(bufio.NewReader(r), w, r)
+}
+
+func newStdioTransport(r *bufio.Reader, w io.Writer, c io.Closer) *StdioTransport {
 	return &StdioTransport{
-		reader: bufio.NewReader(r),
-		writer: w,
-		closer: r,
+		reader:  r,
+		writer:  w,
+		closer:  c,
+		pending: make(map[int]chan JSONRPCResponse),
+		done:    make(chan struct{}),
 	}
 }
 
-// Send writes a JSON-RPC request and reads the response.
-func (t *StdioTransport) Send(req JSONRPCRequest) (JSONRPCResponse, error) {
+// SetHandler installs the handler for server notifications and requests.
+func (t *StdioTransport) SetHandler(h Handler) {
 	t.mu.Lock()
-	defer t.mu.Unlock()
+	t.handler = h
+	t.mu.Unlock()
+}
 
-	data, err := json.Marshal(req)
+// Send writes a JSON-RPC request and waits for its response.
+func (t *StdioTransport) Send(ctx context.Context, req JSONRPCRequest) (JSONRPCResponse, error) {
+	ch := make(chan JSONRPCResponse, 1)
+	t.mu.Lock()
+	t.pending[req.ID] = ch
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		delete(t.pending, req.ID)
+		t.mu.Unlock()
+	}()
+
+	if err := t.write(req); err != nil {
+		return JSONRPCResponse{}, fmt.Errorf("write request: %w", err)
+	}
+	t.readOnce.Do(func() { go t.readLoop() })
+
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-t.done:
+		// The response may have been routed just before the loop stopped.
+		select {
+		case resp := <-ch:
+			return resp, nil
+		default:
+		}
+		return JSONRPCResponse{}, fmt.Errorf("read response: %w", t.readErr)
+	case <-ctx.Done():
+		return JSONRPCResponse{}, ctx.Err()
+	}
+}
+
+// Notify writes a JSON-RPC notification.
+func (t *StdioTransport) Notify(_ context.Context, n JSONRPCNotification) error {
+	if err := t.write(n); err != nil {
+		return fmt.Errorf("write notification: %w", err)
+	}
+	return nil
+}
+
+func (t *StdioTransport) write(v any) error {
+	data, err := json.Marshal(v)
 	if err != nil {
-		return JSONRPCResponse{}, fmt.Errorf("marshal request: %w", err)
+		return fmt.Errorf("marshal: %w", err)
 	}
 	data = append(data, '\n')
-	if _, err := t.writer.Write(data); err != nil {
-		return JSONRPCResponse{}, fmt.Errorf("write request: %w", err)
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_, err = t.writer.Write(data)
+	return err
+}
+
+// readLoop reads messages until the server's stdout closes.
+func (t *StdioTransport) readLoop() {
+	defer close(t.done)
+	for {
+		line, err := t.reader.ReadBytes('\n')
+		if len(line) > 0 {
+			t.dispatch(line)
+		}
+		if err != nil {
+			t.readErr = err
+			return
+		}
 	}
+}
 
-	line, err := t.reader.ReadBytes('\n')
-	if err != nil {
-		return JSONRPCResponse{}, fmt.Errorf("read response: %w", err)
+// dispatch routes one line: responses to their Send, requests and
+// notifications to the handler. Unparseable lines (servers sometimes log to
+// stdout) are skipped.
+func (t *StdioTransport) dispatch(line []byte) {
+	var m message
+	if err := json.Unmarshal(line, &m); err != nil {
+		return
 	}
+	t.mu.Lock()
+	handler := t.handler
+	t.mu.Unlock()
 
-	var resp JSONRPCResponse
-	if err := json.Unmarshal(line, &resp); err != nil {
-		return JSONRPCResponse{}, fmt.Errorf("parse response: %w", err)
+	switch {
+	case m.isResponse():
+		resp, ok := m.response()
+		if !ok {
+			return
+		}
+		t.mu.Lock()
+		ch := t.pending[resp.ID]
+		t.mu.Unlock()
+		if ch != nil {
+			select {
+			case ch <- resp:
+			default: // duplicate response
+			}
+		}
+	case len(m.ID) > 0:
+		_ = t.write(answer(handler, m))
+	case m.Method != "" && handler != nil:
+		handler(m.Method, m.Params)
 	}
-	return resp, nil
 }
 
 // Close terminates the subprocess.
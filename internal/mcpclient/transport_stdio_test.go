@@ -2,6 +2,7 @@ package mcpclient_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"testing"
@@ -26,7 +27,7 @@ func TestStdioTransport_SendReceive(t *testing.T) {
 	tr := mcpclient.NewStdioTransportFromPipes(io.NopCloser(serverOut), serverIn)
 
 	req := mcpclient.JSONRPCRequest{JSONRPC: "2.0", ID: 1, Method: "test"}
-	resp, err := tr.Send(req)
+	resp, err := tr.Send(context.Background(), req)
 	require.NoError(t, err)
 	assert.Equal(t, 1, resp.ID)
 	assert.Nil(t, resp.Error)
@@ -1,6 +1,9 @@
 package mcpclient
 
-import "encoding/json"
+import (
+	"context"
+	"encoding/json"
+)
 
 // JSONRPCRequest is a JSON-RPC 2.0 request.
 type JSONRPCRequest struct {
@@ -10,6 +13,14 @@ type JSONRPCRequest struct {
 	Params  any    `json:"params,omitempty"`
 }
 
+// JSONRPCNotification is a JSON-RPC 2.0 notification: a request without an
+// ID, which gets no response.
+type JSONRPCNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
 // JSONRPCResponse is a JSON-RPC 2.0 response.
 type JSONRPCResponse struct {
 	JSONRPC string          `json:"jsonrpc"`
@@ -26,9 +37,72 @@ type JSONRPCError struct {
 
 func (e *JSONRPCError) Error() string { return e.Message }
 
+// JSON-RPC error codes kasmos answers server requests with.
+const (
+	codeMethodNotFound = -32601
+)
+
+// message is any JSON-RPC message read from a server. Requests and
+// notifications have a Method; requests and responses have an ID, which
+// servers may send as a string.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+}
+
+// isResponse reports whether m answers one of our requests.
+func (m message) isResponse() bool { return m.Method == "" && len(m.ID) > 0 }
+
+// response converts m to a JSONRPCResponse. ok is false when the ID is not
+// one of ours (kasmos only sends integer IDs).
+func (m message) response() (resp JSONRPCResponse, ok bool) {
+	if err := json.Unmarshal(m.ID, &resp.ID); err != nil {
+		return JSONRPCResponse{}, false
+	}
+	resp.JSONRPC, resp.Result, resp.Error = m.JSONRPC, m.Result, m.Error
+	return resp, true
+}
+
+// reply is the response to a server request, echoing its raw ID.
+type reply struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *JSONRPCError   `json:"error,omitempty"`
+}
+
+// Handler receives the messages a server sends on its own initiative:
+// notifications, and requests such as ping, whose answer it returns. The
+// result of a notification is ignored. Handlers run on the transport's read
+// loop, so they must not block or send requests.
+type Handler func(method string, params json.RawMessage) (any, *JSONRPCError)
+
+// answer runs h for a server request m and builds the reply.
+func answer(h Handler, m message) reply {
+	r := reply{JSONRPC: "2.0", ID: m.ID}
+	if h == nil {
+		r.Error = &JSONRPCError{Code: codeMethodNotFound, Message: "method not found: " + m.Method}
+		return r
+	}
+	result, rpcErr := h(m.Method, m.Params)
+	if rpcErr != nil {
+		r.Error = rpcErr
+	} else if result == nil {
+		r.Result = struct{}{}
+	} else {
+		r.Result = result
+	}
+	return r
+}
+
 // Tool represents an MCP tool definition.
 type Tool struct {
 	Name        string          `json:"name"`
+	Title       string          `json:"title,omitempty"`
 	Description string          `json:"description,omitempty"`
 	InputSchema json.RawMessage `json:"inputSchema,omitempty"`
 }
@@ -36,16 +110,120 @@ type Tool struct {
 // ToolResult is the response from a tool call.
 type ToolResult struct {
 	Content []ToolContent `json:"content"`
+	// StructuredContent is the tool's JSON output, when it declares one.
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+	// IsError reports that the tool ran but failed; Content describes why.
+	IsError bool `json:"isError,omitempty"`
 }
 
-// ToolContent is a single content block in a tool result.
+// ToolContent is a single content block in a tool result or prompt
+// message: text, an image or audio clip (base64 Data), or an embedded
+// resource.
 type ToolContent struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
+	Type     string            `json:"type"`
+	Text     string            `json:"text,omitempty"`
+	Data     string            `json:"data,omitempty"`
+	MimeType string            `json:"mimeType,omitempty"`
+	Resource *ResourceContents `json:"resource,omitempty"`
+}
+
+// Resource is an entry of resources/list.
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+}
+
+// ResourceTemplate is an entry of resources/templates/list: a URI template
+// (RFC 6570) naming a family of resources.
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// ResourceContents is the content of a resource: Text, or base64 Blob for
+// binary resources.
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+// Prompt is an entry of prompts/list.
+type Prompt struct {
+	Name        string           `json:"name"`
+	Title       string           `json:"title,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Arguments   []PromptArgument `json:"arguments,omitempty"`
+}
+
+// PromptArgument is an argument a prompt template accepts.
+type PromptArgument struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// PromptResult is a prompt rendered by prompts/get.
+type PromptResult struct {
+	Description string          `json:"description,omitempty"`
+	Messages    []PromptMessage `json:"messages"`
+}
+
+// PromptMessage is one message of a rendered prompt.
+type PromptMessage struct {
+	Role    string      `json:"role"`
+	Content ToolContent `json:"content"`
+}
+
+// Progress is a notifications/progress update for a running request.
+// Total is zero when the server does not know it.
+type Progress struct {
+	Progress float64 `json:"progress"`
+	Total    float64 `json:"total,omitempty"`
+	Message  string  `json:"message,omitempty"`
+}
+
+// ProgressFunc receives the progress updates of one request.
+type ProgressFunc func(Progress)
+
+// ServerInfo names the server and its version.
+type ServerInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// ServerCapabilities lists the features a server declared at initialize.
+// A nil field means the feature is not offered.
+type ServerCapabilities struct {
+	Tools *struct {
+		ListChanged bool `json:"listChanged,omitempty"`
+	} `json:"tools,omitempty"`
+	Resources *struct {
+		Subscribe   bool `json:"subscribe,omitempty"`
+		ListChanged bool `json:"listChanged,omitempty"`
+	} `json:"resources,omitempty"`
+	Prompts *struct {
+		ListChanged bool `json:"listChanged,omitempty"`
+	} `json:"prompts,omitempty"`
+	Logging json.RawMessage `json:"logging,omitempty"`
 }
 
-// Transport sends JSON-RPC requests and returns responses.
+// Transport carries JSON-RPC messages to a server and back.
 type Transport interface {
-	Send(req JSONRPCRequest) (JSONRPCResponse, error)
+	// Send delivers req and waits for its response, or until ctx ends.
+	Send(ctx context.Context, req JSONRPCRequest) (JSONRPCResponse, error)
+	// Notify delivers a notification, which gets no response.
+	Notify(ctx context.Context, n JSONRPCNotification) error
+	// SetHandler installs the handler for messages the server sends on its
+	// own. It is called before the first Send.
+	SetHandler(h Handler)
 	Close() error
 }
@@ -0,0 +1,115 @@
+package mcpserver
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/kastheco/kasmos/config/planfsm"
+)
+
+// Agent roles, matching session.AgentType*.
+const (
+	RolePlanner  = "planner"
+	RoleCoder    = "coder"
+	RoleReviewer = "reviewer"
+	RoleFixer    = "fixer"
+)
+
+// Agent identifies the kasmos agent calling a tool. kasmos exports it to
+// every agent it spawns as KASMOS_* environment variables, which `kas mcp`
+// inherits; over HTTP it travels as Kasmos-* headers.
+type Agent struct {
+	Role     string `json:"role,omitempty"`
+	PlanFile string `json:"plan_file,omitempty"`
+	// Wave and Task are set for wave task agents only.
+	Wave int `json:"wave,omitempty"`
+	Task int `json:"task,omitempty"`
+}
+
+// Agent identity headers, set by Forward and read by HTTPHandler.
+const (
+	headerRole = "Kasmos-Agent"
+	headerPlan = "Kasmos-Plan"
+	headerWave = "Kasmos-Wave"
+	headerTask = "Kasmos-Task"
+)
+
+// AgentFromEnv reads the identity kasmos exported to the agent.
+func AgentFromEnv(getenv func(string) string) Agent {
+	wave, _ := strconv.Atoi(getenv("KASMOS_WAVE"))
+	task, _ := strconv.Atoi(getenv("KASMOS_TASK"))
+	return Agent{
+		Role:     getenv("KASMOS_AGENT"),
+		PlanFile: getenv("KASMOS_PLAN"),
+		Wave:     wave,
+		Task:     task,
+	}
+}
+
+// AgentFromHeader reads the identity sent with an HTTP request.
+func AgentFromHeader(h http.Header) Agent {
+	wave, _ := strconv.Atoi(h.Get(headerWave))
+	task, _ := strconv.Atoi(h.Get(headerTask))
+	return Agent{
+		Role:     h.Get(headerRole),
+		PlanFile: h.Get(headerPlan),
+		Wave:     wave,
+		Task:     task,
+	}
+}
+
+// SetHeader writes the identity into h.
+func (a Agent) SetHeader(h http.Header) {
+	if a.Role != "" {
+		h.Set(headerRole, a.Role)
+	}
+	if a.PlanFile != "" {
+		h.Set(headerPlan, a.PlanFile)
+	}
+	if a.Task > 0 {
+		h.Set(headerWave, strconv.Itoa(a.Wave))
+		h.Set(headerTask, strconv.Itoa(a.Task))
+	}
+}
+
+// IsWaveTask reports whether the agent implements one task of a wave.
+func (a Agent) IsWaveTask() bool { return a.Task > 0 }
+
+// String describes the agent for notes, toasts and logs.
+func (a Agent) String() string {
+	if a.IsWaveTask() {
+		return fmt.Sprintf("wave %d task %d", a.Wave, a.Task)
+	}
+	if a.Role == "" {
+		return "agent"
+	}
+	return a.Role
+}
+
+// DoneEvent is the plan transition an agent's report_task_done stands for:
+// planners finish planning, coders hand over to review, reviewers approve.
+// Wave tasks and other roles have none.
+func DoneEvent(a Agent) (planfsm.Event, bool) {
+	if a.IsWaveTask() {
+		return "", false
+	}
+	switch a.Role {
+	case RolePlanner:
+		return planfsm.PlannerFinished, true
+	case RoleCoder:
+		return planfsm.ImplementFinished, true
+	case RoleReviewer:
+		return planfsm.ReviewApproved, true
+	}
+	return "", false
+}
+
+// FailedEvent is the plan transition an agent's report_task_failed stands
+// for: a reviewer's failure is a request for changes.
+func FailedEvent(a Agent) (planfsm.Event, bool) {
+	if !a.IsWaveTask() && a.Role == RoleReviewer {
+		return planfsm.ReviewChangesRequested, true
+	}
+	return "", false
+}
@@ -0,0 +1,283 @@
+// Package mcpserver serves kasmos's own tools to the agents it spawns over
+// the Model Context Protocol, so they report progress, read plan state and
+// talk to their wave peers and the user with structured tool calls instead
+// of sentinel files.
+//
+// The TUI serves a Backend at /v1/mcp on its control socket. Each agent's
+// harness launches `kas mcp`, which speaks MCP over stdio and forwards to
+// that socket, falling back to writing sentinels when no TUI is running.
+package mcpserver
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/kastheco/kasmos/internal/mcpclient"
+)
+
+// protocolVersion is the newest MCP revision the server speaks.
+const protocolVersion = "2025-06-18"
+
+var supportedVersions = []string{protocolVersion, "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// maxMessageSize bounds one JSON-RPC message.
+const maxMessageSize = 16 * 1024 * 1024
+
+// message is an incoming JSON-RPC message. Requests have a Method and an
+// ID, notifications only a Method.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+func (m message) isRequest() bool { return m.Method != "" && len(m.ID) > 0 }
+
+type response struct {
+	JSONRPC string                  `json:"jsonrpc"`
+	ID      json.RawMessage         `json:"id"`
+	Result  any                     `json:"result,omitempty"`
+	Error   *mcpclient.JSONRPCError `json:"error,omitempty"`
+}
+
+// errorReply answers the request msg with a JSON-RPC error, or returns nil
+// when msg is not a request.
+func errorReply(msg []byte, code int, text string) []byte {
+	var m message
+	if err := json.Unmarshal(msg, &m); err != nil {
+		m.ID = json.RawMessage("null")
+	} else if !m.isRequest() {
+		return nil
+	}
+	data, _ := json.Marshal(response{JSONRPC: "2.0", ID: m.ID, Error: &mcpclient.JSONRPCError{Code: code, Message: text}})
+	return data
+}
+
+// HandleFunc answers one JSON-RPC message from agent and returns the reply,
+// or nil for notifications, which get none.
+type HandleFunc func(ctx context.Context, agent Agent, msg []byte) []byte
+
+// Server implements the MCP methods kasmos offers: the handshake, ping and
+// its tools, which it runs against a Backend. It keeps no session state, so
+// one Server answers every agent.
+type Server struct {
+	backend Backend
+	version string
+}
+
+// NewServer creates a Server answering tool calls from backend. version is
+// reported to clients as the server version.
+func NewServer(backend Backend, version string) *Server {
+	if version == "" {
+		version = "dev"
+	}
+	return &Server{backend: backend, version: version}
+}
+
+// Handle is the server's HandleFunc.
+func (s *Server) Handle(ctx context.Context, agent Agent, msg []byte) []byte {
+	var m message
+	if err := json.Unmarshal(msg, &m); err != nil {
+		return errorReply(msg, codeParseError, "parse error: "+err.Error())
+	}
+	if !m.isRequest() {
+		// notifications/initialized and the like need nothing from us.
+		return nil
+	}
+	result, rpcErr := s.dispatch(ctx, agent, m)
+	resp := response{JSONRPC: "2.0", ID: m.ID, Result: result, Error: rpcErr}
+	if rpcErr == nil && result == nil {
+		resp.Result = struct{}{}
+	}
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return errorReply(msg, codeInternalError, "marshal result: "+err.Error())
+	}
+	return data
+}
+
+func (s *Server) dispatch(ctx context.Context, agent Agent, m message) (any, *mcpclient.JSONRPCError) {
+	switch m.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(m.Params, &params)
+		version := protocolVersion
+		if slices.Contains(supportedVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": "kasmos", "version": s.version},
+			"instructions": "kasmos orchestrates this session. Report the outcome of your work with " +
+				"report_task_done or report_task_failed instead of writing sentinel files.",
+		}, nil
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]any{"tools": toolList()}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(m.Params, &params); err != nil {
+			return nil, &mcpclient.JSONRPCError{Code: codeInvalidParams, Message: "invalid params: " + err.Error()}
+		}
+		result, ok := s.callTool(ctx, agent, params.Name, params.Arguments)
+		if !ok {
+			return nil, &mcpclient.JSONRPCError{Code: codeInvalidParams, Message: "unknown tool: " + params.Name}
+		}
+		return result, nil
+	}
+	return nil, &mcpclient.JSONRPCError{Code: codeMethodNotFound, Message: "method not found: " + m.Method}
+}
+
+// ServeStdio reads newline-delimited JSON-RPC messages from r and writes
+// the replies of handle to w until r ends or ctx is cancelled. Requests run
+// concurrently, so a ping is answered while ask_user waits for the user, and
+// notifications/cancelled cancels the named request.
+func ServeStdio(ctx context.Context, r io.Reader, w io.Writer, agent Agent, handle HandleFunc) error {
+	ctx, cancelAll := context.WithCancel(ctx)
+	defer cancelAll()
+
+	var (
+		writeMu  sync.Mutex
+		mu       sync.Mutex
+		inFlight = make(map[string]context.CancelFunc)
+		wg       sync.WaitGroup
+	)
+	defer wg.Wait()
+	write := func(reply []byte) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_, _ = w.Write(append(reply, '\n'))
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		msg := append([]byte(nil), line...)
+		var m message
+		if err := json.Unmarshal(msg, &m); err != nil {
+			write(errorReply(msg, codeParseError, "parse error: "+err.Error()))
+			continue
+		}
+		if m.Method == "notifications/cancelled" {
+			var params struct {
+				RequestID json.RawMessage `json:"requestId"`
+			}
+			_ = json.Unmarshal(m.Params, &params)
+			mu.Lock()
+			if cancel := inFlight[string(params.RequestID)]; cancel != nil {
+				cancel()
+			}
+			mu.Unlock()
+			continue
+		}
+		if !m.isRequest() {
+			if reply := handle(ctx, agent, msg); reply != nil {
+				write(reply)
+			}
+			continue
+		}
+
+		reqCtx, cancel := context.WithCancel(ctx)
+		id := string(m.ID)
+		mu.Lock()
+		inFlight[id] = cancel
+		mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reply := handle(reqCtx, agent, msg)
+			mu.Lock()
+			delete(inFlight, id)
+			mu.Unlock()
+			// A cancelled request gets no response.
+			if reply != nil && reqCtx.Err() == nil {
+				write(reply)
+			}
+			cancel()
+		}()
+	}
+	return scanner.Err()
+}
+
+// HTTPHandler serves handle over the Streamable HTTP transport with plain
+// JSON responses. The agent is identified by the Kasmos-* request headers.
+func HTTPHandler(handle HandleFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			// kasmos sends nothing unprompted, so there is no GET stream.
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		msg, err := io.ReadAll(io.LimitReader(r.Body, maxMessageSize))
+		if err != nil {
+			http.Error(w, "read request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		reply := handle(r.Context(), AgentFromHeader(r.Header), msg)
+		if reply == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(reply)
+	})
+}
+
+// Forward returns a HandleFunc posting each message to the HTTPHandler at
+// url, e.g. the TUI's control socket. Cancelling ctx aborts the request, so
+// a cancelled call stops waiting on the server too.
+func Forward(client *http.Client, url string) HandleFunc {
+	return func(ctx context.Context, agent Agent, msg []byte) []byte {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(msg))
+		if err != nil {
+			return errorReply(msg, codeInternalError, err.Error())
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		agent.SetHeader(req.Header)
+		resp, err := client.Do(req)
+		if err != nil {
+			return errorReply(msg, codeInternalError, "kasmos is not reachable: "+err.Error())
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxMessageSize))
+		switch {
+		case err != nil:
+			return errorReply(msg, codeInternalError, "read reply: "+err.Error())
+		case resp.StatusCode == http.StatusAccepted:
+			return nil
+		case resp.StatusCode != http.StatusOK:
+			return errorReply(msg, codeInternalError, fmt.Sprintf("kasmos answered %d: %s", resp.StatusCode, strings.TrimSpace(string(body))))
+		}
+		return body
+	}
+}
@@ -0,0 +1,301 @@
+package mcpserver_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/internal/mcpclient"
+	"github.com/kastheco/kasmos/internal/mcpserver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBackend records the calls it gets. AskUser blocks until the context
+// ends when block is set.
+type fakeBackend struct {
+	mu     sync.Mutex
+	calls  []string
+	agents []mcpserver.Agent
+	block  bool
+	asked  chan struct{}
+	ended  chan error
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{asked: make(chan struct{}, 1), ended: make(chan error, 1)}
+}
+
+func (f *fakeBackend) record(agent mcpserver.Agent, call string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+	f.agents = append(f.agents, agent)
+}
+
+func (f *fakeBackend) ReportTaskDone(_ context.Context, agent mcpserver.Agent, summary string) (string, error) {
+	f.record(agent, "done:"+summary)
+	return "task marked complete", nil
+}
+
+func (f *fakeBackend) ReportTaskFailed(_ context.Context, agent mcpserver.Agent, reason string) (string, error) {
+	f.record(agent, "failed:"+reason)
+	return "", errors.New("no retries left")
+}
+
+func (f *fakeBackend) RequestReview(_ context.Context, agent mcpserver.Agent, summary string) (string, error) {
+	f.record(agent, "review:"+summary)
+	return "review requested", nil
+}
+
+func (f *fakeBackend) ReadPlan(_ context.Context, agent mcpserver.Agent, planFile string) (mcpserver.PlanView, error) {
+	f.record(agent, "read:"+planFile)
+	return mcpserver.NewPlanView("p.md", "implementing", "desc", "plan/p", testPlan, func(wave, task int) string {
+		return "pending"
+	}), nil
+}
+
+func (f *fakeBackend) ListWavePeers(_ context.Context, agent mcpserver.Agent) (mcpserver.WavePeers, error) {
+	f.record(agent, "peers")
+	return mcpserver.WavePeers{Wave: agent.Wave}, nil
+}
+
+func (f *fakeBackend) PostNote(_ context.Context, agent mcpserver.Agent, note string) error {
+	f.record(agent, "note:"+note)
+	return nil
+}
+
+func (f *fakeBackend) AskUser(ctx context.Context, agent mcpserver.Agent, question string, choices []string) (string, error) {
+	f.record(agent, "ask:"+question)
+	if !f.block {
+		return choices[0], nil
+	}
+	f.asked <- struct{}{}
+	<-ctx.Done()
+	f.ended <- ctx.Err()
+	return "", ctx.Err()
+}
+
+const testPlan = `# Feature
+
+**Goal:** Ship it.
+
+## Wave 1
+
+### Task 1: Models
+
+Do models.
+
+### Task 2: Views
+
+Do views.
+`
+
+// serveStdio connects a client to handle over in-memory pipes.
+func serveStdio(t *testing.T, agent mcpserver.Agent, handle mcpserver.HandleFunc) *mcpclient.Client {
+	t.Helper()
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = mcpserver.ServeStdio(ctx, serverR, serverW, agent, handle)
+	}()
+	client, err := mcpclient.NewClient(mcpclient.NewStdioTransportFromPipes(clientR, clientW))
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		cancel()
+		_ = clientW.Close()
+		_ = serverW.Close()
+		<-done
+	})
+	require.NoError(t, client.Initialize())
+	return client
+}
+
+func TestServer_ToolsOverStdio(t *testing.T) {
+	backend := newFakeBackend()
+	agent := mcpserver.Agent{Role: mcpserver.RoleCoder, PlanFile: "p.md", Wave: 1, Task: 2}
+	client := serveStdio(t, agent, mcpserver.NewServer(backend, "test").Handle)
+
+	assert.Equal(t, "kasmos", client.ServerInfo().Name)
+
+	tools, err := client.ListTools()
+	require.NoError(t, err)
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	assert.Equal(t, []string{
+		"report_task_done", "report_task_failed", "request_review", "read_plan",
+		"list_wave_peers", "post_note_to_peers", "ask_user",
+	}, names)
+
+	res, err := client.CallTool("report_task_done", map[string]any{"summary": "built it"})
+	require.NoError(t, err)
+	assert.False(t, res.IsError)
+	assert.Equal(t, "task marked complete", res.Content[0].Text)
+
+	res, err = client.CallTool("report_task_failed", map[string]any{"reason": "tests fail"})
+	require.NoError(t, err)
+	assert.True(t, res.IsError, "backend errors are tool errors")
+	assert.Equal(t, "no retries left", res.Content[0].Text)
+
+	res, err = client.CallTool("report_task_failed", map[string]any{})
+	require.NoError(t, err)
+	assert.True(t, res.IsError, "reason is required")
+
+	res, err = client.CallTool("read_plan", nil)
+	require.NoError(t, err)
+	require.False(t, res.IsError)
+	var view mcpserver.PlanView
+	require.NoError(t, json.Unmarshal(res.StructuredContent, &view))
+	assert.Equal(t, "Ship it.", view.Goal)
+	require.Len(t, view.Waves, 1)
+	assert.Equal(t, []mcpserver.TaskView{{Number: 1, Title: "Models", Status: "pending"}}, view.Peers(1, 2))
+
+	res, err = client.CallTool("ask_user", map[string]any{"question": "which db?", "choices": []string{"sqlite"}})
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite", res.Content[0].Text)
+
+	_, err = client.CallTool("no_such_tool", nil)
+	assert.Error(t, err)
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	assert.Equal(t, []string{"done:built it", "failed:tests fail", "read:", "ask:which db?"}, backend.calls)
+	for _, got := range backend.agents {
+		assert.Equal(t, agent, got)
+	}
+}
+
+func TestServer_WaveOnlyTools(t *testing.T) {
+	backend := newFakeBackend()
+	client := serveStdio(t, mcpserver.Agent{Role: mcpserver.RolePlanner, PlanFile: "p.md"},
+		mcpserver.NewServer(backend, "test").Handle)
+
+	for _, name := range []string{"list_wave_peers", "post_note_to_peers"} {
+		res, err := client.CallTool(name, map[string]any{"note": "hi"})
+		require.NoError(t, err)
+		assert.True(t, res.IsError, name)
+	}
+	assert.Empty(t, backend.calls)
+}
+
+func TestServer_CancelledCallEndsBackendCall(t *testing.T) {
+	backend := newFakeBackend()
+	backend.block = true
+	client := serveStdio(t, mcpserver.Agent{Role: mcpserver.RoleCoder}, mcpserver.NewServer(backend, "test").Handle)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() {
+		_, err := client.CallToolContext(ctx, "ask_user", map[string]any{"question": "?"}, nil)
+		errc <- err
+	}()
+	<-backend.asked
+	cancel()
+	assert.Error(t, <-errc)
+	select {
+	case err := <-backend.ended:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(5 * time.Second):
+		t.Fatal("backend call was not cancelled")
+	}
+}
+
+func TestForward_CarriesAgentOverHTTP(t *testing.T) {
+	backend := newFakeBackend()
+	srv := httptest.NewServer(mcpserver.HTTPHandler(mcpserver.NewServer(backend, "test").Handle))
+	defer srv.Close()
+
+	agent := mcpserver.Agent{Role: mcpserver.RoleCoder, PlanFile: "p.md", Wave: 2, Task: 3}
+	client := serveStdio(t, agent, mcpserver.Forward(srv.Client(), srv.URL))
+
+	res, err := client.CallTool("post_note_to_peers", map[string]any{"note": "renamed Foo"})
+	require.NoError(t, err)
+	assert.False(t, res.IsError)
+	require.NoError(t, client.Ping(context.Background()))
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	assert.Equal(t, []string{"note:renamed Foo"}, backend.calls)
+	assert.Equal(t, []mcpserver.Agent{agent}, backend.agents)
+}
+
+func TestHTTPHandler_WithHTTPTransport(t *testing.T) {
+	backend := newFakeBackend()
+	srv := httptest.NewServer(mcpserver.HTTPHandler(mcpserver.NewServer(backend, "test").Handle))
+	defer srv.Close()
+
+	transport := mcpclient.NewHTTPTransport(srv.URL, "")
+	client, err := mcpclient.NewClient(transport)
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.Initialize())
+
+	res, err := client.CallTool("request_review", map[string]any{"summary": "ready"})
+	require.NoError(t, err)
+	assert.Equal(t, "review requested", res.Content[0].Text)
+}
+
+func TestSignalBackend_WritesSentinels(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docs", "plans"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docs", "plans", "p.md"), []byte(testPlan), 0o644))
+	backend := mcpserver.NewSignalBackend(dir, nil)
+	ctx := context.Background()
+	signalsDir := filepath.Join(dir, ".kasmos", "signals")
+
+	_, err := backend.ReportTaskFailed(ctx, mcpserver.Agent{Role: mcpserver.RoleReviewer, PlanFile: "p.md"}, "fix the tests")
+	require.NoError(t, err)
+	sigs := planfsm.ScanSignals(signalsDir)
+	require.Len(t, sigs, 1)
+	assert.Equal(t, planfsm.ReviewChangesRequested, sigs[0].Event)
+	assert.Equal(t, "fix the tests", sigs[0].Body)
+	planfsm.ConsumeSignal(sigs[0])
+
+	// Wave tasks are detected complete by kasmos itself.
+	_, err = backend.ReportTaskDone(ctx, mcpserver.Agent{Role: mcpserver.RoleCoder, PlanFile: "p.md", Wave: 1, Task: 1}, "")
+	require.NoError(t, err)
+	assert.Empty(t, planfsm.ScanSignals(signalsDir))
+
+	_, err = backend.ReportTaskDone(ctx, mcpserver.Agent{Role: mcpserver.RolePlanner, PlanFile: "p.md"}, "")
+	require.NoError(t, err)
+	sigs = planfsm.ScanSignals(signalsDir)
+	require.Len(t, sigs, 1)
+	assert.Equal(t, planfsm.PlannerFinished, sigs[0].Event)
+
+	peers, err := backend.ListWavePeers(ctx, mcpserver.Agent{PlanFile: "p.md", Wave: 1, Task: 1})
+	require.NoError(t, err)
+	require.Len(t, peers.Peers, 1)
+	assert.Equal(t, "Views", peers.Peers[0].Title)
+
+	_, err = backend.AskUser(ctx, mcpserver.Agent{}, "?", nil)
+	assert.Error(t, err)
+}
+
+func TestSignalBackend_ReadPlanRejectsPathTraversal(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "docs", "plans"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "secret.md"), []byte("secret"), 0o644))
+	backend := mcpserver.NewSignalBackend(dir, nil)
+	ctx := context.Background()
+
+	for _, name := range []string{"../../secret.md", "sub/p.md", `..\secret.md`, ".."} {
+		_, err := backend.ReadPlan(ctx, mcpserver.Agent{}, name)
+		assert.ErrorContains(t, err, "invalid plan file", name)
+	}
+	_, err := backend.RequestReview(ctx, mcpserver.Agent{Role: mcpserver.RoleCoder, PlanFile: "../x.md"}, "")
+	assert.ErrorContains(t, err, "invalid plan file")
+	assert.NoDirExists(t, filepath.Join(dir, ".kasmos"))
+}
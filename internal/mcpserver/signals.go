@@ -0,0 +1,129 @@
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/config/planstate"
+)
+
+// errNoSession is returned by the tools that need a running kasmos TUI.
+var errNoSession = errors.New("no kasmos session is attached to this agent; the tool is unavailable")
+
+// SignalBackend is the Backend `kas mcp` falls back to when no TUI is
+// listening: reports are written as sentinel files, which the daemon (or a
+// TUI started later) consumes exactly like sentinels written by hand.
+type SignalBackend struct {
+	workDir string
+	load    func() (*planstate.PlanState, error)
+}
+
+// NewSignalBackend creates a SignalBackend writing sentinels under
+// workDir/.kasmos/signals. load, when set, loads plan state for read_plan;
+// otherwise plans are read from workDir/docs/plans.
+func NewSignalBackend(workDir string, load func() (*planstate.PlanState, error)) *SignalBackend {
+	return &SignalBackend{workDir: workDir, load: load}
+}
+
+// checkPlanFile rejects plan names that are not a bare filename. They come
+// from the agent and are joined onto the plans and signals directories, so a
+// separator or ".." would reach files outside them.
+func checkPlanFile(planFile string) error {
+	if planFile == "" {
+		return fmt.Errorf("you are not working on a plan")
+	}
+	if planFile == "." || planFile == ".." || strings.ContainsAny(planFile, `/\`) {
+		return fmt.Errorf("invalid plan file %q: expected a file name in docs/plans", planFile)
+	}
+	return nil
+}
+
+func (b *SignalBackend) signal(event planfsm.Event, agent Agent, body string) error {
+	if err := checkPlanFile(agent.PlanFile); err != nil {
+		return err
+	}
+	return planfsm.WriteSignal(filepath.Join(b.workDir, ".kasmos", "signals"), event, agent.PlanFile, body)
+}
+
+func (b *SignalBackend) ReportTaskDone(_ context.Context, agent Agent, summary string) (string, error) {
+	if agent.IsWaveTask() {
+		return "noted; kasmos marks the task complete when your session goes idle", nil
+	}
+	event, ok := DoneEvent(agent)
+	if !ok {
+		return "noted", nil
+	}
+	if err := b.signal(event, agent, summary); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("reported %s for %s", event, agent.PlanFile), nil
+}
+
+func (b *SignalBackend) ReportTaskFailed(_ context.Context, agent Agent, reason string) (string, error) {
+	event, ok := FailedEvent(agent)
+	if !ok {
+		return "", errNoSession
+	}
+	if err := b.signal(event, agent, reason); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("reported %s for %s", event, agent.PlanFile), nil
+}
+
+func (b *SignalBackend) RequestReview(_ context.Context, agent Agent, summary string) (string, error) {
+	if agent.IsWaveTask() {
+		return "", fmt.Errorf("wave tasks do not request review; call report_task_done")
+	}
+	if err := b.signal(planfsm.ImplementFinished, agent, summary); err != nil {
+		return "", err
+	}
+	return "review requested for " + agent.PlanFile, nil
+}
+
+func (b *SignalBackend) ReadPlan(_ context.Context, agent Agent, planFile string) (PlanView, error) {
+	if planFile == "" {
+		planFile = agent.PlanFile
+	}
+	if err := checkPlanFile(planFile); err != nil {
+		return PlanView{}, err
+	}
+	var entry planstate.PlanEntry
+	var content string
+	if b.load != nil {
+		if ps, err := b.load(); err == nil {
+			entry, _ = ps.Entry(planFile)
+			content, _ = ps.GetContent(planFile)
+		}
+	}
+	if content == "" {
+		data, err := os.ReadFile(filepath.Join(b.workDir, "docs", "plans", planFile))
+		if err != nil {
+			return PlanView{}, fmt.Errorf("read plan %s: %w", planFile, err)
+		}
+		content = string(data)
+	}
+	return NewPlanView(planFile, string(entry.Status), entry.Description, entry.Branch, content, nil), nil
+}
+
+func (b *SignalBackend) ListWavePeers(ctx context.Context, agent Agent) (WavePeers, error) {
+	view, err := b.ReadPlan(ctx, agent, "")
+	if err != nil {
+		return WavePeers{}, err
+	}
+	peers := WavePeers{Wave: agent.Wave}
+	for _, t := range view.Peers(agent.Wave, agent.Task) {
+		peers.Peers = append(peers.Peers, Peer{TaskView: t})
+	}
+	return peers, nil
+}
+
+func (b *SignalBackend) PostNote(context.Context, Agent, string) error { return errNoSession }
+
+func (b *SignalBackend) AskUser(context.Context, Agent, string, []string) (string, error) {
+	return "", errNoSession
+}
@@ -0,0 +1,322 @@
+package mcpserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kastheco/kasmos/config/planparser"
+	"github.com/kastheco/kasmos/internal/mcpclient"
+)
+
+// Backend carries out the tools for the process that owns the plan FSM and
+// the wave orchestrators. The report methods return a short confirmation
+// for the agent. Errors are shown to the agent as failed tool calls.
+type Backend interface {
+	ReportTaskDone(ctx context.Context, agent Agent, summary string) (string, error)
+	ReportTaskFailed(ctx context.Context, agent Agent, reason string) (string, error)
+	RequestReview(ctx context.Context, agent Agent, summary string) (string, error)
+	// ReadPlan returns planFile, or the agent's own plan when it is empty.
+	ReadPlan(ctx context.Context, agent Agent, planFile string) (PlanView, error)
+	ListWavePeers(ctx context.Context, agent Agent) (WavePeers, error)
+	PostNote(ctx context.Context, agent Agent, note string) error
+	// AskUser blocks until the user answers question or ctx ends. choices,
+	// when given, are offered as answers.
+	AskUser(ctx context.Context, agent Agent, question string, choices []string) (string, error)
+}
+
+// PlanView is what read_plan returns.
+type PlanView struct {
+	Filename    string     `json:"filename"`
+	Status      string     `json:"status,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Branch      string     `json:"branch,omitempty"`
+	Goal        string     `json:"goal,omitempty"`
+	Waves       []WaveView `json:"waves,omitempty"`
+	Content     string     `json:"content"`
+}
+
+// WaveView is one wave of a plan.
+type WaveView struct {
+	Number int        `json:"number"`
+	Tasks  []TaskView `json:"tasks"`
+}
+
+// TaskView is one task of a wave. Status is pending, running, complete or
+// failed while the plan's waves are orchestrated, and empty otherwise.
+type TaskView struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Status string `json:"status,omitempty"`
+}
+
+// WavePeers is what list_wave_peers returns.
+type WavePeers struct {
+	Wave  int    `json:"wave"`
+	Peers []Peer `json:"peers"`
+	Notes []Note `json:"notes"`
+}
+
+// Peer is another task of the caller's wave.
+type Peer struct {
+	TaskView
+	// Instance is the title of the session working on the task.
+	Instance string `json:"instance,omitempty"`
+}
+
+// Note is a message posted with post_note_to_peers.
+type Note struct {
+	From string    `json:"from"`
+	Task int       `json:"task,omitempty"`
+	Text string    `json:"text"`
+	Time time.Time `json:"time"`
+}
+
+// NewPlanView builds the view of a plan from its stored fields. taskStatus,
+// when set, reports the orchestration status of each task.
+func NewPlanView(filename, status, description, branch, content string, taskStatus func(wave, task int) string) PlanView {
+	view := PlanView{
+		Filename:    filename,
+		Status:      status,
+		Description: description,
+		Branch:      branch,
+		Content:     content,
+	}
+	plan, err := planparser.Parse(content)
+	if err != nil {
+		return view
+	}
+	view.Goal = plan.Goal
+	for _, w := range plan.Waves {
+		wave := WaveView{Number: w.Number}
+		for _, t := range w.Tasks {
+			task := TaskView{Number: t.Number, Title: t.Title}
+			if taskStatus != nil {
+				task.Status = taskStatus(w.Number, t.Number)
+			}
+			wave.Tasks = append(wave.Tasks, task)
+		}
+		view.Waves = append(view.Waves, wave)
+	}
+	return view
+}
+
+// Peers returns the tasks of wave other than task.
+func (v PlanView) Peers(wave, task int) []TaskView {
+	var peers []TaskView
+	for _, w := range v.Waves {
+		if w.Number != wave {
+			continue
+		}
+		for _, t := range w.Tasks {
+			if t.Number != task {
+				peers = append(peers, t)
+			}
+		}
+	}
+	return peers
+}
+
+// tool is one kasmos tool: its definition and how to run it.
+type tool struct {
+	def mcpclient.Tool
+	run func(ctx context.Context, b Backend, agent Agent, args json.RawMessage) (any, error)
+}
+
+// summaryArgs are the arguments of the report tools.
+type summaryArgs struct {
+	Summary string `json:"summary"`
+	Reason  string `json:"reason"`
+}
+
+var tools = []tool{
+	{
+		def: mcpclient.Tool{
+			Name: "report_task_done",
+			Description: "Report that your assigned work is finished; call it once, as your last action. " +
+				"Wave task agents mark their task complete, planners finish the plan, coders hand the plan " +
+				"to review and reviewers approve it.",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"summary":{"type":"string",` +
+				`"description":"One or two sentences on what you did."}}}`),
+		},
+		run: func(ctx context.Context, b Backend, agent Agent, args json.RawMessage) (any, error) {
+			var a summaryArgs
+			if err := decodeArgs(args, &a); err != nil {
+				return nil, err
+			}
+			return b.ReportTaskDone(ctx, agent, a.Summary)
+		},
+	},
+	{
+		def: mcpclient.Tool{
+			Name: "report_task_failed",
+			Description: "Report that your assigned work cannot be finished. Wave task agents mark their task " +
+				"failed so it can be retried, reviewers request changes with the reason as feedback for the " +
+				"coder, and other agents alert the user.",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"reason":{"type":"string",` +
+				`"description":"What went wrong, or for reviewers the changes required."}},"required":["reason"]}`),
+		},
+		run: func(ctx context.Context, b Backend, agent Agent, args json.RawMessage) (any, error) {
+			var a summaryArgs
+			if err := decodeArgs(args, &a); err != nil {
+				return nil, err
+			}
+			if strings.TrimSpace(a.Reason) == "" {
+				return nil, fmt.Errorf("reason is required")
+			}
+			return b.ReportTaskFailed(ctx, agent, a.Reason)
+		},
+	},
+	{
+		def: mcpclient.Tool{
+			Name: "request_review",
+			Description: "Hand the plan to a reviewer once its implementation is complete. Only for coders " +
+				"working on a whole plan; wave task agents call report_task_done and kasmos starts the " +
+				"review after the last wave.",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"summary":{"type":"string",` +
+				`"description":"What the reviewer should know about the change."}}}`),
+		},
+		run: func(ctx context.Context, b Backend, agent Agent, args json.RawMessage) (any, error) {
+			var a summaryArgs
+			if err := decodeArgs(args, &a); err != nil {
+				return nil, err
+			}
+			return b.RequestReview(ctx, agent, a.Summary)
+		},
+	},
+	{
+		def: mcpclient.Tool{
+			Name: "read_plan",
+			Description: "Read a plan: its status, branch, goal, waves and tasks with their progress, and " +
+				"the full plan document. Reads your own plan unless plan names another.",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"plan":{"type":"string",` +
+				`"description":"Plan filename, e.g. 2026-02-27-feature.md."}}}`),
+		},
+		run: func(ctx context.Context, b Backend, agent Agent, args json.RawMessage) (any, error) {
+			var a struct {
+				Plan string `json:"plan"`
+			}
+			if err := decodeArgs(args, &a); err != nil {
+				return nil, err
+			}
+			if a.Plan == "" && agent.PlanFile == "" {
+				return nil, fmt.Errorf("plan is required: you are not working on a plan")
+			}
+			return b.ReadPlan(ctx, agent, a.Plan)
+		},
+	},
+	{
+		def: mcpclient.Tool{
+			Name:        "list_wave_peers",
+			Description: "List the other tasks of your wave with their status and sessions, and the notes wave peers have posted.",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{}}`),
+		},
+		run: func(ctx context.Context, b Backend, agent Agent, _ json.RawMessage) (any, error) {
+			if !agent.IsWaveTask() {
+				return nil, fmt.Errorf("you are not a wave task agent")
+			}
+			return b.ListWavePeers(ctx, agent)
+		},
+	},
+	{
+		def: mcpclient.Tool{
+			Name: "post_note_to_peers",
+			Description: "Share a note with the other agents of your wave, e.g. an interface you changed or a " +
+				"file you are about to edit. Peers read it with list_wave_peers.",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"note":{"type":"string"}},"required":["note"]}`),
+		},
+		run: func(ctx context.Context, b Backend, agent Agent, args json.RawMessage) (any, error) {
+			var a struct {
+				Note string `json:"note"`
+			}
+			if err := decodeArgs(args, &a); err != nil {
+				return nil, err
+			}
+			if strings.TrimSpace(a.Note) == "" {
+				return nil, fmt.Errorf("note is required")
+			}
+			if !agent.IsWaveTask() {
+				return nil, fmt.Errorf("you are not a wave task agent")
+			}
+			if err := b.PostNote(ctx, agent, a.Note); err != nil {
+				return nil, err
+			}
+			return "note posted to wave peers", nil
+		},
+	},
+	{
+		def: mcpclient.Tool{
+			Name: "ask_user",
+			Description: "Ask the user a question and wait for the answer. Only use it when you cannot " +
+				"continue without a decision.",
+			InputSchema: json.RawMessage(`{"type":"object","properties":{"question":{"type":"string"},` +
+				`"choices":{"type":"array","items":{"type":"string"},"description":"Suggested answers; the user may still type their own."}},` +
+				`"required":["question"]}`),
+		},
+		run: func(ctx context.Context, b Backend, agent Agent, args json.RawMessage) (any, error) {
+			var a struct {
+				Question string   `json:"question"`
+				Choices  []string `json:"choices"`
+			}
+			if err := decodeArgs(args, &a); err != nil {
+				return nil, err
+			}
+			if strings.TrimSpace(a.Question) == "" {
+				return nil, fmt.Errorf("question is required")
+			}
+			return b.AskUser(ctx, agent, a.Question, a.Choices)
+		},
+	},
+}
+
+func toolList() []mcpclient.Tool {
+	defs := make([]mcpclient.Tool, len(tools))
+	for i, t := range tools {
+		defs[i] = t.def
+	}
+	return defs
+}
+
+func decodeArgs(args json.RawMessage, v any) error {
+	if len(args) == 0 || string(args) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(args, v); err != nil {
+		return fmt.Errorf("invalid arguments: %w", err)
+	}
+	return nil
+}
+
+// callTool runs the tool name. ok is false for unknown tools. Tool errors
+// become results with IsError set, so the agent sees what went wrong.
+func (s *Server) callTool(ctx context.Context, agent Agent, name string, args json.RawMessage) (result *mcpclient.ToolResult, ok bool) {
+	for _, t := range tools {
+		if t.def.Name != name {
+			continue
+		}
+		value, err := t.run(ctx, s.backend, agent, args)
+		if err != nil {
+			return &mcpclient.ToolResult{
+				Content: []mcpclient.ToolContent{{Type: "text", Text: err.Error()}},
+				IsError: true,
+			}, true
+		}
+		if text, isText := value.(string); isText {
+			return &mcpclient.ToolResult{Content: []mcpclient.ToolContent{{Type: "text", Text: text}}}, true
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return &mcpclient.ToolResult{
+				Content: []mcpclient.ToolContent{{Type: "text", Text: "marshal result: " + err.Error()}},
+				IsError: true,
+			}, true
+		}
+		return &mcpclient.ToolResult{
+			Content:           []mcpclient.ToolContent{{Type: "text", Text: string(data)}},
+			StructuredContent: data,
+		}, true
+	}
+	return nil, false
+}
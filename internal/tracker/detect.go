@@ -0,0 +1,87 @@
+package tracker
+
+import (
+	"context"
+	"os"
+	"os/exec"
+
+	"github.com/kastheco/kasmos/cmd"
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/internal/mcpclient"
+	"github.com/kastheco/kasmos/log"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+)
+
+// lookPath finds CLIs on PATH; replaced in tests.
+var lookPath = exec.LookPath
+
+// Detect returns the issue sources available for the repository at
+// repoPath, in overlay order: GitHub or GitLab issues of the origin remote,
+// then Linear, Jira and the [[issues.mcp]] servers configured in cfg.
+// claudeDir is searched for MCP servers alongside the repository's .mcp.json.
+func Detect(cfg *config.Config, repoPath, claudeDir string, e cmd.Executor) []IssueSource {
+	issues := cfg.Issues
+	host, repo := gitpkg.OriginRepo(repoPath)
+	forge := gitpkg.ForgeType(cfg.Forge, host)
+
+	var sources []IssueSource
+	if gh := issues.GitHub; !gh.Disabled && (gh.Repo != "" || forge == config.ForgeGitHub) {
+		if _, err := lookPath("gh"); err == nil {
+			sources = append(sources, NewGitHubSource(e, repoPath, gh.Repo))
+		}
+	}
+
+	gl := issues.GitLab
+	tokenEnv := envName(gl.TokenEnv, "GITLAB_TOKEN")
+	if gl != (config.GitLabIssuesConfig{}) || (forge == config.ForgeGitLab && os.Getenv(tokenEnv) != "") {
+		base, project := gl.URL, gl.Project
+		if base == "" {
+			base = "https://gitlab.com"
+			if forge == config.ForgeGitLab && host != "" {
+				base = "https://" + host
+			}
+		}
+		if project == "" && forge == config.ForgeGitLab {
+			project = repo
+		}
+		if project != "" {
+			sources = append(sources, NewGitLabSource(base, project, os.Getenv(tokenEnv), tokenEnv))
+		}
+	}
+
+	if key := os.Getenv(envName(issues.Linear.TokenEnv, "LINEAR_API_KEY")); key != "" {
+		sources = append(sources, NewLinearSource(key, issues.Linear.Team))
+	}
+
+	if jira := issues.Jira; jira.URL != "" {
+		tokenEnv := envName(jira.TokenEnv, "JIRA_API_TOKEN")
+		sources = append(sources, NewJiraSource(jira.URL, jira.Email, os.Getenv(tokenEnv), tokenEnv, jira.Project))
+	}
+
+	for _, m := range issues.MCP {
+		name, server, found := mcpclient.FindServer(repoPath, claudeDir, func(n string) bool { return n == m.Server })
+		if !found {
+			log.WarningLog.Printf("issues: MCP server %q not found in .mcp.json or the Claude settings", m.Server)
+			continue
+		}
+		dial := func(context.Context) (MCPCaller, error) {
+			client, err := mcpclient.Connect(server, "")
+			if err != nil {
+				return nil, err
+			}
+			return ClientCaller(client), nil
+		}
+		sources = append(sources, NewMCPSource(name, dial, MCPTools{
+			Search: m.SearchTool, Fetch: m.FetchTool, Update: m.UpdateTool, Comment: m.CommentTool,
+		}))
+	}
+	return sources
+}
+
+// envName returns name, or fallback when name is empty.
+func envName(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
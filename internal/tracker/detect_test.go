@@ -0,0 +1,51 @@
+package tracker
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kastheco/kasmos/cmd/cmd_test"
+	"github.com/kastheco/kasmos/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sourceNames(sources []IssueSource) []string {
+	names := make([]string, len(sources))
+	for i, s := range sources {
+		names[i] = s.Name()
+	}
+	return names
+}
+
+func TestDetect(t *testing.T) {
+	t.Setenv("LINEAR_API_KEY", "")
+	t.Setenv("GITLAB_TOKEN", "")
+	defer func(orig func(string) (string, error)) { lookPath = orig }(lookPath)
+	lookPath = func(string) (string, error) { return "/usr/bin/gh", nil }
+	repo := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(repo, ".mcp.json"),
+		[]byte(`{"mcpServers":{"atlassian":{"type":"http","url":"https://mcp.atlassian.com/v1/sse"}}}`), 0o644))
+	mock := cmd_test.NewMockExecutor()
+
+	// No remote and nothing configured.
+	assert.Empty(t, Detect(&config.Config{}, repo, "", mock))
+
+	cfg := &config.Config{Issues: config.IssuesConfig{
+		GitHub: config.GitHubIssuesConfig{Repo: "acme/app"},
+		GitLab: config.GitLabIssuesConfig{Project: "group/app"},
+		Jira:   config.JiraIssuesConfig{URL: "https://acme.atlassian.net"},
+		MCP:    []config.MCPIssuesConfig{{Server: "atlassian"}, {Server: "missing"}},
+	}}
+	t.Setenv("LINEAR_API_KEY", "lin_key")
+	sources := Detect(cfg, repo, "", mock)
+	assert.Equal(t, []string{"github", "gitlab", "linear", "jira", "atlassian"}, sourceNames(sources))
+	assert.Equal(t, "https://gitlab.com", sources[1].(*GitLabSource).baseURL)
+
+	// gh missing or GitHub disabled.
+	lookPath = func(string) (string, error) { return "", errors.New("not found") }
+	cfg.Issues.GitHub.Disabled = true
+	assert.NotContains(t, sourceNames(Detect(cfg, repo, "", mock)), "github")
+}
@@ -0,0 +1,169 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/kastheco/kasmos/cmd"
+)
+
+// githubIssueRef matches "12", "#12" and issue URLs, which gh issue view
+// accepts as they are.
+var githubIssueRef = regexp.MustCompile(`^(#?\d+|https?://\S+/issues/\d+)$`)
+
+// GitHubSource searches the issues of a GitHub repository through the gh CLI.
+type GitHubSource struct {
+	exec cmd.Executor
+	// dir is the repository gh runs in; repo ("owner/name") overrides the
+	// repository gh infers from it.
+	dir  string
+	repo string
+}
+
+// NewGitHubSource creates a GitHubSource running gh in dir. repo may be empty.
+func NewGitHubSource(e cmd.Executor, dir, repo string) *GitHubSource {
+	return &GitHubSource{exec: e, dir: dir, repo: repo}
+}
+
+func (s *GitHubSource) Name() string { return "github" }
+
+// ghIssue is the subset of `gh issue view --json` output kasmos reads.
+type ghIssue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+	State  string `json:"state"`
+	URL    string `json:"url"`
+	Labels []struct {
+		Name string `json:"name"`
+	} `json:"labels"`
+	Milestone *struct {
+		Title string `json:"title"`
+	} `json:"milestone"`
+	Assignees []struct {
+		Login string `json:"login"`
+	} `json:"assignees"`
+}
+
+func (s *GitHubSource) Search(ctx context.Context, query string) ([]SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if githubIssueRef.MatchString(query) {
+		issue, err := s.view(ctx, strings.TrimPrefix(query, "#"), "number,title,state,url")
+		if err != nil {
+			return nil, err
+		}
+		return []SearchResult{s.result(issue)}, nil
+	}
+	out, err := s.gh(ctx, "issue", "list", "--search", query, "--state", "all", "--limit", "20",
+		"--json", "number,title,state,url")
+	if err != nil {
+		return nil, err
+	}
+	var issues []ghIssue
+	if err := json.Unmarshal(out, &issues); err != nil {
+		return nil, fmt.Errorf("parse gh issue list: %w", err)
+	}
+	results := make([]SearchResult, len(issues))
+	for i, issue := range issues {
+		results[i] = s.result(issue)
+	}
+	return results, nil
+}
+
+func (s *GitHubSource) Fetch(ctx context.Context, id string) (*Issue, error) {
+	raw, err := s.view(ctx, strings.TrimPrefix(id, "#"), "number,title,body,state,url,labels,milestone,assignees")
+	if err != nil {
+		return nil, err
+	}
+	issue := &Issue{
+		Source:      "GitHub",
+		ID:          fmt.Sprintf("#%d", raw.Number),
+		Title:       raw.Title,
+		Description: raw.Body,
+		Status:      strings.ToLower(raw.State),
+		URL:         raw.URL,
+		Project:     s.repo,
+		ProjectKind: "Repository",
+		Subtasks:    TaskList(raw.Body),
+	}
+	for _, l := range raw.Labels {
+		issue.Labels = append(issue.Labels, l.Name)
+	}
+	if raw.Milestone != nil && raw.Milestone.Title != "" {
+		issue.Fields = append(issue.Fields, Field{Name: "Milestone", Value: raw.Milestone.Title})
+	}
+	if len(raw.Assignees) > 0 {
+		logins := make([]string, len(raw.Assignees))
+		for i, a := range raw.Assignees {
+			logins[i] = a.Login
+		}
+		issue.Fields = append(issue.Fields, Field{Name: "Assignees", Value: strings.Join(logins, ", ")})
+	}
+	return issue, nil
+}
+
+// SetStatus closes or reopens issue id. GitHub issues have no other states.
+func (s *GitHubSource) SetStatus(ctx context.Context, id, status string) error {
+	var verb string
+	switch strings.ToLower(status) {
+	case "closed", "close":
+		verb = "close"
+	case "open", "opened", "reopen":
+		verb = "reopen"
+	default:
+		return fmt.Errorf("github issues can only be open or closed, not %q", status)
+	}
+	_, err := s.gh(ctx, "issue", verb, strings.TrimPrefix(id, "#"))
+	return err
+}
+
+func (s *GitHubSource) Comment(ctx context.Context, id, body string) error {
+	_, err := s.gh(ctx, "issue", "comment", strings.TrimPrefix(id, "#"), "--body", body)
+	return err
+}
+
+func (s *GitHubSource) result(issue ghIssue) SearchResult {
+	return SearchResult{
+		ID:      fmt.Sprintf("#%d", issue.Number),
+		Title:   issue.Title,
+		Status:  strings.ToLower(issue.State),
+		Project: s.repo,
+		URL:     issue.URL,
+	}
+}
+
+func (s *GitHubSource) view(ctx context.Context, ref, fields string) (ghIssue, error) {
+	out, err := s.gh(ctx, "issue", "view", ref, "--json", fields)
+	if err != nil {
+		return ghIssue{}, err
+	}
+	var issue ghIssue
+	if err := json.Unmarshal(out, &issue); err != nil {
+		return ghIssue{}, fmt.Errorf("parse gh issue view: %w", err)
+	}
+	return issue, nil
+}
+
+// gh runs the GitHub CLI in the repository and returns its stdout. Errors
+// carry gh's stderr.
+func (s *GitHubSource) gh(ctx context.Context, args ...string) ([]byte, error) {
+	if s.repo != "" {
+		args = append(args, "--repo", s.repo)
+	}
+	c := exec.CommandContext(ctx, "gh", args...)
+	c.Dir = s.dir
+	out, err := s.exec.Output(c)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("gh %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("gh %s: %w", args[0], err)
+	}
+	return out, nil
+}
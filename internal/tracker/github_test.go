@@ -0,0 +1,74 @@
+package tracker
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/kastheco/kasmos/cmd/cmd_test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubSource(t *testing.T) {
+	var calls []string
+	mock := cmd_test.NewMockExecutor()
+	mock.OutputFunc = func(c *exec.Cmd) ([]byte, error) {
+		calls = append(calls, strings.Join(c.Args[1:], " "))
+		assert.Equal(t, "/repo", c.Dir)
+		switch {
+		case c.Args[2] == "list":
+			return []byte(`[{"number":12,"title":"Rate limit","state":"OPEN","url":"https://github.com/acme/app/issues/12"}]`), nil
+		case strings.Contains(c.Args[len(c.Args)-3], "body"):
+			return []byte(`{"number":12,"title":"Rate limit","body":"why\n- [x] measure","state":"CLOSED",
+				"url":"https://github.com/acme/app/issues/12","labels":[{"name":"api"}],
+				"milestone":{"title":"v2"},"assignees":[{"login":"ana"},{"login":"bo"}]}`), nil
+		default:
+			return []byte(`{"number":7,"title":"Direct","state":"OPEN"}`), nil
+		}
+	}
+	src := NewGitHubSource(mock, "/repo", "acme/app")
+	ctx := context.Background()
+
+	results, err := src.Search(ctx, "rate limit")
+	require.NoError(t, err)
+	assert.Equal(t, []SearchResult{{ID: "#12", Title: "Rate limit", Status: "open", Project: "acme/app",
+		URL: "https://github.com/acme/app/issues/12"}}, results)
+	assert.Equal(t, "issue list --search rate limit --state all --limit 20 --json number,title,state,url --repo acme/app", calls[0])
+
+	results, err = src.Search(ctx, "#7")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "#7", results[0].ID)
+	assert.True(t, strings.HasPrefix(calls[1], "issue view 7 "), calls[1])
+
+	issue, err := src.Fetch(ctx, "#12")
+	require.NoError(t, err)
+	assert.Equal(t, "GitHub", issue.Source)
+	assert.Equal(t, "closed", issue.Status)
+	assert.Equal(t, []string{"api"}, issue.Labels)
+	assert.Equal(t, []Subtask{{Name: "measure", Done: true}}, issue.Subtasks)
+	assert.Equal(t, []Field{{Name: "Milestone", Value: "v2"}, {Name: "Assignees", Value: "ana, bo"}}, issue.Fields)
+}
+
+func TestGitHubSource_Update(t *testing.T) {
+	var calls []string
+	mock := cmd_test.NewMockExecutor()
+	mock.OutputFunc = func(c *exec.Cmd) ([]byte, error) {
+		calls = append(calls, strings.Join(c.Args[1:], " "))
+		return nil, nil
+	}
+	src := NewGitHubSource(mock, "/repo", "")
+	ctx := context.Background()
+
+	require.NoError(t, src.SetStatus(ctx, "#12", "closed"))
+	require.NoError(t, src.SetStatus(ctx, "#12", "Open"))
+	require.NoError(t, src.Comment(ctx, "#12", "PR: https://github.com/acme/app/pull/3"))
+	assert.Error(t, src.SetStatus(ctx, "#12", "in review"))
+	assert.Equal(t, []string{
+		"issue close 12",
+		"issue reopen 12",
+		"issue comment 12 --body PR: https://github.com/acme/app/pull/3",
+	}, calls)
+}
@@ -0,0 +1,162 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// gitlabIssueRef matches "12", "#12" and issue URLs, capturing the IID.
+var gitlabIssueRef = regexp.MustCompile(`^(?:#?(\d+)|https?://\S+/-/issues/(\d+))$`)
+
+// GitLabSource searches the issues of a GitLab project through the REST API.
+type GitLabSource struct {
+	// baseURL is the instance's web root, e.g. "https://gitlab.com".
+	baseURL string
+	// project is the "group/name" path.
+	project  string
+	token    string
+	tokenEnv string
+}
+
+// NewGitLabSource creates a GitLabSource for project on the instance at
+// baseURL. tokenEnv names the variable token came from, for error messages.
+func NewGitLabSource(baseURL, project, token, tokenEnv string) *GitLabSource {
+	return &GitLabSource{baseURL: strings.TrimSuffix(baseURL, "/"), project: project, token: token, tokenEnv: tokenEnv}
+}
+
+func (s *GitLabSource) Name() string { return "gitlab" }
+
+// gitlabIssue is the subset of GitLab's issue object kasmos reads.
+type gitlabIssue struct {
+	IID         int      `json:"iid"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	State       string   `json:"state"`
+	WebURL      string   `json:"web_url"`
+	Labels      []string `json:"labels"`
+	Milestone   *struct {
+		Title string `json:"title"`
+	} `json:"milestone"`
+	Assignees []struct {
+		Username string `json:"username"`
+	} `json:"assignees"`
+}
+
+func (s *GitLabSource) Search(ctx context.Context, query string) ([]SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if iid := gitlabIID(query); iid != "" {
+		var issue gitlabIssue
+		if err := s.do(ctx, "/issues/"+iid, &issue); err != nil {
+			return nil, err
+		}
+		return []SearchResult{s.result(issue)}, nil
+	}
+	var issues []gitlabIssue
+	if err := s.do(ctx, "/issues?per_page=20&order_by=updated_at&search="+url.QueryEscape(query), &issues); err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, len(issues))
+	for i, issue := range issues {
+		results[i] = s.result(issue)
+	}
+	return results, nil
+}
+
+func (s *GitLabSource) Fetch(ctx context.Context, id string) (*Issue, error) {
+	iid := gitlabIID(id)
+	if iid == "" {
+		return nil, fmt.Errorf("invalid gitlab issue id %q", id)
+	}
+	var raw gitlabIssue
+	if err := s.do(ctx, "/issues/"+iid, &raw); err != nil {
+		return nil, err
+	}
+	issue := &Issue{
+		Source:      "GitLab",
+		ID:          fmt.Sprintf("#%d", raw.IID),
+		Title:       raw.Title,
+		Description: raw.Description,
+		Status:      raw.State,
+		URL:         raw.WebURL,
+		Project:     s.project,
+		ProjectKind: "Project",
+		Labels:      raw.Labels,
+		Subtasks:    TaskList(raw.Description),
+	}
+	if raw.Milestone != nil && raw.Milestone.Title != "" {
+		issue.Fields = append(issue.Fields, Field{Name: "Milestone", Value: raw.Milestone.Title})
+	}
+	if len(raw.Assignees) > 0 {
+		names := make([]string, len(raw.Assignees))
+		for i, a := range raw.Assignees {
+			names[i] = a.Username
+		}
+		issue.Fields = append(issue.Fields, Field{Name: "Assignees", Value: strings.Join(names, ", ")})
+	}
+	return issue, nil
+}
+
+// SetStatus closes or reopens issue id. GitLab issues have no other states.
+func (s *GitLabSource) SetStatus(ctx context.Context, id, status string) error {
+	iid := gitlabIID(id)
+	if iid == "" {
+		return fmt.Errorf("invalid gitlab issue id %q", id)
+	}
+	var event string
+	switch strings.ToLower(status) {
+	case "closed", "close":
+		event = "close"
+	case "opened", "open", "reopen":
+		event = "reopen"
+	default:
+		return fmt.Errorf("gitlab issues can only be opened or closed, not %q", status)
+	}
+	return s.request(ctx, http.MethodPut, "/issues/"+iid, map[string]string{"state_event": event}, nil)
+}
+
+func (s *GitLabSource) Comment(ctx context.Context, id, body string) error {
+	iid := gitlabIID(id)
+	if iid == "" {
+		return fmt.Errorf("invalid gitlab issue id %q", id)
+	}
+	return s.request(ctx, http.MethodPost, "/issues/"+iid+"/notes", map[string]string{"body": body}, nil)
+}
+
+func (s *GitLabSource) result(issue gitlabIssue) SearchResult {
+	return SearchResult{
+		ID:      fmt.Sprintf("#%d", issue.IID),
+		Title:   issue.Title,
+		Status:  issue.State,
+		Project: s.project,
+		URL:     issue.WebURL,
+	}
+}
+
+// do GETs the project API at path (relative to /api/v4/projects/{project})
+// and decodes the JSON response into out.
+func (s *GitLabSource) do(ctx context.Context, path string, out any) error {
+	return s.request(ctx, http.MethodGet, path, nil, out)
+}
+
+// request calls the project API at path with the JSON body in (when non-nil)
+// and decodes the response into out (when non-nil).
+func (s *GitLabSource) request(ctx context.Context, method, path string, in, out any) error {
+	if s.token == "" {
+		return fmt.Errorf("GitLab API token not set. Please export %s first", s.tokenEnv)
+	}
+	endpoint := fmt.Sprintf("%s/api/v4/projects/%s%s", s.baseURL, url.PathEscape(s.project), path)
+	return doJSON(ctx, "gitlab", method, endpoint, map[string]string{"PRIVATE-TOKEN": s.token}, in, out)
+}
+
+// gitlabIID extracts the issue IID from a number, "#number" or issue URL.
+func gitlabIID(ref string) string {
+	m := gitlabIssueRef.FindStringSubmatch(ref)
+	if m == nil {
+		return ""
+	}
+	return m[1] + m[2]
+}
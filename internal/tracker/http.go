@@ -0,0 +1,69 @@
+package tracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// httpClient is shared by the REST and GraphQL sources.
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// apiError is a non-2xx API response.
+type apiError struct {
+	source string
+	status int
+	body   string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s api: %d %s", e.source, e.status, e.body)
+}
+
+// doJSON sends in (when non-nil) as a JSON body to endpoint with headers set
+// and decodes the JSON response into out.
+func doJSON(ctx context.Context, source, method, endpoint string, headers map[string]string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s api: %w", source, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s api: %w", source, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &apiError{source: source, status: resp.StatusCode, body: strings.TrimSpace(string(data))}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s api: decode response: %w", source, err)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package tracker
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// jiraIssueRef matches issue keys ("PROJ-12") and browse URLs, capturing the
+// key.
+var jiraIssueRef = regexp.MustCompile(`^(?:https?://\S+/browse/)?([A-Z][A-Z0-9_]*-\d+)$`)
+
+// JiraSource searches Jira issues through the REST API (v2, which returns
+// descriptions as plain text rather than Atlassian Document Format).
+type JiraSource struct {
+	// baseURL is the site root, e.g. "https://acme.atlassian.net".
+	baseURL string
+	// email selects basic auth with an API token (Jira Cloud); empty sends
+	// the token as a bearer personal access token (Server/Data Center).
+	email    string
+	token    string
+	tokenEnv string
+	// project restricts search to one project key; empty searches all.
+	project string
+}
+
+// NewJiraSource creates a JiraSource for the site at baseURL. tokenEnv names
+// the variable token came from, for error messages.
+func NewJiraSource(baseURL, email, token, tokenEnv, project string) *JiraSource {
+	return &JiraSource{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		email:    email,
+		token:    token,
+		tokenEnv: tokenEnv,
+		project:  project,
+	}
+}
+
+func (s *JiraSource) Name() string { return "jira" }
+
+// jiraStatus is a Jira status with its category ("new", "indeterminate" or
+// "done").
+type jiraStatus struct {
+	Name           string `json:"name"`
+	StatusCategory struct {
+		Key string `json:"key"`
+	} `json:"statusCategory"`
+}
+
+// jiraIssue is the subset of Jira's issue object kasmos reads.
+type jiraIssue struct {
+	Key    string `json:"key"`
+	Fields struct {
+		Summary     string     `json:"summary"`
+		Description string     `json:"description"`
+		Status      jiraStatus `json:"status"`
+		Priority    *struct {
+			Name string `json:"name"`
+		} `json:"priority"`
+		Project struct {
+			Key  string `json:"key"`
+			Name string `json:"name"`
+		} `json:"project"`
+		IssueType struct {
+			Name string `json:"name"`
+		} `json:"issuetype"`
+		Labels   []string `json:"labels"`
+		Assignee *struct {
+			DisplayName string `json:"displayName"`
+		} `json:"assignee"`
+		Subtasks []struct {
+			Key    string `json:"key"`
+			Fields struct {
+				Summary string     `json:"summary"`
+				Status  jiraStatus `json:"status"`
+			} `json:"fields"`
+		} `json:"subtasks"`
+	} `json:"fields"`
+}
+
+const jiraSummaryFields = "summary,status,project"
+
+func (s *JiraSource) Search(ctx context.Context, query string) ([]SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if m := jiraIssueRef.FindStringSubmatch(query); m != nil {
+		var issue jiraIssue
+		if err := s.do(ctx, "/issue/"+m[1]+"?fields="+jiraSummaryFields, &issue); err != nil {
+			return nil, err
+		}
+		return []SearchResult{s.result(issue)}, nil
+	}
+
+	jql := fmt.Sprintf("text ~ %s", jqlString(query))
+	if s.project != "" {
+		jql = fmt.Sprintf("project = %s AND %s", jqlString(s.project), jql)
+	}
+	params := url.Values{"jql": {jql + " ORDER BY updated DESC"}, "fields": {jiraSummaryFields}, "maxResults": {"20"}}
+	var page struct {
+		Issues []jiraIssue `json:"issues"`
+	}
+	// Jira Cloud serves search from /search/jql; Server and Data Center only
+	// know /search.
+	err := s.do(ctx, "/search/jql?"+params.Encode(), &page)
+	var apiErr *apiError
+	if errors.As(err, &apiErr) && apiErr.status == http.StatusNotFound {
+		err = s.do(ctx, "/search?"+params.Encode(), &page)
+	}
+	if err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, len(page.Issues))
+	for i, issue := range page.Issues {
+		results[i] = s.result(issue)
+	}
+	return results, nil
+}
+
+func (s *JiraSource) Fetch(ctx context.Context, id string) (*Issue, error) {
+	var raw jiraIssue
+	fields := "summary,description,status,priority,project,issuetype,labels,assignee,subtasks"
+	if err := s.do(ctx, "/issue/"+url.PathEscape(id)+"?fields="+fields, &raw); err != nil {
+		return nil, err
+	}
+	f := raw.Fields
+	issue := &Issue{
+		Source:      "Jira",
+		ID:          raw.Key,
+		Title:       f.Summary,
+		Description: f.Description,
+		Status:      f.Status.Name,
+		URL:         s.baseURL + "/browse/" + raw.Key,
+		Project:     f.Project.Name,
+		ProjectKind: "Project",
+		Labels:      f.Labels,
+	}
+	if f.Priority != nil {
+		issue.Priority = f.Priority.Name
+	}
+	for _, st := range f.Subtasks {
+		issue.Subtasks = append(issue.Subtasks, Subtask{
+			Name:   st.Key + " " + st.Fields.Summary,
+			Status: st.Fields.Status.Name,
+			Done:   st.Fields.Status.StatusCategory.Key == "done",
+		})
+	}
+	if f.IssueType.Name != "" {
+		issue.Fields = append(issue.Fields, Field{Name: "Type", Value: f.IssueType.Name})
+	}
+	if f.Assignee != nil {
+		issue.Fields = append(issue.Fields, Field{Name: "Assignee", Value: f.Assignee.DisplayName})
+	}
+	return issue, nil
+}
+
+// SetStatus applies the workflow transition of issue id that is named
+// status or leads to a status named status. Issues already in status are
+// left alone.
+func (s *JiraSource) SetStatus(ctx context.Context, id, status string) error {
+	var current jiraIssue
+	if err := s.do(ctx, "/issue/"+url.PathEscape(id)+"?fields=status", &current); err != nil {
+		return err
+	}
+	if strings.EqualFold(current.Fields.Status.Name, status) {
+		return nil
+	}
+	var resp struct {
+		Transitions []struct {
+			ID   string     `json:"id"`
+			Name string     `json:"name"`
+			To   jiraStatus `json:"to"`
+		} `json:"transitions"`
+	}
+	if err := s.do(ctx, "/issue/"+url.PathEscape(id)+"/transitions", &resp); err != nil {
+		return err
+	}
+	for _, t := range resp.Transitions {
+		if strings.EqualFold(t.To.Name, status) || strings.EqualFold(t.Name, status) {
+			body := map[string]any{"transition": map[string]string{"id": t.ID}}
+			return s.request(ctx, http.MethodPost, "/issue/"+url.PathEscape(id)+"/transitions", body, nil)
+		}
+	}
+	return fmt.Errorf("jira issue %s has no transition to %q", id, status)
+}
+
+func (s *JiraSource) Comment(ctx context.Context, id, body string) error {
+	return s.request(ctx, http.MethodPost, "/issue/"+url.PathEscape(id)+"/comment", map[string]string{"body": body}, nil)
+}
+
+func (s *JiraSource) result(issue jiraIssue) SearchResult {
+	return SearchResult{
+		ID:      issue.Key,
+		Title:   issue.Fields.Summary,
+		Status:  issue.Fields.Status.Name,
+		Project: issue.Fields.Project.Key,
+		URL:     s.baseURL + "/browse/" + issue.Key,
+	}
+}
+
+// do GETs the REST API at path (relative to /rest/api/2) and decodes the
+// JSON response into out.
+func (s *JiraSource) do(ctx context.Context, path string, out any) error {
+	return s.request(ctx, http.MethodGet, path, nil, out)
+}
+
+// request calls the REST API at path with the JSON body in (when non-nil)
+// and decodes the response into out (when non-nil).
+func (s *JiraSource) request(ctx context.Context, method, path string, in, out any) error {
+	if s.token == "" {
+		return fmt.Errorf("Jira API token not set. Please export %s first", s.tokenEnv)
+	}
+	auth := "Bearer " + s.token
+	if s.email != "" {
+		auth = "Basic " + base64.StdEncoding.EncodeToString([]byte(s.email+":"+s.token))
+	}
+	return doJSON(ctx, "jira", method, s.baseURL+"/rest/api/2"+path, map[string]string{"Authorization": auth}, in, out)
+}
+
+// jqlString quotes s as a JQL string literal.
+func jqlString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
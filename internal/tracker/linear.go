@@ -0,0 +1,233 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// linearIssueRef matches issue identifiers ("ENG-12") and issue URLs,
+// capturing the identifier.
+var linearIssueRef = regexp.MustCompile(`^(?:https?://linear\.app/\S+/issue/)?([A-Za-z][A-Za-z0-9]*-\d+)(?:/\S*)?$`)
+
+// linearAPI is Linear's GraphQL endpoint.
+const linearAPI = "https://api.linear.app/graphql"
+
+// LinearSource searches Linear issues through the GraphQL API.
+type LinearSource struct {
+	endpoint string
+	apiKey   string
+	// team restricts search to one team key; empty searches every team.
+	team string
+}
+
+// NewLinearSource creates a LinearSource authenticating with a personal API
+// key. team may be empty.
+func NewLinearSource(apiKey, team string) *LinearSource {
+	return &LinearSource{endpoint: linearAPI, apiKey: apiKey, team: team}
+}
+
+func (s *LinearSource) Name() string { return "linear" }
+
+// linearIssue is the subset of Linear's Issue type kasmos reads.
+type linearIssue struct {
+	Identifier    string `json:"identifier"`
+	Title         string `json:"title"`
+	Description   string `json:"description"`
+	URL           string `json:"url"`
+	PriorityLabel string `json:"priorityLabel"`
+	State         struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"state"`
+	Team struct {
+		Key  string `json:"key"`
+		Name string `json:"name"`
+	} `json:"team"`
+	Labels struct {
+		Nodes []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Assignee *struct {
+		Name string `json:"name"`
+	} `json:"assignee"`
+	Children struct {
+		Nodes []linearIssue `json:"nodes"`
+	} `json:"children"`
+}
+
+const linearSummaryFields = `identifier title url state { name type } team { key name }`
+
+func (s *LinearSource) Search(ctx context.Context, query string) ([]SearchResult, error) {
+	query = strings.TrimSpace(query)
+	if m := linearIssueRef.FindStringSubmatch(query); m != nil {
+		var data struct {
+			Issue linearIssue `json:"issue"`
+		}
+		q := `query($id: String!) { issue(id: $id) { ` + linearSummaryFields + ` } }`
+		if err := s.graphql(ctx, q, map[string]any{"id": strings.ToUpper(m[1])}, &data); err != nil {
+			return nil, err
+		}
+		return []SearchResult{linearResult(data.Issue)}, nil
+	}
+
+	vars := map[string]any{"term": query}
+	params, filter := `$term: String!`, ""
+	if s.team != "" {
+		params += `, $team: String!`
+		filter = `, filter: { team: { key: { eq: $team } } }`
+		vars["team"] = s.team
+	}
+	q := `query(` + params + `) {
+  searchIssues(term: $term, first: 20` + filter + `) { nodes { ` + linearSummaryFields + ` } }
+}`
+	var data struct {
+		SearchIssues struct {
+			Nodes []linearIssue `json:"nodes"`
+		} `json:"searchIssues"`
+	}
+	if err := s.graphql(ctx, q, vars, &data); err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, len(data.SearchIssues.Nodes))
+	for i, issue := range data.SearchIssues.Nodes {
+		results[i] = linearResult(issue)
+	}
+	return results, nil
+}
+
+func (s *LinearSource) Fetch(ctx context.Context, id string) (*Issue, error) {
+	q := `query($id: String!) {
+  issue(id: $id) {
+    identifier title description url priorityLabel
+    state { name type } team { key name } labels { nodes { name } } assignee { name }
+    children { nodes { identifier title state { name type } } }
+  }
+}`
+	var data struct {
+		Issue linearIssue `json:"issue"`
+	}
+	if err := s.graphql(ctx, q, map[string]any{"id": id}, &data); err != nil {
+		return nil, err
+	}
+	raw := data.Issue
+	issue := &Issue{
+		Source:      "Linear",
+		ID:          raw.Identifier,
+		Title:       raw.Title,
+		Description: raw.Description,
+		Status:      raw.State.Name,
+		URL:         raw.URL,
+		Project:     raw.Team.Name,
+		ProjectKind: "Team",
+	}
+	if raw.PriorityLabel != "" && raw.PriorityLabel != "No priority" {
+		issue.Priority = raw.PriorityLabel
+	}
+	for _, l := range raw.Labels.Nodes {
+		issue.Labels = append(issue.Labels, l.Name)
+	}
+	for _, c := range raw.Children.Nodes {
+		issue.Subtasks = append(issue.Subtasks, Subtask{
+			Name:   c.Identifier + " " + c.Title,
+			Status: c.State.Name,
+			Done:   c.State.Type == "completed",
+		})
+	}
+	if len(issue.Subtasks) == 0 {
+		issue.Subtasks = TaskList(raw.Description)
+	}
+	if raw.Assignee != nil {
+		issue.Fields = append(issue.Fields, Field{Name: "Assignee", Value: raw.Assignee.Name})
+	}
+	return issue, nil
+}
+
+func linearResult(issue linearIssue) SearchResult {
+	return SearchResult{
+		ID:      issue.Identifier,
+		Title:   issue.Title,
+		Status:  issue.State.Name,
+		Project: issue.Team.Name,
+		URL:     issue.URL,
+	}
+}
+
+// SetStatus moves issue id to the workflow state of its team named status.
+func (s *LinearSource) SetStatus(ctx context.Context, id, status string) error {
+	var data struct {
+		Issue struct {
+			ID    string `json:"id"`
+			State struct {
+				Name string `json:"name"`
+			} `json:"state"`
+			Team struct {
+				States struct {
+					Nodes []struct {
+						ID   string `json:"id"`
+						Name string `json:"name"`
+					} `json:"nodes"`
+				} `json:"states"`
+			} `json:"team"`
+		} `json:"issue"`
+	}
+	const query = `query($id: String!) { issue(id: $id) { id state { name } team { states { nodes { id name } } } } }`
+	if err := s.graphql(ctx, query, map[string]any{"id": id}, &data); err != nil {
+		return err
+	}
+	if strings.EqualFold(data.Issue.State.Name, status) {
+		return nil
+	}
+	for _, state := range data.Issue.Team.States.Nodes {
+		if strings.EqualFold(state.Name, status) {
+			const mutation = `mutation($id: String!, $state: String!) { issueUpdate(id: $id, input: { stateId: $state }) { success } }`
+			var out struct{}
+			return s.graphql(ctx, mutation, map[string]any{"id": data.Issue.ID, "state": state.ID}, &out)
+		}
+	}
+	return fmt.Errorf("linear issue %s has no workflow state %q", id, status)
+}
+
+// Comment posts body on issue id. commentCreate takes the issue's UUID, so
+// identifiers like "ENG-12" are resolved first.
+func (s *LinearSource) Comment(ctx context.Context, id, body string) error {
+	var data struct {
+		Issue struct {
+			ID string `json:"id"`
+		} `json:"issue"`
+	}
+	if err := s.graphql(ctx, `query($id: String!) { issue(id: $id) { id } }`, map[string]any{"id": id}, &data); err != nil {
+		return err
+	}
+	const mutation = `mutation($id: String!, $body: String!) { commentCreate(input: { issueId: $id, body: $body }) { success } }`
+	var out struct{}
+	return s.graphql(ctx, mutation, map[string]any{"id": data.Issue.ID, "body": body}, &out)
+}
+
+// graphql runs query with vars and decodes its data into out.
+func (s *LinearSource) graphql(ctx context.Context, query string, vars map[string]any, out any) error {
+	if s.apiKey == "" {
+		return fmt.Errorf("Linear API key not set")
+	}
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	body := map[string]any{"query": query, "variables": vars}
+	if err := doJSON(ctx, "linear", http.MethodPost, s.endpoint, map[string]string{"Authorization": s.apiKey}, body, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		return fmt.Errorf("linear api: %s", resp.Errors[0].Message)
+	}
+	if err := json.Unmarshal(resp.Data, out); err != nil {
+		return fmt.Errorf("linear api: decode data: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,395 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kastheco/kasmos/internal/mcpclient"
+)
+
+// MCPCaller is the subset of mcpclient.Client that MCPSource needs. Tool
+// calls are bounded by ctx.
+type MCPCaller interface {
+	CallTool(ctx context.Context, name string, args map[string]any) (*mcpclient.ToolResult, error)
+	FindTool(substring string) (mcpclient.Tool, bool)
+}
+
+// ClientCaller adapts an mcpclient.Client to MCPCaller.
+func ClientCaller(c *mcpclient.Client) MCPCaller { return clientCaller{c} }
+
+type clientCaller struct{ *mcpclient.Client }
+
+func (c clientCaller) CallTool(ctx context.Context, name string, args map[string]any) (*mcpclient.ToolResult, error) {
+	return c.CallToolContext(ctx, name, args, nil)
+}
+
+// MCPDialer connects to an MCP server. It is called on first use so that
+// servers are only started (and OAuth only prompted) when the user imports.
+type MCPDialer func(ctx context.Context) (MCPCaller, error)
+
+// MCPSource searches any MCP server exposing issue search and fetch tools.
+// Tool results are read as JSON using the field names common trackers use
+// (id/key/identifier, title/name/summary, description/body, ...).
+type MCPSource struct {
+	name  string
+	dial  MCPDialer
+	tools MCPTools
+
+	mu     sync.Mutex
+	client MCPCaller
+}
+
+// MCPTools names the tools an MCPSource calls. Empty names pick the first
+// tool containing "search", "fetch" or "get", "update", and "comment".
+type MCPTools struct {
+	Search  string
+	Fetch   string
+	Update  string
+	Comment string
+}
+
+// NewMCPSource creates an MCPSource for the server name.
+func NewMCPSource(name string, dial MCPDialer, tools MCPTools) *MCPSource {
+	return &MCPSource{name: name, dial: dial, tools: tools}
+}
+
+func (s *MCPSource) Name() string { return s.name }
+
+// Tool name substrings tried, in order, when no write tool is configured.
+// Specific names come first so "list_comments" is not taken for a writer.
+var (
+	mcpUpdateTools  = []string{"update_issue", "updateissue", "update_task", "edit_issue", "update"}
+	mcpCommentTools = []string{"add_comment", "addcomment", "create_comment", "task_comment", "issue_comment", "comment"}
+)
+
+// Argument names tried, in order, when matching a tool's input schema.
+var (
+	mcpQueryArgs   = []string{"query", "q", "search", "term", "text", "keyword", "jql"}
+	mcpIDArgs      = []string{"id", "issue_id", "issueId", "task_id", "taskId", "key", "issue_key", "issueKey", "identifier", "number"}
+	mcpStatusArgs  = []string{"status", "state", "status_name", "statusName", "state_name", "stateName"}
+	mcpCommentArgs = []string{"body", "comment", "comment_text", "commentText", "text", "content", "message"}
+)
+
+func (s *MCPSource) Search(ctx context.Context, query string) ([]SearchResult, error) {
+	client, tool, err := s.tool(ctx, s.tools.Search, "search")
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.CallTool(ctx, tool.Name, map[string]any{toolArg(tool, mcpQueryArgs): query})
+	if err != nil {
+		s.drop(client)
+		return nil, fmt.Errorf("search: %w", err)
+	}
+	text := ToolText(result)
+	if text == "" {
+		return nil, nil
+	}
+	var v any
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		return nil, fmt.Errorf("parse search results: %w", err)
+	}
+	var results []SearchResult
+	for _, item := range issueList(v) {
+		issue := mapIssue(item)
+		if issue.ID == "" {
+			continue
+		}
+		results = append(results, SearchResult{
+			ID:      issue.ID,
+			Title:   issue.Title,
+			Status:  issue.Status,
+			Project: issue.Project,
+			URL:     issue.URL,
+		})
+	}
+	return results, nil
+}
+
+func (s *MCPSource) Fetch(ctx context.Context, id string) (*Issue, error) {
+	client, tool, err := s.tool(ctx, s.tools.Fetch, "fetch", "get_issue", "get_task", "get")
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.CallTool(ctx, tool.Name, map[string]any{toolArg(tool, mcpIDArgs): id})
+	if err != nil {
+		s.drop(client)
+		return nil, fmt.Errorf("fetch %s: %w", id, err)
+	}
+	text := ToolText(result)
+	if text == "" {
+		return nil, fmt.Errorf("empty response for issue %s", id)
+	}
+
+	var v any
+	if err := json.Unmarshal([]byte(text), &v); err != nil {
+		// Plain-text tools: the first line is the title, the rest the body.
+		title, body, _ := strings.Cut(strings.TrimSpace(text), "\n")
+		return &Issue{
+			Source:      s.name,
+			ID:          id,
+			Title:       strings.TrimSpace(strings.TrimLeft(title, "# ")),
+			Description: strings.TrimSpace(body),
+			Subtasks:    TaskList(body),
+		}, nil
+	}
+	obj := issueObject(v)
+	if obj == nil {
+		return nil, fmt.Errorf("unrecognized %s response for issue %s", s.name, id)
+	}
+	issue := mapIssue(obj)
+	issue.Source = s.name
+	if issue.ID == "" {
+		issue.ID = id
+	}
+	if len(issue.Subtasks) == 0 {
+		issue.Subtasks = TaskList(issue.Description)
+	}
+	return &issue, nil
+}
+
+func (s *MCPSource) SetStatus(ctx context.Context, id, status string) error {
+	client, tool, err := s.tool(ctx, s.tools.Update, mcpUpdateTools...)
+	if err != nil {
+		return err
+	}
+	return s.callWrite(ctx, client, tool, map[string]any{toolArg(tool, mcpIDArgs): id, toolArg(tool, mcpStatusArgs): status})
+}
+
+func (s *MCPSource) Comment(ctx context.Context, id, body string) error {
+	client, tool, err := s.tool(ctx, s.tools.Comment, mcpCommentTools...)
+	if err != nil {
+		return err
+	}
+	return s.callWrite(ctx, client, tool, map[string]any{toolArg(tool, mcpIDArgs): id, toolArg(tool, mcpCommentArgs): body})
+}
+
+// callWrite calls a tool that changes an issue. Tools report failures in
+// the result rather than as protocol errors, so those become errors too.
+func (s *MCPSource) callWrite(ctx context.Context, client MCPCaller, tool mcpclient.Tool, args map[string]any) error {
+	result, err := client.CallTool(ctx, tool.Name, args)
+	if err != nil {
+		s.drop(client)
+		return fmt.Errorf("%s: %w", tool.Name, err)
+	}
+	if result != nil && result.IsError {
+		return fmt.Errorf("%s: %s", tool.Name, ToolText(result))
+	}
+	return nil
+}
+
+// tool connects on first use and returns the named tool, or the first tool
+// containing one of the fallback substrings.
+func (s *MCPSource) tool(ctx context.Context, name string, fallbacks ...string) (MCPCaller, mcpclient.Tool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.client == nil {
+		client, err := s.dial(ctx)
+		if err != nil {
+			return nil, mcpclient.Tool{}, err
+		}
+		s.client = client
+	}
+	if name != "" {
+		if tool, ok := s.client.FindTool(name); ok {
+			return s.client, tool, nil
+		}
+		return nil, mcpclient.Tool{}, fmt.Errorf("no %s tool found in MCP server %s", name, s.name)
+	}
+	for _, sub := range fallbacks {
+		if tool, ok := s.client.FindTool(sub); ok {
+			return s.client, tool, nil
+		}
+	}
+	return nil, mcpclient.Tool{}, fmt.Errorf("no %s tool found in MCP server %s", fallbacks[0], s.name)
+}
+
+// drop forgets client after a failed call so the next call redials: the
+// server may have exited or dropped the session.
+func (s *MCPSource) drop(client MCPCaller) {
+	s.mu.Lock()
+	if s.client != client {
+		s.mu.Unlock()
+		return
+	}
+	s.client = nil
+	s.mu.Unlock()
+	if c, ok := client.(io.Closer); ok {
+		_ = c.Close()
+	}
+}
+
+// ToolText returns the first text content block of a tool result.
+func ToolText(result *mcpclient.ToolResult) string {
+	if result == nil {
+		return ""
+	}
+	for _, c := range result.Content {
+		if c.Type == "text" && c.Text != "" {
+			return c.Text
+		}
+	}
+	return ""
+}
+
+// toolArg picks the argument to pass a value as: the first candidate the
+// tool's input schema declares, else its only required argument, else the
+// first candidate.
+func toolArg(tool mcpclient.Tool, candidates []string) string {
+	var schema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+		Required   []string                   `json:"required"`
+	}
+	if len(tool.InputSchema) > 0 {
+		_ = json.Unmarshal(tool.InputSchema, &schema)
+	}
+	for _, c := range candidates {
+		if _, ok := schema.Properties[c]; ok {
+			return c
+		}
+	}
+	if len(schema.Required) == 1 {
+		return schema.Required[0]
+	}
+	return candidates[0]
+}
+
+// issueList finds the array of issues in a search response: the response
+// itself or an array under a well-known key, possibly nested.
+func issueList(v any) []map[string]any {
+	switch t := v.(type) {
+	case []any:
+		var items []map[string]any
+		for _, item := range t {
+			if m, ok := item.(map[string]any); ok {
+				items = append(items, m)
+			}
+		}
+		return items
+	case map[string]any:
+		for _, key := range []string{"issues", "results", "items", "tasks", "nodes", "data", "values"} {
+			if items := issueList(t[key]); items != nil {
+				return items
+			}
+		}
+	}
+	return nil
+}
+
+// issueObject finds the issue in a fetch response: the response itself when
+// it (or its Jira-style "fields") has a title, or an object under a
+// well-known key.
+func issueObject(v any) map[string]any {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil
+	}
+	if firstString(m, "title", "name", "summary") != "" {
+		return m
+	}
+	if fields, ok := m["fields"].(map[string]any); ok && firstString(fields, "title", "summary") != "" {
+		return m
+	}
+	for _, key := range []string{"issue", "task", "data", "item", "result"} {
+		if obj := issueObject(m[key]); obj != nil {
+			return obj
+		}
+	}
+	return nil
+}
+
+// mapIssue reads the common tracker field names from m.
+func mapIssue(m map[string]any) Issue {
+	// Jira-style responses keep everything but the key under "fields".
+	if fields, ok := m["fields"].(map[string]any); ok {
+		merged := make(map[string]any, len(m)+len(fields))
+		for k, v := range fields {
+			merged[k] = v
+		}
+		for k, v := range m {
+			merged[k] = v
+		}
+		m = merged
+	}
+	issue := Issue{
+		ID:          firstString(m, "identifier", "key", "number", "iid", "id"),
+		Title:       firstString(m, "title", "name", "summary"),
+		Description: firstString(m, "description", "body", "markdown_description", "text_content", "content"),
+		Status:      firstString(m, "status", "state"),
+		Priority:    firstString(m, "priority", "priorityLabel"),
+		URL:         firstString(m, "url", "html_url", "web_url", "link", "permalink"),
+		Project:     firstString(m, "project", "list", "team", "repository", "space"),
+		Labels:      stringList(m, "labels", "tags"),
+	}
+	for _, child := range childList(m, "subtasks", "children", "sub_issues") {
+		st := mapIssue(child)
+		issue.Subtasks = append(issue.Subtasks, Subtask{Name: st.Title, Status: st.Status})
+	}
+	return issue
+}
+
+// firstString returns the first of keys holding a string, a number, or an
+// object with a name-like field.
+func firstString(m map[string]any, keys ...string) string {
+	for _, key := range keys {
+		if s := asString(m[key]); s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+func asString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case map[string]any:
+		return firstString(t, "name", "status", "title", "key", "label")
+	}
+	return ""
+}
+
+// stringList returns the first of keys holding a list, as strings.
+func stringList(m map[string]any, keys ...string) []string {
+	for _, key := range keys {
+		items, ok := m[key].([]any)
+		if !ok {
+			if nested, isMap := m[key].(map[string]any); isMap {
+				items, ok = nested["nodes"].([]any)
+			}
+		}
+		if !ok {
+			continue
+		}
+		var out []string
+		for _, item := range items {
+			if s := asString(item); s != "" {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// childList returns the first of keys holding a list of objects, either
+// directly or as a GraphQL connection ({"nodes": [...]}).
+func childList(m map[string]any, keys ...string) []map[string]any {
+	for _, key := range keys {
+		switch t := m[key].(type) {
+		case []any:
+			return issueList(t)
+		case map[string]any:
+			if nodes, ok := t["nodes"].([]any); ok {
+				return issueList(nodes)
+			}
+		}
+	}
+	return nil
+}
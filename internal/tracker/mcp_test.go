@@ -0,0 +1,174 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kastheco/kasmos/internal/mcpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubMCP answers tool calls with canned text and records the arguments.
+type stubMCP struct {
+	tools   []mcpclient.Tool
+	replies map[string]string
+	// failing names tools whose result is flagged as an error.
+	failing map[string]bool
+	// err fails every call, as a dead server would.
+	err  error
+	args map[string]map[string]any
+}
+
+func (s *stubMCP) CallTool(ctx context.Context, name string, args map[string]any) (*mcpclient.ToolResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.args == nil {
+		s.args = make(map[string]map[string]any)
+	}
+	s.args[name] = args
+	return &mcpclient.ToolResult{
+		Content: []mcpclient.ToolContent{{Type: "text", Text: s.replies[name]}},
+		IsError: s.failing[name],
+	}, nil
+}
+
+func (s *stubMCP) FindTool(sub string) (mcpclient.Tool, bool) {
+	for _, t := range s.tools {
+		if strings.Contains(strings.ToLower(t.Name), strings.ToLower(sub)) {
+			return t, true
+		}
+	}
+	return mcpclient.Tool{}, false
+}
+
+func TestMCPSource_GraphQLShapes(t *testing.T) {
+	stub := &stubMCP{
+		tools: []mcpclient.Tool{
+			{Name: "list_comments"},
+			{Name: "search_issues", InputSchema: json.RawMessage(`{"properties":{"term":{},"limit":{}},"required":["term"]}`)},
+			{Name: "get_issue", InputSchema: json.RawMessage(`{"properties":{"issueId":{}},"required":["issueId"]}`)},
+		},
+		replies: map[string]string{
+			"search_issues": `{"data":{"issues":{"nodes":[
+				{"id":"uuid-1","identifier":"ENG-4","title":"Dark mode","state":{"name":"Todo"},"team":{"name":"Eng"}},
+				{"title":"no id"}]}}}`,
+			"get_issue": `{"issue":{"identifier":"ENG-4","title":"Dark mode","description":"theme","priority":{"label":"High"},
+				"labels":{"nodes":[{"name":"ui"}]},"children":{"nodes":[{"title":"Tokens","state":"Done"}]}}}`,
+		},
+	}
+	src := NewMCPSource("linear", func(context.Context) (MCPCaller, error) { return stub, nil }, MCPTools{})
+	ctx := context.Background()
+
+	results, err := src.Search(ctx, "dark")
+	require.NoError(t, err)
+	assert.Equal(t, []SearchResult{{ID: "ENG-4", Title: "Dark mode", Status: "Todo", Project: "Eng"}}, results)
+	assert.Equal(t, map[string]any{"term": "dark"}, stub.args["search_issues"])
+
+	issue, err := src.Fetch(ctx, "ENG-4")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"issueId": "ENG-4"}, stub.args["get_issue"])
+	assert.Equal(t, &Issue{
+		Source: "linear", ID: "ENG-4", Title: "Dark mode", Description: "theme", Priority: "High",
+		Labels: []string{"ui"}, Subtasks: []Subtask{{Name: "Tokens", Status: "Done"}},
+	}, issue)
+}
+
+func TestMCPSource_JiraShapesAndPlainText(t *testing.T) {
+	stub := &stubMCP{
+		tools: []mcpclient.Tool{{Name: "jira_search"}, {Name: "jira_fetch"}, {Name: "read_page"}},
+		replies: map[string]string{
+			"jira_search": `{"issues":[{"id":"10001","key":"PROJ-9","fields":{"summary":"Greeting","status":{"name":"To Do"}}}]}`,
+			"jira_fetch":  `{"id":"10001","key":"PROJ-9","fields":{"summary":"Greeting","description":"Say hi.\n- [x] copy","project":{"key":"PROJ"}}}`,
+			"read_page":   "# Outage review\n\nWhat happened.\n- [ ] add alert",
+		},
+	}
+	dial := func(context.Context) (MCPCaller, error) { return stub, nil }
+	ctx := context.Background()
+
+	src := NewMCPSource("atlassian", dial, MCPTools{})
+	results, err := src.Search(ctx, "hi")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "PROJ-9", results[0].ID, "key wins over the numeric id")
+	issue, err := src.Fetch(ctx, "PROJ-9")
+	require.NoError(t, err)
+	assert.Equal(t, "Greeting", issue.Title)
+	assert.Equal(t, "PROJ", issue.Project)
+	assert.Equal(t, []Subtask{{Name: "copy", Done: true}}, issue.Subtasks, "task list from the description")
+
+	pages := NewMCPSource("wiki", dial, MCPTools{Search: "jira_search", Fetch: "read_page"})
+	issue, err = pages.Fetch(ctx, "page-1")
+	require.NoError(t, err)
+	assert.Equal(t, &Issue{Source: "wiki", ID: "page-1", Title: "Outage review", Description: "What happened.\n- [ ] add alert",
+		Subtasks: []Subtask{{Name: "add alert"}}}, issue)
+
+	missing := NewMCPSource("wiki", dial, MCPTools{Fetch: "nope"})
+	_, err = missing.Fetch(ctx, "x")
+	assert.ErrorContains(t, err, "no nope tool")
+
+	failing := NewMCPSource("down", func(context.Context) (MCPCaller, error) { return nil, errors.New("boom") }, MCPTools{})
+	_, err = failing.Search(ctx, "x")
+	assert.ErrorContains(t, err, "boom")
+}
+
+func TestMCPSource_Update(t *testing.T) {
+	stub := &stubMCP{
+		tools: []mcpclient.Tool{
+			{Name: "list_comments"},
+			{Name: "update_task", InputSchema: json.RawMessage(`{"properties":{"task_id":{},"status":{}}}`)},
+			{Name: "create_task_comment", InputSchema: json.RawMessage(`{"properties":{"task_id":{},"comment_text":{}}}`)},
+		},
+		replies: map[string]string{"update_task": "unknown status"},
+	}
+	src := NewMCPSource("clickup", func(context.Context) (MCPCaller, error) { return stub, nil }, MCPTools{})
+	ctx := context.Background()
+
+	require.NoError(t, src.SetStatus(ctx, "abc", "in review"))
+	assert.Equal(t, map[string]any{"task_id": "abc", "status": "in review"}, stub.args["update_task"])
+	require.NoError(t, src.Comment(ctx, "abc", "PR opened"))
+	assert.Equal(t, map[string]any{"task_id": "abc", "comment_text": "PR opened"}, stub.args["create_task_comment"])
+	assert.Nil(t, stub.args["list_comments"])
+
+	stub.failing = map[string]bool{"update_task": true}
+	assert.ErrorContains(t, src.SetStatus(ctx, "abc", "shipped"), "unknown status")
+}
+
+func TestMCPSource_CallsHonourContext(t *testing.T) {
+	stub := &stubMCP{tools: []mcpclient.Tool{{Name: "search"}, {Name: "get_issue"}, {Name: "update_issue"}}}
+	src := NewMCPSource("linear", func(context.Context) (MCPCaller, error) { return stub, nil }, MCPTools{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := src.Search(ctx, "x")
+	assert.ErrorIs(t, err, context.Canceled)
+	_, err = src.Fetch(ctx, "ENG-1")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, src.SetStatus(ctx, "ENG-1", "done"), context.Canceled)
+}
+
+func TestMCPSource_RedialsAfterFailedCall(t *testing.T) {
+	stub := &stubMCP{tools: []mcpclient.Tool{{Name: "search"}}, replies: map[string]string{"search": `[]`}, err: errors.New("broken pipe")}
+	dials := 0
+	src := NewMCPSource("linear", func(context.Context) (MCPCaller, error) {
+		dials++
+		return stub, nil
+	}, MCPTools{})
+	ctx := context.Background()
+
+	_, err := src.Search(ctx, "x")
+	assert.ErrorContains(t, err, "broken pipe")
+	stub.err = nil
+	_, err = src.Search(ctx, "x")
+	require.NoError(t, err)
+	_, err = src.Search(ctx, "x")
+	require.NoError(t, err)
+	assert.Equal(t, 2, dials, "redials once after the failure, then reuses the client")
+}
@@ -0,0 +1,234 @@
+package tracker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitLabSource(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("PRIVATE-TOKEN"))
+		assert.True(t, strings.HasPrefix(r.URL.EscapedPath(), "/api/v4/projects/group%2Fapp"), r.URL.EscapedPath())
+		switch r.URL.Path {
+		case "/api/v4/projects/group/app/issues":
+			assert.Equal(t, "flaky ci", r.URL.Query().Get("search"))
+			w.Write([]byte(`[{"iid":3,"title":"Flaky CI","state":"opened","web_url":"https://gl/group/app/-/issues/3"}]`))
+		case "/api/v4/projects/group/app/issues/3":
+			w.Write([]byte(`{"iid":3,"title":"Flaky CI","description":"steps\n- [ ] retry","state":"opened",
+				"labels":["ci"],"milestone":{"title":"M1"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	src := NewGitLabSource(srv.URL+"/", "group/app", "secret", "GITLAB_TOKEN")
+	ctx := context.Background()
+
+	results, err := src.Search(ctx, "flaky ci")
+	require.NoError(t, err)
+	assert.Equal(t, []SearchResult{{ID: "#3", Title: "Flaky CI", Status: "opened", Project: "group/app",
+		URL: "https://gl/group/app/-/issues/3"}}, results)
+
+	results, err = src.Search(ctx, "https://gl/group/app/-/issues/3")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	issue, err := src.Fetch(ctx, "#3")
+	require.NoError(t, err)
+	assert.Equal(t, "GitLab", issue.Source)
+	assert.Equal(t, []Subtask{{Name: "retry"}}, issue.Subtasks)
+	assert.Equal(t, []Field{{Name: "Milestone", Value: "M1"}}, issue.Fields)
+
+	_, err = NewGitLabSource(srv.URL, "group/app", "", "MY_TOKEN").Search(ctx, "x")
+	assert.ErrorContains(t, err, "export MY_TOKEN")
+}
+
+func TestLinearSource(t *testing.T) {
+	var queries []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "lin_key", r.Header.Get("Authorization"))
+		var body struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		queries = append(queries, body.Variables)
+		if _, ok := body.Variables["term"]; ok {
+			assert.Contains(t, body.Query, "team: { key: { eq: $team } }")
+			w.Write([]byte(`{"data":{"searchIssues":{"nodes":[{"identifier":"ENG-4","title":"Dark mode",
+				"state":{"name":"Todo"},"team":{"key":"ENG","name":"Engineering"}}]}}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"issue":{"identifier":"ENG-4","title":"Dark mode","description":"theme",
+			"priorityLabel":"High","state":{"name":"Todo","type":"unstarted"},"team":{"name":"Engineering"},
+			"labels":{"nodes":[{"name":"ui"}]},"children":{"nodes":[
+			{"identifier":"ENG-5","title":"Tokens","state":{"name":"Shipped","type":"completed"}}]}}}}`))
+	}))
+	defer srv.Close()
+	src := NewLinearSource("lin_key", "ENG")
+	src.endpoint = srv.URL
+	ctx := context.Background()
+
+	results, err := src.Search(ctx, "dark")
+	require.NoError(t, err)
+	assert.Equal(t, []SearchResult{{ID: "ENG-4", Title: "Dark mode", Status: "Todo", Project: "Engineering"}}, results)
+	assert.Equal(t, "ENG", queries[0]["team"])
+
+	_, err = src.Search(ctx, "https://linear.app/acme/issue/eng-4/dark-mode")
+	require.NoError(t, err)
+	assert.Equal(t, "ENG-4", queries[1]["id"])
+
+	issue, err := src.Fetch(ctx, "ENG-4")
+	require.NoError(t, err)
+	assert.Equal(t, "High", issue.Priority)
+	assert.Equal(t, []Subtask{{Name: "ENG-5 Tokens", Status: "Shipped", Done: true}}, issue.Subtasks)
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"errors":[{"message":"Entity not found"}]}`))
+	})
+	_, err = src.Fetch(ctx, "ENG-404")
+	assert.ErrorContains(t, err, "Entity not found")
+}
+
+func TestJiraSource(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		user, pass, ok := r.BasicAuth()
+		require.True(t, ok)
+		assert.Equal(t, "me@acme.com", user)
+		assert.Equal(t, "tok", pass)
+		switch r.URL.Path {
+		case "/rest/api/2/search/jql":
+			http.NotFound(w, r) // Data Center
+		case "/rest/api/2/search":
+			assert.Equal(t, `project = "PROJ" AND text ~ "say \"hi\"" ORDER BY updated DESC`, r.URL.Query().Get("jql"))
+			w.Write([]byte(`{"issues":[{"key":"PROJ-9","fields":{"summary":"Greeting","status":{"name":"To Do"},"project":{"key":"PROJ"}}}]}`))
+		case "/rest/api/2/issue/PROJ-9":
+			w.Write([]byte(`{"key":"PROJ-9","fields":{"summary":"Greeting","description":"Say hi.","status":{"name":"To Do"},
+				"priority":{"name":"Major"},"project":{"key":"PROJ","name":"Platform"},"issuetype":{"name":"Story"},
+				"subtasks":[{"key":"PROJ-10","fields":{"summary":"Copy","status":{"name":"Closed","statusCategory":{"key":"done"}}}}]}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+	src := NewJiraSource(srv.URL, "me@acme.com", "tok", "JIRA_API_TOKEN", "PROJ")
+	ctx := context.Background()
+
+	results, err := src.Search(ctx, `say "hi"`)
+	require.NoError(t, err)
+	assert.Equal(t, []SearchResult{{ID: "PROJ-9", Title: "Greeting", Status: "To Do", Project: "PROJ", URL: srv.URL + "/browse/PROJ-9"}}, results)
+	assert.Equal(t, []string{"/rest/api/2/search/jql", "/rest/api/2/search"}, paths, "falls back to the legacy search")
+
+	results, err = src.Search(ctx, srv.URL+"/browse/PROJ-9")
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	issue, err := src.Fetch(ctx, "PROJ-9")
+	require.NoError(t, err)
+	assert.Equal(t, "Jira", issue.Source)
+	assert.Equal(t, "Major", issue.Priority)
+	assert.Equal(t, "Platform", issue.Project)
+	assert.Equal(t, []Subtask{{Name: "PROJ-10 Copy", Status: "Closed", Done: true}}, issue.Subtasks)
+	assert.Equal(t, []Field{{Name: "Type", Value: "Story"}}, issue.Fields)
+}
+
+// recordedRequest is a write a test server received.
+type recordedRequest struct {
+	method, path string
+	body         map[string]any
+}
+
+func recordWrites(t *testing.T, reqs *[]recordedRequest, r *http.Request) {
+	if r.Method == http.MethodGet {
+		return
+	}
+	var body map[string]any
+	require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	*reqs = append(*reqs, recordedRequest{r.Method, r.URL.Path, body})
+}
+
+func TestGitLabSource_Update(t *testing.T) {
+	var writes []recordedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recordWrites(t, &writes, r)
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	src := NewGitLabSource(srv.URL, "group/app", "secret", "GITLAB_TOKEN")
+	ctx := context.Background()
+
+	require.NoError(t, src.SetStatus(ctx, "#3", "closed"))
+	require.NoError(t, src.Comment(ctx, "#3", "done"))
+	assert.Error(t, src.SetStatus(ctx, "#3", "in review"))
+	assert.Equal(t, []recordedRequest{
+		{http.MethodPut, "/api/v4/projects/group/app/issues/3", map[string]any{"state_event": "close"}},
+		{http.MethodPost, "/api/v4/projects/group/app/issues/3/notes", map[string]any{"body": "done"}},
+	}, writes)
+}
+
+func TestJiraSource_Update(t *testing.T) {
+	var writes []recordedRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recordWrites(t, &writes, r)
+		switch r.URL.Path {
+		case "/rest/api/2/issue/PROJ-9":
+			w.Write([]byte(`{"key":"PROJ-9","fields":{"status":{"name":"In Progress"}}}`))
+		case "/rest/api/2/issue/PROJ-9/transitions":
+			w.Write([]byte(`{"transitions":[{"id":"21","name":"Start","to":{"name":"In Progress"}},
+				{"id":"31","name":"Send to review","to":{"name":"In Review"}}]}`))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+	src := NewJiraSource(srv.URL, "", "pat", "JIRA_API_TOKEN", "")
+	ctx := context.Background()
+
+	require.NoError(t, src.SetStatus(ctx, "PROJ-9", "in progress"), "already there")
+	require.NoError(t, src.SetStatus(ctx, "PROJ-9", "in review"))
+	require.NoError(t, src.Comment(ctx, "PROJ-9", "PR opened"))
+	assert.ErrorContains(t, src.SetStatus(ctx, "PROJ-9", "Shipped"), `no transition to "Shipped"`)
+	assert.Equal(t, []recordedRequest{
+		{http.MethodPost, "/rest/api/2/issue/PROJ-9/transitions", map[string]any{"transition": map[string]any{"id": "31"}}},
+		{http.MethodPost, "/rest/api/2/issue/PROJ-9/comment", map[string]any{"body": "PR opened"}},
+	}, writes)
+}
+
+func TestLinearSource_Update(t *testing.T) {
+	var mutations []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Query     string         `json:"query"`
+			Variables map[string]any `json:"variables"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if strings.HasPrefix(body.Query, "mutation") {
+			mutations = append(mutations, body.Variables)
+			w.Write([]byte(`{"data":{"result":{"success":true}}}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"issue":{"id":"uuid-4","state":{"name":"Todo"},"team":{"states":{"nodes":[
+			{"id":"s-todo","name":"Todo"},{"id":"s-review","name":"In Review"}]}}}}}`))
+	}))
+	defer srv.Close()
+	src := NewLinearSource("lin_key", "")
+	src.endpoint = srv.URL
+	ctx := context.Background()
+
+	require.NoError(t, src.SetStatus(ctx, "ENG-4", "in review"))
+	require.NoError(t, src.Comment(ctx, "ENG-4", "PR opened"))
+	assert.ErrorContains(t, src.SetStatus(ctx, "ENG-4", "Shipped"), "no workflow state")
+	assert.Equal(t, []map[string]any{
+		{"id": "uuid-4", "state": "s-review"},
+		{"id": "uuid-4", "body": "PR opened"},
+	}, mutations)
+}
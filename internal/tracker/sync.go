@@ -0,0 +1,300 @@
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/config/planparser"
+	"github.com/kastheco/kasmos/config/planstore"
+	"github.com/kastheco/kasmos/log"
+)
+
+const (
+	// syncOpTimeout bounds one attempt at writing to a tracker.
+	syncOpTimeout = 30 * time.Second
+	// maxSyncAttempts is how often an update is tried before it is dropped.
+	maxSyncAttempts = 10
+	// maxSyncBackoff caps the delay between attempts.
+	maxSyncBackoff = 10 * time.Minute
+)
+
+// PlanLookup returns the stored entry of a plan.
+type PlanLookup func(planFile string) (planstore.PlanEntry, error)
+
+// Syncer writes plan status changes back to the issues plans were imported
+// from. Updates are queued and retried with backoff by Run, so a slow or
+// unreachable tracker never holds up a transition.
+type Syncer struct {
+	cfg    config.IssueSyncConfig
+	lookup PlanLookup
+	// backoff is the delay before the first retry; it doubles per attempt.
+	backoff time.Duration
+
+	mu      sync.Mutex
+	sources map[string]IssueSource
+	queue   []*syncJob
+	wake    chan struct{}
+}
+
+// syncJob is one queued write: a status change, a comment, or both.
+type syncJob struct {
+	planFile string
+	// status is the plan status entered; empty for comment-only jobs.
+	status planfsm.Status
+	// comment overrides the comment derived from status.
+	comment string
+	// statusDone is set once the tracker status is written (or superseded
+	// by a later transition), so retries only repeat the comment.
+	statusDone bool
+	attempts   int
+	next       time.Time
+}
+
+// NewSyncer creates a Syncer reading plan entries through lookup.
+func NewSyncer(cfg config.IssueSyncConfig, lookup PlanLookup) *Syncer {
+	return &Syncer{
+		cfg:     cfg,
+		lookup:  lookup,
+		backoff: 10 * time.Second,
+		sources: make(map[string]IssueSource),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// SetSources replaces the trackers updates are sent to. Updates for a
+// tracker that is not (yet) known wait in the queue.
+func (s *Syncer) SetSources(sources []IssueSource) {
+	s.mu.Lock()
+	s.sources = make(map[string]IssueSource, len(sources))
+	for _, src := range sources {
+		s.sources[src.Name()] = src
+	}
+	s.mu.Unlock()
+	s.poke()
+}
+
+// Observe is a planfsm.TransitionObserver queueing the new status of
+// planFile. It never blocks.
+func (s *Syncer) Observe(planFile string, _, to planfsm.Status, _ planfsm.Event) {
+	if s.cfg.Disabled {
+		return
+	}
+	s.mu.Lock()
+	// A newer status wins over one still waiting to be written.
+	for _, j := range s.queue {
+		if j.planFile == planFile {
+			j.statusDone = true
+		}
+	}
+	s.queue = append(s.queue, &syncJob{planFile: planFile, status: to})
+	s.mu.Unlock()
+	s.poke()
+}
+
+// PullRequestOpened queues a comment linking the pull request of planFile.
+func (s *Syncer) PullRequestOpened(planFile, url string) {
+	if s.cfg.Disabled || s.cfg.NoComments || url == "" {
+		return
+	}
+	s.mu.Lock()
+	s.queue = append(s.queue, &syncJob{planFile: planFile, comment: "kasmos opened a pull request for this issue: " + url, statusDone: true})
+	s.mu.Unlock()
+	s.poke()
+}
+
+// Pending returns the number of queued updates.
+func (s *Syncer) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+func (s *Syncer) poke() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run works through the queue until ctx is cancelled.
+func (s *Syncer) Run(ctx context.Context) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		wait := s.flush(ctx)
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-timer.C:
+		}
+	}
+}
+
+// flush attempts every due job in queue order and returns how long to wait
+// for the next one.
+func (s *Syncer) flush(ctx context.Context) time.Duration {
+	s.mu.Lock()
+	due := make([]*syncJob, 0, len(s.queue))
+	now := time.Now()
+	for _, j := range s.queue {
+		if !j.next.After(now) {
+			due = append(due, j)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, j := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		err := s.attempt(ctx, j)
+		s.mu.Lock()
+		if err == nil {
+			s.remove(j)
+		} else {
+			j.attempts++
+			if j.attempts >= maxSyncAttempts {
+				log.WarningLog.Printf("issue sync: giving up on %s after %d attempts: %v", j.planFile, j.attempts, err)
+				s.remove(j)
+			} else {
+				delay := s.backoff << (j.attempts - 1)
+				if delay > maxSyncBackoff || delay <= 0 {
+					delay = maxSyncBackoff
+				}
+				j.next = time.Now().Add(delay)
+				log.WarningLog.Printf("issue sync: %s: %v (retrying in %s)", j.planFile, err, delay)
+			}
+		}
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wait := time.Hour
+	now = time.Now()
+	for _, j := range s.queue {
+		if d := j.next.Sub(now); d < wait {
+			wait = max(d, 0)
+		}
+	}
+	return wait
+}
+
+// remove drops j from the queue. The caller holds s.mu.
+func (s *Syncer) remove(j *syncJob) {
+	for i, q := range s.queue {
+		if q == j {
+			s.queue = append(s.queue[:i], s.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// attempt writes j to its tracker. Plans not imported from an issue, and
+// trackers that cannot be written to, complete without doing anything.
+func (s *Syncer) attempt(ctx context.Context, j *syncJob) error {
+	entry, err := s.lookup(j.planFile)
+	if err != nil {
+		return fmt.Errorf("look up plan: %w", err)
+	}
+	if entry.IssueSource == "" || entry.IssueID == "" {
+		return nil
+	}
+	s.mu.Lock()
+	src, ok := s.sources[entry.IssueSource]
+	statusDone := j.statusDone
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("tracker %s is not available", entry.IssueSource)
+	}
+	updater, ok := src.(IssueUpdater)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, syncOpTimeout)
+	defer cancel()
+	if !statusDone {
+		if status := s.cfg.TrackerStatus(entry.IssueSource, string(j.status)); status != "" {
+			if err := updater.SetStatus(ctx, entry.IssueID, status); err != nil {
+				return fmt.Errorf("set %s %s to %q: %w", entry.IssueSource, entry.IssueID, status, err)
+			}
+		}
+		s.mu.Lock()
+		j.statusDone = true
+		s.mu.Unlock()
+	}
+	if s.cfg.NoComments {
+		return nil
+	}
+	comment := j.comment
+	if comment == "" {
+		comment = statusComment(entry, j.status)
+	}
+	if comment == "" {
+		return nil
+	}
+	if err := updater.Comment(ctx, entry.IssueID, comment); err != nil {
+		return fmt.Errorf("comment on %s %s: %w", entry.IssueSource, entry.IssueID, err)
+	}
+	return nil
+}
+
+// statusComment is the comment posted when a plan enters status, or "" for
+// statuses that are not worth a comment. The comment on completion
+// summarizes the plan's goal and tasks.
+func statusComment(entry planstore.PlanEntry, status planfsm.Status) string {
+	name := entry.Description
+	if name == "" {
+		name = entry.Filename
+	}
+	var sections []string
+	switch status {
+	case planfsm.StatusReviewing:
+		sections = append(sections, fmt.Sprintf("kasmos finished implementing %q; it is now in review.", name))
+	case planfsm.StatusDone:
+		sections = append(sections, fmt.Sprintf("kasmos plan %q is done.", name))
+		if plan, err := planparser.Parse(entry.Content); err == nil {
+			if plan.Goal != "" {
+				sections = append(sections, "Goal: "+plan.Goal)
+			}
+			var tasks []string
+			for _, w := range plan.Waves {
+				for _, t := range w.Tasks {
+					tasks = append(tasks, "- "+t.Title)
+				}
+			}
+			if len(tasks) > 0 {
+				sections = append(sections, "Tasks:\n"+strings.Join(tasks, "\n"))
+			}
+		}
+	case planfsm.StatusCancelled:
+		return fmt.Sprintf("kasmos plan %q was cancelled.", name)
+	default:
+		return ""
+	}
+	var refs []string
+	if entry.Branch != "" {
+		refs = append(refs, "Branch: "+entry.Branch)
+	}
+	if entry.PRURL != "" {
+		refs = append(refs, "Pull request: "+entry.PRURL)
+	}
+	if len(refs) > 0 {
+		sections = append(sections, strings.Join(refs, "\n"))
+	}
+	return strings.Join(sections, "\n\n")
+}
@@ -0,0 +1,130 @@
+package tracker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/config/planstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUpdater records writes and fails the first failures of them.
+type fakeUpdater struct {
+	mu       sync.Mutex
+	failures int
+	writes   []string
+}
+
+func (f *fakeUpdater) Name() string { return "jira" }
+
+func (f *fakeUpdater) Search(context.Context, string) ([]SearchResult, error) { return nil, nil }
+
+func (f *fakeUpdater) Fetch(context.Context, string) (*Issue, error) { return nil, nil }
+
+func (f *fakeUpdater) SetStatus(_ context.Context, id, status string) error {
+	return f.write(fmt.Sprintf("status %s %s", id, status))
+}
+
+func (f *fakeUpdater) Comment(_ context.Context, id, body string) error {
+	return f.write(fmt.Sprintf("comment %s %s", id, body))
+}
+
+func (f *fakeUpdater) write(w string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("tracker unavailable")
+	}
+	f.writes = append(f.writes, w)
+	return nil
+}
+
+func (f *fakeUpdater) recorded() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.writes...)
+}
+
+func newTestSyncer(cfg config.IssueSyncConfig, plans map[string]planstore.PlanEntry) *Syncer {
+	s := NewSyncer(cfg, func(planFile string) (planstore.PlanEntry, error) {
+		entry, ok := plans[planFile]
+		if !ok {
+			return planstore.PlanEntry{}, fmt.Errorf("plan not found")
+		}
+		return entry, nil
+	})
+	s.backoff = time.Millisecond
+	return s
+}
+
+func TestSyncer_RetriesUntilWritten(t *testing.T) {
+	plans := map[string]planstore.PlanEntry{
+		"imported.md": {Filename: "imported.md", Description: "Dark mode", IssueSource: "jira", IssueID: "PROJ-9",
+			PRURL: "https://github.com/acme/app/pull/3"},
+		"local.md": {Filename: "local.md"},
+	}
+	s := newTestSyncer(config.IssueSyncConfig{}, plans)
+	updater := &fakeUpdater{failures: 2}
+
+	// Transitions queue even before the tracker is detected.
+	s.Observe("local.md", planfsm.StatusReady, planfsm.StatusPlanning, planfsm.PlanStart)
+	s.Observe("imported.md", planfsm.StatusImplementing, planfsm.StatusReviewing, planfsm.ImplementFinished)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+	s.SetSources([]IssueSource{updater})
+
+	require.Eventually(t, func() bool { return s.Pending() == 0 }, 5*time.Second, time.Millisecond)
+	assert.Equal(t, []string{
+		"status PROJ-9 in review",
+		"comment PROJ-9 kasmos finished implementing \"Dark mode\"; it is now in review.\n\n" +
+			"Pull request: https://github.com/acme/app/pull/3",
+	}, updater.recorded())
+}
+
+func TestSyncer_NewerStatusSupersedesQueued(t *testing.T) {
+	plans := map[string]planstore.PlanEntry{
+		"imported.md": {Filename: "imported.md", IssueSource: "jira", IssueID: "PROJ-9"},
+	}
+	s := newTestSyncer(config.IssueSyncConfig{NoComments: true}, plans)
+	updater := &fakeUpdater{}
+	s.SetSources([]IssueSource{updater})
+
+	s.Observe("imported.md", planfsm.StatusReady, planfsm.StatusImplementing, planfsm.ImplementStart)
+	s.Observe("imported.md", planfsm.StatusImplementing, planfsm.StatusReviewing, planfsm.ImplementFinished)
+	s.PullRequestOpened("imported.md", "https://github.com/acme/app/pull/3")
+	assert.Equal(t, 2, s.Pending(), "comments are off")
+
+	s.flush(context.Background())
+	assert.Equal(t, []string{"status PROJ-9 in review"}, updater.recorded())
+	assert.Zero(t, s.Pending())
+}
+
+func TestSyncer_Disabled(t *testing.T) {
+	s := newTestSyncer(config.IssueSyncConfig{Disabled: true}, nil)
+	s.Observe("imported.md", planfsm.StatusReviewing, planfsm.StatusDone, planfsm.ReviewApproved)
+	s.PullRequestOpened("imported.md", "https://github.com/acme/app/pull/3")
+	assert.Zero(t, s.Pending())
+}
+
+func TestStatusComment_DoneSummarizesPlan(t *testing.T) {
+	entry := planstore.PlanEntry{
+		Filename:    "2026-02-28-dark-mode.md",
+		Description: "Dark mode",
+		Branch:      "plan/dark-mode",
+		Content: "# Dark mode\n\n**Goal:** Add a dark theme.\n\n## Wave 1\n\n### Task 1: Tokens\n\nx\n\n" +
+			"## Wave 2\n\n### Task 2: Toggle\n\ny\n",
+	}
+	assert.Equal(t, "kasmos plan \"Dark mode\" is done.\n\nGoal: Add a dark theme.\n\nTasks:\n- Tokens\n- Toggle\n\n"+
+		"Branch: plan/dark-mode", statusComment(entry, planfsm.StatusDone))
+	assert.Empty(t, statusComment(entry, planfsm.StatusPlanning))
+}
@@ -0,0 +1,168 @@
+// Package tracker imports plans from issue trackers. Every tracker is an
+// IssueSource; the TUI's import overlay searches all detected sources and
+// scaffolds a plan from the issue the user picks. Sources that are also an
+// IssueUpdater have plan status changes synced back by a Syncer.
+package tracker
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// IssueSource is an issue tracker plans can be imported from.
+type IssueSource interface {
+	// Name identifies the source in the import overlay, e.g. "github".
+	Name() string
+	// Search finds issues matching query. A query that is an issue ID, key
+	// or URL returns that issue alone.
+	Search(ctx context.Context, query string) ([]SearchResult, error)
+	// Fetch returns the full issue for the ID of a SearchResult.
+	Fetch(ctx context.Context, id string) (*Issue, error)
+}
+
+// IssueUpdater is an IssueSource that can write back to its issues.
+type IssueUpdater interface {
+	IssueSource
+	// SetStatus moves issue id to the tracker status named status.
+	SetStatus(ctx context.Context, id, status string) error
+	// Comment posts body as a comment on issue id.
+	Comment(ctx context.Context, id, body string) error
+}
+
+// SearchResult is one issue in a search listing.
+type SearchResult struct {
+	ID      string
+	Title   string
+	Status  string
+	Project string
+	URL     string
+}
+
+// Issue is a tracker issue mapped to what a plan needs: its name, a
+// description and the subtasks to start the breakdown from.
+type Issue struct {
+	// Source labels the tracker in the scaffold, e.g. "GitHub".
+	Source      string
+	ID          string
+	Title       string
+	Description string
+	Status      string
+	Priority    string
+	URL         string
+	// Project is the list, repository, team or project holding the issue and
+	// ProjectKind names which of those it is, e.g. "Repository".
+	Project     string
+	ProjectKind string
+	Labels      []string
+	Subtasks    []Subtask
+	// Fields are extra tracker fields (custom fields, milestone, assignee).
+	Fields []Field
+}
+
+// Subtask is a child issue or task list item.
+type Subtask struct {
+	Name   string
+	Status string
+	// Done marks the subtask finished when the tracker reports it
+	// separately from Status.
+	Done bool
+}
+
+// Field is a named tracker field value.
+type Field struct {
+	Name  string
+	Value string
+}
+
+var (
+	nonAlphanumeric = regexp.MustCompile(`[^a-z0-9]+`)
+	taskListItem    = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.+?)\s*$`)
+)
+
+// ScaffoldPlan generates a plan markdown from an issue. The issue details
+// are kept as reference for the planner agent that writes the real plan.
+func ScaffoldPlan(issue Issue) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", issue.Title)
+
+	description := strings.TrimSpace(issue.Description)
+	multiline := strings.Contains(description, "\n")
+	if description != "" && !multiline {
+		fmt.Fprintf(&b, "**Goal:** %s\n\n", description)
+	}
+	if issue.ID != "" {
+		fmt.Fprintf(&b, "**Source:** %s %s", issue.Source, issue.ID)
+		if issue.URL != "" {
+			fmt.Fprintf(&b, " (%s)", issue.URL)
+		}
+		b.WriteString("\n\n")
+	}
+	if issue.Status != "" {
+		fmt.Fprintf(&b, "**%s Status:** %s\n\n", issue.Source, issue.Status)
+	}
+	if issue.Priority != "" {
+		fmt.Fprintf(&b, "**Priority:** %s\n\n", issue.Priority)
+	}
+	if issue.Project != "" {
+		kind := issue.ProjectKind
+		if kind == "" {
+			kind = "Project"
+		}
+		fmt.Fprintf(&b, "**%s:** %s\n\n", kind, issue.Project)
+	}
+	if len(issue.Labels) > 0 {
+		fmt.Fprintf(&b, "**Labels:** %s\n\n", strings.Join(issue.Labels, ", "))
+	}
+
+	if multiline {
+		fmt.Fprintf(&b, "## Reference: %s Description\n\n%s\n\n", issue.Source, description)
+	}
+	if len(issue.Subtasks) > 0 {
+		fmt.Fprintf(&b, "## Reference: %s Subtasks\n\n", issue.Source)
+		for _, st := range issue.Subtasks {
+			checkbox := "- [ ] "
+			if st.Done || isDone(st.Status) {
+				checkbox = "- [x] "
+			}
+			fmt.Fprintf(&b, "%s%s\n", checkbox, st.Name)
+		}
+		b.WriteString("\n")
+	}
+	if len(issue.Fields) > 0 {
+		b.WriteString("## Reference: Custom Fields\n\n")
+		for _, f := range issue.Fields {
+			fmt.Fprintf(&b, "- **%s:** %s\n", f.Name, f.Value)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// ScaffoldFilename generates a plan filename from an issue title and date.
+func ScaffoldFilename(name, date string) string {
+	slug := strings.ToLower(strings.TrimSpace(name))
+	slug = nonAlphanumeric.ReplaceAllString(slug, "-")
+	slug = strings.Trim(slug, "-")
+	return fmt.Sprintf("%s-%s.md", date, slug)
+}
+
+// TaskList returns the markdown task list items ("- [ ] ...") in body as
+// subtasks. Trackers without child issues keep their breakdown there.
+func TaskList(body string) []Subtask {
+	var subtasks []Subtask
+	for _, line := range strings.Split(body, "\n") {
+		m := taskListItem.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		subtasks = append(subtasks, Subtask{Name: m[2], Done: m[1] != " "})
+	}
+	return subtasks
+}
+
+func isDone(status string) bool {
+	s := strings.ToLower(status)
+	return s == "done" || s == "complete" || s == "completed" || s == "closed"
+}
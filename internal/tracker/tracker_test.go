@@ -0,0 +1,54 @@
+package tracker
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kastheco/kasmos/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	log.Initialize(false)
+	defer log.Close()
+	os.Exit(m.Run())
+}
+
+func TestScaffoldPlan(t *testing.T) {
+	md := ScaffoldPlan(Issue{
+		Source:      "GitHub",
+		ID:          "#12",
+		Title:       "Rate limit the API",
+		Description: "Clients hammer /search.\n\n- [x] measure\n- [ ] add limiter",
+		Status:      "open",
+		URL:         "https://github.com/acme/app/issues/12",
+		Project:     "acme/app",
+		ProjectKind: "Repository",
+		Labels:      []string{"api", "perf"},
+		Subtasks:    []Subtask{{Name: "measure", Done: true}, {Name: "add limiter"}},
+		Fields:      []Field{{Name: "Milestone", Value: "v2"}},
+	})
+	assert.Contains(t, md, "# Rate limit the API\n")
+	assert.NotContains(t, md, "**Goal:**", "multi-line descriptions go to a reference section")
+	assert.Contains(t, md, "**Source:** GitHub #12 (https://github.com/acme/app/issues/12)")
+	assert.Contains(t, md, "**GitHub Status:** open")
+	assert.Contains(t, md, "**Repository:** acme/app")
+	assert.Contains(t, md, "**Labels:** api, perf")
+	assert.Contains(t, md, "## Reference: GitHub Description\n\nClients hammer /search.")
+	assert.Contains(t, md, "## Reference: GitHub Subtasks\n\n- [x] measure\n- [ ] add limiter\n")
+	assert.Contains(t, md, "- **Milestone:** v2")
+
+	md = ScaffoldPlan(Issue{Source: "Jira", ID: "PROJ-1", Title: "X", Description: "One line.", Project: "Platform"})
+	assert.Contains(t, md, "**Goal:** One line.")
+	assert.Contains(t, md, "**Project:** Platform")
+}
+
+func TestTaskList(t *testing.T) {
+	body := "intro\n- [ ] first\n* [x] second  \n  + [X] nested\n- not a task\n-[ ] no space"
+	assert.Equal(t, []Subtask{
+		{Name: "first"},
+		{Name: "second", Done: true},
+		{Name: "nested", Done: true},
+	}, TaskList(body))
+	assert.Nil(t, TaskList("no tasks here"))
+}
@@ -60,6 +60,13 @@ const (
 	KeyTmuxBrowser // t - browse orphaned tmux sessions
 
 	KeyAuditToggle // L - toggle audit log pane visibility
+	KeyAnalytics   // A - show delivery analytics
+
+	// Diff tab keybindings
+	KeyDiffSideBySide // | - toggle side-by-side diff
+	KeyDiffPrevCommit // [ - previous branch commit
+	KeyDiffNextCommit // ] - next branch commit
+	KeyDiffTaskFilter // f - cycle the task filter
 )
 
 // Backward-compatible aliases; prefer KeyInfoTab/KeyTabInfo.
@@ -97,6 +104,7 @@ var GlobalKeyStringsMap = map[string]KeyName{
 	"t":      KeyTmuxBrowser,
 	"s":      KeySpawnAgent,
 	"L":      KeyAuditToggle,
+	"A":      KeyAnalytics,
 	"T":      KeyFocusList,
 	"p":      KeyViewPlan,
 	"ctrl+s": KeyToggleSidebar,
@@ -104,6 +112,10 @@ var GlobalKeyStringsMap = map[string]KeyName{
 	"!":      KeyTabAgent,
 	"@":      KeyTabDiff,
 	"#":      KeyTabInfo,
+	"|":      KeyDiffSideBySide,
+	"[":      KeyDiffPrevCommit,
+	"]":      KeyDiffNextCommit,
+	"f":      KeyDiffTaskFilter,
 }
 
 // GlobalkeyBindings is a global, immutable map of KeyName tot keybinding.
@@ -251,6 +263,27 @@ var GlobalkeyBindings = map[KeyName]key.Binding{
 		key.WithKeys("L"),
 		key.WithHelp("L", "log"),
 	),
+	KeyAnalytics: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "analytics"),
+	),
+
+	KeyDiffSideBySide: key.NewBinding(
+		key.WithKeys("|"),
+		key.WithHelp("|", "split diff"),
+	),
+	KeyDiffPrevCommit: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "prev commit"),
+	),
+	KeyDiffNextCommit: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("[/]", "commits"),
+	),
+	KeyDiffTaskFilter: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "task filter"),
+	),
 
 	// -- Special keybindings --
 
@@ -7,11 +7,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/kastheco/kasmos/app"
 	cmd2 "github.com/kastheco/kasmos/cmd"
 	"github.com/kastheco/kasmos/config"
 	"github.com/kastheco/kasmos/daemon"
+	"github.com/kastheco/kasmos/internal/gc"
 	initcmd "github.com/kastheco/kasmos/internal/initcmd"
 	sentrypkg "github.com/kastheco/kasmos/internal/sentry"
 	"github.com/kastheco/kasmos/log"
@@ -25,6 +27,7 @@ var (
 	version     = "1.3.0"
 	programFlag string
 	autoYesFlag bool
+	repoFlags   []string
 	daemonFlag  bool
 	rootCmd     = &cobra.Command{
 		Use:   "kas",
@@ -45,7 +48,8 @@ var (
 
 			if daemonFlag {
 				session.NotificationsEnabled = cfg.AreNotificationsEnabled()
-				if err := daemon.RunDaemon(cfg); err != nil {
+				autoYes := cfg.AutoYes || autoYesFlag
+				if err := daemon.RunDaemon(cfg, autoYes, repoFlags); err != nil {
 					log.ErrorLog.Printf("failed to start daemon: %v", err)
 					return err
 				}
@@ -77,9 +81,10 @@ var (
 
 			sentrypkg.SetContext(program, autoYes, filepath.Base(currentDir))
 
-			if autoYes {
+			// Hand plans over to the headless supervisor when the TUI exits.
+			if autoYes || cfg.Supervise {
 				defer func() {
-					if err := daemon.LaunchDaemon(); err != nil {
+					if err := daemon.LaunchDaemon(autoYes, currentDir); err != nil {
 						log.ErrorLog.Printf("failed to launch daemon: %v", err)
 					}
 				}()
@@ -134,6 +139,45 @@ var (
 		},
 	}
 
+	gcDryRun    bool
+	gcForce     bool
+	gcOlderThan string
+	gcCmd       = &cobra.Command{
+		Use:   "gc",
+		Short: "Remove orphaned worktrees, merged plan branches and dead tmux sessions",
+		Long: `Find leftovers of finished work in the current repository and remove them:
+  - worktrees under .worktrees/ that no saved instance or active plan uses
+  - branches of done or cancelled plans that are merged into the default branch
+  - kas_ tmux sessions that no saved instance owns
+Worktrees with uncommitted changes are only removed with --force.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			log.Initialize(false)
+			defer log.Close()
+
+			var olderThan time.Duration
+			if gcOlderThan != "" {
+				age, err := cmd2.ParseAge(gcOlderThan)
+				if err != nil {
+					return fmt.Errorf("--older-than: %w", err)
+				}
+				olderThan = age
+			}
+			// Worktrees, instances and plans are keyed on the repository
+			// root, so resolve it even when run from a subdirectory.
+			repo, err := git.RepoRoot(".")
+			if err != nil {
+				return err
+			}
+			return gc.Run(gc.Options{
+				RepoPath:  repo,
+				DryRun:    gcDryRun,
+				OlderThan: olderThan,
+				Force:     gcForce,
+				Out:       os.Stdout,
+			})
+		},
+	}
+
 	debugCmd = &cobra.Command{
 		Use:   "debug",
 		Short: "Print debug information like config paths",
@@ -170,13 +214,19 @@ func init() {
 		"Program to run in new instances (e.g. 'aider --model ollama_chat/gemma3:1b')")
 	rootCmd.Flags().BoolVarP(&autoYesFlag, "autoyes", "y", false,
 		"[experimental] If enabled, all instances will automatically accept prompts")
-	rootCmd.Flags().BoolVar(&daemonFlag, "daemon", false, "Run a program that loads all sessions"+
-		" and runs autoyes mode on them.")
-
-	// Hide the daemonFlag as it's only for internal use
-	err := rootCmd.Flags().MarkHidden("daemon")
-	if err != nil {
-		panic(err)
+	rootCmd.Flags().BoolVar(&daemonFlag, "daemon", false, "Run the headless supervisor that keeps"+
+		" plans progressing while the TUI is closed.")
+	rootCmd.Flags().StringSliceVar(&repoFlags, "repo", nil, "Repository for the daemon to supervise.")
+
+	gcCmd.Flags().BoolVar(&gcDryRun, "dry-run", false, "Only report what would be removed")
+	gcCmd.Flags().BoolVar(&gcForce, "force", false, "Also remove worktrees with uncommitted changes")
+	gcCmd.Flags().StringVar(&gcOlderThan, "older-than", "", "Only remove items untouched for at least this long (e.g. 72h, 7d, 2w)")
+
+	// Hide the daemon flags as they're only for internal use
+	for _, name := range []string{"daemon", "repo"} {
+		if err := rootCmd.Flags().MarkHidden(name); err != nil {
+			panic(err)
+		}
 	}
 
 	var forceFlag bool
@@ -205,9 +255,13 @@ func init() {
 	rootCmd.AddCommand(debugCmd)
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(resetCmd)
+	rootCmd.AddCommand(gcCmd)
 	rootCmd.AddCommand(kasSetupCmd)
 	rootCmd.AddCommand(cmd2.NewPlanCmd())
 	rootCmd.AddCommand(cmd2.NewServeCmd())
+	rootCmd.AddCommand(cmd2.NewAuditCmd())
+	rootCmd.AddCommand(cmd2.NewCtlCmd())
+	rootCmd.AddCommand(cmd2.NewMCPCmd(version))
 }
 
 func main() {
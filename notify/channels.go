@@ -0,0 +1,396 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/kastheco/kasmos/config"
+)
+
+// newChannel builds the channel described by c.
+func newChannel(c config.NotificationChannel, desktopEnabled bool) (Channel, error) {
+	switch c.Type {
+	case config.NotifyDesktop:
+		return desktop{enabled: desktopEnabled}, nil
+	case config.NotifyWebhook:
+		return newWebhook(c)
+	case config.NotifyNtfy:
+		if c.URL == "" {
+			return nil, fmt.Errorf("ntfy channel needs a url")
+		}
+		return &ntfy{url: c.URL, token: c.Token, priority: c.Priority, tags: c.Tags, headers: c.Headers, client: http.DefaultClient}, nil
+	case config.NotifyEmail:
+		return newEmail(c)
+	case config.NotifyCommand:
+		if c.Command == "" {
+			return nil, fmt.Errorf("command channel needs a command")
+		}
+		return command{command: c.Command}, nil
+	case "":
+		return nil, fmt.Errorf("missing type")
+	default:
+		return nil, fmt.Errorf("unknown type %q", c.Type)
+	}
+}
+
+// eventPayload is the JSON shape of an event sent to webhooks (by default) and
+// command hooks.
+type eventPayload struct {
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Kind      string    `json:"kind"`
+	Time      time.Time `json:"time"`
+	Project   string    `json:"project,omitempty"`
+	PlanFile  string    `json:"plan_file,omitempty"`
+	Instance  string    `json:"instance,omitempty"`
+	AgentType string    `json:"agent_type,omitempty"`
+	Wave      int       `json:"wave,omitempty"`
+	Task      int       `json:"task,omitempty"`
+	Level     string    `json:"level,omitempty"`
+}
+
+func payloadOf(n Notification) eventPayload {
+	e := n.Event
+	return eventPayload{
+		Title:     n.Title,
+		Body:      n.Body,
+		Kind:      string(e.Kind),
+		Time:      e.Timestamp,
+		Project:   e.Project,
+		PlanFile:  e.PlanFile,
+		Instance:  e.InstanceTitle,
+		AgentType: e.AgentType,
+		Wave:      e.WaveNumber,
+		Task:      e.TaskNumber,
+		Level:     e.Level,
+	}
+}
+
+// --- desktop ---
+
+type desktop struct {
+	enabled bool
+}
+
+func (d desktop) Send(_ context.Context, n Notification) error {
+	if d.enabled {
+		SendDesktop(n.Title, n.Body)
+	}
+	return nil
+}
+
+// escapeAppleScript escapes backslashes and double quotes for AppleScript strings.
+func escapeAppleScript(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return s
+}
+
+// SendDesktop shows a desktop notification. It is fire-and-forget: the
+// command is started but we do not wait for it to finish.
+func SendDesktop(title, body string) {
+	switch runtime.GOOS {
+	case "darwin":
+		cmd := exec.Command("osascript", "-e",
+			`display notification "`+escapeAppleScript(body)+`" with title "`+escapeAppleScript(title)+`"`)
+		_ = cmd.Start()
+	case "linux":
+		if path, err := exec.LookPath("notify-send"); err == nil {
+			cmd := exec.Command(path, title, body)
+			_ = cmd.Start()
+		}
+	}
+}
+
+// --- webhook ---
+
+type webhook struct {
+	url     string
+	method  string
+	headers map[string]string
+	body    *template.Template // nil sends the default JSON payload
+	client  *http.Client
+}
+
+var templateFuncs = template.FuncMap{
+	// json renders v as a JSON value, so templates can embed strings safely:
+	// {"text": {{json .Body}}}
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+func newWebhook(c config.NotificationChannel) (*webhook, error) {
+	if c.URL == "" {
+		return nil, fmt.Errorf("webhook channel needs a url")
+	}
+	w := &webhook{url: c.URL, method: c.Method, headers: c.Headers, client: http.DefaultClient}
+	if w.method == "" {
+		w.method = http.MethodPost
+	}
+	if c.Body != "" {
+		tmpl, err := template.New("body").Funcs(templateFuncs).Parse(c.Body)
+		if err != nil {
+			return nil, fmt.Errorf("parse body template: %w", err)
+		}
+		w.body = tmpl
+	}
+	return w, nil
+}
+
+func (w *webhook) Send(ctx context.Context, n Notification) error {
+	var body []byte
+	if w.body == nil {
+		var err error
+		if body, err = json.Marshal(payloadOf(n)); err != nil {
+			return err
+		}
+	} else {
+		var buf bytes.Buffer
+		if err := w.body.Execute(&buf, n); err != nil {
+			return fmt.Errorf("render body: %w", err)
+		}
+		body = buf.Bytes()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, w.method, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.headers {
+		req.Header.Set(k, v)
+	}
+	return doRequest(w.client, req)
+}
+
+// --- ntfy ---
+
+// ntfy publishes to an ntfy-style topic URL: the body is the message and
+// metadata travels in headers.
+type ntfy struct {
+	url      string
+	token    string
+	priority string
+	tags     []string
+	headers  map[string]string
+	client   *http.Client
+}
+
+func (p *ntfy) Send(ctx context.Context, n Notification) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, strings.NewReader(n.Body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", n.Title)
+	if p.priority != "" {
+		req.Header.Set("Priority", p.priority)
+	}
+	if len(p.tags) > 0 {
+		req.Header.Set("Tags", strings.Join(p.tags, ","))
+	}
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
+	return doRequest(p.client, req)
+}
+
+func doRequest(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Redacted(), resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// --- email ---
+
+type email struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	to       []string
+	// sendMail is sendMailContext; replaced in tests.
+	sendMail func(ctx context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func newEmail(c config.NotificationChannel) (*email, error) {
+	if c.SMTPHost == "" {
+		return nil, fmt.Errorf("email channel needs smtp_host")
+	}
+	if c.From == "" || len(c.To) == 0 {
+		return nil, fmt.Errorf("email channel needs from and to")
+	}
+	port := c.SMTPPort
+	if port == 0 {
+		port = 587
+	}
+	return &email{
+		addr:     net.JoinHostPort(c.SMTPHost, strconv.Itoa(port)),
+		host:     c.SMTPHost,
+		username: c.Username,
+		password: c.Password,
+		from:     c.From,
+		to:       c.To,
+		sendMail: sendMailContext,
+	}, nil
+}
+
+func (m *email) Send(ctx context.Context, n Notification) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+	return m.sendMail(ctx, m.addr, auth, m.from, m.to, m.message(n))
+}
+
+// sendMailContext is smtp.SendMail bounded by ctx: the dial honours
+// cancellation and the connection deadline is taken from ctx, so a stalled
+// server cannot hold up Router.Wait.
+func sendMailContext(ctx context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+	// Unblock any in-flight read or write as soon as ctx is cancelled.
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if a != nil {
+		if err := c.Auth(a); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message renders an RFC 5322 plain-text mail for n.
+func (m *email) message(n Notification) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(m.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", sanitizeHeader(n.Title+": "+n.Body))
+	ts := n.Event.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	fmt.Fprintf(&b, "Date: %s\r\n", ts.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(n.Body)
+	b.WriteString("\r\n")
+	e := n.Event
+	for _, kv := range [][2]string{
+		{"event", string(e.Kind)},
+		{"project", e.Project},
+		{"plan", e.PlanFile},
+		{"instance", e.InstanceTitle},
+		{"agent", e.AgentType},
+	} {
+		if kv[1] != "" {
+			fmt.Fprintf(&b, "\r\n%s: %s", kv[0], kv[1])
+		}
+	}
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
+
+// sanitizeHeader strips line breaks so event text cannot inject mail headers.
+func sanitizeHeader(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// --- command ---
+
+// command runs a shell hook per notification with the event as JSON on stdin
+// and as KASMOS_* environment variables.
+type command struct {
+	command string
+}
+
+func (c command) Send(ctx context.Context, n Notification) error {
+	payload, err := json.Marshal(payloadOf(n))
+	if err != nil {
+		return err
+	}
+	e := n.Event
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(),
+		"KASMOS_EVENT="+string(e.Kind),
+		"KASMOS_TITLE="+n.Title,
+		"KASMOS_MESSAGE="+n.Body,
+		"KASMOS_PROJECT="+e.Project,
+		"KASMOS_PLAN="+e.PlanFile,
+		"KASMOS_INSTANCE="+e.InstanceTitle,
+		"KASMOS_AGENT="+e.AgentType,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
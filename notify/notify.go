@@ -0,0 +1,204 @@
+// Package notify fans audit events out to notification channels — desktop
+// popups, webhooks, ntfy push, email and shell hooks — according to the
+// per-EventKind routes in config.toml's [notifications] table.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/log"
+)
+
+// sendTimeout bounds a single channel delivery so a hung webhook or SMTP
+// server cannot pile up goroutines.
+const sendTimeout = 15 * time.Second
+
+// wildcard matches every event kind in a route.
+const wildcard = "*"
+
+// Notification is what a channel delivers: a short title, a body line and the
+// event that caused it (available to webhook templates and command hooks).
+type Notification struct {
+	Title string
+	Body  string
+	Event auditlog.Event
+}
+
+// newNotification derives the title and body shown to the user from an event.
+func newNotification(e auditlog.Event) Notification {
+	title := "kas"
+	if e.Project != "" {
+		title = fmt.Sprintf("kas [%s]", e.Project)
+	}
+	body := e.Message
+	if body == "" {
+		body = string(e.Kind)
+	}
+	return Notification{Title: title, Body: body, Event: e}
+}
+
+// Channel delivers notifications to one destination.
+type Channel interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// Router maps event kinds to the channels that should hear about them.
+// Dispatch is asynchronous and safe for concurrent use.
+type Router struct {
+	channels map[string]Channel
+	routes   map[auditlog.EventKind][]string
+	all      []string // channels routed via the "*" wildcard
+
+	wg sync.WaitGroup
+}
+
+// defaultRoutes preserves the historical behaviour: a desktop popup when an
+// agent finishes its turn.
+var defaultRoutes = []config.NotificationRoute{
+	{Events: []string{string(auditlog.EventAgentReady)}, Channels: []string{config.NotifyDesktop}},
+}
+
+// New builds a Router from cfg. The desktop channel is always available under
+// the name "desktop" and is silenced when desktop notifications are disabled.
+func New(cfg *config.Config) (*Router, error) {
+	desktopEnabled := cfg.AreNotificationsEnabled()
+	r := &Router{
+		channels: map[string]Channel{config.NotifyDesktop: desktop{enabled: desktopEnabled}},
+		routes:   make(map[auditlog.EventKind][]string),
+	}
+
+	names := make([]string, 0, len(cfg.Notifications.Channels))
+	for name := range cfg.Notifications.Channels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ch, err := newChannel(cfg.Notifications.Channels[name], desktopEnabled)
+		if err != nil {
+			return nil, fmt.Errorf("notification channel %q: %w", name, err)
+		}
+		r.channels[name] = ch
+	}
+
+	routes := cfg.Notifications.Routes
+	if len(routes) == 0 {
+		routes = defaultRoutes
+	}
+	for i, route := range routes {
+		for _, name := range route.Channels {
+			if _, ok := r.channels[name]; !ok {
+				return nil, fmt.Errorf("notification route %d: unknown channel %q", i+1, name)
+			}
+		}
+		for _, kind := range route.Events {
+			if kind == wildcard {
+				r.all = appendUnique(r.all, route.Channels...)
+				continue
+			}
+			k := auditlog.EventKind(kind)
+			r.routes[k] = appendUnique(r.routes[k], route.Channels...)
+		}
+	}
+	return r, nil
+}
+
+func appendUnique(dst []string, names ...string) []string {
+	for _, name := range names {
+		dup := false
+		for _, have := range dst {
+			if have == name {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			dst = append(dst, name)
+		}
+	}
+	return dst
+}
+
+// ChannelsFor returns the channel names an event of kind is delivered to.
+func (r *Router) ChannelsFor(kind auditlog.EventKind) []string {
+	return appendUnique(append([]string(nil), r.routes[kind]...), r.all...)
+}
+
+// Dispatch delivers e to every channel routed for its kind in the background.
+// Delivery failures are logged, never returned: notifications must not get in
+// the way of the work they report on.
+func (r *Router) Dispatch(e auditlog.Event) {
+	if r == nil {
+		return
+	}
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	names := r.ChannelsFor(e.Kind)
+	if len(names) == 0 {
+		return
+	}
+	n := newNotification(e)
+	for _, name := range names {
+		ch := r.channels[name]
+		r.wg.Add(1)
+		go func(name string) {
+			defer r.wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+			defer cancel()
+			if err := ch.Send(ctx, n); err != nil {
+				log.WarningLog.Printf("notification %s via %s failed: %v", e.Kind, name, err)
+			}
+		}(name)
+	}
+}
+
+// Wait blocks until every in-flight delivery has finished.
+func (r *Router) Wait() {
+	if r == nil {
+		return
+	}
+	r.wg.Wait()
+}
+
+// routedLogger forwards every emitted audit event to a Router.
+type routedLogger struct {
+	auditlog.Logger
+	router *Router
+}
+
+// WrapLogger returns a Logger that records events in l and also dispatches
+// them through r. Close waits for in-flight notifications.
+func WrapLogger(l auditlog.Logger, r *Router) auditlog.Logger {
+	if r == nil {
+		return l
+	}
+	return &routedLogger{Logger: l, router: r}
+}
+
+func (l *routedLogger) Emit(e auditlog.Event) {
+	l.Logger.Emit(e)
+	l.router.Dispatch(e)
+}
+
+func (l *routedLogger) Close() error {
+	l.router.Wait()
+	return l.Logger.Close()
+}
+
+// Attach builds a Router from cfg and wraps l with it. An invalid
+// [notifications] table is logged and leaves l unwrapped (nil Router), so a
+// typo in config.toml never stops kasmos from starting.
+func Attach(cfg *config.Config, l auditlog.Logger) (auditlog.Logger, *Router) {
+	r, err := New(cfg)
+	if err != nil {
+		log.WarningLog.Printf("notifications: %v — falling back to desktop notifications", err)
+		return l, nil
+	}
+	return WrapLogger(l, r), r
+}
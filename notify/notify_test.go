@@ -0,0 +1,324 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	log.Initialize(false)
+	defer log.Close()
+	os.Exit(m.Run())
+}
+
+// recorder is a local HTTP stand-in that records every request it receives.
+type recorder struct {
+	mu       sync.Mutex
+	requests []recordedRequest
+}
+
+type recordedRequest struct {
+	Method string
+	Header http.Header
+	Body   string
+}
+
+func (rec *recorder) server(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rec.mu.Lock()
+		rec.requests = append(rec.requests, recordedRequest{Method: r.Method, Header: r.Header.Clone(), Body: string(body)})
+		rec.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (rec *recorder) all() []recordedRequest {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]recordedRequest(nil), rec.requests...)
+}
+
+func testConfig(n config.NotificationsConfig) *config.Config {
+	disabled := false
+	// Desktop popups are disabled so tests never shell out to notify-send.
+	return &config.Config{NotificationsEnabled: &disabled, Notifications: n}
+}
+
+func TestRouter_RoutesByEventKind(t *testing.T) {
+	var hook recorder
+	srv := hook.server(t)
+
+	r, err := New(testConfig(config.NotificationsConfig{
+		Channels: map[string]config.NotificationChannel{
+			"ops": {Type: config.NotifyWebhook, URL: srv.URL},
+		},
+		Routes: []config.NotificationRoute{
+			{Events: []string{"wave_failed", "permission_detected"}, Channels: []string{"ops"}},
+			{Events: []string{"agent_finished"}, Channels: []string{"desktop"}},
+		},
+	}))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"ops"}, r.ChannelsFor(auditlog.EventWaveFailed))
+	assert.Equal(t, []string{"desktop"}, r.ChannelsFor(auditlog.EventAgentFinished))
+	assert.Empty(t, r.ChannelsFor(auditlog.EventAgentReady), "explicit routes replace the default")
+
+	r.Dispatch(auditlog.Event{Kind: auditlog.EventWaveFailed, Project: "kasmos", PlanFile: "auth.md", WaveNumber: 2, Message: "wave 2 failed: 1/3 tasks"})
+	r.Dispatch(auditlog.Event{Kind: auditlog.EventAgentFinished, Message: "agent finished"})
+	r.Dispatch(auditlog.Event{Kind: auditlog.EventPromptSent, Message: "unrouted"})
+	r.Wait()
+
+	reqs := hook.all()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, http.MethodPost, reqs[0].Method)
+	assert.Equal(t, "application/json", reqs[0].Header.Get("Content-Type"))
+
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal([]byte(reqs[0].Body), &payload))
+	assert.Equal(t, "wave_failed", payload["kind"])
+	assert.Equal(t, "wave 2 failed: 1/3 tasks", payload["body"])
+	assert.Equal(t, "kas [kasmos]", payload["title"])
+	assert.Equal(t, "auth.md", payload["plan_file"])
+	assert.EqualValues(t, 2, payload["wave"])
+}
+
+func TestRouter_DefaultRoutesAgentReadyToDesktop(t *testing.T) {
+	r, err := New(testConfig(config.NotificationsConfig{}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"desktop"}, r.ChannelsFor(auditlog.EventAgentReady))
+	assert.Empty(t, r.ChannelsFor(auditlog.EventWaveFailed))
+}
+
+func TestRouter_WildcardRoute(t *testing.T) {
+	r, err := New(testConfig(config.NotificationsConfig{
+		Channels: map[string]config.NotificationChannel{
+			"log": {Type: config.NotifyCommand, Command: "true"},
+		},
+		Routes: []config.NotificationRoute{
+			{Events: []string{"*"}, Channels: []string{"log"}},
+			{Events: []string{"wave_failed"}, Channels: []string{"desktop", "log"}},
+		},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"desktop", "log"}, r.ChannelsFor(auditlog.EventWaveFailed))
+	assert.Equal(t, []string{"log"}, r.ChannelsFor(auditlog.EventGitPush))
+}
+
+func TestNew_RejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.NotificationsConfig
+		want string
+	}{
+		{
+			name: "unknown channel type",
+			cfg:  config.NotificationsConfig{Channels: map[string]config.NotificationChannel{"x": {Type: "pager"}}},
+			want: `unknown type "pager"`,
+		},
+		{
+			name: "webhook without url",
+			cfg:  config.NotificationsConfig{Channels: map[string]config.NotificationChannel{"x": {Type: config.NotifyWebhook}}},
+			want: "needs a url",
+		},
+		{
+			name: "bad body template",
+			cfg:  config.NotificationsConfig{Channels: map[string]config.NotificationChannel{"x": {Type: config.NotifyWebhook, URL: "http://x", Body: "{{.Nope"}}},
+			want: "parse body template",
+		},
+		{
+			name: "route to unknown channel",
+			cfg:  config.NotificationsConfig{Routes: []config.NotificationRoute{{Events: []string{"wave_failed"}, Channels: []string{"slack"}}}},
+			want: `unknown channel "slack"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(testConfig(tt.cfg))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
+
+func TestWebhook_TemplatedBodyAndHeaders(t *testing.T) {
+	var hook recorder
+	srv := hook.server(t)
+
+	w, err := newWebhook(config.NotificationChannel{
+		Type:    config.NotifyWebhook,
+		URL:     srv.URL,
+		Method:  http.MethodPut,
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+		Body:    `{"text": {{json (printf "%s: %s" .Event.Kind .Body)}}, "plan": {{json .Event.PlanFile}}}`,
+	})
+	require.NoError(t, err)
+
+	n := newNotification(auditlog.Event{Kind: auditlog.EventPermissionDetected, PlanFile: "auth.md", Message: `needs "rm -rf" approval`})
+	require.NoError(t, w.Send(t.Context(), n))
+
+	reqs := hook.all()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, http.MethodPut, reqs[0].Method)
+	assert.Equal(t, "Bearer secret", reqs[0].Header.Get("Authorization"))
+	assert.JSONEq(t, `{"text": "permission_detected: needs \"rm -rf\" approval", "plan": "auth.md"}`, reqs[0].Body)
+}
+
+func TestWebhook_ReportsHTTPErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad token", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	w, err := newWebhook(config.NotificationChannel{Type: config.NotifyWebhook, URL: srv.URL})
+	require.NoError(t, err)
+	err = w.Send(t.Context(), newNotification(auditlog.Event{Kind: auditlog.EventError}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+	assert.Contains(t, err.Error(), "bad token")
+}
+
+func TestNtfy_SendsMessageWithHeaders(t *testing.T) {
+	var push recorder
+	srv := push.server(t)
+
+	ch, err := newChannel(config.NotificationChannel{
+		Type:     config.NotifyNtfy,
+		URL:      srv.URL + "/kasmos",
+		Token:    "tk_123",
+		Priority: "high",
+		Tags:     []string{"warning", "kasmos"},
+	}, false)
+	require.NoError(t, err)
+
+	n := newNotification(auditlog.Event{Kind: auditlog.EventWaveFailed, Project: "kasmos", Message: "wave 1 failed"})
+	require.NoError(t, ch.Send(t.Context(), n))
+
+	reqs := push.all()
+	require.Len(t, reqs, 1)
+	assert.Equal(t, "wave 1 failed", reqs[0].Body)
+	assert.Equal(t, "kas [kasmos]", reqs[0].Header.Get("Title"))
+	assert.Equal(t, "high", reqs[0].Header.Get("Priority"))
+	assert.Equal(t, "warning,kasmos", reqs[0].Header.Get("Tags"))
+	assert.Equal(t, "Bearer tk_123", reqs[0].Header.Get("Authorization"))
+}
+
+func TestEmail_BuildsMessageAndAuth(t *testing.T) {
+	m, err := newEmail(config.NotificationChannel{
+		Type:     config.NotifyEmail,
+		SMTPHost: "smtp.example.com",
+		Username: "bot",
+		Password: "pw",
+		From:     "kasmos@example.com",
+		To:       []string{"dev@example.com", "ops@example.com"},
+	})
+	require.NoError(t, err)
+
+	var gotAddr, gotFrom string
+	var gotTo []string
+	var gotMsg []byte
+	var gotAuth smtp.Auth
+	m.sendMail = func(_ context.Context, addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		gotAddr, gotAuth, gotFrom, gotTo, gotMsg = addr, a, from, to, msg
+		return nil
+	}
+
+	n := newNotification(auditlog.Event{Kind: auditlog.EventWaveFailed, PlanFile: "auth.md", Message: "wave 1 failed\nBcc: evil@example.com"})
+	require.NoError(t, m.Send(t.Context(), n))
+
+	assert.Equal(t, "smtp.example.com:587", gotAddr)
+	assert.NotNil(t, gotAuth)
+	assert.Equal(t, "kasmos@example.com", gotFrom)
+	assert.Equal(t, []string{"dev@example.com", "ops@example.com"}, gotTo)
+
+	headers, body, found := strings.Cut(string(gotMsg), "\r\n\r\n")
+	require.True(t, found)
+	assert.Contains(t, headers, "To: dev@example.com, ops@example.com\r\n")
+	assert.Contains(t, headers, "Subject: kas: wave 1 failed Bcc: evil@example.com\r\n", "line breaks cannot inject headers")
+	assert.Contains(t, body, "plan: auth.md")
+}
+
+func TestSendMailContext_StalledServerHonoursDeadline(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	go func() {
+		// Accept and never send the SMTP greeting.
+		conn, err := ln.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(t.Context(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = sendMailContext(ctx, ln.Addr().String(), nil, "a@example.com", []string{"b@example.com"}, []byte("hi"))
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestCommand_ReceivesEventOnStdinAndEnv(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "hook.out")
+	ch, err := newChannel(config.NotificationChannel{
+		Type:    config.NotifyCommand,
+		Command: `{ echo "$KASMOS_EVENT|$KASMOS_PLAN"; cat; } > "` + out + `"`,
+	}, false)
+	require.NoError(t, err)
+
+	n := newNotification(auditlog.Event{Kind: auditlog.EventPlanMerged, PlanFile: "auth.md", Message: "merged"})
+	require.NoError(t, ch.Send(t.Context(), n))
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	first, rest, _ := strings.Cut(string(data), "\n")
+	assert.Equal(t, "plan_merged|auth.md", first)
+	var payload map[string]any
+	require.NoError(t, json.Unmarshal([]byte(rest), &payload))
+	assert.Equal(t, "merged", payload["body"])
+}
+
+func TestWrapLogger_RecordsAndDispatches(t *testing.T) {
+	var hook recorder
+	srv := hook.server(t)
+
+	inner, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+
+	r, err := New(testConfig(config.NotificationsConfig{
+		Channels: map[string]config.NotificationChannel{"ops": {Type: config.NotifyWebhook, URL: srv.URL}},
+		Routes:   []config.NotificationRoute{{Events: []string{"wave_failed"}, Channels: []string{"ops"}}},
+	}))
+	require.NoError(t, err)
+
+	l := WrapLogger(inner, r)
+	l.Emit(auditlog.Event{Kind: auditlog.EventWaveFailed, Project: "kasmos", Message: "wave failed"})
+
+	events, err := l.Query(auditlog.QueryFilter{Project: "kasmos"})
+	require.NoError(t, err)
+	assert.Len(t, events, 1, "events are still recorded in the audit log")
+
+	require.NoError(t, l.Close(), "close waits for in-flight deliveries")
+	assert.Len(t, hook.all(), 1)
+}
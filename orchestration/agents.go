@@ -0,0 +1,138 @@
+package orchestration
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/log"
+	"github.com/kastheco/kasmos/session"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+)
+
+// TaskTitle returns the instance title of a wave task's agent.
+func TaskTitle(planFile string, wave, task int) string {
+	return fmt.Sprintf("%s-W%d-T%d", planstate.DisplayName(planFile), wave, task)
+}
+
+// ReviewerTitle returns the instance title of a plan's reviewer.
+func ReviewerTitle(planFile string) string {
+	return planstate.DisplayName(planFile) + "-review"
+}
+
+// CoderTitle returns the instance title of a plan's coder outside wave
+// orchestration (e.g. one applying reviewer feedback).
+func CoderTitle(planFile string) string {
+	return planstate.DisplayName(planFile) + "-implement"
+}
+
+// AgentPhase returns the config phase whose profile runs agents of
+// agentType, and false for ad-hoc agents.
+func AgentPhase(agentType string) (string, bool) {
+	switch agentType {
+	case session.AgentTypeCoder:
+		return "implementing", true
+	case session.AgentTypePlanner:
+		return "planning", true
+	case session.AgentTypeReviewer:
+		return "quality_review", true
+	case session.AgentTypeFixer:
+		return "fixer", true
+	}
+	return "", false
+}
+
+// IsPlanAgent reports whether inst is an agent of agentType working on
+// planFile. Legacy reviewer instances may only have IsReviewer set.
+func IsPlanAgent(inst *session.Instance, planFile, agentType string) bool {
+	if inst.PlanFile != planFile {
+		return false
+	}
+	return inst.AgentType == agentType || (agentType == session.AgentTypeReviewer && inst.IsReviewer)
+}
+
+// ReconcileTask judges a running task of the current wave by its agent: inst
+// is the task's instance (nil once it is gone) and alive whether its tmux
+// session still runs. A missing or paused agent, or one whose session died,
+// fails the task; one back at its prompt with no queued work completes it.
+// Reports whether the agent finished its task.
+func (o *WaveOrchestrator) ReconcileTask(taskNumber int, inst *session.Instance, alive bool) bool {
+	switch {
+	case inst == nil, inst.Paused():
+		o.MarkTaskFailed(taskNumber)
+	case inst.PromptDetected && !inst.AwaitingWork:
+		o.MarkTaskComplete(taskNumber)
+		return true
+	case !alive:
+		o.MarkTaskFailed(taskNumber)
+	}
+	return false
+}
+
+// ErrWaveOrchestratorActive rejects an implement-finished signal for a plan
+// whose waves are still running: the orchestrator starts the review after
+// the last wave.
+var ErrWaveOrchestratorActive = errors.New("wave orchestrator active; it starts the review after the last wave")
+
+// CheckSignal reports whether sig may be applied to the plan FSM. Wave task
+// agents may write implement-finished after finishing their own task; letting
+// it through would start the review while sibling tasks are still running.
+func CheckSignal(sig planfsm.Signal, waveActive bool) error {
+	if sig.Event == planfsm.ImplementFinished && waveActive {
+		return ErrWaveOrchestratorActive
+	}
+	return nil
+}
+
+// transitionMessages describes the FSM edge each agent signal drives, for
+// the audit log.
+var transitionMessages = map[planfsm.Event]string{
+	planfsm.PlannerFinished:        "planning → ready (planner finished)",
+	planfsm.ImplementFinished:      "implementing → reviewing (implement finished)",
+	planfsm.ReviewApproved:         "reviewing → done (review approved)",
+	planfsm.ReviewChangesRequested: "reviewing → implementing (changes requested)",
+}
+
+// TransitionMessage returns the audit message for the transition event drives.
+func TransitionMessage(event planfsm.Event) string {
+	return transitionMessages[event]
+}
+
+// WritePlanFile materializes a plan's content at docs/plans/<planFile> under
+// dir, so agents working there can read it.
+func WritePlanFile(dir, planFile, content string) error {
+	plansDir := filepath.Join(dir, "docs", "plans")
+	if err := os.MkdirAll(plansDir, 0o755); err != nil {
+		return fmt.Errorf("create plans dir: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(plansDir, planFile), []byte(content), 0o644); err != nil {
+		return fmt.Errorf("write plan file: %w", err)
+	}
+	return nil
+}
+
+// ReadPlanFile reads the plan an agent wrote at docs/plans/<planFile> under
+// dir, for ingestion into the plan store.
+func ReadPlanFile(dir, planFile string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "docs", "plans", planFile))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// RecordWaveCheckpoint records the plan branch as it is before wave starts.
+// Call it once the wave's agents are set up (so the branch exists) but before
+// they are prompted. Best effort: a missing checkpoint only means the wave
+// can't be rolled back.
+func RecordWaveCheckpoint(repoPath, branch, planFile string, wave int) {
+	if branch == "" {
+		return
+	}
+	if _, err := gitpkg.CreateWaveCheckpoint(repoPath, branch, planFile, wave); err != nil {
+		log.WarningLog.Printf("wave %d checkpoint for %s: %v", wave, planFile, err)
+	}
+}
@@ -0,0 +1,65 @@
+package orchestration
+
+import (
+	"testing"
+
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/config/planparser"
+	"github.com/kastheco/kasmos/session"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func threeWavePlan() *planparser.Plan {
+	return &planparser.Plan{Waves: []planparser.Wave{
+		{Number: 1, Tasks: []planparser.Task{{Number: 1}, {Number: 2}}},
+		{Number: 2, Tasks: []planparser.Task{{Number: 3}, {Number: 4}}},
+		{Number: 3, Tasks: []planparser.Task{{Number: 5}}},
+	}}
+}
+
+func TestTaskTitle(t *testing.T) {
+	assert.Equal(t, "auth-refactor-W2-T3", TaskTitle("2026-02-21-auth-refactor.md", 2, 3))
+	assert.Equal(t, "auth-refactor-review", ReviewerTitle("2026-02-21-auth-refactor.md"))
+	assert.Equal(t, "auth-refactor-implement", CoderTitle("2026-02-21-auth-refactor.md"))
+}
+
+func TestRestoreWaveOrchestrator(t *testing.T) {
+	orch := RestoreWaveOrchestrator("plan.md", threeWavePlan(), 2, []int{4})
+	require.NotNil(t, orch)
+	assert.Equal(t, WaveStateRunning, orch.State())
+	assert.Equal(t, 2, orch.CurrentWaveNumber())
+	assert.True(t, orch.IsTaskComplete(1), "earlier waves are complete")
+	assert.True(t, orch.IsTaskRunning(3))
+	assert.True(t, orch.IsTaskComplete(4))
+
+	orch.MarkTaskComplete(3)
+	assert.Equal(t, WaveStateWaveComplete, orch.State())
+
+	assert.Nil(t, RestoreWaveOrchestrator("plan.md", threeWavePlan(), 4, nil))
+}
+
+func TestReconcileTask(t *testing.T) {
+	orch := NewWaveOrchestrator("plan.md", threeWavePlan())
+	orch.StartNextWave()
+
+	inst, err := session.NewInstance(session.InstanceOptions{Title: "plan-W1-T1", Path: t.TempDir(), Program: "opencode"})
+	require.NoError(t, err)
+
+	assert.False(t, orch.ReconcileTask(1, inst, true), "a working agent leaves its task running")
+	assert.True(t, orch.IsTaskRunning(1))
+
+	inst.PromptDetected = true
+	assert.True(t, orch.ReconcileTask(1, inst, true))
+	assert.True(t, orch.IsTaskComplete(1))
+
+	assert.False(t, orch.ReconcileTask(2, nil, false))
+	assert.True(t, orch.IsTaskFailed(2), "a missing agent fails its task")
+}
+
+func TestCheckSignal(t *testing.T) {
+	implement := planfsm.Signal{Event: planfsm.ImplementFinished, PlanFile: "plan.md"}
+	assert.ErrorIs(t, CheckSignal(implement, true), ErrWaveOrchestratorActive)
+	assert.NoError(t, CheckSignal(implement, false))
+	assert.NoError(t, CheckSignal(planfsm.Signal{Event: planfsm.ReviewApproved, PlanFile: "plan.md"}, true))
+}
@@ -1,14 +1,42 @@
-package app
+package orchestration
 
 import (
 	"fmt"
 	"strings"
 
 	"github.com/kastheco/kasmos/config/planparser"
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/internal/initcmd/scaffold"
 )
 
-// buildTaskPrompt constructs the prompt for a single task instance.
-func buildTaskPrompt(plan *planparser.Plan, task planparser.Task, waveNumber, totalWaves, peerCount int) string {
+// BuildImplementPrompt returns the prompt for a coder agent session.
+// The plan file is materialized to docs/plans/<planFile> in the agent's worktree
+// before the agent starts. Agents write sentinel signals to .kasmos/signals/ in
+// their worktree; kasmos ingests them on completion.
+func BuildImplementPrompt(planFile string) string {
+	return fmt.Sprintf(
+		"Implement docs/plans/%s using the `kasmos-coder` skill. Execute all tasks sequentially.",
+		planFile,
+	)
+}
+
+// BuildFeedbackPrompt returns the prompt for a coder picking implementation
+// back up after a reviewer requested changes.
+func BuildFeedbackPrompt(planFile, feedback string) string {
+	prompt := BuildImplementPrompt(planFile)
+	if feedback != "" {
+		prompt += fmt.Sprintf("\n\nReviewer feedback from previous round:\n%s", feedback)
+	}
+	return prompt
+}
+
+// BuildReviewPrompt returns the prompt for a reviewer agent session.
+func BuildReviewPrompt(planFile string) string {
+	return scaffold.LoadReviewPrompt("docs/plans/"+planFile, planstate.DisplayName(planFile))
+}
+
+// BuildTaskPrompt constructs the prompt for a single task instance.
+func BuildTaskPrompt(plan *planparser.Plan, task planparser.Task, waveNumber, totalWaves, peerCount int) string {
 	var sb strings.Builder
 
 	sb.WriteString(fmt.Sprintf("Implement Task %d: %s\n\n", task.Number, task.Title))
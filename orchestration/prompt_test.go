@@ -1,4 +1,4 @@
-package app
+package orchestration
 
 import (
 	"testing"
@@ -19,7 +19,7 @@ func TestBuildTaskPrompt(t *testing.T) {
 		Body:   "**Step 1:** Write the test\n\n**Step 2:** Run it",
 	}
 
-	prompt := buildTaskPrompt(plan, task, 1, 3, 4)
+	prompt := BuildTaskPrompt(plan, task, 1, 3, 4)
 
 	// Plan context
 	assert.Contains(t, prompt, "Build a feature")
@@ -48,10 +48,23 @@ func TestBuildTaskPrompt_SingleTask(t *testing.T) {
 	plan := &planparser.Plan{Goal: "Simple"}
 	task := planparser.Task{Number: 1, Title: "Only Task", Body: "Do it"}
 
-	prompt := buildTaskPrompt(plan, task, 1, 1, 1)
+	prompt := BuildTaskPrompt(plan, task, 1, 1, 1)
 
 	// Single task shouldn't mention parallel coordination
 	assert.NotContains(t, prompt, "parallel")
 	assert.NotContains(t, prompt, "NEVER run")
 	assert.NotContains(t, prompt, "other agents")
 }
+
+func TestBuildImplementPrompt(t *testing.T) {
+	prompt := BuildImplementPrompt("2026-02-21-auth-refactor.md")
+	assert.Contains(t, prompt, "Implement docs/plans/2026-02-21-auth-refactor.md")
+}
+
+func TestBuildFeedbackPrompt(t *testing.T) {
+	assert.Equal(t, BuildImplementPrompt("auth.md"), BuildFeedbackPrompt("auth.md", ""))
+
+	prompt := BuildFeedbackPrompt("auth.md", "handle expired tokens")
+	assert.Contains(t, prompt, "Implement docs/plans/auth.md")
+	assert.Contains(t, prompt, "Reviewer feedback from previous round:\nhandle expired tokens")
+}
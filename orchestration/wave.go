@@ -1,4 +1,8 @@
-package app
+// Package orchestration holds the plan lifecycle logic shared by the TUI and
+// the headless daemon supervisor: wave orchestration, the prompts agents are
+// started with, and how wave task agents are named and judged. Both front
+// ends call into it so plans progress the same way whichever one is running.
+package orchestration
 
 import (
 	"github.com/kastheco/kasmos/config/planparser"
@@ -14,23 +18,35 @@ const (
 	WaveStateAllComplete                   // All waves finished
 )
 
-// taskStatus tracks the completion state of a single task.
-type taskStatus int
+// TaskStatus tracks the completion state of a single task.
+type TaskStatus int
 
 const (
-	taskPending taskStatus = iota
-	taskRunning
-	taskComplete
-	taskFailed
+	TaskPending TaskStatus = iota
+	TaskRunning
+	TaskComplete
+	TaskFailed
 )
 
+func (s TaskStatus) String() string {
+	switch s {
+	case TaskRunning:
+		return "running"
+	case TaskComplete:
+		return "complete"
+	case TaskFailed:
+		return "failed"
+	}
+	return "pending"
+}
+
 // WaveOrchestrator manages wave-based parallel task execution for a single plan.
 type WaveOrchestrator struct {
 	planFile          string
 	plan              *planparser.Plan
 	state             WaveState
 	currentWave       int                // 0-indexed into plan.Waves
-	taskStates        map[int]taskStatus // task number → status
+	taskStates        map[int]TaskStatus // task number → status
 	waitingForConfirm bool               // true once we've shown the wave-complete dialog
 }
 
@@ -40,8 +56,34 @@ func NewWaveOrchestrator(planFile string, plan *planparser.Plan) *WaveOrchestrat
 		planFile:   planFile,
 		plan:       plan,
 		state:      WaveStateIdle,
-		taskStates: make(map[int]taskStatus),
+		taskStates: make(map[int]TaskStatus),
+	}
+}
+
+// RestoreWaveOrchestrator rebuilds the orchestrator of a plan whose wave
+// task agents outlived the process that ran it (a TUI restart, or a hand-off
+// between the TUI and the daemon). Waves before wave are complete; in wave,
+// the tasks in done are complete and the others running, so the caller's
+// next ReconcileTask pass judges them. Returns nil if the plan has no such
+// wave.
+func RestoreWaveOrchestrator(planFile string, plan *planparser.Plan, wave int, done []int) *WaveOrchestrator {
+	o := NewWaveOrchestrator(planFile, plan)
+	for {
+		tasks := o.StartNextWave()
+		if tasks == nil {
+			return nil
+		}
+		if o.CurrentWaveNumber() == wave {
+			break
+		}
+		for _, t := range tasks {
+			o.MarkTaskComplete(t.Number)
+		}
+	}
+	for _, n := range done {
+		o.MarkTaskComplete(n)
 	}
+	return o
 }
 
 // State returns the current orchestration state.
@@ -102,7 +144,7 @@ func (o *WaveOrchestrator) StartNextWave() []planparser.Task {
 	o.state = WaveStateRunning
 	tasks := o.plan.Waves[o.currentWave].Tasks
 	for _, t := range tasks {
-		o.taskStates[t.Number] = taskRunning
+		o.taskStates[t.Number] = TaskRunning
 	}
 	return tasks
 }
@@ -111,10 +153,10 @@ func (o *WaveOrchestrator) StartNextWave() []planparser.Task {
 // If all tasks in the current wave are done, transitions state.
 // Idempotent: calling again on an already-resolved task is a no-op.
 func (o *WaveOrchestrator) MarkTaskComplete(taskNumber int) {
-	if o.taskStates[taskNumber] != taskRunning {
+	if o.taskStates[taskNumber] != TaskRunning {
 		return
 	}
-	o.taskStates[taskNumber] = taskComplete
+	o.taskStates[taskNumber] = TaskComplete
 	o.checkWaveComplete()
 }
 
@@ -122,10 +164,10 @@ func (o *WaveOrchestrator) MarkTaskComplete(taskNumber int) {
 // Other tasks in the wave continue. Wave completes when all tasks resolve.
 // Idempotent: calling again on an already-resolved task is a no-op.
 func (o *WaveOrchestrator) MarkTaskFailed(taskNumber int) {
-	if o.taskStates[taskNumber] != taskRunning {
+	if o.taskStates[taskNumber] != TaskRunning {
 		return
 	}
-	o.taskStates[taskNumber] = taskFailed
+	o.taskStates[taskNumber] = TaskFailed
 	o.checkWaveComplete()
 }
 
@@ -155,8 +197,8 @@ func (o *WaveOrchestrator) RetryFailedTasks() []planparser.Task {
 	}
 	var tasks []planparser.Task
 	for _, t := range o.plan.Waves[o.currentWave].Tasks {
-		if o.taskStates[t.Number] == taskFailed {
-			o.taskStates[t.Number] = taskRunning
+		if o.taskStates[t.Number] == TaskFailed {
+			o.taskStates[t.Number] = TaskRunning
 			tasks = append(tasks, t)
 		}
 	}
@@ -167,6 +209,36 @@ func (o *WaveOrchestrator) RetryFailedTasks() []planparser.Task {
 	return tasks
 }
 
+// Rewind reopens the orchestrator before wave waveNumber (1-indexed, as in
+// the plan): earlier waves count as complete, that wave and every later one
+// are pending again, and the state is idle so StartNextWave runs that wave.
+// Returns false if the plan has no such wave.
+func (o *WaveOrchestrator) Rewind(waveNumber int) bool {
+	idx := -1
+	for i, w := range o.plan.Waves {
+		if w.Number == waveNumber {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return false
+	}
+	for i, w := range o.plan.Waves {
+		status := TaskPending
+		if i < idx {
+			status = TaskComplete
+		}
+		for _, t := range w.Tasks {
+			o.taskStates[t.Number] = status
+		}
+	}
+	o.currentWave = idx
+	o.state = WaveStateIdle
+	o.waitingForConfirm = false
+	return true
+}
+
 // IsCurrentWaveComplete returns true if all tasks in the current wave have resolved.
 func (o *WaveOrchestrator) IsCurrentWaveComplete() bool {
 	return o.state == WaveStateWaveComplete || o.state == WaveStateAllComplete
@@ -174,28 +246,53 @@ func (o *WaveOrchestrator) IsCurrentWaveComplete() bool {
 
 // CompletedTaskCount returns the number of completed tasks in the current wave.
 func (o *WaveOrchestrator) CompletedTaskCount() int {
-	return o.countCurrentWaveByStatus(taskComplete)
+	return o.countCurrentWaveByStatus(TaskComplete)
 }
 
 // FailedTaskCount returns the number of failed tasks in the current wave.
 func (o *WaveOrchestrator) FailedTaskCount() int {
-	return o.countCurrentWaveByStatus(taskFailed)
+	return o.countCurrentWaveByStatus(TaskFailed)
+}
+
+// FailedTasks returns the numbers of the current wave's failed tasks, in
+// plan order.
+func (o *WaveOrchestrator) FailedTasks() []int {
+	if o.currentWave >= len(o.plan.Waves) {
+		return nil
+	}
+	var failed []int
+	for _, t := range o.plan.Waves[o.currentWave].Tasks {
+		if o.taskStates[t.Number] == TaskFailed {
+			failed = append(failed, t.Number)
+		}
+	}
+	return failed
 }
 
 // IsTaskRunning returns true if the given task number is currently in the running state.
 // Used to gate the "Mark complete" context menu action.
 func (o *WaveOrchestrator) IsTaskRunning(taskNumber int) bool {
-	return o.taskStates[taskNumber] == taskRunning
+	return o.taskStates[taskNumber] == TaskRunning
 }
 
 // IsTaskComplete returns true if the given task number has completed successfully.
 func (o *WaveOrchestrator) IsTaskComplete(taskNumber int) bool {
-	return o.taskStates[taskNumber] == taskComplete
+	return o.taskStates[taskNumber] == TaskComplete
 }
 
 // IsTaskFailed returns true if the given task number has failed.
 func (o *WaveOrchestrator) IsTaskFailed(taskNumber int) bool {
-	return o.taskStates[taskNumber] == taskFailed
+	return o.taskStates[taskNumber] == TaskFailed
+}
+
+// TaskStatus returns the state of the given task number.
+func (o *WaveOrchestrator) TaskStatus(taskNumber int) TaskStatus {
+	return o.taskStates[taskNumber]
+}
+
+// Plan returns the parsed plan this orchestrator runs.
+func (o *WaveOrchestrator) Plan() *planparser.Plan {
+	return o.plan
 }
 
 // HeaderContext returns the plan header for inclusion in task prompts.
@@ -210,7 +307,7 @@ func (o *WaveOrchestrator) checkWaveComplete() {
 	tasks := o.plan.Waves[o.currentWave].Tasks
 	for _, t := range tasks {
 		s := o.taskStates[t.Number]
-		if s == taskRunning || s == taskPending {
+		if s == TaskRunning || s == TaskPending {
 			return // still in progress
 		}
 	}
@@ -222,7 +319,7 @@ func (o *WaveOrchestrator) checkWaveComplete() {
 	}
 }
 
-func (o *WaveOrchestrator) countCurrentWaveByStatus(s taskStatus) int {
+func (o *WaveOrchestrator) countCurrentWaveByStatus(s TaskStatus) int {
 	if o.currentWave >= len(o.plan.Waves) {
 		return 0
 	}
@@ -1,4 +1,4 @@
-package app
+package orchestration
 
 import (
 	"testing"
@@ -88,6 +88,7 @@ func TestWaveOrchestrator_TaskFailed(t *testing.T) {
 	assert.Equal(t, WaveStateAllComplete, orch.State())
 	assert.Equal(t, 1, orch.FailedTaskCount())
 	assert.Equal(t, 1, orch.CompletedTaskCount())
+	assert.Equal(t, []int{1}, orch.FailedTasks())
 }
 
 func TestWaveOrchestrator_MultiWaveProgression(t *testing.T) {
@@ -245,3 +246,35 @@ func TestWaveOrchestrator_RetryFailedTasksRestoresRunning(t *testing.T) {
 	assert.Equal(t, WaveStateWaveComplete, orch.State(), "wave must be WaveComplete after retry+complete")
 	assert.Equal(t, 0, orch.FailedTaskCount(), "no more failures after retry completes")
 }
+
+func TestWaveOrchestrator_Rewind(t *testing.T) {
+	plan := &planparser.Plan{
+		Waves: []planparser.Wave{
+			{Number: 1, Tasks: []planparser.Task{{Number: 1, Title: "First"}}},
+			{Number: 2, Tasks: []planparser.Task{{Number: 2, Title: "Second"}}},
+			{Number: 3, Tasks: []planparser.Task{{Number: 3, Title: "Third"}}},
+		},
+	}
+
+	orch := NewWaveOrchestrator("plan.md", plan)
+	for _, task := range []int{1, 2, 3} {
+		orch.StartNextWave()
+		orch.MarkTaskComplete(task)
+	}
+	orch.StartNextWave()
+	require.Equal(t, WaveStateAllComplete, orch.State())
+
+	require.True(t, orch.Rewind(2))
+	assert.Equal(t, WaveStateIdle, orch.State())
+	assert.Equal(t, 2, orch.CurrentWaveNumber())
+	assert.True(t, orch.IsTaskComplete(1))
+	assert.False(t, orch.IsTaskComplete(2))
+	assert.False(t, orch.IsTaskComplete(3))
+
+	tasks := orch.StartNextWave()
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "Second", tasks[0].Title)
+	assert.True(t, orch.IsTaskRunning(2))
+
+	assert.False(t, orch.Rewind(7), "unknown wave")
+}
@@ -13,6 +13,9 @@ type Activity struct {
 	Action string
 	// Detail provides additional context (e.g. filename or command).
 	Detail string
+	// Path is the untruncated file path for editing/reading activities, used
+	// by the activity timeline to detect files touched by several agents.
+	Path string
 	// Timestamp is when this activity was detected.
 	Timestamp time.Time
 }
@@ -31,11 +34,34 @@ var claudeShellCmdRegex = regexp.MustCompile(`\$\s+(.+)`)
 // aiderEditingRegex matches Aider's editing pattern.
 var aiderEditingRegex = regexp.MustCompile(`Editing\s+(.+)`)
 
+// opencodeToolRegex matches opencode's tool-call rows like "← Edit src/auth.go"
+// or "┃  Bash go test ./...". The gutter glyph is required so prose such as
+// "Read the docs first" in the agent's reply is not mistaken for a tool call.
+var opencodeToolRegex = regexp.MustCompile(`^[←→┃│⚙✱]\s*(Read|Edit|Write|Patch|Bash|Grep|Glob|List)\s+(.+)`)
+
+// codexToolRegex matches codex's transcript bullets like "• Edited src/auth.go (+3 -1)",
+// "• Ran go test ./..." or "└ Read auth.go". The bullet is required so codex's
+// closing summaries ("Added tests for the parser") are not parsed as edits.
+var codexToolRegex = regexp.MustCompile(`^[•└]\s*(Edited|Added|Deleted|Ran|Read|Searched|Search|Listed|List)\s+(.+)`)
+
+// quotedPatternRegex extracts the quoted pattern from a search tool title,
+// e.g. `'TODO' within src` → TODO.
+var quotedPatternRegex = regexp.MustCompile(`["']([^"']+)["']`)
+
+// codexDiffSuffixRegex strips codex's trailing line-count summary, e.g. " (+3 -1)".
+var codexDiffSuffixRegex = regexp.MustCompile(`\s+\(\+\d+\s+-\d+\)$`)
+
+// geminiToolRegex matches gemini's tool-call box titles like "✔  ReadFile src/auth.go"
+// or "⊷  Shell go test ./... (run tests)". The status glyph is required.
+var geminiToolRegex = regexp.MustCompile(`^(?:│\s*)?[✔✓⊷✗✕]\s+(ReadFile|ReadManyFiles|WriteFile|Edit|Shell|SearchText|FindFiles|ReadFolder|GoogleSearch)\s+(.+?)\s*[│|]?$`)
+
 // ParseActivity parses the pane content to extract the current activity.
 // It scans the last ~30 lines for known patterns. program is the agent name
-// (e.g. "claude", "aider"). Returns nil if no activity is detected.
+// (e.g. "claude", "aider", "opencode", "codex", "gemini"). Returns nil if no
+// activity is detected.
 func ParseActivity(content string, program string) *Activity {
 	clean := ansiRegex.ReplaceAllString(content, "")
+	parse := programLineParser(program)
 
 	lines := strings.Split(clean, "\n")
 
@@ -53,12 +79,8 @@ func ParseActivity(content string, program string) *Activity {
 			continue
 		}
 
-		if strings.Contains(strings.ToLower(program), "claude") {
-			if a := parseClaudeLine(line); a != nil {
-				return a
-			}
-		} else if strings.Contains(strings.ToLower(program), "aider") {
-			if a := parseAiderLine(line); a != nil {
+		if parse != nil {
+			if a := parse(line); a != nil {
 				return a
 			}
 		}
@@ -72,27 +94,35 @@ func ParseActivity(content string, program string) *Activity {
 	return nil
 }
 
+// programLineParser returns the harness-specific line parser for program,
+// or nil when only the generic patterns apply.
+func programLineParser(program string) func(string) *Activity {
+	p := strings.ToLower(program)
+	switch {
+	case strings.Contains(p, "claude"):
+		return parseClaudeLine
+	case strings.Contains(p, "aider"):
+		return parseAiderLine
+	case strings.Contains(p, "opencode"):
+		return parseOpenCodeLine
+	case strings.Contains(p, "codex"):
+		return parseCodexLine
+	case strings.Contains(p, "gemini"):
+		return parseGeminiLine
+	default:
+		return nil
+	}
+}
+
 func parseClaudeLine(line string) *Activity {
 	if m := claudeEditingRegex.FindStringSubmatch(line); m != nil {
-		return &Activity{
-			Action:    "editing",
-			Detail:    truncateDetail(cleanFilename(m[1]), 40),
-			Timestamp: time.Now(),
-		}
+		return fileActivity("editing", m[1])
 	}
 	if m := claudeReadingRegex.FindStringSubmatch(line); m != nil {
-		return &Activity{
-			Action:    "reading",
-			Detail:    truncateDetail(cleanFilename(m[1]), 40),
-			Timestamp: time.Now(),
-		}
+		return fileActivity("reading", m[1])
 	}
 	if m := claudeRunningRegex.FindStringSubmatch(line); m != nil {
-		return &Activity{
-			Action:    "running",
-			Detail:    truncateDetail(strings.TrimSpace(m[1]), 40),
-			Timestamp: time.Now(),
-		}
+		return commandActivity(m[1])
 	}
 	if claudeSearchingRegex.MatchString(line) {
 		return &Activity{
@@ -102,34 +132,137 @@ func parseClaudeLine(line string) *Activity {
 		}
 	}
 	if m := claudeShellCmdRegex.FindStringSubmatch(line); m != nil {
-		return &Activity{
-			Action:    "running",
-			Detail:    truncateDetail(strings.TrimSpace(m[1]), 40),
-			Timestamp: time.Now(),
-		}
+		return commandActivity(m[1])
 	}
 	return nil
 }
 
 func parseAiderLine(line string) *Activity {
 	if m := aiderEditingRegex.FindStringSubmatch(line); m != nil {
-		return &Activity{
-			Action:    "editing",
-			Detail:    truncateDetail(cleanFilename(m[1]), 40),
-			Timestamp: time.Now(),
-		}
+		return fileActivity("editing", m[1])
 	}
 	return nil
 }
 
+func parseOpenCodeLine(line string) *Activity {
+	m := opencodeToolRegex.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	switch m[1] {
+	case "Edit", "Write", "Patch":
+		if !looksLikePath(m[2]) {
+			return nil
+		}
+		return fileActivity("editing", m[2])
+	case "Read":
+		if !looksLikePath(m[2]) {
+			return nil
+		}
+		return fileActivity("reading", m[2])
+	case "Bash":
+		return commandActivity(m[2])
+	default: // Grep, Glob, List
+		return searchActivity(m[2])
+	}
+}
+
+func parseCodexLine(line string) *Activity {
+	m := codexToolRegex.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	target := codexDiffSuffixRegex.ReplaceAllString(m[2], "")
+	switch m[1] {
+	case "Edited", "Added", "Deleted":
+		if !looksLikePath(target) {
+			return nil
+		}
+		return fileActivity("editing", target)
+	case "Read":
+		if !looksLikePath(target) {
+			return nil
+		}
+		return fileActivity("reading", target)
+	case "Ran":
+		return commandActivity(target)
+	default: // Searched, Search, Listed, List
+		return searchActivity(target)
+	}
+}
+
+func parseGeminiLine(line string) *Activity {
+	m := geminiToolRegex.FindStringSubmatch(line)
+	if m == nil {
+		return nil
+	}
+	target := m[2]
+	switch m[1] {
+	case "WriteFile", "Edit":
+		// WriteFile titles read "Writing to <path>"; Edit titles may append ": <desc>".
+		target = strings.TrimPrefix(target, "Writing to ")
+		if idx := strings.Index(target, ": "); idx > 0 {
+			target = target[:idx]
+		}
+		return fileActivity("editing", target)
+	case "ReadFile", "ReadManyFiles":
+		return fileActivity("reading", target)
+	case "Shell":
+		// Shell titles append the description in parentheses.
+		if idx := strings.LastIndex(target, " ("); idx > 0 && strings.HasSuffix(target, ")") {
+			target = target[:idx]
+		}
+		return commandActivity(target)
+	default: // SearchText, FindFiles, ReadFolder, GoogleSearch
+		return searchActivity(target)
+	}
+}
+
+// fileActivity builds an editing/reading activity for the given raw path.
+func fileActivity(action, rawPath string) *Activity {
+	path := strings.TrimSpace(rawPath)
+	return &Activity{
+		Action:    action,
+		Detail:    truncateDetail(cleanFilename(path), 40),
+		Path:      path,
+		Timestamp: time.Now(),
+	}
+}
+
+// commandActivity builds a running activity for the given shell command.
+func commandActivity(command string) *Activity {
+	return &Activity{
+		Action:    "running",
+		Detail:    truncateDetail(strings.TrimSpace(command), 40),
+		Timestamp: time.Now(),
+	}
+}
+
+// searchActivity builds a searching activity for the given query or pattern.
+// When the query contains a quoted pattern, only the pattern is kept.
+func searchActivity(query string) *Activity {
+	detail := strings.TrimSpace(query)
+	if m := quotedPatternRegex.FindStringSubmatch(detail); m != nil {
+		detail = m[1]
+	}
+	return &Activity{
+		Action:    "searching",
+		Detail:    truncateDetail(detail, 40),
+		Timestamp: time.Now(),
+	}
+}
+
+// looksLikePath reports whether s is plausibly a single file path rather than
+// prose: non-empty and free of whitespace.
+func looksLikePath(s string) bool {
+	s = strings.TrimSpace(s)
+	return s != "" && !strings.ContainsAny(s, " \t")
+}
+
 func parseGenericLine(line string) *Activity {
 	// Try to detect shell commands from common prompt patterns.
 	if m := claudeShellCmdRegex.FindStringSubmatch(line); m != nil {
-		return &Activity{
-			Action:    "running",
-			Detail:    truncateDetail(strings.TrimSpace(m[1]), 40),
-			Timestamp: time.Now(),
-		}
+		return commandActivity(m[1])
 	}
 	return nil
 }
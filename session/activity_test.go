@@ -228,3 +228,105 @@ func TestCleanFilename(t *testing.T) {
 		}
 	}
 }
+
+func TestParseActivity_OpenCodeTools(t *testing.T) {
+	tests := []struct {
+		line   string
+		action string
+		detail string
+		path   string
+	}{
+		{"← Edit src/auth/login.go", "editing", "login.go", "src/auth/login.go"},
+		{"┃  Write internal/new.go", "editing", "new.go", "internal/new.go"},
+		{"→ Read README.md", "reading", "README.md", "README.md"},
+		{"$ go test ./...", "running", "go test ./...", ""},
+		{"┃  Bash just build", "running", "just build", ""},
+		{`✱ Grep "handleLogin"`, "searching", "handleLogin", ""},
+	}
+	for _, tt := range tests {
+		a := ParseActivity(tt.line+"\n", "opencode")
+		if a == nil {
+			t.Fatalf("%q: expected activity, got nil", tt.line)
+		}
+		if a.Action != tt.action || a.Detail != tt.detail || a.Path != tt.path {
+			t.Errorf("%q: got {%s %q %q}, want {%s %q %q}", tt.line, a.Action, a.Detail, a.Path, tt.action, tt.detail, tt.path)
+		}
+	}
+}
+
+func TestParseActivity_CodexTools(t *testing.T) {
+	tests := []struct {
+		line   string
+		action string
+		detail string
+		path   string
+	}{
+		{"• Edited session/activity.go (+12 -3)", "editing", "activity.go", "session/activity.go"},
+		{"• Added docs/new.md (+40 -0)", "editing", "new.md", "docs/new.md"},
+		{"• Ran go vet ./...", "running", "go vet ./...", ""},
+		{"  └ Read instance.go", "reading", "instance.go", "instance.go"},
+		{"  └ Search ParseActivity in session", "searching", "ParseActivity in session", ""},
+	}
+	for _, tt := range tests {
+		a := ParseActivity(tt.line+"\n", "codex")
+		if a == nil {
+			t.Fatalf("%q: expected activity, got nil", tt.line)
+		}
+		if a.Action != tt.action || a.Detail != tt.detail || a.Path != tt.path {
+			t.Errorf("%q: got {%s %q %q}, want {%s %q %q}", tt.line, a.Action, a.Detail, a.Path, tt.action, tt.detail, tt.path)
+		}
+	}
+}
+
+func TestParseActivity_GeminiTools(t *testing.T) {
+	tests := []struct {
+		line   string
+		action string
+		detail string
+		path   string
+	}{
+		{"│ ✔  ReadFile src/app.ts                          │", "reading", "app.ts", "src/app.ts"},
+		{"│ ✔  WriteFile Writing to src/new.ts             │", "editing", "new.ts", "src/new.ts"},
+		{"│ ✔  Edit src/app.ts: const a = 1 => const a = 2 │", "editing", "app.ts", "src/app.ts"},
+		{"│ ⊷  Shell npm test (run the unit tests)         │", "running", "npm test", ""},
+		{"│ ✔  SearchText 'TODO' within src                │", "searching", "TODO", ""},
+	}
+	for _, tt := range tests {
+		a := ParseActivity(tt.line+"\n", "gemini")
+		if a == nil {
+			t.Fatalf("%q: expected activity, got nil", tt.line)
+		}
+		if a.Action != tt.action || a.Detail != tt.detail || a.Path != tt.path {
+			t.Errorf("%q: got {%s %q %q}, want {%s %q %q}", tt.line, a.Action, a.Detail, a.Path, tt.action, tt.detail, tt.path)
+		}
+	}
+}
+
+func TestParseActivity_HarnessProseIsIgnored(t *testing.T) {
+	tests := []struct {
+		program string
+		line    string
+	}{
+		{"opencode", "Read the docs first"},
+		{"opencode", "List of changes"},
+		{"opencode", "* Edit the config"},
+		{"codex", "Added tests for the parser"},
+		{"codex", "Edited the handler to return early"},
+		{"codex", "• Edited the handler to return early"},
+		{"gemini", "o Edit src/app.ts"},
+		{"gemini", "x Shell rm -rf build"},
+		{"gemini", "Edit src/app.ts"},
+	}
+	for _, tt := range tests {
+		if a := ParseActivity(tt.line+"\n", tt.program); a != nil {
+			t.Errorf("%s %q: expected nil, got %+v", tt.program, tt.line, a)
+		}
+	}
+}
+
+func TestParseActivity_HarnessParsersAreScoped(t *testing.T) {
+	// Opencode tool rows must not be picked up for an unrelated program.
+	if a := ParseActivity("← Edit src/auth.go\n", "aider"); a != nil {
+		t.Errorf("expected nil for aider, got %+v", a)
+	}
+}
@@ -0,0 +1,180 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// maxTimelineEntries bounds the per-instance activity history so long-running
+// agents don't grow the persisted state without limit.
+const maxTimelineEntries = 200
+
+// ActivityTimeline is a bounded, chronological history of detected agent activity.
+// Consecutive duplicates (same action + detail) are collapsed so a spinner line
+// that stays on screen for many ticks is recorded once.
+type ActivityTimeline struct {
+	entries []Activity
+}
+
+// Record appends a to the timeline. Returns false when a repeats the most
+// recent entry and was dropped.
+func (t *ActivityTimeline) Record(a Activity) bool {
+	if n := len(t.entries); n > 0 {
+		last := t.entries[n-1]
+		if last.Action == a.Action && last.Detail == a.Detail && last.Path == a.Path {
+			return false
+		}
+	}
+	t.entries = append(t.entries, a)
+	if len(t.entries) > maxTimelineEntries {
+		t.entries = append([]Activity(nil), t.entries[len(t.entries)-maxTimelineEntries:]...)
+	}
+	return true
+}
+
+// Entries returns a copy of the timeline, oldest first.
+func (t *ActivityTimeline) Entries() []Activity {
+	out := make([]Activity, len(t.entries))
+	copy(out, t.entries)
+	return out
+}
+
+// Len returns the number of recorded entries.
+func (t *ActivityTimeline) Len() int {
+	return len(t.entries)
+}
+
+// EditedFiles returns the unique file paths the agent edited, in first-edit order.
+func (t *ActivityTimeline) EditedFiles() []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, e := range t.entries {
+		if e.Action != "editing" || e.Path == "" || seen[e.Path] {
+			continue
+		}
+		seen[e.Path] = true
+		files = append(files, e.Path)
+	}
+	return files
+}
+
+// RecordActivity appends a to the instance's activity timeline and makes it the
+// current LastActivity. A nil activity is ignored.
+func (i *Instance) RecordActivity(a *Activity) {
+	if a == nil {
+		return
+	}
+	i.LastActivity = a
+	i.timeline.Record(*a)
+}
+
+// ActivityTimeline returns the instance's recorded activity history, oldest first.
+func (i *Instance) ActivityTimeline() []Activity {
+	return i.timeline.Entries()
+}
+
+// EditedFiles returns the unique files this instance has been seen editing.
+func (i *Instance) EditedFiles() []string {
+	return i.timeline.EditedFiles()
+}
+
+// InstanceActivity is a timeline entry attributed to the instance that produced it.
+type InstanceActivity struct {
+	Activity
+	// Instance is the title of the instance that recorded the activity.
+	Instance string
+}
+
+// PlanActivity merges the activity timelines of every instance bound to planFile
+// into a single history, oldest first. Only the given (live) instances are
+// consulted; callers merge in timelines archived from removed instances via
+// UnmarshalActivity.
+func PlanActivity(instances []*Instance, planFile string) []InstanceActivity {
+	var out []InstanceActivity
+	for _, inst := range instances {
+		if planFile == "" || inst.PlanFile != planFile {
+			continue
+		}
+		for _, a := range inst.timeline.entries {
+			out = append(out, InstanceActivity{Activity: a, Instance: inst.Title})
+		}
+	}
+	sort.SliceStable(out, func(a, b int) bool {
+		return out[a].Timestamp.Before(out[b].Timestamp)
+	})
+	return out
+}
+
+// FileOverlaps returns files edited by more than one of the given instances,
+// mapped to the titles of the instances that touched them. Used to spot parallel
+// wave tasks colliding on the same file before their commits conflict.
+func FileOverlaps(instances []*Instance) map[string][]string {
+	touched := make(map[string][]string)
+	for _, inst := range instances {
+		for _, f := range inst.timeline.EditedFiles() {
+			touched[f] = append(touched[f], inst.Title)
+		}
+	}
+	overlaps := make(map[string][]string)
+	for f, titles := range touched {
+		if len(titles) > 1 {
+			overlaps[f] = titles
+		}
+	}
+	return overlaps
+}
+
+// ActivityData represents the serializable form of an Activity.
+type ActivityData struct {
+	Action    string    `json:"action"`
+	Detail    string    `json:"detail,omitempty"`
+	Path      string    `json:"path,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// MarshalActivity encodes a timeline as JSON for archiving (e.g. in an audit
+// event's Detail) once its instance is removed.
+func MarshalActivity(entries []Activity) (string, error) {
+	var t ActivityTimeline
+	t.entries = entries
+	data := timelineToData(&t)
+	if data == nil {
+		data = []ActivityData{}
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("marshal activity timeline: %w", err)
+	}
+	return string(raw), nil
+}
+
+// UnmarshalActivity decodes a timeline produced by MarshalActivity.
+func UnmarshalActivity(raw string) ([]Activity, error) {
+	var data []ActivityData
+	if err := json.Unmarshal([]byte(raw), &data); err != nil {
+		return nil, fmt.Errorf("unmarshal activity timeline: %w", err)
+	}
+	t := timelineFromData(data)
+	return t.entries, nil
+}
+
+func timelineToData(t *ActivityTimeline) []ActivityData {
+	if len(t.entries) == 0 {
+		return nil
+	}
+	data := make([]ActivityData, len(t.entries))
+	for i, a := range t.entries {
+		data[i] = ActivityData{Action: a.Action, Detail: a.Detail, Path: a.Path, Timestamp: a.Timestamp}
+	}
+	return data
+}
+
+func timelineFromData(data []ActivityData) ActivityTimeline {
+	var t ActivityTimeline
+	for _, d := range data {
+		t.entries = append(t.entries, Activity{Action: d.Action, Detail: d.Detail, Path: d.Path, Timestamp: d.Timestamp})
+	}
+	return t
+}
@@ -0,0 +1,82 @@
+package session
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivityTimeline_CollapsesConsecutiveDuplicates(t *testing.T) {
+	var tl ActivityTimeline
+	now := time.Now()
+	assert.True(t, tl.Record(Activity{Action: "editing", Detail: "a.go", Path: "x/a.go", Timestamp: now}))
+	assert.False(t, tl.Record(Activity{Action: "editing", Detail: "a.go", Path: "x/a.go", Timestamp: now.Add(time.Second)}))
+	assert.True(t, tl.Record(Activity{Action: "running", Detail: "go test", Timestamp: now.Add(2 * time.Second)}))
+	assert.True(t, tl.Record(Activity{Action: "editing", Detail: "a.go", Path: "x/a.go", Timestamp: now.Add(3 * time.Second)}))
+	assert.Equal(t, 3, tl.Len())
+	assert.Equal(t, []string{"x/a.go"}, tl.EditedFiles())
+}
+
+func TestActivityTimeline_Bounded(t *testing.T) {
+	var tl ActivityTimeline
+	for i := 0; i < maxTimelineEntries+25; i++ {
+		tl.Record(Activity{Action: "running", Detail: string(rune('a' + i%26)), Path: time.Duration(i).String()})
+	}
+	require.Equal(t, maxTimelineEntries, tl.Len())
+	assert.Equal(t, time.Duration(25).String(), tl.Entries()[0].Path)
+}
+
+func TestPlanActivityAndFileOverlaps(t *testing.T) {
+	base := time.Now()
+	t1 := &Instance{Title: "p-W1-T1", PlanFile: "p.md"}
+	t2 := &Instance{Title: "p-W1-T2", PlanFile: "p.md"}
+	other := &Instance{Title: "other", PlanFile: "q.md"}
+
+	t1.RecordActivity(&Activity{Action: "editing", Detail: "a.go", Path: "pkg/a.go", Timestamp: base})
+	t2.RecordActivity(&Activity{Action: "editing", Detail: "b.go", Path: "pkg/b.go", Timestamp: base.Add(time.Second)})
+	t2.RecordActivity(&Activity{Action: "editing", Detail: "a.go", Path: "pkg/a.go", Timestamp: base.Add(2 * time.Second)})
+	other.RecordActivity(&Activity{Action: "editing", Detail: "a.go", Path: "pkg/a.go", Timestamp: base})
+
+	history := PlanActivity([]*Instance{t1, t2, other}, "p.md")
+	require.Len(t, history, 3)
+	assert.Equal(t, "p-W1-T1", history[0].Instance)
+	assert.Equal(t, "pkg/a.go", history[2].Path)
+	assert.Equal(t, "p-W1-T2", history[2].Instance)
+
+	overlaps := FileOverlaps([]*Instance{t1, t2})
+	assert.Equal(t, map[string][]string{"pkg/a.go": {"p-W1-T1", "p-W1-T2"}}, overlaps)
+}
+
+func TestActivityTimeline_RoundTripsThroughInstanceData(t *testing.T) {
+	ts := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	inst := &Instance{Title: "coder", Status: Paused}
+	inst.RecordActivity(&Activity{Action: "editing", Detail: "a.go", Path: "pkg/a.go", Timestamp: ts})
+
+	raw, err := json.Marshal(inst.ToInstanceData())
+	require.NoError(t, err)
+	var data InstanceData
+	require.NoError(t, json.Unmarshal(raw, &data))
+	require.Len(t, data.Activity, 1)
+
+	restored, err := FromInstanceData(data)
+	require.NoError(t, err)
+	got := restored.ActivityTimeline()
+	require.Len(t, got, 1)
+	assert.Equal(t, "pkg/a.go", got[0].Path)
+	assert.True(t, got[0].Timestamp.Equal(ts))
+}
+
+func TestMarshalActivity_RoundTrip(t *testing.T) {
+	ts := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	raw, err := MarshalActivity([]Activity{{Action: "running", Detail: "go test", Timestamp: ts}})
+	require.NoError(t, err)
+
+	got, err := UnmarshalActivity(raw)
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, "go test", got[0].Detail)
+	assert.True(t, got[0].Timestamp.Equal(ts))
+}
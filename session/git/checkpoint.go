@@ -0,0 +1,135 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// checkpointRefPrefix is the ref namespace holding wave checkpoints. Refs
+// outside refs/heads and refs/tags are never pushed or shown as branches.
+const checkpointRefPrefix = "refs/kasmos/checkpoints/"
+
+// WaveCheckpoint is the plan branch commit recorded before a wave started.
+type WaveCheckpoint struct {
+	Wave int
+	SHA  string
+}
+
+// checkpointPlanPrefix returns the ref prefix holding a plan's checkpoints.
+// Refs are keyed on the plan filename, which is unique, rather than its
+// display name, which two plans created on different days can share.
+// "2026-02-21-auth-refactor.md" → "refs/kasmos/checkpoints/2026-02-21-auth-refactor/"
+func checkpointPlanPrefix(planFile string) string {
+	name := sanitizeBranchName(strings.TrimSuffix(filepath.Base(planFile), ".md"))
+	name = strings.ReplaceAll(name, "/", "-")
+	if name == "" {
+		name = "plan"
+	}
+	return checkpointRefPrefix + name + "/"
+}
+
+// CheckpointRef returns the ref recording the plan branch before wave started.
+func CheckpointRef(planFile string, wave int) string {
+	return checkpointPlanPrefix(planFile) + "wave-" + strconv.Itoa(wave)
+}
+
+// CreateWaveCheckpoint records the current tip of the plan branch as the
+// checkpoint for wave, replacing any earlier checkpoint for the same wave.
+// It returns the recorded commit.
+func CreateWaveCheckpoint(repoPath, branch, planFile string, wave int) (string, error) {
+	gt := &GitWorktree{repoPath: repoPath, worktreePath: repoPath}
+	sha, err := BranchTip(repoPath, branch)
+	if err != nil {
+		return "", err
+	}
+	if _, err := gt.runGitCommand(repoPath, "update-ref", CheckpointRef(planFile, wave), sha); err != nil {
+		return "", fmt.Errorf("record wave %d checkpoint: %w", wave, err)
+	}
+	return sha, nil
+}
+
+// WaveCheckpoints returns the plan's recorded checkpoints, lowest wave first.
+func WaveCheckpoints(repoPath, planFile string) ([]WaveCheckpoint, error) {
+	gt := &GitWorktree{repoPath: repoPath, worktreePath: repoPath}
+	prefix := checkpointPlanPrefix(planFile)
+	out, err := gt.runGitCommand(repoPath, "for-each-ref", "--format=%(refname) %(objectname)", prefix)
+	if err != nil {
+		return nil, fmt.Errorf("list checkpoints: %w", err)
+	}
+	var checkpoints []WaveCheckpoint
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		ref, sha, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		wave, err := strconv.Atoi(strings.TrimPrefix(ref, prefix+"wave-"))
+		if err != nil {
+			continue
+		}
+		checkpoints = append(checkpoints, WaveCheckpoint{Wave: wave, SHA: sha})
+	}
+	sort.Slice(checkpoints, func(i, j int) bool { return checkpoints[i].Wave < checkpoints[j].Wave })
+	return checkpoints, nil
+}
+
+// RollbackToCheckpoint resets the plan branch in its shared worktree
+// (creating the worktree if needed) to the checkpoint recorded before wave,
+// discarding everything committed or left uncommitted since. Checkpoints of
+// later waves are deleted; the wave's own checkpoint is kept so it can be
+// rolled back to again. It returns the commit the branch now points at.
+func RollbackToCheckpoint(repoPath, branch, planFile string, wave int) (string, error) {
+	gt := &GitWorktree{repoPath: repoPath, worktreePath: repoPath}
+	ref := CheckpointRef(planFile, wave)
+	out, err := gt.runGitCommand(repoPath, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return "", fmt.Errorf("no checkpoint for wave %d: %w", wave, err)
+	}
+	sha := strings.TrimSpace(out)
+
+	worktreePath := PlanWorktreePath(repoPath, branch)
+	if _, err := os.Stat(worktreePath); err != nil {
+		_, _ = gt.runGitCommand(repoPath, "worktree", "prune")
+		if _, err := gt.runGitCommand(repoPath, "worktree", "add", worktreePath, branch); err != nil {
+			return "", fmt.Errorf("check out %s for rollback: %w", branch, err)
+		}
+	}
+	if _, err := gt.runGitCommand(worktreePath, "reset", "--hard", sha); err != nil {
+		return "", fmt.Errorf("reset %s to wave %d checkpoint: %w", branch, wave, err)
+	}
+	if _, err := gt.runGitCommand(worktreePath, "clean", "-fd"); err != nil {
+		return "", fmt.Errorf("clean plan worktree: %w", err)
+	}
+
+	checkpoints, err := WaveCheckpoints(repoPath, planFile)
+	if err != nil {
+		return sha, err
+	}
+	for _, cp := range checkpoints {
+		if cp.Wave > wave {
+			if _, err := gt.runGitCommand(repoPath, "update-ref", "-d", CheckpointRef(planFile, cp.Wave)); err != nil {
+				return sha, fmt.Errorf("delete wave %d checkpoint: %w", cp.Wave, err)
+			}
+		}
+	}
+	return sha, nil
+}
+
+// DeleteWaveCheckpoints removes all of the plan's checkpoints, e.g. once the
+// plan has landed or its branch was reset.
+func DeleteWaveCheckpoints(repoPath, planFile string) error {
+	gt := &GitWorktree{repoPath: repoPath, worktreePath: repoPath}
+	checkpoints, err := WaveCheckpoints(repoPath, planFile)
+	if err != nil {
+		return err
+	}
+	for _, cp := range checkpoints {
+		if _, err := gt.runGitCommand(repoPath, "update-ref", "-d", CheckpointRef(planFile, cp.Wave)); err != nil {
+			return fmt.Errorf("delete wave %d checkpoint: %w", cp.Wave, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointRef(t *testing.T) {
+	assert.Equal(t, "refs/kasmos/checkpoints/2026-02-21-auth-refactor/wave-2",
+		CheckpointRef("2026-02-21-auth-refactor.md", 2))
+	assert.NotEqual(t, CheckpointRef("2026-02-21-auth-refactor.md", 2),
+		CheckpointRef("2026-03-04-auth-refactor.md", 2), "plans sharing a display name keep separate checkpoints")
+}
+
+func TestRollbackToCheckpoint(t *testing.T) {
+	repo, branch, worktree := setupPlanRepo(t)
+	planFile := "2026-02-21-auth.md"
+
+	wave1, err := CreateWaveCheckpoint(repo, branch, planFile, 1)
+	require.NoError(t, err)
+	commitFile(t, worktree, "wave1.go", "package auth\n", "wave 1")
+	wave2, err := CreateWaveCheckpoint(repo, branch, planFile, 2)
+	require.NoError(t, err)
+	commitFile(t, worktree, "wave2.go", "package auth\n", "wave 2")
+	_, err = CreateWaveCheckpoint(repo, branch, planFile, 3)
+	require.NoError(t, err)
+	commitFile(t, worktree, "wave3.go", "package auth\n", "wave 3")
+	require.NoError(t, os.WriteFile(filepath.Join(worktree, "scratch.go"), []byte("x"), 0644))
+
+	checkpoints, err := WaveCheckpoints(repo, planFile)
+	require.NoError(t, err)
+	require.Len(t, checkpoints, 3)
+	assert.Equal(t, WaveCheckpoint{Wave: 1, SHA: wave1}, checkpoints[0])
+	assert.Equal(t, 3, checkpoints[2].Wave)
+
+	sha, err := RollbackToCheckpoint(repo, branch, planFile, 2)
+	require.NoError(t, err)
+	assert.Equal(t, wave2, sha)
+	assert.Equal(t, wave2, gitOut(t, repo, "rev-parse", branch))
+	assert.FileExists(t, filepath.Join(worktree, "wave1.go"))
+	assert.NoFileExists(t, filepath.Join(worktree, "wave2.go"))
+	assert.NoFileExists(t, filepath.Join(worktree, "scratch.go"), "untracked files are cleaned")
+
+	checkpoints, err = WaveCheckpoints(repo, planFile)
+	require.NoError(t, err)
+	assert.Equal(t, []WaveCheckpoint{{Wave: 1, SHA: wave1}, {Wave: 2, SHA: wave2}}, checkpoints,
+		"later checkpoints are dropped, the target is kept")
+
+	_, err = RollbackToCheckpoint(repo, branch, planFile, 3)
+	assert.Error(t, err, "wave 3 checkpoint no longer exists")
+
+	require.NoError(t, DeleteWaveCheckpoints(repo, planFile))
+	checkpoints, err = WaveCheckpoints(repo, planFile)
+	require.NoError(t, err)
+	assert.Empty(t, checkpoints)
+}
+
+func TestRollbackToCheckpoint_RecreatesWorktree(t *testing.T) {
+	repo, branch, worktree := setupPlanRepo(t)
+	planFile := "2026-02-21-auth.md"
+
+	base, err := CreateWaveCheckpoint(repo, branch, planFile, 1)
+	require.NoError(t, err)
+	commitFile(t, worktree, "wave1.go", "package auth\n", "wave 1")
+	gitOut(t, repo, "worktree", "remove", worktree)
+
+	sha, err := RollbackToCheckpoint(repo, branch, planFile, 1)
+	require.NoError(t, err)
+	assert.Equal(t, base, sha)
+	assert.Equal(t, base, gitOut(t, repo, "rev-parse", branch))
+	assert.DirExists(t, worktree)
+}
@@ -0,0 +1,85 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// ChangedFiles lists the files branch changes relative to its merge base
+// with base, sorted.
+func ChangedFiles(repoPath, branch, base string) ([]string, error) {
+	gt := &GitWorktree{repoPath: repoPath, worktreePath: repoPath}
+	out, err := gt.runGitCommand(repoPath, "diff", "--name-only", "--no-renames", base+"..."+branch)
+	if err != nil {
+		return nil, fmt.Errorf("list files changed on %s: %w", branch, err)
+	}
+	var files []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// MergeConflicts predicts the files that would conflict if branches a and b
+// were merged, without touching any worktree or ref (`git merge-tree
+// --write-tree`, git 2.38+). An empty result means they merge cleanly.
+func MergeConflicts(repoPath, a, b string) ([]string, error) {
+	cmd := exec.Command("git", "-C", repoPath, "merge-tree", "--write-tree", "--name-only", "--no-messages", a, b)
+	out, err := cmd.Output()
+	if err != nil {
+		// Exit status 1 with a tree on stdout means the merge has conflicts;
+		// git also exits 1 without output for refs it cannot resolve.
+		var ee *exec.ExitError
+		if !errors.As(err, &ee) {
+			return nil, fmt.Errorf("merge-tree %s %s: %w", a, b, err)
+		}
+		if ee.ExitCode() != 1 || strings.TrimSpace(string(out)) == "" {
+			return nil, fmt.Errorf("merge-tree %s %s: %s (%w)", a, b, strings.TrimSpace(string(ee.Stderr)), err)
+		}
+	}
+	return parseMergeTreeConflicts(string(out)), nil
+}
+
+// parseMergeTreeConflicts parses `git merge-tree --write-tree --name-only
+// --no-messages` output: the merged tree on the first line, then one
+// conflicted path per line.
+func parseMergeTreeConflicts(out string) []string {
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) < 2 {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var files []string
+	for _, line := range lines[1:] {
+		if line = strings.TrimSpace(line); line != "" && !seen[line] {
+			seen[line] = true
+			files = append(files, line)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// IntersectFiles returns the paths present in both sorted lists.
+func IntersectFiles(a, b []string) []string {
+	var common []string
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			common = append(common, a[i])
+			i++
+			j++
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return common
+}
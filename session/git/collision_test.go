@@ -0,0 +1,51 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeConflicts(t *testing.T) {
+	repo := initTestRepo(t)
+	commitFile(t, repo, "shared.go", "package shared\n\nconst V = 0\n", "add shared")
+	base := gitOut(t, repo, "rev-parse", "--abbrev-ref", "HEAD")
+
+	gitOut(t, repo, "checkout", "-q", "-b", "plan/a")
+	commitFile(t, repo, "shared.go", "package shared\n\nconst V = 1\n", "a edits shared")
+	commitFile(t, repo, "a.go", "package a\n", "add a")
+	gitOut(t, repo, "checkout", "-q", base)
+	gitOut(t, repo, "checkout", "-q", "-b", "plan/b")
+	commitFile(t, repo, "shared.go", "package shared\n\nconst V = 2\n", "b edits shared")
+	commitFile(t, repo, "b.go", "package b\n", "add b")
+	gitOut(t, repo, "checkout", "-q", base)
+	gitOut(t, repo, "checkout", "-q", "-b", "plan/c")
+	commitFile(t, repo, "c.go", "package c\n", "add c")
+	gitOut(t, repo, "checkout", "-q", base)
+
+	files, err := ChangedFiles(repo, "plan/a", base)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a.go", "shared.go"}, files)
+
+	other, err := ChangedFiles(repo, "plan/b", base)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shared.go"}, IntersectFiles(files, other))
+
+	conflicts, err := MergeConflicts(repo, "plan/a", "plan/b")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"shared.go"}, conflicts)
+
+	conflicts, err = MergeConflicts(repo, "plan/a", "plan/c")
+	require.NoError(t, err)
+	assert.Empty(t, conflicts)
+
+	_, err = MergeConflicts(repo, "plan/a", "plan/missing")
+	assert.Error(t, err)
+}
+
+func TestParseMergeTreeConflicts(t *testing.T) {
+	assert.Nil(t, parseMergeTreeConflicts("4b825dc642cb6eb9a060e54bf8d69288fbee4904\n"))
+	assert.Equal(t, []string{"a.go", "b.go"},
+		parseMergeTreeConflicts("4b825dc\nb.go\na.go\nb.go\n"))
+}
@@ -3,59 +3,220 @@ package git
 import (
 	"fmt"
 	"os"
+	"os/exec"
 	"strings"
+	"time"
+
+	"github.com/kastheco/kasmos/config/planlog"
 )
 
 // DiffStats holds statistics about the changes in a diff
 type DiffStats struct {
-	// Content is the full diff content
+	// Content is the full diff content, including untracked files
 	Content string
 	// Added is the number of added lines
 	Added int
 	// Removed is the number of removed lines
 	Removed int
+	// Head is the worktree's HEAD commit when the diff was computed
+	Head string
 	// Error holds any error that occurred during diff computation
 	// This allows propagating setup errors (like missing base commit) without breaking the flow
 	Error error
 }
 
+// CommitDiff is a single commit on the branch with its patch.
+type CommitDiff struct {
+	SHA     string
+	Subject string
+	// Task is the value of the commit's Kasmos-Task trailer, if any.
+	Task string
+	// Content is the commit's unified diff.
+	Content string
+}
+
 func (d *DiffStats) IsEmpty() bool {
 	return d.Added == 0 && d.Removed == 0 && d.Content == ""
 }
 
-// Diff returns the git diff between the worktree and the base branch along with statistics
-func (g *GitWorktree) Diff() *DiffStats {
-	stats := &DiffStats{}
+// diffRefreshInterval is how long a cached diff is reused while HEAD and the
+// index are unchanged. Edits to tracked or untracked files touch neither, so
+// they show up within this interval rather than on the next metadata tick.
+const diffRefreshInterval = 2 * time.Second
+
+// diffCacheKey identifies the repository state a cached diff was computed
+// against.
+type diffCacheKey struct {
+	base, head string
+	indexMod   time.Time
+}
 
+// Diff returns the git diff between the worktree and the base branch along
+// with statistics. It runs on every metadata tick, so the result is cached and
+// only recomputed when HEAD, the index or the base moves, or after
+// diffRefreshInterval.
+func (g *GitWorktree) Diff() *DiffStats {
 	// Bail out early if the worktree directory no longer exists on disk
 	// (e.g. cleaned up externally or after pause). Avoids spamming git
 	// errors every tick.
 	if _, err := os.Stat(g.worktreePath); err != nil {
-		stats.Error = fmt.Errorf("worktree path gone: %w", err)
-		return stats
+		return &DiffStats{Error: fmt.Errorf("worktree path gone: %w", err)}
 	}
 
 	base := g.GetBaseCommitSHA()
 	if base == "" {
-		stats.Error = fmt.Errorf("no base commit SHA available")
-		return stats
+		return &DiffStats{Error: fmt.Errorf("no base commit SHA available")}
 	}
 
-	// Diff tracked changes (read-only, does not touch the index).
-	content, err := g.runGitCommand(g.worktreePath, "--no-pager", "diff", base)
+	head, err := g.runGitCommand(g.worktreePath, "rev-parse", "HEAD")
 	if err != nil {
-		stats.Error = err
-		return stats
+		return &DiffStats{Error: err}
 	}
-	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
-			stats.Added++
-		} else if strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---") {
-			stats.Removed++
+	key := diffCacheKey{base: base, head: strings.TrimSpace(head)}
+	if indexPath, err := g.gitIndexPath(); err == nil {
+		if fi, err := os.Stat(indexPath); err == nil {
+			key.indexMod = fi.ModTime()
 		}
 	}
-	stats.Content = content
 
+	g.diffMu.Lock()
+	if g.diffCache != nil && g.diffKey == key && time.Since(g.diffAt) < diffRefreshInterval {
+		cached := *g.diffCache
+		g.diffMu.Unlock()
+		return &cached
+	}
+	g.diffMu.Unlock()
+
+	content, err := g.diffWithUntracked(base)
+	if err != nil {
+		return &DiffStats{Error: err}
+	}
+	stats := &DiffStats{Content: content, Head: key.head}
+	stats.Added, stats.Removed = countDiffLines(content)
+
+	cached := *stats
+	g.diffMu.Lock()
+	g.diffCache, g.diffKey, g.diffAt = &cached, key, time.Now()
+	g.diffMu.Unlock()
 	return stats
 }
+
+// CommitLog returns the branch's commits since the base with their patches,
+// oldest first. It is only needed by the diff pane's per-commit view, so it is
+// computed on demand rather than with Diff.
+func (g *GitWorktree) CommitLog() ([]CommitDiff, error) {
+	base := g.GetBaseCommitSHA()
+	if base == "" {
+		return nil, fmt.Errorf("no base commit SHA available")
+	}
+	log, err := g.runGitCommand(g.worktreePath, "--no-pager", "log", "--reverse", "--no-color",
+		"--format="+commitMarker+"%H%x1f%s%x1f%(trailers:key="+planlog.TaskTrailer+",valueonly,separator=%x2C)",
+		"--patch", base+"..HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return parseCommitLog(log), nil
+}
+
+// gitIndexPath returns the absolute path of the worktree's index file. The
+// path never changes for a worktree, so it is looked up once.
+func (g *GitWorktree) gitIndexPath() (string, error) {
+	g.diffMu.Lock()
+	defer g.diffMu.Unlock()
+	if g.indexPath != "" {
+		return g.indexPath, nil
+	}
+	out, err := g.runGitCommand(g.worktreePath, "rev-parse", "--path-format=absolute", "--git-path", "index")
+	if err != nil {
+		return "", err
+	}
+	g.indexPath = strings.TrimSpace(out)
+	return g.indexPath, nil
+}
+
+// diffWithUntracked diffs the worktree against base, including untracked
+// files. Untracked files are marked intent-to-add in a throwaway copy of the
+// index, so the worktree's real index is never touched.
+func (g *GitWorktree) diffWithUntracked(base string) (string, error) {
+	untracked, err := g.runGitCommand(g.worktreePath, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil || untracked == "" {
+		return g.runGitCommand(g.worktreePath, "--no-pager", "diff", "--no-color", base)
+	}
+
+	indexPath, err := g.gitIndexPath()
+	if err != nil {
+		return "", err
+	}
+	index, err := os.ReadFile(indexPath)
+	if err != nil {
+		return "", fmt.Errorf("read index: %w", err)
+	}
+	tmp, err := os.CreateTemp("", "kasmos-index-*")
+	if err != nil {
+		return "", fmt.Errorf("temp index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(index)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("temp index: %w", err)
+	}
+
+	env := append(os.Environ(), "GIT_INDEX_FILE="+tmp.Name())
+	files := strings.Split(strings.TrimRight(untracked, "\x00"), "\x00")
+	if _, err := runGitWithEnv(env, g.worktreePath, append([]string{"add", "--intent-to-add", "--"}, files...)...); err != nil {
+		return "", err
+	}
+	return runGitWithEnv(env, g.worktreePath, "--no-pager", "diff", "--no-color", base)
+}
+
+// runGitWithEnv runs git in dir with the given environment and returns stdout.
+func runGitWithEnv(env []string, dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = env
+	out, err := cmd.Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("git command failed: %s (%w)", ee.Stderr, err)
+		}
+		return "", fmt.Errorf("git command failed: %w", err)
+	}
+	return string(out), nil
+}
+
+// commitMarker starts each commit header in the log parsed by parseCommitLog.
+const commitMarker = "\x1ecommit "
+
+// parseCommitLog splits `git log --patch` output produced with commitMarker
+// headers into commits.
+func parseCommitLog(out string) []CommitDiff {
+	var commits []CommitDiff
+	for _, block := range strings.Split(out, commitMarker)[1:] {
+		header, patch, _ := strings.Cut(block, "\n")
+		fields := strings.SplitN(header, "\x1f", 3)
+		if len(fields) < 2 {
+			continue
+		}
+		c := CommitDiff{SHA: fields[0], Subject: fields[1]}
+		if len(fields) == 3 {
+			c.Task = strings.TrimSpace(fields[2])
+		}
+		c.Content = strings.TrimLeft(patch, "\n")
+		commits = append(commits, c)
+	}
+	return commits
+}
+
+// countDiffLines counts added and removed lines in a unified diff.
+func countDiffLines(content string) (added, removed int) {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, "+") && !strings.HasPrefix(line, "+++") {
+			added++
+		} else if strings.HasPrefix(line, "-") && !strings.HasPrefix(line, "---") {
+			removed++
+		}
+	}
+	return added, removed
+}
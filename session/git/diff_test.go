@@ -0,0 +1,69 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kastheco/kasmos/config/planlog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommitLog(t *testing.T) {
+	out := commitMarker + "aaa\x1fadd auth\x1f3\n\ndiff --git a/auth.go b/auth.go\n+package auth\n" +
+		commitMarker + "bbb\x1fupdate docs\x1f\n\ndiff --git a/README.md b/README.md\n-old\n+new\n"
+	commits := parseCommitLog(out)
+	require.Len(t, commits, 2)
+	assert.Equal(t, CommitDiff{SHA: "aaa", Subject: "add auth", Task: "3",
+		Content: "diff --git a/auth.go b/auth.go\n+package auth\n"}, commits[0])
+	assert.Equal(t, "bbb", commits[1].SHA)
+	assert.Empty(t, commits[1].Task)
+	assert.Empty(t, parseCommitLog(""))
+}
+
+func TestDiff_IncludesUntrackedAndCommits(t *testing.T) {
+	repo, branch, worktree := setupPlanRepo(t)
+	base := gitOut(t, worktree, "rev-parse", "HEAD~2")
+	gitOut(t, worktree, "commit", "--amend", "-m", "test auth\n\n"+planlog.TaskTrailer+": 2")
+	require.NoError(t, os.WriteFile(filepath.Join(worktree, "new.go"), []byte("package auth\n\nfunc New() {}\n"), 0644))
+
+	g := NewGitWorktreeFromStorage(repo, worktree, "auth", branch, base)
+	stats := g.Diff()
+	require.NoError(t, stats.Error)
+	assert.Contains(t, stats.Content, "diff --git a/new.go b/new.go")
+	assert.Contains(t, stats.Content, "diff --git a/auth.go b/auth.go")
+	assert.Equal(t, 5, stats.Added)
+	assert.Equal(t, gitOut(t, worktree, "rev-parse", "HEAD"), stats.Head)
+
+	commits, err := g.CommitLog()
+	require.NoError(t, err)
+	require.Len(t, commits, 2)
+	assert.Equal(t, "add auth", commits[0].Subject)
+	assert.Equal(t, "2", commits[1].Task)
+	assert.Contains(t, commits[1].Content, "auth_test.go")
+
+	// The worktree's real index is left alone.
+	assert.Equal(t, "?? new.go", gitOut(t, worktree, "status", "--porcelain"))
+}
+
+func TestDiff_CachedUntilHeadMoves(t *testing.T) {
+	repo, branch, worktree := setupPlanRepo(t)
+	base := gitOut(t, worktree, "rev-parse", "HEAD~2")
+	g := NewGitWorktreeFromStorage(repo, worktree, "auth", branch, base)
+
+	first := g.Diff()
+	require.NoError(t, first.Error)
+
+	// A plain file edit is picked up on the next refresh, not every call.
+	require.NoError(t, os.WriteFile(filepath.Join(worktree, "new.go"), []byte("package auth\n"), 0644))
+	assert.Equal(t, first.Content, g.Diff().Content)
+
+	// Committing moves HEAD and the index, which invalidates the cache.
+	gitOut(t, worktree, "add", "new.go")
+	gitOut(t, worktree, "commit", "-m", "add new")
+	stats := g.Diff()
+	require.NoError(t, stats.Error)
+	assert.Contains(t, stats.Content, "diff --git a/new.go b/new.go")
+	assert.NotEqual(t, first.Head, stats.Head)
+}
@@ -0,0 +1,91 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// BranchDrift is how far a branch has moved away from its base.
+type BranchDrift struct {
+	Base string
+	// Ahead is the number of commits on the branch that are not on Base.
+	Ahead int
+	// Behind is the number of commits on Base that the branch lacks.
+	Behind int
+}
+
+// PlanBranchDrift counts the commits branch is ahead of and behind base.
+func PlanBranchDrift(repoPath, branch, base string) (BranchDrift, error) {
+	gt := &GitWorktree{repoPath: repoPath, worktreePath: repoPath}
+	out, err := gt.runGitCommand(repoPath, "rev-list", "--left-right", "--count", base+"..."+branch)
+	if err != nil {
+		return BranchDrift{}, fmt.Errorf("compare %s with %s: %w", branch, base, err)
+	}
+	return parseDrift(base, out)
+}
+
+// parseDrift parses `git rev-list --left-right --count base...branch` output:
+// "<behind>\t<ahead>".
+func parseDrift(base, out string) (BranchDrift, error) {
+	fields := strings.Fields(out)
+	if len(fields) != 2 {
+		return BranchDrift{}, fmt.Errorf("unexpected rev-list output %q", out)
+	}
+	behind, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return BranchDrift{}, fmt.Errorf("unexpected rev-list output %q", out)
+	}
+	ahead, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return BranchDrift{}, fmt.Errorf("unexpected rev-list output %q", out)
+	}
+	return BranchDrift{Base: base, Ahead: ahead, Behind: behind}, nil
+}
+
+// RebasePlanBranch rebases the plan branch onto base inside its shared
+// worktree (creating the worktree if needed) and returns the new base commit
+// for diffing. The worktree must have no uncommitted changes. On a conflict
+// the rebase is aborted and a *MergeConflictError is returned, leaving the
+// branch exactly as it was.
+func RebasePlanBranch(repoPath, branch, base string) (string, error) {
+	return RestackPlanBranch(repoPath, branch, base, base)
+}
+
+// RestackPlanBranch moves the plan branch's commits that are not reachable
+// from upstream onto onto, like `git rebase --onto onto upstream`. Stacked
+// plans use it when their parent lands: upstream is the parent's old tip, so
+// only the plan's own commits are replayed on the default branch. It behaves
+// like RebasePlanBranch otherwise.
+func RestackPlanBranch(repoPath, branch, onto, upstream string) (string, error) {
+	gt := &GitWorktree{repoPath: repoPath, worktreePath: repoPath}
+	worktreePath := PlanWorktreePath(repoPath, branch)
+	if _, err := os.Stat(worktreePath); err != nil {
+		_, _ = gt.runGitCommand(repoPath, "worktree", "prune")
+		if _, err := gt.runGitCommand(repoPath, "worktree", "add", worktreePath, branch); err != nil {
+			return "", fmt.Errorf("check out %s for rebase: %w", branch, err)
+		}
+	}
+	status, err := gt.runGitCommand(worktreePath, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return "", fmt.Errorf("read plan worktree status: %w", err)
+	}
+	if strings.TrimSpace(status) != "" {
+		return "", fmt.Errorf("cannot rebase: plan worktree %s has uncommitted changes", worktreePath)
+	}
+	if _, err := gt.runGitCommand(worktreePath, "rebase", "--onto", onto, upstream); err != nil {
+		return "", abortMerge(gt, worktreePath, branch, onto, MergeStrategyRebase, err, "rebase", "--abort")
+	}
+	out, err := gt.runGitCommand(worktreePath, "merge-base", onto, "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("resolve new base of %s: %w", branch, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// SetBaseCommitSHA replaces the commit diffs are computed against, e.g. after
+// the branch was rebased onto a newer base.
+func (g *GitWorktree) SetBaseCommitSHA(sha string) {
+	g.baseCommitSHA = sha
+}
@@ -0,0 +1,113 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDrift(t *testing.T) {
+	d, err := parseDrift("main", "3\t5\n")
+	require.NoError(t, err)
+	assert.Equal(t, BranchDrift{Base: "main", Ahead: 5, Behind: 3}, d)
+
+	_, err = parseDrift("main", "garbage")
+	assert.Error(t, err)
+}
+
+func TestPlanBranchDrift(t *testing.T) {
+	repo, branch, _ := setupPlanRepo(t)
+	base := DefaultBranch(repo)
+	commitFile(t, repo, "other.go", "package other\n", "unrelated change")
+
+	d, err := PlanBranchDrift(repo, branch, base)
+	require.NoError(t, err)
+	assert.Equal(t, 2, d.Ahead)
+	assert.Equal(t, 1, d.Behind)
+
+	_, err = PlanBranchDrift(repo, "plan/missing", base)
+	assert.Error(t, err)
+}
+
+func TestRebasePlanBranch(t *testing.T) {
+	t.Run("rebases and returns the new base", func(t *testing.T) {
+		repo, branch, worktree := setupPlanRepo(t)
+		base := DefaultBranch(repo)
+		commitFile(t, repo, "other.go", "package other\n", "unrelated change")
+
+		newBase, err := RebasePlanBranch(repo, branch, base)
+		require.NoError(t, err)
+		assert.Equal(t, gitOut(t, repo, "rev-parse", base), newBase)
+		assert.FileExists(t, filepath.Join(worktree, "other.go"))
+
+		d, err := PlanBranchDrift(repo, branch, base)
+		require.NoError(t, err)
+		assert.Equal(t, 0, d.Behind)
+		assert.Equal(t, 2, d.Ahead)
+	})
+
+	t.Run("conflict leaves the branch untouched", func(t *testing.T) {
+		repo, branch, worktree := setupPlanRepo(t)
+		base := DefaultBranch(repo)
+		commitFile(t, worktree, "README.md", "plan version\n", "plan edits readme")
+		commitFile(t, repo, "README.md", "main version\n", "main edits readme")
+		head := gitOut(t, worktree, "rev-parse", "HEAD")
+
+		_, err := RebasePlanBranch(repo, branch, base)
+		conflict, ok := IsMergeConflict(err)
+		require.True(t, ok, "want conflict, got %v", err)
+		assert.Equal(t, []string{"README.md"}, conflict.Files)
+		assert.Equal(t, head, gitOut(t, worktree, "rev-parse", "HEAD"))
+		assert.Empty(t, gitOut(t, worktree, "status", "--porcelain", "--untracked-files=no"))
+	})
+
+	t.Run("dirty worktree is refused", func(t *testing.T) {
+		repo, branch, worktree := setupPlanRepo(t)
+		commitFile(t, repo, "other.go", "package other\n", "unrelated change")
+		require.NoError(t, os.WriteFile(filepath.Join(worktree, "auth.go"), []byte("package auth // wip\n"), 0644))
+
+		_, err := RebasePlanBranch(repo, branch, DefaultBranch(repo))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "uncommitted changes")
+	})
+}
+
+func TestRestackPlanBranch(t *testing.T) {
+	repo, parent, _ := setupPlanRepo(t)
+	base := DefaultBranch(repo)
+	child := "plan/auth-ui"
+	require.NoError(t, EnsurePlanBranchFrom(repo, child, parent))
+	childTree := PlanWorktreePath(repo, child)
+	gitOut(t, repo, "worktree", "add", childTree, child)
+	commitFile(t, childTree, "ui.go", "package ui\n", "add auth ui")
+
+	// The parent lands as a single squash commit and its branch is deleted.
+	tip, err := BranchTip(repo, parent)
+	require.NoError(t, err)
+	require.NoError(t, MergePlanBranchWith(repo, parent, MergeOptions{Strategy: MergeStrategySquash, Base: base}))
+	_, err = BranchTip(repo, parent)
+	require.Error(t, err)
+
+	newBase, err := RestackPlanBranch(repo, child, base, tip)
+	require.NoError(t, err)
+	assert.Equal(t, gitOut(t, repo, "rev-parse", base), newBase)
+	assert.Equal(t, "add auth ui", gitOut(t, childTree, "log", "--format=%s", base+"..HEAD"),
+		"only the child's own commits are replayed")
+	assert.FileExists(t, filepath.Join(childTree, "auth.go"))
+}
+
+func TestSetupOnBaseBranch(t *testing.T) {
+	repo, parent, _ := setupPlanRepo(t)
+	tip, err := BranchTip(repo, parent)
+	require.NoError(t, err)
+
+	shared := NewSharedPlanWorktree(repo, "plan/auth-ui")
+	shared.SetBaseBranch(parent)
+	require.NoError(t, shared.Setup())
+
+	assert.FileExists(t, filepath.Join(shared.GetWorktreePath(), "auth.go"))
+	assert.Equal(t, tip, shared.GetBaseCommitSHA(), "diffs exclude the parent's commits")
+}
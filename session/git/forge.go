@@ -0,0 +1,300 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/kastheco/kasmos/cmd"
+	"github.com/kastheco/kasmos/config"
+)
+
+// ErrUnsupported is returned by forges that cannot perform an operation, e.g.
+// opening a pull request on a remote kasmos only knows how to push to.
+var ErrUnsupported = errors.New("not supported by this forge")
+
+// ErrNoPR is returned by Forge.PRStatus when the branch has no pull request.
+var ErrNoPR = errors.New("no pull request for branch")
+
+// PRState is the lifecycle state of a pull (or merge) request.
+type PRState string
+
+const (
+	PRStateOpen   PRState = "open"
+	PRStateMerged PRState = "merged"
+	PRStateClosed PRState = "closed"
+)
+
+// CheckState summarizes the CI checks of a pull request. Empty means the
+// forge reported no checks.
+type CheckState string
+
+const (
+	ChecksPending CheckState = "pending"
+	ChecksPassing CheckState = "passing"
+	ChecksFailing CheckState = "failing"
+)
+
+// ReviewDecision summarizes the reviews of a pull request. Empty means the
+// forge reported none.
+type ReviewDecision string
+
+const (
+	ReviewApproved         ReviewDecision = "approved"
+	ReviewChangesRequested ReviewDecision = "changes_requested"
+	ReviewRequired         ReviewDecision = "review_required"
+)
+
+// PullRequest is a forge-neutral view of a pull request (GitHub, Gitea) or
+// merge request (GitLab).
+type PullRequest struct {
+	Number int
+	URL    string
+	State  PRState
+	Checks CheckState
+	Review ReviewDecision
+}
+
+// ReviewComment is an unresolved review thread on a pull request. Body holds
+// the opening comment followed by any replies.
+type ReviewComment struct {
+	// PR is the number of the pull request the thread belongs to.
+	PR int
+	// ThreadID identifies the thread when replying to or resolving it.
+	ThreadID string
+	// Path and Line locate the comment; Line is 0 for comments on a whole
+	// file and Path is empty for comments on the pull request itself.
+	Path   string
+	Line   int
+	Author string
+	Body   string
+}
+
+// PROptions describes a pull request to open.
+type PROptions struct {
+	Title string
+	Body  string
+	// Head is the branch with the changes.
+	Head string
+	// Base is the branch to merge into. Empty lets the forge pick its default.
+	Base string
+}
+
+// Forge is a code-hosting service kasmos opens pull requests on. dir is any
+// directory inside the repository (usually the plan worktree).
+type Forge interface {
+	// Name identifies the forge in messages, e.g. "github".
+	Name() string
+	// Check verifies the forge's tooling is installed and authenticated.
+	Check() error
+	// CreatePR opens a pull request, or returns the existing one for opts.Head.
+	CreatePR(dir string, opts PROptions) (*PullRequest, error)
+	// PRStatus fetches the pull request for branch, or ErrNoPR.
+	PRStatus(dir, branch string) (*PullRequest, error)
+	// OpenPR shows the pull request for branch in the browser.
+	OpenPR(dir, branch string) error
+	// OpenBranch shows branch in the browser.
+	OpenBranch(dir, branch string) error
+	// ReviewComments lists the unresolved review threads on the pull request
+	// for branch, or ErrNoPR.
+	ReviewComments(dir, branch string) ([]ReviewComment, error)
+	// ReplyToReview posts body on the thread of c (skipped when body is
+	// empty) and marks the thread resolved when resolve is set.
+	ReplyToReview(dir string, c ReviewComment, body string, resolve bool) error
+}
+
+// DetectForge returns the forge for the repository at repoPath: the [forge]
+// type from config.toml when set, otherwise one guessed from the origin
+// remote's host. Unknown hosts fall back to a push-only forge.
+func DetectForge(repoPath string) Forge {
+	return NewForge(config.LoadConfig().Forge, remoteURL(repoPath), cmd.MakeExecutor())
+}
+
+// NewForge picks the forge for cfg and the origin remote URL. CLI-backed
+// forges run their commands through e.
+func NewForge(cfg config.ForgeConfig, remote string, e cmd.Executor) Forge {
+	host, repo := parseRemoteURL(remote)
+	switch ForgeType(cfg, host) {
+	case config.ForgeGitHub:
+		return &githubForge{exec: e}
+	case config.ForgeGitLab:
+		return &gitlabForge{exec: e}
+	case config.ForgeGitea:
+		base := strings.TrimSuffix(cfg.URL, "/")
+		if base == "" && host != "" {
+			base = "https://" + host
+		}
+		tokenEnv := cfg.TokenEnv
+		if tokenEnv == "" {
+			tokenEnv = "GITEA_TOKEN"
+		}
+		return newGiteaForge(base, repo, os.Getenv(tokenEnv), tokenEnv)
+	default:
+		return &pushForge{remote: remote}
+	}
+}
+
+// ForgeType returns the [forge] type from cfg, or the one guessed from the
+// remote's host when it is unset.
+func ForgeType(cfg config.ForgeConfig, host string) string {
+	if cfg.Type != "" {
+		return cfg.Type
+	}
+	return guessForgeType(host)
+}
+
+// OriginRepo returns the host and "owner/repo" path of the origin remote of
+// the repository at repoPath. Both are empty when there is no origin.
+func OriginRepo(repoPath string) (host, repo string) {
+	return parseRemoteURL(remoteURL(repoPath))
+}
+
+// guessForgeType maps well-known hosts to a forge type.
+func guessForgeType(host string) string {
+	switch {
+	case host == "github.com" || strings.Contains(host, "github"):
+		return config.ForgeGitHub
+	case host == "gitlab.com" || strings.Contains(host, "gitlab"):
+		return config.ForgeGitLab
+	case host == "codeberg.org" || strings.Contains(host, "gitea") || strings.Contains(host, "forgejo"):
+		return config.ForgeGitea
+	default:
+		return config.ForgePush
+	}
+}
+
+// remoteURL returns the URL of the origin remote, or "" if there is none.
+func remoteURL(repoPath string) string {
+	gt := &GitWorktree{repoPath: repoPath, worktreePath: repoPath}
+	out, err := gt.runGitCommand(repoPath, "remote", "get-url", "origin")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// parseRemoteURL splits a git remote URL into its host and "owner/repo"
+// path. It understands scp-style (git@host:owner/repo.git) and URL-style
+// (https://, ssh://) remotes.
+func parseRemoteURL(remote string) (host, repo string) {
+	remote = strings.TrimSpace(remote)
+	if remote == "" {
+		return "", ""
+	}
+	if !strings.Contains(remote, "://") {
+		// scp-style: [user@]host:path
+		at := strings.LastIndex(remote, "@")
+		colon := strings.Index(remote, ":")
+		if colon < 0 || colon < at {
+			return "", ""
+		}
+		host = remote[at+1 : colon]
+		repo = remote[colon+1:]
+	} else {
+		u, err := url.Parse(remote)
+		if err != nil {
+			return "", ""
+		}
+		host = u.Hostname()
+		repo = u.Path
+	}
+	repo = strings.TrimSuffix(strings.Trim(repo, "/"), ".git")
+	return host, repo
+}
+
+// runForgeCommand runs a forge CLI in dir and returns its stdout. Errors carry
+// the CLI's stderr.
+func runForgeCommand(e cmd.Executor, dir, name string, args ...string) ([]byte, error) {
+	c := exec.Command(name, args...)
+	c.Dir = dir
+	out, err := e.Output(c)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return out, fmt.Errorf("%s %s: %s (%w)", name, args[0], strings.TrimSpace(string(exitErr.Stderr)), err)
+		}
+		return out, fmt.Errorf("%s %s: %w", name, args[0], err)
+	}
+	return out, nil
+}
+
+// checkCLI verifies that name is installed and logged in.
+func checkCLI(e cmd.Executor, name, product string) error {
+	if _, err := exec.LookPath(name); err != nil {
+		return fmt.Errorf("%s (%s) is not installed. Please install it first", product, name)
+	}
+	if err := e.Run(exec.Command(name, "auth", "status")); err != nil {
+		return fmt.Errorf("%s is not configured. Please run '%s auth login' first", product, name)
+	}
+	return nil
+}
+
+// openBrowser opens u with the platform's URL handler.
+func openBrowser(u string) error {
+	var c *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		c = exec.Command("open", u)
+	case "windows":
+		c = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		c = exec.Command("xdg-open", u)
+	}
+	if err := c.Start(); err != nil {
+		return fmt.Errorf("open %s: %w", u, err)
+	}
+	return nil
+}
+
+// pushForge is used for remotes kasmos cannot open pull requests on: branches
+// are pushed and everything else is left to the user.
+type pushForge struct {
+	remote string
+}
+
+func (f *pushForge) Name() string { return config.ForgePush }
+
+func (f *pushForge) Check() error { return nil }
+
+func (f *pushForge) CreatePR(string, PROptions) (*PullRequest, error) {
+	return nil, fmt.Errorf("open a pull request for %s: %w (set [forge] type in config.toml)", f.remote, ErrUnsupported)
+}
+
+func (f *pushForge) PRStatus(string, string) (*PullRequest, error) {
+	return nil, ErrUnsupported
+}
+
+func (f *pushForge) OpenPR(string, string) error {
+	return ErrUnsupported
+}
+
+func (f *pushForge) OpenBranch(string, string) error {
+	return ErrUnsupported
+}
+
+func (f *pushForge) ReviewComments(string, string) ([]ReviewComment, error) {
+	return nil, ErrUnsupported
+}
+
+func (f *pushForge) ReplyToReview(string, ReviewComment, string, bool) error {
+	return ErrUnsupported
+}
+
+// joinThread renders the replies after a thread's opening comment.
+func joinThread(first string, replies []threadReply) string {
+	var sb strings.Builder
+	sb.WriteString(strings.TrimSpace(first))
+	for _, r := range replies {
+		fmt.Fprintf(&sb, "\n\n%s replied: %s", r.author, strings.TrimSpace(r.body))
+	}
+	return sb.String()
+}
+
+// threadReply is a follow-up comment in a review thread.
+type threadReply struct {
+	author, body string
+}
@@ -0,0 +1,316 @@
+package git
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kastheco/kasmos/config"
+)
+
+// giteaForge drives Gitea (and Forgejo) through its REST API.
+type giteaForge struct {
+	// baseURL is the web root, e.g. "https://git.example.com".
+	baseURL string
+	// repo is "owner/name".
+	repo     string
+	token    string
+	tokenEnv string
+	client   *http.Client
+	// open shows a URL in the browser; replaced in tests.
+	open func(string) error
+}
+
+func newGiteaForge(baseURL, repo, token, tokenEnv string) *giteaForge {
+	return &giteaForge{
+		baseURL:  baseURL,
+		repo:     repo,
+		token:    token,
+		tokenEnv: tokenEnv,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		open:     openBrowser,
+	}
+}
+
+func (f *giteaForge) Name() string { return config.ForgeGitea }
+
+func (f *giteaForge) Check() error {
+	if f.baseURL == "" || f.repo == "" {
+		return fmt.Errorf("cannot determine the Gitea repository — set [forge] url in config.toml")
+	}
+	if f.token == "" {
+		return fmt.Errorf("Gitea API token not set. Please export %s first", f.tokenEnv)
+	}
+	return nil
+}
+
+// giteaPR is the subset of Gitea's pull request object kasmos reads.
+type giteaPR struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	State   string `json:"state"`
+	Merged  bool   `json:"merged"`
+	Head    struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+}
+
+func (f *giteaForge) CreatePR(_ string, opts PROptions) (*PullRequest, error) {
+	base := opts.Base
+	if base == "" {
+		var repo struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := f.do(http.MethodGet, "", nil, &repo); err != nil {
+			return nil, err
+		}
+		base = repo.DefaultBranch
+	}
+	req := map[string]string{"title": opts.Title, "body": opts.Body, "head": opts.Head, "base": base}
+	var created giteaPR
+	err := f.do(http.MethodPost, "/pulls", req, &created)
+	if err != nil {
+		// 409: a pull request for this head already exists.
+		var apiErr *giteaError
+		if errors.As(err, &apiErr) && apiErr.status == http.StatusConflict {
+			return f.PRStatus("", opts.Head)
+		}
+		return nil, fmt.Errorf("failed to create PR: %w", err)
+	}
+	return f.status(created)
+}
+
+func (f *giteaForge) PRStatus(_ string, branch string) (*PullRequest, error) {
+	pr, err := f.find(branch)
+	if err != nil {
+		return nil, err
+	}
+	return f.status(*pr)
+}
+
+func (f *giteaForge) OpenPR(_ string, branch string) error {
+	pr, err := f.find(branch)
+	if err != nil {
+		return err
+	}
+	return f.open(pr.HTMLURL)
+}
+
+func (f *giteaForge) OpenBranch(_ string, branch string) error {
+	return f.open(fmt.Sprintf("%s/%s/src/branch/%s", f.baseURL, f.repo, branch))
+}
+
+// giteaPageSize is the number of pull requests requested per page.
+const giteaPageSize = 50
+
+// find returns the most recent pull request whose head is branch. The list
+// API cannot filter by head, so it pages through pull requests, most
+// recently updated first, until it finds one.
+func (f *giteaForge) find(branch string) (*giteaPR, error) {
+	for page := 1; ; page++ {
+		var prs []giteaPR
+		path := fmt.Sprintf("/pulls?state=all&sort=recentupdate&limit=%d&page=%d", giteaPageSize, page)
+		if err := f.do(http.MethodGet, path, nil, &prs); err != nil {
+			return nil, err
+		}
+		for i := range prs {
+			if prs[i].Head.Ref == branch {
+				return &prs[i], nil
+			}
+		}
+		// The server may cap limit below giteaPageSize, so only an empty
+		// page marks the end.
+		if len(prs) == 0 {
+			return nil, ErrNoPR
+		}
+	}
+}
+
+// status fills in checks and reviews for pr.
+func (f *giteaForge) status(pr giteaPR) (*PullRequest, error) {
+	out := &PullRequest{Number: pr.Number, URL: pr.HTMLURL, State: PRStateOpen}
+	switch {
+	case pr.Merged:
+		out.State = PRStateMerged
+	case pr.State == "closed":
+		out.State = PRStateClosed
+	}
+
+	if pr.Head.SHA != "" {
+		var combined struct {
+			State      string `json:"state"`
+			TotalCount int    `json:"total_count"`
+		}
+		if err := f.do(http.MethodGet, "/commits/"+pr.Head.SHA+"/status", nil, &combined); err != nil {
+			return nil, err
+		}
+		if combined.TotalCount > 0 {
+			switch combined.State {
+			case "success":
+				out.Checks = ChecksPassing
+			case "failure", "error":
+				out.Checks = ChecksFailing
+			default:
+				out.Checks = ChecksPending
+			}
+		}
+	}
+
+	var reviews []struct {
+		State     string `json:"state"`
+		Stale     bool   `json:"stale"`
+		Dismissed bool   `json:"dismissed"`
+	}
+	if err := f.do(http.MethodGet, fmt.Sprintf("/pulls/%d/reviews", pr.Number), nil, &reviews); err != nil {
+		return nil, err
+	}
+	for _, r := range reviews {
+		if r.Stale || r.Dismissed {
+			continue
+		}
+		switch r.State {
+		case "REQUEST_CHANGES":
+			out.Review = ReviewChangesRequested
+		case "APPROVED":
+			if out.Review == "" {
+				out.Review = ReviewApproved
+			}
+		}
+	}
+	return out, nil
+}
+
+// giteaError is a non-2xx API response.
+type giteaError struct {
+	status int
+	body   string
+}
+
+func (e *giteaError) Error() string {
+	return fmt.Sprintf("gitea api: %d %s", e.status, e.body)
+}
+
+// do calls the repository API at path (relative to /api/v1/repos/{repo}) and
+// decodes the JSON response into out.
+func (f *giteaForge) do(method, path string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	endpoint := fmt.Sprintf("%s/api/v1/repos/%s%s", f.baseURL, (&url.URL{Path: f.repo}).EscapedPath(), path)
+	req, err := http.NewRequest(method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if f.token != "" {
+		req.Header.Set("Authorization", "token "+f.token)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("gitea api: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("gitea api: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &giteaError{status: resp.StatusCode, body: string(bytes.TrimSpace(data))}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("gitea api: decode %s: %w", path, err)
+	}
+	return nil
+}
+
+func (f *giteaForge) ReviewComments(_ string, branch string) ([]ReviewComment, error) {
+	pr, err := f.find(branch)
+	if err != nil {
+		return nil, err
+	}
+	var reviews []struct {
+		ID        int64 `json:"id"`
+		Stale     bool  `json:"stale"`
+		Dismissed bool  `json:"dismissed"`
+	}
+	if err := f.do(http.MethodGet, fmt.Sprintf("/pulls/%d/reviews", pr.Number), nil, &reviews); err != nil {
+		return nil, err
+	}
+	var comments []ReviewComment
+	for _, r := range reviews {
+		if r.Dismissed {
+			continue
+		}
+		var raw []struct {
+			ID               int64  `json:"id"`
+			Body             string `json:"body"`
+			Path             string `json:"path"`
+			Position         int    `json:"position"`
+			OriginalPosition int    `json:"original_position"`
+			User             struct {
+				Login string `json:"login"`
+			} `json:"user"`
+			Resolver *struct{} `json:"resolver"`
+		}
+		if err := f.do(http.MethodGet, fmt.Sprintf("/pulls/%d/reviews/%d/comments", pr.Number, r.ID), nil, &raw); err != nil {
+			return nil, err
+		}
+		for _, c := range raw {
+			if c.Resolver != nil {
+				continue
+			}
+			line := c.Position
+			if line == 0 {
+				line = c.OriginalPosition
+			}
+			comments = append(comments, ReviewComment{
+				PR:       pr.Number,
+				ThreadID: strconv.FormatInt(c.ID, 10),
+				Path:     c.Path,
+				Line:     line,
+				Author:   c.User.Login,
+				Body:     strings.TrimSpace(c.Body),
+			})
+		}
+	}
+	return comments, nil
+}
+
+// ReplyToReview posts body as a pull request comment quoting the review
+// comment: Gitea's API can neither reply inside a review thread nor resolve
+// one, so resolve fails with ErrUnsupported after the reply is posted.
+func (f *giteaForge) ReplyToReview(_ string, c ReviewComment, body string, resolve bool) error {
+	if body != "" {
+		quoted := "> " + strings.ReplaceAll(c.Body, "\n", "\n> ")
+		if c.Path != "" {
+			quoted = fmt.Sprintf("> **%s:%d**\n%s", c.Path, c.Line, quoted)
+		}
+		req := map[string]string{"body": quoted + "\n\n" + body}
+		if err := f.do(http.MethodPost, fmt.Sprintf("/issues/%d/comments", c.PR), req, nil); err != nil {
+			return fmt.Errorf("failed to reply to review comment: %w", err)
+		}
+	}
+	if resolve {
+		return fmt.Errorf("resolve review comment: %w", ErrUnsupported)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kastheco/kasmos/cmd"
+	"github.com/kastheco/kasmos/config"
+)
+
+// githubForge drives GitHub through the gh CLI.
+type githubForge struct {
+	exec cmd.Executor
+}
+
+func (f *githubForge) Name() string { return config.ForgeGitHub }
+
+func (f *githubForge) Check() error {
+	return checkCLI(f.exec, "gh", "GitHub CLI")
+}
+
+func (f *githubForge) CreatePR(dir string, opts PROptions) (*PullRequest, error) {
+	args := []string{"pr", "create", "--title", opts.Title, "--body", opts.Body, "--head", opts.Head}
+	if opts.Base != "" {
+		args = append(args, "--base", opts.Base)
+	}
+	if _, err := runForgeCommand(f.exec, dir, "gh", args...); err != nil && !strings.Contains(err.Error(), "already exists") {
+		return nil, fmt.Errorf("failed to create PR: %w", err)
+	}
+	return f.PRStatus(dir, opts.Head)
+}
+
+func (f *githubForge) PRStatus(dir, branch string) (*PullRequest, error) {
+	out, err := runForgeCommand(f.exec, dir, "gh", "pr", "view", branch,
+		"--json", "number,url,state,reviewDecision,statusCheckRollup")
+	if err != nil {
+		if strings.Contains(err.Error(), "no pull requests found") {
+			return nil, ErrNoPR
+		}
+		return nil, err
+	}
+	return parseGitHubPR(out)
+}
+
+func (f *githubForge) OpenPR(dir, branch string) error {
+	_, err := runForgeCommand(f.exec, dir, "gh", "pr", "view", "--web", branch)
+	return err
+}
+
+func (f *githubForge) OpenBranch(dir, branch string) error {
+	if _, err := runForgeCommand(f.exec, dir, "gh", "browse", "--branch", branch); err != nil {
+		return fmt.Errorf("failed to open branch URL: %w", err)
+	}
+	return nil
+}
+
+// parseGitHubPR converts `gh pr view --json` output.
+func parseGitHubPR(out []byte) (*PullRequest, error) {
+	var raw struct {
+		Number            int    `json:"number"`
+		URL               string `json:"url"`
+		State             string `json:"state"`
+		ReviewDecision    string `json:"reviewDecision"`
+		StatusCheckRollup []struct {
+			// CheckRun fields.
+			Status     string `json:"status"`
+			Conclusion string `json:"conclusion"`
+			// StatusContext field.
+			State string `json:"state"`
+		} `json:"statusCheckRollup"`
+	}
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, fmt.Errorf("parse gh pr view output: %w", err)
+	}
+	pr := &PullRequest{Number: raw.Number, URL: raw.URL}
+	switch raw.State {
+	case "MERGED":
+		pr.State = PRStateMerged
+	case "CLOSED":
+		pr.State = PRStateClosed
+	default:
+		pr.State = PRStateOpen
+	}
+	switch raw.ReviewDecision {
+	case "APPROVED":
+		pr.Review = ReviewApproved
+	case "CHANGES_REQUESTED":
+		pr.Review = ReviewChangesRequested
+	case "REVIEW_REQUIRED":
+		pr.Review = ReviewRequired
+	}
+	var failing, pending bool
+	for _, c := range raw.StatusCheckRollup {
+		switch {
+		case c.State != "":
+			switch c.State {
+			case "FAILURE", "ERROR":
+				failing = true
+			case "PENDING", "EXPECTED":
+				pending = true
+			}
+		case c.Status != "COMPLETED":
+			pending = true
+		default:
+			switch c.Conclusion {
+			case "FAILURE", "TIMED_OUT", "CANCELLED", "ACTION_REQUIRED", "STARTUP_FAILURE":
+				failing = true
+			}
+		}
+	}
+	switch {
+	case failing:
+		pr.Checks = ChecksFailing
+	case pending:
+		pr.Checks = ChecksPending
+	case len(raw.StatusCheckRollup) > 0:
+		pr.Checks = ChecksPassing
+	}
+	return pr, nil
+}
+
+// githubReviewThreadsQuery lists the review threads of a pull request. gh
+// fills in {owner} and {repo} from the repository in the working directory.
+const githubReviewThreadsQuery = `query($owner: String!, $repo: String!, $number: Int!) {
+  repository(owner: $owner, name: $repo) {
+    pullRequest(number: $number) {
+      reviewThreads(first: 100) {
+        nodes {
+          id
+          isResolved
+          path
+          line
+          originalLine
+          comments(first: 50) { nodes { author { login } body } }
+        }
+      }
+    }
+  }
+}`
+
+func (f *githubForge) ReviewComments(dir, branch string) ([]ReviewComment, error) {
+	pr, err := f.PRStatus(dir, branch)
+	if err != nil {
+		return nil, err
+	}
+	out, err := runForgeCommand(f.exec, dir, "gh", "api", "graphql",
+		"-F", "owner={owner}", "-F", "repo={repo}", "-F", fmt.Sprintf("number=%d", pr.Number),
+		"-f", "query="+githubReviewThreadsQuery)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch review comments: %w", err)
+	}
+	return parseGitHubReviewThreads(pr.Number, out)
+}
+
+func (f *githubForge) ReplyToReview(dir string, c ReviewComment, body string, resolve bool) error {
+	if body != "" {
+		if _, err := runForgeCommand(f.exec, dir, "gh", "api", "graphql",
+			"-f", "thread="+c.ThreadID, "-f", "body="+body,
+			"-f", `query=mutation($thread: ID!, $body: String!) {
+  addPullRequestReviewThreadReply(input: {pullRequestReviewThreadId: $thread, body: $body}) { clientMutationId }
+}`); err != nil {
+			return fmt.Errorf("failed to reply to review thread: %w", err)
+		}
+	}
+	if resolve {
+		if _, err := runForgeCommand(f.exec, dir, "gh", "api", "graphql",
+			"-f", "thread="+c.ThreadID,
+			"-f", `query=mutation($thread: ID!) {
+  resolveReviewThread(input: {threadId: $thread}) { clientMutationId }
+}`); err != nil {
+			return fmt.Errorf("failed to resolve review thread: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseGitHubReviewThreads converts the review threads query result, keeping
+// unresolved threads only.
+func parseGitHubReviewThreads(number int, out []byte) ([]ReviewComment, error) {
+	var raw struct {
+		Data struct {
+			Repository struct {
+				PullRequest struct {
+					ReviewThreads struct {
+						Nodes []struct {
+							ID           string `json:"id"`
+							IsResolved   bool   `json:"isResolved"`
+							Path         string `json:"path"`
+							Line         int    `json:"line"`
+							OriginalLine int    `json:"originalLine"`
+							Comments     struct {
+								Nodes []struct {
+									Author struct {
+										Login string `json:"login"`
+									} `json:"author"`
+									Body string `json:"body"`
+								} `json:"nodes"`
+							} `json:"comments"`
+						} `json:"nodes"`
+					} `json:"reviewThreads"`
+				} `json:"pullRequest"`
+			} `json:"repository"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, fmt.Errorf("parse review threads: %w", err)
+	}
+	var comments []ReviewComment
+	for _, t := range raw.Data.Repository.PullRequest.ReviewThreads.Nodes {
+		if t.IsResolved || len(t.Comments.Nodes) == 0 {
+			continue
+		}
+		line := t.Line
+		if line == 0 {
+			// Outdated threads lose their line on the current diff.
+			line = t.OriginalLine
+		}
+		first := t.Comments.Nodes[0]
+		var replies []threadReply
+		for _, c := range t.Comments.Nodes[1:] {
+			replies = append(replies, threadReply{author: c.Author.Login, body: c.Body})
+		}
+		comments = append(comments, ReviewComment{
+			PR:       number,
+			ThreadID: t.ID,
+			Path:     t.Path,
+			Line:     line,
+			Author:   first.Author.Login,
+			Body:     joinThread(first.Body, replies),
+		})
+	}
+	return comments, nil
+}
@@ -0,0 +1,184 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/kastheco/kasmos/cmd"
+	"github.com/kastheco/kasmos/config"
+)
+
+// gitlabForge drives GitLab (including self-hosted instances) through the
+// glab CLI. GitLab calls pull requests merge requests.
+type gitlabForge struct {
+	exec cmd.Executor
+}
+
+func (f *gitlabForge) Name() string { return config.ForgeGitLab }
+
+func (f *gitlabForge) Check() error {
+	return checkCLI(f.exec, "glab", "GitLab CLI")
+}
+
+func (f *gitlabForge) CreatePR(dir string, opts PROptions) (*PullRequest, error) {
+	args := []string{"mr", "create", "--title", opts.Title, "--description", opts.Body,
+		"--source-branch", opts.Head, "--yes"}
+	if opts.Base != "" {
+		args = append(args, "--target-branch", opts.Base)
+	}
+	if _, err := runForgeCommand(f.exec, dir, "glab", args...); err != nil && !strings.Contains(err.Error(), "already exists") {
+		return nil, fmt.Errorf("failed to create merge request: %w", err)
+	}
+	return f.PRStatus(dir, opts.Head)
+}
+
+func (f *gitlabForge) PRStatus(dir, branch string) (*PullRequest, error) {
+	out, err := runForgeCommand(f.exec, dir, "glab", "mr", "view", branch, "--output", "json")
+	if err != nil {
+		if strings.Contains(err.Error(), "no open merge request") || strings.Contains(err.Error(), "not found") {
+			return nil, ErrNoPR
+		}
+		return nil, err
+	}
+	return parseGitLabMR(out)
+}
+
+func (f *gitlabForge) OpenPR(dir, branch string) error {
+	_, err := runForgeCommand(f.exec, dir, "glab", "mr", "view", "--web", branch)
+	return err
+}
+
+func (f *gitlabForge) OpenBranch(dir, branch string) error {
+	if _, err := runForgeCommand(f.exec, dir, "glab", "repo", "view", "--web", "--branch", branch); err != nil {
+		return fmt.Errorf("failed to open branch URL: %w", err)
+	}
+	return nil
+}
+
+// parseGitLabMR converts `glab mr view --output json` output.
+func parseGitLabMR(out []byte) (*PullRequest, error) {
+	var raw struct {
+		IID                 int    `json:"iid"`
+		WebURL              string `json:"web_url"`
+		State               string `json:"state"`
+		DetailedMergeStatus string `json:"detailed_merge_status"`
+		HeadPipeline        *struct {
+			Status string `json:"status"`
+		} `json:"head_pipeline"`
+	}
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, fmt.Errorf("parse glab mr view output: %w", err)
+	}
+	pr := &PullRequest{Number: raw.IID, URL: raw.WebURL}
+	switch raw.State {
+	case "merged":
+		pr.State = PRStateMerged
+	case "closed", "locked":
+		pr.State = PRStateClosed
+	default:
+		pr.State = PRStateOpen
+	}
+	switch raw.DetailedMergeStatus {
+	case "not_approved":
+		pr.Review = ReviewRequired
+	case "requested_changes":
+		pr.Review = ReviewChangesRequested
+	}
+	if raw.HeadPipeline != nil {
+		switch raw.HeadPipeline.Status {
+		case "success":
+			pr.Checks = ChecksPassing
+		case "failed", "canceled":
+			pr.Checks = ChecksFailing
+		case "", "skipped", "manual":
+		default:
+			pr.Checks = ChecksPending
+		}
+	}
+	return pr, nil
+}
+
+func (f *gitlabForge) ReviewComments(dir, branch string) ([]ReviewComment, error) {
+	pr, err := f.PRStatus(dir, branch)
+	if err != nil {
+		return nil, err
+	}
+	// glab fills in :id with the project of the working directory.
+	out, err := runForgeCommand(f.exec, dir, "glab", "api",
+		fmt.Sprintf("projects/:id/merge_requests/%d/discussions?per_page=100", pr.Number))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch review comments: %w", err)
+	}
+	return parseGitLabDiscussions(pr.Number, out)
+}
+
+func (f *gitlabForge) ReplyToReview(dir string, c ReviewComment, body string, resolve bool) error {
+	discussion := fmt.Sprintf("projects/:id/merge_requests/%d/discussions/%s", c.PR, c.ThreadID)
+	if body != "" {
+		if _, err := runForgeCommand(f.exec, dir, "glab", "api", "-X", "POST", discussion+"/notes",
+			"-f", "body="+body); err != nil {
+			return fmt.Errorf("failed to reply to review thread: %w", err)
+		}
+	}
+	if resolve {
+		if _, err := runForgeCommand(f.exec, dir, "glab", "api", "-X", "PUT", discussion,
+			"-f", "resolved=true"); err != nil {
+			return fmt.Errorf("failed to resolve review thread: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseGitLabDiscussions converts merge request discussions, keeping
+// unresolved, resolvable threads only.
+func parseGitLabDiscussions(number int, out []byte) ([]ReviewComment, error) {
+	var raw []struct {
+		ID    string `json:"id"`
+		Notes []struct {
+			Body   string `json:"body"`
+			System bool   `json:"system"`
+			Author struct {
+				Username string `json:"username"`
+			} `json:"author"`
+			Resolvable bool `json:"resolvable"`
+			Resolved   bool `json:"resolved"`
+			Position   *struct {
+				NewPath string `json:"new_path"`
+				NewLine int    `json:"new_line"`
+				OldPath string `json:"old_path"`
+				OldLine int    `json:"old_line"`
+			} `json:"position"`
+		} `json:"notes"`
+	}
+	if err := json.Unmarshal(out, &raw); err != nil {
+		return nil, fmt.Errorf("parse glab discussions: %w", err)
+	}
+	var comments []ReviewComment
+	for _, d := range raw {
+		if len(d.Notes) == 0 {
+			continue
+		}
+		first := d.Notes[0]
+		if first.System || !first.Resolvable || first.Resolved {
+			continue
+		}
+		c := ReviewComment{PR: number, ThreadID: d.ID, Author: first.Author.Username}
+		if p := first.Position; p != nil {
+			c.Path, c.Line = p.NewPath, p.NewLine
+			if c.Line == 0 {
+				// Comments on removed lines only have the old side.
+				c.Path, c.Line = p.OldPath, p.OldLine
+			}
+		}
+		var replies []threadReply
+		for _, n := range d.Notes[1:] {
+			if !n.System {
+				replies = append(replies, threadReply{author: n.Author.Username, body: n.Body})
+			}
+		}
+		c.Body = joinThread(first.Body, replies)
+		comments = append(comments, c)
+	}
+	return comments, nil
+}
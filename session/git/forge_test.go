@@ -0,0 +1,301 @@
+package git
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/kastheco/kasmos/cmd/cmd_test"
+	"github.com/kastheco/kasmos/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRemoteURL(t *testing.T) {
+	tests := []struct {
+		remote, host, repo string
+	}{
+		{"git@github.com:kastheco/kasmos.git", "github.com", "kastheco/kasmos"},
+		{"https://github.com/kastheco/kasmos.git", "github.com", "kastheco/kasmos"},
+		{"ssh://git@gitlab.example.com:2222/group/sub/app.git", "gitlab.example.com", "group/sub/app"},
+		{"https://git.example.com/team/app", "git.example.com", "team/app"},
+		{"", "", ""},
+	}
+	for _, tt := range tests {
+		host, repo := parseRemoteURL(tt.remote)
+		assert.Equal(t, tt.host, host, tt.remote)
+		assert.Equal(t, tt.repo, repo, tt.remote)
+	}
+}
+
+func TestNewForge_DetectsFromRemote(t *testing.T) {
+	mock := cmd_test.NewMockExecutor()
+	assert.Equal(t, config.ForgeGitHub, NewForge(config.ForgeConfig{}, "git@github.com:o/r.git", mock).Name())
+	assert.Equal(t, config.ForgeGitLab, NewForge(config.ForgeConfig{}, "https://gitlab.example.com/o/r.git", mock).Name())
+	assert.Equal(t, config.ForgeGitea, NewForge(config.ForgeConfig{}, "https://codeberg.org/o/r.git", mock).Name())
+	assert.Equal(t, config.ForgePush, NewForge(config.ForgeConfig{}, "git@git.internal:o/r.git", mock).Name())
+	assert.Equal(t, config.ForgePush, NewForge(config.ForgeConfig{}, "", mock).Name())
+
+	// Config wins over detection.
+	f := NewForge(config.ForgeConfig{Type: config.ForgeGitLab}, "git@git.internal:o/r.git", mock)
+	assert.Equal(t, config.ForgeGitLab, f.Name())
+
+	gitea := NewForge(config.ForgeConfig{Type: config.ForgeGitea}, "git@git.internal:team/app.git", mock).(*giteaForge)
+	assert.Equal(t, "https://git.internal", gitea.baseURL)
+	assert.Equal(t, "team/app", gitea.repo)
+	assert.Equal(t, "GITEA_TOKEN", gitea.tokenEnv)
+}
+
+func TestPushForge_CannotOpenPRs(t *testing.T) {
+	f := NewForge(config.ForgeConfig{}, "git@git.internal:o/r.git", cmd_test.NewMockExecutor())
+	require.NoError(t, f.Check())
+	_, err := f.CreatePR("", PROptions{Head: "feature"})
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+func TestParseGitHubPR(t *testing.T) {
+	pr, err := parseGitHubPR([]byte(`{"number":7,"url":"https://github.com/o/r/pull/7","state":"OPEN",
+		"reviewDecision":"CHANGES_REQUESTED","statusCheckRollup":[
+		{"__typename":"CheckRun","status":"COMPLETED","conclusion":"SUCCESS"},
+		{"__typename":"StatusContext","state":"PENDING"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, &PullRequest{
+		Number: 7,
+		URL:    "https://github.com/o/r/pull/7",
+		State:  PRStateOpen,
+		Checks: ChecksPending,
+		Review: ReviewChangesRequested,
+	}, pr)
+
+	pr, err = parseGitHubPR([]byte(`{"number":8,"state":"MERGED","statusCheckRollup":[
+		{"status":"COMPLETED","conclusion":"FAILURE"},{"status":"IN_PROGRESS"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, PRStateMerged, pr.State)
+	assert.Equal(t, ChecksFailing, pr.Checks)
+}
+
+func TestParseGitLabMR(t *testing.T) {
+	pr, err := parseGitLabMR([]byte(`{"iid":12,"web_url":"https://gitlab.example.com/o/r/-/merge_requests/12",
+		"state":"opened","detailed_merge_status":"not_approved","head_pipeline":{"status":"running"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, &PullRequest{
+		Number: 12,
+		URL:    "https://gitlab.example.com/o/r/-/merge_requests/12",
+		State:  PRStateOpen,
+		Checks: ChecksPending,
+		Review: ReviewRequired,
+	}, pr)
+
+	pr, err = parseGitLabMR([]byte(`{"iid":13,"state":"merged","head_pipeline":{"status":"success"}}`))
+	require.NoError(t, err)
+	assert.Equal(t, PRStateMerged, pr.State)
+	assert.Equal(t, ChecksPassing, pr.Checks)
+}
+
+func TestGitHubForge_CreatePRReusesExisting(t *testing.T) {
+	var calls []string
+	mock := cmd_test.NewMockExecutor()
+	mock.OutputFunc = func(c *exec.Cmd) ([]byte, error) {
+		calls = append(calls, strings.Join(c.Args[1:3], " "))
+		if c.Args[2] == "create" {
+			return nil, &exec.ExitError{Stderr: []byte("a pull request for branch \"feature\" already exists")}
+		}
+		return []byte(`{"number":3,"url":"https://github.com/o/r/pull/3","state":"OPEN"}`), nil
+	}
+	f := &githubForge{exec: mock}
+
+	pr, err := f.CreatePR(t.TempDir(), PROptions{Title: "t", Body: "b", Head: "feature"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, pr.Number)
+	assert.Equal(t, []string{"pr create", "pr view"}, calls)
+}
+
+func TestGitHubForge_PRStatusNoPR(t *testing.T) {
+	mock := cmd_test.NewMockExecutor()
+	mock.OutputFunc = func(c *exec.Cmd) ([]byte, error) {
+		return nil, &exec.ExitError{Stderr: []byte("no pull requests found for branch \"feature\"")}
+	}
+	_, err := (&githubForge{exec: mock}).PRStatus(t.TempDir(), "feature")
+	assert.ErrorIs(t, err, ErrNoPR)
+}
+
+func TestGiteaForge(t *testing.T) {
+	var created map[string]string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/repos/team/app", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"default_branch":"main"}`)
+	})
+	mux.HandleFunc("/api/v1/repos/team/app/pulls", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		if r.Method == http.MethodPost {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&created))
+			fmt.Fprint(w, `{"number":5,"html_url":"https://git.example.com/team/app/pulls/5","state":"open","head":{"ref":"feature","sha":"abc"}}`)
+			return
+		}
+		if r.URL.Query().Get("page") != "1" {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprint(w, `[{"number":4,"state":"closed","merged":true,"head":{"ref":"other"}},
+			{"number":5,"html_url":"https://git.example.com/team/app/pulls/5","state":"open","head":{"ref":"feature","sha":"abc"}}]`)
+	})
+	mux.HandleFunc("/api/v1/repos/team/app/commits/abc/status", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"state":"failure","total_count":2}`)
+	})
+	mux.HandleFunc("/api/v1/repos/team/app/pulls/5/reviews", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"state":"APPROVED"},{"state":"REQUEST_CHANGES","stale":true}]`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	f := newGiteaForge(srv.URL, "team/app", "secret", "GITEA_TOKEN")
+	var opened string
+	f.open = func(u string) error { opened = u; return nil }
+	require.NoError(t, f.Check())
+
+	pr, err := f.CreatePR("", PROptions{Title: "Add auth", Body: "body", Head: "feature"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"title": "Add auth", "body": "body", "head": "feature", "base": "main"}, created)
+	assert.Equal(t, &PullRequest{
+		Number: 5,
+		URL:    "https://git.example.com/team/app/pulls/5",
+		State:  PRStateOpen,
+		Checks: ChecksFailing,
+		Review: ReviewApproved,
+	}, pr)
+
+	pr, err = f.PRStatus("", "feature")
+	require.NoError(t, err)
+	assert.Equal(t, 5, pr.Number)
+
+	_, err = f.PRStatus("", "missing")
+	assert.ErrorIs(t, err, ErrNoPR)
+
+	require.NoError(t, f.OpenPR("", "feature"))
+	assert.Equal(t, "https://git.example.com/team/app/pulls/5", opened)
+}
+
+func TestGiteaForge_FindPagesThroughPRs(t *testing.T) {
+	var pages []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/repos/team/app/pulls", func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		switch page {
+		case "1":
+			var prs []string
+			for n := 1; n <= giteaPageSize; n++ {
+				prs = append(prs, fmt.Sprintf(`{"number":%d,"state":"closed","head":{"ref":"other-%d"}}`, n, n))
+			}
+			fmt.Fprintf(w, "[%s]", strings.Join(prs, ","))
+		case "2":
+			fmt.Fprint(w, `[{"number":99,"state":"open","head":{"ref":"old-feature"}}]`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	f := newGiteaForge(srv.URL, "team/app", "secret", "GITEA_TOKEN")
+	pr, err := f.find("old-feature")
+	require.NoError(t, err)
+	assert.Equal(t, 99, pr.Number)
+	assert.Equal(t, []string{"1", "2"}, pages)
+
+	pages = nil
+	_, err = f.find("missing")
+	assert.ErrorIs(t, err, ErrNoPR)
+	assert.Equal(t, []string{"1", "2", "3"}, pages)
+}
+
+func TestGiteaForge_CheckNeedsToken(t *testing.T) {
+	err := newGiteaForge("https://git.example.com", "team/app", "", "MY_TOKEN").Check()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "MY_TOKEN")
+}
+
+func TestParseGitHubReviewThreads(t *testing.T) {
+	comments, err := parseGitHubReviewThreads(7, []byte(`{"data":{"repository":{"pullRequest":{"reviewThreads":{"nodes":[
+		{"id":"T1","isResolved":false,"path":"auth.go","line":12,"comments":{"nodes":[
+			{"author":{"login":"alice"},"body":"check the error"},
+			{"author":{"login":"bob"},"body":"agreed"}]}},
+		{"id":"T2","isResolved":true,"path":"main.go","line":3,"comments":{"nodes":[{"author":{"login":"alice"},"body":"done"}]}},
+		{"id":"T3","isResolved":false,"path":"old.go","line":0,"originalLine":40,"comments":{"nodes":[{"author":{"login":"carol"},"body":"rename"}]}}
+	]}}}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, []ReviewComment{
+		{PR: 7, ThreadID: "T1", Path: "auth.go", Line: 12, Author: "alice", Body: "check the error\n\nbob replied: agreed"},
+		{PR: 7, ThreadID: "T3", Path: "old.go", Line: 40, Author: "carol", Body: "rename"},
+	}, comments)
+}
+
+func TestParseGitLabDiscussions(t *testing.T) {
+	comments, err := parseGitLabDiscussions(12, []byte(`[
+		{"id":"d1","notes":[{"body":"added 2 commits","system":true}]},
+		{"id":"d2","notes":[{"body":"nil check?","author":{"username":"alice"},"resolvable":true,"resolved":false,
+			"position":{"new_path":"auth.go","new_line":8}},
+			{"body":"yes please","author":{"username":"bob"},"resolvable":true}]},
+		{"id":"d3","notes":[{"body":"ok","author":{"username":"alice"},"resolvable":true,"resolved":true}]},
+		{"id":"d4","notes":[{"body":"why removed?","author":{"username":"carol"},"resolvable":true,
+			"position":{"new_path":"x.go","old_path":"x.go","old_line":5}}]}
+	]`))
+	require.NoError(t, err)
+	assert.Equal(t, []ReviewComment{
+		{PR: 12, ThreadID: "d2", Path: "auth.go", Line: 8, Author: "alice", Body: "nil check?\n\nbob replied: yes please"},
+		{PR: 12, ThreadID: "d4", Path: "x.go", Line: 5, Author: "carol", Body: "why removed?"},
+	}, comments)
+}
+
+func TestGitLabForge_ReplyToReview(t *testing.T) {
+	var calls []string
+	mock := cmd_test.NewMockExecutor()
+	mock.OutputFunc = func(c *exec.Cmd) ([]byte, error) {
+		calls = append(calls, strings.Join(c.Args[1:], " "))
+		return []byte(`{}`), nil
+	}
+	f := &gitlabForge{exec: mock}
+	require.NoError(t, f.ReplyToReview(t.TempDir(), ReviewComment{PR: 12, ThreadID: "d2"}, "fixed", true))
+	assert.Equal(t, []string{
+		"api -X POST projects/:id/merge_requests/12/discussions/d2/notes -f body=fixed",
+		"api -X PUT projects/:id/merge_requests/12/discussions/d2 -f resolved=true",
+	}, calls)
+}
+
+func TestGiteaForge_ReviewComments(t *testing.T) {
+	var reply map[string]string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/repos/team/app/pulls", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("page") != "1" {
+			fmt.Fprint(w, `[]`)
+			return
+		}
+		fmt.Fprint(w, `[{"number":5,"state":"open","head":{"ref":"feature"}}]`)
+	})
+	mux.HandleFunc("/api/v1/repos/team/app/pulls/5/reviews", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":1},{"id":2,"dismissed":true}]`)
+	})
+	mux.HandleFunc("/api/v1/repos/team/app/pulls/5/reviews/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":10,"body":"typo","path":"README.md","position":3,"user":{"login":"alice"}},
+			{"id":11,"body":"fixed already","path":"main.go","position":1,"user":{"login":"bob"},"resolver":{"login":"bob"}}]`)
+	})
+	mux.HandleFunc("/api/v1/repos/team/app/issues/5/comments", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&reply))
+		fmt.Fprint(w, `{}`)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	f := newGiteaForge(srv.URL, "team/app", "secret", "GITEA_TOKEN")
+	comments, err := f.ReviewComments("", "feature")
+	require.NoError(t, err)
+	assert.Equal(t, []ReviewComment{{PR: 5, ThreadID: "10", Path: "README.md", Line: 3, Author: "alice", Body: "typo"}}, comments)
+
+	err = f.ReplyToReview("", comments[0], "fixed in abc123", true)
+	assert.ErrorIs(t, err, ErrUnsupported, "gitea cannot resolve threads")
+	assert.Equal(t, "> **README.md:3**\n> typo\n\nfixed in abc123", reply["body"])
+}
@@ -0,0 +1,252 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kastheco/kasmos/config/planstate"
+)
+
+// MergeStrategy selects how a plan branch lands on its base branch.
+type MergeStrategy string
+
+const (
+	// MergeStrategyMerge creates a merge commit (git merge --no-ff).
+	MergeStrategyMerge MergeStrategy = "merge"
+	// MergeStrategySquash squashes the plan into one commit with a generated message.
+	MergeStrategySquash MergeStrategy = "squash"
+	// MergeStrategyRebase rebases the plan branch onto the base, then fast-forwards.
+	MergeStrategyRebase MergeStrategy = "rebase"
+)
+
+// MergeStrategies lists the supported strategies, default first.
+var MergeStrategies = []MergeStrategy{MergeStrategyMerge, MergeStrategySquash, MergeStrategyRebase}
+
+// ParseMergeStrategy validates s. An empty string selects MergeStrategyMerge.
+func ParseMergeStrategy(s string) (MergeStrategy, error) {
+	if s == "" {
+		return MergeStrategyMerge, nil
+	}
+	for _, strategy := range MergeStrategies {
+		if string(strategy) == s {
+			return strategy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown merge strategy %q (want merge, squash or rebase)", s)
+}
+
+// MergeOptions controls MergePlanBranchWith.
+type MergeOptions struct {
+	Strategy MergeStrategy
+	// Base is the branch the plan must land on. Empty means the repository's
+	// default branch (see DefaultBranch).
+	Base string
+	// PlanFile names the plan in generated commit messages.
+	PlanFile string
+}
+
+// MergeConflictError reports a merge or rebase that stopped on conflicts. The
+// operation has been aborted, so both the base branch and the plan worktree
+// are left as they were before the merge.
+type MergeConflictError struct {
+	Branch   string
+	Base     string
+	Strategy MergeStrategy
+	Files    []string
+}
+
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("%s of %s into %s conflicts in %d file(s): %s",
+		e.Strategy, e.Branch, e.Base, len(e.Files), strings.Join(e.Files, ", "))
+}
+
+// IsMergeConflict reports whether err is (or wraps) a *MergeConflictError.
+func IsMergeConflict(err error) (*MergeConflictError, bool) {
+	var conflict *MergeConflictError
+	if errors.As(err, &conflict) {
+		return conflict, true
+	}
+	return nil, false
+}
+
+// DefaultBranch returns the repository's default branch: the branch
+// origin/HEAD points at, else "main" or "master" if present, else the branch
+// currently checked out in repoPath.
+func DefaultBranch(repoPath string) string {
+	gt := &GitWorktree{repoPath: repoPath, worktreePath: repoPath}
+	if out, err := gt.runGitCommand(repoPath, "symbolic-ref", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		if branch := strings.TrimPrefix(strings.TrimSpace(out), "origin/"); branch != "" {
+			return branch
+		}
+	}
+	for _, candidate := range []string{"main", "master"} {
+		if _, err := gt.runGitCommand(repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+candidate); err == nil {
+			return candidate
+		}
+	}
+	out, _ := gt.runGitCommand(repoPath, "rev-parse", "--abbrev-ref", "HEAD")
+	return strings.TrimSpace(out)
+}
+
+// MergePlanBranch merges the plan branch into the current branch (typically main)
+// with a --no-ff merge, removes the worktree, and deletes the plan branch.
+func MergePlanBranch(repoPath, branch string) error {
+	return MergePlanBranchWith(repoPath, branch, MergeOptions{Strategy: MergeStrategyMerge})
+}
+
+// MergePlanBranchWith lands the plan branch on its base using opts.Strategy,
+// then removes the plan worktree and deletes the branch.
+//
+// Before touching anything it checks that repoPath has the base branch checked
+// out and has no uncommitted changes to tracked files. On a conflict the merge
+// (or rebase) is aborted and a *MergeConflictError is returned; the plan
+// worktree is kept so an agent can resolve the conflict there.
+func MergePlanBranchWith(repoPath, branch string, opts MergeOptions) error {
+	gt := &GitWorktree{repoPath: repoPath, worktreePath: repoPath}
+	strategy := opts.Strategy
+	if strategy == "" {
+		strategy = MergeStrategyMerge
+	}
+	base := opts.Base
+	if base == "" {
+		base = DefaultBranch(repoPath)
+	}
+
+	if err := checkMergeTarget(gt, repoPath, base); err != nil {
+		return err
+	}
+	if err := ensureLocalBranch(gt, repoPath, branch); err != nil {
+		return err
+	}
+
+	worktreePath := PlanWorktreePath(repoPath, branch)
+	switch strategy {
+	case MergeStrategyMerge:
+		if _, err := gt.runGitCommand(repoPath, "merge", branch, "--no-ff", "-m",
+			fmt.Sprintf("merge plan branch %s", branch)); err != nil {
+			return abortMerge(gt, repoPath, branch, base, strategy, err, "merge", "--abort")
+		}
+	case MergeStrategySquash:
+		message, err := squashMessage(gt, repoPath, branch, base, opts.PlanFile)
+		if err != nil {
+			return err
+		}
+		if _, err := gt.runGitCommand(repoPath, "merge", "--squash", branch); err != nil {
+			// A squash merge records no MERGE_HEAD, so merge --abort cannot undo it.
+			return abortMerge(gt, repoPath, branch, base, strategy, err, "reset", "--merge")
+		}
+		if _, err := gt.runGitCommand(repoPath, "commit", "-m", message); err != nil {
+			if !strings.Contains(err.Error(), "nothing to commit") {
+				_, _ = gt.runGitCommand(repoPath, "reset", "--merge")
+				return fmt.Errorf("commit squashed %s: %w", branch, err)
+			}
+		}
+	case MergeStrategyRebase:
+		// Rebase inside the plan worktree so the main worktree's HEAD never moves.
+		if _, err := RebasePlanBranch(repoPath, branch, base); err != nil {
+			return err
+		}
+		if _, err := gt.runGitCommand(repoPath, "merge", "--ff-only", branch); err != nil {
+			return fmt.Errorf("fast-forward %s to %s: %w", base, branch, err)
+		}
+	default:
+		return fmt.Errorf("unknown merge strategy %q", strategy)
+	}
+
+	// The plan has landed — the worktree and branch are no longer needed.
+	_, _ = gt.runGitCommand(repoPath, "worktree", "remove", "-f", worktreePath)
+	_, _ = gt.runGitCommand(repoPath, "worktree", "prune")
+	// Squashed commits are not ancestors of base, so -d would refuse.
+	deleteFlag := "-d"
+	if strategy == MergeStrategySquash {
+		deleteFlag = "-D"
+	}
+	// Non-fatal: the merge succeeded, branch cleanup is best-effort.
+	_, _ = gt.runGitCommand(repoPath, "branch", deleteFlag, branch)
+	return nil
+}
+
+// checkMergeTarget verifies that repoPath has base checked out and no
+// uncommitted changes to tracked files.
+func checkMergeTarget(gt *GitWorktree, repoPath, base string) error {
+	out, err := gt.runGitCommand(repoPath, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return fmt.Errorf("read current branch: %w", err)
+	}
+	if current := strings.TrimSpace(out); current != base {
+		return fmt.Errorf("cannot merge: %s has %q checked out, expected %q", repoPath, current, base)
+	}
+	status, err := gt.runGitCommand(repoPath, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return fmt.Errorf("read worktree status: %w", err)
+	}
+	if strings.TrimSpace(status) != "" {
+		return fmt.Errorf("cannot merge: %s has uncommitted changes — commit or stash them first", base)
+	}
+	return nil
+}
+
+// ensureLocalBranch recreates branch from origin when only the remote
+// tracking branch exists.
+func ensureLocalBranch(gt *GitWorktree, repoPath, branch string) error {
+	if _, err := gt.runGitCommand(repoPath, "rev-parse", "--verify", branch); err == nil {
+		return nil
+	}
+	remote := "origin/" + branch
+	if _, err := gt.runGitCommand(repoPath, "rev-parse", "--verify", remote); err != nil {
+		return fmt.Errorf("branch %s not found locally or on remote", branch)
+	}
+	if _, err := gt.runGitCommand(repoPath, "branch", branch, remote); err != nil {
+		return fmt.Errorf("recreate local branch %s from remote: %w", branch, err)
+	}
+	return nil
+}
+
+// abortMerge collects the conflicting files at path, runs the abort command
+// and returns a *MergeConflictError. Failures without conflicts are returned
+// as plain errors.
+func abortMerge(gt *GitWorktree, path, branch, base string, strategy MergeStrategy, cause error, abort ...string) error {
+	files := conflictedFiles(gt, path)
+	if len(files) == 0 {
+		// Nothing was left half-applied worth reporting; abort best-effort.
+		_, _ = gt.runGitCommand(path, abort...)
+		return fmt.Errorf("%s %s: %w", strategy, branch, cause)
+	}
+	if _, err := gt.runGitCommand(path, abort...); err != nil {
+		return fmt.Errorf("%s %s conflicted and could not be aborted (%v): %w", strategy, branch, err, cause)
+	}
+	return &MergeConflictError{Branch: branch, Base: base, Strategy: strategy, Files: files}
+}
+
+// conflictedFiles lists the unmerged paths at path.
+func conflictedFiles(gt *GitWorktree, path string) []string {
+	out, err := gt.runGitCommand(path, "diff", "--name-only", "--diff-filter=U")
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files
+}
+
+// squashMessage builds the commit message for a squash merge: the plan name
+// as the subject and the squashed commit subjects as the body.
+func squashMessage(gt *GitWorktree, repoPath, branch, base, planFile string) (string, error) {
+	subject := "merge plan branch " + branch
+	if planFile != "" {
+		subject = "feat: " + planstate.DisplayName(planFile)
+	}
+	out, err := gt.runGitCommand(repoPath, "log", "--reverse", "--format=- %s", base+".."+branch)
+	if err != nil {
+		return "", fmt.Errorf("list commits on %s: %w", branch, err)
+	}
+	if body := strings.TrimSpace(out); body != "" {
+		return subject + "\n\nsquashed from " + branch + ":\n" + body + "\n", nil
+	}
+	return subject, nil
+}
@@ -0,0 +1,126 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// gitOut runs git in dir and returns trimmed output.
+func gitOut(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput()
+	require.NoErrorf(t, err, "git %v failed: %s", args, string(out))
+	return strings.TrimSpace(string(out))
+}
+
+// commitFile writes content to name in dir and commits it.
+func commitFile(t *testing.T, dir, name, content, msg string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	gitOut(t, dir, "add", name)
+	gitOut(t, dir, "commit", "-m", msg)
+}
+
+// setupPlanRepo creates a repo with a plan branch checked out in its shared
+// worktree, carrying two commits.
+func setupPlanRepo(t *testing.T) (repo, branch, worktree string) {
+	t.Helper()
+	repo = initTestRepo(t)
+	branch = "plan/auth"
+	gitOut(t, repo, "branch", branch)
+	worktree = PlanWorktreePath(repo, branch)
+	gitOut(t, repo, "worktree", "add", worktree, branch)
+	commitFile(t, worktree, "auth.go", "package auth\n", "add auth")
+	commitFile(t, worktree, "auth_test.go", "package auth\n", "test auth")
+	return repo, branch, worktree
+}
+
+func TestParseMergeStrategy(t *testing.T) {
+	s, err := ParseMergeStrategy("")
+	require.NoError(t, err)
+	assert.Equal(t, MergeStrategyMerge, s)
+	s, err = ParseMergeStrategy("squash")
+	require.NoError(t, err)
+	assert.Equal(t, MergeStrategySquash, s)
+	_, err = ParseMergeStrategy("octopus")
+	assert.Error(t, err)
+}
+
+func TestMergePlanBranchWith_Strategies(t *testing.T) {
+	t.Run("merge creates a merge commit", func(t *testing.T) {
+		repo, branch, worktree := setupPlanRepo(t)
+		require.NoError(t, MergePlanBranchWith(repo, branch, MergeOptions{Strategy: MergeStrategyMerge}))
+		assert.Equal(t, "merge plan branch plan/auth", gitOut(t, repo, "log", "-1", "--format=%s"))
+		assert.NoDirExists(t, worktree)
+		assert.FileExists(t, filepath.Join(repo, "auth.go"))
+	})
+
+	t.Run("squash creates one commit with generated message", func(t *testing.T) {
+		repo, branch, _ := setupPlanRepo(t)
+		require.NoError(t, MergePlanBranchWith(repo, branch, MergeOptions{
+			Strategy: MergeStrategySquash,
+			PlanFile: "2026-03-01-auth.md",
+		}))
+		msg := gitOut(t, repo, "log", "-1", "--format=%B")
+		assert.True(t, strings.HasPrefix(msg, "feat: auth"), msg)
+		assert.Contains(t, msg, "- add auth\n- test auth")
+		assert.Equal(t, "2", gitOut(t, repo, "rev-list", "--count", "HEAD"))
+		_, err := exec.Command("git", "-C", repo, "rev-parse", "--verify", branch).Output()
+		assert.Error(t, err, "squashed branch is deleted")
+	})
+
+	t.Run("rebase fast-forwards onto moved base", func(t *testing.T) {
+		repo, branch, _ := setupPlanRepo(t)
+		commitFile(t, repo, "other.go", "package other\n", "unrelated change")
+		require.NoError(t, MergePlanBranchWith(repo, branch, MergeOptions{Strategy: MergeStrategyRebase}))
+		assert.Equal(t, "test auth", gitOut(t, repo, "log", "-1", "--format=%s"))
+		assert.Equal(t, "4", gitOut(t, repo, "rev-list", "--count", "HEAD"), "linear history, no merge commit")
+	})
+}
+
+func TestMergePlanBranchWith_ConflictAborts(t *testing.T) {
+	for _, strategy := range MergeStrategies {
+		t.Run(string(strategy), func(t *testing.T) {
+			repo, branch, worktree := setupPlanRepo(t)
+			commitFile(t, worktree, "README.md", "plan version\n", "plan edits readme")
+			commitFile(t, repo, "README.md", "main version\n", "main edits readme")
+			head := gitOut(t, repo, "rev-parse", "HEAD")
+
+			err := MergePlanBranchWith(repo, branch, MergeOptions{Strategy: strategy})
+			conflict, ok := IsMergeConflict(err)
+			require.True(t, ok, "want conflict, got %v", err)
+			assert.Equal(t, []string{"README.md"}, conflict.Files)
+
+			assert.Equal(t, head, gitOut(t, repo, "rev-parse", "HEAD"), "base branch untouched")
+			assert.Empty(t, gitOut(t, repo, "status", "--porcelain", "--untracked-files=no"), "no merge in progress")
+			assert.DirExists(t, worktree, "plan worktree kept for the fixer")
+			assert.Empty(t, gitOut(t, worktree, "status", "--porcelain", "--untracked-files=no"), "no rebase in progress")
+		})
+	}
+}
+
+func TestMergePlanBranchWith_PreflightChecks(t *testing.T) {
+	t.Run("wrong branch checked out", func(t *testing.T) {
+		repo, branch, _ := setupPlanRepo(t)
+		base := DefaultBranch(repo)
+		gitOut(t, repo, "checkout", "-b", "scratch")
+		err := MergePlanBranchWith(repo, branch, MergeOptions{Base: base})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "expected")
+	})
+
+	t.Run("dirty base", func(t *testing.T) {
+		repo, branch, worktree := setupPlanRepo(t)
+		require.NoError(t, os.WriteFile(filepath.Join(repo, "README.md"), []byte("wip\n"), 0644))
+		err := MergePlanBranchWith(repo, branch, MergeOptions{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "uncommitted changes")
+		assert.DirExists(t, worktree)
+	})
+}
@@ -59,54 +59,23 @@ func CommitPlanScaffoldOnMain(repoPath, planFile string) error {
 // EnsurePlanBranch creates the plan branch off the current HEAD if it doesn't
 // already exist. It is idempotent.
 func EnsurePlanBranch(repoPath, branch string) error {
+	return EnsurePlanBranchFrom(repoPath, branch, "HEAD")
+}
+
+// EnsurePlanBranchFrom creates the plan branch off base (a branch or commit)
+// if it doesn't already exist, e.g. off a parent plan's branch for a stacked
+// plan. It is idempotent.
+func EnsurePlanBranchFrom(repoPath, branch, base string) error {
 	gt := &GitWorktree{repoPath: repoPath, worktreePath: repoPath}
 	if _, err := gt.runGitCommand(repoPath, "rev-parse", "--verify", branch); err == nil {
 		return nil // already exists
 	}
-	if _, err := gt.runGitCommand(repoPath, "branch", branch); err != nil {
+	if _, err := gt.runGitCommand(repoPath, "branch", branch, base); err != nil {
 		return fmt.Errorf("create plan branch %s: %w", branch, err)
 	}
 	return nil
 }
 
-// MergePlanBranch merges the plan branch into the current branch (typically main),
-// removes the worktree, and deletes the plan branch.
-func MergePlanBranch(repoPath, branch string) error {
-	gt := &GitWorktree{repoPath: repoPath, worktreePath: repoPath}
-	worktreePath := PlanWorktreePath(repoPath, branch)
-
-	// Remove worktree first so the branch isn't "checked out" elsewhere.
-	_, _ = gt.runGitCommand(repoPath, "worktree", "remove", "-f", worktreePath)
-	_, _ = gt.runGitCommand(repoPath, "worktree", "prune")
-
-	// Ensure the local branch exists — worktree removal may have deleted it.
-	// If a remote tracking branch exists, recreate the local one from it.
-	if _, err := gt.runGitCommand(repoPath, "rev-parse", "--verify", branch); err != nil {
-		remote := "origin/" + branch
-		if _, remoteErr := gt.runGitCommand(repoPath, "rev-parse", "--verify", remote); remoteErr == nil {
-			if _, brErr := gt.runGitCommand(repoPath, "branch", branch, remote); brErr != nil {
-				return fmt.Errorf("recreate local branch %s from remote: %w", branch, brErr)
-			}
-		} else {
-			return fmt.Errorf("branch %s not found locally or on remote", branch)
-		}
-	}
-
-	// Merge the plan branch into the current branch.
-	if _, err := gt.runGitCommand(repoPath, "merge", branch, "--no-ff", "-m",
-		fmt.Sprintf("merge plan branch %s", branch)); err != nil {
-		return fmt.Errorf("merge %s: %w", branch, err)
-	}
-
-	// Delete the plan branch after successful merge.
-	if _, err := gt.runGitCommand(repoPath, "branch", "-d", branch); err != nil {
-		// Non-fatal: merge succeeded, branch cleanup is best-effort.
-		_ = err
-	}
-
-	return nil
-}
-
 // ResetPlanBranch removes the plan worktree (if any), deletes the branch, and
 // recreates it from the current HEAD. Used by "start over".
 func ResetPlanBranch(repoPath, branch string) error {
@@ -122,3 +91,13 @@ func ResetPlanBranch(repoPath, branch string) error {
 	}
 	return nil
 }
+
+// BranchTip returns the commit branch points at, or an error if it doesn't exist.
+func BranchTip(repoPath, branch string) (string, error) {
+	gt := &GitWorktree{repoPath: repoPath, worktreePath: repoPath}
+	out, err := gt.runGitCommand(repoPath, "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	if err != nil {
+		return "", fmt.Errorf("branch %s not found: %w", branch, err)
+	}
+	return strings.TrimSpace(out), nil
+}
@@ -0,0 +1,61 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kastheco/kasmos/config/planlog"
+)
+
+// trailerHookMarker identifies a prepare-commit-msg hook written by kasmos,
+// so it can be updated without clobbering a hook the user wrote.
+const trailerHookMarker = "# kasmos-commit-trailers"
+
+// trailerHook appends Kasmos-* trailers from the KASMOS_* environment that
+// kasmos exports to agent sessions. Commits made outside kasmos are untouched.
+const trailerHook = `#!/bin/sh
+` + trailerHookMarker + `: installed by kasmos, rewritten on every worktree setup.
+# Attributes commits made by kasmos agents to their plan, wave and task.
+[ -n "$KASMOS_PLAN" ] || exit 0
+case "$2" in merge) exit 0 ;; esac
+msg="$1"
+trailer() {
+	if [ -n "$2" ] && [ "$2" != 0 ]; then
+		git interpret-trailers --in-place --if-exists replace --trailer "$1: $2" "$msg"
+	fi
+}
+trailer ` + planlog.PlanTrailer + ` "$KASMOS_PLAN"
+trailer ` + planlog.WaveTrailer + ` "$KASMOS_WAVE"
+trailer ` + planlog.TaskTrailer + ` "$KASMOS_TASK"
+trailer ` + planlog.AgentTrailer + ` "$KASMOS_AGENT"
+`
+
+// InstallCommitTrailerHook installs the trailer hook as prepare-commit-msg in
+// the repository's hooks directory, which all of its worktrees share. A
+// prepare-commit-msg hook that kasmos did not write is left alone.
+func InstallCommitTrailerHook(repoPath string) error {
+	gt := &GitWorktree{repoPath: repoPath, worktreePath: repoPath}
+	out, err := gt.runGitCommand(repoPath, "rev-parse", "--path-format=absolute", "--git-path", "hooks")
+	if err != nil {
+		return err
+	}
+	hookPath := filepath.Join(strings.TrimSpace(out), "prepare-commit-msg")
+
+	existing, err := os.ReadFile(hookPath)
+	switch {
+	case err == nil && bytes.Equal(existing, []byte(trailerHook)):
+		return nil
+	case err == nil && !bytes.Contains(existing, []byte(trailerHookMarker)):
+		return fmt.Errorf("%s exists and was not written by kasmos; not replacing it", hookPath)
+	case err != nil && !os.IsNotExist(err):
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(hookPath), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(hookPath, []byte(trailerHook), 0o755)
+}
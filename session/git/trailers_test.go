@@ -0,0 +1,49 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/kastheco/kasmos/config/planlog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallCommitTrailerHook(t *testing.T) {
+	repo, branch, worktree := setupPlanRepo(t)
+	require.NoError(t, InstallCommitTrailerHook(repo))
+	require.NoError(t, InstallCommitTrailerHook(repo), "reinstalling is a no-op")
+
+	// Agent commit: trailers come from the KASMOS_* environment.
+	require.NoError(t, os.WriteFile(filepath.Join(worktree, "token.go"), []byte("package auth\n"), 0644))
+	gitOut(t, worktree, "add", "token.go")
+	commit := exec.Command("git", "-C", worktree, "commit", "-m", "add token store")
+	commit.Env = append(os.Environ(), "KASMOS_PLAN=2026-03-01-auth.md", "KASMOS_WAVE=2",
+		"KASMOS_TASK=3", "KASMOS_AGENT=coder")
+	out, err := commit.CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	// Commits made outside kasmos are left alone.
+	commitFile(t, worktree, "notes.md", "notes\n", "manual notes")
+
+	commits, err := planlog.Read(repo, DefaultBranch(repo), branch)
+	require.NoError(t, err)
+	require.Len(t, commits, 4)
+	assert.Equal(t, planlog.Commit{SHA: commits[2].SHA, Subject: "add token store",
+		Plan: "2026-03-01-auth.md", Wave: 2, Task: 3, Agent: "coder"}, commits[2])
+	assert.Equal(t, planlog.Commit{SHA: commits[3].SHA, Subject: "manual notes"}, commits[3])
+}
+
+func TestInstallCommitTrailerHook_KeepsUserHook(t *testing.T) {
+	repo := initTestRepo(t)
+	hook := filepath.Join(repo, ".git", "hooks", "prepare-commit-msg")
+	require.NoError(t, os.MkdirAll(filepath.Dir(hook), 0o755))
+	require.NoError(t, os.WriteFile(hook, []byte("#!/bin/sh\nexit 0\n"), 0o755))
+
+	assert.Error(t, InstallCommitTrailerHook(repo))
+	got, err := os.ReadFile(hook)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\nexit 0\n", string(got))
+}
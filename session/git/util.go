@@ -26,22 +26,6 @@ func sanitizeBranchName(s string) string {
 	return s
 }
 
-// checkGHCLI checks if GitHub CLI is installed and configured
-func checkGHCLI() error {
-	// Check if gh is installed
-	if _, err := exec.LookPath("gh"); err != nil {
-		return fmt.Errorf("GitHub CLI (gh) is not installed. Please install it first")
-	}
-
-	// Check if gh is authenticated
-	cmd := exec.Command("gh", "auth", "status")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("GitHub CLI is not configured. Please run 'gh auth login' first")
-	}
-
-	return nil
-}
-
 // IsGitRepo checks if the given path is within a git repository
 func IsGitRepo(path string) bool {
 	_, err := findGitRepoRoot(path)
@@ -65,3 +49,13 @@ func findGitRepoRoot(path string) (string, error) {
 		currentPath = parent
 	}
 }
+
+// RepoRoot returns the top-level directory of the work tree containing dir,
+// as reported by git rev-parse --show-toplevel.
+func RepoRoot(dir string) (string, error) {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %s", dir)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/kastheco/kasmos/config"
 	"github.com/kastheco/kasmos/log"
 	"path/filepath"
+	"sync"
 	"time"
 )
 
@@ -27,6 +28,16 @@ type GitWorktree struct {
 	branchName string
 	// Base commit hash for the worktree
 	baseCommitSHA string
+	// baseBranch, when set, is the branch a new branch is created from and
+	// diffs are based on, instead of HEAD of the main worktree.
+	baseBranch string
+
+	// diffMu guards the cached Diff result and the index path lookup.
+	diffMu    sync.Mutex
+	diffCache *DiffStats
+	diffKey   diffCacheKey
+	diffAt    time.Time
+	indexPath string
 }
 
 func NewGitWorktreeFromStorage(repoPath string, worktreePath string, sessionName string, branchName string, baseCommitSHA string) *GitWorktree {
@@ -129,6 +140,13 @@ func (g *GitWorktree) GetRepoName() string {
 	return filepath.Base(g.repoPath)
 }
 
+// SetBaseBranch makes Setup branch off base instead of HEAD of the main
+// worktree, and compute the diff base against it. Stacked plans pass their
+// parent plan's branch.
+func (g *GitWorktree) SetBaseBranch(base string) {
+	g.baseBranch = base
+}
+
 // GetBaseCommitSHA returns the base commit SHA for the worktree
 func (g *GitWorktree) GetBaseCommitSHA() string {
 	return g.baseCommitSHA
@@ -22,10 +22,6 @@ func (g *GitWorktree) runGitCommand(path string, args ...string) (string, error)
 
 // PushChanges commits and pushes changes in the worktree to the remote branch
 func (g *GitWorktree) PushChanges(commitMessage string, open bool) error {
-	if err := checkGHCLI(); err != nil {
-		return err
-	}
-
 	if err := g.CommitChanges(commitMessage); err != nil {
 		return err
 	}
@@ -81,33 +77,39 @@ func (g *GitWorktree) GeneratePRBody() (string, error) {
 	return strings.Join(sections, "\n\n"), nil
 }
 
-// CreatePR pushes changes and creates a pull request on GitHub.
-func (g *GitWorktree) CreatePR(title, body, commitMsg string) error {
+// CreatePR pushes changes and opens a pull request on the repository's forge
+// (see DetectForge), then shows it in the browser. base is the branch to
+// merge into; empty targets the forge's default branch, and any other branch
+// (a stacked plan's parent) is pushed first so the forge can see it. An
+// existing pull request for the branch is reused. Returns the pull request
+// so callers can track it.
+func (g *GitWorktree) CreatePR(title, body, commitMsg, base string) (*PullRequest, error) {
+	forge := DetectForge(g.repoPath)
+	if err := forge.Check(); err != nil {
+		return nil, err
+	}
+
 	// Push changes first (without opening browser)
 	if err := g.PushChanges(commitMsg, false); err != nil {
-		return fmt.Errorf("failed to push changes: %w", err)
-	}
-
-	// Create the pull request
-	prCmd := exec.Command("gh", "pr", "create", "--title", title, "--body", body, "--head", g.branchName)
-	prCmd.Dir = g.worktreePath
-	if output, err := prCmd.CombinedOutput(); err != nil {
-		// If PR already exists, just open it
-		if strings.Contains(string(output), "already exists") {
-			viewCmd := exec.Command("gh", "pr", "view", "--web", g.branchName)
-			viewCmd.Dir = g.worktreePath
-			_ = viewCmd.Run()
-			return nil
+		return nil, fmt.Errorf("failed to push changes: %w", err)
+	}
+	if base != "" {
+		if _, err := g.runGitCommand(g.repoPath, "push", "origin", base); err != nil {
+			return nil, fmt.Errorf("failed to push base branch %s: %w", base, err)
 		}
-		return fmt.Errorf("failed to create PR: %s (%w)", output, err)
+	}
+
+	pr, err := forge.CreatePR(g.worktreePath, PROptions{Title: title, Body: body, Head: g.branchName, Base: base})
+	if err != nil {
+		return nil, err
 	}
 
 	// Open the PR in browser
-	viewCmd := exec.Command("gh", "pr", "view", "--web", g.branchName)
-	viewCmd.Dir = g.worktreePath
-	_ = viewCmd.Run()
+	if err := forge.OpenPR(g.worktreePath, g.branchName); err != nil {
+		log.ErrorLog.Printf("failed to open PR: %v", err)
+	}
 
-	return nil
+	return pr, nil
 }
 
 // CommitChanges commits changes locally without pushing to remote
@@ -135,6 +137,15 @@ func (g *GitWorktree) CommitChanges(commitMessage string) error {
 	return nil
 }
 
+// HeadSHA returns the commit checked out in the worktree.
+func (g *GitWorktree) HeadSHA() (string, error) {
+	out, err := g.runGitCommand(g.worktreePath, "rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
 // IsDirty checks if the worktree has uncommitted changes
 func (g *GitWorktree) IsDirty() (bool, error) {
 	output, err := g.runGitCommand(g.worktreePath, "status", "--porcelain")
@@ -155,15 +166,9 @@ func (g *GitWorktree) IsBranchCheckedOut() (bool, error) {
 
 // OpenBranchURL opens the branch URL in the default browser
 func (g *GitWorktree) OpenBranchURL() error {
-	// Check if GitHub CLI is available
-	if err := checkGHCLI(); err != nil {
+	forge := DetectForge(g.repoPath)
+	if err := forge.Check(); err != nil {
 		return err
 	}
-
-	cmd := exec.Command("gh", "browse", "--branch", g.branchName)
-	cmd.Dir = g.worktreePath
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to open branch URL: %w", err)
-	}
-	return nil
+	return forge.OpenBranch(g.worktreePath, g.branchName)
 }
@@ -53,9 +53,19 @@ func (g *GitWorktree) Setup() error {
 	}
 
 	if branchExists {
-		return g.setupFromExistingBranch()
+		err = g.setupFromExistingBranch()
+	} else {
+		err = g.setupNewWorktree()
 	}
-	return g.setupNewWorktree()
+	if err != nil {
+		return err
+	}
+
+	// Attribute agent commits to their plan, wave and task.
+	if err := InstallCommitTrailerHook(g.repoPath); err != nil {
+		log.WarningLog.Printf("commit trailer hook: %v", err)
+	}
+	return nil
 }
 
 // setupFromExistingBranch creates a worktree from an existing branch
@@ -76,9 +86,10 @@ func (g *GitWorktree) setupFromExistingBranch() error {
 	}
 
 	// Resolve a base commit for diff computation. Try merge-base with HEAD
-	// of the main worktree first; fall back to the branch's own HEAD.
+	// of the main worktree (or the base branch) first; fall back to the
+	// branch's own HEAD.
 	if g.baseCommitSHA == "" {
-		if out, err := g.runGitCommand(g.repoPath, "merge-base", "HEAD", g.branchName); err == nil {
+		if out, err := g.runGitCommand(g.repoPath, "merge-base", g.baseRef(), g.branchName); err == nil {
 			g.baseCommitSHA = strings.TrimSpace(out)
 		} else if out, err := g.runGitCommand(g.worktreePath, "rev-parse", "HEAD"); err == nil {
 			g.baseCommitSHA = strings.TrimSpace(out)
@@ -137,7 +148,16 @@ func (g *GitWorktree) syncBranchWithRemote() {
 	}
 }
 
-// setupNewWorktree creates a new worktree from HEAD
+// baseRef is what new branches start from: the base branch if one was set,
+// otherwise HEAD of the main worktree.
+func (g *GitWorktree) baseRef() string {
+	if g.baseBranch != "" {
+		return g.baseBranch
+	}
+	return "HEAD"
+}
+
+// setupNewWorktree creates a new worktree from baseRef
 func (g *GitWorktree) setupNewWorktree() error {
 	// Directory already created in Setup(), skip duplicate creation
 
@@ -155,7 +175,7 @@ func (g *GitWorktree) setupNewWorktree() error {
 		return fmt.Errorf("failed to cleanup existing branch: %w", err)
 	}
 
-	output, err := g.runGitCommand(g.repoPath, "rev-parse", "HEAD")
+	output, err := g.runGitCommand(g.repoPath, "rev-parse", g.baseRef())
 	if err != nil {
 		if strings.Contains(err.Error(), "fatal: ambiguous argument 'HEAD'") ||
 			strings.Contains(err.Error(), "fatal: not a valid object name") ||
@@ -107,13 +107,21 @@ type Instance struct {
 	// this to avoid treating the initial idle prompt as task completion.
 	AwaitingWork bool
 
-	// CPUPercent is the current CPU usage of the instance's process.
+	// CPUPercent is the current CPU usage of the instance's process tree.
 	CPUPercent float64
-	// MemMB is the current memory usage in megabytes.
+	// MemMB is the current memory usage of the process tree in megabytes.
 	MemMB float64
+	// CPUSeconds is the cumulative CPU time of the process tree.
+	CPUSeconds float64
+	// resourceHistory is the rolling usage history (ephemeral). See RecordResourceUsage.
+	resourceHistory []ResourceSample
+	// limitReported is the most severe resource limit already reported. See CheckResourceLimits.
+	limitReported LimitLevel
 
 	// LastActivity is the most recently detected agent activity (ephemeral, not persisted).
 	LastActivity *Activity
+	// timeline is the persisted history of detected activity. See RecordActivity.
+	timeline ActivityTimeline
 
 	// CachedContent is the last tmux capture-pane output, set in app.Update from metadata results.
 	// Used by the preview tick to avoid redundant subprocess calls.
@@ -157,6 +165,7 @@ func (i *Instance) ToInstanceData() InstanceData {
 		ImplementationComplete: i.ImplementationComplete,
 		SoloAgent:              i.SoloAgent,
 		QueuedPrompt:           i.QueuedPrompt,
+		Activity:               timelineToData(&i.timeline),
 	}
 
 	// Only include worktree data if gitWorktree is initialized
@@ -204,6 +213,7 @@ func FromInstanceData(data InstanceData) (*Instance, error) {
 		ImplementationComplete: data.ImplementationComplete,
 		SoloAgent:              data.SoloAgent,
 		QueuedPrompt:           data.QueuedPrompt,
+		timeline:               timelineFromData(data.Activity),
 		gitWorktree: git.NewGitWorktreeFromStorage(
 			data.Worktree.RepoPath,
 			data.Worktree.WorktreePath,
@@ -335,7 +345,7 @@ func (i *Instance) SetStatus(status Status) {
 		// per-task desktop notifications; the user gets a toast when the
 		// wave completes instead.
 		if i.TaskNumber == 0 {
-			SendNotification("kas", fmt.Sprintf("'%s' has finished", i.Title))
+			i.notifyReady()
 		}
 	}
 	if status == Running || status == Loading {
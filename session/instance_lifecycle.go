@@ -261,9 +261,16 @@ func (i *Instance) transferPromptToCli() {
 	}
 }
 
-// setTmuxTaskEnv wires task/wave/peer identity to the tmux session for env var injection.
+// setTmuxTaskEnv wires plan and task/wave/peer identity to the tmux session
+// for env var injection.
 func (i *Instance) setTmuxTaskEnv() {
-	if i.TaskNumber > 0 && i.tmuxSession != nil {
+	if i.tmuxSession == nil {
+		return
+	}
+	if i.PlanFile != "" {
+		i.tmuxSession.SetPlanEnv(i.PlanFile)
+	}
+	if i.TaskNumber > 0 {
 		i.tmuxSession.SetTaskEnv(i.TaskNumber, i.WaveNumber, i.PeerCount)
 	}
 }
@@ -303,6 +310,14 @@ func (i *Instance) StopTmux() {
 	}
 }
 
+// Release drops this process's handle on the tmux session without stopping the
+// agent. Used when another process (TUI or daemon) takes over supervision.
+func (i *Instance) Release() {
+	if i.tmuxSession != nil {
+		_ = i.tmuxSession.ReleasePTY()
+	}
+}
+
 // Pause stops the tmux session and removes the worktree, preserving the branch
 func (i *Instance) Pause() error {
 	if !i.started {
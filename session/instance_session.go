@@ -2,8 +2,6 @@ package session
 
 import (
 	"fmt"
-	"os/exec"
-	"strconv"
 	"strings"
 	"time"
 
@@ -125,8 +123,7 @@ type InstanceMetadata struct {
 	Updated            bool
 	HasPrompt          bool
 	DiffStats          *git.DiffStats
-	CPUPercent         float64
-	MemMB              float64
+	Resources          ResourceUsage
 	ResourceUsageValid bool
 	TmuxAlive          bool              // tmux has-session result (for reviewer completion check)
 	PermissionPrompt   *PermissionPrompt // non-nil when opencode shows a permission dialog
@@ -135,8 +132,9 @@ type InstanceMetadata struct {
 // CollectMetadata gathers all per-tick data for this instance via subprocess calls.
 // Safe to call from a goroutine — reads only, no model mutations.
 // Combines HasUpdated + UpdateDiffStats + UpdateResourceUsage data collection
-// into a single method, eliminating redundant capture-pane calls.
-func (i *Instance) CollectMetadata() InstanceMetadata {
+// into a single method, eliminating redundant capture-pane calls. procs is
+// the tick's shared process table; nil skips resource usage.
+func (i *Instance) CollectMetadata(procs *ProcessTable) InstanceMetadata {
 	var m InstanceMetadata
 
 	if !i.started || i.Status == Paused {
@@ -165,8 +163,8 @@ func (i *Instance) CollectMetadata() InstanceMetadata {
 		m.PermissionPrompt = ParsePermissionPrompt(m.Content, i.Program)
 	}
 
-	// Resource usage (pgrep + ps)
-	m.CPUPercent, m.MemMB, m.ResourceUsageValid = i.collectResourceUsage()
+	// Resource usage (ps over the whole process tree)
+	m.Resources, m.ResourceUsageValid = i.collectResourceUsage(procs)
 
 	// Session liveness (tmux has-session) — used by reviewer completion check.
 	m.TmuxAlive = i.TmuxAlive()
@@ -174,6 +172,14 @@ func (i *Instance) CollectMetadata() InstanceMetadata {
 	return m
 }
 
+// SetBaseCommitSHA points the instance's diff at a new base commit, e.g.
+// after its branch was rebased. No-op for instances without a worktree.
+func (i *Instance) SetBaseCommitSHA(sha string) {
+	if i.gitWorktree != nil {
+		i.gitWorktree.SetBaseCommitSHA(sha)
+	}
+}
+
 // SetDiffStats sets the diff stats from externally collected data.
 func (i *Instance) SetDiffStats(stats *git.DiffStats) {
 	i.diffStats = stats
@@ -211,54 +217,36 @@ func (i *Instance) UpdateDiffStats() error {
 	return nil
 }
 
-// collectResourceUsage queries CPU and memory usage via subprocess calls.
-// Returns (cpu%, memMB, ok). Safe to call from a goroutine.
-func (i *Instance) collectResourceUsage() (float64, float64, bool) {
-	if !i.started || i.tmuxSession == nil {
-		return 0, 0, false
+// collectResourceUsage sums CPU and memory usage over the pane's whole
+// process tree in procs. Safe to call from a goroutine.
+func (i *Instance) collectResourceUsage(procs *ProcessTable) (ResourceUsage, bool) {
+	if !i.started || i.tmuxSession == nil || procs == nil {
+		return ResourceUsage{}, false
 	}
 
 	pid, err := i.tmuxSession.GetPanePID()
 	if err != nil {
-		return 0, 0, false
-	}
-
-	targetPid := strconv.Itoa(pid)
-	childCmd := exec.Command("pgrep", "-P", strconv.Itoa(pid))
-	if childOutput, err := childCmd.Output(); err == nil {
-		if children := strings.Fields(strings.TrimSpace(string(childOutput))); len(children) > 0 {
-			targetPid = children[0]
-		}
-	}
-
-	psCmd := exec.Command("ps", "-o", "%cpu=,rss=", "-p", targetPid)
-	output, err := psCmd.Output()
-	if err != nil {
-		return 0, 0, false
-	}
-
-	fields := strings.Fields(strings.TrimSpace(string(output)))
-	if len(fields) < 2 {
-		return 0, 0, false
+		return ResourceUsage{}, false
 	}
+	return sumProcessTree(procs.procs, pid)
+}
 
-	cpu, err := strconv.ParseFloat(fields[0], 64)
-	if err != nil {
-		return 0, 0, false
-	}
-	rssKB, err := strconv.ParseFloat(fields[1], 64)
-	if err != nil {
-		return 0, 0, false
+// UpdateResourceUsage collects and records the process tree's resource usage
+// synchronously. Used where there is no async metadata tick (the daemon).
+func (i *Instance) UpdateResourceUsage(procs *ProcessTable) {
+	if u, ok := i.collectResourceUsage(procs); ok {
+		i.RecordResourceUsage(u)
 	}
-	return cpu, rssKB / 1024, true
 }
 
-// UpdateResourceUsage queries the process tree for CPU and memory usage.
-// Kept for backward compat but now delegates to collectResourceUsage.
-func (i *Instance) UpdateResourceUsage() {
-	if cpu, mem, ok := i.collectResourceUsage(); ok {
-		i.CPUPercent, i.MemMB = cpu, mem
+// CommitLog returns the branch's commits since the base with their patches.
+// Not part of the metadata tick: the diff pane asks for it when its
+// per-commit view opens.
+func (i *Instance) CommitLog() ([]git.CommitDiff, error) {
+	if !i.started || i.gitWorktree == nil {
+		return nil, nil
 	}
+	return i.gitWorktree.CommitLog()
 }
 
 // GetDiffStats returns the current git diff statistics
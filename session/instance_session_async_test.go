@@ -52,7 +52,7 @@ func TestCollectMetadata_DoesNotMutateCachedPreviewState(t *testing.T) {
 		tmuxSession:      tmuxSession,
 	}
 
-	_ = inst.CollectMetadata()
+	_ = inst.CollectMetadata(nil)
 
 	require.Equal(t, "existing", inst.CachedContent)
 	require.True(t, inst.CachedContentSet)
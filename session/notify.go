@@ -1,21 +1,21 @@
 package session
 
 import (
-	"os/exec"
-	"runtime"
-	"strings"
+	"fmt"
+	"time"
+
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/notify"
 )
 
 // NotificationsEnabled controls whether desktop notifications are sent.
 // Set from config at startup.
 var NotificationsEnabled = true
 
-// escapeAppleScript escapes backslashes and double quotes for AppleScript strings.
-func escapeAppleScript(s string) string {
-	s = strings.ReplaceAll(s, `\`, `\\`)
-	s = strings.ReplaceAll(s, `"`, `\"`)
-	return s
-}
+// Notifier receives notification-worthy events raised by instances (an agent
+// going idle). The TUI and daemon point it at their notify.Router; when nil,
+// a desktop notification is shown directly.
+var Notifier func(auditlog.Event)
 
 // SendNotification sends a desktop notification. It is fire-and-forget:
 // the command is started but we do not wait for it to finish.
@@ -23,16 +23,23 @@ func SendNotification(title, body string) {
 	if !NotificationsEnabled {
 		return
 	}
+	notify.SendDesktop(title, body)
+}
 
-	switch runtime.GOOS {
-	case "darwin":
-		cmd := exec.Command("osascript", "-e",
-			`display notification "`+escapeAppleScript(body)+`" with title "`+escapeAppleScript(title)+`"`)
-		_ = cmd.Start()
-	case "linux":
-		if path, err := exec.LookPath("notify-send"); err == nil {
-			cmd := exec.Command(path, title, body)
-			_ = cmd.Start()
-		}
+// notifyReady reports that the instance finished its turn and is waiting for input.
+func (i *Instance) notifyReady() {
+	msg := fmt.Sprintf("'%s' has finished", i.Title)
+	if Notifier == nil {
+		SendNotification("kas", msg)
+		return
 	}
+	Notifier(auditlog.Event{
+		Kind:          auditlog.EventAgentReady,
+		Timestamp:     time.Now(),
+		PlanFile:      i.PlanFile,
+		InstanceTitle: i.Title,
+		AgentType:     i.AgentType,
+		Message:       msg,
+		Level:         "info",
+	})
 }
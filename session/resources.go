@@ -0,0 +1,261 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kastheco/kasmos/config"
+)
+
+// maxResourceSamples bounds the per-instance resource history. At the 200ms
+// metadata tick this is the last minute of usage — enough for a sparkline.
+const maxResourceSamples = 300
+
+// ResourceUsage is the summed usage of an agent's whole process tree: the
+// pane shell, the agent CLI and everything it spawned (language servers,
+// test runners, compilers).
+type ResourceUsage struct {
+	CPUPercent float64
+	MemMB      float64
+	// CPUSeconds is the cumulative CPU time consumed by live processes.
+	CPUSeconds float64
+	Processes  int
+}
+
+// ResourceSample is one point in an instance's resource history.
+type ResourceSample struct {
+	At         time.Time
+	CPUPercent float64
+	MemMB      float64
+}
+
+// procInfo is one row of the process table.
+type procInfo struct {
+	pid, ppid  int
+	cpuPercent float64
+	rssKB      float64
+	cpuSeconds float64
+}
+
+// ProcessTable is a snapshot of the system's processes. The metadata tick
+// reads it once and shares it across instances rather than running ps per
+// agent.
+type ProcessTable struct {
+	procs []procInfo
+}
+
+// ReadProcessTable snapshots the process table via ps.
+func ReadProcessTable() (*ProcessTable, error) {
+	procs, err := readProcessTable()
+	if err != nil {
+		return nil, err
+	}
+	return &ProcessTable{procs: procs}, nil
+}
+
+// readProcessTable lists every process on the system via ps.
+func readProcessTable() ([]procInfo, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pid=,ppid=,%cpu=,rss=,time=").Output()
+	if err != nil {
+		return nil, err
+	}
+	return parseProcessTable(string(out)), nil
+}
+
+// parseProcessTable parses `ps -o pid=,ppid=,%cpu=,rss=,time=` output.
+// Malformed rows are skipped.
+func parseProcessTable(out string) []procInfo {
+	var procs []procInfo
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		cpu, err3 := strconv.ParseFloat(fields[2], 64)
+		rss, err4 := strconv.ParseFloat(fields[3], 64)
+		secs, err5 := parseCPUTime(fields[4])
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil || err5 != nil {
+			continue
+		}
+		procs = append(procs, procInfo{pid: pid, ppid: ppid, cpuPercent: cpu, rssKB: rss, cpuSeconds: secs})
+	}
+	return procs
+}
+
+// parseCPUTime parses the ps TIME column: "[dd-]hh:mm:ss" on Linux,
+// "mm:ss.ss" on macOS.
+func parseCPUTime(s string) (float64, error) {
+	var days float64
+	if d, rest, ok := strings.Cut(s, "-"); ok {
+		n, err := strconv.Atoi(d)
+		if err != nil {
+			return 0, fmt.Errorf("invalid cpu time %q", s)
+		}
+		days, s = float64(n), rest
+	}
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid cpu time %q", s)
+	}
+	var total float64
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid cpu time %q", s)
+		}
+		total = total*60 + v
+	}
+	return days*86400 + total, nil
+}
+
+// processTree returns root and all of its descendants, parents before
+// children. It returns nil when root is not in the table.
+func processTree(procs []procInfo, root int) []procInfo {
+	children := make(map[int][]int, len(procs))
+	byPid := make(map[int]procInfo, len(procs))
+	for _, p := range procs {
+		byPid[p.pid] = p
+		children[p.ppid] = append(children[p.ppid], p.pid)
+	}
+	if _, ok := byPid[root]; !ok {
+		return nil
+	}
+
+	var tree []procInfo
+	seen := make(map[int]bool)
+	queue := []int{root}
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		if seen[pid] {
+			continue
+		}
+		seen[pid] = true
+		tree = append(tree, byPid[pid])
+		queue = append(queue, children[pid]...)
+	}
+	return tree
+}
+
+// sumProcessTree adds up the usage of root and all of its descendants.
+func sumProcessTree(procs []procInfo, root int) (ResourceUsage, bool) {
+	tree := processTree(procs, root)
+	if tree == nil {
+		return ResourceUsage{}, false
+	}
+	var u ResourceUsage
+	for _, p := range tree {
+		u.CPUPercent += p.cpuPercent
+		u.MemMB += p.rssKB / 1024
+		u.CPUSeconds += p.cpuSeconds
+		u.Processes++
+	}
+	return u, true
+}
+
+// StopProcessTree kills every process in the pane's tree, then the tmux
+// session. Closing the session alone only hangs up the pane; descendants that
+// ignore SIGHUP or started their own session (language servers, watchers)
+// would keep running. Used when a hard resource limit is crossed.
+func (i *Instance) StopProcessTree() error {
+	if !i.started || i.tmuxSession == nil {
+		return nil
+	}
+	var errs []error
+	if pid, err := i.tmuxSession.GetPanePID(); err == nil {
+		procs, err := readProcessTable()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("read process table: %w", err))
+		}
+		tree := processTree(procs, pid)
+		// Leaves first so parents cannot respawn killed children.
+		for k := len(tree) - 1; k >= 0; k-- {
+			p, err := os.FindProcess(tree[k].pid)
+			if err != nil {
+				continue
+			}
+			if err := p.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+				errs = append(errs, fmt.Errorf("kill pid %d: %w", tree[k].pid, err))
+			}
+		}
+	}
+	// The session usually exits with its pane; a failed kill-session is fine.
+	_ = i.tmuxSession.Close()
+	return errors.Join(errs...)
+}
+
+// RecordResourceUsage stores u as the instance's current usage and appends it
+// to the rolling history.
+func (i *Instance) RecordResourceUsage(u ResourceUsage) {
+	i.CPUPercent = u.CPUPercent
+	i.MemMB = u.MemMB
+	i.CPUSeconds = u.CPUSeconds
+	i.resourceHistory = append(i.resourceHistory, ResourceSample{
+		At:         time.Now(),
+		CPUPercent: u.CPUPercent,
+		MemMB:      u.MemMB,
+	})
+	if len(i.resourceHistory) > maxResourceSamples {
+		i.resourceHistory = append([]ResourceSample(nil), i.resourceHistory[len(i.resourceHistory)-maxResourceSamples:]...)
+	}
+}
+
+// ResourceHistory returns a copy of the recorded samples, oldest first.
+func (i *Instance) ResourceHistory() []ResourceSample {
+	out := make([]ResourceSample, len(i.resourceHistory))
+	copy(out, i.resourceHistory)
+	return out
+}
+
+// LimitLevel ranks how far an instance is over its resource limits.
+type LimitLevel int
+
+const (
+	LimitNone LimitLevel = iota
+	LimitSoft
+	LimitHard
+)
+
+// LimitBreach describes the most severe limit an instance is over.
+type LimitBreach struct {
+	Level  LimitLevel
+	Reason string
+}
+
+// EvaluateLimits compares u against l. Hard limits win over soft ones.
+func EvaluateLimits(u ResourceUsage, l config.ResourceLimits) LimitBreach {
+	switch {
+	case l.HardMemoryMB > 0 && u.MemMB >= l.HardMemoryMB:
+		return LimitBreach{LimitHard, fmt.Sprintf("memory %.0fM over hard limit %.0fM", u.MemMB, l.HardMemoryMB)}
+	case l.HardCPUSeconds > 0 && u.CPUSeconds >= l.HardCPUSeconds:
+		return LimitBreach{LimitHard, fmt.Sprintf("cpu time %.0fs over hard limit %.0fs", u.CPUSeconds, l.HardCPUSeconds)}
+	case l.SoftMemoryMB > 0 && u.MemMB >= l.SoftMemoryMB:
+		return LimitBreach{LimitSoft, fmt.Sprintf("memory %.0fM over soft limit %.0fM", u.MemMB, l.SoftMemoryMB)}
+	case l.SoftCPUSeconds > 0 && u.CPUSeconds >= l.SoftCPUSeconds:
+		return LimitBreach{LimitSoft, fmt.Sprintf("cpu time %.0fs over soft limit %.0fs", u.CPUSeconds, l.SoftCPUSeconds)}
+	}
+	return LimitBreach{}
+}
+
+// CheckResourceLimits evaluates the instance's current usage against l and
+// returns a breach only when it escalates past the level already reported, so
+// callers warn once per crossing instead of every tick. Dropping back under
+// the soft limits re-arms the check.
+func (i *Instance) CheckResourceLimits(l config.ResourceLimits) (LimitBreach, bool) {
+	b := EvaluateLimits(ResourceUsage{CPUPercent: i.CPUPercent, MemMB: i.MemMB, CPUSeconds: i.CPUSeconds}, l)
+	if b.Level <= i.limitReported {
+		if b.Level == LimitNone {
+			i.limitReported = LimitNone
+		}
+		return LimitBreach{}, false
+	}
+	i.limitReported = b.Level
+	return b, true
+}
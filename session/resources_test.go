@@ -0,0 +1,99 @@
+package session
+
+import (
+	"testing"
+
+	"github.com/kastheco/kasmos/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCPUTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"00:00:07", 7},
+		{"01:02:03", 3723},
+		{"2-00:00:01", 2*86400 + 1},
+		{"3:04.50", 184.5},
+	}
+	for _, tt := range tests {
+		got, err := parseCPUTime(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.InDelta(t, tt.want, got, 0.001, tt.in)
+	}
+	_, err := parseCPUTime("garbage")
+	assert.Error(t, err)
+}
+
+func TestSumProcessTree(t *testing.T) {
+	procs := parseProcessTable(`
+    1     0  0.0  1024 00:00:01
+  100     1  1.0  2048 00:00:02
+  101   100 50.0 10240 00:01:00
+  102   101 25.5  5120 00:00:30
+  200     1 99.0 99999 00:10:00
+  bad line
+`)
+	require.Len(t, procs, 5)
+
+	u, ok := sumProcessTree(procs, 100)
+	require.True(t, ok)
+	assert.Equal(t, 3, u.Processes)
+	assert.InDelta(t, 76.5, u.CPUPercent, 0.001)
+	assert.InDelta(t, 17.0, u.MemMB, 0.001)
+	assert.InDelta(t, 92.0, u.CPUSeconds, 0.001)
+
+	_, ok = sumProcessTree(procs, 999)
+	assert.False(t, ok)
+
+	var pids []int
+	for _, p := range processTree(procs, 100) {
+		pids = append(pids, p.pid)
+	}
+	assert.Equal(t, []int{100, 101, 102}, pids, "parents before children, unrelated trees excluded")
+	assert.Nil(t, processTree(procs, 999))
+}
+
+func TestRecordResourceUsage_BoundedHistory(t *testing.T) {
+	inst := &Instance{Title: "a"}
+	for i := 0; i < maxResourceSamples+10; i++ {
+		inst.RecordResourceUsage(ResourceUsage{CPUPercent: float64(i), MemMB: 100})
+	}
+	history := inst.ResourceHistory()
+	require.Len(t, history, maxResourceSamples)
+	assert.Equal(t, float64(10), history[0].CPUPercent)
+	assert.Equal(t, float64(maxResourceSamples+9), inst.CPUPercent)
+}
+
+func TestCheckResourceLimits_ReportsEachCrossingOnce(t *testing.T) {
+	limits := config.ResourceLimits{SoftMemoryMB: 500, HardMemoryMB: 1000, HardCPUSeconds: 60}
+	inst := &Instance{Title: "a"}
+
+	inst.RecordResourceUsage(ResourceUsage{MemMB: 100})
+	_, ok := inst.CheckResourceLimits(limits)
+	assert.False(t, ok)
+
+	inst.RecordResourceUsage(ResourceUsage{MemMB: 600})
+	b, ok := inst.CheckResourceLimits(limits)
+	require.True(t, ok)
+	assert.Equal(t, LimitSoft, b.Level)
+	assert.Contains(t, b.Reason, "soft limit")
+
+	_, ok = inst.CheckResourceLimits(limits)
+	assert.False(t, ok, "soft breach must not repeat")
+
+	inst.RecordResourceUsage(ResourceUsage{MemMB: 600, CPUSeconds: 61})
+	b, ok = inst.CheckResourceLimits(limits)
+	require.True(t, ok)
+	assert.Equal(t, LimitHard, b.Level)
+	assert.Contains(t, b.Reason, "cpu time")
+
+	inst.RecordResourceUsage(ResourceUsage{MemMB: 100})
+	_, ok = inst.CheckResourceLimits(limits)
+	assert.False(t, ok)
+	inst.RecordResourceUsage(ResourceUsage{MemMB: 600})
+	_, ok = inst.CheckResourceLimits(limits)
+	assert.True(t, ok, "dropping below soft re-arms the check")
+}
@@ -32,6 +32,8 @@ type InstanceData struct {
 	SoloAgent              bool      `json:"solo_agent,omitempty"`
 	QueuedPrompt           string    `json:"queued_prompt,omitempty"`
 
+	Activity []ActivityData `json:"activity,omitempty"`
+
 	Program   string          `json:"program"`
 	Worktree  GitWorktreeData `json:"worktree"`
 	DiffStats DiffStatsData   `json:"diff_stats"`
@@ -84,3 +84,34 @@ func TestStartTmuxSession_WithoutTaskEnvVars(t *testing.T) {
 	assert.NotContains(t, cmdStr, "KASMOS_PEERS=")
 	assert.Contains(t, cmdStr, "KASMOS_MANAGED=1")
 }
+
+func TestStartTmuxSession_WithPlanEnvVars(t *testing.T) {
+	log.Initialize(false)
+	defer log.Close()
+
+	ptyFactory := NewMockPtyFactory(t)
+	created := false
+	cmdExec := cmd_test.MockCmdExec{
+		RunFunc: func(cmd *exec.Cmd) error {
+			if strings.Contains(cmd.String(), "has-session") && !created {
+				created = true
+				return fmt.Errorf("session already exists")
+			}
+			return nil
+		},
+		OutputFunc: func(cmd *exec.Cmd) ([]byte, error) {
+			return []byte("output"), nil
+		},
+	}
+
+	session := newTmuxSession("test-plan", "claude", false, ptyFactory, cmdExec)
+	session.SetAgentType("coder")
+	session.SetPlanEnv("2026-03-01-auth.md")
+
+	require.NoError(t, session.Start(t.TempDir()))
+	require.GreaterOrEqual(t, len(ptyFactory.cmds), 1)
+
+	cmdStr := cmd2.ToString(ptyFactory.cmds[0])
+	assert.Contains(t, cmdStr, "KASMOS_PLAN='2026-03-01-auth.md'")
+	assert.Contains(t, cmdStr, "KASMOS_AGENT='coder'")
+}
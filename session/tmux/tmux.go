@@ -53,6 +53,9 @@ type TmuxSession struct {
 	taskNumber int
 	waveNumber int
 	peerCount  int
+	// planFile, when non-empty, is exported as KASMOS_PLAN so commits made
+	// by the agent can be attributed to the plan.
+	planFile string
 	// ProgressFunc is called with (stage, description) during Start() to report progress.
 	ProgressFunc func(stage int, desc string)
 	// promptFile is the path to a temporary file containing the initial prompt.
@@ -150,6 +153,12 @@ func (t *TmuxSession) SetTaskEnv(taskNumber, waveNumber, peerCount int) {
 	t.peerCount = peerCount
 }
 
+// SetPlanEnv sets the plan the agent works on, exported as KASMOS_PLAN at
+// Start() time.
+func (t *TmuxSession) SetPlanEnv(planFile string) {
+	t.planFile = planFile
+}
+
 func (t *TmuxSession) reportProgress(stage int, desc string) {
 	if t.ProgressFunc != nil {
 		t.ProgressFunc(stage, desc)
@@ -245,6 +254,14 @@ func (t *TmuxSession) Start(workDir string) error {
 	// tmux set-environment (below) only affects new panes, not the initial program.
 	program = "KASMOS_MANAGED=1 " + program
 
+	// Prepend plan and agent identity so commits can be attributed.
+	if t.planFile != "" {
+		if t.agentType != "" {
+			program = "KASMOS_AGENT=" + shellEscapeSingleQuote(t.agentType) + " " + program
+		}
+		program = "KASMOS_PLAN=" + shellEscapeSingleQuote(t.planFile) + " " + program
+	}
+
 	// Prepend task identity env vars for parallel wave execution.
 	if t.taskNumber > 0 {
 		program = fmt.Sprintf("KASMOS_TASK=%d KASMOS_WAVE=%d KASMOS_PEERS=%d %s",
@@ -432,6 +449,20 @@ func (t *TmuxSession) Close() error {
 	return errors.Join(errs...)
 }
 
+// ReleasePTY closes this process's attached PTY without killing the tmux
+// session, leaving the agent running for another process to restore.
+func (t *TmuxSession) ReleasePTY() error {
+	if t.ptmx == nil {
+		return nil
+	}
+	err := t.ptmx.Close()
+	t.ptmx = nil
+	if err != nil {
+		return fmt.Errorf("error closing PTY: %w", err)
+	}
+	return nil
+}
+
 func (t *TmuxSession) DoesSessionExist() bool {
 	// Using "-t name" does a prefix match, which is wrong. `-t=` does an exact match.
 	existsCmd := exec.Command("tmux", "has-session", fmt.Sprintf("-t=%s", t.sanitizedName))
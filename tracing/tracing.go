@@ -0,0 +1,503 @@
+// Package tracing exports plan lifecycles as OpenTelemetry traces over
+// OTLP/HTTP. It follows the audit event stream: every plan is one trace whose
+// root span runs from the plan's creation until it is done or cancelled, with
+// a child span for each lifecycle stage and wave, and a span per agent under
+// the wave (or stage) it worked in.
+//
+// Trace and root span IDs are derived from the project and plan file, so the
+// spans a plan collects across restarts, and across the TUI and the daemon,
+// land in the same trace. The root and stage spans are exported when they
+// end; their start times come from the audit log when the process that
+// started them has since exited.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/kastheco/kasmos/config/planstate"
+	"github.com/kastheco/kasmos/log"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// serviceName is the service.name resource attribute on every span.
+const serviceName = "kasmos"
+
+// tracesPath is where OTLP/HTTP collectors accept traces.
+const tracesPath = "/v1/traces"
+
+// queueSize bounds the events waiting for the tracer; Observe drops events
+// rather than block the caller when the queue is full.
+const queueSize = 1024
+
+// shutdownTimeout bounds the final flush to the collector on Close.
+const shutdownTimeout = 5 * time.Second
+
+// Span outcomes, recorded in the kasmos.outcome attribute.
+const (
+	outcomeComplete = "complete"
+	outcomeFailed   = "failed"
+	outcomeKilled   = "killed"
+	outcomeFinished = "finished"
+	// outcomeStopped marks spans cut short because their plan moved on
+	// (a new wave, a new stage) without an event ending them.
+	outcomeStopped = "stopped"
+)
+
+// Tracer turns audit events into spans. Observe is safe for concurrent use;
+// events are handled in order on a single worker goroutine.
+type Tracer struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+	// history answers start-time lookups for spans begun before a restart.
+	// May be nil.
+	history auditlog.Logger
+
+	events    chan auditlog.Event
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// plans is owned by the worker goroutine.
+	plans map[string]*planSpans
+}
+
+// planSpans is the open state of one plan's trace.
+type planSpans struct {
+	project, planFile string
+	// stage is the plan's current status and stageSince when it entered it;
+	// both are empty until the tracer sees a status change.
+	stage      string
+	stageSince time.Time
+	// created is when the plan was created, if seen.
+	created time.Time
+	// wave is the running wave; attempts counts the spans started per wave.
+	wave     *waveSpan
+	attempts map[int]int
+	// agents are the running agents' spans by instance title.
+	agents map[string]*agentSpan
+}
+
+type waveSpan struct {
+	number int
+	span   trace.Span
+}
+
+type agentSpan struct {
+	span       trace.Span
+	stage      string
+	wave, task int
+}
+
+// Attach wraps l so the events it records also feed a Tracer exporting to
+// cfg.Tracing. Without a configured endpoint l is returned as is, and a
+// tracer that cannot be built is logged and skipped, so a typo in
+// config.toml never stops kasmos from starting.
+func Attach(cfg *config.Config, l auditlog.Logger) auditlog.Logger {
+	if cfg.Tracing.Endpoint == "" && os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") == "" &&
+		os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT") == "" {
+		return l
+	}
+	t, err := New(cfg.Tracing, l)
+	if err != nil {
+		log.WarningLog.Printf("tracing: %v — traces disabled", err)
+		return l
+	}
+	return WrapLogger(l, t)
+}
+
+// New returns a Tracer exporting to the collector in cfg (or the one named
+// by the standard OTEL_EXPORTER_OTLP_* variables when cfg has no endpoint).
+// history is queried for span start times and may be nil.
+func New(cfg config.TracingConfig, history auditlog.Logger) (*Tracer, error) {
+	var opts []otlptracehttp.Option
+	if cfg.Endpoint != "" {
+		opts = append(opts, otlptracehttp.WithEndpointURL(tracesURL(cfg.Endpoint)))
+	}
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("create otlp exporter: %w", err)
+	}
+	return newTracer(history, sdktrace.WithBatcher(exporter)), nil
+}
+
+// newTracer starts a Tracer whose provider is built from opts.
+func newTracer(history auditlog.Logger, opts ...sdktrace.TracerProviderOption) *Tracer {
+	opts = append([]sdktrace.TracerProviderOption{
+		sdktrace.WithIDGenerator(planIDGenerator{}),
+		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	}, opts...)
+	provider := sdktrace.NewTracerProvider(opts...)
+	t := &Tracer{
+		provider: provider,
+		tracer:   provider.Tracer("github.com/kastheco/kasmos/tracing"),
+		history:  history,
+		events:   make(chan auditlog.Event, queueSize),
+		done:     make(chan struct{}),
+		plans:    make(map[string]*planSpans),
+	}
+	go t.run()
+	return t
+}
+
+// tracesURL appends the OTLP traces path to a collector base URL.
+func tracesURL(endpoint string) string {
+	endpoint = strings.TrimRight(endpoint, "/")
+	if strings.HasSuffix(endpoint, tracesPath) {
+		return endpoint
+	}
+	return endpoint + tracesPath
+}
+
+// Observe queues an audit event for the tracer. Events without a plan are
+// ignored.
+func (t *Tracer) Observe(e auditlog.Event) {
+	if e.PlanFile == "" {
+		return
+	}
+	select {
+	case t.events <- e:
+	default:
+		log.WarningLog.Printf("tracing: queue full, dropping %s event", e.Kind)
+	}
+}
+
+// Close handles the queued events, flushes finished spans to the collector
+// and stops the tracer. Spans still open are not exported.
+func (t *Tracer) Close() error {
+	t.closeOnce.Do(func() { close(t.events) })
+	<-t.done
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return t.provider.Shutdown(ctx)
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+	for e := range t.events {
+		t.handle(e)
+	}
+}
+
+func (t *Tracer) handle(e auditlog.Event) {
+	key := e.Project + "\x00" + e.PlanFile
+	p, ok := t.plans[key]
+	if !ok {
+		p = &planSpans{
+			project:  e.Project,
+			planFile: e.PlanFile,
+			attempts: make(map[int]int),
+			agents:   make(map[string]*agentSpan),
+		}
+		t.plans[key] = p
+	}
+
+	switch e.Kind {
+	case auditlog.EventPlanCreated:
+		p.created = e.Timestamp
+	case auditlog.EventPlanStatusChanged:
+		sc, ok := auditlog.ParseStatusChange(e.Detail)
+		if !ok {
+			return
+		}
+		t.statusChanged(p, sc, e.Timestamp)
+		if sc.To == string(planfsm.StatusDone) || sc.To == string(planfsm.StatusCancelled) {
+			delete(t.plans, key)
+		}
+	case auditlog.EventWaveStarted:
+		p.endWave(e.Timestamp, outcomeStopped, nil)
+		t.startWave(p, e.WaveNumber, e.Timestamp)
+	case auditlog.EventWaveCompleted:
+		// The "all waves complete" event carries no wave number and ends
+		// whichever wave is running.
+		if p.wave != nil && (e.WaveNumber == 0 || e.WaveNumber == p.wave.number) {
+			p.endWave(e.Timestamp, outcomeComplete, nil)
+		}
+	case auditlog.EventWaveFailed:
+		if p.wave != nil && p.wave.number == e.WaveNumber {
+			p.endWave(e.Timestamp, outcomeFailed, auditlog.ParseFailedTasks(e.Detail))
+		}
+	case auditlog.EventAgentSpawned:
+		t.startAgent(p, e)
+	case auditlog.EventAgentFinished:
+		p.endAgent(e.InstanceTitle, e.Timestamp, outcomeFinished)
+	case auditlog.EventAgentKilled:
+		p.endAgent(e.InstanceTitle, e.Timestamp, outcomeKilled)
+	}
+}
+
+// statusChanged exports the span of the stage the plan left and, once the
+// plan is done or cancelled, the plan's root span.
+func (t *Tracer) statusChanged(p *planSpans, sc auditlog.StatusChange, at time.Time) {
+	since := p.stageSince
+	if since.IsZero() || p.stage != sc.From {
+		since = t.lookupTime(p, auditlog.EventPlanStatusChanged, at)
+	}
+	if !since.IsZero() {
+		_, span := t.tracer.Start(p.context(), "stage "+sc.From,
+			trace.WithTimestamp(since),
+			trace.WithAttributes(
+				attribute.String("kasmos.project", p.project),
+				attribute.String("kasmos.plan", p.planFile),
+				attribute.String("kasmos.stage", sc.From),
+				attribute.String("kasmos.event", sc.Event),
+			))
+		span.End(trace.WithTimestamp(at))
+	}
+
+	for title, a := range p.agents {
+		if a.stage == sc.From {
+			a.end(at, outcomeStopped)
+			delete(p.agents, title)
+		}
+	}
+	if sc.From == string(planfsm.StatusImplementing) {
+		p.endWave(at, outcomeStopped, nil)
+	}
+	if p.created.IsZero() {
+		p.created = since
+	}
+	p.stage, p.stageSince = sc.To, at
+
+	if sc.To != string(planfsm.StatusDone) && sc.To != string(planfsm.StatusCancelled) {
+		return
+	}
+	p.endWave(at, outcomeStopped, nil)
+	for _, a := range p.agents {
+		a.end(at, outcomeStopped)
+	}
+	start := t.lookupTime(p, auditlog.EventPlanCreated, at)
+	if start.IsZero() || (!p.created.IsZero() && p.created.Before(start)) {
+		start = p.created
+	}
+	if start.IsZero() {
+		start = at
+	}
+	ctx := context.WithValue(context.Background(), rootIDsKey{}, rootIDs(p.project, p.planFile))
+	_, span := t.tracer.Start(ctx, "plan "+planstate.DisplayName(p.planFile),
+		trace.WithNewRoot(),
+		trace.WithTimestamp(start),
+		trace.WithAttributes(
+			attribute.String("kasmos.project", p.project),
+			attribute.String("kasmos.plan", p.planFile),
+			attribute.String("kasmos.outcome", sc.To),
+		))
+	span.End(trace.WithTimestamp(at))
+}
+
+// lookupTime returns when the latest kind event before at was recorded for
+// p's plan, or the zero time when the audit log has none.
+func (t *Tracer) lookupTime(p *planSpans, kind auditlog.EventKind, at time.Time) time.Time {
+	if t.history == nil {
+		return time.Time{}
+	}
+	events, err := t.history.Query(auditlog.QueryFilter{
+		Project:  p.project,
+		PlanFile: p.planFile,
+		Kinds:    []auditlog.EventKind{kind},
+		Before:   at,
+		Limit:    1,
+	})
+	if err != nil || len(events) == 0 {
+		return time.Time{}
+	}
+	return events[0].Timestamp
+}
+
+func (t *Tracer) startWave(p *planSpans, number int, at time.Time) {
+	p.attempts[number]++
+	_, span := t.tracer.Start(p.context(), fmt.Sprintf("wave %d", number),
+		trace.WithTimestamp(at),
+		trace.WithAttributes(
+			attribute.String("kasmos.project", p.project),
+			attribute.String("kasmos.plan", p.planFile),
+			attribute.Int("kasmos.wave.number", number),
+			attribute.Int("kasmos.wave.attempt", p.attempts[number]),
+		))
+	p.wave = &waveSpan{number: number, span: span}
+}
+
+func (t *Tracer) startAgent(p *planSpans, e auditlog.Event) {
+	if prev, ok := p.agents[e.InstanceTitle]; ok {
+		prev.end(e.Timestamp, outcomeStopped)
+	}
+	ctx := p.context()
+	name := e.AgentType
+	if name == "" {
+		name = "agent"
+	}
+	if e.WaveNumber > 0 && e.TaskNumber > 0 {
+		// Task retries run after their wave failed; give them a new wave span.
+		if p.wave == nil || p.wave.number != e.WaveNumber {
+			p.endWave(e.Timestamp, outcomeStopped, nil)
+			t.startWave(p, e.WaveNumber, e.Timestamp)
+		}
+		ctx = trace.ContextWithSpan(ctx, p.wave.span)
+		name = fmt.Sprintf("task %d", e.TaskNumber)
+	}
+	program, model := auditlog.SplitProgram(e.Program)
+	attrs := []attribute.KeyValue{
+		attribute.String("kasmos.project", p.project),
+		attribute.String("kasmos.plan", p.planFile),
+		attribute.String("kasmos.instance", e.InstanceTitle),
+		attribute.String("kasmos.agent.type", e.AgentType),
+		attribute.String("kasmos.agent.program", program),
+	}
+	if model != "" {
+		attrs = append(attrs, attribute.String("kasmos.agent.model", model))
+	}
+	if e.WaveNumber > 0 {
+		attrs = append(attrs, attribute.Int("kasmos.wave.number", e.WaveNumber))
+	}
+	if e.TaskNumber > 0 {
+		attrs = append(attrs, attribute.Int("kasmos.task.number", e.TaskNumber))
+	}
+	_, span := t.tracer.Start(ctx, name, trace.WithTimestamp(e.Timestamp), trace.WithAttributes(attrs...))
+	p.agents[e.InstanceTitle] = &agentSpan{span: span, stage: p.stage, wave: e.WaveNumber, task: e.TaskNumber}
+}
+
+// endWave ends the running wave and its task spans. Tasks listed in failed
+// end as failed, the rest with the wave's outcome.
+func (p *planSpans) endWave(at time.Time, outcome string, failed []int) {
+	w := p.wave
+	if w == nil {
+		return
+	}
+	p.wave = nil
+	for title, a := range p.agents {
+		if a.wave != w.number || a.task == 0 {
+			continue
+		}
+		taskOutcome := outcome
+		if outcome == outcomeFailed {
+			taskOutcome = outcomeComplete
+			for _, n := range failed {
+				if n == a.task {
+					taskOutcome = outcomeFailed
+				}
+			}
+		}
+		a.end(at, taskOutcome)
+		delete(p.agents, title)
+	}
+	w.span.SetAttributes(attribute.String("kasmos.outcome", outcome))
+	switch outcome {
+	case outcomeFailed:
+		w.span.SetStatus(codes.Error, fmt.Sprintf("%d task(s) failed", len(failed)))
+		w.span.SetAttributes(attribute.IntSlice("kasmos.wave.failed_tasks", failed))
+	case outcomeComplete:
+		w.span.SetStatus(codes.Ok, "")
+	}
+	w.span.End(trace.WithTimestamp(at))
+}
+
+func (p *planSpans) endAgent(title string, at time.Time, outcome string) {
+	if a, ok := p.agents[title]; ok {
+		a.end(at, outcome)
+		delete(p.agents, title)
+	}
+}
+
+func (a *agentSpan) end(at time.Time, outcome string) {
+	a.span.SetAttributes(attribute.String("kasmos.outcome", outcome))
+	if outcome == outcomeFailed {
+		a.span.SetStatus(codes.Error, "task failed")
+	}
+	a.span.End(trace.WithTimestamp(at))
+}
+
+// context returns a context whose parent is the plan's root span, which is
+// only exported when the plan ends.
+func (p *planSpans) context() context.Context {
+	ids := rootIDs(p.project, p.planFile)
+	return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    ids.traceID,
+		SpanID:     ids.spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+}
+
+// planIDs are the trace and root span IDs of a plan's trace.
+type planIDs struct {
+	traceID trace.TraceID
+	spanID  trace.SpanID
+}
+
+// rootIDsKey carries the planIDs a root span must use to planIDGenerator.
+type rootIDsKey struct{}
+
+// rootIDs derives a plan's trace and root span IDs from its project and file.
+func rootIDs(project, planFile string) planIDs {
+	sum := sha256.Sum256([]byte(project + "\x00" + planFile))
+	var ids planIDs
+	copy(ids.traceID[:], sum[:16])
+	copy(ids.spanID[:], sum[16:24])
+	return ids
+}
+
+// planIDGenerator gives plan root spans their derived IDs and every other
+// span random ones.
+type planIDGenerator struct{}
+
+func (planIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	if ids, ok := ctx.Value(rootIDsKey{}).(planIDs); ok {
+		return ids.traceID, ids.spanID
+	}
+	var ids planIDs
+	_, _ = rand.Read(ids.traceID[:])
+	_, _ = rand.Read(ids.spanID[:])
+	return ids.traceID, ids.spanID
+}
+
+func (planIDGenerator) NewSpanID(context.Context, trace.TraceID) trace.SpanID {
+	var id trace.SpanID
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+// tracedLogger records events in the wrapped Logger and feeds them to a
+// Tracer.
+type tracedLogger struct {
+	auditlog.Logger
+	tracer *Tracer
+}
+
+// WrapLogger returns a Logger that records events in l and also traces them
+// with t. Close stops the tracer before closing l, which it queries.
+func WrapLogger(l auditlog.Logger, t *Tracer) auditlog.Logger {
+	return &tracedLogger{Logger: l, tracer: t}
+}
+
+func (l *tracedLogger) Emit(e auditlog.Event) {
+	// Stamp the event here so the span and the audit log agree on its time.
+	if e.Timestamp.IsZero() {
+		e.Timestamp = time.Now()
+	}
+	l.Logger.Emit(e)
+	l.tracer.Observe(e)
+}
+
+func (l *tracedLogger) Close() error {
+	if err := l.tracer.Close(); err != nil {
+		log.WarningLog.Printf("tracing: flush on close: %v", err)
+	}
+	return l.Logger.Close()
+}
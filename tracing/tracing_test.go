@@ -0,0 +1,221 @@
+package tracing
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kastheco/kasmos/config"
+	"github.com/kastheco/kasmos/config/auditlog"
+	"github.com/kastheco/kasmos/config/planfsm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const testPlan = "2026-03-01-auth.md"
+
+// statusChange builds a plan_status_changed event for testPlan.
+func statusChange(at time.Time, event planfsm.Event, from, to planfsm.Status) auditlog.Event {
+	e := auditlog.Event{Kind: auditlog.EventPlanStatusChanged, Project: "kasmos", PlanFile: testPlan, Timestamp: at}
+	auditlog.WithStatusChange(auditlog.StatusChange{Event: string(event), From: string(from), To: string(to)})(&e)
+	return e
+}
+
+// spansByName indexes ended spans by name; spans ending later under a name
+// already taken are listed as name#2, name#3, ...
+func spansByName(spans tracetest.SpanStubs) map[string]tracetest.SpanStub {
+	byName := make(map[string]tracetest.SpanStub)
+	for _, s := range spans {
+		name := s.Name
+		for n := 2; ; n++ {
+			if _, dup := byName[name]; !dup {
+				break
+			}
+			name = s.Name + "#" + string(rune('0'+n))
+		}
+		byName[name] = s
+	}
+	return byName
+}
+
+func attr(s tracetest.SpanStub, key string) attribute.Value {
+	for _, kv := range s.Attributes {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracer_PlanLifecycle(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := newTracer(nil, sdktrace.WithSpanProcessor(recorder))
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	at := func(m int) time.Time { return base.Add(time.Duration(m) * time.Minute) }
+	task := func(m, wave, n int) auditlog.Event {
+		return auditlog.Event{Kind: auditlog.EventAgentSpawned, Project: "kasmos", PlanFile: testPlan,
+			InstanceTitle: "auth-W1-T" + string(rune('0'+n)), AgentType: "coder",
+			Program: "claude --model opus", WaveNumber: wave, TaskNumber: n, Timestamp: at(m)}
+	}
+	for _, e := range []auditlog.Event{
+		{Kind: auditlog.EventPlanCreated, Project: "kasmos", PlanFile: testPlan, Timestamp: at(0)},
+		statusChange(at(5), planfsm.ImplementStart, planfsm.StatusReady, planfsm.StatusImplementing),
+		{Kind: auditlog.EventWaveStarted, Project: "kasmos", PlanFile: testPlan, WaveNumber: 1, Timestamp: at(5)},
+		task(5, 1, 1),
+		task(5, 1, 2),
+		{Kind: auditlog.EventWaveFailed, Project: "kasmos", PlanFile: testPlan, WaveNumber: 1, Detail: "2", Timestamp: at(20)},
+		task(21, 1, 2), // retry
+		{Kind: auditlog.EventWaveCompleted, Project: "kasmos", PlanFile: testPlan, WaveNumber: 1, Timestamp: at(30)},
+		{Kind: auditlog.EventWaveCompleted, Project: "kasmos", PlanFile: testPlan, Timestamp: at(30)},
+		statusChange(at(30), planfsm.ImplementFinished, planfsm.StatusImplementing, planfsm.StatusReviewing),
+		{Kind: auditlog.EventAgentSpawned, Project: "kasmos", PlanFile: testPlan, InstanceTitle: "auth-review",
+			AgentType: "reviewer", Program: "codex", Timestamp: at(31)},
+		statusChange(at(40), planfsm.ReviewApproved, planfsm.StatusReviewing, planfsm.StatusDone),
+	} {
+		tracer.Observe(e)
+	}
+	require.NoError(t, tracer.Close())
+
+	spans := spansByName(tracetest.SpanStubsFromReadOnlySpans(recorder.Ended()))
+	require.Len(t, spans, 9, "plan, 2 stages, 2 wave attempts, 3 tasks, reviewer")
+
+	root := spans["plan auth"]
+	ids := rootIDs("kasmos", testPlan)
+	assert.Equal(t, ids.traceID, root.SpanContext.TraceID())
+	assert.Equal(t, ids.spanID, root.SpanContext.SpanID())
+	assert.False(t, root.Parent.IsValid())
+	assert.Equal(t, at(0), root.StartTime)
+	assert.Equal(t, at(40), root.EndTime)
+	assert.Equal(t, "done", attr(root, "kasmos.outcome").AsString())
+
+	for _, name := range []string{"stage implementing", "stage reviewing", "wave 1", "wave 1#2"} {
+		s, ok := spans[name]
+		require.True(t, ok, name)
+		assert.Equal(t, ids.traceID, s.SpanContext.TraceID(), name)
+		assert.Equal(t, ids.spanID, s.Parent.SpanID(), "%s is a child of the plan", name)
+	}
+	assert.Equal(t, at(5), spans["stage implementing"].StartTime)
+	assert.Equal(t, at(30), spans["stage implementing"].EndTime)
+
+	failedWave, retryWave := spans["wave 1"], spans["wave 1#2"]
+	assert.Equal(t, codes.Error, failedWave.Status.Code)
+	assert.Equal(t, int64(2), attr(retryWave, "kasmos.wave.attempt").AsInt64())
+	assert.Equal(t, codes.Ok, retryWave.Status.Code)
+
+	task1, task2, retry := spans["task 1"], spans["task 2"], spans["task 2#2"]
+	assert.Equal(t, failedWave.SpanContext.SpanID(), task1.Parent.SpanID())
+	assert.Equal(t, "complete", attr(task1, "kasmos.outcome").AsString())
+	assert.Equal(t, "failed", attr(task2, "kasmos.outcome").AsString())
+	assert.Equal(t, codes.Error, task2.Status.Code)
+	assert.Equal(t, retryWave.SpanContext.SpanID(), retry.Parent.SpanID())
+	assert.Equal(t, "complete", attr(retry, "kasmos.outcome").AsString())
+	assert.Equal(t, "claude", attr(task1, "kasmos.agent.program").AsString())
+	assert.Equal(t, "opus", attr(task1, "kasmos.agent.model").AsString())
+	assert.Equal(t, int64(2), attr(task2, "kasmos.task.number").AsInt64())
+
+	reviewer := spans["reviewer"]
+	assert.Equal(t, ids.spanID, reviewer.Parent.SpanID())
+	assert.Equal(t, "stopped", attr(reviewer, "kasmos.outcome").AsString(), "ended with its stage")
+	assert.Equal(t, at(40), reviewer.EndTime)
+}
+
+func TestTracer_StartTimesFromHistory(t *testing.T) {
+	history, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+	defer history.Close()
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	history.Emit(auditlog.Event{Kind: auditlog.EventPlanCreated, Project: "kasmos", PlanFile: testPlan, Timestamp: base})
+	history.Emit(statusChange(base.Add(time.Hour), planfsm.ImplementFinished, planfsm.StatusImplementing, planfsm.StatusReviewing))
+	done := statusChange(base.Add(3*time.Hour), planfsm.ReviewApproved, planfsm.StatusReviewing, planfsm.StatusDone)
+	history.Emit(done)
+
+	// A tracer started after a restart sees only the final transition.
+	recorder := tracetest.NewSpanRecorder()
+	tracer := newTracer(history, sdktrace.WithSpanProcessor(recorder))
+	tracer.Observe(done)
+	require.NoError(t, tracer.Close())
+
+	spans := spansByName(tracetest.SpanStubsFromReadOnlySpans(recorder.Ended()))
+	require.Len(t, spans, 2)
+	assert.Equal(t, base.Add(time.Hour), spans["stage reviewing"].StartTime)
+	assert.Equal(t, base, spans["plan auth"].StartTime)
+}
+
+func TestAttach_ExportsOverOTLPHTTP(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []*coltracepb.ExportTraceServiceRequest
+		headers  http.Header
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		req := &coltracepb.ExportTraceServiceRequest{}
+		require.NoError(t, proto.Unmarshal(body, req))
+		mu.Lock()
+		received = append(received, req)
+		headers = r.Header.Clone()
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	defer collector.Close()
+
+	cfg := &config.Config{Tracing: config.TracingConfig{
+		Endpoint: collector.URL,
+		Headers:  map[string]string{"x-api-key": "secret"},
+	}}
+	local, err := auditlog.NewSQLiteLogger(":memory:")
+	require.NoError(t, err)
+	logger := Attach(cfg, local)
+	require.IsType(t, &tracedLogger{}, logger)
+
+	base := time.Now().Add(-time.Hour)
+	logger.Emit(statusChange(base, planfsm.ImplementStart, planfsm.StatusReady, planfsm.StatusImplementing))
+	logger.Emit(auditlog.Event{Kind: auditlog.EventAgentSpawned, Project: "kasmos", PlanFile: testPlan,
+		InstanceTitle: "auth-coder", AgentType: "coder", Program: "opencode -m sonnet"})
+	logger.Emit(statusChange(time.Time{}, planfsm.ImplementFinished, planfsm.StatusImplementing, planfsm.StatusReviewing))
+	events, err := local.Query(auditlog.QueryFilter{})
+	require.NoError(t, err)
+	assert.Len(t, events, 3, "events still reach the audit log")
+	require.NoError(t, logger.Close(), "close flushes the exporter")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotEmpty(t, received)
+	assert.Equal(t, "secret", headers.Get("x-api-key"))
+	var names []string
+	for _, req := range received {
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				for _, s := range ss.Spans {
+					names = append(names, s.Name)
+				}
+			}
+		}
+	}
+	assert.ElementsMatch(t, []string{"stage implementing", "coder"}, names)
+}
+
+func TestAttach_DisabledWithoutEndpoint(t *testing.T) {
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	l := auditlog.NopLogger()
+	assert.Same(t, l, Attach(&config.Config{}, l))
+}
+
+func TestTracesURL(t *testing.T) {
+	assert.Equal(t, "http://localhost:4318/v1/traces", tracesURL("http://localhost:4318"))
+	assert.Equal(t, "http://localhost:4318/v1/traces", tracesURL("http://localhost:4318/"))
+	assert.Equal(t, "https://otel.example.com/v1/traces", tracesURL("https://otel.example.com/v1/traces"))
+}
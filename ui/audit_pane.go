@@ -201,16 +201,22 @@ func EventKindIcon(kind string) (icon string, color lipgloss.Color) {
 		return "⚡", ColorFoam
 	case "wave_failed":
 		return "⚡", ColorLove
+	case "wave_rolled_back":
+		return "↶", ColorGold
 	case "prompt_sent":
 		return "→", ColorFoam
 	case "git_push":
 		return "↑", ColorFoam
 	case "pr_created":
 		return "⎇", ColorIris
+	case "pr_merged":
+		return "⇒", ColorIris
 	case "permission_detected":
 		return "!", ColorGold
 	case "permission_answered":
 		return "✓", ColorGold
+	case "activity_archived":
+		return "≡", ColorMuted
 	case "session_started":
 		return "▶", ColorFoam
 	case "session_stopped":
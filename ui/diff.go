@@ -3,10 +3,12 @@ package ui
 import (
 	"fmt"
 	"github.com/kastheco/kasmos/session"
+	gitpkg "github.com/kastheco/kasmos/session/git"
 	"path/filepath"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/mattn/go-runewidth"
 )
@@ -16,6 +18,10 @@ var (
 	DeletionStyle = lipgloss.NewStyle().Foreground(ColorDiffDelete)
 	HunkStyle     = lipgloss.NewStyle().Foreground(ColorDiffHunk)
 
+	// Word-level highlights for the changed span of a paired -/+ line.
+	additionWordStyle = lipgloss.NewStyle().Foreground(ColorBase).Background(ColorDiffAdd)
+	deletionWordStyle = lipgloss.NewStyle().Foreground(ColorBase).Background(ColorDiffDelete)
+
 	fileItemStyle = lipgloss.NewStyle().
 			Foreground(ColorIris)
 	fileItemSelectedStyle = lipgloss.NewStyle().
@@ -35,6 +41,8 @@ var (
 			Bold(true)
 	diffHintStyle = lipgloss.NewStyle().
 			Foreground(ColorMuted)
+	diffGutterStyle = lipgloss.NewStyle().
+			Foreground(ColorOverlay)
 )
 
 // fileChunk holds a single file's parsed diff data.
@@ -53,19 +61,48 @@ type DiffPane struct {
 	files        []fileChunk
 	totalAdded   int
 	totalRemoved int
-	fullDiff     string
+	// allDiff is the raw diff of every visible file; fullDiff holds an error
+	// message when there is nothing to show.
+	allDiff  string
+	fullDiff string
 
 	// selectedFile: -1 = all files, 0..N = specific file
 	selectedFile int
 
 	// sidebarWidth is computed from file names
 	sidebarWidth int
+
+	// sideBySide renders old and new lines in two columns.
+	sideBySide bool
+
+	// worktreeDiff is the diff of the worktree against the base, including
+	// untracked files; commits are the branch's commits, oldest first.
+	worktreeDiff string
+	commits      []gitpkg.CommitDiff
+	// The commit log is loaded on demand by the commit and task views, in the
+	// background: commitsLoaded is set once it has been, commitsHead is the
+	// HEAD it was loaded at and instance is where it is loaded from.
+	// loading is set while a load for loadingHead is in flight, and
+	// pendingStep/pendingFilter replay a key pressed before the log arrived.
+	commitsLoaded bool
+	commitsHead   string
+	loading       bool
+	loadingHead   string
+	pendingStep   int
+	pendingFilter bool
+	instance      *session.Instance
+	// commitIdx: -1 = worktree diff, 0..N = a single commit
+	commitIdx int
+
+	// taskFilter limits the view to files and commits of one task ("" = all).
+	taskFilter string
 }
 
 func NewDiffPane() *DiffPane {
 	return &DiffPane{
 		viewport:     viewport.New(0, 0),
 		selectedFile: 0,
+		commitIdx:    -1,
 	}
 }
 
@@ -74,7 +111,10 @@ func (d *DiffPane) SetSize(width, height int) {
 	d.height = height
 	d.computeSidebarWidth()
 	d.updateViewportWidth()
-	d.viewport.Height = height
+	d.viewport.Height = height - 1 // view header line
+	if d.viewport.Height < 1 {
+		d.viewport.Height = 1
+	}
 	d.rebuildViewport()
 }
 
@@ -111,27 +151,172 @@ func (d *DiffPane) computeSidebarWidth() {
 	d.sidebarWidth = innerMax + borderFrame
 }
 
-func (d *DiffPane) SetDiff(instance *session.Instance) {
+// CommitLogMsg carries a commit log loaded in the background for the diff
+// pane; hand it to TabbedWindow.SetDiffCommits.
+type CommitLogMsg struct {
+	instance *session.Instance
+	head     string
+	commits  []gitpkg.CommitDiff
+	err      error
+}
+
+// SetDiff shows instance's worktree diff. The returned command reloads the
+// commit log when the branch HEAD moved since it was loaded.
+func (d *DiffPane) SetDiff(instance *session.Instance) tea.Cmd {
 	if instance == nil || !instance.Started() {
-		d.files = nil
-		d.fullDiff = ""
-		return
+		d.clear("")
+		return nil
 	}
 
 	stats := instance.GetDiffStats()
 	if stats == nil || stats.Error != nil || stats.IsEmpty() {
-		d.files = nil
-		d.fullDiff = ""
+		msg := ""
 		if stats != nil && stats.Error != nil {
-			d.fullDiff = fmt.Sprintf("Error: %v", stats.Error)
+			msg = fmt.Sprintf("Error: %v", stats.Error)
 		}
+		d.clear(msg)
+		return nil
+	}
+
+	if instance != d.instance {
+		d.instance = instance
+		d.resetCommits()
+	}
+	d.worktreeDiff = stats.Content
+	var cmd tea.Cmd
+	if d.commitsLoaded && stats.Head != d.commitsHead {
+		cmd = d.loadCommits(stats.Head)
+	}
+	d.reload()
+	return cmd
+}
+
+// SetCommits applies a commit log loaded by loadCommits, then replays a
+// commit step or task filter key pressed while it was loading. Logs for
+// another instance or a superseded HEAD are dropped.
+func (d *DiffPane) SetCommits(msg CommitLogMsg) {
+	if !d.loading || msg.instance != d.instance || msg.head != d.loadingHead {
 		return
 	}
+	d.loading = false
+	d.loadingHead = ""
+	d.commitsLoaded = true
+	d.commitsHead = msg.head
+	d.commits = nil
+	// Best effort: without a log the worktree view still works.
+	if msg.err == nil {
+		d.commits = msg.commits
+	}
+	if d.commitIdx >= len(d.commits) {
+		d.commitIdx = -1
+	}
+	if d.taskFilter != "" && indexString(d.Tasks(), d.taskFilter) < 0 {
+		d.taskFilter = ""
+	}
 
-	d.totalAdded = stats.Added
-	d.totalRemoved = stats.Removed
-	d.files = parseFileChunks(stats.Content)
-	d.fullDiff = colorizeDiff(stats.Content)
+	step, filter := d.pendingStep, d.pendingFilter
+	d.pendingStep, d.pendingFilter = 0, false
+	switch {
+	case step != 0:
+		d.stepCommit(step)
+	case filter:
+		d.CycleTaskFilter()
+	default:
+		d.reload()
+	}
+}
+
+// clear drops all diff content, leaving msg (if any) in place of the diff.
+func (d *DiffPane) clear(msg string) {
+	d.files = nil
+	d.allDiff = ""
+	d.fullDiff = msg
+	d.worktreeDiff = ""
+	d.instance = nil
+	d.resetCommits()
+}
+
+// resetCommits drops the loaded commit log and leaves the commit and task
+// views.
+func (d *DiffPane) resetCommits() {
+	d.commits = nil
+	d.commitsLoaded = false
+	d.commitsHead = ""
+	d.loading = false
+	d.loadingHead = ""
+	d.pendingStep, d.pendingFilter = 0, false
+	d.commitIdx = -1
+	d.taskFilter = ""
+}
+
+// loadCommits returns a command that (re)loads the branch's commit log from
+// the instance into a CommitLogMsg. head is the HEAD it corresponds to, so it
+// is reloaded only once HEAD moves. Returns nil when that load is already in
+// flight.
+func (d *DiffPane) loadCommits(head string) tea.Cmd {
+	if d.instance == nil {
+		d.commitsLoaded = true
+		d.commitsHead = head
+		d.commits = nil
+		return nil
+	}
+	if d.loading && d.loadingHead == head {
+		return nil
+	}
+	d.loading = true
+	d.loadingHead = head
+	inst := d.instance
+	return func() tea.Msg {
+		commits, err := inst.CommitLog()
+		return CommitLogMsg{instance: inst, head: head, commits: commits, err: err}
+	}
+}
+
+// ensureCommits starts loading the commit log the first time a commit or
+// task view is opened. Returns nil once it is loaded.
+func (d *DiffPane) ensureCommits() tea.Cmd {
+	if d.commitsLoaded {
+		return nil
+	}
+	head := ""
+	if d.instance != nil {
+		if stats := d.instance.GetDiffStats(); stats != nil {
+			head = stats.Head
+		}
+	}
+	return d.loadCommits(head)
+}
+
+// reload re-parses the diff of the current view (worktree or commit) and
+// applies the task filter.
+func (d *DiffPane) reload() {
+	content := d.worktreeDiff
+	if d.commitIdx >= 0 {
+		content = d.commits[d.commitIdx].Content
+	}
+
+	files := parseFileChunks(content)
+	if d.taskFilter != "" && d.commitIdx < 0 {
+		paths := d.taskPaths(d.taskFilter)
+		kept := files[:0]
+		for _, f := range files {
+			if paths[f.path] {
+				kept = append(kept, f)
+			}
+		}
+		files = kept
+	}
+
+	d.files = files
+	d.fullDiff = ""
+	d.totalAdded, d.totalRemoved = 0, 0
+	var all strings.Builder
+	for _, f := range files {
+		d.totalAdded += f.added
+		d.totalRemoved += f.removed
+		all.WriteString(f.diff)
+	}
+	d.allDiff = all.String()
 
 	if d.selectedFile >= len(d.files) {
 		d.selectedFile = len(d.files) - 1
@@ -145,17 +330,150 @@ func (d *DiffPane) SetDiff(instance *session.Instance) {
 	d.rebuildViewport()
 }
 
+// Tasks returns the distinct Kasmos-Task trailer values of the branch's
+// commits, in commit order.
+func (d *DiffPane) Tasks() []string {
+	var tasks []string
+	for _, c := range d.commits {
+		if c.Task != "" && indexString(tasks, c.Task) < 0 {
+			tasks = append(tasks, c.Task)
+		}
+	}
+	return tasks
+}
+
+// taskPaths returns the set of paths touched by the commits of task.
+func (d *DiffPane) taskPaths(task string) map[string]bool {
+	paths := make(map[string]bool)
+	for _, c := range d.commits {
+		if c.Task != task {
+			continue
+		}
+		for _, f := range parseFileChunks(c.Content) {
+			paths[f.path] = true
+		}
+	}
+	return paths
+}
+
+// ToggleSideBySide switches between the unified and side-by-side layouts.
+func (d *DiffPane) ToggleSideBySide() {
+	d.sideBySide = !d.sideBySide
+	d.rebuildViewport()
+}
+
+// NextCommit steps to the next commit of the branch (matching the task
+// filter), wrapping back to the worktree diff after the last one. When the
+// commit log is not loaded yet, the step happens once it arrives and the
+// returned command loads it.
+func (d *DiffPane) NextCommit() tea.Cmd {
+	return d.stepCommit(1)
+}
+
+// PrevCommit steps to the previous commit, wrapping like NextCommit.
+func (d *DiffPane) PrevCommit() tea.Cmd {
+	return d.stepCommit(-1)
+}
+
+func (d *DiffPane) stepCommit(dir int) tea.Cmd {
+	if !d.commitsLoaded {
+		d.pendingStep, d.pendingFilter = dir, false
+		return d.ensureCommits()
+	}
+	if len(d.commits) == 0 {
+		return nil
+	}
+	// Positions run -1 (worktree) .. len-1; skip commits of other tasks.
+	n := len(d.commits) + 1
+	pos := d.commitIdx + 1
+	for range d.commits {
+		pos = ((pos+dir)%n + n) % n
+		idx := pos - 1
+		if idx < 0 || d.taskFilter == "" || d.commits[idx].Task == d.taskFilter {
+			d.commitIdx = idx
+			break
+		}
+	}
+	d.selectedFile = -1
+	d.reload()
+	d.viewport.GotoTop()
+	return nil
+}
+
+// CycleTaskFilter steps the task filter through every task with commits on
+// the branch, then back to showing all tasks. Like NextCommit it waits for
+// the commit log when it is not loaded yet.
+func (d *DiffPane) CycleTaskFilter() tea.Cmd {
+	if !d.commitsLoaded {
+		d.pendingStep, d.pendingFilter = 0, true
+		return d.ensureCommits()
+	}
+	tasks := d.Tasks()
+	if len(tasks) == 0 {
+		d.taskFilter = ""
+		return nil
+	}
+	next := ""
+	if i := indexString(tasks, d.taskFilter); i+1 < len(tasks) {
+		next = tasks[i+1]
+	}
+	d.taskFilter = next
+	if d.commitIdx >= 0 && next != "" && d.commits[d.commitIdx].Task != next {
+		d.commitIdx = -1
+	}
+	d.selectedFile = -1
+	d.reload()
+	d.viewport.GotoTop()
+	return nil
+}
+
 func (d *DiffPane) rebuildViewport() {
 	if len(d.files) == 0 {
 		return
 	}
 	var diff string
 	if d.selectedFile < 0 {
-		diff = d.fullDiff
+		diff = d.allDiff
 	} else if d.selectedFile < len(d.files) {
-		diff = colorizeDiff(d.files[d.selectedFile].diff)
+		diff = d.files[d.selectedFile].diff
+	}
+	if d.sideBySide {
+		d.viewport.SetContent(renderSideBySide(diff, d.viewport.Width))
+	} else {
+		d.viewport.SetContent(colorizeDiff(diff))
 	}
-	d.viewport.SetContent(diff)
+}
+
+// renderHeader describes the current view above the diff: worktree or the
+// selected commit, the task filter, and the view keys.
+func (d *DiffPane) renderHeader(width int) string {
+	view := "worktree"
+	if d.commitIdx >= 0 {
+		c := d.commits[d.commitIdx]
+		sha := c.SHA
+		if len(sha) > 7 {
+			sha = sha[:7]
+		}
+		view = fmt.Sprintf("commit %d/%d %s %s", d.commitIdx+1, len(d.commits), sha, c.Subject)
+		if c.Task != "" {
+			view += " · task " + c.Task
+		}
+	} else if len(d.commits) > 0 {
+		view = fmt.Sprintf("worktree · %d commit(s)", len(d.commits))
+	}
+	if d.taskFilter != "" {
+		view += " · filter: task " + d.taskFilter
+	}
+	hint := "| split  [ ] commits"
+	if !d.commitsLoaded || len(d.Tasks()) > 0 {
+		hint += "  f task"
+	}
+
+	gap := width - runewidth.StringWidth(view) - runewidth.StringWidth(hint)
+	if gap < 2 {
+		return diffHeaderStyle.Render(runewidth.Truncate(view, width, "…"))
+	}
+	return diffHeaderStyle.Render(view) + strings.Repeat(" ", gap) + diffHintStyle.Render(hint)
 }
 
 func (d *DiffPane) String() string {
@@ -164,11 +482,17 @@ func (d *DiffPane) String() string {
 		if d.fullDiff != "" {
 			msg = d.fullDiff
 		}
-		return lipgloss.Place(d.width, d.height, lipgloss.Center, lipgloss.Center, msg)
+		if len(d.commits) == 0 {
+			return lipgloss.Place(d.width, d.height, lipgloss.Center, lipgloss.Center, msg)
+		}
+		// Keep the header so an empty commit or task view can be stepped out of.
+		return lipgloss.JoinVertical(lipgloss.Left, d.renderHeader(d.width),
+			lipgloss.Place(d.width, d.height-1, lipgloss.Center, lipgloss.Center, msg))
 	}
 
 	sidebar := d.renderSidebar()
-	diffContent := d.viewport.View()
+	diffContent := lipgloss.JoinVertical(lipgloss.Left,
+		d.renderHeader(d.viewport.Width), d.viewport.View())
 
 	// Join sidebar and diff horizontally
 	return lipgloss.JoinHorizontal(lipgloss.Top, sidebar, " ", diffContent)
@@ -363,20 +687,194 @@ func parseFileChunks(content string) []fileChunk {
 func colorizeDiff(diff string) string {
 	var coloredOutput strings.Builder
 	lines := strings.Split(diff, "\n")
-	for _, line := range lines {
-		if len(line) > 0 {
-			if strings.HasPrefix(line, "@@") {
-				coloredOutput.WriteString(HunkStyle.Render(line) + "\n")
-			} else if line[0] == '+' && (len(line) == 1 || line[1] != '+') {
-				coloredOutput.WriteString(AdditionStyle.Render(line) + "\n")
-			} else if line[0] == '-' && (len(line) == 1 || line[1] != '-') {
-				coloredOutput.WriteString(DeletionStyle.Render(line) + "\n")
-			} else {
-				coloredOutput.WriteString(line + "\n")
-			}
-		} else {
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if len(line) == 0 {
 			coloredOutput.WriteString("\n")
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			coloredOutput.WriteString(HunkStyle.Render(line) + "\n")
+		case isDiffLine(line, '-'):
+			// Pair a run of removed lines with the added lines that follow
+			// it and highlight the changed words of each pair.
+			removed, added := diffRun(lines, i)
+			for j, old := range removed {
+				if j < len(added) {
+					oldSpan, _ := changedSpans(old[1:], added[j][1:])
+					coloredOutput.WriteString(renderSpan(old, oldSpan, DeletionStyle, deletionWordStyle) + "\n")
+				} else {
+					coloredOutput.WriteString(DeletionStyle.Render(old) + "\n")
+				}
+			}
+			for j, add := range added {
+				if j < len(removed) {
+					_, newSpan := changedSpans(removed[j][1:], add[1:])
+					coloredOutput.WriteString(renderSpan(add, newSpan, AdditionStyle, additionWordStyle) + "\n")
+				} else {
+					coloredOutput.WriteString(AdditionStyle.Render(add) + "\n")
+				}
+			}
+			i += len(removed) + len(added) - 1
+		case isDiffLine(line, '+'):
+			coloredOutput.WriteString(AdditionStyle.Render(line) + "\n")
+		default:
+			coloredOutput.WriteString(line + "\n")
 		}
 	}
 	return coloredOutput.String()
 }
+
+// isDiffLine reports whether line is a removed ('-') or added ('+') line,
+// excluding the ---/+++ file headers.
+func isDiffLine(line string, marker byte) bool {
+	return len(line) > 0 && line[0] == marker && (len(line) == 1 || line[1] != marker)
+}
+
+// diffRun returns the run of removed lines starting at lines[i] and the run
+// of added lines directly after it.
+func diffRun(lines []string, i int) (removed, added []string) {
+	for ; i < len(lines) && isDiffLine(lines[i], '-'); i++ {
+		removed = append(removed, lines[i])
+	}
+	for ; i < len(lines) && isDiffLine(lines[i], '+'); i++ {
+		added = append(added, lines[i])
+	}
+	return removed, added
+}
+
+// span is a byte range [start, end) of a line.
+type span struct{ start, end int }
+
+// changedSpans returns the changed part of oldLine and newLine: what is left
+// after stripping their common prefix and suffix, widened to whole words.
+// Both spans are empty when the lines are identical or share nothing.
+func changedSpans(oldLine, newLine string) (span, span) {
+	prefix := 0
+	for prefix < len(oldLine) && prefix < len(newLine) && oldLine[prefix] == newLine[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldLine)-prefix && suffix < len(newLine)-prefix &&
+		oldLine[len(oldLine)-1-suffix] == newLine[len(newLine)-1-suffix] {
+		suffix++
+	}
+	if prefix == 0 && suffix == 0 {
+		return span{}, span{}
+	}
+	// Widen to word boundaries so highlights never split a word.
+	if wordAt(oldLine, prefix) || wordAt(newLine, prefix) {
+		for prefix > 0 && isWordByte(oldLine[prefix-1]) {
+			prefix--
+		}
+	}
+	if wordAt(oldLine, len(oldLine)-suffix-1) || wordAt(newLine, len(newLine)-suffix-1) {
+		for suffix > 0 && isWordByte(oldLine[len(oldLine)-suffix]) {
+			suffix--
+		}
+	}
+	return span{prefix, len(oldLine) - suffix}, span{prefix, len(newLine) - suffix}
+}
+
+// wordAt reports whether s has a word byte at index i.
+func wordAt(s string, i int) bool {
+	return i >= 0 && i < len(s) && isWordByte(s[i])
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= 0x80
+}
+
+// renderSpan renders a diff line (marker included) with base, and the span of
+// its text (marker excluded) with strong.
+func renderSpan(line string, sp span, base, strong lipgloss.Style) string {
+	if sp.end <= sp.start {
+		return base.Render(line)
+	}
+	text := line[1:]
+	return base.Render(line[:1]+text[:sp.start]) + strong.Render(text[sp.start:sp.end]) + base.Render(text[sp.end:])
+}
+
+// renderSideBySide lays a unified diff out in two columns: removed lines on
+// the left, added lines on the right, context on both sides.
+func renderSideBySide(diff string, width int) string {
+	col := (width - 3) / 2
+	if col < 8 {
+		return colorizeDiff(diff)
+	}
+	gutter := diffGutterStyle.Render(" │ ")
+
+	var b strings.Builder
+	row := func(left, right string) {
+		b.WriteString(left + gutter + right + "\n")
+	}
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	inHunk := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			inHunk = false
+			b.WriteString(diffHeaderStyle.Render(runewidth.Truncate(line, width, "…")) + "\n")
+		case strings.HasPrefix(line, "@@"):
+			inHunk = true
+			b.WriteString(HunkStyle.Render(runewidth.Truncate(line, width, "…")) + "\n")
+		case !inHunk || strings.HasPrefix(line, "\\"):
+			// File headers (index, ---/+++, mode lines) and "no newline" notes.
+			b.WriteString(fileItemDimStyle.Render(runewidth.Truncate(line, width, "…")) + "\n")
+		case isDiffLine(line, '-') || isDiffLine(line, '+'):
+			removed, added := diffRun(lines, i)
+			for j := 0; j < len(removed) || j < len(added); j++ {
+				left, right := sideCell("", span{}, col, DeletionStyle, deletionWordStyle), sideCell("", span{}, col, AdditionStyle, additionWordStyle)
+				var oldSpan, newSpan span
+				if j < len(removed) && j < len(added) {
+					oldSpan, newSpan = changedSpans(expandTabs(removed[j][1:]), expandTabs(added[j][1:]))
+				}
+				if j < len(removed) {
+					left = sideCell(removed[j], oldSpan, col, DeletionStyle, deletionWordStyle)
+				}
+				if j < len(added) {
+					right = sideCell(added[j], newSpan, col, AdditionStyle, additionWordStyle)
+				}
+				row(left, right)
+			}
+			i += len(removed) + len(added) - 1
+		default:
+			ctx := sideCell(" "+strings.TrimPrefix(line, " "), span{}, col, lipgloss.NewStyle(), lipgloss.NewStyle())
+			row(ctx, ctx)
+		}
+	}
+	return b.String()
+}
+
+// sideCell renders one column of a side-by-side row, truncated and padded to
+// width. line carries its diff marker; an empty line renders blank.
+func sideCell(line string, sp span, width int, base, strong lipgloss.Style) string {
+	if line == "" {
+		return strings.Repeat(" ", width)
+	}
+	line = line[:1] + expandTabs(line[1:])
+	if runewidth.StringWidth(line) > width {
+		line = runewidth.Truncate(line, width, "…")
+		limit := len(line) - len("…") - 1
+		sp.start = min(sp.start, limit)
+		sp.end = min(sp.end, limit)
+	}
+	pad := width - runewidth.StringWidth(line)
+	return renderSpan(line, sp, base, strong) + strings.Repeat(" ", pad)
+}
+
+func expandTabs(s string) string {
+	return strings.ReplaceAll(s, "\t", "    ")
+}
+
+// indexString returns the index of s in list, or -1.
+func indexString(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kastheco/kasmos/session"
+	gitpkg "github.com/kastheco/kasmos/session/git"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedSpans(t *testing.T) {
+	oldLine, newLine := "return fooBar(x)", "return fooBaz(x)"
+	o, n := changedSpans(oldLine, newLine)
+	assert.Equal(t, "fooBar", oldLine[o.start:o.end], "widened to the whole word")
+	assert.Equal(t, "fooBaz", newLine[n.start:n.end])
+
+	oldLine, newLine = "a + b", "a - b"
+	o, n = changedSpans(oldLine, newLine)
+	assert.Equal(t, "+", oldLine[o.start:o.end])
+	assert.Equal(t, "-", newLine[n.start:n.end])
+
+	o, n = changedSpans("abc", "xyz")
+	assert.Equal(t, span{}, o, "nothing in common: no word highlight")
+	assert.Equal(t, span{}, n)
+}
+
+func TestRenderSideBySide(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1,3 +1,3 @@\n ctx\n-old line\n+new line\n+extra\n"
+	out := stripANSI(renderSideBySide(diff, 41))
+	lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+	require.Len(t, lines, 7)
+	assert.Equal(t, " ctx                │  ctx", strings.TrimRight(lines[4], " "))
+	assert.Equal(t, "-old line           │ +new line", strings.TrimRight(lines[5], " "))
+	assert.Equal(t, "                    │ +extra", strings.TrimRight(lines[6], " "))
+}
+
+func newTestDiffPane() *DiffPane {
+	d := NewDiffPane()
+	d.SetSize(100, 20)
+	d.worktreeDiff = "diff --git a/auth.go b/auth.go\n+a\ndiff --git a/db.go b/db.go\n+b\n+c\ndiff --git a/new.go b/new.go\n+d\n"
+	d.commits = []gitpkg.CommitDiff{
+		{SHA: "aaaaaaaa1", Subject: "add auth", Task: "1", Content: "diff --git a/auth.go b/auth.go\n+a\n"},
+		{SHA: "bbbbbbbb2", Subject: "add db", Task: "2", Content: "diff --git a/db.go b/db.go\n+b\n+c\n"},
+	}
+	d.commitsLoaded = true
+	d.reload()
+	return d
+}
+
+func TestDiffPane_StepCommits(t *testing.T) {
+	d := newTestDiffPane()
+	require.Len(t, d.files, 3, "worktree view lists every file, untracked included")
+
+	d.NextCommit()
+	assert.Equal(t, 0, d.commitIdx)
+	require.Len(t, d.files, 1)
+	assert.Equal(t, "auth.go", d.files[0].path)
+	assert.Contains(t, stripANSI(d.String()), "commit 1/2 aaaaaaa add auth · task 1")
+
+	d.NextCommit()
+	d.NextCommit()
+	assert.Equal(t, -1, d.commitIdx, "wraps back to the worktree diff")
+	d.PrevCommit()
+	assert.Equal(t, 1, d.commitIdx)
+}
+
+func TestDiffPane_TaskFilter(t *testing.T) {
+	d := newTestDiffPane()
+	assert.Equal(t, []string{"1", "2"}, d.Tasks())
+
+	d.CycleTaskFilter()
+	assert.Equal(t, "1", d.taskFilter)
+	require.Len(t, d.files, 1)
+	assert.Equal(t, "auth.go", d.files[0].path)
+	assert.Equal(t, 1, d.totalAdded)
+
+	// Commit stepping skips commits of other tasks.
+	d.NextCommit()
+	assert.Equal(t, 0, d.commitIdx)
+	d.NextCommit()
+	assert.Equal(t, -1, d.commitIdx)
+
+	d.CycleTaskFilter()
+	assert.Equal(t, "2", d.taskFilter)
+	d.CycleTaskFilter()
+	assert.Empty(t, d.taskFilter)
+	assert.Len(t, d.files, 3)
+}
+
+func TestDiffPane_CommitLogLoadsInBackground(t *testing.T) {
+	d := newTestDiffPane()
+	commits := d.commits
+	inst := &session.Instance{Title: "auth"}
+	d.instance = inst
+	d.resetCommits()
+
+	cmd := d.NextCommit()
+	require.NotNil(t, cmd, "the log is loaded by a command, not inline")
+	assert.Nil(t, d.NextCommit(), "a load already in flight is not started twice")
+	assert.Equal(t, -1, d.commitIdx, "the step waits for the log")
+
+	d.SetCommits(CommitLogMsg{instance: &session.Instance{}, commits: commits})
+	assert.False(t, d.commitsLoaded, "logs of another instance are dropped")
+
+	d.SetCommits(CommitLogMsg{instance: inst, commits: commits})
+	assert.True(t, d.commitsLoaded)
+	assert.Equal(t, 0, d.commitIdx, "the pending step is replayed")
+	assert.Contains(t, stripANSI(d.String()), "commit 1/2 aaaaaaa add auth")
+}
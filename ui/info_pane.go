@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	"github.com/charmbracelet/lipgloss"
@@ -35,6 +36,21 @@ type InfoData struct {
 	PlanTopic       string
 	PlanBranch      string
 	PlanCreated     string
+	// PlanStackedOn names the plan whose branch this plan is stacked on.
+	PlanStackedOn string
+	// PlanBase is the branch drift is measured against; empty when unknown.
+	PlanBase   string
+	PlanAhead  int
+	PlanBehind int
+	// PlanCollisions summarizes the active plans changing the same files.
+	PlanCollisions string
+	// PlanPR* describe the plan's pull request; PlanPRNumber is 0 when none
+	// is tracked. State, checks and review are empty until first polled.
+	PlanPRNumber int
+	PlanPRURL    string
+	PlanPRState  string
+	PlanPRChecks string
+	PlanPRReview string
 
 	// Plan summary fields (shown when plan header is selected, no instance).
 	PlanInstanceCount int
@@ -47,6 +63,10 @@ type InfoData struct {
 	// Resource fields (shown when instance is selected).
 	CPUPercent float64
 	MemMB      float64
+	CPUSeconds float64
+	// CPUHistory and MemHistory are the rolling usage samples, oldest first.
+	CPUHistory []float64
+	MemHistory []float64
 
 	// Wave fields (zero values = no wave).
 	AgentType  string
@@ -56,6 +76,12 @@ type InfoData struct {
 	TotalTasks int
 	WaveTasks  []WaveTaskInfo
 
+	// Activity is the recorded activity history, newest first. For plan headers
+	// it spans every instance of the plan and Instance is set on each entry.
+	Activity []ActivityEntry
+	// OverlapFiles lists files edited by more than one instance of the plan.
+	OverlapFiles []string
+
 	// HasPlan is true when the instance is bound to a plan.
 	HasPlan bool
 	// HasInstance is true when an instance is selected.
@@ -70,6 +96,18 @@ type WaveTaskInfo struct {
 	State  string // "complete", "running", "failed", "pending"
 }
 
+// ActivityEntry is a single row of the activity timeline.
+type ActivityEntry struct {
+	Time     string // pre-formatted timestamp, e.g. "14:03:22"
+	Action   string // "editing", "running", "reading", "searching"
+	Detail   string
+	Instance string // empty for single-instance timelines
+}
+
+// maxActivityRows caps how many timeline rows are rendered; older entries are
+// still persisted but rarely useful at a glance.
+const maxActivityRows = 100
+
 // InfoPane renders instance and plan metadata in the info tab.
 type InfoPane struct {
 	width, height int
@@ -92,11 +130,20 @@ func (p *InfoPane) SetSize(width, height int) {
 	p.viewport.SetContent(p.render())
 }
 
-// SetData updates the data to render.
+// SetData updates the data to render. The scroll position is kept when the
+// same instance or plan is re-rendered (e.g. on every metadata tick) so the
+// activity history can be scrolled; a new selection scrolls back to the top.
 func (p *InfoPane) SetData(data InfoData) {
+	sameSelection := data.Title == p.data.Title && data.PlanName == p.data.PlanName &&
+		data.IsPlanHeaderSelected == p.data.IsPlanHeaderSelected
 	p.data = data
+	offset := p.viewport.YOffset
 	p.viewport.SetContent(p.render())
-	p.viewport.GotoTop()
+	if sameSelection {
+		p.viewport.SetYOffset(offset)
+	} else {
+		p.viewport.GotoTop()
+	}
 }
 
 // ScrollUp scrolls the viewport up.
@@ -145,6 +192,21 @@ func (p *InfoPane) renderRow(label, value string) string {
 	)
 }
 
+// sparklineWidth is the widest usage history drawn next to a resource value.
+const sparklineWidth = 24
+
+// withSparkline appends a sparkline of history to value when there is room.
+func (p *InfoPane) withSparkline(value string, history []float64) string {
+	width := p.width - lipgloss.Width(infoLabelStyle.Render("memory")) - len(value) - 2
+	if width > sparklineWidth {
+		width = sparklineWidth
+	}
+	if len(history) < 2 || width < 4 {
+		return value
+	}
+	return value + "  " + Sparkline(history, width)
+}
+
 func (p *InfoPane) renderStatusRow(label, value string) string {
 	valueWidth := p.width - lipgloss.Width(infoLabelStyle.Render(label))
 	if valueWidth < 10 {
@@ -184,12 +246,51 @@ func (p *InfoPane) renderPlanSection() string {
 	if p.data.PlanBranch != "" {
 		lines = append(lines, p.renderRow("branch", p.data.PlanBranch))
 	}
+	if p.data.PlanStackedOn != "" {
+		lines = append(lines, p.renderRow("stacked on", p.data.PlanStackedOn))
+	}
 	if p.data.PlanCreated != "" {
 		lines = append(lines, p.renderRow("created", p.data.PlanCreated))
 	}
+	if p.data.PlanBase != "" {
+		lines = append(lines, p.renderRow("drift", p.driftSummary()))
+	}
+	if p.data.PlanCollisions != "" {
+		lines = append(lines, p.renderRow("collides", p.data.PlanCollisions))
+	}
+	if p.data.PlanPRNumber > 0 {
+		lines = append(lines, p.renderRow("pr", p.prSummary()))
+		if p.data.PlanPRURL != "" {
+			lines = append(lines, p.renderRow("pr url", p.data.PlanPRURL))
+		}
+	}
 	return strings.Join(lines, "\n")
 }
 
+// driftSummary describes how far the plan branch is from its base.
+func (p *InfoPane) driftSummary() string {
+	if p.data.PlanBehind == 0 {
+		return fmt.Sprintf("up to date with %s, %d ahead", p.data.PlanBase, p.data.PlanAhead)
+	}
+	return fmt.Sprintf("%d behind %s, %d ahead", p.data.PlanBehind, p.data.PlanBase, p.data.PlanAhead)
+}
+
+// prSummary describes the plan's pull request, e.g.
+// "#42 open, checks passing, changes requested".
+func (p *InfoPane) prSummary() string {
+	parts := []string{fmt.Sprintf("#%d", p.data.PlanPRNumber)}
+	if p.data.PlanPRState != "" {
+		parts[0] += " " + p.data.PlanPRState
+	}
+	if p.data.PlanPRChecks != "" {
+		parts = append(parts, "checks "+p.data.PlanPRChecks)
+	}
+	if p.data.PlanPRReview != "" {
+		parts = append(parts, strings.ReplaceAll(p.data.PlanPRReview, "_", " "))
+	}
+	return strings.Join(parts, ", ")
+}
+
 func (p *InfoPane) renderInstanceSection() string {
 	lines := []string{
 		infoSectionStyle.Render("instance"),
@@ -223,8 +324,11 @@ func (p *InfoPane) renderInstanceSection() string {
 		lines = append(lines, p.renderRow("task", fmt.Sprintf("%d of %d", p.data.TaskNumber, p.data.TotalTasks)))
 	}
 	if p.data.CPUPercent > 0 || p.data.MemMB > 0 {
-		lines = append(lines, p.renderRow("cpu", fmt.Sprintf("%.0f%%", math.Round(p.data.CPUPercent))))
-		lines = append(lines, p.renderRow("memory", fmt.Sprintf("%.0fM", p.data.MemMB)))
+		lines = append(lines, p.renderRow("cpu", p.withSparkline(fmt.Sprintf("%.0f%%", math.Round(p.data.CPUPercent)), p.data.CPUHistory)))
+		lines = append(lines, p.renderRow("memory", p.withSparkline(fmt.Sprintf("%.0fM", p.data.MemMB), p.data.MemHistory)))
+		if p.data.CPUSeconds > 0 {
+			lines = append(lines, p.renderRow("cpu time", (time.Duration(p.data.CPUSeconds)*time.Second).String()))
+		}
 	}
 	return strings.Join(lines, "\n")
 }
@@ -246,9 +350,24 @@ func (p *InfoPane) renderPlanSummary() string {
 	if p.data.PlanBranch != "" {
 		lines = append(lines, p.renderRow("branch", p.data.PlanBranch))
 	}
+	if p.data.PlanStackedOn != "" {
+		lines = append(lines, p.renderRow("stacked on", p.data.PlanStackedOn))
+	}
 	if p.data.PlanCreated != "" {
 		lines = append(lines, p.renderRow("created", p.data.PlanCreated))
 	}
+	if p.data.PlanBase != "" {
+		lines = append(lines, p.renderRow("drift", p.driftSummary()))
+	}
+	if p.data.PlanCollisions != "" {
+		lines = append(lines, p.renderRow("collides", p.data.PlanCollisions))
+	}
+	if p.data.PlanPRNumber > 0 {
+		lines = append(lines, p.renderRow("pr", p.prSummary()))
+		if p.data.PlanPRURL != "" {
+			lines = append(lines, p.renderRow("pr url", p.data.PlanPRURL))
+		}
+	}
 
 	if p.data.PlanInstanceCount > 0 {
 		summary := fmt.Sprintf("%d", p.data.PlanInstanceCount)
@@ -313,6 +432,51 @@ func (p *InfoPane) renderWaveSection() string {
 	return strings.Join(lines, "\n")
 }
 
+func activityColor(action string) lipgloss.TerminalColor {
+	switch action {
+	case "editing":
+		return ColorIris
+	case "running":
+		return ColorGold
+	case "reading", "searching":
+		return ColorFoam
+	default:
+		return ColorMuted
+	}
+}
+
+func (p *InfoPane) renderActivitySection() string {
+	lines := []string{
+		infoSectionStyle.Render("activity"),
+		p.renderDivider(),
+	}
+	if len(p.data.OverlapFiles) > 0 {
+		warn := lipgloss.NewStyle().Foreground(ColorLove)
+		lines = append(lines, warn.Render("⚠ files edited by multiple agents:"))
+		for _, f := range p.data.OverlapFiles {
+			lines = append(lines, warn.Render("  "+f))
+		}
+		lines = append(lines, "")
+	}
+	timeStyle := lipgloss.NewStyle().Foreground(ColorMuted)
+	rows := p.data.Activity
+	if len(rows) > maxActivityRows {
+		rows = rows[:maxActivityRows]
+	}
+	for _, e := range rows {
+		row := timeStyle.Render(e.Time) + " " +
+			lipgloss.NewStyle().Foreground(activityColor(e.Action)).Render(fmt.Sprintf("%-9s", e.Action))
+		if e.Detail != "" {
+			row += " " + infoValueStyle.Render(e.Detail)
+		}
+		if e.Instance != "" {
+			row += " " + timeStyle.Render("("+e.Instance+")")
+		}
+		lines = append(lines, row)
+	}
+	return strings.Join(lines, "\n")
+}
+
 // render builds the content string. Called internally when data changes.
 func (p *InfoPane) render() string {
 	if !p.data.HasInstance && !p.data.IsPlanHeaderSelected {
@@ -325,6 +489,9 @@ func (p *InfoPane) render() string {
 		if len(p.data.WaveTasks) > 0 {
 			sections = append(sections, p.renderWaveSection())
 		}
+		if len(p.data.Activity) > 0 {
+			sections = append(sections, p.renderActivitySection())
+		}
 	} else {
 		if p.data.HasPlan {
 			sections = append(sections, p.renderPlanSection())
@@ -333,6 +500,9 @@ func (p *InfoPane) render() string {
 		if len(p.data.WaveTasks) > 0 {
 			sections = append(sections, p.renderWaveSection())
 		}
+		if len(p.data.Activity) > 0 {
+			sections = append(sections, p.renderActivitySection())
+		}
 	}
 
 	return strings.Join(sections, "\n\n")
@@ -150,3 +150,77 @@ func TestInfoPane_InstanceWithResources(t *testing.T) {
 	assert.Contains(t, output, "13%")
 	assert.Contains(t, output, "340M")
 }
+
+func TestInfoPane_PlanDrift(t *testing.T) {
+	pane := NewInfoPane()
+	pane.SetSize(80, 30)
+	pane.SetData(InfoData{
+		IsPlanHeaderSelected: true,
+		PlanName:             "my-feature",
+		PlanBase:             "main",
+		PlanAhead:            4,
+		PlanBehind:           12,
+	})
+	assert.Contains(t, pane.String(), "12 behind main, 4 ahead")
+
+	pane.SetData(InfoData{IsPlanHeaderSelected: true, PlanName: "my-feature", PlanBase: "main", PlanAhead: 2})
+	assert.Contains(t, pane.String(), "up to date with main, 2 ahead")
+}
+
+func TestInfoPane_PlanPR(t *testing.T) {
+	pane := NewInfoPane()
+	pane.SetSize(100, 30)
+	pane.SetData(InfoData{
+		IsPlanHeaderSelected: true,
+		PlanName:             "my-feature",
+		PlanPRNumber:         42,
+		PlanPRURL:            "https://github.com/o/r/pull/42",
+		PlanPRState:          "open",
+		PlanPRChecks:         "failing",
+		PlanPRReview:         "changes_requested",
+	})
+	output := pane.String()
+	assert.Contains(t, output, "#42 open, checks failing, changes requested")
+	assert.Contains(t, output, "https://github.com/o/r/pull/42")
+}
+
+func TestInfoPane_ActivityTimeline(t *testing.T) {
+	pane := NewInfoPane()
+	pane.SetSize(80, 40)
+	pane.SetData(InfoData{
+		IsPlanHeaderSelected: true,
+		PlanName:             "my-feature",
+		Activity: []ActivityEntry{
+			{Time: "14:03:22", Action: "editing", Detail: "auth.go", Instance: "my-feature-W1-T2"},
+			{Time: "14:02:10", Action: "running", Detail: "go test ./..."},
+		},
+		OverlapFiles: []string{"internal/auth.go"},
+	})
+
+	output := pane.String()
+	assert.Contains(t, output, "activity")
+	assert.Contains(t, output, "14:03:22")
+	assert.Contains(t, output, "my-feature-W1-T2")
+	assert.Contains(t, output, "go test ./...")
+	assert.Contains(t, output, "internal/auth.go")
+}
+
+func TestInfoPane_KeepsScrollForSameSelection(t *testing.T) {
+	pane := NewInfoPane()
+	pane.SetSize(80, 5)
+	data := InfoData{HasInstance: true, Title: "coder", Status: "running"}
+	for i := 0; i < 20; i++ {
+		data.Activity = append(data.Activity, ActivityEntry{Time: "12:00:00", Action: "running", Detail: "step"})
+	}
+	pane.SetData(data)
+	pane.ScrollDown()
+	pane.ScrollDown()
+	scrolled := pane.String()
+
+	pane.SetData(data)
+	assert.Equal(t, scrolled, pane.String(), "re-rendering the same instance should keep the scroll offset")
+
+	data.Title = "other"
+	pane.SetData(data)
+	assert.NotEqual(t, scrolled, pane.String(), "a new selection should scroll back to the top")
+}
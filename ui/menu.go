@@ -203,7 +203,10 @@ func (m *Menu) addInstanceOptions() {
 		actionGroup = append(actionGroup, keys.KeyResume)
 	}
 
-	// Navigation group (when in diff tab): up/down navigate when diff is focused via Tab ring
+	// Diff view group (when in diff tab)
+	if m.isInDiffTab {
+		actionGroup = append(actionGroup, keys.KeyDiffSideBySide, keys.KeyDiffNextCommit, keys.KeyDiffTaskFilter)
+	}
 
 	// System group
 	systemGroup := []keys.KeyName{keys.KeySearch, keys.KeyTab, keys.KeyHelp, keys.KeyQuit}
@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/charmbracelet/bubbles/spinner"
@@ -259,12 +260,19 @@ func TestInspectPlan_MovesHistoryToDead(t *testing.T) {
 	assert.True(t, hasHistory, "plan-b should still be in history")
 }
 
-func TestRebuildRows_ClickUpAvailable(t *testing.T) {
+func TestRebuildRows_ImportSources(t *testing.T) {
 	n := newTestPanel()
-	n.SetClickUpAvailable(true)
+	n.SetImportSources([]string{"clickup"})
 	require.Len(t, n.rows, 1)
 	assert.Equal(t, navRowImportAction, n.rows[0].Kind)
-	assert.Equal(t, SidebarImportClickUp, n.rows[0].ID)
+	assert.Equal(t, SidebarImportIssue, n.rows[0].ID)
+	assert.Equal(t, "+ import from clickup", n.rows[0].Label)
+
+	n.SetImportSources([]string{"github", "jira"})
+	assert.Equal(t, "+ import issue", n.rows[0].Label)
+
+	n.SetImportSources(nil)
+	assert.Empty(t, n.rows)
 }
 
 // ---------- sort ordering ----------
@@ -663,6 +671,44 @@ func TestString_BasicOutput(t *testing.T) {
 	assert.Contains(t, output, "worker")
 }
 
+func TestString_PlanDriftBadge(t *testing.T) {
+	n := newTestPanel()
+	n.SetSize(60, 30)
+	plans := []PlanDisplay{{Filename: "stale-plan.md", Behind: 7}, {Filename: "fresh-plan.md"}}
+	n.SetData(plans, nil, nil, nil, nil)
+
+	output := n.String()
+	assert.Contains(t, output, "↓7")
+	assert.Equal(t, 1, strings.Count(output, "↓"), "up-to-date plans show no badge")
+}
+
+func TestString_PlanPRBadge(t *testing.T) {
+	n := newTestPanel()
+	n.SetSize(60, 30)
+	plans := []PlanDisplay{{Filename: "pr-plan.md", PRNumber: 42, PRChecks: "failing"}, {Filename: "no-pr-plan.md"}}
+	n.SetData(plans, nil, nil, nil, nil)
+
+	output := n.String()
+	assert.Contains(t, output, "#42")
+	assert.Equal(t, 1, strings.Count(output, "#"), "plans without a PR show no badge")
+}
+
+func TestString_PlanCollisionBadge(t *testing.T) {
+	n := newTestPanel()
+	n.SetSize(60, 30)
+	plans := []PlanDisplay{
+		{Filename: "auth.md", Collides: 2, Conflicts: true},
+		{Filename: "billing.md", Collides: 1},
+		{Filename: "docs.md"},
+	}
+	n.SetData(plans, nil, nil, nil, nil)
+
+	output := n.String()
+	assert.Contains(t, output, "⚠2")
+	assert.Contains(t, output, "⚠1")
+	assert.Equal(t, 2, strings.Count(output, "⚠"), "plans without collisions show no badge")
+}
+
 func TestString_EmptyPanel(t *testing.T) {
 	n := newTestPanel()
 	n.SetSize(60, 30)
@@ -927,3 +973,32 @@ func TestString_ImplementingPlanAppearsActive(t *testing.T) {
 	output := n.String()
 	assert.Contains(t, output, "active", "implementing plan should appear in active section")
 }
+
+func TestString_StackedPlans(t *testing.T) {
+	n := newTestPanel()
+	n.SetSize(60, 30)
+	plans := []PlanDisplay{
+		{Filename: "2026-03-01-api.md"},
+		{Filename: "2026-03-01-billing.md"},
+		{Filename: "2026-03-02-ui.md", Parent: "2026-03-01-api.md"},
+		{Filename: "2026-03-03-ui-polish.md", Parent: "2026-03-02-ui.md"},
+	}
+	n.SetData(plans, nil, nil, nil, nil)
+
+	output := n.String()
+	api := strings.Index(output, "api")
+	ui := strings.Index(output, "└ ▸ ui")
+	polish := strings.Index(output, "  └ ▸ ui-polish")
+	billing := strings.Index(output, "billing")
+	require.True(t, api >= 0 && ui >= 0 && polish >= 0 && billing >= 0, output)
+	assert.True(t, api < ui && ui < polish && polish < billing, "stacks render under their parent:\n%s", output)
+}
+
+func TestStackPlans_ParentElsewhere(t *testing.T) {
+	plans := []PlanDisplay{{Filename: "b.md", Parent: "not-listed.md"}, {Filename: "a.md", Parent: "b.md"}}
+	ordered, depth := stackPlans(plans)
+	require.Len(t, ordered, 2)
+	assert.Equal(t, "b.md", ordered[0].Filename)
+	assert.Equal(t, 0, depth["b.md"])
+	assert.Equal(t, 1, depth["a.md"])
+}
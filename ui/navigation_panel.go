@@ -18,7 +18,7 @@ const (
 	SidebarPlanPrefix        = "__plan__"
 	SidebarTopicPrefix       = "__topic__"
 	SidebarPlanHistoryToggle = "__plan_history_toggle__"
-	SidebarImportClickUp     = "__import_clickup__"
+	SidebarImportIssue       = "__import_issue__"
 )
 
 type PlanDisplay struct {
@@ -27,6 +27,18 @@ type PlanDisplay struct {
 	Description string
 	Branch      string
 	Topic       string
+	// Behind is how many commits the plan branch lacks from the default branch.
+	Behind int
+	// PRNumber is the plan's pull request, 0 when none is tracked. PRChecks
+	// is its CI summary: "passing", "failing", "pending" or empty.
+	PRNumber int
+	PRChecks string
+	// Parent is the filename of the plan this plan is stacked on, if any.
+	Parent string
+	// Collides is how many other active plans change the same files;
+	// Conflicts is set when any of them would not merge cleanly.
+	Collides  int
+	Conflicts bool
 }
 
 type TopicStatus struct {
@@ -55,6 +67,12 @@ type navRow struct {
 	Label           string
 	PlanFile        string
 	PlanStatus      string // plan lifecycle status (e.g. "implementing", "reviewing")
+	Behind          int    // commits the plan branch is behind the default branch
+	PRNumber        int    // plan pull request number, 0 if none
+	PRChecks        string // CI summary of the plan pull request
+	StackDepth      int    // how deep the plan sits in a stack shown above it
+	Collides        int    // other active plans changing the same files
+	Conflicts       bool   // a colliding plan would not merge cleanly
 	Instance        *session.Instance
 	Collapsed       bool
 	HasRunning      bool
@@ -78,6 +96,10 @@ var (
 	navIdleIconStyle      = lipgloss.NewStyle().Foreground(ColorMuted)
 	navCancelledLblStyle  = lipgloss.NewStyle().Foreground(ColorMuted).Strikethrough(true)
 	navImportStyle        = lipgloss.NewStyle().Foreground(ColorFoam).Padding(0, 1)
+	navDriftStyle         = lipgloss.NewStyle().Foreground(ColorGold)
+	navPRStyle            = lipgloss.NewStyle().Foreground(ColorIris)
+	navStackStyle         = lipgloss.NewStyle().Foreground(ColorMuted)
+	navConflictStyle      = lipgloss.NewStyle().Foreground(ColorLove)
 	navHistoryDivStyle    = lipgloss.NewStyle().Foreground(ColorMuted)
 	navLegendLabelStyle   = lipgloss.NewStyle().Foreground(ColorMuted)
 	navSearchBoxStyle     = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(ColorOverlay).Padding(0, 1)
@@ -107,7 +129,7 @@ type NavigationPanel struct {
 	historyExpanded bool
 	searchActive    bool
 	searchQuery     string
-	clickUpAvail    bool
+	importSources   []string
 
 	// Audit section rendered by the AuditPane and displayed inside the border.
 	auditView   string
@@ -303,7 +325,7 @@ func (n *NavigationPanel) rebuildRows() {
 
 	// Helper to append a plan header + its child instances.
 	// indent is the indentation level in spaces for the plan row.
-	appendPlan := func(p PlanDisplay, indent int) {
+	appendPlan := func(p PlanDisplay, indent, depth int) {
 		insts := instancesByPlan[p.Filename]
 		hasRunning, hasNotification := aggregateNavPlanStatus(insts, n.planStatuses[p.Filename])
 		collapsed := n.isPlanCollapsed(p.Filename, hasRunning, hasNotification)
@@ -313,6 +335,12 @@ func (n *NavigationPanel) rebuildRows() {
 			Label:           planstate.DisplayName(p.Filename),
 			PlanFile:        p.Filename,
 			PlanStatus:      p.Status,
+			Behind:          p.Behind,
+			PRNumber:        p.PRNumber,
+			PRChecks:        p.PRChecks,
+			StackDepth:      depth,
+			Collides:        p.Collides,
+			Conflicts:       p.Conflicts,
 			Collapsed:       collapsed,
 			HasRunning:      hasRunning,
 			HasNotification: hasNotification,
@@ -333,8 +361,12 @@ func (n *NavigationPanel) rebuildRows() {
 	}
 
 	// Import action pinned at the top of the list, below the search bar.
-	if n.clickUpAvail {
-		rows = append(rows, navRow{Kind: navRowImportAction, ID: SidebarImportClickUp, Label: "+ import from clickup"})
+	if len(n.importSources) > 0 {
+		label := "+ import issue"
+		if len(n.importSources) == 1 {
+			label = "+ import from " + n.importSources[0]
+		}
+		rows = append(rows, navRow{Kind: navRowImportAction, ID: SidebarImportIssue, Label: label})
 	}
 
 	// Dead section: done plans with instances or manually inspected.
@@ -343,7 +375,7 @@ func (n *NavigationPanel) rebuildRows() {
 		rows = append(rows, navRow{Kind: navRowDeadToggle, ID: "__dead_toggle__", Label: "dead", Collapsed: !n.deadExpanded})
 		if n.deadExpanded {
 			for _, p := range n.deadPlans {
-				appendPlan(p, 2)
+				appendPlan(p, 2, 0)
 			}
 		}
 	}
@@ -359,9 +391,10 @@ func (n *NavigationPanel) rebuildRows() {
 		}
 	}
 
-	// Emit active plans flat.
+	// Emit active plans flat, stacked plans right below their parent.
+	activePlans, activeDepth := stackPlans(activePlans)
 	for _, p := range activePlans {
-		appendPlan(p, 0)
+		appendPlan(p, 0, activeDepth[p.Filename])
 	}
 
 	// Solo instances between active and idle.
@@ -400,8 +433,9 @@ func (n *NavigationPanel) rebuildRows() {
 			Collapsed: collapsed,
 		})
 		if !collapsed {
+			topicIdlePlans, depth := stackPlans(topicIdlePlans)
 			for _, p := range topicIdlePlans {
-				appendPlan(p, 2)
+				appendPlan(p, 2, depth[p.Filename])
 				emitted[p.Filename] = true
 			}
 		} else {
@@ -412,11 +446,16 @@ func (n *NavigationPanel) rebuildRows() {
 	}
 
 	// Emit ungrouped idle plans (no topic).
+	var ungroupedIdle []PlanDisplay
 	for _, p := range idlePlans {
 		if !emitted[p.Filename] {
-			appendPlan(p, 0)
+			ungroupedIdle = append(ungroupedIdle, p)
 		}
 	}
+	ungroupedIdle, idleDepth := stackPlans(ungroupedIdle)
+	for _, p := range ungroupedIdle {
+		appendPlan(p, 0, idleDepth[p.Filename])
+	}
 
 	if len(n.historyPlans) > 0 {
 		rows = append(rows, navRow{Kind: navRowHistoryToggle, ID: SidebarPlanHistoryToggle, Label: "history", Collapsed: !n.historyExpanded})
@@ -551,9 +590,12 @@ func (n *NavigationPanel) SetAuditView(view string, h int) {
 	n.auditHeight = h
 }
 
-func (n *NavigationPanel) SetFocused(focused bool)    { n.focused = focused }
-func (n *NavigationPanel) IsFocused() bool            { return n.focused }
-func (n *NavigationPanel) SetClickUpAvailable(a bool) { n.clickUpAvail = a; n.rebuildRows() }
+func (n *NavigationPanel) SetFocused(focused bool) { n.focused = focused }
+func (n *NavigationPanel) IsFocused() bool         { return n.focused }
+
+// SetImportSources sets the issue trackers the import action searches; the
+// action is hidden when there are none.
+func (n *NavigationPanel) SetImportSources(names []string) { n.importSources = names; n.rebuildRows() }
 
 func (n *NavigationPanel) ActivateSearch()        { n.searchActive = true; n.searchQuery = "" }
 func (n *NavigationPanel) DeactivateSearch()      { n.searchActive = false; n.searchQuery = "" }
@@ -1045,6 +1087,70 @@ func navPlanStatusIcon(row navRow) string {
 	return navIdleIconStyle.Render("○")
 }
 
+// stackPlans orders plans so each stacked plan follows its parent, keeping
+// the existing order otherwise, and returns each plan's depth in its stack.
+// Plans whose parent is not in the list start a stack of their own.
+func stackPlans(plans []PlanDisplay) ([]PlanDisplay, map[string]int) {
+	present := make(map[string]bool, len(plans))
+	for _, p := range plans {
+		present[p.Filename] = true
+	}
+	children := make(map[string][]PlanDisplay)
+	var roots []PlanDisplay
+	for _, p := range plans {
+		if p.Parent != "" && p.Parent != p.Filename && present[p.Parent] {
+			children[p.Parent] = append(children[p.Parent], p)
+		} else {
+			roots = append(roots, p)
+		}
+	}
+	ordered := make([]PlanDisplay, 0, len(plans))
+	depth := make(map[string]int, len(plans))
+	var walk func(p PlanDisplay, d int)
+	walk = func(p PlanDisplay, d int) {
+		if _, seen := depth[p.Filename]; seen {
+			return
+		}
+		depth[p.Filename] = d
+		ordered = append(ordered, p)
+		for _, c := range children[p.Filename] {
+			walk(c, d+1)
+		}
+	}
+	for _, p := range roots {
+		walk(p, 0)
+	}
+	// Plans caught in a parent cycle have no root; keep them visible.
+	for _, p := range plans {
+		walk(p, 0)
+	}
+	return ordered, depth
+}
+
+// navPRBadge renders "#N" for a plan's pull request, colored by its CI checks.
+func navPRBadge(row navRow) string {
+	style := navPRStyle
+	switch row.PRChecks {
+	case "passing":
+		style = style.Foreground(ColorFoam)
+	case "failing":
+		style = style.Foreground(ColorLove)
+	case "pending":
+		style = style.Foreground(ColorGold)
+	}
+	return style.Render(fmt.Sprintf("#%d", row.PRNumber))
+}
+
+// navCollisionBadge renders "⚠N" for a plan sharing files with N other
+// active plans: red when one of them would conflict, gold otherwise.
+func navCollisionBadge(row navRow) string {
+	style := navDriftStyle
+	if row.Conflicts {
+		style = navConflictStyle
+	}
+	return style.Render(fmt.Sprintf("⚠%d", row.Collides))
+}
+
 // navSectionLabel returns a lowercase section label for a plan sort key.
 func navSectionLabel(key int) string {
 	switch key {
@@ -1079,9 +1185,22 @@ func (n *NavigationPanel) renderNavRow(row navRow, contentWidth int) string {
 			chevron = "▾"
 		}
 		statusIcon := navPlanStatusIcon(row)
+		if row.Behind > 0 {
+			statusIcon = navDriftStyle.Render(fmt.Sprintf("↓%d", row.Behind)) + " " + statusIcon
+		}
+		if row.PRNumber > 0 {
+			statusIcon = navPRBadge(row) + " " + statusIcon
+		}
+		if row.Collides > 0 {
+			statusIcon = navCollisionBadge(row) + " " + statusIcon
+		}
 		statusW := lipgloss.Width(statusIcon)
 		indent := strings.Repeat(" ", row.Indent)
 		indentW := row.Indent
+		if row.StackDepth > 0 {
+			indent += strings.Repeat("  ", row.StackDepth-1) + navStackStyle.Render("└") + " "
+			indentW += 2 * row.StackDepth
+		}
 
 		label := row.Label
 		// Layout: indent + chevron(1) + space(1) + label + gap + space(1) + status
@@ -18,6 +18,7 @@ const (
 	ToastSuccess
 	ToastError
 	ToastLoading
+	ToastWarning
 )
 
 // AnimPhase represents the current animation phase of a toast.
@@ -38,6 +39,7 @@ const (
 	InfoDismissAfter    = 3 * time.Second
 	SuccessDismissAfter = 3 * time.Second
 	ErrorDismissAfter   = 5 * time.Second
+	WarningDismissAfter = 5 * time.Second
 
 	MinToastWidth = 30
 	MaxToastWidth = 60
@@ -114,6 +116,11 @@ func (tm *ToastManager) Error(msg string) string {
 	return tm.addToast(ToastError, msg, ErrorDismissAfter)
 }
 
+// Warning creates a warning toast and returns its ID.
+func (tm *ToastManager) Warning(msg string) string {
+	return tm.addToast(ToastWarning, msg, WarningDismissAfter)
+}
+
 // Loading creates a loading toast with no auto-dismiss and returns its ID.
 func (tm *ToastManager) Loading(msg string) string {
 	return tm.addToast(ToastLoading, msg, 0)
@@ -264,6 +271,8 @@ func toastColor(typ ToastType) lipgloss.Color {
 		return colorLove
 	case ToastLoading:
 		return colorGold
+	case ToastWarning:
+		return colorGold
 	default:
 		// Defensive fallback for future ToastType values not yet handled;
 		// renders as an info-style toast rather than breaking the UI.
@@ -290,6 +299,8 @@ func (tm *ToastManager) toastIcon(typ ToastType) string {
 		return style.Render("✓")
 	case ToastError:
 		return style.Render("✗")
+	case ToastWarning:
+		return style.Render("!")
 	case ToastLoading:
 		return style.Render(tm.spinner.View())
 	default:
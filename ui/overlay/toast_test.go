@@ -48,6 +48,12 @@ func TestToastTypes(t *testing.T) {
 	assert.NotEmpty(t, loadingID, "Loading() must return a non-empty ID")
 	assert.Zero(t, tm.toasts[3].Duration, "Loading toasts should have zero duration (no auto-dismiss)")
 
+	warningID := tm.Warning("warning message")
+	require.Len(t, tm.toasts, 5)
+	assert.Equal(t, ToastWarning, tm.toasts[4].Type, "Warning() should create ToastWarning")
+	assert.Equal(t, WarningDismissAfter, tm.toasts[4].Duration)
+	assert.NotEmpty(t, warningID)
+
 	// Verify all IDs are unique.
 	ids := map[string]bool{infoID: true, successID: true, errorID: true, loadingID: true}
 	assert.Len(t, ids, 4, "all toast IDs should be unique")
@@ -0,0 +1,34 @@
+package ui
+
+import "strings"
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline renders the last width values as a row of block glyphs scaled to
+// the largest value shown. Returns "" when there is nothing to draw.
+func Sparkline(values []float64, width int) string {
+	if width <= 0 || len(values) == 0 {
+		return ""
+	}
+	if len(values) > width {
+		values = values[len(values)-width:]
+	}
+	var peak float64
+	for _, v := range values {
+		if v > peak {
+			peak = v
+		}
+	}
+	var b strings.Builder
+	for _, v := range values {
+		idx := 0
+		if peak > 0 && v > 0 {
+			idx = int(v / peak * float64(len(sparkBlocks)-1))
+			if idx >= len(sparkBlocks) {
+				idx = len(sparkBlocks) - 1
+			}
+		}
+		b.WriteRune(sparkBlocks[idx])
+	}
+	return b.String()
+}
@@ -0,0 +1,15 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "", Sparkline(nil, 10))
+	assert.Equal(t, "▁▄█", Sparkline([]float64{0, 50, 100}, 10))
+	assert.Equal(t, "▁▁", Sparkline([]float64{0, 0}, 10))
+	// Only the most recent width values are drawn.
+	assert.Equal(t, "▄█", Sparkline([]float64{100, 50, 100}, 2))
+}
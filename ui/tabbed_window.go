@@ -197,11 +197,38 @@ func (w *TabbedWindow) ViewportHandlesKey(msg tea.KeyMsg) bool {
 	return w.preview.ViewportHandlesKey(msg)
 }
 
-func (w *TabbedWindow) UpdateDiff(instance *session.Instance) {
+// UpdateDiff refreshes the diff tab for instance. The returned command, if
+// any, reloads the commit log in the background.
+func (w *TabbedWindow) UpdateDiff(instance *session.Instance) tea.Cmd {
 	if w.activeTab != DiffTab {
-		return
+		return nil
 	}
-	w.diff.SetDiff(instance)
+	return w.diff.SetDiff(instance)
+}
+
+// SetDiffCommits applies a commit log loaded in the background.
+func (w *TabbedWindow) SetDiffCommits(msg CommitLogMsg) {
+	w.diff.SetCommits(msg)
+}
+
+// ToggleDiffSideBySide switches the diff tab between unified and side-by-side.
+func (w *TabbedWindow) ToggleDiffSideBySide() {
+	w.diff.ToggleSideBySide()
+}
+
+// DiffNextCommit steps the diff tab to the branch's next commit.
+func (w *TabbedWindow) DiffNextCommit() tea.Cmd {
+	return w.diff.NextCommit()
+}
+
+// DiffPrevCommit steps the diff tab to the branch's previous commit.
+func (w *TabbedWindow) DiffPrevCommit() tea.Cmd {
+	return w.diff.PrevCommit()
+}
+
+// CycleDiffTaskFilter steps the diff tab's task filter.
+func (w *TabbedWindow) CycleDiffTaskFilter() tea.Cmd {
+	return w.diff.CycleTaskFilter()
 }
 
 // SetInfoData updates the info pane data.